@@ -93,7 +93,7 @@ func (a *App) printUsage() {
 	fmt.Fprintln(w, "            - db-user    alternatively, set environment variable SCS_DB_USERNAME")
 	fmt.Fprintln(w, "            - db-pass    alternatively, set environment variable SCS_DB_PASSWORD")
 	fmt.Fprintln(w, "            - db-name    alternatively, set environment variable SCS_DB_NAME")
-	fmt.Fprintln(w, "            - db-sslmode <disable|allow|prefer|require|verify-ca|verify-full>")
+	fmt.Fprintln(w, "            - db-sslmode <allow|prefer|require|verify-ca|verify-full>")
 	fmt.Fprintln(w, "                         alternatively, set environment variable SCS_DB_SSLMODE")
 	fmt.Fprintln(w, "            - db-sslcert path to where the certificate file of database. Only applicable")
 	fmt.Fprintln(w, "                         for db-sslmode=<verify-ca|verify-full. If left empty, the cert")
@@ -102,6 +102,13 @@ func (a *App) printUsage() {
 	fmt.Fprintln(w, "            - db-sslcertsrc <path to where the database ssl/tls certificate file>")
 	fmt.Fprintln(w, "                         mandatory if db-sslcert does not already exist")
 	fmt.Fprintln(w, "                         alternatively, set environment variable SCS_DB_SSLCERTSRC")
+	fmt.Fprintln(w, "            - db-schema  schema under which SCS tables are created, defaults to public schema")
+	fmt.Fprintln(w, "                         alternatively, set environment variable SCS_DB_SCHEMA")
+	fmt.Fprintln(w, "            - db-backend <postgres|sqlite> database platforms and collaterals are cached in, defaults to postgres.")
+	fmt.Fprintln(w, "                         sqlite needs SCS built with the sqlite tag and ignores the other database settings")
+	fmt.Fprintln(w, "                         alternatively, set environment variable SCS_DB_BACKEND")
+	fmt.Fprintln(w, "            - db-sqlite-path path of SQLite database file, defaults to /opt/scs/scs.db")
+	fmt.Fprintln(w, "                         alternatively, set environment variable SCS_DB_SQLITE_PATH")
 	fmt.Fprintln(w, "")
 	fmt.Fprintln(w, "    update_service_config    Updates Service Configuration")
 	fmt.Fprintln(w, "                             Required env variables:")
@@ -112,15 +119,72 @@ func (a *App) printUsage() {
 	fmt.Fprintln(w, "                                 - SCS_SERVER_IDLE_TIMEOUT                          : SGX Caching Service Request Idle Timeout")
 	fmt.Fprintln(w, "                                 - SCS_SERVER_MAX_HEADER_BYTES                      : SGX Caching Service Max Length Of Request Header Bytes")
 	fmt.Fprintln(w, "                                 - INTEL_PROVISIONING_SERVER                        : Intel ECDSA Provisioning Server URL")
-	fmt.Fprintln(w, "                                 - INTEL_PROVISIONING_SERVER_API_KEY                : Intel ECDSA Provisioning Server API Subscription key")
+	fmt.Fprintln(w, "                                 - INTEL_PROVISIONING_SERVER_CERTIFICATION_PATH     : Intel ECDSA Provisioning Server certification path, default /sgx/certification")
+	fmt.Fprintln(w, "                                 - INTEL_PROVISIONING_SERVER_API_VERSION            : Intel ECDSA Provisioning Server API version, default v3")
+	fmt.Fprintln(w, "                                 - INTEL_PROVISIONING_SERVER_TCB_UPDATE             : Intel ECDSA Provisioning Server update type of TcbInfo and QE identity, standard or early, default standard")
+	fmt.Fprintln(w, "                                 - INTEL_PROVISIONING_SERVER_API_KEY                : Intel ECDSA Provisioning Server API Subscription key, comma separated keys are rotated, deprecated in favour of INTEL_PROVISIONING_SERVER_API_KEY_SOURCE")
+	fmt.Fprintln(w, "                                 - INTEL_PROVISIONING_SERVER_API_KEY_SOURCE         : Source of Intel ECDSA Provisioning Server API Subscription keys loaded on start, file:<path> or env:<name>")
 	fmt.Fprintln(w, "                                 - SCS_LOGLEVEL                                     : SGX Caching Service Log Level")
 	fmt.Fprintln(w, "                                 - SCS_LOG_MAX_LENGTH                               : SGX Caching Service Log maximum length")
 	fmt.Fprintln(w, "                                 - SCS_ENABLE_CONSOLE_LOG                           : SGX Caching Service Enable standard output")
 	fmt.Fprintln(w, "                                 - SCS_REFRESH_HOURS                                : SCS Automatic Refresh of SGX Data")
 	fmt.Fprintln(w, "                                 - RETRY_COUNT                                      : Number of retry to PCS server")
 	fmt.Fprintln(w, "                                 - WAIT_TIME                                        : Duration Time between each retries to PCS")
+	fmt.Fprintln(w, "                                 - SCS_ACCEPTED_TCB_STATUSES                        : Comma separated TCB Statuses reported as UpToDate by tcbstatus API")
+	fmt.Fprintln(w, "                                 - SCS_MIN_TCB_COMP_SVNS                            : Comma separated minimum SGX TCB comp svns, from sgxtcbcomp01svn, below which tcbstatus API reports a platform as not UpToDate")
+	fmt.Fprintln(w, "                                 - SCS_MIN_PCE_SVN                                  : Minimum pcesvn below which tcbstatus API reports a platform as not UpToDate")
+	fmt.Fprintln(w, "                                 - SCS_ALLOW_UNKNOWN_PLATFORM_INFO_FIELDS           : Log and ignore unknown fields in platform info pushed by SGX Agent instead of rejecting it")
+	fmt.Fprintln(w, "                                 - SCS_CACHING_MODEL                                : lazy fetches TcbInfo, PCK CRL and QE identity when a platform is pushed, precache defers them to the next refresh, defaults to lazy")
+	fmt.Fprintln(w, "                                 - SCS_DUPLICATE_PLATFORM_PUSH_POLICY               : ignore or update a platform pushed again with a different enc_ppid, defaults to ignore")
+	fmt.Fprintln(w, "                                 - SCS_ENC_PPID_WITH_MANIFEST_POLICY                : manifest fetches PCK certs of a platform pushed with both enc_ppid and manifest using the manifest, reject fails such pushes, defaults to manifest")
+	fmt.Fprintln(w, "                                 - SCS_PCK_CERTS_NOT_AVAILABLE_POLICY               : reject fails pushes of platforms for which PCS has no PCK cert available with 404, retry fails them with 503 and a Retry-After header, defaults to reject")
+	fmt.Fprintln(w, "                                 - SCS_READS_DURING_REFRESH_POLICY                  : serve serves the cache on read APIs during a full refresh, unavailable fails them with 503 and a Retry-After header, defaults to serve")
+	fmt.Fprintln(w, "                                 - SCS_PREFETCH_COLLATERALS_ON_START                : Fetch QE identity and TCB info of cached platforms from PCS in background at startup")
+	fmt.Fprintln(w, "                                 - SCS_REFRESH_ALL_PCK_CRLS                         : Refresh PCK CRLs of both processor and platform CAs, also when no platform of a CA is cached yet")
+	fmt.Fprintln(w, "                                 - SCS_PCS_AUDIT_ENABLED                            : Retain raw Intel PCS server responses in database for audit")
+	fmt.Fprintln(w, "                                 - SCS_PCS_AUDIT_MAX_RECORDS                        : Max number of latest Intel PCS server responses retained for audit")
+	fmt.Fprintln(w, "                                 - SCS_ROUTE_TIMEOUTS                               : Comma separated route=duration timeouts of SCS apis, e.g. /refreshes=10s,/tcbstatus=5s, not exceeding SCS_SERVER_WRITE_TIMEOUT, routes without a timeout are bound only by the server timeouts")
+	fmt.Fprintln(w, "                                 - SCS_CORS_ALLOWED_ORIGINS                         : Comma separated origins, such as https://dashboard.example.com, allowed to call SCS APIs from browsers, CORS is disabled when not set")
+	fmt.Fprintln(w, "                                 - SCS_CORS_ALLOWED_METHODS                         : Comma separated methods of SCS APIs allowed from browsers, defaults to GET so that only read APIs are allowed")
+	fmt.Fprintln(w, "                                 - SCS_CORS_ALLOWED_HEADERS                         : Comma separated request headers allowed from browsers besides Authorization")
+	fmt.Fprintln(w, "                                 - SCS_CORS_ALLOW_CREDENTIALS                       : Allow browsers to send credentials such as cookies, cannot be used with * origin")
+	fmt.Fprintln(w, "                                 - SCS_PCS_REQUEST_TIMEOUT                          : Intel PCS client overall Request Timeout Duration including reading the response body")
+	fmt.Fprintln(w, "                                 - SCS_PCS_DIAL_TIMEOUT                             : Intel PCS client Dial Timeout Duration")
+	fmt.Fprintln(w, "                                 - SCS_PCS_TLS_HANDSHAKE_TIMEOUT                    : Intel PCS client TLS Handshake Timeout Duration")
+	fmt.Fprintln(w, "                                 - SCS_PCS_RESPONSE_HEADER_TIMEOUT                  : Intel PCS client Response Header Timeout Duration")
+	fmt.Fprintln(w, "                                 - SCS_PCS_IDLE_CONN_TIMEOUT                        : Intel PCS client Idle Connection Timeout Duration")
+	fmt.Fprintln(w, "                                 - SCS_PCS_MAX_IDLE_CONNS_PER_HOST                  : Max idle connections kept open to Intel PCS server for reuse")
+	fmt.Fprintln(w, "                                 - SCS_PCS_MAX_CONCURRENT_REQUESTS                  : Max requests to Intel PCS server in flight at once from refresh and lazy caching together")
+	fmt.Fprintln(w, "                                 - SCS_PLATFORM_MAX_AGE_DAYS                        : Purge platforms not pushed for more than the given days during refresh, 0 disables purging")
+	fmt.Fprintln(w, "                                 - SCS_PCK_CERT_EXPIRY_WARNING_DAYS                 : Days before its expiry from which the PCK cert of a platform is reported as expiring, default 30")
+	fmt.Fprintln(w, "                                 - SCS_REFRESH_BATCH_SIZE                           : Number of platforms whose PCK certs are refreshed in a batch, progress of refresh is saved after each batch")
+	fmt.Fprintln(w, "                                 - SCS_REFRESH_GRACE_ATTEMPTS                       : Number of times refresh of a failed collateral is attempted again within the same refresh, default 1")
+	fmt.Fprintln(w, "                                 - SCS_REFRESH_GRACE_DELAY_SECONDS                  : Delay in seconds before refresh of a failed collateral is attempted again, default 30")
+	fmt.Fprintln(w, "                                 - SCS_REFRESH_FAILURE_ALERT_THRESHOLD              : Consecutive refresh failures of a collateral after which an alert is raised, 0 disables alerts, default 3")
+	fmt.Fprintln(w, "                                 - SCS_REFRESH_FAILURE_ALERT_WEBHOOK                : URL to which refresh failure alerts are posted as JSON, optional")
+	fmt.Fprintln(w, "                                 - SCS_TIMER_REFRESH_PCK_CERTS                      : Refresh PCK certs on the refresh timer, false leaves them to the refresh API, default true")
+	fmt.Fprintln(w, "                                 - SCS_TIMER_REFRESH_COLLATERALS                    : Refresh PCK CRLs, TcbInfo and QE identity on the refresh timer, false leaves them to the refresh API, default true")
+	fmt.Fprintln(w, "                                 - SCS_CACHE_TCB_STATUS                             : Precompute tcb status of platforms on push and refresh, and serve it until the platform or its TcbInfo changes")
+	fmt.Fprintln(w, "                                 - SCS_NORMALIZE_TCB_INFO                           : Store TCB levels of TcbInfo parsed into the tcb_levels table when TcbInfo is cached")
+	fmt.Fprintln(w, "                                 - SCS_PCS_DEBUG_LOG_ENABLED                        : Log url and headers of every Intel PCS server request and response, with secrets redacted")
+	fmt.Fprintln(w, "                                 - SCS_TRACING_OTLP_ENDPOINT                        : OTLP/HTTP endpoint of an OpenTelemetry collector to which traces are exported, tracing is disabled when not set")
+	fmt.Fprintln(w, "                                 - SCS_SGX_ROOT_CA_FILE                             : PEM file of SGX Root CA certificate trusted for PCK certificate issuer chains returned by Intel PCS server, default Intel SGX Root CA")
+	fmt.Fprintln(w, "                                 - SCS_SIGNATURE_VERIFICATION_POLICY                : Collaterals from Intel PCS server failing signature verification are rejected (enforce), cached with a warning (warn) or not verified (off), default warn")
+	fmt.Fprintln(w, "                                 - SCS_WRITE_RATE_LIMIT                             : Requests per second allowed on mutating APIs from all clients, 0 disables the limit")
+	fmt.Fprintln(w, "                                 - SCS_WRITE_RATE_BURST                             : Burst of requests allowed on mutating APIs from all clients")
+	fmt.Fprintln(w, "                                 - SCS_WRITE_CLIENT_RATE_LIMIT                      : Requests per second allowed on mutating APIs from a client IP, 0 disables the limit")
+	fmt.Fprintln(w, "                                 - SCS_WRITE_CLIENT_RATE_BURST                      : Burst of requests allowed on mutating APIs from a client IP")
+	fmt.Fprintln(w, "                                 - SCS_TRUSTED_JWT_ISSUERS                          : Comma separated issuers of JWTs accepted on authorized APIs, any issuer is accepted when not set")
+	fmt.Fprintln(w, "                                 - SCS_TRUSTED_JWT_AUDIENCES                        : Comma separated audiences of JWTs accepted on authorized APIs, any audience is accepted when not set")
+	fmt.Fprintln(w, "                                 - SCS_HTTP2_ENABLED                                : Negotiate HTTP/2 with clients supporting it, default true")
+	fmt.Fprintln(w, "                                 - SCS_DEV_MODE                                     : Run SGX Caching Service in development mode")
+	fmt.Fprintln(w, "                                 - INTEL_PROVISIONING_SERVER_INSECURE_SKIP_VERIFY   : Skip TLS verification of Intel ECDSA Provisioning Server, INSECURE, allowed only with SCS_DEV_MODE")
 	fmt.Fprintln(w, "                                 - AAS_API_URL                                      : AAS API URL")
 	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "    diagnostics              Validates the saved configuration by checking database connection, Intel PCS server")
+	fmt.Fprintln(w, "                             connection and TCB info fetch from Intel PCS server. Not run as part of all")
+	fmt.Fprintln(w, "                             - Option [--fmspc=<fmspc>] FMSPC value used to fetch TCB info")
+	fmt.Fprintln(w, "")
 	fmt.Fprintln(w, "    download_ca_cert         Download CMS root CA certificate")
 	fmt.Fprintln(w, "                             - Option [--force] overwrites any existing files, and always downloads new root CA cert")
 	fmt.Fprintln(w, "                             Required env variables specific to setup task are:")
@@ -305,6 +369,7 @@ func (a *App) Run(args []string) error {
 			args[2] != "download_cert_tls" &&
 			args[2] != "database" &&
 			args[2] != "update_service_config" &&
+			args[2] != "diagnostics" &&
 			args[2] != "all" {
 			a.printUsage()
 			return errors.New("No such setup task")
@@ -369,6 +434,14 @@ func (a *App) Run(args []string) error {
 			},
 			AskInput: false,
 		}
+		// diagnostics needs a complete configuration and is therefore run only on request
+		if task == "diagnostics" {
+			setupRunner.Tasks = append(setupRunner.Tasks, tasks.Diagnostics{
+				Flags:         flags,
+				Config:        a.configuration(),
+				ConsoleWriter: os.Stdout,
+			})
+		}
 		if task == "all" {
 			err = setupRunner.RunTasks()
 		} else {
@@ -424,39 +497,94 @@ func (a *App) startServer() error {
 	log.Info("Starting SCS Server")
 
 	// Open database
-	scsDB, err := postgres.Open(c.Postgres.Hostname, c.Postgres.Port, c.Postgres.DBName,
-		c.Postgres.Username, c.Postgres.Password, c.Postgres.SSLMode, c.Postgres.SSLCert)
+	scsDB, err := postgres.OpenConfigured(c)
 	if err != nil {
-		log.WithError(err).Error("failed to open Postgres database")
+		log.WithError(err).Error("failed to open database")
 		return err
 	}
 	defer scsDB.Close()
 	log.Info("Migrating Database")
-	err = scsDB.Migrate()
+	err = resource.PrepareDatabase(scsDB)
 	if err != nil {
-		log.WithError(err).Error("Failed to migrate database")
+		log.WithError(err).Error("Refusing to start with a database which is not migrated")
+		return err
 	}
 
 	// create provision server client
-	pccsClient := domain.NewPCCSClient()
+	err = c.ValidateProvServerInfo()
+	if err != nil {
+		log.WithError(err).Error("Refusing to start with an invalid Intel PCS server url")
+		return err
+	}
+	err = c.ValidateProvServerTLS()
+	if err != nil {
+		log.WithError(err).Error("Refusing to start with TLS verification of Intel PCS server disabled")
+		return err
+	}
+	if c.ProvServerInsecureSkipVerify {
+		slog.Warn("TLS certificate verification of Intel PCS server is disabled in dev mode, this is INSECURE")
+	}
+	err = resource.SetSgxRootCA(c.SgxRootCAFile)
+	if err != nil {
+		log.WithError(err).Error("Refusing to start with an invalid SGX root CA file")
+		return err
+	}
+	pccsClient := domain.NewPCCSClient(c.ProvServerInsecureSkipVerify, c.ProvServerTransport)
+	resource.LimitPcsRequests(c.ProvServerTransport.MaxConcurrentRequests)
+
+	if c.PcsAuditEnabled {
+		maxRecords := c.PcsAuditMaxRecords
+		if maxRecords <= 0 {
+			maxRecords = constants.DefaultPcsAuditMaxRecords
+		}
+		resource.StartPcsAudit(scsDB, maxRecords)
+	}
+	if c.TracingOtlpEndpoint != "" {
+		resource.StartTracing(c.TracingOtlpEndpoint)
+	}
 
 	// Start Refresh routine
 	refreshTrigger := make(chan constants.RefreshTrigger)
 	go resource.RefreshPlatformInfo(scsDB, refreshTrigger, c, &pccsClient)
 
+	// Warm the cache in background, PCS being unreachable at boot is not fatal
+	if c.PrefetchCollateralsOnStart {
+		go func() {
+			if err := resource.PrefetchCollaterals(scsDB, c, &pccsClient); err != nil {
+				log.WithError(err).Warn("Prefetch of collaterals at startup failed, collaterals will be fetched on demand")
+			}
+		}()
+	}
+
 	// Start refresh timer
-	err = resource.InitAutoRefreshTimer(scsDB, refreshTrigger, a.configuration().RefreshHours)
+	err = resource.InitAutoRefreshTimer(scsDB, refreshTrigger, a.configuration().RefreshHours, a.configuration().TimerRefresh)
 	if err != nil {
 		log.WithError(err).Info("Refresh Timer init failed")
 		return err
 	}
 
+	// config.yml is read again on SIGHUP, without restarting the server or dropping connections
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			a.reloadConfiguration(c)
+		}
+	}()
+
 	r := mux.NewRouter()
 	r.SkipClean(true)
+	// spans of a request are started before authentication, so that rejected requests are traced too
+	r.Use(resource.TracingMiddleware)
+
+	routeTimeout := resource.NewRouteTimeout(c.RouteTimeouts)
+	refreshGuard := resource.NewRefreshGuard(c.ReadsDuringRefreshPolicy)
 
 	// Create Router, set routes
 	// no JWT token authentication for this url as its invoked by QPL lib
-	sr := r.PathPrefix("/scs/sgx/certification/v1").Subrouter()
+	sr := r.PathPrefix(constants.APIPathPrefix).Subrouter()
+	sr.Use(routeTimeout)
+	sr.Use(refreshGuard)
 	func(setters ...func(*mux.Router, repository.SCSDatabase, *config.Configuration, *domain.HttpClient)) {
 		for _, setter := range setters {
 			setter(sr, scsDB, c, &pccsClient)
@@ -464,15 +592,21 @@ func (a *App) startServer() error {
 	}(resource.QuoteProviderOps)
 
 	// Use token based auth for platform data push api
-	sr = r.PathPrefix("/scs/sgx/certification/v1").Subrouter()
+	sr = r.PathPrefix(constants.APIPathPrefix).Subrouter()
+	sr.Use(routeTimeout)
 	sr.Use(middleware.NewTokenAuth(constants.TrustedJWTSigningCertsDir,
 		constants.TrustedCAsStoreDir, fnGetJwtCerts,
 		time.Minute*constants.DefaultJwtValidateCacheKeyMins))
+	sr.Use(resource.NewTrustedTokenAuth(c.TrustedTokens))
+	sr.Use(refreshGuard)
+	// mutating apis trigger Intel PCS requests and database writes, so they are rate limited
+	sr.Use(resource.NewRateLimiter(c.WriteRateLimit).Middleware)
 	func(setters ...func(*mux.Router, repository.SCSDatabase, *config.Configuration, *domain.HttpClient)) {
 		for _, setter := range setters {
 			setter(sr, scsDB, c, &pccsClient)
 		}
-	}(resource.PlatformInfoOps)
+	}(resource.PlatformInfoOps, resource.CachePurgeOps, resource.CacheArchiveOps, resource.CacheStatsOps, resource.CollateralCheckOps,
+		resource.CollateralCacheOps, resource.SubscriptionKeyOps, resource.PckCertSelectionOps)
 
 	func(setters ...func(*mux.Router, repository.SCSDatabase, chan<- constants.RefreshTrigger)) {
 		for _, setter := range setters {
@@ -480,6 +614,17 @@ func (a *App) startServer() error {
 		}
 	}(resource.RefreshPlatformInfoOps)
 
+	// gRPC api is served on the same port over HTTP/2, its methods are all reads and so are neither
+	// rate limited nor bound by route timeouts, as gRPC clients set deadlines of their own
+	sr = r.PathPrefix(resource.GrpcPathPrefix).Subrouter()
+	resource.CollateralGrpcOps(sr, scsDB, c, &pccsClient)
+	sr = r.PathPrefix(resource.GrpcPathPrefix).Subrouter()
+	sr.Use(middleware.NewTokenAuth(constants.TrustedJWTSigningCertsDir,
+		constants.TrustedCAsStoreDir, fnGetJwtCerts,
+		time.Minute*constants.DefaultJwtValidateCacheKeyMins))
+	sr.Use(resource.NewTrustedTokenAuth(c.TrustedTokens))
+	resource.TcbStatusGrpcOps(sr, scsDB, c, &pccsClient)
+
 	tlsconfig := &tls.Config{
 		MinVersion: tls.VersionTLS13,
 		CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
@@ -491,10 +636,11 @@ func (a *App) startServer() error {
 	stop := make(chan os.Signal)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	httpLog := stdlog.New(a.httpLogWriter(), "", 0)
+	cors := resource.NewCORS(c.CORS, r)
 
 	h := &http.Server{
 		Addr:              fmt.Sprintf(":%d", c.Port),
-		Handler:           handlers.RecoveryHandler(handlers.RecoveryLogger(httpLog), handlers.PrintRecoveryStack(true))(handlers.CombinedLoggingHandler(a.httpLogWriter(), r)),
+		Handler:           handlers.RecoveryHandler(handlers.RecoveryLogger(httpLog), handlers.PrintRecoveryStack(true))(handlers.CombinedLoggingHandler(a.httpLogWriter(), cors(r))),
 		ErrorLog:          httpLog,
 		TLSConfig:         tlsconfig,
 		ReadTimeout:       c.ReadTimeout,
@@ -503,6 +649,7 @@ func (a *App) startServer() error {
 		IdleTimeout:       c.IdleTimeout,
 		MaxHeaderBytes:    c.MaxHeaderBytes,
 	}
+	c.ConfigureHTTP2(h)
 
 	// dispatch web server go routine
 	go func() {
@@ -526,10 +673,32 @@ func (a *App) startServer() error {
 		log.WithError(err).Info("Failed to gracefully shutdown webserver")
 		return err
 	}
+	// spans of the requests completed by the shutdown are still queued for export
+	if err := resource.StopTracing(ctx); err != nil {
+		log.WithError(err).Warn("Failed to export the traces of the last requests")
+	}
 	slog.Info(commLogMsg.ServiceStop)
 	return nil
 }
 
+// reloadConfiguration applies the settings of config.yml which can change while SCS is running, the
+// current configuration is kept when config.yml cannot be read or is invalid
+func (a *App) reloadConfiguration(c *config.Configuration) {
+	refreshHours := c.RefreshHours
+	if err := c.Reload(); err != nil {
+		log.WithError(err).Error("Could not reload configuration, keeping the current configuration")
+		return
+	}
+	log.Logger.SetLevel(c.LogLevel)
+	slog.Logger.SetLevel(c.LogLevel)
+	// resetting the refresh timer restarts the wait for the next refresh, so it is reset only when
+	// the interval changes
+	if c.RefreshHours != refreshHours {
+		resource.ResetAutoRefreshTimer(c.RefreshHours)
+	}
+	slog.Info("Configuration reloaded from config.yml")
+}
+
 func (a *App) start() error {
 	fmt.Fprintln(a.consoleWriter(), `Forwarding to "systemctl start scs"`)
 	systemctl, err := exec.LookPath("systemctl")
@@ -662,6 +831,11 @@ func validateSetupArgs(cmd string, args []string) error {
 			"SCS_DB_SSLMODE":    "db-sslmode",
 			"SCS_DB_SSLCERT":    "db-sslcert",
 			"SCS_DB_SSLCERTSRC": "db-sslcertsrc",
+			"SCS_DB_SCHEMA":     "db-schema",
+		}
+		// Postgres settings are not needed with the sqlite backend
+		if strings.TrimSpace(os.Getenv("SCS_DB_BACKEND")) == constants.DBBackendSqlite {
+			envNamesCmdOpts = map[string]string{"SCS_DB_SQLITE_PATH": "db-sqlite-path"}
 		}
 
 		fs = flag.NewFlagSet("database", flag.ContinueOnError)
@@ -673,6 +847,9 @@ func validateSetupArgs(cmd string, args []string) error {
 		fs.String("db-sslmode", "", "Database SSL Mode")
 		fs.String("db-sslcert", "", "Database SSL Cert Destination")
 		fs.String("db-sslcertsrc", "", "Database SSL Cert Source File")
+		fs.String("db-schema", "", "Database Schema")
+		fs.String("db-backend", "", "Database Backend")
+		fs.String("db-sqlite-path", "", "SQLite Database Path")
 
 		err := fs.Parse(args)
 		if err != nil {
@@ -683,6 +860,16 @@ func validateSetupArgs(cmd string, args []string) error {
 	case "update_service_config":
 		return nil
 
+	case "diagnostics":
+		fs = flag.NewFlagSet("diagnostics", flag.ContinueOnError)
+		fs.String("fmspc", "", "FMSPC value used to fetch TCB info")
+
+		err := fs.Parse(args)
+		if err != nil {
+			return fmt.Errorf("fail to parse arguments: %s", err.Error())
+		}
+		return nil
+
 	case "all":
 		if len(args) != 0 {
 			return errors.New("Please setup the arguments with env")
@@ -707,10 +894,9 @@ func (a *App) PrintDirFileContents(dir string) error {
 }
 
 func (a *App) DatabaseFactory() (repository.SCSDatabase, error) {
-	pg := &a.configuration().Postgres
-	p, err := postgres.Open(pg.Hostname, pg.Port, pg.DBName, pg.Username, pg.Password, pg.SSLMode, pg.SSLCert)
+	p, err := postgres.OpenConfigured(a.configuration())
 	if err != nil {
-		fmt.Println("failed to open postgres connection for setup task")
+		fmt.Println("failed to open database connection for setup task")
 		return nil, err
 	}
 	return p, nil
@@ -5,14 +5,19 @@
 package config
 
 import (
+	"crypto/tls"
 	"errors"
 	commLog "intel/isecl/lib/common/v5/log"
 	"intel/isecl/lib/common/v5/setup"
 	"intel/isecl/scs/v5/constants"
+	"io/ioutil"
+	"net/http"
 	"net/url"
 	"os"
 	"path"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	errorLog "github.com/pkg/errors"
@@ -20,6 +25,64 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// ProvServerTransport tunes connection handling of the Intel PCS client, zero values fall back to defaults
+type ProvServerTransport struct {
+	// RequestTimeout bounds a whole request to PCS including reading the response body, while
+	// ResponseHeaderTimeout bounds only the wait for response headers once the request is sent
+	RequestTimeout        time.Duration
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	IdleConnTimeout       time.Duration
+	MaxIdleConnsPerHost   int
+	// MaxConcurrentRequests caps the requests to PCS in flight at once across refresh and lazy caching
+	MaxConcurrentRequests int
+}
+
+// WriteRateLimit limits the mutating apis with token buckets, rates are in requests per second and
+// a limit is disabled when its rate is zero
+type WriteRateLimit struct {
+	// Rate and Burst limit the requests from all clients
+	Rate  float64
+	Burst int
+	// ClientRate and ClientBurst limit the requests from each client IP
+	ClientRate  float64
+	ClientBurst int
+}
+
+// RefreshFailureAlert escalates a collateral which has failed to refresh Threshold consecutive
+// times, with an error log and a POST of the alert to WebhookURL when it is set. Alerts are
+// disabled when Threshold is 0
+type RefreshFailureAlert struct {
+	Threshold  int
+	WebhookURL string
+}
+
+// TimerRefresh leaves the pck certs or the collaterals, PCK CRLs, TcbInfo and QE identity, out of the
+// refreshes triggered by the refresh timer, so that they are refreshed only through the refresh api.
+// Stale platforms are purged only by the full refresh the timer triggers when neither is skipped
+type TimerRefresh struct {
+	SkipPckCerts    bool
+	SkipCollaterals bool
+}
+
+// CORS lets browser based dashboards served from one of AllowedOrigins call the apis of AllowedMethods,
+// GET when none is set, sending AllowedHeaders along with the Authorization header of the bearer token.
+// CORS is disabled when AllowedOrigins is empty
+type CORS struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+}
+
+// TrustedTokens restricts the JWTs accepted on the authorized apis to the ones minted by one of
+// the Issuers for one of the Audiences, a claim is not checked when its list is empty
+type TrustedTokens struct {
+	Issuers   []string
+	Audiences []string
+}
+
 // Configuration is the global configuration struct that is marshalled/unmarshaled to a persisted yaml file
 // Probably should embed a config generic struct
 type Configuration struct {
@@ -34,7 +97,15 @@ type Configuration struct {
 		Port     int
 		SSLMode  string
 		SSLCert  string
+		// Schema under which SCS tables are created, public schema is used when empty
+		Schema string
 	}
+	// DBBackend is the database platforms and collaterals are cached in, postgres or sqlite. SQLite
+	// suits single node deployments without a Postgres server, Postgres settings are not used with it
+	DBBackend string
+	// SqlitePath is the file of the SQLite database
+	SqlitePath string
+
 	LogMaxLength    int
 	LogEnableStdout bool
 	LogLevel        log.Level
@@ -48,9 +119,27 @@ type Configuration struct {
 	RefreshHours   int
 
 	ProvServerInfo struct {
-		ProvServerURL      string
+		ProvServerURL string
+		// CertificationPath and APIVersion are joined to ProvServerURL to build the urls of PCS apis,
+		// ProvServerURL is the base url of the apis as is when both are empty
+		CertificationPath string
+		APIVersion        string
+		// TcbUpdate is the update type of TcbInfo and QE identity requested from PCS, see GetTcbUpdate
+		TcbUpdate          string
 		APISubscriptionkey string
+		// APISubscriptionkeys are rotated through for PCS requests when more than one key is configured
+		APISubscriptionkeys []string
+		// APISubscriptionkeySource references where the subscription keys are loaded from when SCS
+		// starts, file:<path> of a mounted secret or env:<name> of an environment variable. Keys loaded
+		// from it are not written to the configuration file
+		APISubscriptionkeySource string
 	}
+	// ProvServerInsecureSkipVerify disables TLS verification of Intel PCS server,
+	// honoured only when DevMode is enabled
+	ProvServerInsecureSkipVerify bool
+	DevMode                      bool
+	ProvServerTransport          ProvServerTransport
+
 	Subject struct {
 		TLSCertCommonName string
 		JWTCertCommonName string
@@ -63,8 +152,99 @@ type Configuration struct {
 	WriteTimeout      time.Duration
 	IdleTimeout       time.Duration
 	MaxHeaderBytes    int
+	// HTTP2Enabled negotiates HTTP/2 over TLS with the clients supporting it, so that many
+	// concurrent requests of a client are multiplexed on a connection. HTTP/2 is negotiated when
+	// it is not set, as by config files written before it was added, only false opts out of it
+	HTTP2Enabled *bool
+
+	// CachingModel is one of the caching models in constants, see GetCacheModel
+	CachingModel        int
+	AcceptedTcbStatuses []string
+	// MinTcbCompSvns and MinPceSvn are a TCB baseline of the operator, a platform whose tcb status is
+	// accepted is still reported as not UpToDate when sgxtcbcomp01svn onwards or the pcesvn of its
+	// tcb is lower than the minimum. The baseline is not enforced when both are unset
+	MinTcbCompSvns []int
+	MinPceSvn      int
+
+	AllowUnknownPlatformInfoFields bool
+	// DuplicatePlatformPushPolicy decides whether a platform pushed again with the same qeid and
+	// raw TCB level but a different enc_ppid is ignored or updated along with its pck certs
+	DuplicatePlatformPushPolicy string
+	// EncPpidWithManifestPolicy decides whether a platform pushed with both enc_ppid and manifest has
+	// its pck certs fetched from PCS using the manifest or is rejected
+	EncPpidWithManifestPolicy string
+	// PckCertsNotAvailablePolicy decides whether a platform pushed while PCS has no pck cert available
+	// for any of its TCB levels is rejected, or asked to be pushed again later
+	PckCertsNotAvailablePolicy string
+	// ReadsDuringRefreshPolicy decides whether the read apis serve the cache while a full refresh
+	// updates it, or fail with 503 so that consumers requiring consistent data retry after the refresh
+	ReadsDuringRefreshPolicy string
+	// PrefetchCollateralsOnStart fetches QE identity and TCB info of cached platforms in background at startup
+	PrefetchCollateralsOnStart bool
+	// RefreshAllPckCrls fetches the PCK CRLs of both processor and platform CAs on refresh, also when
+	// not cached yet, so that both are cached before the first platform of a CA is pushed
+	RefreshAllPckCrls bool
+
+	// PcsAuditEnabled retains raw responses of Intel PCS server, latest PcsAuditMaxRecords are kept
+	PcsAuditEnabled    bool
+	PcsAuditMaxRecords int
+
+	// PlatformMaxAgeDays purges the platforms not pushed for more than the given days during a full
+	// refresh, purging is disabled when it is 0
+	PlatformMaxAgeDays int
+
+	// PckCertExpiryWarningDays is the number of days before its expiry from which the selected pck cert
+	// of a platform is reported as expiring, see the expiring refresh type
+	PckCertExpiryWarningDays int
+
+	// RefreshBatchSize is the number of platforms whose pck certs are refreshed in a batch during
+	// refresh, progress of the refresh is saved after each batch
+	RefreshBatchSize int
+
+	RefreshFailureAlert RefreshFailureAlert
+
+	TimerRefresh TimerRefresh
+
+	// RefreshGraceAttempts is the number of times refresh of a collateral which failed is attempted
+	// again within the same refresh, RefreshGraceDelaySeconds apart, before it is recorded as failed
+	RefreshGraceAttempts     int
+	RefreshGraceDelaySeconds int
+
+	// CacheTcbStatus stores the tcb status of a platform when it is pushed or refreshed, so that
+	// it is served without evaluating TcbInfo until the platform or its TcbInfo changes
+	CacheTcbStatus bool
+
+	// NormalizeTcbInfo stores the TCB levels of TcbInfo parsed into the tcb_levels table when TcbInfo
+	// is cached, along with the raw TcbInfo which is still the one served. Tcb status of platforms is
+	// then evaluated from the stored TCB levels
+	NormalizeTcbInfo bool
+
+	// PcsDebugLogEnabled logs the url and headers of every Intel PCS request and response,
+	// with the subscription key and encrypted ppid redacted
+	PcsDebugLogEnabled bool
+
+	// TracingOtlpEndpoint is the OTLP/HTTP endpoint of an OpenTelemetry collector, such as
+	// http://otel-collector:4318, to which traces of the apis and refreshes are exported. Tracing
+	// is disabled when it is empty
+	TracingOtlpEndpoint string
+
+	// SgxRootCAFile is the PEM file of SGX root CA certificate which the PCK certificate issuer
+	// chains returned by Intel PCS server should end at, the Intel SGX Root CA is trusted when empty
+	SgxRootCAFile string
 
-	CachingModel int
+	// SignatureVerificationPolicy decides whether collaterals returned by Intel PCS server failing
+	// verification of their signature or issuer chain, TcbInfo, QE identity, PCK certificates and
+	// CRLs, are rejected (enforce), cached with a warning logged (warn) or not verified at all (off)
+	SignatureVerificationPolicy string
+
+	WriteRateLimit WriteRateLimit
+	TrustedTokens  TrustedTokens
+	CORS           CORS
+
+	// RouteTimeouts bound the time to serve the apis of a route, keyed by its path template after the
+	// api prefix, e.g. /refreshes. They do not exceed WriteTimeout, past which the response of a route
+	// cannot be written
+	RouteTimeouts map[string]time.Duration
 
 	WaitTime   int
 	RetryCount int
@@ -72,9 +252,18 @@ type Configuration struct {
 
 var global *Configuration
 
+// settingsLock guards the settings which can change while SCS is running, the subscription keys
+// which can be rotated and the settings applied by Reload
+var settingsLock sync.RWMutex
+
+var apiVersionRegex = regexp.MustCompile(`^v[0-9]+$`)
+
 func Global() *Configuration {
 	if global == nil {
 		global = Load(path.Join(constants.ConfigDir, constants.ConfigFile))
+		if err := global.LoadSubscriptionKeys(); err != nil {
+			log.WithError(err).Error("config/config:Global() Could not load Intel PCS api subscription keys")
+		}
 	}
 	return global
 }
@@ -85,7 +274,8 @@ func (conf *Configuration) Save() error {
 	if conf.configFile == "" {
 		return ErrNoConfigFile
 	}
-	file, err := os.OpenFile(conf.configFile, os.O_RDWR, 0)
+	// truncated, as rotated subscription keys can make the encoded configuration shorter
+	file, err := os.OpenFile(conf.configFile, os.O_RDWR|os.O_TRUNC, 0)
 	if err != nil {
 		// we have an error
 		if os.IsNotExist(err) {
@@ -106,7 +296,14 @@ func (conf *Configuration) Save() error {
 		}
 	}()
 
-	return yaml.NewEncoder(file).Encode(conf)
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	saved := *conf
+	if saved.ProvServerInfo.APISubscriptionkeySource != "" {
+		saved.ProvServerInfo.APISubscriptionkey = ""
+		saved.ProvServerInfo.APISubscriptionkeys = nil
+	}
+	return yaml.NewEncoder(file).Encode(&saved)
 }
 
 func (conf *Configuration) SaveConfiguration(taskName string, c setup.Context) error {
@@ -169,6 +366,253 @@ func (conf *Configuration) SaveConfiguration(taskName string, c setup.Context) e
 	return conf.Save()
 }
 
+// SubscriptionKeys returns the Intel PCS api subscription keys to be rotated through,
+// falling back to the single APISubscriptionkey
+func (conf *Configuration) SubscriptionKeys() []string {
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	if len(conf.ProvServerInfo.APISubscriptionkeys) > 0 {
+		return conf.ProvServerInfo.APISubscriptionkeys
+	}
+	return []string{conf.ProvServerInfo.APISubscriptionkey}
+}
+
+// SetSubscriptionKeys replaces the Intel PCS api subscription keys, PCS requests sent after it
+// returns use the new keys
+func (conf *Configuration) SetSubscriptionKeys(keys []string) {
+	settingsLock.Lock()
+	defer settingsLock.Unlock()
+	conf.ProvServerInfo.APISubscriptionkeys = keys
+	conf.ProvServerInfo.APISubscriptionkey = keys[0]
+}
+
+// SplitSubscriptionKeys returns the comma separated Intel PCS api subscription keys of value
+func SplitSubscriptionKeys(value string) []string {
+	var keys []string
+	for _, key := range strings.Split(value, ",") {
+		key = strings.TrimSpace(key)
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// ReadSecretReference reads a secret referenced as file:<path>, the absolute path of a file such
+// as a mounted secret, or as env:<name>, an environment variable
+func ReadSecretReference(reference string) (string, error) {
+	var secret string
+	switch {
+	case strings.HasPrefix(reference, constants.SecretFileReference):
+		secretFile := strings.TrimPrefix(reference, constants.SecretFileReference)
+		if !path.IsAbs(secretFile) {
+			return "", errorLog.Errorf("secret file %q should be an absolute path", secretFile)
+		}
+		content, err := ioutil.ReadFile(secretFile)
+		if err != nil {
+			return "", errorLog.Wrap(err, "could not read secret file")
+		}
+		secret = string(content)
+	case strings.HasPrefix(reference, constants.SecretEnvReference):
+		name := strings.TrimPrefix(reference, constants.SecretEnvReference)
+		secret = os.Getenv(name)
+		if secret == "" {
+			return "", errorLog.Errorf("secret environment variable %q is not set", name)
+		}
+	default:
+		return "", errorLog.Errorf("secret reference should be either %s<path> or %s<name>",
+			constants.SecretFileReference, constants.SecretEnvReference)
+	}
+	if secret = strings.TrimSpace(secret); secret == "" {
+		return "", errorLog.New("secret is empty")
+	}
+	return secret, nil
+}
+
+// LoadSubscriptionKeys loads the Intel PCS api subscription keys from APISubscriptionkeySource.
+// Keys stored in the configuration file are still used when no source is configured, but are
+// deprecated as they are stored in plaintext
+func (conf *Configuration) LoadSubscriptionKeys() error {
+	source := conf.ProvServerInfo.APISubscriptionkeySource
+	if source == "" {
+		for _, key := range conf.SubscriptionKeys() {
+			if key != "" {
+				log.Warn("config/config:LoadSubscriptionKeys() Intel PCS api subscription keys stored in plaintext in the " +
+					"configuration file are deprecated, configure INTEL_PROVISIONING_SERVER_API_KEY_SOURCE instead")
+				break
+			}
+		}
+		return nil
+	}
+
+	secret, err := ReadSecretReference(source)
+	if err != nil {
+		return errorLog.Wrap(err, "could not read subscription keys")
+	}
+	keys := SplitSubscriptionKeys(secret)
+	if len(keys) == 0 || len(keys) > constants.MaxSubscriptionKeys {
+		return errorLog.Errorf("between 1 and %d subscription keys should be provided", constants.MaxSubscriptionKeys)
+	}
+	conf.SetSubscriptionKeys(keys)
+	return nil
+}
+
+// ValidateProvServerTLS ensures that TLS verification of Intel PCS server can be
+// disabled only in dev mode
+func (conf *Configuration) ValidateProvServerTLS() error {
+	if conf.ProvServerInsecureSkipVerify && !conf.DevMode {
+		return errors.New("ProvServerInsecureSkipVerify is not allowed when DevMode is disabled")
+	}
+	return nil
+}
+
+// ConfigureHTTP2 restricts the server to HTTP/1.1 when HTTP/2 is disabled, the server negotiates
+// HTTP/2 by default otherwise. With HTTP/2, IdleTimeout applies to a connection while ReadTimeout
+// and WriteTimeout apply to each stream, so that the same timeouts hold for a request whichever
+// protocol is negotiated
+func (conf *Configuration) ConfigureHTTP2(h *http.Server) {
+	if conf.HTTP2Enabled == nil || *conf.HTTP2Enabled {
+		return
+	}
+	if h.TLSConfig == nil {
+		h.TLSConfig = &tls.Config{}
+	}
+	// a non nil TLSNextProto stops the server from configuring HTTP/2 by default
+	h.TLSConfig.NextProtos = []string{"http/1.1"}
+	h.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+}
+
+// GetCacheModel returns the caching model of the collaterals of pushed platforms, the lazy caching
+// model is returned when an unknown model is configured
+func (conf *Configuration) GetCacheModel() int {
+	if conf == nil || conf.CachingModel != constants.PreCachingModel {
+		return constants.LazyCachingModel
+	}
+	return constants.PreCachingModel
+}
+
+// GetTcbUpdate returns the update type of TcbInfo and QE identity requested from PCS. Early update
+// collaterals carry the TCB evaluation data number of an upcoming TCB recovery before it becomes
+// standard, standard is returned when no update type is configured
+func (conf *Configuration) GetTcbUpdate() string {
+	if conf == nil || conf.ProvServerInfo.TcbUpdate == "" {
+		return constants.StandardTcbUpdate
+	}
+	return conf.ProvServerInfo.TcbUpdate
+}
+
+// ProvServerAPIURL returns the url of an api of Intel PCS server or PCCS, e.g. pckcerts
+func (conf *Configuration) ProvServerAPIURL(api string) string {
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	apiURL := strings.TrimRight(conf.ProvServerInfo.ProvServerURL, "/")
+	for _, part := range []string{conf.ProvServerInfo.CertificationPath, conf.ProvServerInfo.APIVersion, api} {
+		if part = strings.Trim(part, "/"); part != "" {
+			apiURL += "/" + part
+		}
+	}
+	return apiURL
+}
+
+// PcsRetries returns the number of attempts of a failed Intel PCS request and the seconds between them
+func (conf *Configuration) PcsRetries() (int, int) {
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	return conf.RetryCount, conf.WaitTime
+}
+
+// Reload reads the configuration file again and applies the settings which can change while SCS is
+// running: Intel PCS server url, retries of PCS requests, refresh interval and log level. Other settings
+// changed in the file, such as the database connection, are left unchanged until SCS is restarted
+func (conf *Configuration) Reload() error {
+	file, err := os.Open(conf.configFile)
+	if err != nil {
+		return errorLog.Wrap(err, "could not open configuration file")
+	}
+	defer func() {
+		derr := file.Close()
+		if derr != nil {
+			log.WithError(derr).Error("Failed to close config.yml")
+		}
+	}()
+	var reloaded Configuration
+	if err := yaml.NewDecoder(file).Decode(&reloaded); err != nil {
+		return errorLog.Wrap(err, "could not decode configuration file")
+	}
+	// subscription keys are not reloaded, they are rotated with the subscription keys api
+	reloaded.SetSubscriptionKeys(conf.SubscriptionKeys())
+	if err := reloaded.ValidateProvServerInfo(); err != nil {
+		return err
+	}
+
+	settingsLock.Lock()
+	defer settingsLock.Unlock()
+	if reloaded.Port != conf.Port || reloaded.Postgres != conf.Postgres || reloaded.DBBackend != conf.DBBackend ||
+		reloaded.SqlitePath != conf.SqlitePath {
+		log.Warn("config/config:Reload() Changes to the port or database settings take effect when SCS is restarted")
+	}
+	// the Intel PCS client is built with its transport at startup
+	if reloaded.ProvServerTransport != conf.ProvServerTransport {
+		log.Warn("config/config:Reload() Changes to the Intel PCS client timeouts and connection settings take effect when SCS is restarted")
+	}
+	conf.ProvServerInfo.ProvServerURL = reloaded.ProvServerInfo.ProvServerURL
+	conf.ProvServerInfo.CertificationPath = reloaded.ProvServerInfo.CertificationPath
+	conf.ProvServerInfo.APIVersion = reloaded.ProvServerInfo.APIVersion
+	conf.RetryCount = reloaded.RetryCount
+	conf.WaitTime = reloaded.WaitTime
+	conf.RefreshHours = reloaded.RefreshHours
+	conf.LogLevel = reloaded.LogLevel
+	return nil
+}
+
+// SplitProvServerURL splits a url of Intel PCS server or PCCS ending with the api version, such as
+// https://api.trustedservices.intel.com/sgx/certification/v3, into the base url, the certification
+// path and the api version. A url which does not end with a version is returned as the base url
+func SplitProvServerURL(provServerURL string) (string, string, string) {
+	u, err := url.Parse(provServerURL)
+	if err != nil {
+		return provServerURL, "", ""
+	}
+	path := strings.TrimRight(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	if i < 0 || !apiVersionRegex.MatchString(path[i+1:]) {
+		return provServerURL, "", ""
+	}
+	certificationPath, apiVersion := path[:i], path[i+1:]
+	u.Path, u.RawPath = "", ""
+	return u.String(), certificationPath, apiVersion
+}
+
+// ValidateProvServerInfo ensures that Intel PCS server url is an absolute http or https url, so that
+// a misconfigured url is not first noticed when collaterals are fetched. A missing subscription key
+// for Intel PCS is only warned about, as the key is not needed by a PCCS deployed in its place
+func (conf *Configuration) ValidateProvServerInfo() error {
+	provServerURL, err := url.Parse(conf.ProvServerInfo.ProvServerURL)
+	if err != nil {
+		return errorLog.Wrap(err, "ProvServerURL is invalid")
+	}
+	if (provServerURL.Scheme != "http" && provServerURL.Scheme != "https") || provServerURL.Host == "" {
+		return errorLog.Errorf("ProvServerURL %q should be an absolute http or https url", conf.ProvServerInfo.ProvServerURL)
+	}
+	if conf.ProvServerInfo.APIVersion != "" && !apiVersionRegex.MatchString(conf.ProvServerInfo.APIVersion) {
+		return errorLog.Errorf("APIVersion %q should be of the form v<number>", conf.ProvServerInfo.APIVersion)
+	}
+	if update := conf.ProvServerInfo.TcbUpdate; update != "" && update != constants.StandardTcbUpdate && update != constants.EarlyTcbUpdate {
+		return errorLog.Errorf("TcbUpdate %q should be one of %s, %s", update, constants.StandardTcbUpdate, constants.EarlyTcbUpdate)
+	}
+
+	host := provServerURL.Hostname()
+	if host == constants.IntelPcsDomain || strings.HasSuffix(host, "."+constants.IntelPcsDomain) {
+		for _, key := range conf.SubscriptionKeys() {
+			if key != "" {
+				return nil
+			}
+		}
+		log.Warnf("config/config:ValidateProvServerInfo() No api subscription key is configured for Intel PCS server %s", host)
+	}
+	return nil
+}
+
 func Load(filePath string) *Configuration {
 	var c Configuration
 	file, _ := os.Open(filePath)
@@ -5,13 +5,28 @@
 package config
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"intel/isecl/lib/common/v5/setup"
+	"intel/isecl/scs/v5/constants"
 	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 )
 
 func TestLoad(t *testing.T) {
@@ -109,3 +124,273 @@ func TestGlobal(t *testing.T) {
 	conf := Global()
 	assert.NotNil(t, conf)
 }
+
+func TestValidateProvServerTLS(t *testing.T) {
+	c := Configuration{}
+	assert.NoError(t, c.ValidateProvServerTLS())
+
+	c.ProvServerInsecureSkipVerify = true
+	assert.Error(t, c.ValidateProvServerTLS())
+
+	c.DevMode = true
+	assert.NoError(t, c.ValidateProvServerTLS())
+}
+
+func TestValidateProvServerInfo(t *testing.T) {
+	c := Configuration{}
+	for _, provServerURL := range []string{"https://api.trustedservices.intel.com/sgx/certification/v3/",
+		"http://pccs.example.com:8081/sgx/certification/v3"} {
+		c.ProvServerInfo.ProvServerURL = provServerURL
+		assert.NoError(t, c.ValidateProvServerInfo())
+	}
+
+	for _, provServerURL := range []string{"", "abcdefg", "/sgx/certification/v3", "ftp://api.trustedservices.intel.com",
+		"https://", "https://api.trustedservices.intel.com:port/", "https://%zz"} {
+		c.ProvServerInfo.ProvServerURL = provServerURL
+		assert.Error(t, c.ValidateProvServerInfo(), provServerURL)
+	}
+
+	c.ProvServerInfo.ProvServerURL = "https://api.trustedservices.intel.com"
+	c.ProvServerInfo.APIVersion = "4"
+	assert.Error(t, c.ValidateProvServerInfo())
+}
+
+func TestReload(t *testing.T) {
+	temp, _ := ioutil.TempFile("", "config.yml")
+	defer os.Remove(temp.Name())
+	c := Load(temp.Name())
+	c.Port = 9000
+	c.RefreshHours = 24
+	c.RetryCount = 3
+	c.WaitTime = 1
+	c.LogLevel = logrus.InfoLevel
+	c.ProvServerInfo.ProvServerURL = "https://api.trustedservices.intel.com"
+	c.ProvServerInfo.APIVersion = "v3"
+	c.SetSubscriptionKeys([]string{"key1"})
+	assert.NoError(t, c.Save())
+
+	edited := Load(temp.Name())
+	edited.Port = 9001
+	edited.RefreshHours = 12
+	edited.RetryCount = 5
+	edited.WaitTime = 2
+	edited.LogLevel = logrus.DebugLevel
+	edited.ProvServerInfo.ProvServerURL = "http://pccs.example.com:8081"
+	edited.ProvServerInfo.APIVersion = "v4"
+	assert.NoError(t, edited.Save())
+
+	assert.NoError(t, c.Reload())
+	assert.Equal(t, "http://pccs.example.com:8081/v4/pckcerts", c.ProvServerAPIURL("pckcerts"))
+	retryCount, waitTime := c.PcsRetries()
+	assert.Equal(t, 5, retryCount)
+	assert.Equal(t, 2, waitTime)
+	assert.Equal(t, 12, c.RefreshHours)
+	assert.Equal(t, logrus.DebugLevel, c.LogLevel)
+	// port is used from the next start of SCS, and subscription keys are not reloaded
+	assert.Equal(t, 9000, c.Port)
+	assert.Equal(t, []string{"key1"}, c.SubscriptionKeys())
+
+	// PCS client timeouts are used from the next start of SCS, which is logged
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+	edited.ProvServerTransport.RequestTimeout = time.Minute
+	assert.NoError(t, edited.Save())
+	assert.NoError(t, c.Reload())
+	assert.Zero(t, c.ProvServerTransport.RequestTimeout)
+	if assert.NotNil(t, hook.LastEntry()) {
+		assert.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+		assert.Contains(t, hook.LastEntry().Message, "Intel PCS client timeouts")
+	}
+
+	// configuration is kept when the file is invalid
+	edited.ProvServerInfo.ProvServerURL = "pccs.example.com"
+	assert.NoError(t, edited.Save())
+	assert.Error(t, c.Reload())
+	assert.Equal(t, "http://pccs.example.com:8081/v4/pckcerts", c.ProvServerAPIURL("pckcerts"))
+
+	c.configFile = "/test/nofound.yml"
+	assert.Error(t, c.Reload())
+}
+
+func TestProvServerAPIURL(t *testing.T) {
+	c := Configuration{}
+	c.ProvServerInfo.ProvServerURL = "https://api.trustedservices.intel.com"
+	c.ProvServerInfo.CertificationPath = "/sgx/certification"
+	c.ProvServerInfo.APIVersion = "v3"
+	assert.Equal(t, "https://api.trustedservices.intel.com/sgx/certification/v3/pckcerts", c.ProvServerAPIURL("pckcerts"))
+	assert.Equal(t, "https://api.trustedservices.intel.com/sgx/certification/v3/qe/identity", c.ProvServerAPIURL("qe/identity"))
+
+	// local PCCS
+	c.ProvServerInfo.ProvServerURL = "https://localhost:8081/"
+	c.ProvServerInfo.CertificationPath = "sgx/certification/"
+	c.ProvServerInfo.APIVersion = "v4"
+	assert.Equal(t, "https://localhost:8081/sgx/certification/v4/tcb", c.ProvServerAPIURL("tcb"))
+	assert.Equal(t, "https://localhost:8081/sgx/certification/v4/pckcrl", c.ProvServerAPIURL("pckcrl"))
+
+	// url configured with the path and version
+	c.ProvServerInfo.ProvServerURL = "https://api.trustedservices.intel.com/sgx/certification/v3/"
+	c.ProvServerInfo.CertificationPath = ""
+	c.ProvServerInfo.APIVersion = ""
+	assert.Equal(t, "https://api.trustedservices.intel.com/sgx/certification/v3/pckcrl", c.ProvServerAPIURL("pckcrl"))
+}
+
+func TestSplitProvServerURL(t *testing.T) {
+	for provServerURL, expected := range map[string][3]string{
+		"https://api.trustedservices.intel.com/sgx/certification/v3/": {"https://api.trustedservices.intel.com", "/sgx/certification", "v3"},
+		"https://localhost:8081/sgx/certification/v4":                 {"https://localhost:8081", "/sgx/certification", "v4"},
+		"https://localhost:8081/v4":                                   {"https://localhost:8081", "", "v4"},
+		"https://localhost:8081":                                      {"https://localhost:8081", "", ""},
+		"http://localhost:8444/ips":                                   {"http://localhost:8444/ips", "", ""},
+	} {
+		baseURL, certificationPath, apiVersion := SplitProvServerURL(provServerURL)
+		assert.Equal(t, expected, [3]string{baseURL, certificationPath, apiVersion}, provServerURL)
+	}
+}
+
+func TestGetCacheModel(t *testing.T) {
+	var c *Configuration
+	assert.Equal(t, constants.LazyCachingModel, c.GetCacheModel())
+
+	c = &Configuration{}
+	assert.Equal(t, constants.LazyCachingModel, c.GetCacheModel())
+	c.CachingModel = constants.PreCachingModel
+	assert.Equal(t, constants.PreCachingModel, c.GetCacheModel())
+	c.CachingModel = 5
+	assert.Equal(t, constants.LazyCachingModel, c.GetCacheModel())
+}
+
+func TestGetTcbUpdate(t *testing.T) {
+	var c *Configuration
+	assert.Equal(t, constants.StandardTcbUpdate, c.GetTcbUpdate())
+
+	c = &Configuration{}
+	assert.Equal(t, constants.StandardTcbUpdate, c.GetTcbUpdate())
+	c.ProvServerInfo.TcbUpdate = constants.EarlyTcbUpdate
+	assert.Equal(t, constants.EarlyTcbUpdate, c.GetTcbUpdate())
+
+	c.ProvServerInfo.ProvServerURL = "https://api.trustedservices.intel.com"
+	assert.NoError(t, c.ValidateProvServerInfo())
+	c.ProvServerInfo.TcbUpdate = "latest"
+	assert.Error(t, c.ValidateProvServerInfo())
+}
+
+func TestSubscriptionKeys(t *testing.T) {
+	c := Configuration{}
+	c.ProvServerInfo.APISubscriptionkey = "key1"
+	assert.Equal(t, []string{"key1"}, c.SubscriptionKeys())
+
+	c.ProvServerInfo.APISubscriptionkeys = []string{"key1", "key2"}
+	assert.Equal(t, []string{"key1", "key2"}, c.SubscriptionKeys())
+
+	c.SetSubscriptionKeys([]string{"key3"})
+	assert.Equal(t, []string{"key3"}, c.SubscriptionKeys())
+	assert.Equal(t, "key3", c.ProvServerInfo.APISubscriptionkey)
+}
+
+func TestReadSecretReference(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "pcs-api-key")
+	assert.NoError(t, ioutil.WriteFile(secretFile, []byte("key1,key2\n"), 0600))
+	secret, err := ReadSecretReference(constants.SecretFileReference + secretFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "key1,key2", secret)
+
+	os.Setenv("SCS_TEST_PCS_API_KEY", "key3")
+	defer os.Unsetenv("SCS_TEST_PCS_API_KEY")
+	secret, err = ReadSecretReference(constants.SecretEnvReference + "SCS_TEST_PCS_API_KEY")
+	assert.NoError(t, err)
+	assert.Equal(t, "key3", secret)
+
+	emptyFile := filepath.Join(t.TempDir(), "empty")
+	assert.NoError(t, ioutil.WriteFile(emptyFile, []byte(" \n"), 0600))
+	for _, reference := range []string{
+		secretFile,
+		constants.SecretFileReference + "pcs-api-key",
+		constants.SecretFileReference + secretFile + ".missing",
+		constants.SecretFileReference + emptyFile,
+		constants.SecretEnvReference + "SCS_TEST_PCS_API_KEY_UNSET",
+	} {
+		_, err = ReadSecretReference(reference)
+		assert.Error(t, err, reference)
+	}
+}
+
+func TestLoadSubscriptionKeys(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "pcs-api-key")
+	assert.NoError(t, ioutil.WriteFile(secretFile, []byte("key1, key2"), 0600))
+
+	// plaintext keys are used as before when no source is configured
+	c := Load(filepath.Join(t.TempDir(), "config.yml"))
+	c.ProvServerInfo.APISubscriptionkey = "plainkey"
+	assert.NoError(t, c.LoadSubscriptionKeys())
+	assert.Equal(t, []string{"plainkey"}, c.SubscriptionKeys())
+
+	c.ProvServerInfo.APISubscriptionkeySource = constants.SecretFileReference + secretFile
+	assert.NoError(t, c.LoadSubscriptionKeys())
+	assert.Equal(t, []string{"key1", "key2"}, c.SubscriptionKeys())
+
+	os.Setenv("SCS_TEST_PCS_API_KEY", "key3")
+	defer os.Unsetenv("SCS_TEST_PCS_API_KEY")
+	c.ProvServerInfo.APISubscriptionkeySource = constants.SecretEnvReference + "SCS_TEST_PCS_API_KEY"
+	assert.NoError(t, c.LoadSubscriptionKeys())
+	assert.Equal(t, []string{"key3"}, c.SubscriptionKeys())
+
+	// keys loaded from the source are not written to the configuration file
+	assert.NoError(t, c.Save())
+	saved := Load(c.configFile)
+	assert.Equal(t, c.ProvServerInfo.APISubscriptionkeySource, saved.ProvServerInfo.APISubscriptionkeySource)
+	assert.Empty(t, saved.ProvServerInfo.APISubscriptionkey)
+	assert.Empty(t, saved.ProvServerInfo.APISubscriptionkeys)
+	assert.Equal(t, []string{"key3"}, c.SubscriptionKeys())
+	assert.NoError(t, saved.LoadSubscriptionKeys())
+	assert.Equal(t, []string{"key3"}, saved.SubscriptionKeys())
+
+	os.Setenv("SCS_TEST_PCS_API_KEY", strings.Repeat("key,", constants.MaxSubscriptionKeys+1))
+	assert.Error(t, c.LoadSubscriptionKeys())
+	c.ProvServerInfo.APISubscriptionkeySource = "vault:pcs-api-key"
+	assert.Error(t, c.LoadSubscriptionKeys())
+}
+
+func negotiatedProto(t *testing.T, conf *Configuration) string {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "SCS TLS Certificate"},
+		NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour), IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)}}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	h := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		TLSConfig: &tls.Config{MinVersion: tls.VersionTLS13,
+			Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}},
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  10 * time.Second,
+	}
+	conf.ConfigureHTTP2(h)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go h.ServeTLS(ln, "", "")
+	defer h.Close()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		ForceAttemptHTTP2: true}}
+	resp, err := client.Get("https://" + ln.Addr().String())
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	return resp.Proto
+}
+
+func TestConfigureHTTP2(t *testing.T) {
+	enabled, disabled := true, false
+	assert.Equal(t, "HTTP/2.0", negotiatedProto(t, &Configuration{HTTP2Enabled: &enabled}))
+	assert.Equal(t, "HTTP/1.1", negotiatedProto(t, &Configuration{HTTP2Enabled: &disabled}))
+	// config files written before the option was added negotiate HTTP/2 as before
+	assert.Equal(t, "HTTP/2.0", negotiatedProto(t, &Configuration{}))
+
+	var conf Configuration
+	assert.NoError(t, yaml.Unmarshal([]byte("port: 9000\n"), &conf))
+	h := &http.Server{TLSConfig: &tls.Config{}}
+	conf.ConfigureHTTP2(h)
+	assert.Nil(t, h.TLSConfig.NextProtos)
+	assert.Nil(t, h.TLSNextProto)
+}
@@ -24,8 +24,13 @@ const (
 	TrustedCAsStoreDir             = ConfigDir + "certs/trustedca/"
 	ServiceRemoveCmd               = "systemctl disable scs"
 	DefaultSSLCertFilePath         = ConfigDir + "scsdbcert.pem"
+	DefaultSqliteDBFile            = HomeDir + "scs.db"
+	DBBackendPostgres              = "postgres"
+	DBBackendSqlite                = "sqlite"
+	DefaultDBSSLMode               = "verify-full"
 	ServiceName                    = "SCS"
 	ExplicitServiceName            = "SGX Caching Service"
+	APIPathPrefix                  = "/scs/sgx/certification/v1"
 	HostDataUpdaterGroupName       = "HostDataUpdater"
 	HostDataReaderGroupName        = "HostDataReader"
 	CacheManagerGroupName          = "CacheManager"
@@ -35,7 +40,10 @@ const (
 	DefaultKeyAlgorithmLength      = 3072
 	DefaultScsTLSSan               = "127.0.0.1,localhost"
 	DefaultScsTLSCn                = "SCS TLS Certificate"
-	DefaultIntelProvServerURL      = "https://sbx.api.trustedservices.intel.com/sgx/certification/v3/"
+	DefaultIntelProvServerURL      = "https://sbx.api.trustedservices.intel.com"
+	DefaultProvServerCertPath      = "/sgx/certification"
+	DefaultProvServerAPIVersion    = "v3"
+	IntelPcsDomain                 = "trustedservices.intel.com"
 	EncPPIDKey                     = "encrypted_ppid"
 	PPID                           = "ppid"
 	CPUSvnKey                      = "cpu_svn"
@@ -46,6 +54,7 @@ const (
 	EncodingValue                  = "der"
 	FmspcKey                       = "fmspc"
 	HwUUIDKey                      = "hardware_uuid"
+	ManifestKey                    = "manifest"
 	DefaultScsRefreshHours         = 720
 	DefaultJwtValidateCacheKeyMins = 60
 	SCSLogLevel                    = "SCS_LOGLEVEL"
@@ -54,17 +63,40 @@ const (
 	DefaultWriteTimeout            = 10 * time.Second
 	DefaultIdleTimeout             = 1 * time.Second
 	DefaultMaxHeaderBytes          = 1 << 20
+	DefaultPcsClientTimeout        = 3 * time.Second
+	DefaultPcsDialTimeout          = 2 * time.Second
+	DefaultPcsTLSHandshakeTimeout  = 2 * time.Second
+	DefaultPcsRespHeaderTimeout    = 3 * time.Second
+	DefaultPcsIdleConnTimeout      = 90 * time.Second
+	DefaultPcsMaxIdleConnsPerHost  = MaxConcurrentRefreshRequests * 2
+	DefaultPcsRequestConcurrency   = MaxConcurrentRefreshRequests * 2
 	DefaultLogEntryMaxLength       = 300
 	TypeRefreshCert                = "certs"
 	TypeRefreshTcb                 = "tcbs"
+	TypeRefreshQe                  = "qe"
+	TypeRefreshOutOfDate           = "outofdate"
 	MaxTcbLevels                   = 16
 	DefaultRetrycount              = 3
 	DefaultWaitTime                = 1
+	DefaultAcceptedTcbStatuses     = "UpToDate,ConfigurationNeeded"
+	TcbLevelNotFound               = "TCBLevelNotFound"
+	DefaultDiagnosticsFmspc        = "00906ED50000"
 	MaxQueryParamsLength           = 50
 	DBMaxConnPercentage            = 70 // Percentage of DB's max connection. Ideally this should be around 25 to 75 % as we don't want to exhaust DB's connections.
 	DBConnMaxLifetimeMinutes       = 20 // DB connection lifetime.
 	MaxConcurrentRefreshRequests   = 5
 	MaxConcurrentRefreshDBUpdates  = MaxConcurrentRefreshRequests * 5
+	RefreshPlatformsPageSize       = 500
+	MaxRefreshBatchSize            = 10000
+	DefaultPlatformsPageLimit      = 100
+	MaxPlatformsPageLimit          = 1000
+	DefaultPcsAuditMaxRecords      = 10000
+	MinPlatformMaxAgeDays          = 7
+	PcsAuditQueueSize              = 100
+	TraceQueueSize                 = 2048
+	TraceExportBatchSize           = 512
+	TraceExportInterval            = 5 * time.Second
+	TraceExportTimeout             = 10 * time.Second
 	RefreshCoolOffTimeout          = 10
 	RefreshStatusSucceeded         = "success"
 	RefreshStatusTooMany           = "toomanyrequests"
@@ -72,6 +104,58 @@ const (
 	RefreshStatusIdle              = "idle"
 	RefreshStatusStarted           = "started"
 	RefreshStatusInProgress        = "inprogress"
+	RegistrationStatusRegistered   = "Registered"
+	RegistrationStatusUnregistered = "NotRegistered"
+	RegistrationStatusUnknown      = "Unknown"
+	RegistrationSourceCache        = "cache"
+	RegistrationSourcePcs          = "pcs"
+	MaxCachePurgeFmspcs            = 100
+	MaxSubscriptionKeys            = 10
+	SecretFileReference            = "file:"
+	SecretEnvReference             = "env:"
+	CachePurgeStatusPurged         = "purged"
+	CachePurgeStatusNotFound       = "notfound"
+	CachePurgeStatusFailed         = "failed"
+	DuplicatePlatformPushIgnore    = "ignore"
+	DuplicatePlatformPushUpdate    = "update"
+	EncPpidWithManifestPrefer      = "manifest"
+	EncPpidWithManifestReject      = "reject"
+	PckCertsNotAvailableReject     = "reject"
+	PckCertsNotAvailableRetry      = "retry"
+	PckCertsNotAvailableRetryAfter = 3600
+	ReadsDuringRefreshServe        = "serve"
+	ReadsDuringRefreshUnavailable  = "unavailable"
+	RefreshInProgressRetryAfter    = 60
+	SignatureVerificationEnforce   = "enforce"
+	SignatureVerificationWarn      = "warn"
+	SignatureVerificationOff       = "off"
+	ScopeFmspcKey                  = "fmspc"
+	ScopePceIDKey                  = "pceid"
+	DefaultRefreshFailureThreshold = 3
+	DefaultRefreshGraceAttempts    = 1
+	DefaultRefreshGraceDelaySecs   = 30
+	DefaultPckCertExpiryWarnDays   = 30
+	TypeRefreshExpiring            = "expiring"
+	RefreshAlertWebhookTimeout     = 5 * time.Second
+	PlatformAccessUpdateInterval   = 10 * time.Minute
+	DefaultWriteRateLimit          = 50
+	DefaultWriteRateBurst          = 100
+	DefaultWriteClientRateLimit    = 1
+	DefaultWriteClientRateBurst    = 10
+	MaxRateLimitedClients          = 10000
+	CacheArchiveVersion            = 1
+	CollateralPckCert              = "pckcert"
+	CollateralPckCrl               = "pckcrl"
+	CollateralTcbInfo              = "tcbinfo"
+	CollateralQeIdentity           = "qeidentity"
+	CollateralRootCaCrl            = "rootcacrl"
+	CollateralPckCertChain         = "pckcertchain"
+	CollateralStatusPresent        = "present"
+	CollateralStatusMissing        = "missing"
+	CollateralStatusExpired        = "expired"
+	RootCaCrlID                    = "RootCA"
+	PckCrlCaProcessor              = "processor"
+	PckCrlCaPlatform               = "platform"
 )
 
 type RefreshTrigger int
@@ -79,6 +163,24 @@ type RefreshTrigger int
 const (
 	TriggerStatus = iota + 1
 	TriggerStart
+	TriggerStartCerts
+	TriggerStartTcbs
+	TriggerStartQe
+	TriggerStartOutOfDateCerts
+	TriggerStartExpiringCerts
+)
+
+// caching models of the collaterals of pushed platforms, TcbInfo, PCK CRL and QE identity are
+// fetched when a platform is pushed with LazyCachingModel, and by the next refresh with PreCachingModel
+const (
+	LazyCachingModel = iota
+	PreCachingModel
+)
+
+// update types of TcbInfo and QE identity of Intel PCS server
+const (
+	StandardTcbUpdate = "standard"
+	EarlyTcbUpdate    = "early"
 )
 
 type CacheType int
@@ -87,3 +189,23 @@ const (
 	CacheInsert = iota + 1
 	CacheRefresh
 )
+
+// IntelSgxRootCA is the Intel SGX Root CA certificate, which is the trust anchor of the issuer chains
+// of collaterals returned by Intel PCS server unless another root CA is configured
+const IntelSgxRootCA = `-----BEGIN CERTIFICATE-----
+MIICjzCCAjSgAwIBAgIUImUM1lqdNInzg7SVUr9QGzknBqwwCgYIKoZIzj0EAwIw
+aDEaMBgGA1UEAwwRSW50ZWwgU0dYIFJvb3QgQ0ExGjAYBgNVBAoMEUludGVsIENv
+cnBvcmF0aW9uMRQwEgYDVQQHDAtTYW50YSBDbGFyYTELMAkGA1UECAwCQ0ExCzAJ
+BgNVBAYTAlVTMB4XDTE4MDUyMTEwNDUxMFoXDTQ5MTIzMTIzNTk1OVowaDEaMBgG
+A1UEAwwRSW50ZWwgU0dYIFJvb3QgQ0ExGjAYBgNVBAoMEUludGVsIENvcnBvcmF0
+aW9uMRQwEgYDVQQHDAtTYW50YSBDbGFyYTELMAkGA1UECAwCQ0ExCzAJBgNVBAYT
+AlVTMFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEC6nEwMDIYZOj/iPWsCzaEKi7
+1OiOSLRFhWGjbnBVJfVnkY4u3IjkDYYL0MxO4mqsyYjlBalTVYxFP2sJBK5zlKOB
+uzCBuDAfBgNVHSMEGDAWgBQiZQzWWp00ifODtJVSv1AbOScGrDBSBgNVHR8ESzBJ
+MEegRaBDhkFodHRwczovL2NlcnRpZmljYXRlcy50cnVzdGVkc2VydmljZXMuaW50
+ZWwuY29tL0ludGVsU0dYUm9vdENBLmRlcjAdBgNVHQ4EFgQUImUM1lqdNInzg7SV
+Ur9QGzknBqwwDgYDVR0PAQH/BAQDAgEGMBIGA1UdEwEB/wQIMAYBAf8CAQEwCgYI
+KoZIzj0EAwIDSQAwRgIhAOW/5QkR+S9CiSDcNoowLuPRLsWGf/Yi7GSX94BgwTwg
+AiEA4J0lrHoMs+Xo5o/sX6O9QWxHRAvZUGOdRQ7cvqRXaqI=
+-----END CERTIFICATE-----
+`
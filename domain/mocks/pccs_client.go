@@ -205,6 +205,8 @@ func (c *ClientMock) Do(req *http.Request) (*http.Response, error) {
 
 	if strings.Contains(req.URL.String(), "pckcerts") {
 		responseBody = respBody
+	} else if strings.Contains(req.URL.String(), "rootcacrl") {
+		responseBody = []byte(pckCrlResp)
 	} else if strings.Contains(req.URL.String(), "pckcrl") {
 		decodedResp, _ := hex.DecodeString(pckCrlResp)
 		responseBody = decodedResp
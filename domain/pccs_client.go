@@ -5,7 +5,11 @@
 package domain
 
 import (
+	"crypto/tls"
 	clog "intel/isecl/lib/common/v5/log"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"net"
 	"net/http"
 	"time"
 )
@@ -16,13 +20,56 @@ type HttpClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
-func NewPCCSClient() HttpClient {
+// NewPCCSClient creates the client used for Intel PCS server calls. insecureSkipVerify
+// disables TLS certificate verification of PCS server and must be used only for development
+func NewPCCSClient(insecureSkipVerify bool, transportConf config.ProvServerTransport) HttpClient {
 	log.Trace("domain/scs_client.go:NewPCCSClient() Entering")
 	defer log.Trace("resource/scs_client.go:NewPCCSClient() Leaving")
 
-	client := &http.Client{
-		Timeout: time.Duration(3 * time.Second),
+	transport := newPCCSTransport(transportConf)
+	if insecureSkipVerify {
+		log.Warn("domain/scs_client.go:NewPCCSClient() INSECURE: TLS certificate verification of Intel PCS server is disabled. " +
+			"This must never be used in production")
+		transport.TLSClientConfig = &tls.Config{
+			// allowed only in dev mode, see config.ValidateProvServerTLS
+			InsecureSkipVerify: true,
+		}
 	}
 
-	return client
+	return &http.Client{
+		Timeout:   durationOrDefault(transportConf.RequestTimeout, constants.DefaultPcsClientTimeout),
+		Transport: transport,
+	}
+}
+
+// newPCCSTransport builds the transport with explicit connection level timeouts, so that a slow
+// dial or TLS handshake fails with a clear error instead of consuming the whole request timeout,
+// and keeps idle connections to PCS around for reuse across sequential refresh requests
+func newPCCSTransport(conf config.ProvServerTransport) *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   durationOrDefault(conf.DialTimeout, constants.DefaultPcsDialTimeout),
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   durationOrDefault(conf.TLSHandshakeTimeout, constants.DefaultPcsTLSHandshakeTimeout),
+		ResponseHeaderTimeout: durationOrDefault(conf.ResponseHeaderTimeout, constants.DefaultPcsRespHeaderTimeout),
+		IdleConnTimeout:       durationOrDefault(conf.IdleConnTimeout, constants.DefaultPcsIdleConnTimeout),
+		MaxIdleConnsPerHost:   intOrDefault(conf.MaxIdleConnsPerHost, constants.DefaultPcsMaxIdleConnsPerHost),
+		ForceAttemptHTTP2:     true,
+	}
+}
+
+func durationOrDefault(d, defaultValue time.Duration) time.Duration {
+	if d <= 0 {
+		return defaultValue
+	}
+	return d
+}
+
+func intOrDefault(i, defaultValue int) int {
+	if i <= 0 {
+		return defaultValue
+	}
+	return i
 }
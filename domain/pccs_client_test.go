@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package domain
+
+import (
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPCCSClientTransport(t *testing.T) {
+	client := NewPCCSClient(false, config.ProvServerTransport{
+		DialTimeout:         time.Second,
+		TLSHandshakeTimeout: 1500 * time.Millisecond,
+		MaxIdleConnsPerHost: 4,
+	}).(*http.Client)
+	assert.Equal(t, constants.DefaultPcsClientTimeout, client.Timeout)
+
+	transport, ok := client.Transport.(*http.Transport)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.NotNil(t, transport.DialContext)
+	assert.NotNil(t, transport.Proxy)
+	assert.Equal(t, 1500*time.Millisecond, transport.TLSHandshakeTimeout)
+	assert.Equal(t, 4, transport.MaxIdleConnsPerHost)
+	// unset values fall back to defaults
+	assert.Equal(t, constants.DefaultPcsRespHeaderTimeout, transport.ResponseHeaderTimeout)
+	assert.Equal(t, constants.DefaultPcsIdleConnTimeout, transport.IdleConnTimeout)
+	assert.Nil(t, transport.TLSClientConfig)
+}
+
+func TestNewPCCSClientInsecureSkipVerify(t *testing.T) {
+	client := NewPCCSClient(true, config.ProvServerTransport{}).(*http.Client)
+	transport := client.Transport.(*http.Transport)
+	assert.True(t, transport.TLSClientConfig.InsecureSkipVerify)
+	assert.Equal(t, constants.DefaultPcsTLSHandshakeTimeout, transport.TLSHandshakeTimeout)
+	assert.Equal(t, constants.DefaultPcsMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+}
+
+func TestNewPCCSClientResponseHeaderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	stalled := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// accepts the request but does not respond
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer stalled.Close()
+
+	client := NewPCCSClient(false, config.ProvServerTransport{
+		RequestTimeout:        5 * time.Second,
+		ResponseHeaderTimeout: 100 * time.Millisecond,
+	})
+	assert.Equal(t, 5*time.Second, client.(*http.Client).Timeout)
+
+	req, _ := http.NewRequest(http.MethodGet, stalled.URL, nil)
+	start := time.Now()
+	_, err := client.Do(req)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "timeout awaiting response headers")
+	}
+	assert.True(t, time.Since(start) < time.Second, "request should fail before the overall timeout")
+}
+
+func TestNewPCCSClientSlowBody(t *testing.T) {
+	streaming := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		for i := 0; i < 3; i++ {
+			_, _ = w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer streaming.Close()
+
+	// the body is read within the overall timeout even though it takes longer than the header timeout
+	client := NewPCCSClient(false, config.ProvServerTransport{
+		RequestTimeout:        5 * time.Second,
+		ResponseHeaderTimeout: 100 * time.Millisecond,
+	})
+	req, _ := http.NewRequest(http.MethodGet, streaming.URL, nil)
+	resp, err := client.Do(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("chunk", 3), string(body))
+}
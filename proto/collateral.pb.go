@@ -0,0 +1,971 @@
+//
+// Copyright (C) 2022 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.33.0
+// 	protoc        (unknown)
+// source: collateral.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetPckCertRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	EncryptedPpid string `protobuf:"bytes,1,opt,name=encrypted_ppid,json=encryptedPpid,proto3" json:"encrypted_ppid,omitempty"`
+	Cpusvn        string `protobuf:"bytes,2,opt,name=cpusvn,proto3" json:"cpusvn,omitempty"`
+	Pcesvn        string `protobuf:"bytes,3,opt,name=pcesvn,proto3" json:"pcesvn,omitempty"`
+	Pceid         string `protobuf:"bytes,4,opt,name=pceid,proto3" json:"pceid,omitempty"`
+	Qeid          string `protobuf:"bytes,5,opt,name=qeid,proto3" json:"qeid,omitempty"`
+}
+
+func (x *GetPckCertRequest) Reset() {
+	*x = GetPckCertRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_collateral_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetPckCertRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPckCertRequest) ProtoMessage() {}
+
+func (x *GetPckCertRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_collateral_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPckCertRequest.ProtoReflect.Descriptor instead.
+func (*GetPckCertRequest) Descriptor() ([]byte, []int) {
+	return file_collateral_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetPckCertRequest) GetEncryptedPpid() string {
+	if x != nil {
+		return x.EncryptedPpid
+	}
+	return ""
+}
+
+func (x *GetPckCertRequest) GetCpusvn() string {
+	if x != nil {
+		return x.Cpusvn
+	}
+	return ""
+}
+
+func (x *GetPckCertRequest) GetPcesvn() string {
+	if x != nil {
+		return x.Pcesvn
+	}
+	return ""
+}
+
+func (x *GetPckCertRequest) GetPceid() string {
+	if x != nil {
+		return x.Pceid
+	}
+	return ""
+}
+
+func (x *GetPckCertRequest) GetQeid() string {
+	if x != nil {
+		return x.Qeid
+	}
+	return ""
+}
+
+// PckCert is the PCK certificate selected for the raw tcb level of the platform
+type PckCert struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PckCert     string `protobuf:"bytes,1,opt,name=pck_cert,json=pckCert,proto3" json:"pck_cert,omitempty"`
+	IssuerChain string `protobuf:"bytes,2,opt,name=issuer_chain,json=issuerChain,proto3" json:"issuer_chain,omitempty"`
+	Tcbm        string `protobuf:"bytes,3,opt,name=tcbm,proto3" json:"tcbm,omitempty"`
+}
+
+func (x *PckCert) Reset() {
+	*x = PckCert{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_collateral_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PckCert) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PckCert) ProtoMessage() {}
+
+func (x *PckCert) ProtoReflect() protoreflect.Message {
+	mi := &file_collateral_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PckCert.ProtoReflect.Descriptor instead.
+func (*PckCert) Descriptor() ([]byte, []int) {
+	return file_collateral_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PckCert) GetPckCert() string {
+	if x != nil {
+		return x.PckCert
+	}
+	return ""
+}
+
+func (x *PckCert) GetIssuerChain() string {
+	if x != nil {
+		return x.IssuerChain
+	}
+	return ""
+}
+
+func (x *PckCert) GetTcbm() string {
+	if x != nil {
+		return x.Tcbm
+	}
+	return ""
+}
+
+type GetPckCrlRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// ca is either processor or platform
+	Ca string `protobuf:"bytes,1,opt,name=ca,proto3" json:"ca,omitempty"`
+}
+
+func (x *GetPckCrlRequest) Reset() {
+	*x = GetPckCrlRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_collateral_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetPckCrlRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPckCrlRequest) ProtoMessage() {}
+
+func (x *GetPckCrlRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_collateral_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPckCrlRequest.ProtoReflect.Descriptor instead.
+func (*GetPckCrlRequest) Descriptor() ([]byte, []int) {
+	return file_collateral_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetPckCrlRequest) GetCa() string {
+	if x != nil {
+		return x.Ca
+	}
+	return ""
+}
+
+// PckCrl is served as cached, expired tells the CRL is past its nextUpdate as PCS could not be reached
+// to replace it
+type PckCrl struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Crl         []byte `protobuf:"bytes,1,opt,name=crl,proto3" json:"crl,omitempty"`
+	IssuerChain string `protobuf:"bytes,2,opt,name=issuer_chain,json=issuerChain,proto3" json:"issuer_chain,omitempty"`
+	Expired     bool   `protobuf:"varint,3,opt,name=expired,proto3" json:"expired,omitempty"`
+}
+
+func (x *PckCrl) Reset() {
+	*x = PckCrl{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_collateral_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PckCrl) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PckCrl) ProtoMessage() {}
+
+func (x *PckCrl) ProtoReflect() protoreflect.Message {
+	mi := &file_collateral_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PckCrl.ProtoReflect.Descriptor instead.
+func (*PckCrl) Descriptor() ([]byte, []int) {
+	return file_collateral_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PckCrl) GetCrl() []byte {
+	if x != nil {
+		return x.Crl
+	}
+	return nil
+}
+
+func (x *PckCrl) GetIssuerChain() string {
+	if x != nil {
+		return x.IssuerChain
+	}
+	return ""
+}
+
+func (x *PckCrl) GetExpired() bool {
+	if x != nil {
+		return x.Expired
+	}
+	return false
+}
+
+type GetTcbInfoRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Fmspc string `protobuf:"bytes,1,opt,name=fmspc,proto3" json:"fmspc,omitempty"`
+}
+
+func (x *GetTcbInfoRequest) Reset() {
+	*x = GetTcbInfoRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_collateral_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetTcbInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTcbInfoRequest) ProtoMessage() {}
+
+func (x *GetTcbInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_collateral_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTcbInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetTcbInfoRequest) Descriptor() ([]byte, []int) {
+	return file_collateral_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetTcbInfoRequest) GetFmspc() string {
+	if x != nil {
+		return x.Fmspc
+	}
+	return ""
+}
+
+type TcbInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TcbInfo     string `protobuf:"bytes,1,opt,name=tcb_info,json=tcbInfo,proto3" json:"tcb_info,omitempty"`
+	IssuerChain string `protobuf:"bytes,2,opt,name=issuer_chain,json=issuerChain,proto3" json:"issuer_chain,omitempty"`
+}
+
+func (x *TcbInfo) Reset() {
+	*x = TcbInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_collateral_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TcbInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TcbInfo) ProtoMessage() {}
+
+func (x *TcbInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_collateral_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TcbInfo.ProtoReflect.Descriptor instead.
+func (*TcbInfo) Descriptor() ([]byte, []int) {
+	return file_collateral_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TcbInfo) GetTcbInfo() string {
+	if x != nil {
+		return x.TcbInfo
+	}
+	return ""
+}
+
+func (x *TcbInfo) GetIssuerChain() string {
+	if x != nil {
+		return x.IssuerChain
+	}
+	return ""
+}
+
+type GetQeIdentityRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetQeIdentityRequest) Reset() {
+	*x = GetQeIdentityRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_collateral_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetQeIdentityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetQeIdentityRequest) ProtoMessage() {}
+
+func (x *GetQeIdentityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_collateral_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetQeIdentityRequest.ProtoReflect.Descriptor instead.
+func (*GetQeIdentityRequest) Descriptor() ([]byte, []int) {
+	return file_collateral_proto_rawDescGZIP(), []int{6}
+}
+
+type QeIdentity struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	QeIdentity  string `protobuf:"bytes,1,opt,name=qe_identity,json=qeIdentity,proto3" json:"qe_identity,omitempty"`
+	IssuerChain string `protobuf:"bytes,2,opt,name=issuer_chain,json=issuerChain,proto3" json:"issuer_chain,omitempty"`
+}
+
+func (x *QeIdentity) Reset() {
+	*x = QeIdentity{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_collateral_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *QeIdentity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QeIdentity) ProtoMessage() {}
+
+func (x *QeIdentity) ProtoReflect() protoreflect.Message {
+	mi := &file_collateral_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QeIdentity.ProtoReflect.Descriptor instead.
+func (*QeIdentity) Descriptor() ([]byte, []int) {
+	return file_collateral_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *QeIdentity) GetQeIdentity() string {
+	if x != nil {
+		return x.QeIdentity
+	}
+	return ""
+}
+
+func (x *QeIdentity) GetIssuerChain() string {
+	if x != nil {
+		return x.IssuerChain
+	}
+	return ""
+}
+
+type GetTcbStatusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Qeid  string `protobuf:"bytes,1,opt,name=qeid,proto3" json:"qeid,omitempty"`
+	Pceid string `protobuf:"bytes,2,opt,name=pceid,proto3" json:"pceid,omitempty"`
+}
+
+func (x *GetTcbStatusRequest) Reset() {
+	*x = GetTcbStatusRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_collateral_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetTcbStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTcbStatusRequest) ProtoMessage() {}
+
+func (x *GetTcbStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_collateral_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTcbStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetTcbStatusRequest) Descriptor() ([]byte, []int) {
+	return file_collateral_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetTcbStatusRequest) GetQeid() string {
+	if x != nil {
+		return x.Qeid
+	}
+	return ""
+}
+
+func (x *GetTcbStatusRequest) GetPceid() string {
+	if x != nil {
+		return x.Pceid
+	}
+	return ""
+}
+
+// TcbInfoFreshness tells how fresh the TcbInfo the tcb status was evaluated against is
+type TcbInfoFreshness struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	IssueDate               string `protobuf:"bytes,1,opt,name=issue_date,json=issueDate,proto3" json:"issue_date,omitempty"`
+	NextUpdate              string `protobuf:"bytes,2,opt,name=next_update,json=nextUpdate,proto3" json:"next_update,omitempty"`
+	TcbEvaluationDataNumber int32  `protobuf:"varint,3,opt,name=tcb_evaluation_data_number,json=tcbEvaluationDataNumber,proto3" json:"tcb_evaluation_data_number,omitempty"`
+	// updated_time is the time SCS cached the TcbInfo, in RFC 3339 format
+	UpdatedTime string `protobuf:"bytes,4,opt,name=updated_time,json=updatedTime,proto3" json:"updated_time,omitempty"`
+}
+
+func (x *TcbInfoFreshness) Reset() {
+	*x = TcbInfoFreshness{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_collateral_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TcbInfoFreshness) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TcbInfoFreshness) ProtoMessage() {}
+
+func (x *TcbInfoFreshness) ProtoReflect() protoreflect.Message {
+	mi := &file_collateral_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TcbInfoFreshness.ProtoReflect.Descriptor instead.
+func (*TcbInfoFreshness) Descriptor() ([]byte, []int) {
+	return file_collateral_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TcbInfoFreshness) GetIssueDate() string {
+	if x != nil {
+		return x.IssueDate
+	}
+	return ""
+}
+
+func (x *TcbInfoFreshness) GetNextUpdate() string {
+	if x != nil {
+		return x.NextUpdate
+	}
+	return ""
+}
+
+func (x *TcbInfoFreshness) GetTcbEvaluationDataNumber() int32 {
+	if x != nil {
+		return x.TcbEvaluationDataNumber
+	}
+	return 0
+}
+
+func (x *TcbInfoFreshness) GetUpdatedTime() string {
+	if x != nil {
+		return x.UpdatedTime
+	}
+	return ""
+}
+
+type TcbStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// up_to_date is set when the tcb status is one of the accepted statuses
+	UpToDate  bool              `protobuf:"varint,1,opt,name=up_to_date,json=upToDate,proto3" json:"up_to_date,omitempty"`
+	Message   string            `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	TcbStatus string            `protobuf:"bytes,3,opt,name=tcb_status,json=tcbStatus,proto3" json:"tcb_status,omitempty"`
+	TcbInfo   *TcbInfoFreshness `protobuf:"bytes,4,opt,name=tcb_info,json=tcbInfo,proto3" json:"tcb_info,omitempty"`
+}
+
+func (x *TcbStatus) Reset() {
+	*x = TcbStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_collateral_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TcbStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TcbStatus) ProtoMessage() {}
+
+func (x *TcbStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_collateral_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TcbStatus.ProtoReflect.Descriptor instead.
+func (*TcbStatus) Descriptor() ([]byte, []int) {
+	return file_collateral_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *TcbStatus) GetUpToDate() bool {
+	if x != nil {
+		return x.UpToDate
+	}
+	return false
+}
+
+func (x *TcbStatus) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *TcbStatus) GetTcbStatus() string {
+	if x != nil {
+		return x.TcbStatus
+	}
+	return ""
+}
+
+func (x *TcbStatus) GetTcbInfo() *TcbInfoFreshness {
+	if x != nil {
+		return x.TcbInfo
+	}
+	return nil
+}
+
+var File_collateral_proto protoreflect.FileDescriptor
+
+var file_collateral_proto_rawDesc = []byte{
+	0x0a, 0x10, 0x63, 0x6f, 0x6c, 0x6c, 0x61, 0x74, 0x65, 0x72, 0x61, 0x6c, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x12, 0x06, 0x73, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x22, 0x94, 0x01, 0x0a, 0x11, 0x47,
+	0x65, 0x74, 0x50, 0x63, 0x6b, 0x43, 0x65, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x25, 0x0a, 0x0e, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x65, 0x64, 0x5f, 0x70, 0x70,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70,
+	0x74, 0x65, 0x64, 0x50, 0x70, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x70, 0x75, 0x73, 0x76,
+	0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x63, 0x70, 0x75, 0x73, 0x76, 0x6e, 0x12,
+	0x16, 0x0a, 0x06, 0x70, 0x63, 0x65, 0x73, 0x76, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x70, 0x63, 0x65, 0x73, 0x76, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x63, 0x65, 0x69, 0x64,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x70, 0x63, 0x65, 0x69, 0x64, 0x12, 0x12, 0x0a,
+	0x04, 0x71, 0x65, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x71, 0x65, 0x69,
+	0x64, 0x22, 0x5b, 0x0a, 0x07, 0x50, 0x63, 0x6b, 0x43, 0x65, 0x72, 0x74, 0x12, 0x19, 0x0a, 0x08,
+	0x70, 0x63, 0x6b, 0x5f, 0x63, 0x65, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x70, 0x63, 0x6b, 0x43, 0x65, 0x72, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x69, 0x73, 0x73, 0x75, 0x65,
+	0x72, 0x5f, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x69,
+	0x73, 0x73, 0x75, 0x65, 0x72, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x63,
+	0x62, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x63, 0x62, 0x6d, 0x22, 0x22,
+	0x0a, 0x10, 0x47, 0x65, 0x74, 0x50, 0x63, 0x6b, 0x43, 0x72, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x63, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02,
+	0x63, 0x61, 0x22, 0x57, 0x0a, 0x06, 0x50, 0x63, 0x6b, 0x43, 0x72, 0x6c, 0x12, 0x10, 0x0a, 0x03,
+	0x63, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x63, 0x72, 0x6c, 0x12, 0x21,
+	0x0a, 0x0c, 0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x5f, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x43, 0x68, 0x61, 0x69,
+	0x6e, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x64, 0x22, 0x29, 0x0a, 0x11, 0x47,
+	0x65, 0x74, 0x54, 0x63, 0x62, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x14, 0x0a, 0x05, 0x66, 0x6d, 0x73, 0x70, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x66, 0x6d, 0x73, 0x70, 0x63, 0x22, 0x47, 0x0a, 0x07, 0x54, 0x63, 0x62, 0x49, 0x6e, 0x66,
+	0x6f, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x63, 0x62, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x74, 0x63, 0x62, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x21, 0x0a, 0x0c,
+	0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x5f, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0b, 0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x22,
+	0x16, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x51, 0x65, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x50, 0x0a, 0x0a, 0x51, 0x65, 0x49, 0x64, 0x65,
+	0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x1f, 0x0a, 0x0b, 0x71, 0x65, 0x5f, 0x69, 0x64, 0x65, 0x6e,
+	0x74, 0x69, 0x74, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x71, 0x65, 0x49, 0x64,
+	0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x21, 0x0a, 0x0c, 0x69, 0x73, 0x73, 0x75, 0x65, 0x72,
+	0x5f, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x69, 0x73,
+	0x73, 0x75, 0x65, 0x72, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x22, 0x3f, 0x0a, 0x13, 0x47, 0x65, 0x74,
+	0x54, 0x63, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x12, 0x0a, 0x04, 0x71, 0x65, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x71, 0x65, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x63, 0x65, 0x69, 0x64, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x70, 0x63, 0x65, 0x69, 0x64, 0x22, 0xb2, 0x01, 0x0a, 0x10, 0x54,
+	0x63, 0x62, 0x49, 0x6e, 0x66, 0x6f, 0x46, 0x72, 0x65, 0x73, 0x68, 0x6e, 0x65, 0x73, 0x73, 0x12,
+	0x1d, 0x0a, 0x0a, 0x69, 0x73, 0x73, 0x75, 0x65, 0x5f, 0x64, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x09, 0x69, 0x73, 0x73, 0x75, 0x65, 0x44, 0x61, 0x74, 0x65, 0x12, 0x1f,
+	0x0a, 0x0b, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0a, 0x6e, 0x65, 0x78, 0x74, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12,
+	0x3b, 0x0a, 0x1a, 0x74, 0x63, 0x62, 0x5f, 0x65, 0x76, 0x61, 0x6c, 0x75, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x17, 0x74, 0x63, 0x62, 0x45, 0x76, 0x61, 0x6c, 0x75, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x44, 0x61, 0x74, 0x61, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x21, 0x0a, 0x0c,
+	0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0b, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x22,
+	0x97, 0x01, 0x0a, 0x09, 0x54, 0x63, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1c, 0x0a,
+	0x0a, 0x75, 0x70, 0x5f, 0x74, 0x6f, 0x5f, 0x64, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x08, 0x75, 0x70, 0x54, 0x6f, 0x44, 0x61, 0x74, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x63, 0x62, 0x5f, 0x73, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x74, 0x63, 0x62, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x12, 0x33, 0x0a, 0x08, 0x74, 0x63, 0x62, 0x5f, 0x69, 0x6e, 0x66, 0x6f,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x73, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e,
+	0x54, 0x63, 0x62, 0x49, 0x6e, 0x66, 0x6f, 0x46, 0x72, 0x65, 0x73, 0x68, 0x6e, 0x65, 0x73, 0x73,
+	0x52, 0x07, 0x74, 0x63, 0x62, 0x49, 0x6e, 0x66, 0x6f, 0x32, 0xba, 0x02, 0x0a, 0x0a, 0x43, 0x6f,
+	0x6c, 0x6c, 0x61, 0x74, 0x65, 0x72, 0x61, 0x6c, 0x12, 0x38, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x50,
+	0x63, 0x6b, 0x43, 0x65, 0x72, 0x74, 0x12, 0x19, 0x2e, 0x73, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e,
+	0x47, 0x65, 0x74, 0x50, 0x63, 0x6b, 0x43, 0x65, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x0f, 0x2e, 0x73, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x63, 0x6b, 0x43, 0x65,
+	0x72, 0x74, 0x12, 0x35, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x50, 0x63, 0x6b, 0x43, 0x72, 0x6c, 0x12,
+	0x18, 0x2e, 0x73, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x50, 0x63, 0x6b, 0x43,
+	0x72, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x73, 0x63, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x50, 0x63, 0x6b, 0x43, 0x72, 0x6c, 0x12, 0x38, 0x0a, 0x0a, 0x47, 0x65, 0x74,
+	0x54, 0x63, 0x62, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x19, 0x2e, 0x73, 0x63, 0x73, 0x2e, 0x76, 0x31,
+	0x2e, 0x47, 0x65, 0x74, 0x54, 0x63, 0x62, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x73, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x63, 0x62, 0x49,
+	0x6e, 0x66, 0x6f, 0x12, 0x41, 0x0a, 0x0d, 0x47, 0x65, 0x74, 0x51, 0x65, 0x49, 0x64, 0x65, 0x6e,
+	0x74, 0x69, 0x74, 0x79, 0x12, 0x1c, 0x2e, 0x73, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65,
+	0x74, 0x51, 0x65, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x12, 0x2e, 0x73, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x51, 0x65, 0x49, 0x64,
+	0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x3e, 0x0a, 0x0c, 0x47, 0x65, 0x74, 0x54, 0x63, 0x62,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1b, 0x2e, 0x73, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e,
+	0x47, 0x65, 0x74, 0x54, 0x63, 0x62, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x73, 0x63, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x63, 0x62,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x42, 0x1a, 0x5a, 0x18, 0x69, 0x6e, 0x74, 0x65, 0x6c, 0x2f,
+	0x69, 0x73, 0x65, 0x63, 0x6c, 0x2f, 0x73, 0x63, 0x73, 0x2f, 0x76, 0x35, 0x2f, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_collateral_proto_rawDescOnce sync.Once
+	file_collateral_proto_rawDescData = file_collateral_proto_rawDesc
+)
+
+func file_collateral_proto_rawDescGZIP() []byte {
+	file_collateral_proto_rawDescOnce.Do(func() {
+		file_collateral_proto_rawDescData = protoimpl.X.CompressGZIP(file_collateral_proto_rawDescData)
+	})
+	return file_collateral_proto_rawDescData
+}
+
+var file_collateral_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_collateral_proto_goTypes = []interface{}{
+	(*GetPckCertRequest)(nil),    // 0: scs.v1.GetPckCertRequest
+	(*PckCert)(nil),              // 1: scs.v1.PckCert
+	(*GetPckCrlRequest)(nil),     // 2: scs.v1.GetPckCrlRequest
+	(*PckCrl)(nil),               // 3: scs.v1.PckCrl
+	(*GetTcbInfoRequest)(nil),    // 4: scs.v1.GetTcbInfoRequest
+	(*TcbInfo)(nil),              // 5: scs.v1.TcbInfo
+	(*GetQeIdentityRequest)(nil), // 6: scs.v1.GetQeIdentityRequest
+	(*QeIdentity)(nil),           // 7: scs.v1.QeIdentity
+	(*GetTcbStatusRequest)(nil),  // 8: scs.v1.GetTcbStatusRequest
+	(*TcbInfoFreshness)(nil),     // 9: scs.v1.TcbInfoFreshness
+	(*TcbStatus)(nil),            // 10: scs.v1.TcbStatus
+}
+var file_collateral_proto_depIdxs = []int32{
+	9,  // 0: scs.v1.TcbStatus.tcb_info:type_name -> scs.v1.TcbInfoFreshness
+	0,  // 1: scs.v1.Collateral.GetPckCert:input_type -> scs.v1.GetPckCertRequest
+	2,  // 2: scs.v1.Collateral.GetPckCrl:input_type -> scs.v1.GetPckCrlRequest
+	4,  // 3: scs.v1.Collateral.GetTcbInfo:input_type -> scs.v1.GetTcbInfoRequest
+	6,  // 4: scs.v1.Collateral.GetQeIdentity:input_type -> scs.v1.GetQeIdentityRequest
+	8,  // 5: scs.v1.Collateral.GetTcbStatus:input_type -> scs.v1.GetTcbStatusRequest
+	1,  // 6: scs.v1.Collateral.GetPckCert:output_type -> scs.v1.PckCert
+	3,  // 7: scs.v1.Collateral.GetPckCrl:output_type -> scs.v1.PckCrl
+	5,  // 8: scs.v1.Collateral.GetTcbInfo:output_type -> scs.v1.TcbInfo
+	7,  // 9: scs.v1.Collateral.GetQeIdentity:output_type -> scs.v1.QeIdentity
+	10, // 10: scs.v1.Collateral.GetTcbStatus:output_type -> scs.v1.TcbStatus
+	6,  // [6:11] is the sub-list for method output_type
+	1,  // [1:6] is the sub-list for method input_type
+	1,  // [1:1] is the sub-list for extension type_name
+	1,  // [1:1] is the sub-list for extension extendee
+	0,  // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_collateral_proto_init() }
+func file_collateral_proto_init() {
+	if File_collateral_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_collateral_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetPckCertRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_collateral_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PckCert); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_collateral_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetPckCrlRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_collateral_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PckCrl); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_collateral_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetTcbInfoRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_collateral_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TcbInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_collateral_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetQeIdentityRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_collateral_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*QeIdentity); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_collateral_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetTcbStatusRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_collateral_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TcbInfoFreshness); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_collateral_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TcbStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_collateral_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_collateral_proto_goTypes,
+		DependencyIndexes: file_collateral_proto_depIdxs,
+		MessageInfos:      file_collateral_proto_msgTypes,
+	}.Build()
+	File_collateral_proto = out.File
+	file_collateral_proto_rawDesc = nil
+	file_collateral_proto_goTypes = nil
+	file_collateral_proto_depIdxs = nil
+}
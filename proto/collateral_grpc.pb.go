@@ -0,0 +1,261 @@
+//
+// Copyright (C) 2022 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: collateral.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Collateral_GetPckCert_FullMethodName    = "/scs.v1.Collateral/GetPckCert"
+	Collateral_GetPckCrl_FullMethodName     = "/scs.v1.Collateral/GetPckCrl"
+	Collateral_GetTcbInfo_FullMethodName    = "/scs.v1.Collateral/GetTcbInfo"
+	Collateral_GetQeIdentity_FullMethodName = "/scs.v1.Collateral/GetQeIdentity"
+	Collateral_GetTcbStatus_FullMethodName  = "/scs.v1.Collateral/GetTcbStatus"
+)
+
+// CollateralClient is the client API for Collateral service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CollateralClient interface {
+	GetPckCert(ctx context.Context, in *GetPckCertRequest, opts ...grpc.CallOption) (*PckCert, error)
+	GetPckCrl(ctx context.Context, in *GetPckCrlRequest, opts ...grpc.CallOption) (*PckCrl, error)
+	GetTcbInfo(ctx context.Context, in *GetTcbInfoRequest, opts ...grpc.CallOption) (*TcbInfo, error)
+	GetQeIdentity(ctx context.Context, in *GetQeIdentityRequest, opts ...grpc.CallOption) (*QeIdentity, error)
+	GetTcbStatus(ctx context.Context, in *GetTcbStatusRequest, opts ...grpc.CallOption) (*TcbStatus, error)
+}
+
+type collateralClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCollateralClient(cc grpc.ClientConnInterface) CollateralClient {
+	return &collateralClient{cc}
+}
+
+func (c *collateralClient) GetPckCert(ctx context.Context, in *GetPckCertRequest, opts ...grpc.CallOption) (*PckCert, error) {
+	out := new(PckCert)
+	err := c.cc.Invoke(ctx, Collateral_GetPckCert_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *collateralClient) GetPckCrl(ctx context.Context, in *GetPckCrlRequest, opts ...grpc.CallOption) (*PckCrl, error) {
+	out := new(PckCrl)
+	err := c.cc.Invoke(ctx, Collateral_GetPckCrl_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *collateralClient) GetTcbInfo(ctx context.Context, in *GetTcbInfoRequest, opts ...grpc.CallOption) (*TcbInfo, error) {
+	out := new(TcbInfo)
+	err := c.cc.Invoke(ctx, Collateral_GetTcbInfo_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *collateralClient) GetQeIdentity(ctx context.Context, in *GetQeIdentityRequest, opts ...grpc.CallOption) (*QeIdentity, error) {
+	out := new(QeIdentity)
+	err := c.cc.Invoke(ctx, Collateral_GetQeIdentity_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *collateralClient) GetTcbStatus(ctx context.Context, in *GetTcbStatusRequest, opts ...grpc.CallOption) (*TcbStatus, error) {
+	out := new(TcbStatus)
+	err := c.cc.Invoke(ctx, Collateral_GetTcbStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CollateralServer is the server API for Collateral service.
+// All implementations must embed UnimplementedCollateralServer
+// for forward compatibility
+type CollateralServer interface {
+	GetPckCert(context.Context, *GetPckCertRequest) (*PckCert, error)
+	GetPckCrl(context.Context, *GetPckCrlRequest) (*PckCrl, error)
+	GetTcbInfo(context.Context, *GetTcbInfoRequest) (*TcbInfo, error)
+	GetQeIdentity(context.Context, *GetQeIdentityRequest) (*QeIdentity, error)
+	GetTcbStatus(context.Context, *GetTcbStatusRequest) (*TcbStatus, error)
+	mustEmbedUnimplementedCollateralServer()
+}
+
+// UnimplementedCollateralServer must be embedded to have forward compatible implementations.
+type UnimplementedCollateralServer struct {
+}
+
+func (UnimplementedCollateralServer) GetPckCert(context.Context, *GetPckCertRequest) (*PckCert, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPckCert not implemented")
+}
+func (UnimplementedCollateralServer) GetPckCrl(context.Context, *GetPckCrlRequest) (*PckCrl, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPckCrl not implemented")
+}
+func (UnimplementedCollateralServer) GetTcbInfo(context.Context, *GetTcbInfoRequest) (*TcbInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTcbInfo not implemented")
+}
+func (UnimplementedCollateralServer) GetQeIdentity(context.Context, *GetQeIdentityRequest) (*QeIdentity, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetQeIdentity not implemented")
+}
+func (UnimplementedCollateralServer) GetTcbStatus(context.Context, *GetTcbStatusRequest) (*TcbStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTcbStatus not implemented")
+}
+func (UnimplementedCollateralServer) mustEmbedUnimplementedCollateralServer() {}
+
+// UnsafeCollateralServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CollateralServer will
+// result in compilation errors.
+type UnsafeCollateralServer interface {
+	mustEmbedUnimplementedCollateralServer()
+}
+
+func RegisterCollateralServer(s grpc.ServiceRegistrar, srv CollateralServer) {
+	s.RegisterService(&Collateral_ServiceDesc, srv)
+}
+
+func _Collateral_GetPckCert_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPckCertRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CollateralServer).GetPckCert(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Collateral_GetPckCert_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CollateralServer).GetPckCert(ctx, req.(*GetPckCertRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Collateral_GetPckCrl_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPckCrlRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CollateralServer).GetPckCrl(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Collateral_GetPckCrl_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CollateralServer).GetPckCrl(ctx, req.(*GetPckCrlRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Collateral_GetTcbInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTcbInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CollateralServer).GetTcbInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Collateral_GetTcbInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CollateralServer).GetTcbInfo(ctx, req.(*GetTcbInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Collateral_GetQeIdentity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetQeIdentityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CollateralServer).GetQeIdentity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Collateral_GetQeIdentity_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CollateralServer).GetQeIdentity(ctx, req.(*GetQeIdentityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Collateral_GetTcbStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTcbStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CollateralServer).GetTcbStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Collateral_GetTcbStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CollateralServer).GetTcbStatus(ctx, req.(*GetTcbStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Collateral_ServiceDesc is the grpc.ServiceDesc for Collateral service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Collateral_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "scs.v1.Collateral",
+	HandlerType: (*CollateralServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetPckCert",
+			Handler:    _Collateral_GetPckCert_Handler,
+		},
+		{
+			MethodName: "GetPckCrl",
+			Handler:    _Collateral_GetPckCrl_Handler,
+		},
+		{
+			MethodName: "GetTcbInfo",
+			Handler:    _Collateral_GetTcbInfo_Handler,
+		},
+		{
+			MethodName: "GetQeIdentity",
+			Handler:    _Collateral_GetQeIdentity_Handler,
+		},
+		{
+			MethodName: "GetTcbStatus",
+			Handler:    _Collateral_GetTcbStatus_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "collateral.proto",
+}
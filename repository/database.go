@@ -4,8 +4,19 @@
  */
 package repository
 
+import "github.com/pkg/errors"
+
+// ErrNotFound is returned, wrapped, by the methods retrieving a single record when no record matches
+var ErrNotFound = errors.New("record not found")
+
+// ErrAlreadyExists is returned, wrapped, by the methods creating a record when a record with the
+// same primary key is already cached, e.g. by a concurrent request
+var ErrAlreadyExists = errors.New("record already exists")
+
 type SCSDatabase interface {
 	Migrate() error
+	// Ping checks that the database is reachable
+	Ping() error
 	PlatformRepository() PlatformRepository
 	PlatformTcbRepository() PlatformTcbRepository
 	PckCertChainRepository() PckCertChainRepository
@@ -13,6 +24,12 @@ type SCSDatabase interface {
 	PckCrlRepository() PckCrlRepository
 	FmspcTcbInfoRepository() FmspcTcbInfoRepository
 	QEIdentityRepository() QEIdentityRepository
+	RootCaCrlRepository() RootCaCrlRepository
 	LastRefreshRepository() LastRefreshRepository
+	PcsAuditRecordRepository() PcsAuditRecordRepository
+	TcbLevelRepository() TcbLevelRepository
+	// ExecuteInTransaction runs fn with a database whose repositories are bound to a single
+	// transaction, which is committed when fn succeeds and rolled back when fn fails or panics
+	ExecuteInTransaction(fn func(SCSDatabase) error) error
 	Close()
 }
@@ -10,6 +10,8 @@ type FmspcTcbInfoRepository interface {
 	Create(*types.FmspcTcbInfo) (*types.FmspcTcbInfo, error)
 	Retrieve(*types.FmspcTcbInfo) (*types.FmspcTcbInfo, error)
 	RetrieveAll() (types.FmspcTcbInfos, error)
+	RetrievePaginated(types.Pagination) (types.FmspcTcbInfos, error)
+	RetrieveLatestUpdated() (*types.FmspcTcbInfo, error)
 	Update(*types.FmspcTcbInfo) error
 	Delete(*types.FmspcTcbInfo) error
 }
@@ -0,0 +1,58 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package memory
+
+import (
+	"intel/isecl/scs/v5/types"
+)
+
+type FmspcTcbInfoRepository struct {
+	t *table
+}
+
+func (r *FmspcTcbInfoRepository) Create(tcb *types.FmspcTcbInfo) (*types.FmspcTcbInfo, error) {
+	if err := r.t.create(tcb); err != nil {
+		return nil, err
+	}
+	return tcb, nil
+}
+
+func (r *FmspcTcbInfoRepository) Retrieve(tcb *types.FmspcTcbInfo) (*types.FmspcTcbInfo, error) {
+	if !r.t.retrieve(tcb) {
+		return nil, r.t.notFound("Retrieve")
+	}
+	return tcb, nil
+}
+
+func (r *FmspcTcbInfoRepository) RetrieveAll() (types.FmspcTcbInfos, error) {
+	var tcbs types.FmspcTcbInfos
+	r.t.all(&tcbs, nil)
+	return tcbs, nil
+}
+
+func (r *FmspcTcbInfoRepository) RetrievePaginated(page types.Pagination) (types.FmspcTcbInfos, error) {
+	var tcbs types.FmspcTcbInfos
+	if err := r.t.paginate(&tcbs, page, nil); err != nil {
+		return nil, err
+	}
+	return tcbs, nil
+}
+
+func (r *FmspcTcbInfoRepository) RetrieveLatestUpdated() (*types.FmspcTcbInfo, error) {
+	var tcb types.FmspcTcbInfo
+	if !r.t.latest(&tcb) {
+		return nil, r.t.notFound("RetrieveLatestUpdated")
+	}
+	return &tcb, nil
+}
+
+func (r *FmspcTcbInfoRepository) Update(tcb *types.FmspcTcbInfo) error {
+	return r.t.update(tcb)
+}
+
+func (r *FmspcTcbInfoRepository) Delete(tcb *types.FmspcTcbInfo) error {
+	r.t.deleteRecord(tcb)
+	return nil
+}
@@ -0,0 +1,27 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package memory
+
+import (
+	"intel/isecl/scs/v5/types"
+)
+
+type LastRefreshRepository struct {
+	t *table
+}
+
+func (r *LastRefreshRepository) Retrieve() (*types.LastRefresh, error) {
+	var lastRefresh types.LastRefresh
+	if !r.t.retrieve(&lastRefresh) {
+		return nil, nil
+	}
+	return &lastRefresh, nil
+}
+
+// Update keeps only the last refresh info, the same as the postgres repository
+func (r *LastRefreshRepository) Update(lastRefresh *types.LastRefresh) error {
+	r.t.delete(func(interface{}) bool { return true })
+	return r.t.create(lastRefresh)
+}
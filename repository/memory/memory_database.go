@@ -0,0 +1,130 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+
+// Package memory provides an in-memory implementation of SCSDatabase, so that code depending on
+// the repositories can be tested without a postgres database
+package memory
+
+import (
+	"intel/isecl/scs/v5/repository"
+)
+
+type Database struct {
+	platforms       *table
+	platformTcbs    *table
+	pckCertChains   *table
+	pckCerts        *table
+	pckCrls         *table
+	fmspcTcbInfos   *table
+	qeIdentities    *table
+	rootCaCrls      *table
+	lastRefresh     *table
+	pcsAuditRecords *pcsAuditTable
+	tcbLevels       *table
+}
+
+func NewDatabase() *Database {
+	return &Database{
+		platforms:       newTable("platforms"),
+		platformTcbs:    newTable("platform_tcbs"),
+		pckCertChains:   newTable("pck_cert_chains"),
+		pckCerts:        newTable("pck_certs"),
+		pckCrls:         newTable("pck_crls"),
+		fmspcTcbInfos:   newTable("fmspc_tcb_infos"),
+		qeIdentities:    newTable("qe_identities"),
+		rootCaCrls:      newTable("root_ca_crls"),
+		lastRefresh:     newTable("last_refreshes"),
+		pcsAuditRecords: &pcsAuditTable{table: newTable("pcs_audit_records")},
+		tcbLevels:       newTable("tcb_levels"),
+	}
+}
+
+func (d *Database) Migrate() error {
+	return nil
+}
+
+func (d *Database) Ping() error {
+	return nil
+}
+
+func (d *Database) PlatformRepository() repository.PlatformRepository {
+	return &PlatformRepository{t: d.platforms}
+}
+
+func (d *Database) PlatformTcbRepository() repository.PlatformTcbRepository {
+	return &PlatformTcbRepository{t: d.platformTcbs}
+}
+
+func (d *Database) PckCertChainRepository() repository.PckCertChainRepository {
+	return &PckCertChainRepository{t: d.pckCertChains}
+}
+
+func (d *Database) PckCertRepository() repository.PckCertRepository {
+	return &PckCertRepository{t: d.pckCerts}
+}
+
+func (d *Database) PckCrlRepository() repository.PckCrlRepository {
+	return &PckCrlRepository{t: d.pckCrls}
+}
+
+func (d *Database) FmspcTcbInfoRepository() repository.FmspcTcbInfoRepository {
+	return &FmspcTcbInfoRepository{t: d.fmspcTcbInfos}
+}
+
+func (d *Database) QEIdentityRepository() repository.QEIdentityRepository {
+	return &QEIdentityRepository{t: d.qeIdentities}
+}
+
+func (d *Database) RootCaCrlRepository() repository.RootCaCrlRepository {
+	return &RootCaCrlRepository{t: d.rootCaCrls}
+}
+
+func (d *Database) LastRefreshRepository() repository.LastRefreshRepository {
+	return &LastRefreshRepository{t: d.lastRefresh}
+}
+
+func (d *Database) PcsAuditRecordRepository() repository.PcsAuditRecordRepository {
+	return &PcsAuditRecordRepository{t: d.pcsAuditRecords}
+}
+
+func (d *Database) TcbLevelRepository() repository.TcbLevelRepository {
+	return &TcbLevelRepository{t: d.tcbLevels}
+}
+
+func (d *Database) tables() []*table {
+	return []*table{d.platforms, d.platformTcbs, d.pckCertChains, d.pckCerts, d.pckCrls,
+		d.fmspcTcbInfos, d.qeIdentities, d.rootCaCrls, d.lastRefresh, d.pcsAuditRecords.table,
+		d.tcbLevels}
+}
+
+// ExecuteInTransaction restores all tables to their state before fn when fn fails or panics.
+// Unlike a postgres transaction, changes made by fn are visible to concurrent callers
+func (d *Database) ExecuteInTransaction(fn func(repository.SCSDatabase) error) error {
+	tables := d.tables()
+	snapshots := make([][]interface{}, len(tables))
+	for i, t := range tables {
+		snapshots[i] = t.snapshot()
+	}
+
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		for i, t := range tables {
+			t.restore(snapshots[i])
+		}
+	}()
+
+	err := fn(d)
+	if err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+func (d *Database) Close() {
+}
@@ -0,0 +1,327 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package memory
+
+import (
+	"fmt"
+	"intel/isecl/scs/v5/repository"
+	"intel/isecl/scs/v5/types"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ repository.SCSDatabase = (*Database)(nil)
+
+func TestPlatformRepository(t *testing.T) {
+	r := NewDatabase().PlatformRepository()
+	platform := &types.Platform{QeID: "qeid", PceID: "0000", Fmspc: "20606a000000", Ppid: "ppid"}
+
+	_, err := r.Create(platform)
+	assert.NoError(t, err)
+	_, err = r.Create(&types.Platform{QeID: "qeid", PceID: "0000"})
+	assert.True(t, errors.Is(err, repository.ErrAlreadyExists))
+
+	// records are retrieved by their non zero fields
+	p, err := r.Retrieve(&types.Platform{QeID: "qeid", PceID: "0000"})
+	assert.NoError(t, err)
+	assert.Equal(t, "20606a000000", p.Fmspc)
+	_, err = r.Retrieve(&types.Platform{QeID: "qeid", PceID: "0000", Fmspc: "00606a000000"})
+	assert.True(t, errors.Is(err, repository.ErrNotFound))
+
+	// only non zero fields are updated
+	err = r.Update(&types.Platform{QeID: "qeid", PceID: "0000", CPUSvn: "cpusvn"})
+	assert.NoError(t, err)
+	p, err = r.Retrieve(&types.Platform{QeID: "qeid", PceID: "0000"})
+	assert.NoError(t, err)
+	assert.Equal(t, "cpusvn", p.CPUSvn)
+	assert.Equal(t, "20606a000000", p.Fmspc)
+	err = r.Update(&types.Platform{QeID: "other", PceID: "0000", CPUSvn: "cpusvn"})
+	assert.EqualError(t, err, "Update: - no rows affected")
+
+	// records returned are copies of the stored records
+	p.Fmspc = "00606a000000"
+	p, err = r.Retrieve(&types.Platform{QeID: "qeid", PceID: "0000"})
+	assert.NoError(t, err)
+	assert.Equal(t, "20606a000000", p.Fmspc)
+
+	assert.NoError(t, r.Delete(&types.Platform{QeID: "qeid", PceID: "0000"}))
+	_, err = r.Retrieve(&types.Platform{QeID: "qeid", PceID: "0000"})
+	assert.True(t, errors.Is(err, repository.ErrNotFound))
+}
+
+func TestPlatformRepositoryUpsert(t *testing.T) {
+	r := NewDatabase().PlatformRepository()
+	createdTime := time.Date(2022, 6, 21, 0, 0, 0, 0, time.UTC)
+
+	// concurrent upserts of a platform all succeed and only one of them creates it
+	var wg sync.WaitGroup
+	var created int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ok, err := r.Upsert(&types.Platform{QeID: "qeid", PceID: "0000", CPUSvn: fmt.Sprintf("cpusvn%d", i),
+				CreatedTime: createdTime.Add(time.Duration(i) * time.Hour)})
+			assert.NoError(t, err)
+			if ok {
+				atomic.AddInt32(&created, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), created)
+
+	platforms, err := r.RetrieveAll()
+	assert.NoError(t, err)
+	assert.Len(t, platforms, 1)
+
+	// an update keeps the created time of the platform
+	ok, err := r.Upsert(&types.Platform{QeID: "qeid", PceID: "0000", Fmspc: "20606a000000", CreatedTime: createdTime.Add(-time.Hour)})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	p, err := r.Retrieve(&types.Platform{QeID: "qeid", PceID: "0000"})
+	assert.NoError(t, err)
+	assert.Equal(t, "20606a000000", p.Fmspc)
+	assert.Equal(t, platforms[0].CreatedTime, p.CreatedTime)
+	assert.Equal(t, platforms[0].CPUSvn, p.CPUSvn)
+}
+
+func TestRetrievePaginated(t *testing.T) {
+	r := NewDatabase().PlatformRepository()
+	now := time.Now()
+	for i, qeID := range []string{"qeid1", "qeid2", "qeid3"} {
+		_, err := r.Create(&types.Platform{QeID: qeID, PceID: "0000", Ppid: "ppid",
+			UpdatedTime: now.Add(-time.Duration(i) * time.Hour)})
+		assert.NoError(t, err)
+	}
+
+	platforms, err := r.RetrievePaginated(types.Pagination{Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, platforms, 2)
+	assert.Equal(t, "qeid1", platforms[0].QeID)
+
+	platforms, err = r.RetrievePaginated(types.Pagination{Limit: 2, Offset: 1,
+		OrderBy: types.OrderByUpdatedTime})
+	assert.NoError(t, err)
+	assert.Len(t, platforms, 2)
+	assert.Equal(t, "qeid2", platforms[0].QeID)
+	assert.Equal(t, "qeid1", platforms[1].QeID)
+
+	_, err = r.RetrievePaginated(types.Pagination{Limit: 0})
+	assert.Error(t, err)
+
+	_, err = r.Create(&types.Platform{QeID: "qeid4", PceID: "0001", Ppid: "ppid", UpdatedTime: now})
+	assert.NoError(t, err)
+	platforms, err = r.RetrieveByPceID("0000", types.Pagination{Limit: 2, Offset: 1})
+	assert.NoError(t, err)
+	assert.Len(t, platforms, 2)
+	assert.Equal(t, "qeid2", platforms[0].QeID)
+	platforms, err = r.RetrieveByPceID("0001", types.Pagination{Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, platforms, 1)
+
+	platforms, err = r.RetrieveNotUpdatedSince(now.Add(-30 * time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, platforms, 2)
+}
+
+func TestPckCertRepository(t *testing.T) {
+	db := NewDatabase()
+	r := db.PckCertRepository()
+	_, err := r.Create(&types.PckCert{QeID: "qeid", PceID: "0000", CPUSvn: "cpusvn", PceSvn: "pcesvn"})
+	assert.NoError(t, err)
+
+	_, err = r.RetrieveByTcbLevel(&types.PckCert{QeID: "qeid", PceID: "0000", CPUSvn: "cpusvn"})
+	assert.True(t, errors.Is(err, repository.ErrNotFound))
+	_, err = r.RetrieveByTcbLevel(&types.PckCert{QeID: "qeid", PceID: "0000", CPUSvn: "cpusvn", PceSvn: "pcesvn"})
+	assert.NoError(t, err)
+
+	// pck certs without a known expiry are not expiring
+	notAfter := time.Date(2022, 6, 21, 0, 0, 0, 0, time.UTC)
+	_, err = r.Create(&types.PckCert{QeID: "qeid", PceID: "0000", CPUSvn: "cpusvn", PceSvn: "0b00", NotAfter: notAfter})
+	assert.NoError(t, err)
+	certs, err := r.RetrieveExpiringBefore(notAfter)
+	assert.NoError(t, err)
+	assert.Empty(t, certs)
+	certs, err = r.RetrieveExpiringBefore(notAfter.Add(time.Hour))
+	assert.NoError(t, err)
+	if assert.Len(t, certs, 1) {
+		assert.Equal(t, "0b00", certs[0].PceSvn)
+	}
+
+	assert.NoError(t, r.DeleteByPlatform(&types.Platform{QeID: "qeid", PceID: "0000"}))
+	certs, err = r.RetrieveAll()
+	assert.NoError(t, err)
+	assert.Empty(t, certs)
+}
+
+func TestSingleRecordRepositories(t *testing.T) {
+	db := NewDatabase()
+
+	_, err := db.QEIdentityRepository().Retrieve()
+	assert.True(t, errors.Is(err, repository.ErrNotFound))
+	_, err = db.QEIdentityRepository().Create(&types.QEIdentity{ID: "id", QeInfo: "identity"})
+	assert.NoError(t, err)
+	qe, err := db.QEIdentityRepository().Retrieve()
+	assert.NoError(t, err)
+	assert.Equal(t, "identity", qe.QeInfo)
+
+	_, err = db.RootCaCrlRepository().Retrieve()
+	assert.True(t, errors.Is(err, repository.ErrNotFound))
+	_, err = db.RootCaCrlRepository().Create(&types.RootCaCrl{ID: "RootCA", RootCaCrl: "crl"})
+	assert.NoError(t, err)
+	assert.NoError(t, db.RootCaCrlRepository().Update(&types.RootCaCrl{ID: "RootCA", RootCaCrl: "refreshed"}))
+	rootCaCrl, err := db.RootCaCrlRepository().Retrieve()
+	assert.NoError(t, err)
+	assert.Equal(t, "refreshed", rootCaCrl.RootCaCrl)
+
+	lastRefresh, err := db.LastRefreshRepository().Retrieve()
+	assert.NoError(t, err)
+	assert.Nil(t, lastRefresh)
+	assert.NoError(t, db.LastRefreshRepository().Update(&types.LastRefresh{Status: "first"}))
+	assert.NoError(t, db.LastRefreshRepository().Update(&types.LastRefresh{Status: "second"}))
+	lastRefresh, err = db.LastRefreshRepository().Retrieve()
+	assert.NoError(t, err)
+	assert.Equal(t, "second", lastRefresh.Status)
+}
+
+func TestRetrieveLatestUpdated(t *testing.T) {
+	db := NewDatabase()
+	now := time.Now()
+
+	_, err := db.FmspcTcbInfoRepository().RetrieveLatestUpdated()
+	assert.True(t, errors.Is(err, repository.ErrNotFound))
+	for i, fmspc := range []string{"00906ed50000", "20606a000000", "00606a000000"} {
+		_, err = db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: fmspc, UpdatedTime: now.Add(time.Duration(i%2) * time.Hour)})
+		assert.NoError(t, err)
+	}
+	tcbInfo, err := db.FmspcTcbInfoRepository().RetrieveLatestUpdated()
+	assert.NoError(t, err)
+	assert.Equal(t, "20606a000000", tcbInfo.Fmspc)
+
+	_, err = db.PckCrlRepository().Create(&types.PckCrl{Ca: "processor", UpdatedTime: now})
+	assert.NoError(t, err)
+	_, err = db.PckCrlRepository().Create(&types.PckCrl{Ca: "platform", UpdatedTime: now})
+	assert.NoError(t, err)
+	// records updated at the same time are picked by primary key
+	pckCrl, err := db.PckCrlRepository().RetrieveLatestUpdated()
+	assert.NoError(t, err)
+	assert.Equal(t, "platform", pckCrl.Ca)
+
+	_, err = db.PckCertRepository().Create(&types.PckCert{QeID: "qeid1", PceID: "0000", UpdatedTime: now})
+	assert.NoError(t, err)
+	_, err = db.PckCertRepository().Create(&types.PckCert{QeID: "qeid2", PceID: "0000", UpdatedTime: now.Add(-time.Hour)})
+	assert.NoError(t, err)
+	assert.NoError(t, db.PckCertRepository().Update(&types.PckCert{QeID: "qeid2", PceID: "0000", UpdatedTime: now.Add(time.Hour)}))
+	pckCert, err := db.PckCertRepository().RetrieveLatestUpdated()
+	assert.NoError(t, err)
+	assert.Equal(t, "qeid2", pckCert.QeID)
+}
+
+func TestQEIdentityRepositoryDuplicates(t *testing.T) {
+	r := NewDatabase().QEIdentityRepository()
+	now := time.Now()
+	for i, id := range []string{"QE", "QE2", "QE1"} {
+		_, err := r.Create(&types.QEIdentity{ID: id, QeInfo: id, UpdatedTime: now.Add(time.Duration(i%2) * time.Hour)})
+		assert.NoError(t, err)
+	}
+
+	// the most recently updated record is retrieved, ties are broken by id
+	qe, err := r.Retrieve()
+	assert.NoError(t, err)
+	assert.Equal(t, "QE2", qe.ID)
+	qes, err := r.RetrieveAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"QE2", "QE", "QE1"}, []string{qes[0].ID, qes[1].ID, qes[2].ID})
+}
+
+func TestPcsAuditRecordPrune(t *testing.T) {
+	r := NewDatabase().PcsAuditRecordRepository().(*PcsAuditRecordRepository)
+	for i := 0; i < 5; i++ {
+		_, err := r.Create(&types.PcsAuditRecord{Endpoint: "tcb", Response: "response"})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, r.Prune(2))
+	records := r.RetrieveAll()
+	assert.Len(t, records, 2)
+	assert.Equal(t, uint(4), records[0].ID)
+	assert.Equal(t, uint(5), records[1].ID)
+}
+
+func TestTcbLevelRepository(t *testing.T) {
+	r := NewDatabase().TcbLevelRepository()
+	date := time.Date(2020, 5, 28, 0, 0, 0, 0, time.UTC)
+
+	err := r.Replace("20606a000000", types.TcbLevels{
+		{Fmspc: "20606a000000", Level: 1, PceSvn: 10, TcbStatus: "UpToDate", TcbDate: date},
+		{Fmspc: "20606a000000", Level: 2, PceSvn: 9, TcbStatus: "OutOfDate", TcbDate: date.AddDate(0, -2, 0)},
+	})
+	assert.NoError(t, err)
+	err = r.Replace("00906ed50000", types.TcbLevels{
+		{Fmspc: "00906ed50000", Level: 2, PceSvn: 7, TcbStatus: "OutOfDate", TcbDate: date.AddDate(-1, 0, 0)},
+		{Fmspc: "00906ed50000", Level: 1, PceSvn: 8, TcbStatus: "OutOfDate", TcbDate: date},
+	})
+	assert.NoError(t, err)
+
+	levels, err := r.RetrieveByFmspc("00906ed50000")
+	assert.NoError(t, err)
+	if assert.Len(t, levels, 2) {
+		assert.Equal(t, 1, levels[0].Level)
+		assert.Equal(t, uint16(8), levels[0].PceSvn)
+	}
+
+	// levels of a newer TcbInfo replace all the previous levels of the fmspc
+	err = r.Replace("20606a000000", types.TcbLevels{
+		{Fmspc: "20606a000000", Level: 1, PceSvn: 11, TcbStatus: "UpToDate", TcbDate: date.AddDate(0, 1, 0)},
+	})
+	assert.NoError(t, err)
+	levels, err = r.RetrieveByFmspc("20606a000000")
+	assert.NoError(t, err)
+	assert.Len(t, levels, 1)
+
+	assert.NoError(t, r.DeleteByFmspc("00906ed50000"))
+	levels, err = r.RetrieveByFmspc("00906ed50000")
+	assert.NoError(t, err)
+	assert.Empty(t, levels)
+}
+
+func TestExecuteInTransaction(t *testing.T) {
+	db := NewDatabase()
+	platform := &types.Platform{QeID: "qeid", PceID: "0000", Ppid: "ppid"}
+
+	// changes are rolled back when fn fails
+	err := db.ExecuteInTransaction(func(tx repository.SCSDatabase) error {
+		_, err := tx.PlatformRepository().Create(platform)
+		assert.NoError(t, err)
+		return errors.New("fail")
+	})
+	assert.EqualError(t, err, "fail")
+	_, err = db.PlatformRepository().Retrieve(&types.Platform{QeID: "qeid", PceID: "0000"})
+	assert.Error(t, err)
+
+	// and when fn panics
+	assert.Panics(t, func() {
+		_ = db.ExecuteInTransaction(func(tx repository.SCSDatabase) error {
+			_, err := tx.PlatformRepository().Create(platform)
+			assert.NoError(t, err)
+			panic("fail")
+		})
+	})
+	_, err = db.PlatformRepository().Retrieve(&types.Platform{QeID: "qeid", PceID: "0000"})
+	assert.Error(t, err)
+
+	err = db.ExecuteInTransaction(func(tx repository.SCSDatabase) error {
+		_, err := tx.PlatformRepository().Create(platform)
+		return err
+	})
+	assert.NoError(t, err)
+	_, err = db.PlatformRepository().Retrieve(&types.Platform{QeID: "qeid", PceID: "0000"})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package memory
+
+import (
+	"intel/isecl/scs/v5/types"
+	"time"
+)
+
+type PckCertRepository struct {
+	t *table
+}
+
+func (r *PckCertRepository) Create(p *types.PckCert) (*types.PckCert, error) {
+	if err := r.t.create(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *PckCertRepository) Retrieve(p *types.PckCert) (*types.PckCert, error) {
+	if !r.t.retrieve(p) {
+		return nil, r.t.notFound("Retrieve")
+	}
+	return p, nil
+}
+
+func (r *PckCertRepository) RetrieveByTcbLevel(p *types.PckCert) (*types.PckCert, error) {
+	found := r.t.first(p, func(record interface{}) bool {
+		pck := record.(*types.PckCert)
+		return pck.QeID == p.QeID && pck.PceID == p.PceID && pck.CPUSvn == p.CPUSvn && pck.PceSvn == p.PceSvn
+	})
+	if !found {
+		return nil, r.t.notFound("RetrieveByTcbLevel")
+	}
+	return p, nil
+}
+
+func (r *PckCertRepository) RetrieveAll() (types.PckCerts, error) {
+	var p types.PckCerts
+	r.t.all(&p, nil)
+	return p, nil
+}
+
+func (r *PckCertRepository) RetrieveLatestUpdated() (*types.PckCert, error) {
+	var pckCert types.PckCert
+	if !r.t.latest(&pckCert) {
+		return nil, r.t.notFound("RetrieveLatestUpdated")
+	}
+	return &pckCert, nil
+}
+
+func (r *PckCertRepository) RetrieveExpiringBefore(t time.Time) (types.PckCerts, error) {
+	var p types.PckCerts
+	r.t.all(&p, func(record interface{}) bool {
+		notAfter := record.(*types.PckCert).NotAfter
+		return !notAfter.IsZero() && notAfter.Before(t)
+	})
+	return p, nil
+}
+
+func (r *PckCertRepository) Update(p *types.PckCert) error {
+	return r.t.update(p)
+}
+
+func (r *PckCertRepository) Upsert(p *types.PckCert) (bool, error) {
+	update := *p
+	update.CreatedTime = time.Time{}
+	return r.t.upsert(p, &update), nil
+}
+
+func (r *PckCertRepository) Delete(p *types.PckCert) error {
+	r.t.deleteRecord(p)
+	return nil
+}
+
+func (r *PckCertRepository) DeleteByPlatform(p *types.Platform) error {
+	r.t.delete(func(record interface{}) bool {
+		pck := record.(*types.PckCert)
+		return pck.QeID == p.QeID && pck.PceID == p.PceID
+	})
+	return nil
+}
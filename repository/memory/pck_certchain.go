@@ -0,0 +1,42 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package memory
+
+import (
+	"intel/isecl/scs/v5/types"
+)
+
+type PckCertChainRepository struct {
+	t *table
+}
+
+func (r *PckCertChainRepository) Create(pcc *types.PckCertChain) (*types.PckCertChain, error) {
+	if err := r.t.create(pcc); err != nil {
+		return nil, err
+	}
+	return pcc, nil
+}
+
+func (r *PckCertChainRepository) Retrieve(pcc *types.PckCertChain) (*types.PckCertChain, error) {
+	if !r.t.retrieve(pcc) {
+		return nil, r.t.notFound("Retrieve")
+	}
+	return pcc, nil
+}
+
+func (r *PckCertChainRepository) RetrieveAll() (types.PckCertChains, error) {
+	var pccs types.PckCertChains
+	r.t.all(&pccs, nil)
+	return pccs, nil
+}
+
+func (r *PckCertChainRepository) Update(pcc *types.PckCertChain) error {
+	return r.t.update(pcc)
+}
+
+func (r *PckCertChainRepository) Delete(pcc *types.PckCertChain) error {
+	r.t.deleteRecord(pcc)
+	return nil
+}
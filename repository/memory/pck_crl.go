@@ -0,0 +1,58 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package memory
+
+import (
+	"intel/isecl/scs/v5/types"
+)
+
+type PckCrlRepository struct {
+	t *table
+}
+
+func (r *PckCrlRepository) Create(crl *types.PckCrl) (*types.PckCrl, error) {
+	if err := r.t.create(crl); err != nil {
+		return nil, err
+	}
+	return crl, nil
+}
+
+func (r *PckCrlRepository) Retrieve(crl *types.PckCrl) (*types.PckCrl, error) {
+	if !r.t.retrieve(crl) {
+		return nil, r.t.notFound("Retrieve")
+	}
+	return crl, nil
+}
+
+func (r *PckCrlRepository) RetrieveAll() (types.PckCrls, error) {
+	var crls types.PckCrls
+	r.t.all(&crls, nil)
+	return crls, nil
+}
+
+func (r *PckCrlRepository) RetrievePaginated(page types.Pagination) (types.PckCrls, error) {
+	var crls types.PckCrls
+	if err := r.t.paginate(&crls, page, nil); err != nil {
+		return nil, err
+	}
+	return crls, nil
+}
+
+func (r *PckCrlRepository) RetrieveLatestUpdated() (*types.PckCrl, error) {
+	var crl types.PckCrl
+	if !r.t.latest(&crl) {
+		return nil, r.t.notFound("RetrieveLatestUpdated")
+	}
+	return &crl, nil
+}
+
+func (r *PckCrlRepository) Update(crl *types.PckCrl) error {
+	return r.t.update(crl)
+}
+
+func (r *PckCrlRepository) Delete(crl *types.PckCrl) error {
+	r.t.deleteRecord(crl)
+	return nil
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package memory
+
+import (
+	"intel/isecl/scs/v5/types"
+	"sync"
+)
+
+// pcsAuditTable assigns ids to audit records the way a serial primary key does
+type pcsAuditTable struct {
+	*table
+	idMu   sync.Mutex
+	nextID uint
+}
+
+type PcsAuditRecordRepository struct {
+	t *pcsAuditTable
+}
+
+func (r *PcsAuditRecordRepository) Create(record *types.PcsAuditRecord) (*types.PcsAuditRecord, error) {
+	r.t.idMu.Lock()
+	r.t.nextID++
+	record.ID = r.t.nextID
+	r.t.idMu.Unlock()
+
+	if err := r.t.create(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// RetrieveAll returns the retained audit records, it is not part of the repository interface
+func (r *PcsAuditRecordRepository) RetrieveAll() []types.PcsAuditRecord {
+	var records []types.PcsAuditRecord
+	r.t.all(&records, nil)
+	return records
+}
+
+func (r *PcsAuditRecordRepository) Prune(maxRecords int) error {
+	records := r.RetrieveAll()
+	if len(records) <= maxRecords {
+		return nil
+	}
+	retained := make(map[uint]bool)
+	for i := len(records) - 1; i >= 0 && len(retained) < maxRecords; i-- {
+		retained[records[i].ID] = true
+	}
+	r.t.delete(func(record interface{}) bool {
+		return !retained[record.(*types.PcsAuditRecord).ID]
+	})
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package memory
+
+import (
+	"intel/isecl/scs/v5/types"
+	"time"
+)
+
+type PlatformRepository struct {
+	t *table
+}
+
+func (r *PlatformRepository) Create(p *types.Platform) (*types.Platform, error) {
+	if err := r.t.create(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *PlatformRepository) Retrieve(p *types.Platform) (*types.Platform, error) {
+	if !r.t.retrieve(p) {
+		return nil, r.t.notFound("Retrieve")
+	}
+	return p, nil
+}
+
+func (r *PlatformRepository) RetrieveAll() (types.Platforms, error) {
+	var p types.Platforms
+	r.t.all(&p, nil)
+	return p, nil
+}
+
+func (r *PlatformRepository) RetrievePaginated(page types.Pagination) (types.Platforms, error) {
+	var p types.Platforms
+	if err := r.t.paginate(&p, page, nil); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *PlatformRepository) RetrieveByPceID(pceID string, page types.Pagination) (types.Platforms, error) {
+	var p types.Platforms
+	err := r.t.paginate(&p, page, func(record interface{}) bool {
+		return record.(*types.Platform).PceID == pceID
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *PlatformRepository) RetrieveByFmspc(fmspc string) (types.Platforms, error) {
+	var p types.Platforms
+	r.t.all(&p, func(record interface{}) bool {
+		return record.(*types.Platform).Fmspc == fmspc
+	})
+	return p, nil
+}
+
+func (r *PlatformRepository) RetrieveNotUpdatedSince(t time.Time) (types.Platforms, error) {
+	var p types.Platforms
+	r.t.all(&p, func(record interface{}) bool {
+		return record.(*types.Platform).UpdatedTime.Before(t)
+	})
+	return p, nil
+}
+
+func (r *PlatformRepository) Update(p *types.Platform) error {
+	return r.t.update(p)
+}
+
+func (r *PlatformRepository) Upsert(p *types.Platform) (bool, error) {
+	update := *p
+	update.CreatedTime = time.Time{}
+	return r.t.upsert(p, &update), nil
+}
+
+func (r *PlatformRepository) Delete(p *types.Platform) error {
+	r.t.deleteRecord(p)
+	return nil
+}
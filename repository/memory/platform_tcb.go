@@ -0,0 +1,49 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package memory
+
+import (
+	"intel/isecl/scs/v5/types"
+	"time"
+)
+
+type PlatformTcbRepository struct {
+	t *table
+}
+
+func (r *PlatformTcbRepository) Create(p *types.PlatformTcb) (*types.PlatformTcb, error) {
+	if err := r.t.create(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *PlatformTcbRepository) Retrieve(p *types.PlatformTcb) (*types.PlatformTcb, error) {
+	if !r.t.retrieve(p) {
+		return nil, r.t.notFound("Retrieve")
+	}
+	return p, nil
+}
+
+func (r *PlatformTcbRepository) RetrieveAll() (types.PlatformTcbs, error) {
+	var p types.PlatformTcbs
+	r.t.all(&p, nil)
+	return p, nil
+}
+
+func (r *PlatformTcbRepository) Update(p *types.PlatformTcb) error {
+	return r.t.update(p)
+}
+
+func (r *PlatformTcbRepository) Upsert(p *types.PlatformTcb) (bool, error) {
+	update := *p
+	update.CreatedTime = time.Time{}
+	return r.t.upsert(p, &update), nil
+}
+
+func (r *PlatformTcbRepository) Delete(p *types.PlatformTcb) error {
+	r.t.deleteRecord(p)
+	return nil
+}
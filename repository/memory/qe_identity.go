@@ -0,0 +1,52 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package memory
+
+import (
+	"intel/isecl/scs/v5/types"
+	"sort"
+)
+
+type QEIdentityRepository struct {
+	t *table
+}
+
+func (r *QEIdentityRepository) Create(qe *types.QEIdentity) (*types.QEIdentity, error) {
+	if err := r.t.create(qe); err != nil {
+		return nil, err
+	}
+	return qe, nil
+}
+
+func (r *QEIdentityRepository) Retrieve() (*types.QEIdentity, error) {
+	qes, _ := r.RetrieveAll()
+	if len(qes) == 0 {
+		return nil, r.t.notFound("Retrieve")
+	}
+	return &qes[0], nil
+}
+
+// RetrieveAll returns the qe identities ordered the same way as the postgres repository,
+// most recently updated first
+func (r *QEIdentityRepository) RetrieveAll() (types.QEIdentities, error) {
+	var qes types.QEIdentities
+	r.t.all(&qes, nil)
+	sort.SliceStable(qes, func(i, j int) bool {
+		if !qes[i].UpdatedTime.Equal(qes[j].UpdatedTime) {
+			return qes[i].UpdatedTime.After(qes[j].UpdatedTime)
+		}
+		return qes[i].ID < qes[j].ID
+	})
+	return qes, nil
+}
+
+func (r *QEIdentityRepository) Update(qe *types.QEIdentity) error {
+	return r.t.update(qe)
+}
+
+func (r *QEIdentityRepository) Delete(qe *types.QEIdentity) error {
+	r.t.deleteRecord(qe)
+	return nil
+}
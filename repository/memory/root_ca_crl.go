@@ -0,0 +1,37 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package memory
+
+import (
+	"intel/isecl/scs/v5/types"
+)
+
+type RootCaCrlRepository struct {
+	t *table
+}
+
+func (r *RootCaCrlRepository) Create(crl *types.RootCaCrl) (*types.RootCaCrl, error) {
+	if err := r.t.create(crl); err != nil {
+		return nil, err
+	}
+	return crl, nil
+}
+
+func (r *RootCaCrlRepository) Retrieve() (*types.RootCaCrl, error) {
+	var crl types.RootCaCrl
+	if !r.t.retrieve(&crl) {
+		return nil, r.t.notFound("Retrieve")
+	}
+	return &crl, nil
+}
+
+func (r *RootCaCrlRepository) Update(crl *types.RootCaCrl) error {
+	return r.t.update(crl)
+}
+
+func (r *RootCaCrlRepository) Delete(crl *types.RootCaCrl) error {
+	r.t.deleteRecord(crl)
+	return nil
+}
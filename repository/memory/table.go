@@ -0,0 +1,292 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package memory
+
+import (
+	"fmt"
+	"intel/isecl/scs/v5/repository"
+	"intel/isecl/scs/v5/types"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// table keeps the records of one type in insertion order. Records are identified by the fields
+// tagged as gorm primary_key and are matched the same way as gorm matches a struct condition,
+// so that repositories behave like their postgres counterparts. Stored records are never
+// modified in place, an update replaces the record so that snapshots stay consistent
+type table struct {
+	name    string
+	mu      sync.Mutex
+	records []interface{}
+}
+
+func newTable(name string) *table {
+	return &table{name: name}
+}
+
+// primaryKeyFields returns the indexes of primary key fields of a struct type
+func primaryKeyFields(t reflect.Type) []int {
+	var fields []int
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Contains(t.Field(i).Tag.Get("gorm"), "primary_key") {
+			fields = append(fields, i)
+		}
+	}
+	return fields
+}
+
+func copyRecord(record interface{}) interface{} {
+	v := reflect.ValueOf(record).Elem()
+	c := reflect.New(v.Type())
+	c.Elem().Set(v)
+	return c.Interface()
+}
+
+func samePrimaryKey(a, b interface{}) bool {
+	va, vb := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+	for _, i := range primaryKeyFields(va.Type()) {
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesNonZero reports whether record has the same value for every non zero field of query
+func matchesNonZero(record, query interface{}) bool {
+	vr, vq := reflect.ValueOf(record).Elem(), reflect.ValueOf(query).Elem()
+	for i := 0; i < vq.NumField(); i++ {
+		if vq.Field(i).IsZero() {
+			continue
+		}
+		if !reflect.DeepEqual(vr.Field(i).Interface(), vq.Field(i).Interface()) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *table) notFound(op string) error {
+	return errors.Wrapf(repository.ErrNotFound, "%s: failed to retrieve a record from %s table", op, t.name)
+}
+
+func (t *table) create(record interface{}) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, r := range t.records {
+		if samePrimaryKey(r, record) {
+			return errors.Wrapf(repository.ErrAlreadyExists, "Create: failed to create a record in %s table", t.name)
+		}
+	}
+	t.records = append(t.records, copyRecord(record))
+	return nil
+}
+
+// first copies the first record for which match returns true into dst
+func (t *table) first(dst interface{}, match func(interface{}) bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, r := range t.records {
+		if match(r) {
+			reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(r).Elem())
+			return true
+		}
+	}
+	return false
+}
+
+// retrieve copies the first record matching the non zero fields of query into query
+func (t *table) retrieve(query interface{}) bool {
+	q := copyRecord(query)
+	return t.first(query, func(r interface{}) bool {
+		return matchesNonZero(r, q)
+	})
+}
+
+// all appends copies of the records for which match returns true to the slice pointed by dst
+func (t *table) all(dst interface{}, match func(interface{}) bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := reflect.ValueOf(dst).Elem()
+	for _, r := range t.records {
+		if match == nil || match(r) {
+			out.Set(reflect.Append(out, reflect.ValueOf(r).Elem()))
+		}
+	}
+}
+
+// latest copies the most recently updated record into dst, records updated at the same time are
+// picked by primary key the way gorm First does
+func (t *table) latest(dst interface{}) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var latest reflect.Value
+	for _, r := range t.records {
+		v := reflect.ValueOf(r).Elem()
+		if !latest.IsValid() {
+			latest = v
+			continue
+		}
+		updated := v.FieldByName("UpdatedTime").Interface().(time.Time)
+		latestUpdated := latest.FieldByName("UpdatedTime").Interface().(time.Time)
+		if updated.After(latestUpdated) || (updated.Equal(latestUpdated) && lessPrimaryKey(v, latest)) {
+			latest = v
+		}
+	}
+	if !latest.IsValid() {
+		return false
+	}
+	reflect.ValueOf(dst).Elem().Set(latest)
+	return true
+}
+
+// lessPrimaryKey orders records by the values of their primary key fields
+func lessPrimaryKey(a, b reflect.Value) bool {
+	for _, i := range primaryKeyFields(a.Type()) {
+		ka, kb := fmt.Sprint(a.Field(i).Interface()), fmt.Sprint(b.Field(i).Interface())
+		if ka != kb {
+			return ka < kb
+		}
+	}
+	return false
+}
+
+// paginate appends a page of the records for which match returns true to the slice pointed by
+// dst, ordered the same way as the postgres repositories order a page
+func (t *table) paginate(dst interface{}, page types.Pagination, match func(interface{}) bool) error {
+	if page.Limit <= 0 || page.Offset < 0 {
+		return errors.New("paginate: limit should be positive and offset should not be negative")
+	}
+
+	var orderField string
+	switch page.OrderBy {
+	case "":
+	case types.OrderByCreatedTime:
+		orderField = "CreatedTime"
+	case types.OrderByUpdatedTime:
+		orderField = "UpdatedTime"
+	default:
+		return errors.Errorf("paginate: records cannot be ordered by %s", page.OrderBy)
+	}
+
+	var records []interface{}
+	t.mu.Lock()
+	for _, r := range t.records {
+		if match == nil || match(r) {
+			records = append(records, r)
+		}
+	}
+	t.mu.Unlock()
+
+	less := func(a, b interface{}) bool {
+		va, vb := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+		if orderField != "" {
+			ta := va.FieldByName(orderField).Interface().(time.Time)
+			tb := vb.FieldByName(orderField).Interface().(time.Time)
+			if !ta.Equal(tb) {
+				return ta.Before(tb)
+			}
+		}
+		return lessPrimaryKey(va, vb)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if page.Descending {
+			return less(records[j], records[i])
+		}
+		return less(records[i], records[j])
+	})
+
+	out := reflect.ValueOf(dst).Elem()
+	for i := page.Offset; i < len(records) && i < page.Offset+page.Limit; i++ {
+		out.Set(reflect.Append(out, reflect.ValueOf(records[i]).Elem()))
+	}
+	return nil
+}
+
+// withNonZeroFields returns a copy of stored with the non zero fields of record set on it
+func withNonZeroFields(stored, record interface{}) interface{} {
+	updated := copyRecord(stored)
+	vu, vr := reflect.ValueOf(updated).Elem(), reflect.ValueOf(record).Elem()
+	for i := 0; i < vr.NumField(); i++ {
+		if !vr.Field(i).IsZero() {
+			vu.Field(i).Set(vr.Field(i))
+		}
+	}
+	return updated
+}
+
+// update sets the non zero fields of record on the stored record with the same primary key,
+// the way gorm Updates does with a struct
+func (t *table) update(record interface{}) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for n, r := range t.records {
+		if samePrimaryKey(r, record) {
+			t.records[n] = withNonZeroFields(r, record)
+			return nil
+		}
+	}
+	return errors.New("Update: - no rows affected")
+}
+
+// upsert creates record, or sets the non zero fields of update on the stored record with the same
+// primary key when one exists, in one step the way an insert on conflict does. It returns whether
+// record was created
+func (t *table) upsert(record, update interface{}) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for n, r := range t.records {
+		if samePrimaryKey(r, record) {
+			t.records[n] = withNonZeroFields(r, update)
+			return false
+		}
+	}
+	t.records = append(t.records, copyRecord(record))
+	return true
+}
+
+// delete removes the records for which match returns true
+func (t *table) delete(match func(interface{}) bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var remaining []interface{}
+	for _, r := range t.records {
+		if !match(r) {
+			remaining = append(remaining, r)
+		}
+	}
+	t.records = remaining
+}
+
+// deleteRecord removes the record with the same primary key as record
+func (t *table) deleteRecord(record interface{}) {
+	t.delete(func(r interface{}) bool {
+		return samePrimaryKey(r, record)
+	})
+}
+
+func (t *table) snapshot() []interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]interface{}{}, t.records...)
+}
+
+func (t *table) restore(records []interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.records = records
+}
@@ -0,0 +1,44 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package memory
+
+import (
+	"intel/isecl/scs/v5/types"
+	"sort"
+)
+
+type TcbLevelRepository struct {
+	t *table
+}
+
+func (r *TcbLevelRepository) Replace(fmspc string, levels types.TcbLevels) error {
+	r.t.delete(func(record interface{}) bool {
+		return record.(*types.TcbLevel).Fmspc == fmspc
+	})
+	for i := range levels {
+		if err := r.t.create(&levels[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *TcbLevelRepository) RetrieveByFmspc(fmspc string) (types.TcbLevels, error) {
+	var levels types.TcbLevels
+	r.t.all(&levels, func(record interface{}) bool {
+		return record.(*types.TcbLevel).Fmspc == fmspc
+	})
+	sort.SliceStable(levels, func(i, j int) bool {
+		return levels[i].Level < levels[j].Level
+	})
+	return levels, nil
+}
+
+func (r *TcbLevelRepository) DeleteByFmspc(fmspc string) error {
+	r.t.delete(func(record interface{}) bool {
+		return record.(*types.TcbLevel).Fmspc == fmspc
+	})
+	return nil
+}
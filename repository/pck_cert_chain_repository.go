@@ -9,6 +9,7 @@ import "intel/isecl/scs/v5/types"
 type PckCertChainRepository interface {
 	Create(*types.PckCertChain) (*types.PckCertChain, error)
 	Retrieve(*types.PckCertChain) (*types.PckCertChain, error)
+	RetrieveAll() (types.PckCertChains, error)
 	Update(*types.PckCertChain) error
 	Delete(*types.PckCertChain) error
 }
@@ -4,12 +4,20 @@
  */
 package repository
 
-import "intel/isecl/scs/v5/types"
+import (
+	"intel/isecl/scs/v5/types"
+	"time"
+)
 
 type PckCertRepository interface {
 	Create(*types.PckCert) (*types.PckCert, error)
 	Retrieve(*types.PckCert) (*types.PckCert, error)
+	RetrieveByTcbLevel(*types.PckCert) (*types.PckCert, error)
 	RetrieveAll() (types.PckCerts, error)
+	RetrieveLatestUpdated() (*types.PckCert, error)
+	RetrieveExpiringBefore(time.Time) (types.PckCerts, error)
 	Update(*types.PckCert) error
+	Upsert(*types.PckCert) (bool, error)
 	Delete(*types.PckCert) error
+	DeleteByPlatform(*types.Platform) error
 }
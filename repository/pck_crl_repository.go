@@ -10,6 +10,8 @@ type PckCrlRepository interface {
 	Create(*types.PckCrl) (*types.PckCrl, error)
 	Retrieve(*types.PckCrl) (*types.PckCrl, error)
 	RetrieveAll() (types.PckCrls, error)
+	RetrievePaginated(types.Pagination) (types.PckCrls, error)
+	RetrieveLatestUpdated() (*types.PckCrl, error)
 	Update(*types.PckCrl) error
 	Delete(*types.PckCrl) error
 }
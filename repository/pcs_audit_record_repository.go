@@ -0,0 +1,12 @@
+/*
+ * Copyright (C) 2020 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package repository
+
+import "intel/isecl/scs/v5/types"
+
+type PcsAuditRecordRepository interface {
+	Create(*types.PcsAuditRecord) (*types.PcsAuditRecord, error)
+	Prune(maxRecords int) error
+}
@@ -11,5 +11,6 @@ type PlatformTcbRepository interface {
 	Retrieve(*types.PlatformTcb) (*types.PlatformTcb, error)
 	RetrieveAll() (types.PlatformTcbs, error)
 	Update(*types.PlatformTcb) error
+	Upsert(*types.PlatformTcb) (bool, error)
 	Delete(*types.PlatformTcb) error
 }
@@ -4,12 +4,20 @@
  */
 package repository
 
-import "intel/isecl/scs/v5/types"
+import (
+	"intel/isecl/scs/v5/types"
+	"time"
+)
 
 type PlatformRepository interface {
 	Create(*types.Platform) (*types.Platform, error)
 	Retrieve(*types.Platform) (*types.Platform, error)
 	RetrieveAll() (types.Platforms, error)
+	RetrievePaginated(types.Pagination) (types.Platforms, error)
+	RetrieveByPceID(pceID string, page types.Pagination) (types.Platforms, error)
+	RetrieveByFmspc(fmspc string) (types.Platforms, error)
+	RetrieveNotUpdatedSince(time.Time) (types.Platforms, error)
 	Update(*types.Platform) error
+	Upsert(*types.Platform) (bool, error)
 	Delete(*types.Platform) error
 }
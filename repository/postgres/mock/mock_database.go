@@ -4,23 +4,34 @@
  */
 package mock
 
-import "intel/isecl/scs/v5/repository"
+import (
+	"errors"
+	"intel/isecl/scs/v5/repository"
+	"intel/isecl/scs/v5/types"
+)
 
 type MockDatabase struct {
-	MockPlatformRepository     repository.PlatformRepository
-	MockPlatformTcbRepository  repository.PlatformTcbRepository
-	MockFmspcTcbInfoRepository repository.FmspcTcbInfoRepository
-	MockPckCertChainRepository repository.PckCertChainRepository
-	MockPckCertRepository      repository.PckCertRepository
-	MockPckCrlRepository       repository.PckCrlRepository
-	MockLastRefreshRepository  repository.LastRefreshRepository
-	MockQEIdentityRepository   repository.QEIdentityRepository
+	MockPlatformRepository       repository.PlatformRepository
+	MockPlatformTcbRepository    repository.PlatformTcbRepository
+	MockFmspcTcbInfoRepository   repository.FmspcTcbInfoRepository
+	MockPckCertChainRepository   repository.PckCertChainRepository
+	MockPckCertRepository        repository.PckCertRepository
+	MockPckCrlRepository         repository.PckCrlRepository
+	MockLastRefreshRepository    repository.LastRefreshRepository
+	MockQEIdentityRepository     repository.QEIdentityRepository
+	MockRootCaCrlRepository      repository.RootCaCrlRepository
+	MockPcsAuditRecordRepository repository.PcsAuditRecordRepository
+	MockTcbLevelRepository       repository.TcbLevelRepository
 }
 
 func (pd *MockDatabase) Migrate() error {
 	return nil
 }
 
+func (pd *MockDatabase) Ping() error {
+	return nil
+}
+
 func (pd *MockDatabase) PlatformRepository() repository.PlatformRepository {
 	return pd.MockPlatformRepository
 }
@@ -53,5 +64,39 @@ func (pd *MockDatabase) QEIdentityRepository() repository.QEIdentityRepository {
 	return pd.MockQEIdentityRepository
 }
 
+func (pd *MockDatabase) RootCaCrlRepository() repository.RootCaCrlRepository {
+	return pd.MockRootCaCrlRepository
+}
+
+func (pd *MockDatabase) PcsAuditRecordRepository() repository.PcsAuditRecordRepository {
+	return pd.MockPcsAuditRecordRepository
+}
+
+func (pd *MockDatabase) TcbLevelRepository() repository.TcbLevelRepository {
+	return pd.MockTcbLevelRepository
+}
+
+// ExecuteInTransaction runs fn directly on the mock repositories, changes made before
+// fn fails are not rolled back
+func (pd *MockDatabase) ExecuteInTransaction(fn func(repository.SCSDatabase) error) error {
+	return fn(pd)
+}
+
 func (pd *MockDatabase) Close() {
 }
+
+// pageBounds returns the bounds of a page out of count records kept in insertion order
+func pageBounds(count int, page types.Pagination) (int, int, error) {
+	if page.Limit <= 0 || page.Offset < 0 {
+		return 0, 0, errors.New("invalid pagination")
+	}
+	start := page.Offset
+	if start > count {
+		start = count
+	}
+	end := start + page.Limit
+	if end > count {
+		end = count
+	}
+	return start, end, nil
+}
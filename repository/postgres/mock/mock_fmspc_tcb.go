@@ -23,13 +23,14 @@ func (r *MockFmspcTcbInfoRepository) Create(tcb *types.FmspcTcbInfo) (*types.Fms
 	if r.FmspcTcbInfo != nil {
 		for _, thisFmspc := range r.FmspcTcbInfo {
 			if thisFmspc.Fmspc == tcb.Fmspc {
-				return nil, errors.New("already exists")
+				return nil, repository.ErrAlreadyExists
 			}
 		}
 	}
 	tcbInfo := &types.FmspcTcbInfo{
 		Fmspc:       tcb.Fmspc,
 		TcbInfo:     tcb.TcbInfo,
+		TcbUpdate:   tcb.TcbUpdate,
 		CreatedTime: time.Now(),
 		UpdatedTime: time.Now().Add(2 * time.Hour),
 	}
@@ -44,7 +45,7 @@ func (r *MockFmspcTcbInfoRepository) Retrieve(tcb *types.FmspcTcbInfo) (*types.F
 			return tcbInfo, nil
 		}
 	}
-	return nil, errors.New("no records found")
+	return nil, repository.ErrNotFound
 }
 
 func (r *MockFmspcTcbInfoRepository) RetrieveAll() (types.FmspcTcbInfos, error) {
@@ -55,6 +56,31 @@ func (r *MockFmspcTcbInfoRepository) RetrieveAll() (types.FmspcTcbInfos, error)
 	return fmspcTcbInfos, nil
 }
 
+func (r *MockFmspcTcbInfoRepository) RetrievePaginated(page types.Pagination) (types.FmspcTcbInfos, error) {
+	start, end, err := pageBounds(len(r.FmspcTcbInfo), page)
+	if err != nil {
+		return nil, err
+	}
+	var records types.FmspcTcbInfos
+	for _, record := range r.FmspcTcbInfo[start:end] {
+		records = append(records, *record)
+	}
+	return records, nil
+}
+
+func (r *MockFmspcTcbInfoRepository) RetrieveLatestUpdated() (*types.FmspcTcbInfo, error) {
+	var latest *types.FmspcTcbInfo
+	for _, record := range r.FmspcTcbInfo {
+		if latest == nil || record.UpdatedTime.After(latest.UpdatedTime) {
+			latest = record
+		}
+	}
+	if latest == nil {
+		return nil, repository.ErrNotFound
+	}
+	return latest, nil
+}
+
 func (r *MockFmspcTcbInfoRepository) Update(tcb *types.FmspcTcbInfo) error {
 	if tcb.Fmspc == "" {
 		return errors.New("updated failed due to missing field")
@@ -22,18 +22,22 @@ func NewMockPckCertRepository() repository.PckCertRepository {
 func (r *MockPckCertRepository) Create(u *types.PckCert) (*types.PckCert, error) {
 	if r.PckCerts != nil {
 		for _, pckCert := range r.PckCerts {
-			if u.QeID == pckCert.QeID {
-				return nil, errors.New("pckCert already exists")
+			if u.QeID == pckCert.QeID && u.PceID == pckCert.PceID &&
+				u.CPUSvn == pckCert.CPUSvn && u.PceSvn == pckCert.PceSvn {
+				return nil, repository.ErrAlreadyExists
 			}
 		}
 	}
 	newPckCert := &types.PckCert{
 		QeID:        u.QeID,
 		PceID:       u.PceID,
+		CPUSvn:      u.CPUSvn,
+		PceSvn:      u.PceSvn,
 		CertIndex:   u.CertIndex,
 		Tcbms:       u.Tcbms,
 		Fmspc:       u.Fmspc,
 		PckCerts:    u.PckCerts,
+		NotAfter:    u.NotAfter,
 		CreatedTime: time.Now(),
 		UpdatedTime: time.Now().Add(2 * time.Hour),
 	}
@@ -43,17 +47,50 @@ func (r *MockPckCertRepository) Create(u *types.PckCert) (*types.PckCert, error)
 
 func (r *MockPckCertRepository) Retrieve(pckcert *types.PckCert) (*types.PckCert, error) {
 	for _, pck := range r.PckCerts {
-		if pck.QeID == pckcert.QeID || pck.PceID == pckcert.PceID {
+		if pck.QeID == pckcert.QeID && pck.PceID == pckcert.PceID {
 			return pck, nil
 		}
 	}
-	return nil, errors.New("no records found")
+	return nil, repository.ErrNotFound
+}
+
+func (r *MockPckCertRepository) RetrieveByTcbLevel(pckcert *types.PckCert) (*types.PckCert, error) {
+	for _, pck := range r.PckCerts {
+		if pck.QeID == pckcert.QeID && pck.PceID == pckcert.PceID &&
+			pck.CPUSvn == pckcert.CPUSvn && pck.PceSvn == pckcert.PceSvn {
+			return pck, nil
+		}
+	}
+	return nil, repository.ErrNotFound
 }
 
 func (r *MockPckCertRepository) RetrieveAll() (types.PckCerts, error) {
 	return nil, nil
 }
 
+func (r *MockPckCertRepository) RetrieveLatestUpdated() (*types.PckCert, error) {
+	var latest *types.PckCert
+	for _, record := range r.PckCerts {
+		if latest == nil || record.UpdatedTime.After(latest.UpdatedTime) {
+			latest = record
+		}
+	}
+	if latest == nil {
+		return nil, repository.ErrNotFound
+	}
+	return latest, nil
+}
+
+func (r *MockPckCertRepository) RetrieveExpiringBefore(t time.Time) (types.PckCerts, error) {
+	var records types.PckCerts
+	for _, pckCert := range r.PckCerts {
+		if !pckCert.NotAfter.IsZero() && pckCert.NotAfter.Before(t) {
+			records = append(records, *pckCert)
+		}
+	}
+	return records, nil
+}
+
 func (r *MockPckCertRepository) Update(p *types.PckCert) error {
 	if p.QeID == "" && p.PceID == "" {
 		return errors.New("updated failed due to missing field")
@@ -61,6 +98,24 @@ func (r *MockPckCertRepository) Update(p *types.PckCert) error {
 	return nil
 }
 
+func (r *MockPckCertRepository) Upsert(p *types.PckCert) (bool, error) {
+	if _, err := r.Create(p); err == nil {
+		return true, nil
+	}
+	return false, r.Update(p)
+}
+
 func (r *MockPckCertRepository) Delete(p *types.PckCert) error {
 	return nil
 }
+
+func (r *MockPckCertRepository) DeleteByPlatform(p *types.Platform) error {
+	var remaining []*types.PckCert
+	for _, pck := range r.PckCerts {
+		if pck.QeID != p.QeID || pck.PceID != p.PceID {
+			remaining = append(remaining, pck)
+		}
+	}
+	r.PckCerts = remaining
+	return nil
+}
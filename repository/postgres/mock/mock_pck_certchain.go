@@ -23,7 +23,7 @@ func (r *MockPckCertChainRepository) Create(pcc *types.PckCertChain) (*types.Pck
 	if r.CertChains != nil {
 		for _, certChain := range r.CertChains {
 			if pcc.Ca == certChain.Ca {
-				return nil, errors.New("cert chain already exists")
+				return nil, repository.ErrAlreadyExists
 			}
 		}
 	}
@@ -43,7 +43,15 @@ func (r *MockPckCertChainRepository) Retrieve(pcc *types.PckCertChain) (*types.P
 			return certChain, nil
 		}
 	}
-	return nil, errors.New("no records found")
+	return nil, repository.ErrNotFound
+}
+
+func (r *MockPckCertChainRepository) RetrieveAll() (types.PckCertChains, error) {
+	var certChains types.PckCertChains
+	for _, certChain := range r.CertChains {
+		certChains = append(certChains, *certChain)
+	}
+	return certChains, nil
 }
 
 func (r *MockPckCertChainRepository) Update(pcc *types.PckCertChain) error {
@@ -23,13 +23,16 @@ func (r *MockPckCrlRepository) Create(crl *types.PckCrl) (*types.PckCrl, error)
 	if r.PckCrls != nil {
 		for _, thisCrl := range r.PckCrls {
 			if thisCrl.Ca == crl.Ca {
-				return nil, errors.New("already exists")
+				return nil, repository.ErrAlreadyExists
 			}
 		}
 	}
 	pckCrl := &types.PckCrl{
 		Ca:              crl.Ca,
 		PckCrlCertChain: crl.PckCrlCertChain,
+		PckCrl:          crl.PckCrl,
+		ThisUpdate:      crl.ThisUpdate,
+		NextUpdate:      crl.NextUpdate,
 		CreatedTime:     time.Now(),
 		UpdatedTime:     time.Now().Add(2 * time.Hour),
 	}
@@ -43,7 +46,7 @@ func (r *MockPckCrlRepository) Retrieve(crl *types.PckCrl) (*types.PckCrl, error
 			return thisCrl, nil
 		}
 	}
-	return nil, errors.New("no records found")
+	return nil, repository.ErrNotFound
 }
 
 func (r *MockPckCrlRepository) RetrieveAll() (types.PckCrls, error) {
@@ -54,10 +57,44 @@ func (r *MockPckCrlRepository) RetrieveAll() (types.PckCrls, error) {
 	return pckCrls, nil
 }
 
+func (r *MockPckCrlRepository) RetrievePaginated(page types.Pagination) (types.PckCrls, error) {
+	start, end, err := pageBounds(len(r.PckCrls), page)
+	if err != nil {
+		return nil, err
+	}
+	var records types.PckCrls
+	for _, record := range r.PckCrls[start:end] {
+		records = append(records, *record)
+	}
+	return records, nil
+}
+
+func (r *MockPckCrlRepository) RetrieveLatestUpdated() (*types.PckCrl, error) {
+	var latest *types.PckCrl
+	for _, record := range r.PckCrls {
+		if latest == nil || record.UpdatedTime.After(latest.UpdatedTime) {
+			latest = record
+		}
+	}
+	if latest == nil {
+		return nil, repository.ErrNotFound
+	}
+	return latest, nil
+}
+
 func (r *MockPckCrlRepository) Update(crl *types.PckCrl) error {
 	if crl.Ca == "" && crl.PckCrlCertChain == "" {
 		return errors.New("update failed")
 	}
+	for _, thisCrl := range r.PckCrls {
+		if thisCrl.Ca == crl.Ca {
+			thisCrl.PckCrlCertChain = crl.PckCrlCertChain
+			thisCrl.PckCrl = crl.PckCrl
+			thisCrl.ThisUpdate = crl.ThisUpdate
+			thisCrl.NextUpdate = crl.NextUpdate
+			thisCrl.UpdatedTime = crl.UpdatedTime
+		}
+	}
 	return nil
 }
 
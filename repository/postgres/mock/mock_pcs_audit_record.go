@@ -0,0 +1,51 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package mock
+
+import (
+	"intel/isecl/scs/v5/repository"
+	"intel/isecl/scs/v5/types"
+	"sync"
+	"time"
+)
+
+type MockPcsAuditRecordRepository struct {
+	mu      sync.Mutex
+	Records []*types.PcsAuditRecord
+}
+
+func NewMockPcsAuditRecordRepository() repository.PcsAuditRecordRepository {
+	return &MockPcsAuditRecordRepository{}
+}
+
+func (r *MockPcsAuditRecordRepository) Create(record *types.PcsAuditRecord) (*types.PcsAuditRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	newRecord := *record
+	newRecord.ID = uint(len(r.Records) + 1)
+	newRecord.CreatedTime = time.Now()
+	r.Records = append(r.Records, &newRecord)
+	return &newRecord, nil
+}
+
+func (r *MockPcsAuditRecordRepository) Prune(maxRecords int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.Records) > maxRecords {
+		r.Records = r.Records[len(r.Records)-maxRecords:]
+	}
+	return nil
+}
+
+// RetrieveAll returns a copy of the records held, safe to call while records are being created
+func (r *MockPcsAuditRecordRepository) RetrieveAll() []types.PcsAuditRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var records []types.PcsAuditRecord
+	for _, record := range r.Records {
+		records = append(records, *record)
+	}
+	return records
+}
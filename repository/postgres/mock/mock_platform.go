@@ -23,7 +23,7 @@ func (r *MockPlatformRepository) Create(p *types.Platform) (*types.Platform, err
 	if r.Platforms != nil {
 		for _, platform := range r.Platforms {
 			if p.QeID == platform.QeID && p.PceID == platform.PceID {
-				return nil, errors.New("cert chain already exists")
+				return nil, repository.ErrAlreadyExists
 			}
 		}
 	}
@@ -53,7 +53,7 @@ func (r *MockPlatformRepository) Retrieve(p *types.Platform) (*types.Platform, e
 			return platform, nil
 		}
 	}
-	return nil, errors.New("no records found")
+	return nil, repository.ErrNotFound
 }
 
 func (r *MockPlatformRepository) RetrieveAll() (types.Platforms, error) {
@@ -64,6 +64,56 @@ func (r *MockPlatformRepository) RetrieveAll() (types.Platforms, error) {
 	return thisPlatforms, nil
 }
 
+func (r *MockPlatformRepository) RetrievePaginated(page types.Pagination) (types.Platforms, error) {
+	start, end, err := pageBounds(len(r.Platforms), page)
+	if err != nil {
+		return nil, err
+	}
+	var records types.Platforms
+	for _, record := range r.Platforms[start:end] {
+		records = append(records, *record)
+	}
+	return records, nil
+}
+
+func (r *MockPlatformRepository) RetrieveByPceID(pceID string, page types.Pagination) (types.Platforms, error) {
+	var matching []*types.Platform
+	for _, platform := range r.Platforms {
+		if platform.PceID == pceID {
+			matching = append(matching, platform)
+		}
+	}
+	start, end, err := pageBounds(len(matching), page)
+	if err != nil {
+		return nil, err
+	}
+	var records types.Platforms
+	for _, record := range matching[start:end] {
+		records = append(records, *record)
+	}
+	return records, nil
+}
+
+func (r *MockPlatformRepository) RetrieveByFmspc(fmspc string) (types.Platforms, error) {
+	var records types.Platforms
+	for _, platform := range r.Platforms {
+		if platform.Fmspc == fmspc {
+			records = append(records, *platform)
+		}
+	}
+	return records, nil
+}
+
+func (r *MockPlatformRepository) RetrieveNotUpdatedSince(t time.Time) (types.Platforms, error) {
+	var records types.Platforms
+	for _, platform := range r.Platforms {
+		if platform.UpdatedTime.Before(t) {
+			records = append(records, *platform)
+		}
+	}
+	return records, nil
+}
+
 func (r *MockPlatformRepository) Update(p *types.Platform) error {
 	if p.QeID == "" && p.PceID == "" {
 		return errors.New("update failed due to missing field")
@@ -71,6 +121,19 @@ func (r *MockPlatformRepository) Update(p *types.Platform) error {
 	return nil
 }
 
+func (r *MockPlatformRepository) Upsert(p *types.Platform) (bool, error) {
+	if _, err := r.Create(p); err == nil {
+		return true, nil
+	}
+	return false, r.Update(p)
+}
+
 func (r *MockPlatformRepository) Delete(p *types.Platform) error {
+	for i, platform := range r.Platforms {
+		if p.QeID == platform.QeID && p.PceID == platform.PceID {
+			r.Platforms = append(r.Platforms[:i], r.Platforms[i+1:]...)
+			break
+		}
+	}
 	return nil
 }
@@ -23,7 +23,7 @@ func (r *MockPlatformTcbRepository) Create(p *types.PlatformTcb) (*types.Platfor
 	if r.PlatformTcbs != nil {
 		for _, platformtcb := range r.PlatformTcbs {
 			if p.QeID == platformtcb.QeID && p.PceID == platformtcb.PceID {
-				return nil, errors.New("cert chain already exists")
+				return nil, repository.ErrAlreadyExists
 			}
 		}
 	}
@@ -55,6 +55,13 @@ func (r *MockPlatformTcbRepository) Update(p *types.PlatformTcb) error {
 	return nil
 }
 
+func (r *MockPlatformTcbRepository) Upsert(p *types.PlatformTcb) (bool, error) {
+	if _, err := r.Create(p); err == nil {
+		return true, nil
+	}
+	return false, r.Update(p)
+}
+
 func (r *MockPlatformTcbRepository) Delete(p *types.PlatformTcb) error {
 	return nil
 }
@@ -21,12 +21,13 @@ func NewMockQEIdentityRepository() repository.QEIdentityRepository {
 
 func (r *MockQEIdentityRepository) Create(qe *types.QEIdentity) (*types.QEIdentity, error) {
 	if r.QEList != nil && r.QEList.ID == qe.ID {
-		return nil, errors.New("already exists")
+		return nil, repository.ErrAlreadyExists
 	}
 	newQe := &types.QEIdentity{
 		ID:            qe.ID,
 		QeInfo:        qe.QeInfo,
 		QeIssuerChain: qe.QeIssuerChain,
+		TcbUpdate:     qe.TcbUpdate,
 		CreatedTime:   time.Now(),
 		UpdatedTime:   time.Now().Add(2 * time.Hour),
 	}
@@ -38,7 +39,15 @@ func (r *MockQEIdentityRepository) Retrieve() (*types.QEIdentity, error) {
 	if r.QEList != nil {
 		return r.QEList, nil
 	}
-	return nil, errors.New("no records found")
+	return nil, repository.ErrNotFound
+}
+
+func (r *MockQEIdentityRepository) RetrieveAll() (types.QEIdentities, error) {
+	var qes types.QEIdentities
+	if r.QEList != nil {
+		qes = append(qes, *r.QEList)
+	}
+	return qes, nil
 }
 
 func (r *MockQEIdentityRepository) Update(qe *types.QEIdentity) error {
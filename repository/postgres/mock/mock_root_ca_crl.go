@@ -0,0 +1,58 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package mock
+
+import (
+	"errors"
+	"intel/isecl/scs/v5/repository"
+	"intel/isecl/scs/v5/types"
+	"time"
+)
+
+type MockRootCaCrlRepository struct {
+	RootCaCrl *types.RootCaCrl
+}
+
+func NewMockRootCaCrlRepository() repository.RootCaCrlRepository {
+	return &MockRootCaCrlRepository{}
+}
+
+func (r *MockRootCaCrlRepository) Create(crl *types.RootCaCrl) (*types.RootCaCrl, error) {
+	if r.RootCaCrl != nil && r.RootCaCrl.ID == crl.ID {
+		return nil, repository.ErrAlreadyExists
+	}
+	r.RootCaCrl = &types.RootCaCrl{
+		ID:          crl.ID,
+		RootCaCrl:   crl.RootCaCrl,
+		ThisUpdate:  crl.ThisUpdate,
+		NextUpdate:  crl.NextUpdate,
+		CreatedTime: time.Now(),
+		UpdatedTime: time.Now(),
+	}
+	return r.RootCaCrl, nil
+}
+
+func (r *MockRootCaCrlRepository) Retrieve() (*types.RootCaCrl, error) {
+	if r.RootCaCrl != nil {
+		return r.RootCaCrl, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *MockRootCaCrlRepository) Update(crl *types.RootCaCrl) error {
+	if r.RootCaCrl == nil || crl.RootCaCrl == "" {
+		return errors.New("update failed")
+	}
+	r.RootCaCrl.RootCaCrl = crl.RootCaCrl
+	r.RootCaCrl.ThisUpdate = crl.ThisUpdate
+	r.RootCaCrl.NextUpdate = crl.NextUpdate
+	r.RootCaCrl.UpdatedTime = crl.UpdatedTime
+	return nil
+}
+
+func (r *MockRootCaCrlRepository) Delete(crl *types.RootCaCrl) error {
+	r.RootCaCrl = nil
+	return nil
+}
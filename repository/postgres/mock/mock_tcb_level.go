@@ -0,0 +1,45 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package mock
+
+import (
+	"intel/isecl/scs/v5/repository"
+	"intel/isecl/scs/v5/types"
+)
+
+type MockTcbLevelRepository struct {
+	TcbLevels types.TcbLevels
+}
+
+func NewMockTcbLevelRepository() repository.TcbLevelRepository {
+	return &MockTcbLevelRepository{}
+}
+
+func (r *MockTcbLevelRepository) Replace(fmspc string, levels types.TcbLevels) error {
+	_ = r.DeleteByFmspc(fmspc)
+	r.TcbLevels = append(r.TcbLevels, levels...)
+	return nil
+}
+
+func (r *MockTcbLevelRepository) RetrieveByFmspc(fmspc string) (types.TcbLevels, error) {
+	var levels types.TcbLevels
+	for _, level := range r.TcbLevels {
+		if level.Fmspc == fmspc {
+			levels = append(levels, level)
+		}
+	}
+	return levels, nil
+}
+
+func (r *MockTcbLevelRepository) DeleteByFmspc(fmspc string) error {
+	var remaining types.TcbLevels
+	for _, level := range r.TcbLevels {
+		if level.Fmspc != fmspc {
+			remaining = append(remaining, level)
+		}
+	}
+	r.TcbLevels = remaining
+	return nil
+}
@@ -5,37 +5,204 @@
 package postgres
 
 import (
+	"database/sql"
 	"fmt"
 	commLog "intel/isecl/lib/common/v5/log"
 	commLogMsg "intel/isecl/lib/common/v5/log/message"
 	"intel/isecl/scs/v5/constants"
 	"intel/isecl/scs/v5/repository"
 	"intel/isecl/scs/v5/types"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
 )
 
 var log = commLog.GetDefaultLogger()
 var slog = commLog.GetSecurityLogger()
 
+// PostgresDatabase is the gorm implementation of SCSDatabase. It also serves SQLite databases opened
+// with OpenSqlite, statements which differ between the two are chosen by the dialect of DB
 type PostgresDatabase struct {
-	DB *gorm.DB
+	DB     *gorm.DB
+	Schema string
+}
+
+// isSqlite checks if the database is a SQLite database rather than a Postgres one
+func (pd *PostgresDatabase) isSqlite() bool {
+	return pd.DB.Dialect().GetName() == sqliteDialect
 }
 
 func (pd *PostgresDatabase) Migrate() error {
-	pd.DB.AutoMigrate(types.Platform{})
-	pd.DB.AutoMigrate(types.PlatformTcb{})
-	pd.DB.AutoMigrate(types.PckCertChain{})
-	pd.DB.AutoMigrate(types.PckCert{})
-	pd.DB.AutoMigrate(types.PckCrl{})
-	pd.DB.AutoMigrate(types.FmspcTcbInfo{})
-	pd.DB.AutoMigrate(types.LastRefresh{})
-	pd.DB.AutoMigrate(types.QEIdentity{})
+	if pd.isSqlite() {
+		return pd.migrateSqlite()
+	}
+	if pd.Schema != "" {
+		err := pd.DB.Exec("CREATE SCHEMA IF NOT EXISTS " + pq.QuoteIdentifier(pd.Schema)).Error
+		if err != nil {
+			return errors.Wrapf(err, "failed to create schema %s", pd.Schema)
+		}
+	}
+	errs := pd.autoMigrate(types.Platform{}, types.PlatformTcb{}, types.PckCertChain{}, types.PckCert{})
+	// pck certs are keyed on the raw TCB level of the platform so that records for multiple
+	// TCB levels of a platform can coexist. Rekeying locks the table and rebuilds its index, so
+	// it is done only once for a table which is not yet keyed on the raw TCB level.
+	keyed, err := pd.hasPrimaryKey("pck_certs", "qe_id", "pce_id", "cpu_svn", "pce_svn")
+	if err == nil && !keyed {
+		err = pd.keyPckCertsOnTcbLevel()
+	}
+	if err != nil {
+		errs = append(errs, errors.Wrap(err, "failed to key pck certs on raw TCB level"))
+	}
+	// a multi-package platform presents a qeid with more than one pce id, so its platform tcbs
+	// are keyed on both in the same way as platforms
+	keyed, err = pd.hasPrimaryKey("platform_tcbs", "qe_id", "pce_id")
+	if err == nil && !keyed {
+		err = pd.keyPlatformTcbsOnPceID()
+	}
+	if err != nil {
+		errs = append(errs, errors.Wrap(err, "failed to key platform tcbs on pce id"))
+	}
+	errs = append(errs, pd.autoMigrate(types.PckCrl{}, types.FmspcTcbInfo{}, types.LastRefresh{}, types.QEIdentity{},
+		types.RootCaCrl{}, types.PcsAuditRecord{}, types.TcbLevel{})...)
+	return migrationError(errs)
+}
+
+// hasPrimaryKey checks if the primary key of a table consists of exactly the given columns
+func (pd *PostgresDatabase) hasPrimaryKey(table string, columns ...string) (bool, error) {
+	rows, err := pd.DB.Raw("SELECT a.attname FROM pg_index i JOIN pg_attribute a ON a.attrelid = i.indrelid AND "+
+		"a.attnum = ANY(i.indkey) WHERE i.indrelid = ?::regclass AND i.indisprimary", table).Rows()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to retrieve primary key of %s table", table)
+	}
+	defer rows.Close()
+
+	keyColumns := map[string]bool{}
+	for rows.Next() {
+		var column string
+		if err = rows.Scan(&column); err != nil {
+			return false, errors.Wrapf(err, "failed to retrieve primary key of %s table", table)
+		}
+		keyColumns[column] = true
+	}
+	if err = rows.Err(); err != nil {
+		return false, errors.Wrapf(err, "failed to retrieve primary key of %s table", table)
+	}
+	if len(keyColumns) != len(columns) {
+		return false, nil
+	}
+	for _, column := range columns {
+		if !keyColumns[column] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// keyPckCertsOnTcbLevel rekeys the pck certs on the raw TCB level of the platform. Records cached
+// before cpu_svn and pce_svn were added take the raw TCB level from the platforms table, those
+// of a platform which is no longer cached cannot take it and are deleted, as the primary key
+// cannot be added while any record lacks it
+func (pd *PostgresDatabase) keyPckCertsOnTcbLevel() error {
+	err := pd.DB.Exec("UPDATE pck_certs SET cpu_svn = platforms.cpu_svn, pce_svn = platforms.pce_svn FROM platforms " +
+		"WHERE pck_certs.cpu_svn IS NULL AND pck_certs.qe_id = platforms.qe_id AND pck_certs.pce_id = platforms.pce_id").Error
+	if err != nil {
+		return errors.Wrap(err, "failed to set raw TCB level of pck certs")
+	}
+	deleted := pd.DB.Exec("DELETE FROM pck_certs WHERE cpu_svn IS NULL OR pce_svn IS NULL")
+	if deleted.Error != nil {
+		return errors.Wrap(deleted.Error, "failed to delete pck certs without raw TCB level")
+	}
+	if deleted.RowsAffected > 0 {
+		log.Warnf("Deleted %d pck certs of platforms no longer cached while keying pck certs on raw TCB level", deleted.RowsAffected)
+	}
+	return pd.DB.Exec("ALTER TABLE pck_certs DROP CONSTRAINT IF EXISTS pck_certs_pkey, ADD PRIMARY KEY (qe_id, pce_id, cpu_svn, pce_svn)").Error
+}
+
+// keyPlatformTcbsOnPceID rekeys the platform tcbs on the qeid and pce id of the platform. Records
+// cached without a pce id take it from the platforms table, those of a platform which is no
+// longer cached cannot take it and are deleted, as the primary key cannot be added while any
+// record lacks it
+func (pd *PostgresDatabase) keyPlatformTcbsOnPceID() error {
+	err := pd.DB.Exec("UPDATE platform_tcbs SET pce_id = platforms.pce_id FROM platforms " +
+		"WHERE platform_tcbs.pce_id IS NULL AND platform_tcbs.qe_id = platforms.qe_id").Error
+	if err != nil {
+		return errors.Wrap(err, "failed to set pce id of platform tcbs")
+	}
+	deleted := pd.DB.Exec("DELETE FROM platform_tcbs WHERE pce_id IS NULL")
+	if deleted.Error != nil {
+		return errors.Wrap(deleted.Error, "failed to delete platform tcbs without pce id")
+	}
+	if deleted.RowsAffected > 0 {
+		log.Warnf("Deleted %d platform tcbs of platforms no longer cached while keying platform tcbs on pce id", deleted.RowsAffected)
+	}
+	return pd.DB.Exec("ALTER TABLE platform_tcbs DROP CONSTRAINT IF EXISTS platform_tcbs_pkey, ADD PRIMARY KEY (qe_id, pce_id)").Error
+}
+
+// migrationError reports every failed migration step at once
+func migrationError(errs []error) error {
+	if len(errs) > 0 {
+		failures := make([]string, len(errs))
+		for i, err := range errs {
+			failures[i] = err.Error()
+		}
+		return errors.Errorf("%d of the migration steps failed: %s", len(errs), strings.Join(failures, "; "))
+	}
 	return nil
 }
 
+// autoMigrate migrates the tables of the models, a failure to migrate a table does not stop the
+// others from being migrated so that every failure is reported at once
+func (pd *PostgresDatabase) autoMigrate(models ...interface{}) []error {
+	var errs []error
+	for _, model := range models {
+		err := pd.DB.AutoMigrate(model).Error
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to migrate %s table", pd.DB.NewScope(model).TableName()))
+		}
+	}
+	return errs
+}
+
+// retrieveError wraps the error of a query for a single record, a record which is not found is
+// returned as repository.ErrNotFound so that callers can tell it apart from a failed query
+func retrieveError(err error, message string) error {
+	if gorm.IsRecordNotFoundError(err) {
+		return errors.Wrap(repository.ErrNotFound, message)
+	}
+	return errors.Wrap(err, message)
+}
+
+// createError wraps the error of creating a record, a record whose primary key is already cached
+// is returned as repository.ErrAlreadyExists so that callers can tell it apart from a failed insert
+func createError(err error, message string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+		return errors.Wrap(repository.ErrAlreadyExists, message)
+	}
+	// SQLite reports the violated constraint only in the message of the error
+	if strings.HasPrefix(err.Error(), "UNIQUE constraint failed") {
+		return errors.Wrap(repository.ErrAlreadyExists, message)
+	}
+	return errors.Wrap(err, message)
+}
+
+// Ping checks that the database is reachable
+func (pd *PostgresDatabase) Ping() error {
+	if pd.DB == nil {
+		return errors.New("database connection is not opened")
+	}
+	db, ok := pd.DB.CommonDB().(*sql.DB)
+	if !ok {
+		// a database bound to a transaction is reachable while the transaction is open
+		return nil
+	}
+	return db.Ping()
+}
+
 func (pd *PostgresDatabase) PlatformRepository() repository.PlatformRepository {
 	return &PostgresPlatformRepository{db: pd.DB}
 }
@@ -68,6 +235,50 @@ func (pd *PostgresDatabase) QEIdentityRepository() repository.QEIdentityReposito
 	return &PostgresQEIdentityRepository{db: pd.DB}
 }
 
+func (pd *PostgresDatabase) RootCaCrlRepository() repository.RootCaCrlRepository {
+	return &PostgresRootCaCrlRepository{db: pd.DB}
+}
+
+func (pd *PostgresDatabase) PcsAuditRecordRepository() repository.PcsAuditRecordRepository {
+	return &PostgresPcsAuditRecordRepository{db: pd.DB}
+}
+
+func (pd *PostgresDatabase) TcbLevelRepository() repository.TcbLevelRepository {
+	return &PostgresTcbLevelRepository{db: pd.DB}
+}
+
+// ExecuteInTransaction runs fn with repositories bound to a gorm transaction. The transaction is
+// rolled back when fn returns an error or panics, the panic is propagated after rollback.
+// Transactions cannot be nested and the database passed to fn must not be closed
+func (pd *PostgresDatabase) ExecuteInTransaction(fn func(repository.SCSDatabase) error) error {
+	tx := pd.DB.Begin()
+	if tx.Error != nil {
+		return errors.Wrap(tx.Error, "ExecuteInTransaction: failed to begin transaction")
+	}
+
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		if err := tx.Rollback().Error; err != nil {
+			log.WithError(err).Error("ExecuteInTransaction: failed to rollback transaction")
+		}
+	}()
+
+	err := fn(&PostgresDatabase{DB: tx, Schema: pd.Schema})
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return errors.Wrap(err, "ExecuteInTransaction: failed to commit transaction")
+	}
+	committed = true
+	return nil
+}
+
 func (pd *PostgresDatabase) Close() {
 	if pd.DB != nil {
 		err := pd.DB.Close()
@@ -77,6 +288,42 @@ func (pd *PostgresDatabase) Close() {
 	}
 }
 
+// paginate applies limit, offset and ordering of a page to a query, ordering by
+// primaryKey when no order is requested so that pages are stable
+func paginate(db *gorm.DB, page types.Pagination, primaryKey string) (*gorm.DB, error) {
+	if page.Limit <= 0 || page.Offset < 0 {
+		return nil, errors.New("paginate: limit should be positive and offset should not be negative")
+	}
+
+	order := primaryKey
+	switch page.OrderBy {
+	case "":
+	case types.OrderByCreatedTime, types.OrderByUpdatedTime:
+		order = page.OrderBy + ", " + primaryKey
+	default:
+		return nil, errors.Errorf("paginate: records cannot be ordered by %s", page.OrderBy)
+	}
+	if page.Descending {
+		order = strings.ReplaceAll(order, ",", " DESC,") + " DESC"
+	}
+	return db.Order(order).Limit(page.Limit).Offset(page.Offset), nil
+}
+
+// insertIfNotExists inserts a record unless a record with the same primary key exists, so that
+// concurrent inserts of a record don't fail on the primary key. It returns whether it was inserted
+func insertIfNotExists(db *gorm.DB, record interface{}, primaryKey string) (bool, error) {
+	result := db.Set("gorm:insert_option", "ON CONFLICT ("+primaryKey+") DO NOTHING").Create(record)
+	// no row is returned by an insert which conflicts with an existing record on Postgres, while
+	// on SQLite, which returns no rows for an insert, no row is affected by it
+	if errors.Is(result.Error, sql.ErrNoRows) {
+		return false, nil
+	}
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 func setConnectionPool(db *gorm.DB) {
 	// Query DB's max_connections settings
 	type Result struct {
@@ -99,24 +346,87 @@ func setConnectionPool(db *gorm.DB) {
 	}
 }
 
-func Open(host string, port int, dbname, user, password, sslMode, sslCert string) (*PostgresDatabase, error) {
+// sslModes are the accepted SSL modes of database connection
+var sslModes = map[string]bool{"allow": true, "prefer": true, "require": true, "verify-ca": true, "verify-full": true}
+
+// ParseSSLMode returns the SSL mode of database connection, verify-full is used when none is given.
+// Modes which are not accepted are rejected instead of being replaced with verify-full
+func ParseSSLMode(sslMode string) (string, error) {
+	sslMode = strings.TrimSpace(strings.ToLower(sslMode))
+	if sslMode == "" {
+		return constants.DefaultDBSSLMode, nil
+	}
+	if !sslModes[sslMode] {
+		return "", errors.Errorf("invalid database sslmode %q, should be one of allow, prefer, require, verify-ca or verify-full", sslMode)
+	}
+	return sslMode, nil
+}
+
+// SSLModeVerifiesServer checks if the database server certificate is verified against sslrootcert in an SSL mode
+func SSLModeVerifiesServer(sslMode string) bool {
+	return sslMode == "verify-ca" || sslMode == "verify-full"
+}
+
+// ValidateSSLParams returns the SSL mode of database connection after checking that sslCert is a
+// readable file when the mode verifies the database server certificate, so that a missing
+// certificate is reported before connecting rather than as a connection failure
+func ValidateSSLParams(sslMode, sslCert string) (string, error) {
+	sslMode, err := ParseSSLMode(sslMode)
+	if err != nil {
+		return "", err
+	}
+	if !SSLModeVerifiesServer(sslMode) {
+		return sslMode, nil
+	}
+	if sslCert == "" {
+		return "", errors.Errorf("database sslcert should be provided with sslmode %s", sslMode)
+	}
+	file, err := os.Open(sslCert)
+	if err != nil {
+		return "", errors.Wrapf(err, "database sslcert %s of sslmode %s is not readable", sslCert, sslMode)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		return "", errors.Errorf("database sslcert %s of sslmode %s is not a file", sslCert, sslMode)
+	}
+	return sslMode, nil
+}
+
+// connectionString builds the connection string of database, tables are looked up and
+// created in schema when one is given. sslMode is expected to be validated by ValidateSSLParams
+func connectionString(host string, port int, dbname, user, password, sslMode, sslCert, schema string) string {
 	sslMode = strings.TrimSpace(strings.ToLower(sslMode))
-	if sslMode != "allow" && sslMode != "prefer" && sslMode != "require" && sslMode != "verify-ca" {
-		sslMode = "verify-full"
+	if sslMode == "" {
+		sslMode = constants.DefaultDBSSLMode
 	}
 
 	var sslCertParams string
-	if sslMode == "verify-ca" || sslMode == "verify-full" {
+	if SSLModeVerifiesServer(sslMode) {
 		sslCertParams = " sslrootcert=" + sslCert
 	}
 
+	var schemaParams string
+	if schema != "" {
+		schemaParams = " search_path=" + pq.QuoteIdentifier(schema)
+	}
+	return fmt.Sprintf("host=%s port=%d user=%s dbname=%s password=%s sslmode=%s%s%s",
+		host, port, user, dbname, password, sslMode, sslCertParams, schemaParams)
+}
+
+func Open(host string, port int, dbname, user, password, sslMode, sslCert, schema string) (*PostgresDatabase, error) {
+	sslMode, err := ValidateSSLParams(sslMode, sslCert)
+	if err != nil {
+		slog.Errorf("%s: Failed to connect to db, invalid SSL parameters", commLogMsg.BadConnection)
+		return nil, err
+	}
+
 	var db *gorm.DB
 	var dbErr error
 	const numAttempts = 4
 	for i := 0; i < numAttempts; i++ {
 		const retryTime = 1
-		db, dbErr = gorm.Open("postgres", fmt.Sprintf("host=%s port=%d user=%s dbname=%s password=%s sslmode=%s%s",
-			host, port, user, dbname, password, sslMode, sslCertParams))
+		db, dbErr = gorm.Open("postgres", connectionString(host, port, dbname, user, password, sslMode, sslCert, schema))
 		if dbErr != nil {
 			log.WithError(dbErr).Infof("Failed to connect to DB, retrying attempt %d/%d", i+1, numAttempts)
 		} else {
@@ -132,5 +442,5 @@ func Open(host string, port int, dbname, user, password, sslMode, sslCert string
 
 	setConnectionPool(db)
 
-	return &PostgresDatabase{DB: db}, nil
+	return &PostgresDatabase{DB: db, Schema: schema}, nil
 }
@@ -0,0 +1,545 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package postgres
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"intel/isecl/scs/v5/repository"
+	"intel/isecl/scs/v5/types"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectionString(t *testing.T) {
+	assert.Equal(t, "host=localhost port=5432 user=scs dbname=scsdb password=pass sslmode=require",
+		connectionString("localhost", 5432, "scsdb", "scs", "pass", "require", "", ""))
+	assert.Equal(t, "host=localhost port=5432 user=scs dbname=scsdb password=pass sslmode=verify-full sslrootcert=/etc/scs/tdcertdb.pem search_path=\"scs_schema\"",
+		connectionString("localhost", 5432, "scsdb", "scs", "pass", "", "/etc/scs/tdcertdb.pem", "scs_schema"))
+}
+
+func TestValidateSSLParams(t *testing.T) {
+	sslCert, err := ioutil.TempFile("", "scsdbcert.pem")
+	assert.NoError(t, err)
+	sslCert.Close()
+	defer os.Remove(sslCert.Name())
+
+	sslMode, err := ValidateSSLParams("", sslCert.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, "verify-full", sslMode)
+	sslMode, err = ValidateSSLParams("Verify-CA", sslCert.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, "verify-ca", sslMode)
+	sslMode, err = ValidateSSLParams("require", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "require", sslMode)
+
+	// invalid modes are not replaced with verify-full
+	_, err = ValidateSSLParams("verify_full", sslCert.Name())
+	assert.Error(t, err)
+	_, err = ValidateSSLParams("disable", "")
+	assert.Error(t, err)
+
+	// root certificate is required by the modes verifying the server certificate
+	_, err = ValidateSSLParams("verify-full", "")
+	assert.Error(t, err)
+	_, err = ValidateSSLParams("verify-full", "/nonexistent/scsdbcert.pem")
+	assert.Error(t, err)
+	_, err = ValidateSSLParams("verify-ca", os.TempDir())
+	assert.Error(t, err)
+
+	_, err = Open("localhost", 5432, "scsdb", "scs", "pass", "verify-full", "/nonexistent/scsdbcert.pem", "")
+	assert.Error(t, err)
+}
+
+func TestRetrieveError(t *testing.T) {
+	err := retrieveError(gorm.ErrRecordNotFound, "Retrieve: failed to retrieve a record from platform table")
+	assert.True(t, errors.Is(err, repository.ErrNotFound))
+	assert.Contains(t, err.Error(), "platform table")
+
+	err = retrieveError(errors.New("connection refused"), "Retrieve: failed to retrieve a record from platform table")
+	assert.False(t, errors.Is(err, repository.ErrNotFound))
+	assert.Contains(t, err.Error(), "connection refused")
+}
+
+func TestCreateError(t *testing.T) {
+	err := createError(&pq.Error{Code: "23505", Message: "duplicate key value violates unique constraint"},
+		"Create: failed to create a record in pck_certs table")
+	assert.True(t, errors.Is(err, repository.ErrAlreadyExists))
+	assert.Contains(t, err.Error(), "pck_certs table")
+
+	err = createError(errors.New("UNIQUE constraint failed: pck_certs.qe_id, pck_certs.pce_id"),
+		"Create: failed to create a record in pck_certs table")
+	assert.True(t, errors.Is(err, repository.ErrAlreadyExists))
+
+	err = createError(&pq.Error{Code: "23502", Message: "null value in column violates not-null constraint"},
+		"Create: failed to create a record in pck_certs table")
+	assert.False(t, errors.Is(err, repository.ErrAlreadyExists))
+	assert.Contains(t, err.Error(), "not-null constraint")
+}
+
+func TestCreateDuplicate(t *testing.T) {
+	sqlDB, err := sql.Open("scs_induced_failure", "")
+	assert.NoError(t, err)
+	db, err := gorm.Open("postgres", sqlDB)
+	assert.NoError(t, err)
+	defer db.Close()
+	pd := &PostgresDatabase{DB: db}
+
+	// the insert of a cert chain cached concurrently fails on its primary key
+	induced.failOn, induced.statements = []string{`INSERT INTO "pck_cert_chains"`}, nil
+	induced.failErr = &pq.Error{Code: "23505", Message: "duplicate key value violates unique constraint"}
+	defer func() { induced.failOn, induced.failErr = nil, nil }()
+	_, err = pd.PckCertChainRepository().Create(&types.PckCertChain{Ca: "processor", PckCertChain: "certchain"})
+	assert.True(t, errors.Is(err, repository.ErrAlreadyExists))
+
+	induced.failErr = errors.New("connection reset by peer")
+	_, err = pd.PckCertChainRepository().Create(&types.PckCertChain{Ca: "processor", PckCertChain: "certchain"})
+	if assert.Error(t, err) {
+		assert.False(t, errors.Is(err, repository.ErrAlreadyExists))
+		assert.Contains(t, err.Error(), "connection reset by peer")
+	}
+}
+
+// inducedFailureDriver is a database/sql driver which fails the statements containing any of
+// failOn, with failErr if set, other statements succeed without any effect other than reporting
+// rowsAffected and queries return no rows, other than primaryKey for a query of the primary key of a table
+type inducedFailureDriver struct {
+	failOn       []string
+	failErr      error
+	statements   []string
+	rowsAffected int64
+	primaryKey   []string
+}
+
+var induced = &inducedFailureDriver{}
+
+func init() {
+	sql.Register("scs_induced_failure", induced)
+}
+
+func (d *inducedFailureDriver) Open(string) (driver.Conn, error) {
+	return d, nil
+}
+
+func (d *inducedFailureDriver) Prepare(query string) (driver.Stmt, error) {
+	d.statements = append(d.statements, query)
+	for _, failOn := range d.failOn {
+		if strings.Contains(query, failOn) {
+			if d.failErr != nil {
+				return nil, d.failErr
+			}
+			return nil, errors.Errorf("induced failure of %s", failOn)
+		}
+	}
+	return inducedFailureStmt{query: query}, nil
+}
+
+func (d *inducedFailureDriver) Close() error {
+	return nil
+}
+
+// Begin begins a transaction which has no effect, as the statements run in it have none
+func (d *inducedFailureDriver) Begin() (driver.Tx, error) {
+	return d, nil
+}
+
+func (d *inducedFailureDriver) Commit() error {
+	return nil
+}
+
+func (d *inducedFailureDriver) Rollback() error {
+	return nil
+}
+
+type inducedFailureStmt struct {
+	query string
+}
+
+func (inducedFailureStmt) Close() error {
+	return nil
+}
+
+func (inducedFailureStmt) NumInput() int {
+	return -1
+}
+
+func (inducedFailureStmt) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(induced.rowsAffected), nil
+}
+
+func (s inducedFailureStmt) Query([]driver.Value) (driver.Rows, error) {
+	if strings.Contains(s.query, "indisprimary") {
+		return &inducedFailureRows{values: induced.primaryKey}, nil
+	}
+	return &inducedFailureRows{}, nil
+}
+
+type inducedFailureRows struct {
+	values []string
+}
+
+func (*inducedFailureRows) Columns() []string {
+	return []string{"count"}
+}
+
+func (*inducedFailureRows) Close() error {
+	return nil
+}
+
+func (r *inducedFailureRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+func TestMigrateFailure(t *testing.T) {
+	sqlDB, err := sql.Open("scs_induced_failure", "")
+	assert.NoError(t, err)
+	db, err := gorm.Open("postgres", sqlDB)
+	assert.NoError(t, err)
+	defer db.Close()
+	pd := &PostgresDatabase{DB: db}
+
+	induced.failOn, induced.statements = nil, nil
+	assert.NoError(t, pd.Migrate())
+	// levels are numbered from TcbInfo, so the level column must not be a serial
+	assert.Contains(t, strings.Join(induced.statements, "\n"), `CREATE TABLE "tcb_levels" ("fmspc" text,"level" integer`)
+
+	// every failed migration step is reported, and the following tables are still migrated
+	induced.failOn, induced.statements = []string{`CREATE TABLE "platform_tcbs"`, `CREATE TABLE "pck_crls"`}, nil
+	err = pd.Migrate()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "2 of the migration steps failed")
+		assert.Contains(t, err.Error(), "failed to migrate platform_tcbs table")
+		assert.Contains(t, err.Error(), "failed to migrate pck_crls table")
+	}
+	assert.Contains(t, strings.Join(induced.statements, "\n"), `CREATE TABLE "pcs_audit_records"`)
+
+	induced.failOn, induced.statements = []string{"ALTER TABLE pck_certs"}, nil
+	err = pd.Migrate()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "failed to key pck certs on raw TCB level")
+	}
+	// pck certs of platforms no longer cached are deleted before the primary key is added
+	statements := strings.Join(induced.statements, "\n")
+	assert.Contains(t, statements, "DELETE FROM pck_certs WHERE cpu_svn IS NULL OR pce_svn IS NULL")
+	assert.Less(t, strings.Index(statements, "DELETE FROM pck_certs"), strings.Index(statements, "ALTER TABLE pck_certs"))
+
+	// pck certs already keyed on the raw TCB level are not rekeyed
+	induced.failOn, induced.statements = nil, nil
+	induced.primaryKey = []string{"pce_svn", "qe_id", "cpu_svn", "pce_id"}
+	assert.NoError(t, pd.Migrate())
+	induced.primaryKey = nil
+	assert.NotContains(t, strings.Join(induced.statements, "\n"), "pck_certs SET")
+	assert.NotContains(t, strings.Join(induced.statements, "\n"), "ALTER TABLE pck_certs")
+
+	induced.failOn, induced.statements = []string{"ALTER TABLE platform_tcbs"}, nil
+	err = pd.Migrate()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "failed to key platform tcbs on pce id")
+	}
+	statements = strings.Join(induced.statements, "\n")
+	assert.Contains(t, statements, "DELETE FROM platform_tcbs WHERE pce_id IS NULL")
+	assert.Less(t, strings.Index(statements, "DELETE FROM platform_tcbs"), strings.Index(statements, "ALTER TABLE platform_tcbs"))
+
+	// platform tcbs already keyed on the pce id are not rekeyed
+	induced.failOn, induced.statements = nil, nil
+	induced.primaryKey = []string{"qe_id", "pce_id"}
+	assert.NoError(t, pd.Migrate())
+	induced.primaryKey = nil
+	assert.NotContains(t, strings.Join(induced.statements, "\n"), "platform_tcbs SET")
+	assert.NotContains(t, strings.Join(induced.statements, "\n"), "ALTER TABLE platform_tcbs")
+}
+
+// TestColumnNames checks that gorm resolves the fields to the column names which the raw queries
+// and migration statements of the repositories use, so that renaming a field does not leave them
+// querying a column gorm no longer creates
+func TestColumnNames(t *testing.T) {
+	sqlDB, err := sql.Open("scs_induced_failure", "")
+	assert.NoError(t, err)
+	db, err := gorm.Open("postgres", sqlDB)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	columns := []struct {
+		model  interface{}
+		field  string
+		column string
+	}{
+		{types.Platform{}, "QeID", "qe_id"},
+		{types.Platform{}, "PceID", "pce_id"},
+		{types.Platform{}, "CPUSvn", "cpu_svn"},
+		{types.Platform{}, "PceSvn", "pce_svn"},
+		{types.Platform{}, "Fmspc", "fmspc"},
+		{types.Platform{}, "UpdatedTime", "updated_time"},
+		{types.PlatformTcb{}, "QeID", "qe_id"},
+		{types.PlatformTcb{}, "PceID", "pce_id"},
+		{types.PckCert{}, "QeID", "qe_id"},
+		{types.PckCert{}, "PceID", "pce_id"},
+		{types.PckCert{}, "CPUSvn", "cpu_svn"},
+		{types.PckCert{}, "PceSvn", "pce_svn"},
+		{types.PckCert{}, "UpdatedTime", "updated_time"},
+		{types.PckCrl{}, "UpdatedTime", "updated_time"},
+		{types.FmspcTcbInfo{}, "UpdatedTime", "updated_time"},
+		{types.QEIdentity{}, "ID", "id"},
+		{types.QEIdentity{}, "UpdatedTime", "updated_time"},
+		{types.PcsAuditRecord{}, "ID", "id"},
+		{types.TcbLevel{}, "Fmspc", "fmspc"},
+		{types.TcbLevel{}, "Level", "level"},
+		{types.TcbLevel{}, "TcbStatus", "tcb_status"},
+		{types.TcbLevel{}, "TcbDate", "tcb_date"},
+	}
+	for _, c := range columns {
+		field, ok := db.NewScope(c.model).FieldByName(c.field)
+		if assert.True(t, ok, "%T has no field %s", c.model, c.field) {
+			assert.Equal(t, c.column, field.DBName, "column of %T.%s", c.model, c.field)
+		}
+	}
+}
+
+func TestMigrateSqlite(t *testing.T) {
+	sqlDB, err := sql.Open("scs_induced_failure", "")
+	assert.NoError(t, err)
+	db, err := gorm.Open(sqliteDialect, sqlDB)
+	assert.NoError(t, err)
+	defer db.Close()
+	pd := &PostgresDatabase{DB: db}
+
+	induced.failOn, induced.statements = nil, nil
+	assert.NoError(t, pd.Migrate())
+	statements := strings.Join(induced.statements, "\n")
+	// tables are created with their current primary keys instead of being rekeyed
+	assert.Contains(t, statements, `PRIMARY KEY ("qe_id","pce_id","cpu_svn","pce_svn")`)
+	assert.Contains(t, statements, `CREATE TABLE "tcb_levels"`)
+	assert.NotContains(t, statements, "ALTER TABLE")
+	assert.NotContains(t, statements, "CREATE SCHEMA")
+
+	induced.failOn, induced.statements = []string{`CREATE TABLE "pck_crls"`}, nil
+	err = pd.Migrate()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "failed to migrate pck_crls table")
+	}
+}
+
+func TestInsertIfNotExistsSqlite(t *testing.T) {
+	sqlDB, err := sql.Open("scs_induced_failure", "")
+	assert.NoError(t, err)
+	db, err := gorm.Open(sqliteDialect, sqlDB)
+	assert.NoError(t, err)
+	defer db.Close()
+	defer func() {
+		induced.rowsAffected = 0
+	}()
+
+	induced.failOn, induced.statements = nil, nil
+	induced.rowsAffected = 1
+	created, err := insertIfNotExists(db, &types.Platform{QeID: "qeid", PceID: "0000"}, "qe_id, pce_id")
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.Contains(t, strings.Join(induced.statements, "\n"), "ON CONFLICT (qe_id, pce_id) DO NOTHING")
+
+	// no row is affected by an insert conflicting with an existing record
+	induced.rowsAffected = 0
+	created, err = insertIfNotExists(db, &types.Platform{QeID: "qeid", PceID: "0000"}, "qe_id, pce_id")
+	assert.NoError(t, err)
+	assert.False(t, created)
+}
+
+func TestOpenSqlite(t *testing.T) {
+	if !sqliteSupported() {
+		_, err := OpenSqlite(t.TempDir() + "/scs.db")
+		assert.Error(t, err)
+		return
+	}
+	_, err := OpenSqlite("")
+	assert.Error(t, err)
+
+	db, err := OpenSqlite(t.TempDir() + "/sqlite/scs.db")
+	if assert.NoError(t, err) {
+		defer db.Close()
+		assert.NoError(t, db.Migrate())
+		assert.NoError(t, db.Ping())
+	}
+}
+
+// openTestDatabase opens the database tests are run against, tests needing a database are run
+// only when SCS_TEST_DB_HOSTNAME, SCS_TEST_DB_PORT, SCS_TEST_DB_NAME, SCS_TEST_DB_USERNAME and
+// SCS_TEST_DB_PASSWORD are set. Tables are migrated under schema which is dropped on cleanup
+func openTestDatabase(t *testing.T, schema string) *PostgresDatabase {
+	host := os.Getenv("SCS_TEST_DB_HOSTNAME")
+	if host == "" {
+		t.Skip("SCS_TEST_DB_HOSTNAME is not set, skipping database test")
+	}
+	port, err := strconv.Atoi(os.Getenv("SCS_TEST_DB_PORT"))
+	if err != nil {
+		port = 5432
+	}
+
+	db, err := Open(host, port, os.Getenv("SCS_TEST_DB_NAME"), os.Getenv("SCS_TEST_DB_USERNAME"),
+		os.Getenv("SCS_TEST_DB_PASSWORD"), "prefer", "", schema)
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	t.Cleanup(func() {
+		db.DB.Exec("DROP SCHEMA IF EXISTS " + schema + " CASCADE")
+		db.Close()
+	})
+
+	if err = db.Migrate(); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	return db
+}
+
+func TestMigrateWithSchema(t *testing.T) {
+	const schema = "scs_test_schema"
+	db := openTestDatabase(t, schema)
+
+	var count int
+	err := db.DB.Raw("SELECT count(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = ?",
+		schema, "platforms").Row().Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	platform := &types.Platform{QeID: "qeid", PceID: "0000", Fmspc: "20606a000000", Ppid: "ppid"}
+	_, err = db.PlatformRepository().Create(platform)
+	assert.NoError(t, err)
+
+	platform.Fmspc = "30606a000000"
+	assert.NoError(t, db.PlatformRepository().Update(platform))
+
+	retrieved, err := db.PlatformRepository().Retrieve(&types.Platform{QeID: "qeid", PceID: "0000"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "30606a000000", retrieved.Fmspc)
+	}
+	assert.NoError(t, db.PlatformRepository().Delete(platform))
+}
+
+func TestExecuteInTransaction(t *testing.T) {
+	db := openTestDatabase(t, "scs_test_tx_schema")
+	platform := &types.Platform{QeID: "qeid", PceID: "0000", Fmspc: "20606a000000", Ppid: "ppid"}
+
+	// changes are rolled back when fn fails
+	err := db.ExecuteInTransaction(func(tx repository.SCSDatabase) error {
+		_, err := tx.PlatformRepository().Create(platform)
+		assert.NoError(t, err)
+		return errors.New("fail")
+	})
+	assert.EqualError(t, err, "fail")
+	_, err = db.PlatformRepository().Retrieve(&types.Platform{QeID: "qeid", PceID: "0000"})
+	assert.Error(t, err)
+
+	// changes are rolled back when fn panics
+	assert.Panics(t, func() {
+		_ = db.ExecuteInTransaction(func(tx repository.SCSDatabase) error {
+			_, err := tx.PlatformRepository().Create(platform)
+			assert.NoError(t, err)
+			panic("fail")
+		})
+	})
+	_, err = db.PlatformRepository().Retrieve(&types.Platform{QeID: "qeid", PceID: "0000"})
+	assert.Error(t, err)
+
+	// changes are committed when fn succeeds
+	err = db.ExecuteInTransaction(func(tx repository.SCSDatabase) error {
+		_, err := tx.PlatformRepository().Create(platform)
+		return err
+	})
+	assert.NoError(t, err)
+	_, err = db.PlatformRepository().Retrieve(&types.Platform{QeID: "qeid", PceID: "0000"})
+	assert.NoError(t, err)
+}
+
+// openTestBackends opens a database of each backend which tests of the repositories are run against.
+// The Postgres database is opened as by openTestDatabase, and the SQLite database only when SCS is
+// built with the sqlite tag. The test is skipped when neither is available
+func openTestBackends(t *testing.T, schema string) map[string]*PostgresDatabase {
+	backends := map[string]*PostgresDatabase{}
+	if os.Getenv("SCS_TEST_DB_HOSTNAME") != "" {
+		backends["postgres"] = openTestDatabase(t, schema)
+	}
+	if sqliteSupported() {
+		db, err := OpenSqlite(t.TempDir() + "/scs.db")
+		if err != nil {
+			t.Fatalf("failed to open test SQLite database: %v", err)
+		}
+		t.Cleanup(db.Close)
+		if err = db.Migrate(); err != nil {
+			t.Fatalf("failed to migrate test SQLite database: %v", err)
+		}
+		backends["sqlite"] = db
+	}
+	if len(backends) == 0 {
+		t.Skip("neither SCS_TEST_DB_HOSTNAME is set nor SCS is built with the sqlite tag, skipping database test")
+	}
+	return backends
+}
+
+func TestRepositoriesOnBackends(t *testing.T) {
+	for name, db := range openTestBackends(t, "scs_test_backends_schema") {
+		t.Run(name, func(t *testing.T) {
+			platform := &types.Platform{QeID: "qeid", PceID: "0000", Fmspc: "20606a000000", Ppid: "ppid"}
+			created, err := db.PlatformRepository().Upsert(platform)
+			assert.NoError(t, err)
+			assert.True(t, created)
+			platform.Fmspc = "30606a000000"
+			created, err = db.PlatformRepository().Upsert(platform)
+			assert.NoError(t, err)
+			assert.False(t, created)
+			retrieved, err := db.PlatformRepository().Retrieve(&types.Platform{QeID: "qeid", PceID: "0000"})
+			if assert.NoError(t, err) {
+				assert.Equal(t, "30606a000000", retrieved.Fmspc)
+			}
+			_, err = db.PlatformRepository().Retrieve(&types.Platform{QeID: "other", PceID: "0000"})
+			assert.True(t, errors.Is(err, repository.ErrNotFound))
+
+			pckCert := &types.PckCert{QeID: "qeid", PceID: "0000", CPUSvn: "cpusvn", PceSvn: "0a00",
+				Tcbms: []string{"tcbm1", "tcbm2"}, PckCerts: []string{"cert1", "cert2"}}
+			created, err = db.PckCertRepository().Upsert(pckCert)
+			assert.NoError(t, err)
+			assert.True(t, created)
+			retrievedCert, err := db.PckCertRepository().RetrieveByTcbLevel(&types.PckCert{QeID: "qeid", PceID: "0000", CPUSvn: "cpusvn", PceSvn: "0a00"})
+			if assert.NoError(t, err) {
+				assert.Equal(t, []string{"cert1", "cert2"}, []string(retrievedCert.PckCerts))
+			}
+
+			now := time.Now().UTC()
+			assert.NoError(t, db.TcbLevelRepository().Replace("20606a000000", types.TcbLevels{
+				{Fmspc: "20606a000000", Level: 1, TcbStatus: "UpToDate", TcbDate: now},
+				{Fmspc: "20606a000000", Level: 2, TcbStatus: "OutOfDate", TcbDate: now.Add(-time.Hour)},
+			}))
+			levels, err := db.TcbLevelRepository().RetrieveByFmspc("20606a000000")
+			if assert.NoError(t, err) && assert.Len(t, levels, 2) {
+				assert.Equal(t, "OutOfDate", levels[1].TcbStatus)
+			}
+
+			for i := 0; i < 3; i++ {
+				_, err = db.PcsAuditRecordRepository().Create(&types.PcsAuditRecord{Endpoint: "tcb", Response: "response"})
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, db.PcsAuditRecordRepository().Prune(1))
+
+			err = db.ExecuteInTransaction(func(tx repository.SCSDatabase) error {
+				return tx.PlatformRepository().Delete(platform)
+			})
+			assert.NoError(t, err)
+			_, err = db.PlatformRepository().Retrieve(&types.Platform{QeID: "qeid", PceID: "0000"})
+			assert.Error(t, err)
+		})
+	}
+}
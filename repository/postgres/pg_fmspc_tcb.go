@@ -18,7 +18,7 @@ type PostgresFmspcTcbInfoRepository struct {
 func (r *PostgresFmspcTcbInfoRepository) Create(tcb *types.FmspcTcbInfo) (*types.FmspcTcbInfo, error) {
 	err := r.db.Create(tcb).Error
 	if err != nil {
-		return nil, errors.Wrap(err, "create: failed to create a record in fmspctcb table")
+		return nil, createError(err, "create: failed to create a record in fmspctcb table")
 	}
 	return tcb, nil
 }
@@ -26,7 +26,7 @@ func (r *PostgresFmspcTcbInfoRepository) Create(tcb *types.FmspcTcbInfo) (*types
 func (r *PostgresFmspcTcbInfoRepository) Retrieve(tcb *types.FmspcTcbInfo) (*types.FmspcTcbInfo, error) {
 	err := r.db.Where(tcb).First(&tcb).Error
 	if err != nil {
-		return nil, errors.Wrap(err, "Retrieve: failed to retrive a record from fmspctcb table")
+		return nil, retrieveError(err, "Retrieve: failed to retrive a record from fmspctcb table")
 	}
 	return tcb, nil
 }
@@ -40,6 +40,30 @@ func (r *PostgresFmspcTcbInfoRepository) RetrieveAll() (types.FmspcTcbInfos, err
 	return tcbs, nil
 }
 
+func (r *PostgresFmspcTcbInfoRepository) RetrievePaginated(page types.Pagination) (types.FmspcTcbInfos, error) {
+	db, err := paginate(r.db, page, "fmspc")
+	if err != nil {
+		return nil, errors.Wrap(err, "RetrievePaginated: invalid pagination")
+	}
+
+	var tcbs types.FmspcTcbInfos
+	err = db.Find(&tcbs).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "RetrievePaginated: failed to retrieve a page of fmspctcb records")
+	}
+	return tcbs, nil
+}
+
+// RetrieveLatestUpdated returns the most recently updated record
+func (r *PostgresFmspcTcbInfoRepository) RetrieveLatestUpdated() (*types.FmspcTcbInfo, error) {
+	var tcb types.FmspcTcbInfo
+	err := r.db.Order("updated_time desc").First(&tcb).Error
+	if err != nil {
+		return nil, retrieveError(err, "RetrieveLatestUpdated: failed to retrieve the latest updated record from fmspctcb table")
+	}
+	return &tcb, nil
+}
+
 func (r *PostgresFmspcTcbInfoRepository) Update(tcb *types.FmspcTcbInfo) error {
 	db := r.db.Model(tcb).Updates(tcb)
 	if db.Error != nil {
@@ -6,6 +6,7 @@ package postgres
 
 import (
 	"intel/isecl/scs/v5/types"
+	"time"
 
 	"github.com/jinzhu/gorm"
 	"github.com/pkg/errors"
@@ -18,7 +19,7 @@ type PostgresPckCertRepository struct {
 func (r *PostgresPckCertRepository) Create(u *types.PckCert) (*types.PckCert, error) {
 	err := r.db.Create(u).Error
 	if err != nil {
-		return nil, errors.Wrap(err, "Create: failed to create a record in pck_certs table")
+		return nil, createError(err, "Create: failed to create a record in pck_certs table")
 	}
 	return u, nil
 }
@@ -26,7 +27,18 @@ func (r *PostgresPckCertRepository) Create(u *types.PckCert) (*types.PckCert, er
 func (r *PostgresPckCertRepository) Retrieve(pckcert *types.PckCert) (*types.PckCert, error) {
 	err := r.db.Where(pckcert).First(pckcert).Error
 	if err != nil {
-		return nil, errors.Wrap(err, "Retrieve: failed to retrieve a record from pck_certs table")
+		return nil, retrieveError(err, "Retrieve: failed to retrieve a record from pck_certs table")
+	}
+	return pckcert, nil
+}
+
+// RetrieveByTcbLevel retrieves the pck cert record selected for a specific raw TCB level
+// (cpusvn and pcesvn) of a platform. Unlike Retrieve, none of the key fields can be left out
+func (r *PostgresPckCertRepository) RetrieveByTcbLevel(pckcert *types.PckCert) (*types.PckCert, error) {
+	err := r.db.Where("qe_id = ? AND pce_id = ? AND cpu_svn = ? AND pce_svn = ?",
+		pckcert.QeID, pckcert.PceID, pckcert.CPUSvn, pckcert.PceSvn).First(pckcert).Error
+	if err != nil {
+		return nil, retrieveError(err, "RetrieveByTcbLevel: failed to retrieve a record from pck_certs table")
 	}
 	return pckcert, nil
 }
@@ -41,6 +53,27 @@ func (r *PostgresPckCertRepository) RetrieveAll() (types.PckCerts, error) {
 	return pckcerts, nil
 }
 
+// RetrieveLatestUpdated returns the most recently updated record
+func (r *PostgresPckCertRepository) RetrieveLatestUpdated() (*types.PckCert, error) {
+	var pckCert types.PckCert
+	err := r.db.Order("updated_time desc").First(&pckCert).Error
+	if err != nil {
+		return nil, retrieveError(err, "RetrieveLatestUpdated: failed to retrieve the latest updated record from pck_certs table")
+	}
+	return &pckCert, nil
+}
+
+// RetrieveExpiringBefore returns the records whose selected pck cert expires before t, records
+// without a known expiry are left out
+func (r *PostgresPckCertRepository) RetrieveExpiringBefore(t time.Time) (types.PckCerts, error) {
+	var pckCerts types.PckCerts
+	err := r.db.Where("not_after > ? AND not_after < ?", time.Time{}, t).Find(&pckCerts).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "RetrieveExpiringBefore: failed to retrieve records from pck_certs table")
+	}
+	return pckCerts, nil
+}
+
 func (r *PostgresPckCertRepository) Update(p *types.PckCert) error {
 	db := r.db.Model(p).Updates(p)
 	if db.Error != nil {
@@ -51,9 +84,30 @@ func (r *PostgresPckCertRepository) Update(p *types.PckCert) error {
 	return nil
 }
 
+// Upsert creates the pck cert record of a raw TCB level, or updates it when one exists for the
+// TCB level. It returns whether the record was created
+func (r *PostgresPckCertRepository) Upsert(p *types.PckCert) (bool, error) {
+	created, err := insertIfNotExists(r.db, p, "qe_id, pce_id, cpu_svn, pce_svn")
+	if err != nil || created {
+		return created, errors.Wrap(err, "Upsert: failed to create a record in pck_certs table")
+	}
+	update := *p
+	update.CreatedTime = time.Time{}
+	return false, r.Update(&update)
+}
+
 func (r *PostgresPckCertRepository) Delete(p *types.PckCert) error {
 	if err := r.db.Delete(p).Error; err != nil {
 		return errors.Wrap(err, "Delete: failed to delete a record from pck_certs table")
 	}
 	return nil
 }
+
+// DeleteByPlatform deletes pck certs cached for all raw tcb levels of a platform
+func (r *PostgresPckCertRepository) DeleteByPlatform(p *types.Platform) error {
+	err := r.db.Where("qe_id = ? AND pce_id = ?", p.QeID, p.PceID).Delete(&types.PckCert{}).Error
+	if err != nil {
+		return errors.Wrap(err, "DeleteByPlatform: failed to delete records from pck_certs table")
+	}
+	return nil
+}
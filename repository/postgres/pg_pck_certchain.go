@@ -18,7 +18,7 @@ type PostgresPckCertChainRepository struct {
 func (r *PostgresPckCertChainRepository) Create(pcc *types.PckCertChain) (*types.PckCertChain, error) {
 	err := r.db.Create(pcc).Error
 	if err != nil {
-		return nil, errors.Wrap(err, "Create: failed to create a record in pck_cert_chains table")
+		return nil, createError(err, "Create: failed to create a record in pck_cert_chains table")
 	}
 	return pcc, nil
 }
@@ -26,11 +26,20 @@ func (r *PostgresPckCertChainRepository) Create(pcc *types.PckCertChain) (*types
 func (r *PostgresPckCertChainRepository) Retrieve(pcc *types.PckCertChain) (*types.PckCertChain, error) {
 	err := r.db.Where(pcc).First(pcc).Error
 	if err != nil {
-		return nil, errors.Wrap(err, "Retrieve: failed to retrieve record from pck_cert_chains table")
+		return nil, retrieveError(err, "Retrieve: failed to retrieve record from pck_cert_chains table")
 	}
 	return pcc, nil
 }
 
+func (r *PostgresPckCertChainRepository) RetrieveAll() (types.PckCertChains, error) {
+	var pccs types.PckCertChains
+	err := r.db.Find(&pccs).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "RetrieveAll: failed to retrieve all records from pck_cert_chains table")
+	}
+	return pccs, nil
+}
+
 func (r *PostgresPckCertChainRepository) Update(pcc *types.PckCertChain) error {
 	db := r.db.Model(pcc).Updates(pcc)
 	if db.Error != nil {
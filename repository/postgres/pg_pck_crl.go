@@ -18,7 +18,7 @@ type PostgresPckCrlRepository struct {
 func (r *PostgresPckCrlRepository) Create(crl *types.PckCrl) (*types.PckCrl, error) {
 	err := r.db.Create(crl).Error
 	if err != nil {
-		return nil, errors.Wrap(err, "Create: failed to create a record in pckcrl table")
+		return nil, createError(err, "Create: failed to create a record in pckcrl table")
 	}
 	return crl, nil
 }
@@ -26,7 +26,7 @@ func (r *PostgresPckCrlRepository) Create(crl *types.PckCrl) (*types.PckCrl, err
 func (r *PostgresPckCrlRepository) Retrieve(crl *types.PckCrl) (*types.PckCrl, error) {
 	err := r.db.Where(crl).First(&crl).Error
 	if err != nil {
-		return nil, errors.Wrap(err, "Retrieve: failed to retrieve a record from pckcrl table")
+		return nil, retrieveError(err, "Retrieve: failed to retrieve a record from pckcrl table")
 	}
 	return crl, nil
 }
@@ -40,6 +40,30 @@ func (r *PostgresPckCrlRepository) RetrieveAll() (types.PckCrls, error) {
 	return crls, nil
 }
 
+func (r *PostgresPckCrlRepository) RetrievePaginated(page types.Pagination) (types.PckCrls, error) {
+	db, err := paginate(r.db, page, "ca")
+	if err != nil {
+		return nil, errors.Wrap(err, "RetrievePaginated: invalid pagination")
+	}
+
+	var crls types.PckCrls
+	err = db.Find(&crls).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "RetrievePaginated: failed to retrieve a page of records from pckcrl table")
+	}
+	return crls, nil
+}
+
+// RetrieveLatestUpdated returns the most recently updated record
+func (r *PostgresPckCrlRepository) RetrieveLatestUpdated() (*types.PckCrl, error) {
+	var crl types.PckCrl
+	err := r.db.Order("updated_time desc").First(&crl).Error
+	if err != nil {
+		return nil, retrieveError(err, "RetrieveLatestUpdated: failed to retrieve the latest updated record from pckcrl table")
+	}
+	return &crl, nil
+}
+
 func (r *PostgresPckCrlRepository) Update(crl *types.PckCrl) error {
 	db := r.db.Model(crl).Updates(crl)
 	if db.Error != nil {
@@ -0,0 +1,34 @@
+/*
+ * Copyright (C) 2020 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package postgres
+
+import (
+	"intel/isecl/scs/v5/types"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type PostgresPcsAuditRecordRepository struct {
+	db *gorm.DB
+}
+
+func (r *PostgresPcsAuditRecordRepository) Create(record *types.PcsAuditRecord) (*types.PcsAuditRecord, error) {
+	err := r.db.Create(record).Error
+	if err != nil {
+		return nil, createError(err, "Create: failed to create a record in pcs_audit_records table")
+	}
+	return record, nil
+}
+
+// Prune deletes all but the latest maxRecords records
+func (r *PostgresPcsAuditRecordRepository) Prune(maxRecords int) error {
+	err := r.db.Exec("DELETE FROM pcs_audit_records WHERE id NOT IN "+
+		"(SELECT id FROM pcs_audit_records ORDER BY id DESC LIMIT ?)", maxRecords).Error
+	if err != nil {
+		return errors.Wrap(err, "Prune: failed to delete older records from pcs_audit_records table")
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ package postgres
 
 import (
 	"intel/isecl/scs/v5/types"
+	"time"
 
 	"github.com/jinzhu/gorm"
 	"github.com/pkg/errors"
@@ -18,7 +19,7 @@ type PostgresPlatformRepository struct {
 func (r *PostgresPlatformRepository) Create(p *types.Platform) (*types.Platform, error) {
 	err := r.db.Create(p).Error
 	if err != nil {
-		return nil, errors.Wrap(err, "create: failed to create a record in platform table")
+		return nil, createError(err, "create: failed to create a record in platform table")
 	}
 	return p, nil
 }
@@ -26,7 +27,7 @@ func (r *PostgresPlatformRepository) Create(p *types.Platform) (*types.Platform,
 func (r *PostgresPlatformRepository) Retrieve(p *types.Platform) (*types.Platform, error) {
 	err := r.db.Where(p).First(p).Error
 	if err != nil {
-		return nil, errors.Wrap(err, "Retrieve: failed to retrieve a record from platform table")
+		return nil, retrieveError(err, "Retrieve: failed to retrieve a record from platform table")
 	}
 	return p, nil
 }
@@ -40,6 +41,52 @@ func (r *PostgresPlatformRepository) RetrieveAll() (types.Platforms, error) {
 	return p, nil
 }
 
+func (r *PostgresPlatformRepository) RetrievePaginated(page types.Pagination) (types.Platforms, error) {
+	db, err := paginate(r.db, page, "qe_id, pce_id")
+	if err != nil {
+		return nil, errors.Wrap(err, "RetrievePaginated: invalid pagination")
+	}
+
+	var p types.Platforms
+	err = db.Find(&p).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "RetrievePaginated: failed to retrieve a page of records from platform table")
+	}
+	return p, nil
+}
+
+func (r *PostgresPlatformRepository) RetrieveByPceID(pceID string, page types.Pagination) (types.Platforms, error) {
+	db, err := paginate(r.db.Where("pce_id = ?", pceID), page, "qe_id, pce_id")
+	if err != nil {
+		return nil, errors.Wrap(err, "RetrieveByPceID: invalid pagination")
+	}
+
+	var p types.Platforms
+	err = db.Find(&p).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "RetrieveByPceID: failed to retrieve a page of records from platform table")
+	}
+	return p, nil
+}
+
+func (r *PostgresPlatformRepository) RetrieveByFmspc(fmspc string) (types.Platforms, error) {
+	var p types.Platforms
+	err := r.db.Where("fmspc = ?", fmspc).Find(&p).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "RetrieveByFmspc: failed to retrieve records from platform table")
+	}
+	return p, nil
+}
+
+func (r *PostgresPlatformRepository) RetrieveNotUpdatedSince(t time.Time) (types.Platforms, error) {
+	var p types.Platforms
+	err := r.db.Where("updated_time < ?", t).Find(&p).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "RetrieveNotUpdatedSince: failed to retrieve records from platform table")
+	}
+	return p, nil
+}
+
 func (r *PostgresPlatformRepository) Update(p *types.Platform) error {
 	db := r.db.Model(p).Updates(p)
 	if db.Error != nil {
@@ -50,6 +97,18 @@ func (r *PostgresPlatformRepository) Update(p *types.Platform) error {
 	return nil
 }
 
+// Upsert creates the platform, or updates it when a platform with the same qeid and pce id is
+// already cached, e.g. by a concurrent push. It returns whether the platform was created
+func (r *PostgresPlatformRepository) Upsert(p *types.Platform) (bool, error) {
+	created, err := insertIfNotExists(r.db, p, "qe_id, pce_id")
+	if err != nil || created {
+		return created, errors.Wrap(err, "Upsert: failed to create a record in platform table")
+	}
+	update := *p
+	update.CreatedTime = time.Time{}
+	return false, r.Update(&update)
+}
+
 func (r *PostgresPlatformRepository) Delete(p *types.Platform) error {
 	if err := r.db.Delete(p).Error; err != nil {
 		return errors.Wrap(err, "Update: failed to delete a record from platform table")
@@ -6,6 +6,7 @@ package postgres
 
 import (
 	"intel/isecl/scs/v5/types"
+	"time"
 
 	"github.com/jinzhu/gorm"
 	"github.com/pkg/errors"
@@ -18,7 +19,7 @@ type PostgresPlatformTcbRepository struct {
 func (r *PostgresPlatformTcbRepository) Create(p *types.PlatformTcb) (*types.PlatformTcb, error) {
 	err := r.db.Create(p).Error
 	if err != nil {
-		return nil, errors.Wrap(err, "Create: failed to create a record in platform_tcbs table")
+		return nil, createError(err, "Create: failed to create a record in platform_tcbs table")
 	}
 	return p, nil
 }
@@ -26,7 +27,7 @@ func (r *PostgresPlatformTcbRepository) Create(p *types.PlatformTcb) (*types.Pla
 func (r *PostgresPlatformTcbRepository) Retrieve(p *types.PlatformTcb) (*types.PlatformTcb, error) {
 	err := r.db.Where(p).First(p).Error
 	if err != nil {
-		return nil, errors.Wrap(err, "Retrieve: failed to retrieve a record from platform_tcbs table")
+		return nil, retrieveError(err, "Retrieve: failed to retrieve a record from platform_tcbs table")
 	}
 	return p, nil
 }
@@ -51,6 +52,18 @@ func (r *PostgresPlatformTcbRepository) Update(p *types.PlatformTcb) error {
 	return nil
 }
 
+// Upsert creates the platform tcb, or updates it when one with the same qeid and pce id exists.
+// It returns whether the platform tcb was created
+func (r *PostgresPlatformTcbRepository) Upsert(p *types.PlatformTcb) (bool, error) {
+	created, err := insertIfNotExists(r.db, p, "qe_id, pce_id")
+	if err != nil || created {
+		return created, errors.Wrap(err, "Upsert: failed to create a record in platform_tcbs table")
+	}
+	update := *p
+	update.CreatedTime = time.Time{}
+	return false, r.Update(&update)
+}
+
 func (r *PostgresPlatformTcbRepository) Delete(p *types.PlatformTcb) error {
 	if err := r.db.Delete(p).Error; err != nil {
 		return errors.Wrap(err, "Delete: failed to delete a record in platform_tcbs table")
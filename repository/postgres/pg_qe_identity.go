@@ -18,20 +18,32 @@ type PostgresQEIdentityRepository struct {
 func (r *PostgresQEIdentityRepository) Create(qe *types.QEIdentity) (*types.QEIdentity, error) {
 	err := r.db.Create(qe).Error
 	if err != nil {
-		return nil, errors.Wrap(err, "Create: failed to create a record in qe_identities table")
+		return nil, createError(err, "Create: failed to create a record in qe_identities table")
 	}
 	return qe, nil
 }
 
+// Retrieve returns the most recently updated qe identity, so that the same record is picked
+// even if the table has duplicate rows
 func (r *PostgresQEIdentityRepository) Retrieve() (*types.QEIdentity, error) {
 	var qe types.QEIdentity
-	err := r.db.First(&qe).Error
+	err := r.db.Order("updated_time desc").First(&qe).Error
 	if err != nil {
-		return nil, errors.Wrap(err, "Retrieve: failed to retrieve record from qe_identities table")
+		return nil, retrieveError(err, "Retrieve: failed to retrieve record from qe_identities table")
 	}
 	return &qe, nil
 }
 
+// RetrieveAll returns all the qe identities, most recently updated first
+func (r *PostgresQEIdentityRepository) RetrieveAll() (types.QEIdentities, error) {
+	var qes types.QEIdentities
+	err := r.db.Order("updated_time desc").Order("id").Find(&qes).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "RetrieveAll: failed to retrieve all records from qe_identities table")
+	}
+	return qes, nil
+}
+
 func (r *PostgresQEIdentityRepository) Update(qe *types.QEIdentity) error {
 	db := r.db.Model(qe).Updates(qe)
 	if db.Error != nil {
@@ -0,0 +1,50 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package postgres
+
+import (
+	"intel/isecl/scs/v5/types"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type PostgresRootCaCrlRepository struct {
+	db *gorm.DB
+}
+
+func (r *PostgresRootCaCrlRepository) Create(crl *types.RootCaCrl) (*types.RootCaCrl, error) {
+	err := r.db.Create(crl).Error
+	if err != nil {
+		return nil, createError(err, "Create: failed to create a record in root_ca_crls table")
+	}
+	return crl, nil
+}
+
+func (r *PostgresRootCaCrlRepository) Retrieve() (*types.RootCaCrl, error) {
+	var crl types.RootCaCrl
+	err := r.db.First(&crl).Error
+	if err != nil {
+		return nil, retrieveError(err, "Retrieve: failed to retrieve record from root_ca_crls table")
+	}
+	return &crl, nil
+}
+
+func (r *PostgresRootCaCrlRepository) Update(crl *types.RootCaCrl) error {
+	db := r.db.Model(crl).Updates(crl)
+	if db.Error != nil {
+		return errors.Wrap(db.Error, "Update: failed to update a record in root_ca_crls table")
+	} else if db.RowsAffected != 1 {
+		return errors.New("Update: - no rows affected")
+	}
+	return nil
+}
+
+func (r *PostgresRootCaCrlRepository) Delete(crl *types.RootCaCrl) error {
+	if err := r.db.Delete(crl).Error; err != nil {
+		return errors.Wrap(err, "Delete: failed to delete a record from root_ca_crls table")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package postgres
+
+import (
+	"intel/isecl/scs/v5/types"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+type PostgresTcbLevelRepository struct {
+	db *gorm.DB
+}
+
+// Replace deletes the stored levels of fmspc before creating the new ones, it should be called
+// on a database bound to a transaction so that the levels of an fmspc are never partially stored
+func (r *PostgresTcbLevelRepository) Replace(fmspc string, levels types.TcbLevels) error {
+	if err := r.DeleteByFmspc(fmspc); err != nil {
+		return errors.Wrap(err, "Replace: failed to delete previous tcb levels")
+	}
+	for i := range levels {
+		err := r.db.Create(&levels[i]).Error
+		if err != nil {
+			return errors.Wrap(err, "Replace: failed to create a record in tcb_levels table")
+		}
+	}
+	return nil
+}
+
+func (r *PostgresTcbLevelRepository) RetrieveByFmspc(fmspc string) (types.TcbLevels, error) {
+	var levels types.TcbLevels
+	err := r.db.Where("fmspc = ?", fmspc).Order("level").Find(&levels).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "RetrieveByFmspc: failed to retrieve records from tcb_levels table")
+	}
+	return levels, nil
+}
+
+func (r *PostgresTcbLevelRepository) DeleteByFmspc(fmspc string) error {
+	err := r.db.Where("fmspc = ?", fmspc).Delete(&types.TcbLevel{}).Error
+	if err != nil {
+		return errors.Wrap(err, "DeleteByFmspc: failed to delete records from tcb_levels table")
+	}
+	return nil
+}
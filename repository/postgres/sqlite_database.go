@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package postgres
+
+import (
+	"database/sql"
+	commLogMsg "intel/isecl/lib/common/v5/log/message"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/types"
+	"os"
+	"path/filepath"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// sqliteDialect is the name of the gorm dialect, and of the database/sql driver, of SQLite
+const sqliteDialect = "sqlite3"
+
+// sqliteParams are the connection params of SQLite databases. Concurrent writes wait for each
+// other rather than failing as busy, and readers are not blocked by writes in WAL mode
+const sqliteParams = "?_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate"
+
+// sqliteSupported checks if SCS is built with the SQLite driver, which is included only by the
+// sqlite build tag as it needs cgo
+func sqliteSupported() bool {
+	for _, driver := range sql.Drivers() {
+		if driver == sqliteDialect {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenSqlite opens the SQLite database at path, creating it when it does not exist. It is meant
+// for single node deployments which cannot run a Postgres server
+func OpenSqlite(path string) (*PostgresDatabase, error) {
+	if !sqliteSupported() {
+		return nil, errors.New("SCS is not built with SQLite support, build it with the sqlite tag to use the sqlite database backend")
+	}
+	if path == "" {
+		return nil, errors.New("path of SQLite database is not set")
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create directory of SQLite database %s", path)
+	}
+
+	db, err := gorm.Open(sqliteDialect, "file:"+path+sqliteParams)
+	if err != nil {
+		slog.Errorf("%s: Failed to open SQLite database %s", commLogMsg.BadConnection, path)
+		return nil, errors.Wrapf(err, "failed to open SQLite database %s", path)
+	}
+	err = os.Chmod(path, 0600)
+	if err != nil {
+		log.WithError(err).Warnf("could not restrict permissions of SQLite database %s", path)
+	}
+	return &PostgresDatabase{DB: db}, nil
+}
+
+// migrateSqlite migrates the tables of a SQLite database. Tables are created with their current
+// primary keys, so the steps rekeying tables of older Postgres databases are not needed
+func (pd *PostgresDatabase) migrateSqlite() error {
+	errs := pd.autoMigrate(types.Platform{}, types.PlatformTcb{}, types.PckCertChain{}, types.PckCert{},
+		types.PckCrl{}, types.FmspcTcbInfo{}, types.LastRefresh{}, types.QEIdentity{},
+		types.RootCaCrl{}, types.PcsAuditRecord{}, types.TcbLevel{})
+	return migrationError(errs)
+}
+
+// OpenConfigured opens the database of the configured backend
+func OpenConfigured(conf *config.Configuration) (*PostgresDatabase, error) {
+	if conf.DBBackend == constants.DBBackendSqlite {
+		return OpenSqlite(conf.SqlitePath)
+	}
+	pg := conf.Postgres
+	return Open(pg.Hostname, pg.Port, pg.DBName, pg.Username, pg.Password, pg.SSLMode, pg.SSLCert, pg.Schema)
+}
@@ -0,0 +1,10 @@
+//go:build sqlite
+
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package postgres
+
+// the SQLite driver needs cgo, so it is built in only with the sqlite tag
+import _ "github.com/jinzhu/gorm/dialects/sqlite"
@@ -9,6 +9,7 @@ import "intel/isecl/scs/v5/types"
 type QEIdentityRepository interface {
 	Create(*types.QEIdentity) (*types.QEIdentity, error)
 	Retrieve() (*types.QEIdentity, error)
+	RetrieveAll() (types.QEIdentities, error)
 	Update(*types.QEIdentity) error
 	Delete(*types.QEIdentity) error
 }
@@ -0,0 +1,14 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package repository
+
+import "intel/isecl/scs/v5/types"
+
+type RootCaCrlRepository interface {
+	Create(*types.RootCaCrl) (*types.RootCaCrl, error)
+	Retrieve() (*types.RootCaCrl, error)
+	Update(*types.RootCaCrl) error
+	Delete(*types.RootCaCrl) error
+}
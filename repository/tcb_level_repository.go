@@ -0,0 +1,17 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package repository
+
+import (
+	"intel/isecl/scs/v5/types"
+)
+
+type TcbLevelRepository interface {
+	// Replace stores levels as the TCB levels of fmspc in place of its previously stored levels
+	Replace(fmspc string, levels types.TcbLevels) error
+	// RetrieveByFmspc returns the TCB levels of fmspc ordered by level
+	RetrieveByFmspc(fmspc string) (types.TcbLevels, error)
+	DeleteByFmspc(fmspc string) error
+}
@@ -0,0 +1,539 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	commLogMsg "intel/isecl/lib/common/v5/log/message"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/domain"
+	"intel/isecl/scs/v5/repository"
+	"intel/isecl/scs/v5/types"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// CacheArchive is a backup of the cached collaterals and platforms. Checksum is the hex encoded
+// sha256 digest of Records, so that Records are imported only when they are not corrupted
+type CacheArchive struct {
+	Version     int             `json:"version"`
+	CreatedTime time.Time       `json:"created_time"`
+	Checksum    string          `json:"checksum"`
+	Records     json.RawMessage `json:"records"`
+}
+
+type CacheArchiveRecords struct {
+	Platforms     []archivedPlatform     `json:"platforms"`
+	PlatformTcbs  []archivedPlatformTcb  `json:"platform_tcbs"`
+	PckCerts      []archivedPckCert      `json:"pck_certs"`
+	PckCertChains []archivedPckCertChain `json:"pck_cert_chains"`
+	PckCrls       []archivedPckCrl       `json:"pck_crls"`
+	FmspcTcbInfos []archivedFmspcTcbInfo `json:"fmspc_tcb_infos"`
+	QeIdentities  []archivedQEIdentity   `json:"qe_identities"`
+	RootCaCrls    []archivedRootCaCrl    `json:"root_ca_crls"`
+}
+
+// CacheImportResult is the number of records imported into each table
+type CacheImportResult struct {
+	Platforms     int `json:"platforms"`
+	PlatformTcbs  int `json:"platform_tcbs"`
+	PckCerts      int `json:"pck_certs"`
+	PckCertChains int `json:"pck_cert_chains"`
+	PckCrls       int `json:"pck_crls"`
+	FmspcTcbInfos int `json:"fmspc_tcb_infos"`
+	QeIdentities  int `json:"qe_identities"`
+	RootCaCrls    int `json:"root_ca_crls"`
+}
+
+// The archived records have the fields of the database schemas in types, which are not serialized
+// to json, so that they can be converted to and from the schemas
+type archivedPlatform struct {
+	QeID        string    `json:"qe_id"`
+	PceID       string    `json:"pce_id"`
+	CPUSvn      string    `json:"cpu_svn"`
+	PceSvn      string    `json:"pce_svn"`
+	Encppid     string    `json:"enc_ppid"`
+	Fmspc       string    `json:"fmspc"`
+	Ca          string    `json:"ca"`
+	Manifest    string    `json:"manifest"`
+	Ppid        string    `json:"ppid"`
+	CreatedTime time.Time `json:"created_time"`
+	UpdatedTime time.Time `json:"updated_time"`
+
+	TcbStatus     string    `json:"tcb_status,omitempty"`
+	TcbStatusTime time.Time `json:"tcb_status_time"`
+
+	LastAccessedTime time.Time `json:"last_accessed_time"`
+}
+
+type archivedPlatformTcb struct {
+	QeID        string    `json:"qe_id"`
+	PceID       string    `json:"pce_id"`
+	CPUSvn      string    `json:"cpu_svn"`
+	PceSvn      string    `json:"pce_svn"`
+	Tcbm        string    `json:"tcbm"`
+	CreatedTime time.Time `json:"created_time"`
+	UpdatedTime time.Time `json:"updated_time"`
+}
+
+type archivedPckCert struct {
+	QeID        string         `json:"qe_id"`
+	PceID       string         `json:"pce_id"`
+	CPUSvn      string         `json:"cpu_svn"`
+	PceSvn      string         `json:"pce_svn"`
+	CertIndex   uint8          `json:"cert_index"`
+	Tcbms       pq.StringArray `json:"tcbms"`
+	Fmspc       string         `json:"fmspc"`
+	PckCerts    pq.StringArray `json:"pck_certs"`
+	CreatedTime time.Time      `json:"created_time"`
+	UpdatedTime time.Time      `json:"updated_time"`
+	NotAfter    time.Time      `json:"not_after"`
+}
+
+type archivedPckCertChain struct {
+	Ca           string    `json:"ca"`
+	PckCertChain string    `json:"pck_cert_chain"`
+	CreatedTime  time.Time `json:"created_time"`
+	UpdatedTime  time.Time `json:"updated_time"`
+}
+
+type archivedPckCrl struct {
+	Ca              string    `json:"ca"`
+	PckCrlCertChain string    `json:"pck_crl_cert_chain"`
+	PckCrl          string    `json:"pck_crl"`
+	ThisUpdate      time.Time `json:"this_update"`
+	NextUpdate      time.Time `json:"next_update"`
+	CreatedTime     time.Time `json:"created_time"`
+	UpdatedTime     time.Time `json:"updated_time"`
+}
+
+type archivedFmspcTcbInfo struct {
+	Fmspc              string    `json:"fmspc"`
+	TcbInfo            string    `json:"tcb_info"`
+	TcbInfoIssuerChain string    `json:"tcb_info_issuer_chain"`
+	TcbUpdate          string    `json:"tcb_update,omitempty"`
+	CreatedTime        time.Time `json:"created_time"`
+	UpdatedTime        time.Time `json:"updated_time"`
+}
+
+type archivedQEIdentity struct {
+	ID            string    `json:"id"`
+	QeInfo        string    `json:"qe_info"`
+	QeIssuerChain string    `json:"qe_issuer_chain"`
+	TcbUpdate     string    `json:"tcb_update,omitempty"`
+	CreatedTime   time.Time `json:"created_time"`
+	UpdatedTime   time.Time `json:"updated_time"`
+}
+
+type archivedRootCaCrl struct {
+	ID          string    `json:"id"`
+	RootCaCrl   string    `json:"root_ca_crl"`
+	ThisUpdate  time.Time `json:"this_update"`
+	NextUpdate  time.Time `json:"next_update"`
+	CreatedTime time.Time `json:"created_time"`
+	UpdatedTime time.Time `json:"updated_time"`
+}
+
+func CacheArchiveOps(r *mux.Router, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) {
+	r.Handle("/cache/export", handlers.CompressHandler(handlers.ContentTypeHandler(exportCache(db), "application/json"))).Methods("GET")
+	r.Handle("/cache/import", handlers.ContentTypeHandler(importCache(db, conf), "application/json")).Methods("POST")
+}
+
+func cacheArchiveChecksum(records []byte) string {
+	digest := sha256.Sum256(records)
+	return hex.EncodeToString(digest[:])
+}
+
+// retrieveCacheArchiveRecords reads all the records of the repositories, except for the last
+// refresh and pcs audit records which are specific to an instance
+func retrieveCacheArchiveRecords(db repository.SCSDatabase) (*CacheArchiveRecords, error) {
+	records := &CacheArchiveRecords{}
+	platforms, err := db.PlatformRepository().RetrieveAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range platforms {
+		records.Platforms = append(records.Platforms, archivedPlatform(p))
+	}
+
+	platformTcbs, err := db.PlatformTcbRepository().RetrieveAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, tcb := range platformTcbs {
+		records.PlatformTcbs = append(records.PlatformTcbs, archivedPlatformTcb(tcb))
+	}
+
+	pckCerts, err := db.PckCertRepository().RetrieveAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, cert := range pckCerts {
+		records.PckCerts = append(records.PckCerts, archivedPckCert(cert))
+	}
+
+	certChains, err := db.PckCertChainRepository().RetrieveAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, certChain := range certChains {
+		records.PckCertChains = append(records.PckCertChains, archivedPckCertChain(certChain))
+	}
+
+	crls, err := db.PckCrlRepository().RetrieveAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, crl := range crls {
+		records.PckCrls = append(records.PckCrls, archivedPckCrl(crl))
+	}
+
+	tcbInfos, err := db.FmspcTcbInfoRepository().RetrieveAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, tcbInfo := range tcbInfos {
+		records.FmspcTcbInfos = append(records.FmspcTcbInfos, archivedFmspcTcbInfo(tcbInfo))
+	}
+
+	qeIdentity, err := db.QEIdentityRepository().Retrieve()
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	if qeIdentity != nil {
+		records.QeIdentities = append(records.QeIdentities, archivedQEIdentity(*qeIdentity))
+	}
+
+	rootCaCrl, err := db.RootCaCrlRepository().Retrieve()
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	if rootCaCrl != nil {
+		records.RootCaCrls = append(records.RootCaCrls, archivedRootCaCrl(*rootCaCrl))
+	}
+	return records, nil
+}
+
+// validateCacheArchiveRecords checks the keys of the records, the same way as they are checked
+// when the records are cached through the other apis
+func validateCacheArchiveRecords(records *CacheArchiveRecords) error {
+	for _, p := range records.Platforms {
+		if !validateInputString(constants.QeIDKey, p.QeID) || !validateInputString(constants.PceIDKey, p.PceID) {
+			return errors.Errorf("invalid platform with qeid %s and pceid %s", p.QeID, p.PceID)
+		}
+	}
+	for _, tcb := range records.PlatformTcbs {
+		if !validateInputString(constants.QeIDKey, tcb.QeID) || !validateInputString(constants.PceIDKey, tcb.PceID) {
+			return errors.Errorf("invalid platform tcb with qeid %s and pceid %s", tcb.QeID, tcb.PceID)
+		}
+	}
+	for _, cert := range records.PckCerts {
+		if !validateInputString(constants.QeIDKey, cert.QeID) || !validateInputString(constants.PceIDKey, cert.PceID) {
+			return errors.Errorf("invalid pck certs with qeid %s and pceid %s", cert.QeID, cert.PceID)
+		}
+	}
+	for _, certChain := range records.PckCertChains {
+		if !validateInputString(constants.CaKey, certChain.Ca) {
+			return errors.Errorf("invalid pck cert chain with ca %s", certChain.Ca)
+		}
+	}
+	for _, crl := range records.PckCrls {
+		if !validateInputString(constants.CaKey, crl.Ca) {
+			return errors.Errorf("invalid pck crl with ca %s", crl.Ca)
+		}
+	}
+	for _, tcbInfo := range records.FmspcTcbInfos {
+		if !validateInputString(constants.FmspcKey, tcbInfo.Fmspc) {
+			return errors.Errorf("invalid tcb info with fmspc %s", tcbInfo.Fmspc)
+		}
+	}
+	if len(records.QeIdentities) > 1 {
+		return errors.New("more than one qe identity provided")
+	}
+	if len(records.RootCaCrls) > 1 {
+		return errors.New("more than one root ca crl provided")
+	}
+	return nil
+}
+
+// importCacheArchiveRecords upserts the records. A cached record with the same key is replaced
+// rather than updated, so that the record is cached as it was archived, including its empty fields
+func importCacheArchiveRecords(tx repository.SCSDatabase, records *CacheArchiveRecords) error {
+	for _, archived := range records.Platforms {
+		p := types.Platform(archived)
+		existing, err := tx.PlatformRepository().Retrieve(&types.Platform{QeID: p.QeID, PceID: p.PceID})
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		if existing != nil {
+			if err := tx.PlatformRepository().Delete(existing); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.PlatformRepository().Create(&p); err != nil {
+			return errors.Wrapf(err, "could not import platform with qeid %s", p.QeID)
+		}
+	}
+
+	for _, archived := range records.PlatformTcbs {
+		tcb := types.PlatformTcb(archived)
+		existing, err := tx.PlatformTcbRepository().Retrieve(&types.PlatformTcb{QeID: tcb.QeID, PceID: tcb.PceID})
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		if existing != nil {
+			if err := tx.PlatformTcbRepository().Delete(existing); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.PlatformTcbRepository().Create(&tcb); err != nil {
+			return errors.Wrapf(err, "could not import platform tcb with qeid %s", tcb.QeID)
+		}
+	}
+
+	for _, archived := range records.PckCerts {
+		cert := types.PckCert(archived)
+		// pck certs of a platform are cached per TCB level, so the one replaced is of the same TCB level
+		existing, err := tx.PckCertRepository().RetrieveByTcbLevel(&types.PckCert{QeID: cert.QeID, PceID: cert.PceID,
+			CPUSvn: cert.CPUSvn, PceSvn: cert.PceSvn})
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		if existing != nil {
+			if err := tx.PckCertRepository().Delete(existing); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.PckCertRepository().Create(&cert); err != nil {
+			return errors.Wrapf(err, "could not import pck certs with qeid %s", cert.QeID)
+		}
+	}
+
+	for _, archived := range records.PckCertChains {
+		certChain := types.PckCertChain(archived)
+		existing, err := tx.PckCertChainRepository().Retrieve(&types.PckCertChain{Ca: certChain.Ca})
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		if existing != nil {
+			if err := tx.PckCertChainRepository().Delete(existing); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.PckCertChainRepository().Create(&certChain); err != nil {
+			return errors.Wrapf(err, "could not import pck cert chain with ca %s", certChain.Ca)
+		}
+	}
+
+	for _, archived := range records.PckCrls {
+		crl := types.PckCrl(archived)
+		existing, err := tx.PckCrlRepository().Retrieve(&types.PckCrl{Ca: crl.Ca})
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		if existing != nil {
+			if err := tx.PckCrlRepository().Delete(existing); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.PckCrlRepository().Create(&crl); err != nil {
+			return errors.Wrapf(err, "could not import pck crl with ca %s", crl.Ca)
+		}
+	}
+
+	for _, archived := range records.FmspcTcbInfos {
+		tcbInfo := types.FmspcTcbInfo(archived)
+		existing, err := tx.FmspcTcbInfoRepository().Retrieve(&types.FmspcTcbInfo{Fmspc: tcbInfo.Fmspc})
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		if existing != nil {
+			if err := tx.FmspcTcbInfoRepository().Delete(existing); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.FmspcTcbInfoRepository().Create(&tcbInfo); err != nil {
+			return errors.Wrapf(err, "could not import tcb info with fmspc %s", tcbInfo.Fmspc)
+		}
+	}
+
+	for _, archived := range records.QeIdentities {
+		qeIdentity := types.QEIdentity(archived)
+		existing, err := tx.QEIdentityRepository().Retrieve()
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		if existing != nil {
+			if err := tx.QEIdentityRepository().Delete(existing); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.QEIdentityRepository().Create(&qeIdentity); err != nil {
+			return errors.Wrap(err, "could not import qe identity")
+		}
+	}
+
+	for _, archived := range records.RootCaCrls {
+		rootCaCrl := types.RootCaCrl(archived)
+		existing, err := tx.RootCaCrlRepository().Retrieve()
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		if existing != nil {
+			if err := tx.RootCaCrlRepository().Delete(existing); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.RootCaCrlRepository().Create(&rootCaCrl); err != nil {
+			return errors.Wrap(err, "could not import root ca crl")
+		}
+	}
+	return nil
+}
+
+// exportCache writes a versioned archive of all the cached records, which can be imported into
+// another instance for offline provisioning without access to PCS
+func exportCache(db repository.SCSDatabase) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.CacheManagerGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		if len(r.URL.Query()) != 0 {
+			slog.Error("resource/cache_archive_ops: exportCache() Query params not supported")
+			return &resourceError{Message: "query parameters are not supported", StatusCode: http.StatusBadRequest}
+		}
+
+		records, err := retrieveCacheArchiveRecords(db)
+		if err != nil {
+			slog.WithError(err).Error("resource/cache_archive_ops: exportCache() Failed to retrieve cached records")
+			return &resourceError{Message: "could not retrieve cached records", StatusCode: http.StatusInternalServerError}
+		}
+		recordsJSON, err := json.Marshal(records)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		archive := CacheArchive{
+			Version:     constants.CacheArchiveVersion,
+			CreatedTime: clock.Now().UTC(),
+			Checksum:    cacheArchiveChecksum(recordsJSON),
+			Records:     recordsJSON,
+		}
+		js, err := json.Marshal(archive)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=scs-cache-%s.json",
+			archive.CreatedTime.Format("20060102T150405Z")))
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(js)
+		if err != nil {
+			log.WithError(err).Error("Could not write cache archive to response")
+		}
+		slog.Infof("%s: Cache exported by: %s", commLogMsg.AuthorizedAccess, r.RemoteAddr)
+		return nil
+	}
+}
+
+// importCache upserts the records of an archive created by exportCache, in a single transaction so
+// that either all or none of the records are imported
+func importCache(db repository.SCSDatabase, conf *config.Configuration) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.CacheManagerGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		if r.ContentLength == 0 {
+			slog.Error("resource/cache_archive_ops: importCache() The request body was not provided")
+			return &resourceError{Message: "cache archive not provided",
+				StatusCode: http.StatusBadRequest}
+		}
+
+		var archive CacheArchive
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&archive)
+		if err != nil {
+			slog.WithError(err).Errorf("resource/cache_archive_ops: importCache() %s :  Failed to decode request body", commLogMsg.InvalidInputBadEncoding)
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusBadRequest}
+		}
+
+		if archive.Version != constants.CacheArchiveVersion {
+			slog.Errorf("resource/cache_archive_ops: importCache() Unsupported cache archive version %d", archive.Version)
+			return &resourceError{Message: fmt.Sprintf("unsupported cache archive version %d, expected %d", archive.Version, constants.CacheArchiveVersion),
+				StatusCode: http.StatusBadRequest}
+		}
+		if archive.Checksum != cacheArchiveChecksum(archive.Records) {
+			slog.Error("resource/cache_archive_ops: importCache() Cache archive checksum mismatch")
+			return &resourceError{Message: "cache archive checksum does not match its records", StatusCode: http.StatusBadRequest}
+		}
+
+		var records CacheArchiveRecords
+		dec = json.NewDecoder(bytes.NewReader(archive.Records))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&records)
+		if err != nil {
+			slog.WithError(err).Errorf("resource/cache_archive_ops: importCache() %s :  Failed to decode cache archive records", commLogMsg.InvalidInputBadEncoding)
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusBadRequest}
+		}
+		err = validateCacheArchiveRecords(&records)
+		if err != nil {
+			slog.WithError(err).Error("resource/cache_archive_ops: importCache() Input validation failed")
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusBadRequest}
+		}
+
+		err = db.ExecuteInTransaction(func(tx repository.SCSDatabase) error {
+			return importCacheArchiveRecords(tx, &records)
+		})
+		if err != nil {
+			slog.WithError(err).Error("resource/cache_archive_ops: importCache() Failed to import cache archive")
+			return &resourceError{Message: "could not import cache archive", StatusCode: http.StatusInternalServerError}
+		}
+		for i := range records.FmspcTcbInfos {
+			tcbInfo := types.FmspcTcbInfo(records.FmspcTcbInfos[i])
+			cacheTcbLevels(db, &tcbInfo, conf)
+		}
+
+		result := CacheImportResult{
+			Platforms:     len(records.Platforms),
+			PlatformTcbs:  len(records.PlatformTcbs),
+			PckCerts:      len(records.PckCerts),
+			PckCertChains: len(records.PckCertChains),
+			PckCrls:       len(records.PckCrls),
+			FmspcTcbInfos: len(records.FmspcTcbInfos),
+			QeIdentities:  len(records.QeIdentities),
+			RootCaCrls:    len(records.RootCaCrls),
+		}
+		js, err := json.Marshal(result)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(js)
+		if err != nil {
+			log.WithError(err).Error("Could not write cache import result to response")
+		}
+		slog.Infof("%s: Cache archive created at %s imported by: %s", commLogMsg.AuthorizedAccess,
+			archive.CreatedTime.Format(time.RFC3339), r.RemoteAddr)
+		return nil
+	}
+}
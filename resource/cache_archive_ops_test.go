@@ -0,0 +1,186 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"intel/isecl/lib/common/v5/context"
+	"intel/isecl/lib/common/v5/types/aas"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/repository/memory"
+	"intel/isecl/scs/v5/types"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gorilla/mux"
+	consts "github.com/intel-secl/intel-secl/v5/pkg/lib/common/constants"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cache Archive Validation", func() {
+	var exportDB, importDB *memory.Database
+
+	cacheArchiveResponse := func(db *memory.Database, method, urlPath string, body io.Reader) *httptest.ResponseRecorder {
+		router := mux.NewRouter()
+		CacheArchiveOps(router, db, nil, nil)
+
+		req, err := http.NewRequest(method, urlPath, body)
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+
+		permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.CacheManagerGroupName}}
+		req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+		roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.CacheManagerGroupName, Context: "type=SCS"}}
+		req = context.SetUserRoles(req, roleInfo)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	exportArchive := func() CacheArchive {
+		w := cacheArchiveResponse(exportDB, http.MethodGet, "/cache/export", nil)
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var archive CacheArchive
+		Expect(json.Unmarshal(w.Body.Bytes(), &archive)).To(Succeed())
+		return archive
+	}
+
+	importArchive := func(archive CacheArchive) *httptest.ResponseRecorder {
+		js, err := json.Marshal(archive)
+		Expect(err).NotTo(HaveOccurred())
+		return cacheArchiveResponse(importDB, http.MethodPost, "/cache/import", bytes.NewReader(js))
+	}
+
+	BeforeEach(func() {
+		exportDB = memory.NewDatabase()
+		exportDB.PlatformRepository().Create(&types.Platform{QeID: "6518145496973c5e69577195511e9080", PceID: "0000", Fmspc: "20606a000000", Ppid: "ppid"})
+		exportDB.PlatformTcbRepository().Create(&types.PlatformTcb{QeID: "6518145496973c5e69577195511e9080", PceID: "0000", Tcbm: "tcbm"})
+		exportDB.PckCertRepository().Create(&types.PckCert{QeID: "6518145496973c5e69577195511e9080", PceID: "0000", CPUSvn: "cpusvn", PceSvn: "pcesvn",
+			Tcbms: []string{"tcbm"}, PckCerts: []string{"pckcert"}})
+		exportDB.PckCertChainRepository().Create(&types.PckCertChain{Ca: "processor", PckCertChain: "certchain"})
+		exportDB.PckCrlRepository().Create(&types.PckCrl{Ca: "processor", PckCrl: "crl", PckCrlCertChain: "crlchain"})
+		exportDB.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: "20606a000000", TcbInfo: "tcbinfo"})
+		exportDB.QEIdentityRepository().Create(&types.QEIdentity{ID: "QE", QeInfo: "qeinfo"})
+		exportDB.RootCaCrlRepository().Create(&types.RootCaCrl{ID: "RootCA", RootCaCrl: "rootcacrl"})
+
+		importDB = memory.NewDatabase()
+		importDB.PlatformRepository().Create(&types.Platform{QeID: "6518145496973c5e69577195511e9080", PceID: "0000", Fmspc: "20606a000000",
+			Manifest: "manifest", Ppid: "ppid"})
+		importDB.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: "00906ed50000", TcbInfo: "tcbinfo"})
+		importDB.RootCaCrlRepository().Create(&types.RootCaCrl{ID: "RootCA", RootCaCrl: "oldrootcacrl"})
+	})
+
+	Describe("exportCache and importCache validation", func() {
+		Context("exportCache", func() {
+
+			It("Should return StatusBadRequest - Query params not supported", func() {
+				Expect(cacheArchiveResponse(exportDB, http.MethodGet, "/cache/export?fmspc=20606a000000", nil).Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusOK - Archive of all the cached records", func() {
+				archive := exportArchive()
+				Expect(archive.Version).To(Equal(constants.CacheArchiveVersion))
+				Expect(archive.Checksum).To(Equal(cacheArchiveChecksum(archive.Records)))
+
+				var records CacheArchiveRecords
+				Expect(json.Unmarshal(archive.Records, &records)).To(Succeed())
+				Expect(records.Platforms).To(HaveLen(1))
+				Expect(records.PckCerts[0].PckCerts).To(ConsistOf("pckcert"))
+				Expect(records.PckCertChains).To(HaveLen(1))
+				Expect(records.PckCrls).To(HaveLen(1))
+				Expect(records.QeIdentities).To(HaveLen(1))
+				Expect(records.RootCaCrls).To(HaveLen(1))
+			})
+		})
+
+		Context("importCache", func() {
+
+			It("Should return StatusBadRequest - Unsupported archive version", func() {
+				archive := exportArchive()
+				archive.Version = constants.CacheArchiveVersion + 1
+				Expect(importArchive(archive).Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusBadRequest - Archive checksum mismatch", func() {
+				archive := exportArchive()
+				archive.Records = bytes.Replace(archive.Records, []byte("tcbinfo"), []byte("tcbinf0"), 1)
+				Expect(importArchive(archive).Code).To(Equal(http.StatusBadRequest))
+
+				tcbInfos, err := importDB.FmspcTcbInfoRepository().RetrieveAll()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tcbInfos).To(HaveLen(1))
+			})
+
+			It("Should return StatusBadRequest - More than one root ca crl in archive", func() {
+				records := []byte(`{"root_ca_crls": [{"id": "RootCA"}, {"id": "RootCA"}]}`)
+				archive := CacheArchive{Version: constants.CacheArchiveVersion, Checksum: cacheArchiveChecksum(records), Records: records}
+				Expect(importArchive(archive).Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusBadRequest - Invalid record in archive", func() {
+				records := []byte(`{"fmspc_tcb_infos": [{"fmspc": "20606a"}]}`)
+				archive := CacheArchive{Version: constants.CacheArchiveVersion, Checksum: cacheArchiveChecksum(records), Records: records}
+				Expect(importArchive(archive).Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusOK - Records upserted", func() {
+				w := importArchive(exportArchive())
+				Expect(w.Code).To(Equal(http.StatusOK))
+
+				var result CacheImportResult
+				Expect(json.Unmarshal(w.Body.Bytes(), &result)).To(Succeed())
+				Expect(result).To(Equal(CacheImportResult{Platforms: 1, PlatformTcbs: 1, PckCerts: 1, PckCertChains: 1, PckCrls: 1,
+					FmspcTcbInfos: 1, QeIdentities: 1, RootCaCrls: 1}))
+
+				platform, err := importDB.PlatformRepository().Retrieve(&types.Platform{QeID: "6518145496973c5e69577195511e9080", PceID: "0000"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(platform.Manifest).To(BeEmpty())
+				tcbInfos, err := importDB.FmspcTcbInfoRepository().RetrieveAll()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tcbInfos).To(HaveLen(2))
+				pckCert, err := importDB.PckCertRepository().Retrieve(&types.PckCert{QeID: "6518145496973c5e69577195511e9080", PceID: "0000"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pckCert.PckCerts).To(ConsistOf("pckcert"))
+				_, err = importDB.QEIdentityRepository().Retrieve()
+				Expect(err).NotTo(HaveOccurred())
+				rootCaCrl, err := importDB.RootCaCrlRepository().Retrieve()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rootCaCrl.RootCaCrl).To(Equal("rootcacrl"))
+			})
+
+			It("Should return StatusOK - Pck certs of every TCB level of a platform replaced", func() {
+				qeID := "6518145496973c5e69577195511e9080"
+				exportDB.PckCertRepository().Create(&types.PckCert{QeID: qeID, PceID: "0000", CPUSvn: "cpusvn2", PceSvn: "pcesvn2",
+					CertIndex: 1, Tcbms: []string{"tcbm", "tcbm2"}, PckCerts: []string{"pckcert", "pckcert2"}})
+				// both TCB levels are cached already, in the other order than they are archived
+				importDB.PckCertRepository().Create(&types.PckCert{QeID: qeID, PceID: "0000", CPUSvn: "cpusvn2", PceSvn: "pcesvn2",
+					Tcbms: []string{"old"}, PckCerts: []string{"old"}})
+				importDB.PckCertRepository().Create(&types.PckCert{QeID: qeID, PceID: "0000", CPUSvn: "cpusvn", PceSvn: "pcesvn",
+					Tcbms: []string{"old"}, PckCerts: []string{"old"}})
+
+				w := importArchive(exportArchive())
+				Expect(w.Code).To(Equal(http.StatusOK))
+
+				pckCerts, err := importDB.PckCertRepository().RetrieveAll()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pckCerts).To(HaveLen(2))
+				pckCert, err := importDB.PckCertRepository().RetrieveByTcbLevel(&types.PckCert{QeID: qeID, PceID: "0000",
+					CPUSvn: "cpusvn", PceSvn: "pcesvn"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pckCert.PckCerts).To(ConsistOf("pckcert"))
+				pckCert, err = importDB.PckCertRepository().RetrieveByTcbLevel(&types.PckCert{QeID: qeID, PceID: "0000",
+					CPUSvn: "cpusvn2", PceSvn: "pcesvn2"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pckCert.CertIndex).To(Equal(uint8(1)))
+				Expect(pckCert.PckCerts).To(ConsistOf("pckcert", "pckcert2"))
+			})
+		})
+	})
+})
@@ -0,0 +1,148 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	commLogMsg "intel/isecl/lib/common/v5/log/message"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/domain"
+	"intel/isecl/scs/v5/repository"
+	"intel/isecl/scs/v5/types"
+	"net/http"
+
+	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+type CachePurgeRequest struct {
+	Fmspcs         []string `json:"fmspcs"`
+	PurgePlatforms bool     `json:"purge_platforms"`
+}
+
+type CachePurgeResult struct {
+	Fmspc           string `json:"fmspc"`
+	Status          string `json:"status"`
+	PurgedPlatforms int    `json:"purged_platforms"`
+	Error           string `json:"error,omitempty"`
+}
+
+func CachePurgeOps(r *mux.Router, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) {
+	r.Handle("/cache/purge", handlers.ContentTypeHandler(purgeCache(db), "application/json")).Methods("POST")
+}
+
+// purgeFmspc deletes the TcbInfo of an fmspc in a transaction, along with the platforms of the
+// fmspc when purgePlatforms is set
+func purgeFmspc(db repository.SCSDatabase, fmspc string, purgePlatforms bool) CachePurgeResult {
+	result := CachePurgeResult{Fmspc: fmspc, Status: constants.CachePurgeStatusNotFound}
+	err := db.ExecuteInTransaction(func(tx repository.SCSDatabase) error {
+		purged := false
+		tcbInfo, err := tx.FmspcTcbInfoRepository().Retrieve(&types.FmspcTcbInfo{Fmspc: fmspc})
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		if tcbInfo != nil {
+			if err := tx.FmspcTcbInfoRepository().Delete(tcbInfo); err != nil {
+				return err
+			}
+			if err := tx.TcbLevelRepository().DeleteByFmspc(fmspc); err != nil {
+				return err
+			}
+			purged = true
+		}
+
+		if purgePlatforms {
+			platforms, err := tx.PlatformRepository().RetrieveByFmspc(fmspc)
+			if err != nil {
+				return err
+			}
+			for i := range platforms {
+				if err := deletePlatform(tx, &platforms[i]); err != nil {
+					return errors.Wrapf(err, "could not purge platform with qeid %s", platforms[i].QeID)
+				}
+			}
+			result.PurgedPlatforms = len(platforms)
+			purged = purged || len(platforms) > 0
+		}
+
+		if purged {
+			result.Status = constants.CachePurgeStatusPurged
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Errorf("Could not purge cached collaterals of fmspc %s", fmspc)
+		return CachePurgeResult{Fmspc: fmspc, Status: constants.CachePurgeStatusFailed, Error: err.Error()}
+	}
+	if result.Status == constants.CachePurgeStatusPurged {
+		log.Infof("Purged TcbInfo and %d platforms of fmspc %s", result.PurgedPlatforms, fmspc)
+	}
+	return result
+}
+
+// purgeCache drops the cached TcbInfo, and optionally the platforms, of a list of fmspcs, e.g. when
+// retiring platform generations. Each fmspc is purged in its own transaction and reported separately
+func purgeCache(db repository.SCSDatabase) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.CacheManagerGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		if r.ContentLength == 0 {
+			slog.Error("resource/cache_purge_ops: purgeCache() The request body was not provided")
+			return &resourceError{Message: "purge request not provided",
+				StatusCode: http.StatusBadRequest}
+		}
+
+		var purgeReq CachePurgeRequest
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&purgeReq)
+		if err != nil {
+			slog.WithError(err).Errorf("resource/cache_purge_ops: purgeCache() %s :  Failed to decode request body", commLogMsg.InvalidInputBadEncoding)
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusBadRequest}
+		}
+
+		if len(purgeReq.Fmspcs) == 0 || len(purgeReq.Fmspcs) > constants.MaxCachePurgeFmspcs {
+			return &resourceError{Message: fmt.Sprintf("between 1 and %d fmspcs should be provided", constants.MaxCachePurgeFmspcs),
+				StatusCode: http.StatusBadRequest}
+		}
+		fmspcs := make([]string, 0, len(purgeReq.Fmspcs))
+		seen := make(map[string]bool)
+		for _, fmspc := range purgeReq.Fmspcs {
+			if !validateInputString(constants.FmspcKey, fmspc) {
+				slog.Error("resource/cache_purge_ops: purgeCache() Input validation failed")
+				return &resourceError{Message: "invalid fmspc: " + fmspc, StatusCode: http.StatusBadRequest}
+			}
+			if !seen[fmspc] {
+				seen[fmspc] = true
+				fmspcs = append(fmspcs, fmspc)
+			}
+		}
+
+		results := make([]CachePurgeResult, len(fmspcs))
+		for i, fmspc := range fmspcs {
+			results[i] = purgeFmspc(db, fmspc, purgeReq.PurgePlatforms)
+		}
+
+		js, err := json.Marshal(results)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(js)
+		if err != nil {
+			log.WithError(err).Error("Could not write cache purge results to response")
+		}
+		slog.Infof("%s: Cache purged for %d fmspcs by: %s", commLogMsg.AuthorizedAccess, len(fmspcs), r.RemoteAddr)
+		return nil
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"encoding/json"
+	"intel/isecl/lib/common/v5/context"
+	"intel/isecl/lib/common/v5/types/aas"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/repository/memory"
+	"intel/isecl/scs/v5/types"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gorilla/mux"
+	consts "github.com/intel-secl/intel-secl/v5/pkg/lib/common/constants"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cache Purge Validation", func() {
+	var router *mux.Router
+	var w *httptest.ResponseRecorder
+	var db *memory.Database
+
+	purgeCacheResponse := func(body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, "/cache/purge", strings.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+
+		permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.CacheManagerGroupName}}
+		req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+		roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.CacheManagerGroupName, Context: "type=SCS"}}
+		req = context.SetUserRoles(req, roleInfo)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	BeforeEach(func() {
+		router = mux.NewRouter()
+		db = memory.NewDatabase()
+		CachePurgeOps(router, db, nil, nil)
+
+		db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: "20606a000000", TcbInfo: "tcbinfo"})
+		db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: "00906ed50000", TcbInfo: "tcbinfo"})
+		db.PlatformRepository().Create(&types.Platform{QeID: "6518145496973c5e69577195511e9080", PceID: "0000", Fmspc: "20606a000000", Ppid: "ppid"})
+		db.PckCertRepository().Create(&types.PckCert{QeID: "6518145496973c5e69577195511e9080", PceID: "0000", CPUSvn: "cpusvn", PceSvn: "pcesvn"})
+		db.PlatformRepository().Create(&types.Platform{QeID: "7518145496973c5e69577195511e9080", PceID: "0000", Fmspc: "00906ed50000", Ppid: "ppid"})
+	})
+
+	Describe("purgeCache validation", func() {
+		Context("purgeCache", func() {
+
+			It("Should return StatusBadRequest - Request body not provided", func() {
+				Expect(purgeCacheResponse("").Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusBadRequest - Unknown field in request", func() {
+				Expect(purgeCacheResponse(`{"fmspcs": ["20606a000000"], "ca": "processor"}`).Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusBadRequest - No fmspc provided", func() {
+				Expect(purgeCacheResponse(`{"fmspcs": []}`).Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusBadRequest - Invalid fmspc provided", func() {
+				Expect(purgeCacheResponse(`{"fmspcs": ["20606a000000", "20606a"]}`).Code).To(Equal(http.StatusBadRequest))
+				_, err := db.FmspcTcbInfoRepository().Retrieve(&types.FmspcTcbInfo{Fmspc: "20606a000000"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("Should return StatusOK - TcbInfo purged and platforms retained", func() {
+				w := purgeCacheResponse(`{"fmspcs": ["20606a000000", "20606a000000", "30606a000000"]}`)
+				Expect(w.Code).To(Equal(http.StatusOK))
+
+				var results []CachePurgeResult
+				Expect(json.Unmarshal(w.Body.Bytes(), &results)).To(Succeed())
+				Expect(results).To(Equal([]CachePurgeResult{
+					{Fmspc: "20606a000000", Status: constants.CachePurgeStatusPurged},
+					{Fmspc: "30606a000000", Status: constants.CachePurgeStatusNotFound},
+				}))
+
+				_, err := db.FmspcTcbInfoRepository().Retrieve(&types.FmspcTcbInfo{Fmspc: "20606a000000"})
+				Expect(err).To(HaveOccurred())
+				platforms, _ := db.PlatformRepository().RetrieveAll()
+				Expect(platforms).To(HaveLen(2))
+			})
+
+			It("Should return StatusOK - TCB levels of purged TcbInfo deleted", func() {
+				db.TcbLevelRepository().Replace("20606a000000", types.TcbLevels{{Fmspc: "20606a000000", Level: 1, TcbStatus: "UpToDate"}})
+				db.TcbLevelRepository().Replace("00906ed50000", types.TcbLevels{{Fmspc: "00906ed50000", Level: 1, TcbStatus: "UpToDate"}})
+				w := purgeCacheResponse(`{"fmspcs": ["20606a000000"]}`)
+				Expect(w.Code).To(Equal(http.StatusOK))
+
+				levels, _ := db.TcbLevelRepository().RetrieveByFmspc("20606a000000")
+				Expect(levels).To(BeEmpty())
+				levels, _ = db.TcbLevelRepository().RetrieveByFmspc("00906ed50000")
+				Expect(levels).To(HaveLen(1))
+			})
+
+			It("Should return StatusOK - TcbInfo and platforms purged", func() {
+				w := purgeCacheResponse(`{"fmspcs": ["20606a000000"], "purge_platforms": true}`)
+				Expect(w.Code).To(Equal(http.StatusOK))
+
+				var results []CachePurgeResult
+				Expect(json.Unmarshal(w.Body.Bytes(), &results)).To(Succeed())
+				Expect(results).To(Equal([]CachePurgeResult{
+					{Fmspc: "20606a000000", Status: constants.CachePurgeStatusPurged, PurgedPlatforms: 1},
+				}))
+
+				platforms, _ := db.PlatformRepository().RetrieveAll()
+				Expect(platforms).To(HaveLen(1))
+				Expect(platforms[0].Fmspc).To(Equal("00906ed50000"))
+				pckCerts, _ := db.PckCertRepository().RetrieveAll()
+				Expect(pckCerts).To(BeEmpty())
+				_, err := db.FmspcTcbInfoRepository().Retrieve(&types.FmspcTcbInfo{Fmspc: "00906ed50000"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+})
@@ -0,0 +1,207 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"encoding/json"
+	commLogMsg "intel/isecl/lib/common/v5/log/message"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/domain"
+	"intel/isecl/scs/v5/repository"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// CollateralCacheStats reports how often reads of a collateral are served from the cache (hits) and
+// how often they trigger a fetch from PCS server (misses). Fetches counts every lazy fetch of the
+// collateral from PCS server, including the ones done while refreshing the cache. LatestUpdatedTime
+// is the time the most recently updated record of the collateral was fetched or refreshed, and
+// ConsecutiveRefreshFailures is the number of refreshes of the collateral failed since it was last refreshed.
+// Expiring is reported for pck certs only, as the number of selected pck certs which have expired or
+// expire within PckCertExpiryWarningDays
+type CollateralCacheStats struct {
+	Collateral                 string     `json:"collateral"`
+	Hits                       uint64     `json:"hits"`
+	Misses                     uint64     `json:"misses"`
+	MissRate                   float64    `json:"miss_rate"`
+	Fetches                    uint64     `json:"fetches"`
+	LatestUpdatedTime          *time.Time `json:"latest_updated_time,omitempty"`
+	ConsecutiveRefreshFailures int        `json:"consecutive_refresh_failures"`
+	Expiring                   *int       `json:"expiring,omitempty"`
+}
+
+type collateralCounters struct {
+	hits    uint64
+	misses  uint64
+	fetches uint64
+}
+
+// cacheStats holds the counters of each collateral, the set of collaterals is fixed so that
+// counters can be updated atomically without locking
+type cacheStats struct {
+	counters map[string]*collateralCounters
+}
+
+var cacheStatsCollaterals = []string{constants.CollateralPckCert, constants.CollateralPckCrl,
+	constants.CollateralTcbInfo, constants.CollateralQeIdentity, constants.CollateralRootCaCrl}
+
+func newCacheStats() *cacheStats {
+	s := &cacheStats{counters: make(map[string]*collateralCounters)}
+	for _, collateral := range cacheStatsCollaterals {
+		s.counters[collateral] = &collateralCounters{}
+	}
+	return s
+}
+
+// stats is replaced only by tests
+var stats = newCacheStats()
+
+func recordCacheHit(collateral string) {
+	atomic.AddUint64(&stats.counters[collateral].hits, 1)
+}
+
+func recordCacheMiss(collateral string) {
+	atomic.AddUint64(&stats.counters[collateral].misses, 1)
+}
+
+func recordCacheFetch(collateral string) {
+	atomic.AddUint64(&stats.counters[collateral].fetches, 1)
+}
+
+func (s *cacheStats) snapshot() []CollateralCacheStats {
+	result := make([]CollateralCacheStats, 0, len(cacheStatsCollaterals))
+	for _, collateral := range cacheStatsCollaterals {
+		c := s.counters[collateral]
+		cs := CollateralCacheStats{
+			Collateral: collateral,
+			Hits:       atomic.LoadUint64(&c.hits),
+			Misses:     atomic.LoadUint64(&c.misses),
+			Fetches:    atomic.LoadUint64(&c.fetches),
+		}
+		if reads := cs.Hits + cs.Misses; reads > 0 {
+			cs.MissRate = float64(cs.Misses) / float64(reads)
+		}
+		result = append(result, cs)
+	}
+	return result
+}
+
+// latestUpdatedTimes returns the updated time of the most recently updated record of each cached
+// collateral, a collateral without any record is left out
+func latestUpdatedTimes(db repository.SCSDatabase) (map[string]time.Time, error) {
+	retrievers := map[string]func() (time.Time, error){
+		constants.CollateralPckCert: func() (time.Time, error) {
+			pckCert, err := db.PckCertRepository().RetrieveLatestUpdated()
+			if err != nil {
+				return time.Time{}, err
+			}
+			return pckCert.UpdatedTime, nil
+		},
+		constants.CollateralPckCrl: func() (time.Time, error) {
+			pckCrl, err := db.PckCrlRepository().RetrieveLatestUpdated()
+			if err != nil {
+				return time.Time{}, err
+			}
+			return pckCrl.UpdatedTime, nil
+		},
+		constants.CollateralTcbInfo: func() (time.Time, error) {
+			tcbInfo, err := db.FmspcTcbInfoRepository().RetrieveLatestUpdated()
+			if err != nil {
+				return time.Time{}, err
+			}
+			return tcbInfo.UpdatedTime, nil
+		},
+		// Retrieve returns the most recently updated QE identity
+		constants.CollateralQeIdentity: func() (time.Time, error) {
+			qeIdentity, err := db.QEIdentityRepository().Retrieve()
+			if err != nil {
+				return time.Time{}, err
+			}
+			return qeIdentity.UpdatedTime, nil
+		},
+		constants.CollateralRootCaCrl: func() (time.Time, error) {
+			rootCaCrl, err := db.RootCaCrlRepository().Retrieve()
+			if err != nil {
+				return time.Time{}, err
+			}
+			return rootCaCrl.UpdatedTime, nil
+		},
+	}
+
+	latest := make(map[string]time.Time)
+	for collateral, retrieve := range retrievers {
+		updatedTime, err := retrieve()
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "failed to retrieve the latest updated %s", collateral)
+		}
+		latest[collateral] = updatedTime
+	}
+	return latest, nil
+}
+
+func CacheStatsOps(r *mux.Router, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) {
+	r.Handle("/cache/stats", getCacheStats(db, conf)).Methods("GET")
+}
+
+// getCacheStats reports the cache hits and misses of each collateral since the service started,
+// to tell whether reads are served by the pre-cached collaterals or dominated by lazy fetches,
+// along with the time the collateral was last updated as a freshness indicator and the number of
+// pck certs expiring
+func getCacheStats(db repository.SCSDatabase, conf *config.Configuration) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.CacheManagerGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		if len(r.URL.Query()) != 0 {
+			slog.Error("resource/cache_stats_ops: getCacheStats() Query params not supported")
+			return &resourceError{Message: "query parameters are not supported", StatusCode: http.StatusBadRequest}
+		}
+
+		latest, err := latestUpdatedTimes(db)
+		if err != nil {
+			log.WithError(err).Error("resource/cache_stats_ops: getCacheStats() Error retrieving latest updated collaterals")
+			return &resourceError{Message: "failed to retrieve latest updated collaterals", StatusCode: http.StatusInternalServerError}
+		}
+		expiring, err := db.PckCertRepository().RetrieveExpiringBefore(pckCertExpiryWarningTime(conf))
+		if err != nil {
+			log.WithError(err).Error("resource/cache_stats_ops: getCacheStats() Error retrieving expiring pck certs")
+			return &resourceError{Message: "failed to retrieve expiring pck certs", StatusCode: http.StatusInternalServerError}
+		}
+		expiringCount := len(expiring)
+		snapshot := stats.snapshot()
+		for i := range snapshot {
+			if snapshot[i].Collateral == constants.CollateralPckCert {
+				snapshot[i].Expiring = &expiringCount
+			}
+			if t, ok := latest[snapshot[i].Collateral]; ok {
+				snapshot[i].LatestUpdatedTime = &t
+			}
+			snapshot[i].ConsecutiveRefreshFailures = failures.consecutiveFailures(snapshot[i].Collateral)
+		}
+
+		js, err := json.Marshal(snapshot)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(js)
+		if err != nil {
+			log.WithError(err).Error("Could not write cache stats to response")
+		}
+		slog.Infof("%s: Cache stats retrieved by: %s", commLogMsg.AuthorizedAccess, r.RemoteAddr)
+		return nil
+	}
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	"intel/isecl/lib/common/v5/context"
+	"intel/isecl/lib/common/v5/types/aas"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/repository/memory"
+	"intel/isecl/scs/v5/types"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// useCacheStats resets the cache stats of resource package until the test completes
+func useCacheStats(t *testing.T) {
+	stats = newCacheStats()
+	t.Cleanup(func() {
+		stats = newCacheStats()
+	})
+}
+
+func cacheStatsResponse(db *memory.Database, urlPath string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	CacheStatsOps(router, db, nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, urlPath, nil)
+	permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.CacheManagerGroupName}}
+	req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+	roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.CacheManagerGroupName, Context: "type=SCS"}}
+	req = context.SetUserRoles(req, roleInfo)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCacheStatsConcurrentUpdates(t *testing.T) {
+	useCacheStats(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				recordCacheHit(constants.CollateralTcbInfo)
+				recordCacheMiss(constants.CollateralPckCrl)
+				recordCacheFetch(constants.CollateralPckCrl)
+			}
+		}()
+	}
+	wg.Wait()
+
+	snapshot := stats.snapshot()
+	assert.Len(t, snapshot, 5)
+	for _, cs := range snapshot {
+		switch cs.Collateral {
+		case constants.CollateralTcbInfo:
+			assert.Equal(t, CollateralCacheStats{Collateral: cs.Collateral, Hits: 1000}, cs)
+		case constants.CollateralPckCrl:
+			assert.Equal(t, CollateralCacheStats{Collateral: cs.Collateral, Misses: 1000, MissRate: 1, Fetches: 1000}, cs)
+		default:
+			assert.Equal(t, CollateralCacheStats{Collateral: cs.Collateral}, cs)
+		}
+	}
+}
+
+func TestGetCacheStats(t *testing.T) {
+	useCacheStats(t)
+	useRefreshFailures(t)
+
+	db := memory.NewDatabase()
+	_, err := db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: "20606a000000", TcbInfo: "tcbinfo"})
+	assert.NoError(t, err)
+
+	// a tcb info served from the cache is a hit, one which is not cached is a miss
+	router := mux.NewRouter()
+	QuoteProviderOps(router, db, nil, nil)
+	for _, fmspc := range []string{"20606a000000", "20606a000000", "20606a000000", "00906ed50000"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tcb?fmspc="+fmspc, nil))
+	}
+
+	assert.Equal(t, http.StatusBadRequest, cacheStatsResponse(db, "/cache/stats?collateral=tcbinfo").Code)
+
+	w := cacheStatsResponse(db, "/cache/stats")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var snapshot []CollateralCacheStats
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &snapshot))
+	assert.Len(t, snapshot, 5)
+	assert.NotNil(t, snapshot[2].LatestUpdatedTime)
+	snapshot[2].LatestUpdatedTime = nil
+	assert.Equal(t, CollateralCacheStats{Collateral: constants.CollateralTcbInfo, Hits: 3, Misses: 1, MissRate: 0.25, Fetches: 1}, snapshot[2])
+}
+
+func TestGetCacheStatsExpiringPckCerts(t *testing.T) {
+	useCacheStats(t)
+	useRefreshFailures(t)
+
+	db := memory.NewDatabase()
+	now := time.Now().UTC()
+	for i, notAfter := range []time.Time{now.AddDate(0, 0, -1), now.AddDate(0, 0, 10), now.AddDate(1, 0, 0), {}} {
+		_, err := db.PckCertRepository().Create(&types.PckCert{QeID: fmt.Sprintf("%031d%d", 0, i), PceID: "0000", NotAfter: notAfter})
+		assert.NoError(t, err)
+	}
+
+	w := cacheStatsResponse(db, "/cache/stats")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var snapshot []CollateralCacheStats
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &snapshot))
+	for _, cs := range snapshot {
+		if cs.Collateral == constants.CollateralPckCert {
+			// an expired pck cert and one expiring within the default warning days
+			if assert.NotNil(t, cs.Expiring) {
+				assert.Equal(t, 2, *cs.Expiring)
+			}
+		} else {
+			assert.Nil(t, cs.Expiring)
+		}
+	}
+}
+
+func TestGetCacheStatsLatestUpdated(t *testing.T) {
+	useCacheStats(t)
+	useRefreshFailures(t)
+
+	db := memory.NewDatabase()
+	updatedTime := time.Date(2022, 6, 21, 10, 0, 0, 0, time.UTC)
+	_, err := db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: "00906ed50000", UpdatedTime: updatedTime.Add(-time.Hour)})
+	assert.NoError(t, err)
+	_, err = db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: "20606a000000", UpdatedTime: updatedTime})
+	assert.NoError(t, err)
+	_, err = db.QEIdentityRepository().Create(&types.QEIdentity{ID: "QE", UpdatedTime: updatedTime.Add(time.Hour)})
+	assert.NoError(t, err)
+
+	w := cacheStatsResponse(db, "/cache/stats")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var snapshot []CollateralCacheStats
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &snapshot))
+	for _, cs := range snapshot {
+		switch cs.Collateral {
+		case constants.CollateralTcbInfo:
+			assert.True(t, updatedTime.Equal(*cs.LatestUpdatedTime))
+		case constants.CollateralQeIdentity:
+			assert.True(t, updatedTime.Add(time.Hour).Equal(*cs.LatestUpdatedTime))
+		default:
+			// collaterals which are not cached have no updated time
+			assert.Nil(t, cs.LatestUpdatedTime)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"context"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/repository/memory"
+	"intel/isecl/scs/v5/types"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a Clock which moves only when advanced, tickers fire once for every
+// interval passed by an advance
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+type fakeTicker struct {
+	c       chan time.Time
+	period  time.Duration
+	next    time.Time
+	stopped bool
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.stopped = true
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTicker{c: make(chan time.Time, 1), period: d, next: c.now.Add(d)}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// Advance moves the clock forward by d, a tick is dropped when the previous one is not yet
+// received, the same as time.Ticker does for slow receivers
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, t := range c.tickers {
+		for !t.stopped && !t.next.After(c.now) {
+			select {
+			case t.c <- t.next:
+			default:
+			}
+			t.next = t.next.Add(t.period)
+		}
+	}
+}
+
+// useFakeClock replaces the clock used by resource package with a fake clock set to now,
+// until the test completes
+func useFakeClock(t *testing.T, now time.Time) *fakeClock {
+	fake := &fakeClock{now: now}
+	clock = fake
+	t.Cleanup(func() {
+		clock = realClock{}
+	})
+	return fake
+}
+
+func TestIsPckCrlExpiredWithFakeClock(t *testing.T) {
+	now := time.Date(2022, time.April, 1, 0, 0, 0, 0, time.UTC)
+	fake := useFakeClock(t, now)
+
+	pckCrl := &types.PckCrl{NextUpdate: now.Add(time.Hour)}
+	assert.False(t, isPckCrlExpired(pckCrl))
+	fake.Advance(2 * time.Hour)
+	assert.True(t, isPckCrlExpired(pckCrl))
+}
+
+func TestIsCoolOffTimeoutWithFakeClock(t *testing.T) {
+	now := time.Date(2022, time.April, 1, 0, 0, 0, 0, time.UTC)
+	fake := useFakeClock(t, now)
+
+	lastRefresh := &types.LastRefresh{CompletedAt: now}
+	fake.Advance(4 * time.Second)
+	remaining := isCoolOffTimeout(lastRefresh)
+	if assert.NotNil(t, remaining) {
+		assert.Equal(t, constants.RefreshCoolOffTimeout-4, *remaining)
+	}
+	fake.Advance(constants.RefreshCoolOffTimeout * time.Second)
+	assert.Nil(t, isCoolOffTimeout(lastRefresh))
+}
+
+func TestCacheTimesWithFakeClock(t *testing.T) {
+	now := time.Date(2022, time.April, 1, 0, 0, 0, 0, time.UTC)
+	fake := useFakeClock(t, now)
+	db := memory.NewDatabase()
+
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000", Ppid: "ppid"}
+	assert.NoError(t, cachePlatformInfo(context.Background(), db, platform, constants.CacheInsert))
+	cached, err := db.PlatformRepository().Retrieve(&types.Platform{QeID: platform.QeID, PceID: platform.PceID})
+	assert.NoError(t, err)
+	assert.Equal(t, now, cached.CreatedTime)
+	assert.Equal(t, now, cached.UpdatedTime)
+
+	fake.Advance(24 * time.Hour)
+	assert.NoError(t, cachePlatformInfo(context.Background(), db, &types.Platform{QeID: platform.QeID, PceID: platform.PceID}, constants.CacheRefresh))
+	cached, err = db.PlatformRepository().Retrieve(&types.Platform{QeID: platform.QeID, PceID: platform.PceID})
+	assert.NoError(t, err)
+	assert.Equal(t, now, cached.CreatedTime)
+	assert.Equal(t, now.Add(24*time.Hour), cached.UpdatedTime)
+
+	// platform is purged once it is not pushed for longer than max age
+	fake.Advance(time.Duration(constants.MinPlatformMaxAgeDays) * 24 * time.Hour)
+	purged, err := purgeStalePlatforms(db, constants.MinPlatformMaxAgeDays+1)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, purged)
+	fake.Advance(48 * time.Hour)
+	purged, err = purgeStalePlatforms(db, constants.MinPlatformMaxAgeDays+1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+}
+
+func TestAutoRefreshTimerWithFakeClock(t *testing.T) {
+	now := time.Date(2022, time.April, 1, 0, 0, 0, 0, time.UTC)
+	fake := useFakeClock(t, now)
+	db := memory.NewDatabase()
+	refreshTrigger := make(chan constants.RefreshTrigger, 1)
+
+	assert.NoError(t, InitAutoRefreshTimer(db, refreshTrigger, 2, config.TimerRefresh{}))
+	fake.Advance(time.Hour)
+	select {
+	case <-refreshTrigger:
+		t.Fatal("refresh triggered before refresh hours passed")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	fake.Advance(time.Hour)
+	select {
+	case trigger := <-refreshTrigger:
+		assert.EqualValues(t, constants.TriggerStart, trigger)
+	case <-time.After(time.Second):
+		t.Fatal("refresh not triggered after refresh hours passed")
+	}
+}
+
+func TestAutoRefreshTimerRefreshTypes(t *testing.T) {
+	tests := []struct {
+		timerRefresh config.TimerRefresh
+		trigger      constants.RefreshTrigger
+		triggered    bool
+	}{
+		{config.TimerRefresh{}, constants.TriggerStart, true},
+		{config.TimerRefresh{SkipPckCerts: true}, constants.TriggerStartTcbs, true},
+		{config.TimerRefresh{SkipCollaterals: true}, constants.TriggerStartCerts, true},
+		{config.TimerRefresh{SkipPckCerts: true, SkipCollaterals: true}, 0, false},
+	}
+	for _, tc := range tests {
+		trigger, ok := timerRefreshTrigger(tc.timerRefresh)
+		assert.Equal(t, tc.triggered, ok, "%+v", tc.timerRefresh)
+		assert.EqualValues(t, tc.trigger, trigger, "%+v", tc.timerRefresh)
+
+		fake := useFakeClock(t, time.Date(2022, time.April, 1, 0, 0, 0, 0, time.UTC))
+		refreshTrigger := make(chan constants.RefreshTrigger, 1)
+		assert.NoError(t, InitAutoRefreshTimer(memory.NewDatabase(), refreshTrigger, 1, tc.timerRefresh))
+		fake.Advance(time.Hour)
+		select {
+		case trigger := <-refreshTrigger:
+			assert.True(t, tc.triggered, "%+v", tc.timerRefresh)
+			assert.EqualValues(t, tc.trigger, trigger, "%+v", tc.timerRefresh)
+		case <-time.After(200 * time.Millisecond):
+			assert.False(t, tc.triggered, "refresh not triggered for %+v", tc.timerRefresh)
+		}
+	}
+}
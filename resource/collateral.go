@@ -0,0 +1,352 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/domain"
+	"intel/isecl/scs/v5/repository"
+	"intel/isecl/scs/v5/types"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// The retrieve functions below look up a collateral in the cache, fetching and caching it from PCS
+// server on a miss. They hold no REST specifics other than the status code of the resourceError
+// returned, so that every API serving collaterals behaves the same way as the REST handlers
+
+// retrievePckCert returns the PCK certificate best suited for the raw tcb level of the platform, the
+// platform is identified by qeid and pceid and should have the encrypted ppid, cpusvn and pcesvn set
+func retrievePckCert(ctx context.Context, db repository.SCSDatabase, pInfo *types.Platform, conf *config.Configuration, client *domain.HttpClient) (*types.PckCert, *types.PckCertChain, error) {
+	var existingPckCert *types.PckCert
+	var existingPckCertChain *types.PckCertChain
+
+	existingPinfo, err := db.PlatformRepository().Retrieve(&types.Platform{QeID: pInfo.QeID, PceID: pInfo.PceID})
+	if err != nil {
+		return nil, nil, retrieveRecordError(err, "platform")
+	}
+
+	if existingPinfo != nil {
+		recordPlatformAccess(db, existingPinfo)
+		pckCert := &types.PckCert{QeID: pInfo.QeID, PceID: pInfo.PceID, CPUSvn: pInfo.CPUSvn, PceSvn: pInfo.PceSvn}
+		existingPckCert, err = db.PckCertRepository().RetrieveByTcbLevel(pckCert)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+		certChain := &types.PckCertChain{Ca: existingPinfo.Ca}
+		existingPckCertChain, err = db.PckCertChainRepository().Retrieve(certChain)
+		if err != nil {
+			return nil, nil, &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+	}
+	// a stale selection is resolved again by fetching the pck certs of the platform from PCS
+	if existingPckCert != nil && isPckCertSelectionStale(existingPckCert) {
+		log.Warnf("Cached pck cert selection of platform with qeid %s is stale, re-fetching pck certs", pInfo.QeID)
+		existingPckCert = nil
+	}
+	if existingPckCert != nil {
+		recordCacheHit(constants.CollateralPckCert)
+		return existingPckCert, existingPckCertChain, nil
+	}
+
+	recordCacheMiss(constants.CollateralPckCert)
+	// platform may already be cached for a different raw tcb level
+	var cacheType constants.CacheType = constants.CacheInsert
+	if existingPinfo != nil {
+		pInfo.Manifest = existingPinfo.Manifest
+		cacheType = constants.CacheRefresh
+	}
+
+	// getLazyCachePckCert API will get PCK Certs and will cache it as well.
+	existingPckCert, existingPckCertChain, _, err = getLazyCachePckCert(ctx, db, pInfo, cacheType, conf, client)
+	if err != nil {
+		log.WithError(err).Error("Pck Cert Retrieval failed")
+		return nil, nil, &resourceError{Message: err.Error(), StatusCode: pcsErrorStatusCode(err, http.StatusNotFound)}
+	}
+	return existingPckCert, existingPckCertChain, nil
+}
+
+// recordPlatformAccess stores the time the platform is served by a read api. The time is written
+// only when the stored one is older than PlatformAccessUpdateInterval, and failure to store it is
+// only logged as the read itself has succeeded
+func recordPlatformAccess(db repository.SCSDatabase, platform *types.Platform) {
+	now := clock.Now().UTC()
+	if now.Sub(platform.LastAccessedTime) < constants.PlatformAccessUpdateInterval {
+		return
+	}
+
+	platform.LastAccessedTime = now
+	err := db.PlatformRepository().Update(&types.Platform{QeID: platform.QeID, PceID: platform.PceID,
+		LastAccessedTime: platform.LastAccessedTime})
+	if err != nil {
+		log.WithError(err).Warnf("Could not store last accessed time of platform with qeid %s", platform.QeID)
+	}
+}
+
+// retrievePckCrl returns the PCK CRL of the ca. An expired CRL is replaced when PCS server is
+// reachable, otherwise the cached one is returned
+func retrievePckCrl(ctx context.Context, db repository.SCSDatabase, ca string, conf *config.Configuration, client *domain.HttpClient) (*types.PckCrl, error) {
+	existingPckCrl, err := db.PckCrlRepository().Retrieve(&types.PckCrl{Ca: ca})
+	if existingPckCrl == nil {
+		recordCacheMiss(constants.CollateralPckCrl)
+		existingPckCrl, err = getLazyCachePckCrl(ctx, db, ca, constants.CacheInsert, conf, client)
+		if existingPckCrl == nil || err != nil {
+			return nil, &resourceError{Message: "Error retrieving required PCK CRL", StatusCode: pcsErrorStatusCode(err, http.StatusNotFound)}
+		}
+	} else if isPckCrlExpired(existingPckCrl) {
+		// try to replace the expired CRL, the cached one is served if PCS is not reachable
+		recordCacheMiss(constants.CollateralPckCrl)
+		refreshedPckCrl, err := getLazyCachePckCrl(ctx, db, ca, constants.CacheRefresh, conf, client)
+		if err != nil {
+			log.WithError(err).Warnf("Could not refresh expired PCK CRL for ca %s", ca)
+		} else {
+			existingPckCrl = refreshedPckCrl
+		}
+	} else {
+		recordCacheHit(constants.CollateralPckCrl)
+	}
+	return existingPckCrl, nil
+}
+
+// retrieveRootCaCrl returns the CRL of SGX Root CA. An expired CRL is replaced when PCS server is
+// reachable, otherwise the cached one is returned
+func retrieveRootCaCrl(ctx context.Context, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) (*types.RootCaCrl, error) {
+	existingRootCaCrl, err := db.RootCaCrlRepository().Retrieve()
+	if existingRootCaCrl == nil {
+		recordCacheMiss(constants.CollateralRootCaCrl)
+		existingRootCaCrl, err = getLazyCacheRootCaCrl(ctx, db, constants.CacheInsert, conf, client)
+		if existingRootCaCrl == nil || err != nil {
+			return nil, &resourceError{Message: "Error retrieving required Root CA CRL", StatusCode: pcsErrorStatusCode(err, http.StatusNotFound)}
+		}
+	} else if isRootCaCrlExpired(existingRootCaCrl) {
+		// try to replace the expired CRL, the cached one is served if PCS is not reachable
+		recordCacheMiss(constants.CollateralRootCaCrl)
+		refreshedRootCaCrl, err := getLazyCacheRootCaCrl(ctx, db, constants.CacheRefresh, conf, client)
+		if err != nil {
+			log.WithError(err).Warn("Could not refresh expired Root CA CRL")
+		} else {
+			existingRootCaCrl = refreshedRootCaCrl
+		}
+	} else {
+		recordCacheHit(constants.CollateralRootCaCrl)
+	}
+	return existingRootCaCrl, nil
+}
+
+func retrieveQeIdentity(ctx context.Context, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) (*types.QEIdentity, error) {
+	existingQeInfo, err := db.QEIdentityRepository().Retrieve()
+	if existingQeInfo != nil {
+		recordCacheHit(constants.CollateralQeIdentity)
+		return existingQeInfo, nil
+	}
+
+	recordCacheMiss(constants.CollateralQeIdentity)
+	existingQeInfo, err = getLazyCacheQEIdentityInfo(ctx, db, constants.CacheInsert, conf, client)
+	if err != nil || existingQeInfo == nil {
+		return nil, &resourceError{Message: "Error retrieving QEIdentity info", StatusCode: pcsErrorStatusCode(err, http.StatusNotFound)}
+	}
+	return existingQeInfo, nil
+}
+
+func retrieveTcbInfo(ctx context.Context, db repository.SCSDatabase, fmspc string, conf *config.Configuration, client *domain.HttpClient) (*types.FmspcTcbInfo, error) {
+	existingFmspc, err := db.FmspcTcbInfoRepository().Retrieve(&types.FmspcTcbInfo{Fmspc: fmspc})
+	if existingFmspc != nil {
+		recordCacheHit(constants.CollateralTcbInfo)
+		return existingFmspc, nil
+	}
+
+	recordCacheMiss(constants.CollateralTcbInfo)
+	existingFmspc, err = cacheMissingFmspcTcbInfo(ctx, db, fmspc, conf, client)
+	if err != nil || existingFmspc == nil {
+		return nil, &resourceError{Message: "Error retrieving TCB info", StatusCode: pcsErrorStatusCode(err, http.StatusNotFound)}
+	}
+	return existingFmspc, nil
+}
+
+// retrieveTcbStatus reports whether the tcb status of a cached platform is one of the accepted statuses
+func retrieveTcbStatus(db repository.SCSDatabase, platform *types.Platform, conf *config.Configuration) (*Response, error) {
+	status, err := cachedPlatformTcbStatus(db, platform, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &Response{Status: "false", Message: "TCB Status is not UpToDate", TcbStatus: status}
+	if status == constants.TcbLevelNotFound {
+		response.Message = "TCB level of the platform is not present in TcbInfo"
+	} else if isTcbStatusAccepted(status, conf) {
+		reason, err := tcbBelowBaseline(db, platform, conf)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			response.Message = reason
+		} else {
+			response.Status = "true"
+			response.Message = "TCB Status is UpToDate"
+		}
+	}
+	response.TcbInfo = tcbInfoFreshness(db, platform.Fmspc)
+	return response, nil
+}
+
+// tcbBelowBaseline checks the tcb of the pck cert selected for the platform against the TCB baseline
+// configured by the operator, and returns why it is below the baseline or empty when it is not
+func tcbBelowBaseline(db repository.SCSDatabase, platform *types.Platform, conf *config.Configuration) (string, error) {
+	if conf == nil || (len(conf.MinTcbCompSvns) == 0 && conf.MinPceSvn == 0) {
+		return "", nil
+	}
+
+	pckCert, err := db.PckCertRepository().RetrieveByTcbLevel(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID,
+		CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn})
+	if err != nil {
+		return "", retrieveRecordError(err, "pck cert")
+	}
+	if int(pckCert.CertIndex) >= len(pckCert.Tcbms) {
+		return "", &resourceError{Message: "pck cert selection of the platform is stale, refresh of its pck certs is required",
+			StatusCode: http.StatusConflict}
+	}
+	tcbm, err := hex.DecodeString(pckCert.Tcbms[pckCert.CertIndex])
+	if err != nil || len(tcbm) != constants.MaxTcbLevels+2 {
+		return "", &resourceError{Message: "cannot decode tcbm of the selected pck cert", StatusCode: http.StatusInternalServerError}
+	}
+
+	for i, minSvn := range conf.MinTcbCompSvns {
+		if int(tcbm[i]) < minSvn {
+			return fmt.Sprintf("TCB is below the configured baseline: sgxtcbcomp%02dsvn %d is lower than %d", i+1, tcbm[i], minSvn), nil
+		}
+	}
+	if pceSvn := binary.LittleEndian.Uint16(tcbm[constants.MaxTcbLevels:]); int(pceSvn) < conf.MinPceSvn {
+		return fmt.Sprintf("TCB is below the configured baseline: pcesvn %d is lower than %d", pceSvn, conf.MinPceSvn), nil
+	}
+	return "", nil
+}
+
+// tcbInfoFreshness reads the freshness of the cached TcbInfo of an fmspc, it is left out of the tcb
+// status when the TcbInfo cannot be read as the status is still valid without it
+func tcbInfoFreshness(db repository.SCSDatabase, fmspc string) *TcbInfoFreshness {
+	tcbInfo, err := db.FmspcTcbInfoRepository().Retrieve(&types.FmspcTcbInfo{Fmspc: fmspc})
+	if err != nil {
+		log.WithError(err).Warnf("Could not retrieve TcbInfo of fmspc %s for its freshness", fmspc)
+		return nil
+	}
+	var tcbInfoJSON TcbInfoJSON
+	if err := json.Unmarshal([]byte(tcbInfo.TcbInfo), &tcbInfoJSON); err != nil {
+		log.WithError(err).Warnf("Could not unmarshal TcbInfo of fmspc %s for its freshness", fmspc)
+		return nil
+	}
+	return &TcbInfoFreshness{
+		IssueDate:               tcbInfoJSON.TcbInfo.IssueDate,
+		NextUpdate:              tcbInfoJSON.TcbInfo.NextUpdate,
+		TcbEvaluationDataNumber: tcbInfoJSON.TcbInfo.TcbEvaluationDataNumber,
+		UpdatedTime:             tcbInfo.UpdatedTime,
+	}
+}
+
+// BundledCollateral is a collateral in a CollateralBundle. Data and IssuerChain are set when the
+// collateral is present or expired, Error tells why a missing collateral could not be retrieved
+type BundledCollateral struct {
+	Status      string `json:"status"`
+	Data        string `json:"data,omitempty"`
+	IssuerChain string `json:"issuer_chain,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// BundledPckCert is the PCK certificate selected for the current raw tcb level of the platform
+type BundledPckCert struct {
+	BundledCollateral
+	Tcbm string `json:"tcbm,omitempty"`
+}
+
+// CollateralBundle holds every collateral needed to verify a quote of a platform. PckCert and PckCrl
+// are not set when only fmspc is requested, as the platform is not known then
+type CollateralBundle struct {
+	Fmspc      string             `json:"fmspc"`
+	Ca         string             `json:"ca,omitempty"`
+	PckCert    *BundledPckCert    `json:"pck_cert,omitempty"`
+	PckCrl     *BundledCollateral `json:"pck_crl,omitempty"`
+	TcbInfo    BundledCollateral  `json:"tcb_info"`
+	QeIdentity BundledCollateral  `json:"qe_identity"`
+}
+
+// bundledIssuerChain returns an issuer chain as the PEM certificates ordered from the issuer to the
+// root, chains which cannot be normalized are returned as cached
+func bundledIssuerChain(chain string) string {
+	normalized, err := normalizePckCertChain(chain)
+	if err != nil {
+		log.WithError(err).Warn("Could not normalize cached issuer chain")
+		return chain
+	}
+	return normalized
+}
+
+func bundledCollateral(data, issuerChain string, expired bool) BundledCollateral {
+	status := constants.CollateralStatusPresent
+	if expired {
+		status = constants.CollateralStatusExpired
+	}
+	return BundledCollateral{Status: status, Data: data, IssuerChain: bundledIssuerChain(issuerChain)}
+}
+
+func missingCollateral(err error) BundledCollateral {
+	return BundledCollateral{Status: constants.CollateralStatusMissing, Error: err.Error()}
+}
+
+// retrieveCollateralBundle assembles the collaterals of a platform with the same retrieve functions
+// as the APIs serving each of them. platform is nil when only fmspc is requested. A collateral which
+// cannot be retrieved is reported missing in the bundle instead of failing the others
+func retrieveCollateralBundle(ctx context.Context, db repository.SCSDatabase, platform *types.Platform, fmspc string, conf *config.Configuration,
+	client *domain.HttpClient) *CollateralBundle {
+	bundle := &CollateralBundle{Fmspc: fmspc}
+	if platform != nil {
+		bundle.Fmspc = platform.Fmspc
+		bundle.Ca = platform.Ca
+
+		pckCert, pckCertChain, err := retrievePckCert(ctx, db, platform, conf, client)
+		if err != nil {
+			bundle.PckCert = &BundledPckCert{BundledCollateral: missingCollateral(err)}
+		} else {
+			bundle.PckCert = &BundledPckCert{
+				BundledCollateral: bundledCollateral(pckCert.PckCerts[pckCert.CertIndex], pckCertChain.PckCertChain, false),
+				Tcbm:              pckCert.Tcbms[pckCert.CertIndex],
+			}
+		}
+
+		pckCrl, err := retrievePckCrl(ctx, db, platform.Ca, conf, client)
+		if err != nil {
+			crl := missingCollateral(err)
+			bundle.PckCrl = &crl
+		} else {
+			crl := bundledCollateral(pckCrl.PckCrl, pckCrl.PckCrlCertChain, isPckCrlExpired(pckCrl))
+			bundle.PckCrl = &crl
+		}
+	}
+
+	tcbInfo, err := retrieveTcbInfo(ctx, db, bundle.Fmspc, conf, client)
+	if err != nil {
+		bundle.TcbInfo = missingCollateral(err)
+	} else {
+		var tcbInfoJSON TcbInfoJSON
+		expired := json.Unmarshal([]byte(tcbInfo.TcbInfo), &tcbInfoJSON) == nil && isNextUpdatePassed(tcbInfoJSON.TcbInfo.NextUpdate)
+		bundle.TcbInfo = bundledCollateral(tcbInfo.TcbInfo, tcbInfo.TcbInfoIssuerChain, expired)
+	}
+
+	qeIdentity, err := retrieveQeIdentity(ctx, db, conf, client)
+	if err != nil {
+		bundle.QeIdentity = missingCollateral(err)
+	} else {
+		var qeIdentityJSON types.QeIdentityJSON
+		expired := json.Unmarshal([]byte(qeIdentity.QeInfo), &qeIdentityJSON) == nil &&
+			isNextUpdatePassed(qeIdentityJSON.EnclaveIdentity.NextUpdate)
+		bundle.QeIdentity = bundledCollateral(qeIdentity.QeInfo, qeIdentity.QeIssuerChain, expired)
+	}
+	return bundle
+}
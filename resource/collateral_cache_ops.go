@@ -0,0 +1,97 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"encoding/json"
+	commLogMsg "intel/isecl/lib/common/v5/log/message"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/domain"
+	"intel/isecl/scs/v5/repository"
+	"net/http"
+
+	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+)
+
+// CollateralCacheRequest identifies the collaterals to be cached for quotes of platforms which are
+// not registered with SCS, by the fmspc and the ca of their PCK certificates
+type CollateralCacheRequest struct {
+	Fmspc string `json:"fmspc"`
+	Ca    string `json:"ca"`
+}
+
+func CollateralCacheOps(r *mux.Router, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) {
+	r.Handle("/collateral/cache", handlers.ContentTypeHandler(cacheCollateral(db, conf, client), "application/json")).Methods("POST")
+}
+
+// cacheCollateral caches TcbInfo of the fmspc, PCK CRL of the ca and QE identity, for verifiers which
+// only have quotes and not the enc_ppid or manifest needed to push the platform. Collaterals which are
+// already cached are not fetched again, they are kept up to date by refresh like those of pushed platforms
+func cacheCollateral(db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.HostDataReaderGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		if r.ContentLength == 0 {
+			slog.Error("resource/collateral_cache_ops: cacheCollateral() The request body was not provided")
+			return &resourceError{Message: "collateral cache request not provided",
+				StatusCode: http.StatusBadRequest}
+		}
+
+		var cacheReq CollateralCacheRequest
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&cacheReq)
+		if err != nil {
+			slog.WithError(err).Errorf("resource/collateral_cache_ops: cacheCollateral() %s :  Failed to decode request body", commLogMsg.InvalidInputBadEncoding)
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusBadRequest}
+		}
+
+		if !validateInputString(constants.FmspcKey, cacheReq.Fmspc) || !validateInputString(constants.CaKey, cacheReq.Ca) {
+			slog.Error("resource/collateral_cache_ops: cacheCollateral() Input validation failed")
+			return &resourceError{Message: "invalid fmspc or ca", StatusCode: http.StatusBadRequest}
+		}
+
+		scopes, err := getPlatformScopes(r, constants.HostDataReaderGroupName)
+		if err != nil {
+			return err
+		}
+		if err := authorizePlatformScope(r, scopes, cacheReq.Fmspc, ""); err != nil {
+			return err
+		}
+
+		_, err = retrieveTcbInfo(r.Context(), db, cacheReq.Fmspc, conf, client)
+		if err != nil {
+			return err
+		}
+		_, err = retrievePckCrl(r.Context(), db, cacheReq.Ca, conf, client)
+		if err != nil {
+			return err
+		}
+		_, err = retrieveQeIdentity(r.Context(), db, conf, client)
+		if err != nil {
+			return err
+		}
+
+		res := Response{Status: "Success", Message: "collaterals of fmspc " + cacheReq.Fmspc + " and ca " + cacheReq.Ca + " are cached"}
+		js, err := json.Marshal(res)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(js)
+		if err != nil {
+			log.WithError(err).Error("Could not write collateral cache response")
+		}
+		slog.Infof("%s: Collaterals of fmspc %s cached by: %s", commLogMsg.AuthorizedAccess, cacheReq.Fmspc, r.RemoteAddr)
+		return nil
+	}
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"intel/isecl/lib/common/v5/context"
+	"intel/isecl/lib/common/v5/types/aas"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/domain"
+	"intel/isecl/scs/v5/domain/mocks"
+	"intel/isecl/scs/v5/repository/memory"
+	"intel/isecl/scs/v5/types"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func cacheCollateralResponse(db *memory.Database, client domain.HttpClient, body string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	CollateralCacheOps(router, db, config.Load(testConfigFilePath), &client)
+
+	req := httptest.NewRequest(http.MethodPost, "/collateral/cache", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataReaderGroupName}}
+	req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+	roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: "type=SCS"}}
+	req = context.SetUserRoles(req, roleInfo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCacheCollateral(t *testing.T) {
+	db := memory.NewDatabase()
+	client := mocks.NewClientMock(http.StatusOK)
+
+	assert.Equal(t, http.StatusBadRequest, cacheCollateralResponse(db, client, "").Code)
+	assert.Equal(t, http.StatusBadRequest, cacheCollateralResponse(db, client, `{"fmspc": "20606a000000"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, cacheCollateralResponse(db, client, `{"fmspc": "20606a", "ca": "processor"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, cacheCollateralResponse(db, client, `{"fmspc": "20606a000000", "ca": "other"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, cacheCollateralResponse(db, client,
+		`{"fmspc": "20606a000000", "ca": "processor", "qeid": "0518145496973c5e69577195511e9080"}`).Code)
+
+	// collaterals are cached without the platform being registered
+	w := cacheCollateralResponse(db, client, `{"fmspc": "20606a000000", "ca": "processor"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	_, err := db.FmspcTcbInfoRepository().Retrieve(&types.FmspcTcbInfo{Fmspc: "20606a000000"})
+	assert.NoError(t, err)
+	_, err = db.PckCrlRepository().Retrieve(&types.PckCrl{Ca: "processor"})
+	assert.NoError(t, err)
+	_, err = db.QEIdentityRepository().Retrieve()
+	assert.NoError(t, err)
+	platforms, _ := db.PlatformRepository().RetrieveAll()
+	assert.Empty(t, platforms)
+
+	// collaterals which cannot be fetched from PCS are reported as not found
+	w = cacheCollateralResponse(memory.NewDatabase(), mocks.NewClientMock(http.StatusBadRequest), `{"fmspc": "20606a000000", "ca": "processor"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
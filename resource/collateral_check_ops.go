@@ -0,0 +1,223 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"encoding/json"
+	commLogMsg "intel/isecl/lib/common/v5/log/message"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/domain"
+	"intel/isecl/scs/v5/repository"
+	"intel/isecl/scs/v5/types"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// CollateralCheckRequest identifies the platform which produced a quote. fmspc is optional, it is
+// needed only to check TcbInfo of a platform which is not cached
+type CollateralCheckRequest struct {
+	QeID  string `json:"qeid"`
+	PceID string `json:"pceid"`
+	Fmspc string `json:"fmspc"`
+}
+
+type CollateralStatus struct {
+	Collateral string `json:"collateral"`
+	Status     string `json:"status"`
+}
+
+// CollateralCheckResult reports whether each collateral needed to verify a quote of the platform is
+// cached, the platform is ready for attestation only when all of them are present
+type CollateralCheckResult struct {
+	Ready       bool               `json:"ready"`
+	Collaterals []CollateralStatus `json:"collaterals"`
+}
+
+func CollateralCheckOps(r *mux.Router, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) {
+	r.Handle("/collateral/check", handlers.ContentTypeHandler(checkCollateral(db), "application/json")).Methods("POST")
+}
+
+// isNextUpdatePassed checks if the nextUpdate time of TcbInfo or QE identity has passed. A nextUpdate
+// which cannot be parsed is not reported as expired, the same as CRLs cached without nextUpdate
+func isNextUpdatePassed(nextUpdate string) bool {
+	t, err := time.Parse(time.RFC3339, nextUpdate)
+	if err != nil {
+		return false
+	}
+	return clock.Now().UTC().After(t)
+}
+
+// collateralStatus returns the status of a collateral from the result of retrieving it, a failure
+// other than the collateral not being cached is returned as is
+func collateralStatus(err error, expired func() bool) (string, error) {
+	if errors.Is(err, repository.ErrNotFound) {
+		return constants.CollateralStatusMissing, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if expired != nil && expired() {
+		return constants.CollateralStatusExpired, nil
+	}
+	return constants.CollateralStatusPresent, nil
+}
+
+// checkPlatformCollaterals reports the status of the cached collaterals of a platform. platform is nil
+// when the platform is not cached, its pck cert, pck cert chain and PCK CRL are then reported missing.
+// Collaterals are only read from the database, nothing is fetched from PCS
+func checkPlatformCollaterals(db repository.SCSDatabase, platform *types.Platform, fmspc string) (*CollateralCheckResult, error) {
+	result := &CollateralCheckResult{Ready: true}
+	add := func(collateral string, err error, expired func() bool) error {
+		status, err := collateralStatus(err, expired)
+		if err != nil {
+			return errors.Wrapf(err, "could not retrieve %s", collateral)
+		}
+		result.Collaterals = append(result.Collaterals, CollateralStatus{Collateral: collateral, Status: status})
+		result.Ready = result.Ready && status == constants.CollateralStatusPresent
+		return nil
+	}
+
+	if platform != nil {
+		pckCert, err := db.PckCertRepository().RetrieveByTcbLevel(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID,
+			CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn})
+		// a stale selection has to be resolved again from PCS before the pck cert can be served
+		if err == nil && isPckCertSelectionStale(pckCert) {
+			err = repository.ErrNotFound
+		}
+		if err = add(constants.CollateralPckCert, err, nil); err != nil {
+			return nil, err
+		}
+
+		_, err = db.PckCertChainRepository().Retrieve(&types.PckCertChain{Ca: platform.Ca})
+		if err = add(constants.CollateralPckCertChain, err, nil); err != nil {
+			return nil, err
+		}
+
+		pckCrl, err := db.PckCrlRepository().Retrieve(&types.PckCrl{Ca: platform.Ca})
+		if err = add(constants.CollateralPckCrl, err, func() bool { return isPckCrlExpired(pckCrl) }); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, collateral := range []string{constants.CollateralPckCert, constants.CollateralPckCertChain, constants.CollateralPckCrl} {
+			if err := add(collateral, repository.ErrNotFound, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var err error
+	var tcbInfo *types.FmspcTcbInfo
+	if fmspc == "" {
+		err = repository.ErrNotFound
+	} else {
+		tcbInfo, err = db.FmspcTcbInfoRepository().Retrieve(&types.FmspcTcbInfo{Fmspc: fmspc})
+	}
+	err = add(constants.CollateralTcbInfo, err, func() bool {
+		var tcbInfoJSON TcbInfoJSON
+		if err := json.Unmarshal([]byte(tcbInfo.TcbInfo), &tcbInfoJSON); err != nil {
+			return false
+		}
+		return isNextUpdatePassed(tcbInfoJSON.TcbInfo.NextUpdate)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	qeIdentity, err := db.QEIdentityRepository().Retrieve()
+	err = add(constants.CollateralQeIdentity, err, func() bool {
+		var qeIdentityJSON types.QeIdentityJSON
+		if err := json.Unmarshal([]byte(qeIdentity.QeInfo), &qeIdentityJSON); err != nil {
+			return false
+		}
+		return isNextUpdatePassed(qeIdentityJSON.EnclaveIdentity.NextUpdate)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rootCaCrl, err := db.RootCaCrlRepository().Retrieve()
+	err = add(constants.CollateralRootCaCrl, err, func() bool { return isRootCaCrlExpired(rootCaCrl) })
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// checkCollateral reports whether all the collaterals needed to verify a quote of a platform are
+// cached and not expired, so that a client can find out in one call if a quote can be verified
+func checkCollateral(db repository.SCSDatabase) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.HostDataReaderGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		if r.ContentLength == 0 {
+			slog.Error("resource/collateral_check_ops: checkCollateral() The request body was not provided")
+			return &resourceError{Message: "collateral check request not provided",
+				StatusCode: http.StatusBadRequest}
+		}
+
+		var checkReq CollateralCheckRequest
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&checkReq)
+		if err != nil {
+			slog.WithError(err).Errorf("resource/collateral_check_ops: checkCollateral() %s :  Failed to decode request body", commLogMsg.InvalidInputBadEncoding)
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusBadRequest}
+		}
+
+		if !validateInputString(constants.QeIDKey, checkReq.QeID) || !validateInputString(constants.PceIDKey, checkReq.PceID) ||
+			(checkReq.Fmspc != "" && !validateInputString(constants.FmspcKey, checkReq.Fmspc)) {
+			slog.Error("resource/collateral_check_ops: checkCollateral() Input validation failed")
+			return &resourceError{Message: "invalid platform identifiers", StatusCode: http.StatusBadRequest}
+		}
+
+		scopes, err := getPlatformScopes(r, constants.HostDataReaderGroupName)
+		if err != nil {
+			return err
+		}
+
+		fmspc := checkReq.Fmspc
+		platform, err := db.PlatformRepository().Retrieve(&types.Platform{QeID: checkReq.QeID, PceID: checkReq.PceID})
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return retrieveRecordError(err, "platform")
+		}
+		if platform != nil {
+			if fmspc != "" && fmspc != platform.Fmspc {
+				return &resourceError{Message: "fmspc does not match the cached platform", StatusCode: http.StatusBadRequest}
+			}
+			fmspc = platform.Fmspc
+		}
+		if err := authorizePlatformScope(r, scopes, fmspc, checkReq.PceID); err != nil {
+			return err
+		}
+
+		result, err := checkPlatformCollaterals(db, platform, fmspc)
+		if err != nil {
+			log.WithError(err).Errorf("Could not check collaterals of platform with qeid %s", checkReq.QeID)
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		js, err := json.Marshal(result)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(js)
+		if err != nil {
+			log.WithError(err).Error("Could not write collateral check result to response")
+		}
+		slog.Infof("%s: Collaterals checked by: %s", commLogMsg.AuthorizedAccess, r.RemoteAddr)
+		return nil
+	}
+}
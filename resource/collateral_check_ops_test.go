@@ -0,0 +1,131 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"encoding/json"
+	"intel/isecl/lib/common/v5/context"
+	"intel/isecl/lib/common/v5/types/aas"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/repository/memory"
+	"intel/isecl/scs/v5/types"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func checkCollateralResponse(db *memory.Database, body string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	CollateralCheckOps(router, db, nil, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/collateral/check", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataReaderGroupName}}
+	req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+	roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: "type=SCS"}}
+	req = context.SetUserRoles(req, roleInfo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func collateralStatuses(result *CollateralCheckResult) map[string]string {
+	statuses := make(map[string]string)
+	for _, c := range result.Collaterals {
+		statuses[c.Collateral] = c.Status
+	}
+	return statuses
+}
+
+func TestCheckPlatformCollaterals(t *testing.T) {
+	// nextUpdate of testTcbInfoJson and qeInfo is 2020-07-15T06:42:01Z
+	now := time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)
+	fake := useFakeClock(t, now)
+
+	db := memory.NewDatabase()
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000", Ca: "processor"}
+
+	result, err := checkPlatformCollaterals(db, nil, "")
+	assert.NoError(t, err)
+	assert.False(t, result.Ready)
+	assert.Len(t, result.Collaterals, 6)
+	for _, c := range result.Collaterals {
+		assert.Equal(t, constants.CollateralStatusMissing, c.Status)
+	}
+
+	db.PckCertRepository().Create(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn,
+		PceSvn: platform.PceSvn, PckCerts: []string{"cert0"}, Tcbms: []string{"020200000000000000000000000000000a00"}})
+	db.PckCertChainRepository().Create(&types.PckCertChain{Ca: platform.Ca, PckCertChain: "certchain"})
+	db.PckCrlRepository().Create(&types.PckCrl{Ca: platform.Ca, PckCrl: "crl", NextUpdate: now.Add(24 * time.Hour)})
+	db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: platform.Fmspc, TcbInfo: string(testTcbInfoJson)})
+	db.QEIdentityRepository().Create(&types.QEIdentity{ID: "QE", QeInfo: string(qeInfo)})
+	db.RootCaCrlRepository().Create(&types.RootCaCrl{ID: constants.RootCaCrlID, RootCaCrl: "crl", NextUpdate: now.Add(24 * time.Hour)})
+
+	result, err = checkPlatformCollaterals(db, platform, platform.Fmspc)
+	assert.NoError(t, err)
+	assert.True(t, result.Ready)
+	for _, c := range result.Collaterals {
+		assert.Equal(t, constants.CollateralStatusPresent, c.Status, c.Collateral)
+	}
+
+	// collaterals past their nextUpdate are reported expired
+	fake.Advance(3 * 24 * time.Hour)
+	result, err = checkPlatformCollaterals(db, platform, platform.Fmspc)
+	assert.NoError(t, err)
+	assert.False(t, result.Ready)
+	assert.Equal(t, map[string]string{
+		constants.CollateralPckCert:      constants.CollateralStatusPresent,
+		constants.CollateralPckCertChain: constants.CollateralStatusPresent,
+		constants.CollateralPckCrl:       constants.CollateralStatusExpired,
+		constants.CollateralTcbInfo:      constants.CollateralStatusPresent,
+		constants.CollateralQeIdentity:   constants.CollateralStatusPresent,
+		constants.CollateralRootCaCrl:    constants.CollateralStatusExpired,
+	}, collateralStatuses(result))
+
+	fake.Advance(30 * 24 * time.Hour)
+	result, err = checkPlatformCollaterals(db, platform, platform.Fmspc)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.CollateralStatusExpired, collateralStatuses(result)[constants.CollateralTcbInfo])
+	assert.Equal(t, constants.CollateralStatusExpired, collateralStatuses(result)[constants.CollateralQeIdentity])
+}
+
+func TestCheckCollateral(t *testing.T) {
+	useFakeClock(t, time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC))
+
+	db := memory.NewDatabase()
+	db.PlatformRepository().Create(&types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000", Ca: "processor"})
+	db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: "20606a000000", TcbInfo: string(testTcbInfoJson)})
+
+	assert.Equal(t, http.StatusBadRequest, checkCollateralResponse(db, "").Code)
+	assert.Equal(t, http.StatusBadRequest, checkCollateralResponse(db, `{"qeid": "0518145496973c5e69577195511e9080"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, checkCollateralResponse(db,
+		`{"qeid": "0518145496973c5e69577195511e9080", "pceid": "0000", "ca": "processor"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, checkCollateralResponse(db,
+		`{"qeid": "0518145496973c5e69577195511e9080", "pceid": "0000", "fmspc": "00906ed50000"}`).Code)
+
+	w := checkCollateralResponse(db, `{"qeid": "0518145496973c5e69577195511e9080", "pceid": "0000"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var result CollateralCheckResult
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.False(t, result.Ready)
+	assert.Equal(t, constants.CollateralStatusMissing, collateralStatuses(&result)[constants.CollateralPckCert])
+	assert.Equal(t, constants.CollateralStatusPresent, collateralStatuses(&result)[constants.CollateralTcbInfo])
+
+	// TcbInfo of a platform which is not cached is checked for the provided fmspc
+	w = checkCollateralResponse(db, `{"qeid": "1518145496973c5e69577195511e9080", "pceid": "0000", "fmspc": "20606a000000"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	result = CollateralCheckResult{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, constants.CollateralStatusMissing, collateralStatuses(&result)[constants.CollateralPckCert])
+	assert.Equal(t, constants.CollateralStatusPresent, collateralStatuses(&result)[constants.CollateralTcbInfo])
+}
@@ -0,0 +1,245 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	commLogMsg "intel/isecl/lib/common/v5/log/message"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/domain"
+	pb "intel/isecl/scs/v5/proto"
+	"intel/isecl/scs/v5/repository"
+	"intel/isecl/scs/v5/types"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// GrpcPathPrefix is the path prefix of the methods of the gRPC collateral service, which is served on
+// the port of the REST apis. The ops are set on routers of this prefix
+var GrpcPathPrefix = "/" + pb.Collateral_ServiceDesc.ServiceName
+
+// CollateralGrpcOps serves the collateral methods of the gRPC api, which like the REST apis invoked by
+// QPL need no token
+func CollateralGrpcOps(r *mux.Router, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) {
+	handleGrpcMethods(r, newCollateralGrpcServer(db, conf, client), pb.Collateral_GetPckCert_FullMethodName,
+		pb.Collateral_GetPckCrl_FullMethodName, pb.Collateral_GetTcbInfo_FullMethodName, pb.Collateral_GetQeIdentity_FullMethodName)
+}
+
+// TcbStatusGrpcOps serves the tcb status method of the gRPC api, which is authorized as the tcbstatus
+// REST api and so is to be routed behind token authentication
+func TcbStatusGrpcOps(r *mux.Router, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) {
+	handleGrpcMethods(r, newCollateralGrpcServer(db, conf, client), pb.Collateral_GetTcbStatus_FullMethodName)
+}
+
+// grpcRequestKey holds the http request of a gRPC call in its context, so that the call is authorized
+// with the roles the token authentication has stored in the request
+type grpcRequestKey struct{}
+
+// handleGrpcMethods routes the methods to the gRPC server, which serves them over the HTTP/2 connections
+// of the REST apis. Only the given methods are routed, so that the middlewares of the router apply to
+// each of them
+func handleGrpcMethods(r *mux.Router, server *grpc.Server, methods ...string) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), grpcRequestKey{}, r)))
+	})
+	for _, method := range methods {
+		r.Handle(strings.TrimPrefix(method, GrpcPathPrefix), handler).Methods("POST")
+	}
+}
+
+func newCollateralGrpcServer(db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) *grpc.Server {
+	server := grpc.NewServer(grpc.UnaryInterceptor(grpcRefreshGuard(conf)))
+	pb.RegisterCollateralServer(server, &collateralGrpcServer{db: db, conf: conf, client: client})
+	return server
+}
+
+// grpcRefreshGuard fails the calls with Unavailable while a full refresh is in progress, as the refresh
+// guard does for the REST read apis, since all the methods of the gRPC api are reads
+func grpcRefreshGuard(conf *config.Configuration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if conf.ReadsDuringRefreshPolicy == constants.ReadsDuringRefreshUnavailable && isFullRefreshInProgress() {
+			log.Debugf("resource/collateral_grpc_ops: Call of %s rejected during a full refresh", info.FullMethod)
+			_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(constants.RefreshInProgressRetryAfter)))
+			return nil, status.Error(codes.Unavailable, "refresh in progress")
+		}
+		return handler(ctx, req)
+	}
+}
+
+type collateralGrpcServer struct {
+	pb.UnimplementedCollateralServer
+	db     repository.SCSDatabase
+	conf   *config.Configuration
+	client *domain.HttpClient
+}
+
+func (s *collateralGrpcServer) GetPckCert(ctx context.Context, req *pb.GetPckCertRequest) (*pb.PckCert, error) {
+	encryptedppid := strings.ToLower(req.EncryptedPpid)
+	cpusvn := strings.ToLower(req.Cpusvn)
+	pcesvn := strings.ToLower(req.Pcesvn)
+	pceid := strings.ToLower(req.Pceid)
+	qeid := strings.ToLower(req.Qeid)
+	if !validateInputString(constants.EncPPIDKey, encryptedppid) ||
+		!validateInputString(constants.CPUSvnKey, cpusvn) ||
+		!validateInputString(constants.PceSvnKey, pcesvn) ||
+		!validateInputString(constants.PceIDKey, pceid) ||
+		!validateInputString(constants.QeIDKey, qeid) {
+		slog.Errorf("resource/collateral_grpc_ops: GetPckCert() Input validation failed for request")
+		return nil, status.Error(codes.InvalidArgument, "invalid request param")
+	}
+
+	pInfo := &types.Platform{QeID: qeid, PceID: pceid, Encppid: encryptedppid, CPUSvn: cpusvn, PceSvn: pcesvn}
+	pckCert, pckCertChain, err := retrievePckCert(ctx, s.db, pInfo, s.conf, s.client)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	slog.Infof("%s: PCK certificate retrieved over gRPC by: %s", commLogMsg.AuthorizedAccess, grpcPeer(ctx))
+	return &pb.PckCert{
+		PckCert:     pckCert.PckCerts[pckCert.CertIndex],
+		IssuerChain: bundledIssuerChain(pckCertChain.PckCertChain),
+		Tcbm:        pckCert.Tcbms[pckCert.CertIndex],
+	}, nil
+}
+
+func (s *collateralGrpcServer) GetPckCrl(ctx context.Context, req *pb.GetPckCrlRequest) (*pb.PckCrl, error) {
+	ca := strings.TrimSpace(strings.ToLower(req.Ca))
+	if !validateInputString(constants.CaKey, ca) {
+		slog.Errorf("resource/collateral_grpc_ops: GetPckCrl() Input validation failed for request")
+		return nil, status.Error(codes.InvalidArgument, "invalid request param")
+	}
+
+	pckCrl, err := retrievePckCrl(ctx, s.db, ca, s.conf, s.client)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	crlExpired := isPckCrlExpired(pckCrl)
+	if crlExpired {
+		log.Warnf("Serving PCK CRL for ca %s past its nextUpdate %s", ca, pckCrl.NextUpdate)
+	}
+	slog.Infof("%s: PCK CRL retrieved over gRPC by: %s", commLogMsg.AuthorizedAccess, grpcPeer(ctx))
+	return &pb.PckCrl{Crl: []byte(pckCrl.PckCrl), IssuerChain: pckCrl.PckCrlCertChain, Expired: crlExpired}, nil
+}
+
+func (s *collateralGrpcServer) GetTcbInfo(ctx context.Context, req *pb.GetTcbInfoRequest) (*pb.TcbInfo, error) {
+	if !validateInputString(constants.FmspcKey, req.Fmspc) {
+		slog.Errorf("resource/collateral_grpc_ops: GetTcbInfo() Input validation failed for request")
+		return nil, status.Error(codes.InvalidArgument, "invalid request param")
+	}
+
+	tcbInfo, err := retrieveTcbInfo(ctx, s.db, req.Fmspc, s.conf, s.client)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	slog.Infof("%s: TCB Info retrieved over gRPC by: %s", commLogMsg.AuthorizedAccess, grpcPeer(ctx))
+	return &pb.TcbInfo{TcbInfo: tcbInfo.TcbInfo, IssuerChain: tcbInfo.TcbInfoIssuerChain}, nil
+}
+
+func (s *collateralGrpcServer) GetQeIdentity(ctx context.Context, _ *pb.GetQeIdentityRequest) (*pb.QeIdentity, error) {
+	qeIdentity, err := retrieveQeIdentity(ctx, s.db, s.conf, s.client)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	slog.Infof("%s: QE Identity info retrieved over gRPC by: %s", commLogMsg.AuthorizedAccess, grpcPeer(ctx))
+	return &pb.QeIdentity{QeIdentity: qeIdentity.QeInfo, IssuerChain: qeIdentity.QeIssuerChain}, nil
+}
+
+func (s *collateralGrpcServer) GetTcbStatus(ctx context.Context, req *pb.GetTcbStatusRequest) (*pb.TcbStatus, error) {
+	r, ok := ctx.Value(grpcRequestKey{}).(*http.Request)
+	if !ok {
+		return nil, status.Error(codes.Internal, "http request of the call is not known")
+	}
+	err := authorizeEndpoint(r, constants.HostDataReaderGroupName, true)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	if !validateInputString(constants.QeIDKey, req.Qeid) || !validateInputString(constants.PceIDKey, req.Pceid) {
+		slog.Errorf("resource/collateral_grpc_ops: GetTcbStatus() Input validation failed for request")
+		return nil, status.Error(codes.InvalidArgument, "invalid request param")
+	}
+
+	scopes, err := getPlatformScopes(r, constants.HostDataReaderGroupName)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	platform, err := s.db.PlatformRepository().Retrieve(&types.Platform{QeID: req.Qeid, PceID: req.Pceid})
+	if err != nil {
+		return nil, grpcError(retrieveRecordError(err, "platform"))
+	}
+	if err := authorizePlatformScope(r, scopes, platform.Fmspc, platform.PceID); err != nil {
+		return nil, grpcError(err)
+	}
+	recordPlatformAccess(s.db, platform)
+
+	response, err := retrieveTcbStatus(s.db, platform, s.conf)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	tcbStatus := &pb.TcbStatus{UpToDate: response.Status == "true", Message: response.Message, TcbStatus: response.TcbStatus}
+	if response.TcbInfo != nil {
+		tcbStatus.TcbInfo = &pb.TcbInfoFreshness{
+			IssueDate:               response.TcbInfo.IssueDate,
+			NextUpdate:              response.TcbInfo.NextUpdate,
+			TcbEvaluationDataNumber: int32(response.TcbInfo.TcbEvaluationDataNumber),
+			UpdatedTime:             response.TcbInfo.UpdatedTime.UTC().Format(time.RFC3339),
+		}
+	}
+	slog.Infof("%s: TCB status retrieved over gRPC by: %s", commLogMsg.AuthorizedAccess, r.RemoteAddr)
+	return tcbStatus, nil
+}
+
+// grpcError converts the errors of the retrieve functions, which carry the http status code the REST
+// apis respond with, into the gRPC status of the same meaning
+func grpcError(err error) error {
+	var statusCode int
+	var message string
+	var resErr *resourceError
+	var privErr *privilegeError
+	if errors.As(err, &resErr) {
+		statusCode, message = resErr.StatusCode, resErr.Message
+	} else if errors.As(err, &privErr) {
+		statusCode, message = privErr.StatusCode, privErr.Message
+	} else {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	code := codes.Internal
+	switch statusCode {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.FailedPrecondition
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	}
+	if message == "" {
+		message = http.StatusText(statusCode)
+	}
+	return status.Error(code, message)
+}
+
+func grpcPeer(ctx context.Context) string {
+	if r, ok := ctx.Value(grpcRequestKey{}).(*http.Request); ok {
+		return r.RemoteAddr
+	}
+	return "unknown"
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"context"
+	"crypto/x509"
+	commContext "intel/isecl/lib/common/v5/context"
+	"intel/isecl/lib/common/v5/types/aas"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	pb "intel/isecl/scs/v5/proto"
+	"intel/isecl/scs/v5/repository/memory"
+	"intel/isecl/scs/v5/types"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeTokenAuth stands in for the token auth middleware, the bearer token is taken as the name of
+// the role granted to the caller
+func fakeTokenAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if role == "" {
+			http.Error(w, "no bearer token provided", http.StatusUnauthorized)
+			return
+		}
+		r = commContext.SetUserRoles(r, []aas.RoleInfo{{Service: constants.ServiceName, Name: role}})
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestCollateralGrpcApi(t *testing.T) {
+	db := memory.NewDatabase()
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000", Ca: "processor"}
+	_, err := db.PlatformRepository().Create(platform)
+	assert.NoError(t, err)
+	_, err = db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: platform.Fmspc, TcbInfo: string(testTcbInfoJson),
+		TcbInfoIssuerChain: "tcbinfochain"})
+	assert.NoError(t, err)
+	_, err = db.PckCertChainRepository().Create(&types.PckCertChain{Ca: platform.Ca, PckCertChain: "certchain"})
+	assert.NoError(t, err)
+	_, err = db.PckCertRepository().Create(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn,
+		PceSvn: platform.PceSvn, CertIndex: 1, PckCerts: []string{"cert0", "cert1"},
+		Tcbms: []string{"020200000000000000000000000000000a00", "010100000000000000000000000000000900"}})
+	assert.NoError(t, err)
+	_, err = db.PckCrlRepository().Create(&types.PckCrl{Ca: platform.Ca, PckCrl: "crl", PckCrlCertChain: "crlchain",
+		NextUpdate: time.Now().Add(time.Hour)})
+	assert.NoError(t, err)
+	_, err = db.QEIdentityRepository().Create(&types.QEIdentity{ID: "id", QeInfo: "identity", QeIssuerChain: "qechain"})
+	assert.NoError(t, err)
+
+	conf := &config.Configuration{ReadsDuringRefreshPolicy: constants.ReadsDuringRefreshUnavailable}
+	// tracing is enabled, so that responses are streamed through the writer of the tracing middleware
+	enableTestTracing(t)
+	r := mux.NewRouter()
+	r.Use(TracingMiddleware)
+	CollateralGrpcOps(r.PathPrefix(GrpcPathPrefix).Subrouter(), db, conf, nil)
+	sr := r.PathPrefix(GrpcPathPrefix).Subrouter()
+	sr.Use(fakeTokenAuth)
+	TcbStatusGrpcOps(sr, db, conf, nil)
+
+	server := httptest.NewUnstartedServer(r)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	conn, err := grpc.Dial(strings.TrimPrefix(server.URL, "https://"),
+		grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(roots, "")))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	client := pb.NewCollateralClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pckCert, err := client.GetPckCert(ctx, &pb.GetPckCertRequest{EncryptedPpid: strings.Repeat("ab", 384),
+		Cpusvn: platform.CPUSvn, Pcesvn: platform.PceSvn, Pceid: platform.PceID, Qeid: platform.QeID})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "cert1", pckCert.PckCert)
+		assert.Equal(t, "certchain", pckCert.IssuerChain)
+		assert.Equal(t, "010100000000000000000000000000000900", pckCert.Tcbm)
+	}
+
+	pckCrl, err := client.GetPckCrl(ctx, &pb.GetPckCrlRequest{Ca: "processor"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []byte("crl"), pckCrl.Crl)
+		assert.Equal(t, "crlchain", pckCrl.IssuerChain)
+		assert.False(t, pckCrl.Expired)
+	}
+
+	tcbInfo, err := client.GetTcbInfo(ctx, &pb.GetTcbInfoRequest{Fmspc: platform.Fmspc})
+	if assert.NoError(t, err) {
+		assert.Equal(t, string(testTcbInfoJson), tcbInfo.TcbInfo)
+		assert.Equal(t, "tcbinfochain", tcbInfo.IssuerChain)
+	}
+
+	qeIdentity, err := client.GetQeIdentity(ctx, &pb.GetQeIdentityRequest{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "identity", qeIdentity.QeIdentity)
+		assert.Equal(t, "qechain", qeIdentity.IssuerChain)
+	}
+
+	// errors of the retrieve functions are returned as the gRPC status of the same meaning
+	_, err = client.GetTcbInfo(ctx, &pb.GetTcbInfoRequest{Fmspc: "20606_a000000"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.GetPckCert(ctx, &pb.GetPckCertRequest{EncryptedPpid: strings.Repeat("ab", 384),
+		Cpusvn: platform.CPUSvn, Pcesvn: platform.PceSvn, Pceid: platform.PceID, Qeid: "1518145496973c5e69577195511e9080"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// tcb status is only served to callers authorized as for the tcbstatus REST api
+	tcbStatusRequest := &pb.GetTcbStatusRequest{Qeid: platform.QeID, Pceid: platform.PceID}
+	_, err = client.GetTcbStatus(ctx, tcbStatusRequest)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.GetTcbStatus(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+constants.HostDataUpdaterGroupName),
+		tcbStatusRequest)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	readerCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+constants.HostDataReaderGroupName)
+	tcbStatus, err := client.GetTcbStatus(readerCtx, tcbStatusRequest)
+	if assert.NoError(t, err) {
+		assert.False(t, tcbStatus.UpToDate)
+		assert.Equal(t, "OutOfDate", tcbStatus.TcbStatus)
+		if assert.NotNil(t, tcbStatus.TcbInfo) {
+			assert.Equal(t, "2020-07-15T06:42:01Z", tcbStatus.TcbInfo.NextUpdate)
+		}
+	}
+	_, err = client.GetTcbStatus(readerCtx, &pb.GetTcbStatusRequest{Qeid: "1518145496973c5e69577195511e9080", Pceid: "0000"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// reads are unavailable during a full refresh as for the REST apis
+	setFullRefreshInProgress(true)
+	defer setFullRefreshInProgress(false)
+	_, err = client.GetQeIdentity(ctx, &pb.GetQeIdentityRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,265 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"context"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/repository"
+	"intel/isecl/scs/v5/repository/memory"
+	"intel/isecl/scs/v5/types"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetrieveCollaterals(t *testing.T) {
+	db := memory.NewDatabase()
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000", Ca: "processor"}
+	_, err := db.PlatformRepository().Create(platform)
+	assert.NoError(t, err)
+	_, err = db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: platform.Fmspc, TcbInfo: string(testTcbInfoJson)})
+	assert.NoError(t, err)
+	_, err = db.PckCertChainRepository().Create(&types.PckCertChain{Ca: platform.Ca, PckCertChain: "certchain"})
+	assert.NoError(t, err)
+	_, err = db.PckCertRepository().Create(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn,
+		PceSvn: platform.PceSvn, CertIndex: 1, PckCerts: []string{"cert0", "cert1"},
+		Tcbms: []string{"020200000000000000000000000000000a00", "010100000000000000000000000000000900"}})
+	assert.NoError(t, err)
+
+	tcbInfo, err := retrieveTcbInfo(context.Background(), db, platform.Fmspc, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, string(testTcbInfoJson), tcbInfo.TcbInfo)
+
+	pckCert, certChain, err := retrievePckCert(context.Background(), db, &types.Platform{QeID: platform.QeID, PceID: platform.PceID,
+		CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "cert1", pckCert.PckCerts[pckCert.CertIndex])
+	assert.Equal(t, "certchain", certChain.PckCertChain)
+
+	// a platform which is not cached is not found
+	_, _, err = retrievePckCert(context.Background(), db, &types.Platform{QeID: "1518145496973c5e69577195511e9080", PceID: "0000"}, nil, nil)
+	if assert.IsType(t, &resourceError{}, err) {
+		assert.Equal(t, http.StatusNotFound, err.(*resourceError).StatusCode)
+	}
+
+	response, err := retrieveTcbStatus(db, platform, &config.Configuration{})
+	assert.NoError(t, err)
+	assert.Equal(t, "false", response.Status)
+	assert.Equal(t, "OutOfDate", response.TcbStatus)
+}
+
+func TestRetrieveTcbStatusFreshness(t *testing.T) {
+	db := memory.NewDatabase()
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000", Ca: "processor"}
+	_, err := db.PlatformRepository().Create(platform)
+	assert.NoError(t, err)
+	cachedTime := time.Date(2020, 6, 16, 8, 0, 0, 0, time.UTC)
+	_, err = db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: platform.Fmspc, TcbInfo: string(testTcbInfoJson),
+		CreatedTime: cachedTime, UpdatedTime: cachedTime})
+	assert.NoError(t, err)
+	_, err = db.PckCertRepository().Create(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn,
+		PceSvn: platform.PceSvn, CertIndex: 1, PckCerts: []string{"cert0", "cert1"},
+		Tcbms: []string{"020200000000000000000000000000000a00", "010100000000000000000000000000000900"}})
+	assert.NoError(t, err)
+
+	response, err := retrieveTcbStatus(db, platform, &config.Configuration{})
+	assert.NoError(t, err)
+	assert.Equal(t, "OutOfDate", response.TcbStatus)
+	assert.Equal(t, &TcbInfoFreshness{IssueDate: "2020-06-15T06:42:01Z", NextUpdate: "2020-07-15T06:42:01Z",
+		TcbEvaluationDataNumber: 5, UpdatedTime: cachedTime}, response.TcbInfo)
+
+	// the status is returned without freshness when the cached TcbInfo cannot be read
+	assert.Nil(t, tcbInfoFreshness(db, "30606a000000"))
+}
+
+func TestRetrieveTcbStatusBaseline(t *testing.T) {
+	db := memory.NewDatabase()
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000", Ca: "processor"}
+	_, err := db.PlatformRepository().Create(platform)
+	assert.NoError(t, err)
+	_, err = db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: platform.Fmspc, TcbInfo: string(testTcbInfoJson)})
+	assert.NoError(t, err)
+	_, err = db.PckCertRepository().Create(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn,
+		PceSvn: platform.PceSvn, CertIndex: 0, PckCerts: []string{"cert0", "cert1"},
+		Tcbms: []string{"020200000000000000000000000000000a00", "010100000000000000000000000000000900"}})
+	assert.NoError(t, err)
+
+	// tcb of the platform meets the baseline
+	conf := &config.Configuration{MinTcbCompSvns: []int{2, 2}, MinPceSvn: 10}
+	response, err := retrieveTcbStatus(db, platform, conf)
+	assert.NoError(t, err)
+	assert.Equal(t, "true", response.Status)
+	assert.Equal(t, "UpToDate", response.TcbStatus)
+
+	// UpToDate platforms below the baseline are not reported as UpToDate
+	conf = &config.Configuration{MinTcbCompSvns: []int{2, 3}}
+	response, err = retrieveTcbStatus(db, platform, conf)
+	assert.NoError(t, err)
+	assert.Equal(t, "false", response.Status)
+	assert.Equal(t, "UpToDate", response.TcbStatus)
+	assert.Equal(t, "TCB is below the configured baseline: sgxtcbcomp02svn 2 is lower than 3", response.Message)
+
+	conf = &config.Configuration{MinTcbCompSvns: []int{2, 2}, MinPceSvn: 11}
+	response, err = retrieveTcbStatus(db, platform, conf)
+	assert.NoError(t, err)
+	assert.Equal(t, "false", response.Status)
+	assert.Equal(t, "TCB is below the configured baseline: pcesvn 10 is lower than 11", response.Message)
+}
+
+func TestRetrieveCollateralsStaleCertIndex(t *testing.T) {
+	db := memory.NewDatabase()
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000", Ca: "processor"}
+	_, err := db.PlatformRepository().Create(platform)
+	assert.NoError(t, err)
+	_, err = db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: platform.Fmspc, TcbInfo: string(testTcbInfoJson)})
+	assert.NoError(t, err)
+	_, err = db.PckCertChainRepository().Create(&types.PckCertChain{Ca: platform.Ca, PckCertChain: "certchain"})
+	assert.NoError(t, err)
+	// cert index selected before the pck certs were refreshed to fewer certs
+	_, err = db.PckCertRepository().Create(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn,
+		PceSvn: platform.PceSvn, CertIndex: 2, PckCerts: []string{"cert0", "cert1"},
+		Tcbms: []string{"020200000000000000000000000000000a00", "010100000000000000000000000000000900"}})
+	assert.NoError(t, err)
+
+	// the selection cannot be re-resolved from cached TcbInfo as the cert is not a valid pck cert
+	_, err = retrieveTcbStatus(db, platform, &config.Configuration{})
+	if assert.IsType(t, &resourceError{}, err) {
+		assert.Equal(t, http.StatusConflict, err.(*resourceError).StatusCode)
+	}
+
+	// pck certs are re-fetched from PCS, which is not configured here
+	_, _, err = retrievePckCert(context.Background(), db, &types.Platform{QeID: platform.QeID, PceID: platform.PceID,
+		CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn}, nil, nil)
+	assert.IsType(t, &resourceError{}, err)
+}
+
+func TestRecordPlatformAccess(t *testing.T) {
+	now := time.Date(2022, 6, 21, 10, 0, 0, 0, time.UTC)
+	fake := useFakeClock(t, now)
+
+	db := memory.NewDatabase()
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000", Ca: "processor"}
+	_, err := db.PlatformRepository().Create(platform)
+	assert.NoError(t, err)
+	_, err = db.PckCertChainRepository().Create(&types.PckCertChain{Ca: platform.Ca, PckCertChain: "certchain"})
+	assert.NoError(t, err)
+	_, err = db.PckCertRepository().Create(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn,
+		PceSvn: platform.PceSvn, CertIndex: 0, PckCerts: []string{"cert0"}, Tcbms: []string{"020200000000000000000000000000000a00"}})
+	assert.NoError(t, err)
+
+	lastAccessedTime := func() time.Time {
+		_, _, err := retrievePckCert(context.Background(), db, &types.Platform{QeID: platform.QeID, PceID: platform.PceID,
+			CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn}, nil, nil)
+		assert.NoError(t, err)
+		stored, err := db.PlatformRepository().Retrieve(&types.Platform{QeID: platform.QeID, PceID: platform.PceID})
+		assert.NoError(t, err)
+		return stored.LastAccessedTime
+	}
+	assert.Equal(t, now, lastAccessedTime())
+
+	// reads within the update interval do not write the platform
+	fake.Advance(constants.PlatformAccessUpdateInterval / 2)
+	assert.Equal(t, now, lastAccessedTime())
+
+	fake.Advance(constants.PlatformAccessUpdateInterval)
+	assert.Equal(t, now.Add(3*constants.PlatformAccessUpdateInterval/2), lastAccessedTime())
+}
+
+// unreachablePlatformRepository fails every query of platforms
+type unreachablePlatformRepository struct {
+	repository.PlatformRepository
+}
+
+func (unreachablePlatformRepository) Retrieve(*types.Platform) (*types.Platform, error) {
+	return nil, errors.New("connection refused")
+}
+
+type unreachablePlatformDatabase struct {
+	*memory.Database
+}
+
+func (d unreachablePlatformDatabase) PlatformRepository() repository.PlatformRepository {
+	return unreachablePlatformRepository{d.Database.PlatformRepository()}
+}
+
+func TestRetrieveRecordError(t *testing.T) {
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00"}
+
+	// a platform which is not cached is not found
+	_, _, err := retrievePckCert(context.Background(), memory.NewDatabase(), platform, nil, nil)
+	if assert.IsType(t, &resourceError{}, err) {
+		assert.Equal(t, http.StatusNotFound, err.(*resourceError).StatusCode)
+		assert.Contains(t, err.(*resourceError).Message, "no platform record found")
+	}
+
+	// a failed query is not reported as a platform not found
+	_, _, err = retrievePckCert(context.Background(), unreachablePlatformDatabase{memory.NewDatabase()}, platform, nil, nil)
+	if assert.IsType(t, &resourceError{}, err) {
+		assert.Equal(t, http.StatusInternalServerError, err.(*resourceError).StatusCode)
+		assert.Contains(t, err.(*resourceError).Message, "connection refused")
+	}
+}
+
+func TestRetrieveCollateralBundle(t *testing.T) {
+	// nextUpdate of testTcbInfoJson and qeInfo is 2020-07-15T06:42:01Z
+	now := time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)
+	useFakeClock(t, now)
+
+	db := memory.NewDatabase()
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000", Ca: "processor"}
+	_, err := db.PlatformRepository().Create(platform)
+	assert.NoError(t, err)
+	_, err = db.PckCertChainRepository().Create(&types.PckCertChain{Ca: platform.Ca, PckCertChain: "certchain"})
+	assert.NoError(t, err)
+	_, err = db.PckCertRepository().Create(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn,
+		PceSvn: platform.PceSvn, CertIndex: 1, PckCerts: []string{"cert0", "cert1"},
+		Tcbms: []string{"020200000000000000000000000000000a00", "010100000000000000000000000000000900"}})
+	assert.NoError(t, err)
+	_, err = db.PckCrlRepository().Create(&types.PckCrl{Ca: platform.Ca, PckCrl: "crl", PckCrlCertChain: "crlchain",
+		NextUpdate: now.Add(-time.Hour)})
+	assert.NoError(t, err)
+	_, err = db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: platform.Fmspc, TcbInfo: string(testTcbInfoJson),
+		TcbInfoIssuerChain: "tcbinfochain"})
+	assert.NoError(t, err)
+
+	// QE identity is neither cached nor fetched without configuration, the other collaterals are still returned
+	bundle := retrieveCollateralBundle(context.Background(), db, platform, "", nil, nil)
+	assert.Equal(t, platform.Fmspc, bundle.Fmspc)
+	assert.Equal(t, platform.Ca, bundle.Ca)
+	if assert.NotNil(t, bundle.PckCert) {
+		assert.Equal(t, BundledPckCert{BundledCollateral: BundledCollateral{Status: constants.CollateralStatusPresent,
+			Data: "cert1", IssuerChain: "certchain"}, Tcbm: "010100000000000000000000000000000900"}, *bundle.PckCert)
+	}
+	if assert.NotNil(t, bundle.PckCrl) {
+		assert.Equal(t, BundledCollateral{Status: constants.CollateralStatusExpired, Data: "crl", IssuerChain: "crlchain"}, *bundle.PckCrl)
+	}
+	assert.Equal(t, BundledCollateral{Status: constants.CollateralStatusPresent, Data: string(testTcbInfoJson),
+		IssuerChain: "tcbinfochain"}, bundle.TcbInfo)
+	assert.Equal(t, constants.CollateralStatusMissing, bundle.QeIdentity.Status)
+	assert.NotEmpty(t, bundle.QeIdentity.Error)
+	assert.Empty(t, bundle.QeIdentity.Data)
+
+	// only TcbInfo and QE identity are returned for an fmspc
+	_, err = db.QEIdentityRepository().Create(&types.QEIdentity{ID: "QE", QeInfo: string(qeInfo), QeIssuerChain: "qechain"})
+	assert.NoError(t, err)
+	bundle = retrieveCollateralBundle(context.Background(), db, nil, platform.Fmspc, nil, nil)
+	assert.Nil(t, bundle.PckCert)
+	assert.Nil(t, bundle.PckCrl)
+	assert.Equal(t, constants.CollateralStatusPresent, bundle.TcbInfo.Status)
+	assert.Equal(t, BundledCollateral{Status: constants.CollateralStatusPresent, Data: string(qeInfo), IssuerChain: "qechain"},
+		bundle.QeIdentity)
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"intel/isecl/scs/v5/config"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+)
+
+// NewCORS answers the CORS requests of browsers on the origins allowed by conf, for the routes of router
+// served with one of the allowed methods, e.g. the read apis with the default GET. It wraps router rather
+// than being a middleware of its routes, as preflight requests are made with OPTIONS and without a bearer
+// token, and so match neither the route nor the token auth. Requests of the other routes and methods are
+// served without CORS headers, and the handler is returned as is when CORS is disabled
+func NewCORS(conf config.CORS, router *mux.Router) func(http.Handler) http.Handler {
+	if len(conf.AllowedOrigins) == 0 {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+
+	methods := conf.AllowedMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet}
+	}
+	// the apis are authorized by a bearer token, which browsers send only when Authorization is allowed
+	options := []handlers.CORSOption{
+		handlers.AllowedOrigins(conf.AllowedOrigins),
+		handlers.AllowedMethods(methods),
+		handlers.AllowedHeaders(append([]string{"Authorization"}, conf.AllowedHeaders...)),
+	}
+	if conf.AllowCredentials {
+		options = append(options, handlers.AllowCredentials())
+	}
+
+	return func(next http.Handler) http.Handler {
+		cors := handlers.CORS(options...)(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Origin") == "" || !isCORSRoute(router, r, methods) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// the response differs by origin, so that it is not cached for other origins
+			w.Header().Add("Vary", "Origin")
+			cors.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isCORSRoute checks if a request, or the request a preflight request is made for, is made with one of
+// the allowed methods to a route of router
+func isCORSRoute(router *mux.Router, r *http.Request, methods []string) bool {
+	method := r.Method
+	if r.Method == http.MethodOptions {
+		method = r.Header.Get("Access-Control-Request-Method")
+	}
+	allowed := false
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return false
+	}
+
+	req := r.Clone(r.Context())
+	req.Method = strings.ToUpper(method)
+	var match mux.RouteMatch
+	return router.Match(req, &match)
+}
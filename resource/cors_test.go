@@ -0,0 +1,106 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// newCORSRouter routes a read and a mutating api behind an auth middleware which, like the token auth,
+// rejects requests without a bearer token
+func newCORSRouter() *mux.Router {
+	router := mux.NewRouter()
+	sr := router.PathPrefix(constants.APIPathPrefix).Subrouter()
+	sr.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	sr.Handle("/platforms", ok).Methods("GET")
+	sr.Handle("/refreshes", ok).Methods("POST")
+	return router
+}
+
+func corsResponse(handler http.Handler, method, path, origin string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, constants.APIPathPrefix+path, nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSPreflight(t *testing.T) {
+	const origin = "https://dashboard.example.com"
+	preflight := map[string]string{"Access-Control-Request-Method": "GET", "Access-Control-Request-Headers": "Authorization"}
+
+	// preflight requests are not answered when CORS is disabled
+	router := newCORSRouter()
+	w := corsResponse(NewCORS(config.CORS{}, router)(router), http.MethodOptions, "/platforms", origin, preflight)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	handler := NewCORS(config.CORS{AllowedOrigins: []string{origin}}, router)(router)
+	// preflight of a read api is answered without a bearer token
+	w = corsResponse(handler, http.MethodOptions, "/platforms", origin, preflight)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, origin, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Authorization", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "Origin", w.Header().Get("Vary"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+
+	// preflight of another origin, of a method not allowed or of an unknown route is not answered
+	w = corsResponse(handler, http.MethodOptions, "/platforms", "https://other.example.com", preflight)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	w = corsResponse(handler, http.MethodOptions, "/refreshes", origin, map[string]string{"Access-Control-Request-Method": "POST"})
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	w = corsResponse(handler, http.MethodOptions, "/unknown", origin, preflight)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// request headers not allowed are rejected
+	w = corsResponse(handler, http.MethodOptions, "/platforms", origin,
+		map[string]string{"Access-Control-Request-Method": "GET", "Access-Control-Request-Headers": "Authorization,X-Custom"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// the request itself is still authorized by its bearer token
+	w = corsResponse(handler, http.MethodGet, "/platforms", origin, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, origin, w.Header().Get("Access-Control-Allow-Origin"))
+	w = corsResponse(handler, http.MethodGet, "/platforms", origin, map[string]string{"Authorization": "Bearer token"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, origin, w.Header().Get("Access-Control-Allow-Origin"))
+	w = corsResponse(handler, http.MethodPost, "/refreshes", origin, map[string]string{"Authorization": "Bearer token"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	// mutating apis are enabled along with their method, and credentials when allowed
+	handler = NewCORS(config.CORS{AllowedOrigins: []string{origin}, AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"X-Custom"}, AllowCredentials: true}, router)(router)
+	w = corsResponse(handler, http.MethodOptions, "/refreshes", origin,
+		map[string]string{"Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "Authorization,X-Custom"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, origin, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Authorization,X-Custom", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+}
@@ -5,48 +5,101 @@
 package resource
 
 import (
+	"context"
 	"intel/isecl/scs/v5/config"
 	"intel/isecl/scs/v5/constants"
 	"intel/isecl/scs/v5/domain"
 	"intel/isecl/scs/v5/repository"
 	"intel/isecl/scs/v5/types"
+	"sync"
 
 	"github.com/pkg/errors"
 )
 
+// keyedLock serializes operations on the same key, such as caching the TcbInfo of an fmspc, while
+// operations on different keys run concurrently
+type keyedLock struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	held chan struct{}
+	refs int
+}
+
+// lock waits until the lock of key is acquired or ctx is done, the returned func releases the lock
+func (l *keyedLock) lock(ctx context.Context, key string) (func(), error) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*keyLock)
+	}
+	kl := l.locks[key]
+	if kl == nil {
+		kl = &keyLock{held: make(chan struct{}, 1)}
+		l.locks[key] = kl
+	}
+	kl.refs++
+	l.mu.Unlock()
+
+	select {
+	case kl.held <- struct{}{}:
+		return func() {
+			<-kl.held
+			l.release(key, kl)
+		}, nil
+	case <-ctx.Done():
+		l.release(key, kl)
+		return nil, ctx.Err()
+	}
+}
+
+func (l *keyedLock) release(key string, kl *keyLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	kl.refs--
+	if kl.refs == 0 {
+		delete(l.locks, key)
+	}
+}
+
+var tcbInfoCacheLock keyedLock
+
 // perform an api call to pcs server to get PCK Certificate for a sgx platform and store in db
-func getLazyCachePckCert(db repository.SCSDatabase, platformInfo *types.Platform, cacheType constants.CacheType, conf *config.Configuration, client *domain.HttpClient) (*types.PckCert, *types.PckCertChain, string, error) {
+func getLazyCachePckCert(ctx context.Context, db repository.SCSDatabase, platformInfo *types.Platform, cacheType constants.CacheType, conf *config.Configuration, client *domain.HttpClient) (*types.PckCert, *types.PckCertChain, string, error) {
 	log.Trace("resource/lazy_cache_ops: getLazyCachePckCert() Entering")
 	defer log.Trace("resource/lazy_cache_ops: getLazyCachePckCert() Leaving")
 
-	pckCertInfo, fmspcTcbInfo, pckCertChain, ca, err := fetchPckCertInfo(platformInfo, conf, client)
+	recordCacheFetch(constants.CollateralPckCert)
+	pckCertInfo, fmspcTcbInfo, pckCertChain, ca, err := fetchPckCertInfo(ctx, platformInfo, conf, client)
 	if err != nil {
-		return nil, nil, "", errors.New("fetchPckCertInfo:" + err.Error())
+		return nil, nil, "", errors.Wrap(err, "fetchPckCertInfo")
 	}
 
 	platformInfo.Fmspc = fmspcTcbInfo.Fmspc
 	platformInfo.Ca = ca
-	err = cachePlatformInfo(db, platformInfo, cacheType)
+	err = cachePlatformInfo(ctx, db, platformInfo, cacheType)
 	if err != nil {
 		return nil, nil, "", errors.New("cachePlatformInfo:" + err.Error())
 	}
 
-	err = cachePlatformTcbInfo(db, platformInfo, pckCertInfo.Tcbms[pckCertInfo.CertIndex], cacheType)
+	err = cachePlatformTcbInfo(ctx, db, platformInfo, pckCertInfo.Tcbms[pckCertInfo.CertIndex], cacheType)
 	if err != nil {
 		return nil, nil, "", errors.New("cachePlatformTcbInfo:" + err.Error())
 	}
 
-	_, err = cacheFmspcTcbInfo(db, fmspcTcbInfo, cacheType)
+	_, err = cacheFmspcTcbInfo(ctx, db, fmspcTcbInfo, cacheType)
 	if err != nil {
 		return nil, nil, "", errors.New("cacheFmpscTcbInfo:" + err.Error())
 	}
+	cacheTcbLevels(db, fmspcTcbInfo, conf)
 
-	certChain, err := cachePckCertChainInfo(db, pckCertChain, ca, cacheType)
+	certChain, err := cachePckCertChainInfo(ctx, db, pckCertChain, ca, cacheType)
 	if err != nil {
 		return nil, nil, "", errors.New("cachePckCertChainInfo:" + err.Error())
 	}
 
-	pckCert, err := cachePckCertInfo(db, pckCertInfo, cacheType)
+	pckCert, err := cachePckCertInfo(ctx, db, pckCertInfo, pckCertCacheType(db, pckCertInfo))
 	if err != nil {
 		return nil, nil, "", errors.New("cachePckCertInfo:" + err.Error())
 	}
@@ -56,34 +109,58 @@ func getLazyCachePckCert(db repository.SCSDatabase, platformInfo *types.Platform
 }
 
 // perform an api call to pcs server to get trusted computing base info for a sgx platform and store in db
-func getLazyCacheFmspcTcbInfo(db repository.SCSDatabase, fmspcType string, cacheType constants.CacheType, conf *config.Configuration, client *domain.HttpClient) (*types.FmspcTcbInfo, error) {
+func getLazyCacheFmspcTcbInfo(ctx context.Context, db repository.SCSDatabase, fmspcType string, cacheType constants.CacheType, conf *config.Configuration, client *domain.HttpClient) (*types.FmspcTcbInfo, error) {
 	log.Trace("resource/lazy_cache_ops: getLazyCacheFmspcTcbInfo() Entering")
 	defer log.Trace("resource/lazy_cache_ops: getLazyCacheFmspcTcbInfo() Leaving")
 
-	fmspcTcbInfo, err := fetchFmspcTcbInfo(fmspcType, conf, client)
+	recordCacheFetch(constants.CollateralTcbInfo)
+	fmspcTcbInfo, err := fetchFmspcTcbInfo(ctx, fmspcType, conf, client)
 	if err != nil {
-		return nil, errors.New("getLazyCacheFmspcTcbInfo: failed to fetch tcbinfo")
+		return nil, errors.Wrap(err, "getLazyCacheFmspcTcbInfo: failed to fetch tcbinfo")
 	}
 
-	fmspcTcb, err := cacheFmspcTcbInfo(db, fmspcTcbInfo, cacheType)
+	fmspcTcb, err := cacheFmspcTcbInfo(ctx, db, fmspcTcbInfo, cacheType)
 	if err != nil {
 		return nil, errors.New("cacheFmspcTcbInfo:" + err.Error())
 	}
+	cacheTcbLevels(db, fmspcTcb, conf)
 
 	log.Debug("getLazyCacheFmspcTcbInfo fetch and cache operation completed")
 	return fmspcTcb, nil
 }
 
-func getLazyCachePckCrl(db repository.SCSDatabase, caType string, cacheType constants.CacheType, conf *config.Configuration, client *domain.HttpClient) (*types.PckCrl, error) {
+// cacheMissingFmspcTcbInfo fetches and caches the TcbInfo of an fmspc which is not cached yet.
+// Platforms of a rack share an fmspc and are pushed at about the same time, so callers for the same
+// fmspc wait for the fetch in progress and then find its TcbInfo cached, instead of each fetching it
+// from PCS. The TcbInfo is fetched again by the next caller if the fetch in progress fails
+func cacheMissingFmspcTcbInfo(ctx context.Context, db repository.SCSDatabase, fmspc string, conf *config.Configuration, client *domain.HttpClient) (*types.FmspcTcbInfo, error) {
+	unlock, err := tcbInfoCacheLock.lock(ctx, fmspc)
+	if err != nil {
+		return nil, errors.Wrap(err, "cacheMissingFmspcTcbInfo: gave up waiting for tcbinfo being cached")
+	}
+	defer unlock()
+
+	existingFmspc, err := db.FmspcTcbInfoRepository().Retrieve(&types.FmspcTcbInfo{Fmspc: fmspc})
+	if existingFmspc != nil {
+		return existingFmspc, nil
+	}
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, errors.Wrap(err, "cacheMissingFmspcTcbInfo: could not retrieve tcbinfo")
+	}
+	return getLazyCacheFmspcTcbInfo(ctx, db, fmspc, constants.CacheInsert, conf, client)
+}
+
+func getLazyCachePckCrl(ctx context.Context, db repository.SCSDatabase, caType string, cacheType constants.CacheType, conf *config.Configuration, client *domain.HttpClient) (*types.PckCrl, error) {
 	log.Trace("resource/lazy_cache_ops: getLazyCachePckCrl() Entering")
 	defer log.Trace("resource/lazy_cache_ops: getLazyCachePckCrl() Leaving")
 
-	pckCRLInfo, err := fetchPckCrlInfo(caType, conf, client)
+	recordCacheFetch(constants.CollateralPckCrl)
+	pckCRLInfo, err := fetchPckCrlInfo(ctx, caType, conf, client)
 	if err != nil {
-		return nil, errors.New("getLazyCachePckCrl: Failed to fetch PCKCRLInfo")
+		return nil, errors.Wrap(err, "getLazyCachePckCrl: Failed to fetch PCKCRLInfo")
 	}
 
-	pckCrl, err := cachePckCrlInfo(db, pckCRLInfo, cacheType)
+	pckCrl, err := cachePckCrlInfo(ctx, db, pckCRLInfo, cacheType)
 	if err != nil {
 		return nil, errors.New("cachePckCRLInfo:" + err.Error())
 	}
@@ -92,16 +169,36 @@ func getLazyCachePckCrl(db repository.SCSDatabase, caType string, cacheType cons
 	return pckCrl, nil
 }
 
-func getLazyCacheQEIdentityInfo(db repository.SCSDatabase, cacheType constants.CacheType, config *config.Configuration, client *domain.HttpClient) (*types.QEIdentity, error) {
+func getLazyCacheRootCaCrl(ctx context.Context, db repository.SCSDatabase, cacheType constants.CacheType, conf *config.Configuration, client *domain.HttpClient) (*types.RootCaCrl, error) {
+	log.Trace("resource/lazy_cache_ops: getLazyCacheRootCaCrl() Entering")
+	defer log.Trace("resource/lazy_cache_ops: getLazyCacheRootCaCrl() Leaving")
+
+	recordCacheFetch(constants.CollateralRootCaCrl)
+	rootCaCrlInfo, err := fetchRootCaCrlInfo(ctx, conf, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "getLazyCacheRootCaCrl: Failed to fetch root ca crl")
+	}
+
+	rootCaCrl, err := cacheRootCaCrlInfo(ctx, db, rootCaCrlInfo, cacheType)
+	if err != nil {
+		return nil, errors.New("cacheRootCaCrlInfo:" + err.Error())
+	}
+
+	log.Debug("getLazyCacheRootCaCrl fetch and cache operation completed")
+	return rootCaCrl, nil
+}
+
+func getLazyCacheQEIdentityInfo(ctx context.Context, db repository.SCSDatabase, cacheType constants.CacheType, config *config.Configuration, client *domain.HttpClient) (*types.QEIdentity, error) {
 	log.Trace("resource/lazy_cache_ops: getLazyCacheQEIdentityInfo() Entering")
 	defer log.Trace("resource/lazy_cache_ops: getLazyCacheQEIdentityInfo() Leaving")
 
-	qeInfo, err := fetchQeIdentityInfo(config, client)
+	recordCacheFetch(constants.CollateralQeIdentity)
+	qeInfo, err := fetchQeIdentityInfo(ctx, config, client)
 	if err != nil {
-		return nil, errors.New("fetchQeIdentityInfo:" + err.Error())
+		return nil, errors.Wrap(err, "fetchQeIdentityInfo")
 	}
 
-	qeIdentity, err := cacheQeIdentityInfo(db, qeInfo, cacheType)
+	qeIdentity, err := cacheQeIdentityInfo(ctx, db, qeInfo, cacheType)
 	if err != nil {
 		return nil, errors.New("cacheQeIdentityInfo:" + err.Error())
 	}
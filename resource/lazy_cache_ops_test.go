@@ -5,14 +5,21 @@
 package resource
 
 import (
+	"context"
 	"encoding/json"
 	"intel/isecl/scs/v5/config"
 	"intel/isecl/scs/v5/constants"
 	"intel/isecl/scs/v5/domain"
 	"intel/isecl/scs/v5/domain/mocks"
+	"intel/isecl/scs/v5/repository/memory"
 	"intel/isecl/scs/v5/repository/postgres/mock"
 	"intel/isecl/scs/v5/types"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -68,14 +75,14 @@ func TestGetLazyCachePckCert(t *testing.T) {
 	pInfo := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000"}
 
 	// getLazyCachePckCert API will get PCK Certs and will cache it as well.
-	_, _, _, err := getLazyCachePckCert(db, platform, constants.CacheInsert, conf, &client)
+	_, _, _, err := getLazyCachePckCert(context.Background(), db, platform, constants.CacheInsert, conf, &client)
 	assert.NotNil(t, err)
 
 	platform.Manifest = ""
-	_, _, _, err = getLazyCachePckCert(db, platform, constants.CacheInsert, conf, &client)
+	_, _, _, err = getLazyCachePckCert(context.Background(), db, platform, constants.CacheInsert, conf, &client)
 	assert.NotNil(t, err)
 
-	_, _, _, err = getLazyCachePckCert(db, pInfo, constants.CacheInsert, conf, &client)
+	_, _, _, err = getLazyCachePckCert(context.Background(), db, pInfo, constants.CacheInsert, conf, &client)
 	assert.NotNil(t, err)
 }
 
@@ -127,24 +134,24 @@ func TestGetLazyCachePckCrl(t *testing.T) {
 
 	db.PckCertChainRepository().Create(certChain)
 
-	_, err := getLazyCachePckCrl(db, platform.Ca, constants.CacheInsert, conf, &client)
+	_, err := getLazyCachePckCrl(context.Background(), db, platform.Ca, constants.CacheInsert, conf, &client)
 	assert.Nil(t, err)
 
-	_, err = getLazyCachePckCrl(db, platform.Ca, constants.CacheInsert, nil, &client)
+	_, err = getLazyCachePckCrl(context.Background(), db, platform.Ca, constants.CacheInsert, nil, &client)
 	assert.NotNil(t, err)
 
-	_, err = getLazyCachePckCrl(db, platform.Ca, 2, conf, &client)
+	_, err = getLazyCachePckCrl(context.Background(), db, platform.Ca, 2, conf, &client)
 	assert.Nil(t, err)
 }
 
 func TestGetLazyCacheFmspcTcbInfo(t *testing.T) {
 
 	db := &mock.MockDatabase{}
-	_, err := getLazyCacheFmspcTcbInfo(db, "20606a000000", constants.CacheInsert, nil, nil)
+	_, err := getLazyCacheFmspcTcbInfo(context.Background(), db, "20606a000000", constants.CacheInsert, nil, nil)
 	assert.NotNil(t, err)
 
 	conf := config.Load(testConfigFilePath)
-	_, err = getLazyCacheFmspcTcbInfo(db, "20606a000000", constants.CacheInsert, conf, nil)
+	_, err = getLazyCacheFmspcTcbInfo(context.Background(), db, "20606a000000", constants.CacheInsert, conf, nil)
 	assert.NotNil(t, err)
 }
 
@@ -195,6 +202,77 @@ func TestGetLazyCacheQEIdentityInfo(t *testing.T) {
 	}
 
 	db.PckCertChainRepository().Create(certChain)
-	_, err := getLazyCacheQEIdentityInfo(db, constants.CacheInsert, conf, &client)
+	_, err := getLazyCacheQEIdentityInfo(context.Background(), db, constants.CacheInsert, conf, &client)
 	assert.Nil(t, err)
 }
+
+// slowTcbInfoClient counts the TcbInfo requests to PCS, and delays them so that concurrent callers
+// overlap with the request in flight
+type slowTcbInfoClient struct {
+	requests int32
+	client   domain.HttpClient
+}
+
+func (c *slowTcbInfoClient) Do(req *http.Request) (*http.Response, error) {
+	if strings.Contains(req.URL.Path, "/tcb") {
+		atomic.AddInt32(&c.requests, 1)
+		time.Sleep(50 * time.Millisecond)
+	}
+	return c.client.Do(req)
+}
+
+func TestCacheMissingFmspcTcbInfoConcurrently(t *testing.T) {
+	db := memory.NewDatabase()
+	conf := config.Load(testConfigFilePath)
+	counting := &slowTcbInfoClient{client: mocks.NewClientMock(http.StatusOK)}
+	var client domain.HttpClient = counting
+
+	// pushes of two platforms with the same fmspc fetch its TcbInfo from PCS once
+	var wg sync.WaitGroup
+	results := make([]*types.FmspcTcbInfo, 2)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tcbInfo, err := cacheMissingFmspcTcbInfo(context.Background(), db, "20606a000000", conf, &client)
+			assert.NoError(t, err)
+			results[i] = tcbInfo
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&counting.requests))
+	for _, tcbInfo := range results {
+		if assert.NotNil(t, tcbInfo) {
+			assert.Equal(t, "20606a000000", tcbInfo.Fmspc)
+		}
+	}
+
+	// a different fmspc is fetched
+	_, err := cacheMissingFmspcTcbInfo(context.Background(), db, "30606a000000", conf, &client)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&counting.requests))
+	assert.Empty(t, tcbInfoCacheLock.locks)
+}
+
+func TestKeyedLock(t *testing.T) {
+	var l keyedLock
+	unlock, err := l.lock(context.Background(), "20606a000000")
+	assert.NoError(t, err)
+
+	// other keys are not blocked
+	unlockOther, err := l.lock(context.Background(), "30606a000000")
+	assert.NoError(t, err)
+	unlockOther()
+
+	// waiting for a held key is abandoned with the context
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = l.lock(ctx, "20606a000000")
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	unlock()
+	unlock, err = l.lock(context.Background(), "20606a000000")
+	assert.NoError(t, err)
+	unlock()
+	assert.Empty(t, l.locks)
+}
@@ -0,0 +1,284 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"encoding/json"
+	commLogMsg "intel/isecl/lib/common/v5/log/message"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/domain"
+	"intel/isecl/scs/v5/repository"
+	"intel/isecl/scs/v5/types"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// PckCertReselectionResult counts the cached platforms by the outcome of selecting their pck cert again,
+// platforms whose selection failed keep their earlier selection and are listed by qeid
+type PckCertReselectionResult struct {
+	Platforms  int      `json:"platforms"`
+	Reselected int      `json:"reselected"`
+	Unchanged  int      `json:"unchanged"`
+	Failed     []string `json:"failed"`
+}
+
+// PckCertSelectionRequest carries a TcbInfo response of PCS, along with its url escaped issuer chain,
+// which the client obtained through another channel, to evaluate the pck cert selection and TCB status
+// of a cached platform against it. pce_id is optional, as qe_id identifies a platform
+type PckCertSelectionRequest struct {
+	QeID               string          `json:"qe_id"`
+	PceID              string          `json:"pce_id,omitempty"`
+	TcbInfo            json.RawMessage `json:"tcb_info"`
+	TcbInfoIssuerChain string          `json:"tcb_info_issuer_chain"`
+	Persist            bool            `json:"persist"`
+}
+
+// EvaluatedPckCertSelection is the pck cert selection of a platform evaluated against a supplied
+// TcbInfo. Persisted is set when the TcbInfo and the selection were cached as requested
+type EvaluatedPckCertSelection struct {
+	PckCertSelection
+	Persisted bool `json:"persisted"`
+}
+
+func PckCertSelectionOps(r *mux.Router, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) {
+	r.Handle("/cache/pckcerts/reselect", reselectPckCerts(db)).Methods("POST")
+	r.Handle("/pckcertselection", handlers.ContentTypeHandler(evaluatePckCertSelection(db, conf), "application/json")).Methods("POST")
+}
+
+// reselectCachedPckCerts selects again the pck cert of every cached platform for its current raw tcb
+// level, from its cached pck certs and the cached TcbInfo of its fmspc. Nothing is fetched from PCS
+func reselectCachedPckCerts(db repository.SCSDatabase) (*PckCertReselectionResult, error) {
+	platforms, err := db.PlatformRepository().RetrieveAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not retrieve platforms")
+	}
+
+	result := &PckCertReselectionResult{Platforms: len(platforms), Failed: []string{}}
+	tcbInfos := make(map[string]string)
+	for i := range platforms {
+		platform := &platforms[i]
+		reselected, err := reselectCachedPckCert(db, platform, tcbInfos)
+		if err != nil {
+			log.WithError(err).Warnf("Could not select pck cert of platform with qeid %s again", platform.QeID)
+			result.Failed = append(result.Failed, platform.QeID)
+			continue
+		}
+		if reselected {
+			result.Reselected++
+		} else {
+			result.Unchanged++
+		}
+	}
+	return result, nil
+}
+
+// reselectCachedPckCert selects again the pck cert of a platform, tcbInfos holds the TcbInfo of the
+// fmspcs retrieved so far so that it is retrieved once for the platforms sharing an fmspc
+func reselectCachedPckCert(db repository.SCSDatabase, platform *types.Platform, tcbInfos map[string]string) (bool, error) {
+	pckCert, err := db.PckCertRepository().RetrieveByTcbLevel(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID,
+		CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn})
+	if err != nil {
+		return false, errors.Wrap(err, "could not retrieve pck cert")
+	}
+
+	tcbInfo, ok := tcbInfos[platform.Fmspc]
+	if !ok {
+		fmspcTcbInfo, err := db.FmspcTcbInfoRepository().Retrieve(&types.FmspcTcbInfo{Fmspc: platform.Fmspc})
+		if err != nil {
+			return false, errors.Wrap(err, "could not retrieve tcb info")
+		}
+		tcbInfo = fmspcTcbInfo.TcbInfo
+		tcbInfos[platform.Fmspc] = tcbInfo
+	}
+
+	certIndex := pckCert.CertIndex
+	if err := reselectPckCert(db, platform, pckCert, tcbInfo); err != nil {
+		return false, err
+	}
+	return pckCert.CertIndex != certIndex, nil
+}
+
+// reselectPckCerts keeps the pck cert selection of cached platforms correct after their TcbInfo changed,
+// e.g. on a TCB recovery, without refreshing their pck certs from PCS
+func reselectPckCerts(db repository.SCSDatabase) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.CacheManagerGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		result, err := reselectCachedPckCerts(db)
+		if err != nil {
+			log.WithError(err).Error("Could not select pck certs of cached platforms again")
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		js, err := json.Marshal(result)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(js)
+		if err != nil {
+			log.WithError(err).Error("Could not write pck cert reselection result to response")
+		}
+		slog.Infof("%s: Pck certs of %d platforms selected again, %d changed, by: %s", commLogMsg.AuthorizedAccess,
+			result.Platforms, result.Reselected, r.RemoteAddr)
+		return nil
+	}
+}
+
+// evaluateSuppliedTcbInfo selects the pck cert of a platform from its cached pck certs and evaluates its
+// TCB status against a TcbInfo supplied by the client, without contacting PCS. The cached pck cert is not
+// modified, the selection and the TcbInfo are only cached by persistSuppliedTcbInfo
+func evaluateSuppliedTcbInfo(db repository.SCSDatabase, platform *types.Platform, tcbInfo string) (*PckCertSelection, error) {
+	pckCert, err := db.PckCertRepository().RetrieveByTcbLevel(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID,
+		CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn})
+	if err != nil {
+		return nil, retrieveRecordError(err, "pck cert")
+	}
+	if len(pckCert.PckCerts) == 0 || len(pckCert.PckCerts) != len(pckCert.Tcbms) {
+		return nil, &resourceError{Message: "cached pck certs and tcbms do not match, refresh of its pck certs is required",
+			StatusCode: http.StatusConflict}
+	}
+
+	evaluated := *pckCert
+	evaluated.CertIndex, err = selectPckCert(platform, pckCert.PckCerts, tcbInfo)
+	if errors.Is(err, ErrRawTcbBelowPckCerts) {
+		return nil, &resourceError{Message: err.Error(), StatusCode: http.StatusUnprocessableEntity}
+	}
+	if err != nil {
+		slog.WithError(err).Warnf("resource/pck_cert_selection_ops: evaluateSuppliedTcbInfo() Could not select pck cert of platform with qeid %s", platform.QeID)
+		return nil, &resourceError{Message: "failed to select pck cert against the tcb info", StatusCode: http.StatusBadRequest}
+	}
+	if isPckCertSelectionStale(&evaluated) {
+		return nil, &resourceError{Message: "selected pck cert is out of range of cached pck certs", StatusCode: http.StatusInternalServerError}
+	}
+	return compareTcbLevels(platform, &evaluated, tcbInfo)
+}
+
+// persistSuppliedTcbInfo caches a supplied TcbInfo in place of the cached TcbInfo of its fmspc, and the
+// pck cert selected against it. A TcbInfo older than the cached one, by its tcbEvaluationDataNumber, is
+// rejected so that a client cannot roll back the TCB statuses of the platforms sharing the fmspc
+func persistSuppliedTcbInfo(r *http.Request, db repository.SCSDatabase, platform *types.Platform, tcbInfo *TcbInfoJSON,
+	fmspcTcbInfo *types.FmspcTcbInfo, conf *config.Configuration) error {
+	existingFmspc, err := db.FmspcTcbInfoRepository().Retrieve(&types.FmspcTcbInfo{Fmspc: platform.Fmspc})
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+	}
+	if existingFmspc != nil {
+		var existingTcbInfo TcbInfoJSON
+		if err := json.Unmarshal([]byte(existingFmspc.TcbInfo), &existingTcbInfo); err == nil &&
+			existingTcbInfo.TcbInfo.TcbEvaluationDataNumber > tcbInfo.TcbInfo.TcbEvaluationDataNumber {
+			return &resourceError{Message: "tcb info is older than the cached tcb info", StatusCode: http.StatusConflict}
+		}
+	}
+
+	_, err = cacheFmspcTcbInfo(r.Context(), db, fmspcTcbInfo, constants.CacheInsert)
+	if err != nil {
+		return &resourceError{Message: "could not cache tcb info", StatusCode: http.StatusInternalServerError}
+	}
+	cacheTcbLevels(db, fmspcTcbInfo, conf)
+
+	pckCert, err := db.PckCertRepository().RetrieveByTcbLevel(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID,
+		CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn})
+	if err == nil {
+		err = reselectPckCert(db, platform, pckCert, fmspcTcbInfo.TcbInfo)
+	}
+	if err != nil {
+		log.WithError(err).Errorf("Could not cache pck cert selection of platform with qeid %s", platform.QeID)
+		return &resourceError{Message: "could not cache pck cert selection", StatusCode: http.StatusInternalServerError}
+	}
+	return nil
+}
+
+// evaluatePckCertSelection selects the pck cert of a cached platform and evaluates its TCB status against
+// a TcbInfo supplied by the client, e.g. one carried into an air-gapped environment, without contacting
+// PCS. The supplied TcbInfo is not trusted, so its signature is always verified regardless of the
+// signature verification policy. Nothing is cached unless persist is requested
+func evaluatePckCertSelection(db repository.SCSDatabase, conf *config.Configuration) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.CacheManagerGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		if r.ContentLength == 0 {
+			slog.Error("resource/pck_cert_selection_ops: evaluatePckCertSelection() The request body was not provided")
+			return &resourceError{Message: "pck cert selection request not provided",
+				StatusCode: http.StatusBadRequest}
+		}
+
+		var selectionReq PckCertSelectionRequest
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&selectionReq)
+		if err != nil {
+			slog.WithError(err).Errorf("resource/pck_cert_selection_ops: evaluatePckCertSelection() %s :  Failed to decode request body", commLogMsg.InvalidInputBadEncoding)
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusBadRequest}
+		}
+
+		qeID := strings.ToLower(selectionReq.QeID)
+		pceID := strings.ToLower(selectionReq.PceID)
+		if !validateInputString(constants.QeIDKey, qeID) || (pceID != "" && !validateInputString(constants.PceIDKey, pceID)) {
+			slog.Error("resource/pck_cert_selection_ops: evaluatePckCertSelection() Input validation failed")
+			return &resourceError{Message: "invalid qe_id or pce_id", StatusCode: http.StatusBadRequest}
+		}
+
+		var tcbInfo TcbInfoJSON
+		if err := json.Unmarshal(selectionReq.TcbInfo, &tcbInfo); err != nil {
+			slog.WithError(err).Error("resource/pck_cert_selection_ops: evaluatePckCertSelection() Invalid tcb info")
+			return &resourceError{Message: "invalid tcb info", StatusCode: http.StatusBadRequest}
+		}
+		if err := verifyTcbInfoSignature(selectionReq.TcbInfo, selectionReq.TcbInfoIssuerChain); err != nil {
+			slog.WithError(err).Error("resource/pck_cert_selection_ops: evaluatePckCertSelection() Tcb info signature verification failed")
+			return &resourceError{Message: "tcb info signature could not be verified", StatusCode: http.StatusBadRequest}
+		}
+
+		platform, err := db.PlatformRepository().Retrieve(&types.Platform{QeID: qeID, PceID: pceID})
+		if err != nil {
+			return retrieveRecordError(err, "platform")
+		}
+		if !strings.EqualFold(tcbInfo.TcbInfo.Fmspc, platform.Fmspc) {
+			return &resourceError{Message: "tcb info is not for the fmspc of the platform", StatusCode: http.StatusBadRequest}
+		}
+
+		selection, err := evaluateSuppliedTcbInfo(db, platform, string(selectionReq.TcbInfo))
+		if err != nil {
+			return err
+		}
+		result := EvaluatedPckCertSelection{PckCertSelection: *selection}
+		if selectionReq.Persist {
+			fmspcTcbInfo := &types.FmspcTcbInfo{Fmspc: platform.Fmspc, TcbInfo: string(selectionReq.TcbInfo),
+				TcbInfoIssuerChain: selectionReq.TcbInfoIssuerChain, TcbUpdate: conf.GetTcbUpdate()}
+			if err := persistSuppliedTcbInfo(r, db, platform, &tcbInfo, fmspcTcbInfo, conf); err != nil {
+				return err
+			}
+			result.Persisted = true
+		}
+
+		js, err := json.Marshal(result)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(js)
+		if err != nil {
+			log.WithError(err).Error("Could not write evaluated pck cert selection to response")
+		}
+		slog.Infof("%s: PCK cert selection evaluated against supplied tcb info, persisted: %t, by: %s", commLogMsg.AuthorizedAccess,
+			result.Persisted, r.RemoteAddr)
+		return nil
+	}
+}
@@ -0,0 +1,219 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"intel/isecl/lib/common/v5/context"
+	"intel/isecl/lib/common/v5/types/aas"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/repository/memory"
+	"intel/isecl/scs/v5/types"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// useFakePckCertSelection selects the pck cert at the index the TcbInfo maps to, as the selection
+// library accepts only genuine pck certs
+func useFakePckCertSelection(t *testing.T, indexes map[string]uint8) {
+	selectPckCert = func(platform *types.Platform, pckCerts []string, tcbInfo string) (uint8, error) {
+		return indexes[tcbInfo], nil
+	}
+	t.Cleanup(func() {
+		selectPckCert = getBestPckCert
+	})
+}
+
+func reselectPckCertsResponse(router *mux.Router) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/cache/pckcerts/reselect", nil)
+	permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.CacheManagerGroupName}}
+	req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+	roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.CacheManagerGroupName, Context: "type=SCS"}}
+	req = context.SetUserRoles(req, roleInfo)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestReselectPckCerts(t *testing.T) {
+	useFakePckCertSelection(t, map[string]uint8{"tcbinfo": 0, "recovered tcbinfo": 1})
+
+	db := memory.NewDatabase()
+	router := mux.NewRouter()
+	PckCertSelectionOps(router, db, nil, nil)
+
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000", Ca: "processor"}
+	_, err := db.PlatformRepository().Create(platform)
+	assert.NoError(t, err)
+	_, err = db.PckCertRepository().Create(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn,
+		PceSvn: platform.PceSvn, CertIndex: 0, PckCerts: []string{"cert0", "cert1"},
+		Tcbms: []string{"020200000000000000000000000000000a00", "010100000000000000000000000000000900"}})
+	assert.NoError(t, err)
+	tcbInfo, err := db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: platform.Fmspc, TcbInfo: "tcbinfo"})
+	assert.NoError(t, err)
+	// platform without cached pck certs
+	_, err = db.PlatformRepository().Create(&types.Platform{QeID: "7518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000"})
+	assert.NoError(t, err)
+
+	var result PckCertReselectionResult
+	w := reselectPckCertsResponse(router)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, PckCertReselectionResult{Platforms: 2, Reselected: 0, Unchanged: 1,
+		Failed: []string{"7518145496973c5e69577195511e9080"}}, result)
+
+	// TcbInfo updated by a TCB recovery
+	tcbInfo.TcbInfo = "recovered tcbinfo"
+	assert.NoError(t, db.FmspcTcbInfoRepository().Update(tcbInfo))
+
+	w = reselectPckCertsResponse(router)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 1, result.Reselected)
+	assert.Equal(t, 0, result.Unchanged)
+
+	pckCert, err := db.PckCertRepository().RetrieveByTcbLevel(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID,
+		CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn})
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint8(1), pckCert.CertIndex)
+		assert.Len(t, pckCert.PckCerts, 2)
+	}
+}
+
+func TestReselectPckCertsForbidden(t *testing.T) {
+	router := mux.NewRouter()
+	PckCertSelectionOps(router, memory.NewDatabase(), nil, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/cache/pckcerts/reselect", nil)
+	permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataReaderGroupName}}
+	req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+	roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: "type=SCS"}}
+	req = context.SetUserRoles(req, roleInfo)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func evaluatePckCertSelectionResponse(router *mux.Router, group string, selectionReq PckCertSelectionRequest) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(selectionReq)
+	req := httptest.NewRequest(http.MethodPost, "/pckcertselection", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{group}}
+	req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+	roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: group, Context: "type=SCS"}}
+	req = context.SetUserRoles(req, roleInfo)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// createTestTcbInfo returns a TcbInfo of fmspc signed by a test signing certificate, along with its url
+// escaped issuer chain
+func createTestTcbInfo(t *testing.T, fmspc string, tcbEvaluationDataNumber int) ([]byte, string) {
+	tcbInfo, issuerChain, err := createTestSignedPcsBody("tcbInfo", fmt.Sprintf(`{"version":2,"fmspc":"%s","pceId":"0000",`+
+		`"issueDate":"2022-06-21T10:00:00Z","nextUpdate":"2022-07-21T10:00:00Z","tcbType":0,"tcbEvaluationDataNumber":%d,`+
+		`"tcbLevels":[{"tcb":{"sgxtcbcomp01svn":2,"sgxtcbcomp02svn":2,"pcesvn":10},"tcbDate":"2021-11-10T00:00:00Z","tcbStatus":"UpToDate"},`+
+		`{"tcb":{"sgxtcbcomp01svn":1,"sgxtcbcomp02svn":1,"pcesvn":9},"tcbDate":"2020-11-11T00:00:00Z","tcbStatus":"OutOfDate"}]}`,
+		fmspc, tcbEvaluationDataNumber))
+	assert.NoError(t, err)
+	return tcbInfo, issuerChain
+}
+
+func TestEvaluatePckCertSelection(t *testing.T) {
+	cachedTcbInfo, cachedIssuerChain := createTestTcbInfo(t, "20606A000000", 12)
+	suppliedTcbInfo, suppliedIssuerChain := createTestTcbInfo(t, "20606A000000", 13)
+	olderTcbInfo, olderIssuerChain := createTestTcbInfo(t, "20606A000000", 11)
+	otherTcbInfo, otherIssuerChain := createTestTcbInfo(t, "00906ED50000", 13)
+	useFakePckCertSelection(t, map[string]uint8{string(cachedTcbInfo): 0, string(suppliedTcbInfo): 1, string(olderTcbInfo): 1})
+	useSgxRootCA(t, suppliedIssuerChain)
+
+	db := memory.NewDatabase()
+	router := mux.NewRouter()
+	PckCertSelectionOps(router, db, &config.Configuration{}, nil)
+
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000", Ca: "processor"}
+	_, err := db.PlatformRepository().Create(platform)
+	assert.NoError(t, err)
+	tcbms := []string{"020200000000000000000000000000000a00", "010100000000000000000000000000000900"}
+	_, err = db.PckCertRepository().Create(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn,
+		PceSvn: platform.PceSvn, CertIndex: 0, PckCerts: []string{"cert0", "cert1"}, Tcbms: tcbms})
+	assert.NoError(t, err)
+	_, err = db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: platform.Fmspc, TcbInfo: string(cachedTcbInfo),
+		TcbInfoIssuerChain: cachedIssuerChain})
+	assert.NoError(t, err)
+
+	cachedSelection := func() (uint8, string) {
+		pckCert, err := db.PckCertRepository().RetrieveByTcbLevel(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID,
+			CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn})
+		assert.NoError(t, err)
+		tcbInfo, err := db.FmspcTcbInfoRepository().Retrieve(&types.FmspcTcbInfo{Fmspc: platform.Fmspc})
+		assert.NoError(t, err)
+		return pckCert.CertIndex, tcbInfo.TcbInfo
+	}
+
+	// selection and status are evaluated against the supplied TcbInfo without caching it
+	var result EvaluatedPckCertSelection
+	w := evaluatePckCertSelectionResponse(router, constants.CacheManagerGroupName, PckCertSelectionRequest{QeID: platform.QeID,
+		TcbInfo: suppliedTcbInfo, TcbInfoIssuerChain: suppliedIssuerChain})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, uint8(1), result.CertIndex)
+	assert.Equal(t, tcbms[1], result.Tcbm)
+	assert.Equal(t, "OutOfDate", result.TcbStatus)
+	assert.False(t, result.Persisted)
+	certIndex, tcbInfo := cachedSelection()
+	assert.Equal(t, uint8(0), certIndex)
+	assert.Equal(t, string(cachedTcbInfo), tcbInfo)
+
+	// TcbInfo which is tampered, signed by another issuer chain, or of another fmspc is rejected
+	tampered := bytes.Replace(suppliedTcbInfo, []byte(`"tcbStatus":"OutOfDate"`), []byte(`"tcbStatus":"UpToDate"`), 1)
+	for _, selectionReq := range []PckCertSelectionRequest{
+		{QeID: platform.QeID, TcbInfo: tampered, TcbInfoIssuerChain: suppliedIssuerChain},
+		{QeID: platform.QeID, TcbInfo: suppliedTcbInfo, TcbInfoIssuerChain: otherIssuerChain},
+		{QeID: platform.QeID, TcbInfo: otherTcbInfo, TcbInfoIssuerChain: otherIssuerChain},
+		{QeID: platform.QeID, TcbInfo: []byte(`{}`)},
+		{QeID: "invalid", TcbInfo: suppliedTcbInfo, TcbInfoIssuerChain: suppliedIssuerChain},
+	} {
+		w = evaluatePckCertSelectionResponse(router, constants.CacheManagerGroupName, selectionReq)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+	w = evaluatePckCertSelectionResponse(router, constants.CacheManagerGroupName, PckCertSelectionRequest{
+		QeID: "7518145496973c5e69577195511e9080", TcbInfo: suppliedTcbInfo, TcbInfoIssuerChain: suppliedIssuerChain})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = evaluatePckCertSelectionResponse(router, constants.HostDataReaderGroupName, PckCertSelectionRequest{QeID: platform.QeID,
+		TcbInfo: suppliedTcbInfo, TcbInfoIssuerChain: suppliedIssuerChain})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// TcbInfo and selection are cached when requested
+	w = evaluatePckCertSelectionResponse(router, constants.CacheManagerGroupName, PckCertSelectionRequest{QeID: platform.QeID,
+		PceID: platform.PceID, TcbInfo: suppliedTcbInfo, TcbInfoIssuerChain: suppliedIssuerChain, Persist: true})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, uint8(1), result.CertIndex)
+	assert.True(t, result.Persisted)
+	certIndex, tcbInfo = cachedSelection()
+	assert.Equal(t, uint8(1), certIndex)
+	assert.Equal(t, string(suppliedTcbInfo), tcbInfo)
+
+	// TcbInfo older than the cached one is evaluated, but not cached
+	w = evaluatePckCertSelectionResponse(router, constants.CacheManagerGroupName, PckCertSelectionRequest{QeID: platform.QeID,
+		TcbInfo: olderTcbInfo, TcbInfoIssuerChain: olderIssuerChain})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = evaluatePckCertSelectionResponse(router, constants.CacheManagerGroupName, PckCertSelectionRequest{QeID: platform.QeID,
+		TcbInfo: olderTcbInfo, TcbInfoIssuerChain: olderIssuerChain, Persist: true})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	_, tcbInfo = cachedSelection()
+	assert.Equal(t, string(suppliedTcbInfo), tcbInfo)
+}
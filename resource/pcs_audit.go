@@ -0,0 +1,69 @@
+/*
+ * Copyright (C) 2020 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"encoding/base64"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/repository"
+	"intel/isecl/scs/v5/types"
+)
+
+// pcsAuditor retains raw responses of Intel PCS server in background, so that the
+// caching path is never blocked on writing audit records
+type pcsAuditor struct {
+	db         repository.SCSDatabase
+	maxRecords int
+	records    chan *types.PcsAuditRecord
+}
+
+var auditor *pcsAuditor
+
+// StartPcsAudit starts retaining raw responses of Intel PCS server, only the latest
+// maxRecords responses are kept
+func StartPcsAudit(db repository.SCSDatabase, maxRecords int) {
+	a := &pcsAuditor{
+		db:         db,
+		maxRecords: maxRecords,
+		records:    make(chan *types.PcsAuditRecord, constants.PcsAuditQueueSize),
+	}
+	go a.run()
+	auditor = a
+	log.Infof("Raw responses of Intel PCS server are retained for audit, max records: %d", maxRecords)
+}
+
+func (a *pcsAuditor) run() {
+	for record := range a.records {
+		_, err := a.db.PcsAuditRecordRepository().Create(record)
+		if err != nil {
+			log.WithError(err).Errorf("could not write audit record of %s response", record.Endpoint)
+			continue
+		}
+		err = a.db.PcsAuditRecordRepository().Prune(a.maxRecords)
+		if err != nil {
+			log.WithError(err).Error("could not prune pcs audit records")
+		}
+	}
+}
+
+// auditPcsResponse queues a raw response of Intel PCS server for audit when audit is enabled,
+// the response is dropped when the audit queue is full
+func auditPcsResponse(endpoint, requestKey, issuerChain string, body []byte) {
+	a := auditor
+	if a == nil {
+		return
+	}
+	record := &types.PcsAuditRecord{
+		Endpoint:    endpoint,
+		RequestKey:  requestKey,
+		IssuerChain: issuerChain,
+		Response:    base64.StdEncoding.EncodeToString(body),
+	}
+	select {
+	case a.records <- record:
+	default:
+		log.Warnf("pcs audit queue is full, %s response for %s is not retained", endpoint, requestKey)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"context"
+	"encoding/base64"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/domain/mocks"
+	"intel/isecl/scs/v5/repository/postgres/mock"
+	"intel/isecl/scs/v5/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPcsAudit(t *testing.T) {
+	db := getMockDatabase()
+	auditRepo := db.PcsAuditRecordRepository().(*mock.MockPcsAuditRecordRepository)
+	conf := config.Load(testConfigFilePath)
+	client := mocks.NewClientMock(200)
+
+	// responses are not retained when audit is disabled
+	_, err := fetchFmspcTcbInfo(context.Background(), "20606a000000", conf, &client)
+	assert.Nil(t, err)
+	assert.Empty(t, auditRepo.RetrieveAll())
+
+	StartPcsAudit(db, 2)
+	defer func() {
+		auditor = nil
+	}()
+
+	fmspcTcbInfo, err := fetchFmspcTcbInfo(context.Background(), "20606a000000", conf, &client)
+	assert.Nil(t, err)
+	var records []types.PcsAuditRecord
+	assert.Eventually(t, func() bool {
+		records = auditRepo.RetrieveAll()
+		return len(records) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "tcb info", records[0].Endpoint)
+	assert.Equal(t, "20606a000000", records[0].RequestKey)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte(fmspcTcbInfo.TcbInfo)), records[0].Response)
+
+	// only the latest records are kept
+	_, err = fetchQeIdentityInfo(context.Background(), conf, &client)
+	assert.Nil(t, err)
+	_, err = fetchPckCrlInfo(context.Background(), "processor", conf, &client)
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		records = auditRepo.RetrieveAll()
+		return len(records) == 2 && records[1].Endpoint == "pckcrl"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "qe identity", records[0].Endpoint)
+	assert.Equal(t, "processor", records[1].RequestKey)
+}
+
+func TestAuditPcsResponseQueueFull(t *testing.T) {
+	auditor = &pcsAuditor{records: make(chan *types.PcsAuditRecord, 1)}
+	defer func() {
+		auditor = nil
+	}()
+
+	// no reader drains the queue, audit must not block the caller
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < constants.PcsAuditQueueSize; i++ {
+			auditPcsResponse("tcb info", "20606a000000", "", []byte("{}"))
+		}
+		done <- true
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("auditPcsResponse blocked on a full audit queue")
+	}
+	assert.Len(t, auditor.records, 1)
+}
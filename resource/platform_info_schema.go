@@ -0,0 +1,175 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// platformInfoSchemaVersion is the version of the schema which pushed platform info is validated
+// against. A schema change which rejects payloads accepted so far is made in a new version
+const platformInfoSchemaVersion = "v1"
+
+//go:embed schemas/platform_info.v1.json
+var platformInfoSchemaJSON []byte
+
+var platformInfoSchema = mustLoadSchema(platformInfoSchemaJSON)
+
+// jsonSchema is the subset of JSON schema (draft-07) keywords used by the schemas of SCS payloads.
+// Keywords not listed here are not supported and are ignored
+type jsonSchema struct {
+	ID         string                 `json:"$id"`
+	Type       string                 `json:"type"`
+	Required   []string               `json:"required"`
+	Properties map[string]*jsonSchema `json:"properties"`
+	MinLength  *int                   `json:"minLength"`
+	MaxLength  *int                   `json:"maxLength"`
+	Pattern    string                 `json:"pattern"`
+
+	pattern *regexp.Regexp
+}
+
+// schemaError is a violation of the schema by the value of a field, which is named by its path in
+// the payload
+type schemaError struct {
+	Field   string
+	Message string
+}
+
+func (e schemaError) String() string {
+	return e.Field + ": " + e.Message
+}
+
+// schemaErrors lists all the violations of the schema by a payload
+type schemaErrors []schemaError
+
+func (e schemaErrors) Error() string {
+	messages := make([]string, len(e))
+	for i := range e {
+		messages[i] = e[i].String()
+	}
+	return strings.Join(messages, ", ")
+}
+
+func mustLoadSchema(data []byte) *jsonSchema {
+	schema, err := loadSchema(data)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+func loadSchema(data []byte) (*jsonSchema, error) {
+	var schema jsonSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal schema")
+	}
+	if err := schema.compile(); err != nil {
+		return nil, errors.Wrapf(err, "could not compile schema %s", schema.ID)
+	}
+	return &schema, nil
+}
+
+func (s *jsonSchema) compile() error {
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return err
+		}
+		s.pattern = pattern
+	}
+	for _, property := range s.Properties {
+		if err := property.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate validates a JSON payload against the schema, and returns every field which violates
+// it sorted by field, so that a client can fix all of them at once
+func (s *jsonSchema) validate(payload []byte) error {
+	var value interface{}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return errors.Wrap(err, "payload is not valid JSON")
+	}
+
+	var errs schemaErrors
+	s.validateValue(value, "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
+
+func (s *jsonSchema) validateValue(value interface{}, field string, errs *schemaErrors) {
+	fail := func(format string, args ...interface{}) {
+		name := field
+		if name == "" {
+			name = "(root)"
+		}
+		*errs = append(*errs, schemaError{Field: name, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			fail("should be an object")
+			return
+		}
+		for _, required := range s.Required {
+			if _, ok := object[required]; !ok {
+				*errs = append(*errs, schemaError{Field: joinField(field, required), Message: "is required"})
+			}
+		}
+		for name, property := range s.Properties {
+			if v, ok := object[name]; ok {
+				property.validateValue(v, joinField(field, name), errs)
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail("should be a string")
+			return
+		}
+		length := utf8.RuneCountInString(str)
+		if s.MinLength != nil && s.MaxLength != nil && *s.MinLength == *s.MaxLength && length != *s.MinLength {
+			fail("should be %d characters long", *s.MinLength)
+			return
+		}
+		if s.MinLength != nil && length < *s.MinLength {
+			fail("should be at least %d characters long", *s.MinLength)
+			return
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			fail("should be at most %d characters long", *s.MaxLength)
+			return
+		}
+		if s.pattern != nil && !s.pattern.MatchString(str) {
+			fail("should match %s", s.Pattern)
+		}
+	}
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
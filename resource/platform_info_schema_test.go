@@ -0,0 +1,106 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"intel/isecl/lib/common/v5/context"
+	"intel/isecl/lib/common/v5/types/aas"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/repository/memory"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func validPlatformInfo() map[string]interface{} {
+	return map[string]interface{}{
+		"enc_ppid":      strings.Repeat("0f", 384),
+		"cpu_svn":       "1bf8deed6f929ce40bd658e61ea722eb",
+		"pce_svn":       "0a00",
+		"pce_id":        "0000",
+		"qe_id":         "0518145496973c5e69577195511e9080",
+		"hardware_uuid": "5a8de8c4-84a1-4cc0-9b95-2d8b9a0ff1b4",
+	}
+}
+
+func validatePlatformInfo(t *testing.T, platformInfo interface{}) error {
+	payload, err := json.Marshal(platformInfo)
+	assert.NoError(t, err)
+	return platformInfoSchema.validate(payload)
+}
+
+func TestPlatformInfoSchema(t *testing.T) {
+	assert.NoError(t, validatePlatformInfo(t, validPlatformInfo()))
+
+	withManifest := validPlatformInfo()
+	withManifest["manifest"] = "178e874b49e44aa599bb3057170925b4"
+	assert.NoError(t, validatePlatformInfo(t, withManifest))
+
+	// fields not in the schema are left to the unknown fields policy
+	withUnknown := validPlatformInfo()
+	withUnknown["agent_version"] = "5.0"
+	assert.NoError(t, validatePlatformInfo(t, withUnknown))
+
+	malformed := []struct {
+		name   string
+		modify func(map[string]interface{})
+		errors string
+	}{
+		{"missing fields", func(p map[string]interface{}) { delete(p, "qe_id"); delete(p, "cpu_svn") },
+			"cpu_svn: is required, qe_id: is required"},
+		{"wrong type", func(p map[string]interface{}) { p["pce_svn"] = 10 }, "pce_svn: should be a string"},
+		{"short enc_ppid", func(p map[string]interface{}) { p["enc_ppid"] = "0f0f" }, "enc_ppid: should be 768 characters long"},
+		{"non hex qe_id", func(p map[string]interface{}) { p["qe_id"] = strings.Repeat("z", 32) }, "qe_id: should match ^[0-9a-fA-F]*$"},
+		{"padded hardware_uuid", func(p map[string]interface{}) { p["hardware_uuid"] = " 5a8de8c4-84a1-4cc0-9b95-2d8b9a0ff1b4" },
+			"hardware_uuid: should be 36 characters long"},
+		{"long manifest", func(p map[string]interface{}) { p["manifest"] = strings.Repeat("0", 131074) },
+			"manifest: should be at most 131072 characters long"},
+		{"several fields", func(p map[string]interface{}) { p["pce_id"] = "00"; p["manifest"] = "xyz"; delete(p, "enc_ppid") },
+			"enc_ppid: is required, manifest: should match ^[0-9a-fA-F]*$, pce_id: should be 4 characters long"},
+	}
+	for _, tc := range malformed {
+		platformInfo := validPlatformInfo()
+		tc.modify(platformInfo)
+		err := validatePlatformInfo(t, platformInfo)
+		if assert.Error(t, err, tc.name) {
+			assert.Equal(t, tc.errors, err.Error(), tc.name)
+		}
+	}
+
+	assert.EqualError(t, validatePlatformInfo(t, []string{"qe_id"}), "(root): should be an object")
+	assert.Error(t, platformInfoSchema.validate([]byte(`{"qe_id":`)))
+
+	_, err := loadSchema([]byte(`{"type": "object", "properties": {"qe_id": {"type": "string", "pattern": "("}}}`))
+	assert.Error(t, err)
+}
+
+func TestPushPlatformInfoSchemaErrors(t *testing.T) {
+	router := mux.NewRouter()
+	PlatformInfoOps(router, memory.NewDatabase(), nil, nil)
+
+	platformInfo := validPlatformInfo()
+	platformInfo["pce_svn"] = "0a"
+	delete(platformInfo, "qe_id")
+	body, _ := json.Marshal(platformInfo)
+
+	req := httptest.NewRequest(http.MethodPost, "/platforms", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataUpdaterGroupName}}
+	req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+	roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataUpdaterGroupName, Context: "type=SCS"}}
+	req = context.SetUserRoles(req, roleInfo)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// malformed payload is rejected with all its field errors before the token subject is checked
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "pce_svn: should be 4 characters long, qe_id: is required")
+}
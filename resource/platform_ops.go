@@ -12,6 +12,10 @@ package resource
 import "C"
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
@@ -22,7 +26,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"intel/isecl/lib/common/v5/context"
+	commContext "intel/isecl/lib/common/v5/context"
 	commLogMsg "intel/isecl/lib/common/v5/log/message"
 	"intel/isecl/scs/v5/config"
 	"intel/isecl/scs/v5/constants"
@@ -30,12 +34,16 @@ import (
 	"intel/isecl/scs/v5/repository"
 	"intel/isecl/scs/v5/types"
 	"io/ioutil"
+	"math"
+	"math/big"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"os"
 	"os/signal"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -43,7 +51,6 @@ import (
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
-	"github.com/jinzhu/gorm"
 	"github.com/pkg/errors"
 )
 
@@ -54,6 +61,14 @@ const (
 	Undefined
 )
 
+// tcbComparisonResults names the outcomes of compareTcbComponents for the selection diagnostics
+var tcbComparisonResults = map[int]string{
+	Error:          "Error",
+	EqualOrGreater: "EqualOrGreater",
+	Lower:          "Lower",
+	Undefined:      "Undefined",
+}
+
 type RefreshResponse struct {
 	Status      string             `json:"status"`
 	RetryAfter  *int               `json:"retry-after,omitempty"`
@@ -61,8 +76,108 @@ type RefreshResponse struct {
 }
 
 type Response struct {
-	Status  string
-	Message string
+	Status    string
+	Message   string
+	TcbStatus string `json:",omitempty"`
+	// TcbInfo tells how fresh the TcbInfo the tcb status was evaluated against is, so that the
+	// consumer can apply its own freshness policy
+	TcbInfo *TcbInfoFreshness `json:",omitempty"`
+}
+
+// TcbInfoFreshness is the issueDate, nextUpdate and tcbEvaluationDataNumber of a cached TcbInfo, and
+// the time SCS last cached it
+type TcbInfoFreshness struct {
+	IssueDate               string
+	NextUpdate              string
+	TcbEvaluationDataNumber int
+	UpdatedTime             time.Time
+}
+
+type RegistrationStatus struct {
+	QeID   string `json:"qeid,omitempty"`
+	PceID  string `json:"pceid"`
+	Status string `json:"status"`
+	Source string `json:"source"`
+}
+
+type RevokedPlatform struct {
+	QeID  string `json:"qeid"`
+	PceID string `json:"pceid"`
+}
+
+// PlatformSummary lists the cached values of a platform, without its encrypted ppid and manifest
+type PlatformSummary struct {
+	QeID        string    `json:"qeid"`
+	PceID       string    `json:"pceid"`
+	CPUSvn      string    `json:"cpusvn"`
+	PceSvn      string    `json:"pcesvn"`
+	Fmspc       string    `json:"fmspc"`
+	Ca          string    `json:"ca"`
+	UpdatedTime time.Time `json:"updated-time"`
+	// LastAccessedTime is not returned for platforms not served by a read api since it is tracked
+	LastAccessedTime *time.Time `json:"last-accessed-time,omitempty"`
+	// PckCertDaysToExpiry is the number of days until the pck cert selected for the platform expires,
+	// negative once it has expired. It is not returned when the expiry of the pck cert is not known
+	PckCertDaysToExpiry *int `json:"pck-cert-days-to-expiry,omitempty"`
+	PckCertExpiring     bool `json:"pck-cert-expiring"`
+}
+
+// PckCrlSummary lists the validity of a cached PCK CRL, the CRL itself is returned only on request.
+// ThisUpdate and NextUpdate are not known for CRLs cached before their validity was stored
+type PckCrlSummary struct {
+	Ca                 string     `json:"ca"`
+	IssuerChainPresent bool       `json:"issuer-chain-present"`
+	ThisUpdate         *time.Time `json:"this-update,omitempty"`
+	NextUpdate         *time.Time `json:"next-update,omitempty"`
+	Expired            bool       `json:"expired"`
+	UpdatedTime        time.Time  `json:"updated-time"`
+	PckCrl             string     `json:"pck-crl,omitempty"`
+}
+
+// TcbLevelComparison is the outcome of comparing the raw tcb of the selected pck cert of a platform
+// against a TCB level of TcbInfo. Matched is set on the level whose status is the TCB status
+type TcbLevelComparison struct {
+	Tcb       TcbLevels `json:"tcb"`
+	TcbDate   string    `json:"tcb_date"`
+	TcbStatus string    `json:"tcb_status"`
+	Result    string    `json:"result"`
+	Matched   bool      `json:"matched"`
+}
+
+// PckCertSelection describes the pck cert selected for the current raw tcb level of a platform and
+// how its tcbm was compared against the TCB levels, sorted the way they are compared
+type PckCertSelection struct {
+	QeID      string               `json:"qe_id"`
+	PceID     string               `json:"pce_id"`
+	Fmspc     string               `json:"fmspc"`
+	CertIndex uint8                `json:"cert_index"`
+	CertCount int                  `json:"cert_count"`
+	Tcbm      string               `json:"tcbm"`
+	TcbStatus string               `json:"tcb_status"`
+	TcbLevels []TcbLevelComparison `json:"tcb_levels"`
+}
+
+// TcbComparison is the outcome of comparing the current raw tcb of a platform against the raw tcb
+// cached for it. ReselectionNeeded is set when they differ, as the pck cert selected for the cached
+// raw tcb may not be the one PCS would select for the current raw tcb
+type TcbComparison struct {
+	QeID              string `json:"qe_id"`
+	PceID             string `json:"pce_id"`
+	CPUSvn            string `json:"cpu_svn"`
+	PceSvn            string `json:"pce_svn"`
+	CachedCPUSvn      string `json:"cached_cpu_svn"`
+	CachedPceSvn      string `json:"cached_pce_svn"`
+	Result            string `json:"result"`
+	ReselectionNeeded bool   `json:"reselection_needed"`
+}
+
+// TcbStatusSummary counts the platforms of an fmspc by their TCB status, platforms whose TCB status
+// could not be evaluated, e.g. as their pck certs are not cached, are counted as unevaluated
+type TcbStatusSummary struct {
+	Fmspc       string         `json:"fmspc"`
+	Platforms   int            `json:"platforms"`
+	TcbStatuses map[string]int `json:"tcb_statuses"`
+	Unevaluated int            `json:"unevaluated"`
 }
 
 type PlatformInfo struct {
@@ -123,15 +238,67 @@ type PckCertsInfo struct {
 	Cert string    `json:"cert"`
 }
 
+// ErrPckCertsNotAvailable is returned when PCS returns "Not available" instead of the pck cert of
+// every TCB level of a platform, as it does for platforms which are not yet registered with PCS
+var ErrPckCertsNotAvailable = errors.New("no PCK certificates available for platform")
+
+// ErrRawTcbBelowPckCerts is returned when PCK Cert Selection Lib finds the raw tcb of a platform lower than
+// the tcb of all its pck certs, which usually means that the TCB of the platform is to be updated, e.g. by
+// a microcode or BIOS update, before a pck cert can be selected for it
+var ErrRawTcbBelowPckCerts = errors.New("raw TCB of platform is lower than TCB of all its PCK certificates")
+
 type cpuSvn struct {
 	bytes []byte
 }
 
 var tcbStatusRetrieveParams = map[string]bool{"qeid": true, "pceid": true}
 
+var pckCertsRetrieveParams = map[string]bool{"qeid": true, "pceid": true}
+
+var pckCertSelectionRetrieveParams = map[string]bool{"qeid": true, "pceid": true}
+
+var tcbComparisonRetrieveParams = map[string]bool{"qeid": true, "pceid": true, "cpusvn": true, "pcesvn": true}
+
+var tcbStatusSummaryRetrieveParams = map[string]bool{"fmspc": true}
+
+var registrationStatusRetrieveParams = map[string]bool{"qeid": true, "pceid": true, "encrypted_ppid": true}
+
+var platformsRetrieveParams = map[string]bool{"pceid": true, "limit": true, "offset": true}
+
+var platformRetrieveParams = map[string]bool{"qeid": true, "pceid": true}
+
+var revokedPlatformsRetrieveParams = map[string]bool{"ca": true}
+
+var pckCrlsRetrieveParams = map[string]bool{"verbose": true, "limit": true, "offset": true}
+
+var refreshStartParams = map[string]bool{"type": true}
+
+// refresh types which can be requested through the refresh api instead of a full refresh
+var refreshTypeTriggers = map[string]constants.RefreshTrigger{
+	constants.TypeRefreshCert:      constants.TriggerStartCerts,
+	constants.TypeRefreshTcb:       constants.TriggerStartTcbs,
+	constants.TypeRefreshQe:        constants.TriggerStartQe,
+	constants.TypeRefreshOutOfDate: constants.TriggerStartOutOfDateCerts,
+	constants.TypeRefreshExpiring:  constants.TriggerStartExpiringCerts,
+}
+
 func PlatformInfoOps(r *mux.Router, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) {
 	r.Handle("/platforms", handlers.ContentTypeHandler(pushPlatformInfo(db, conf, client), "application/json")).Methods("POST")
-	r.Handle("/tcbstatus", handlers.ContentTypeHandler(getTcbStatus(db), "application/json")).Methods("GET")
+	// a platform is addressed either by the {qeid} path variable or by the qeid query param, which
+	// mux exposes as the same variable, so that both forms share the handlers
+	r.Handle("/platforms/{qeid}", handlers.ContentTypeHandler(getPlatform(db, conf), "application/json")).Methods("GET")
+	r.Handle("/platforms/{qeid}", handlers.ContentTypeHandler(deletePlatformInfo(db), "application/json")).Methods("DELETE")
+	r.Handle("/platforms", handlers.ContentTypeHandler(getPlatform(db, conf), "application/json")).Methods("GET").Queries("qeid", "{qeid}")
+	r.Handle("/platforms", handlers.ContentTypeHandler(deletePlatformInfo(db), "application/json")).Methods("DELETE").Queries("qeid", "{qeid}")
+	r.Handle("/platforms", handlers.ContentTypeHandler(getPlatforms(db, conf), "application/json")).Methods("GET")
+	r.Handle("/tcbstatus", handlers.ContentTypeHandler(getTcbStatus(db, conf), "application/json")).Methods("GET")
+	r.Handle("/pckcerts", handlers.CompressHandler(handlers.ContentTypeHandler(getPckCerts(db, conf, client), "application/json"))).Methods("GET")
+	r.Handle("/pckcertselection", handlers.ContentTypeHandler(getPckCertSelection(db, conf), "application/json")).Methods("GET")
+	r.Handle("/tcbcomparison", handlers.ContentTypeHandler(getTcbComparison(db), "application/json")).Methods("GET")
+	r.Handle("/tcbstatussummary", handlers.ContentTypeHandler(getTcbStatusSummary(db, conf), "application/json")).Methods("GET")
+	r.Handle("/registrationstatus", handlers.ContentTypeHandler(getRegistrationStatus(db, conf, client), "application/json")).Methods("GET")
+	r.Handle("/revokedplatforms", handlers.ContentTypeHandler(getRevokedPlatforms(db), "application/json")).Methods("GET")
+	r.Handle("/pckcrls", handlers.ContentTypeHandler(getPckCrls(db), "application/json")).Methods("GET")
 }
 
 func RefreshPlatformInfoOps(r *mux.Router, db repository.SCSDatabase, trigger chan<- constants.RefreshTrigger) {
@@ -139,6 +306,10 @@ func RefreshPlatformInfoOps(r *mux.Router, db repository.SCSDatabase, trigger ch
 	r.Handle("/refreshes", handlers.ContentTypeHandler(refreshPlatformInfoStart(db, trigger), "application/json")).Methods("POST")
 }
 
+// selectPckCert selects the pck cert best suited for the raw tcb level of a platform from cached pck
+// certs, it is replaced only by tests as the selection library accepts only genuine pck certs
+var selectPckCert = getBestPckCert
+
 // This function invokes SGX DCAP PCK Certificate Selection Library (C++)
 // we pass following parameters to the C++ library
 // 1. current taw tcb level of the platform (cpusvn and pcesvn value)
@@ -207,29 +378,86 @@ func getBestPckCert(platformInfo *types.Platform, pckCerts []string, tcb string)
 		"Raw TCB is lower than all input PCKs",
 	}
 
-	if ret != 0 {
+	if ret == C.PCK_CERT_SELECT_PCK_NOT_FOUND {
+		err = ErrRawTcbBelowPckCerts
+	} else if ret != 0 {
 		err = errors.New(certError[ret])
 	}
 	return uint8(certIdx), err
 }
 
-func fetchPckCertInfo(platformInfo *types.Platform, conf *config.Configuration, client *domain.HttpClient) (*types.PckCert, *types.FmspcTcbInfo, string, string, error) {
+// isPckCertSelectionStale checks if the cert index of a cached pck cert is out of range of its pck
+// certs or tcbms, which are replaced on refresh independently of the selected cert index
+func isPckCertSelectionStale(pckCert *types.PckCert) bool {
+	return int(pckCert.CertIndex) >= len(pckCert.PckCerts) || int(pckCert.CertIndex) >= len(pckCert.Tcbms)
+}
+
+// reselectPckCert selects again the pck cert best suited for the raw tcb level of the platform from
+// the cached pck certs and TcbInfo, and stores the selection when it changed
+func reselectPckCert(db repository.SCSDatabase, platform *types.Platform, pckCert *types.PckCert, tcbInfo string) error {
+	if len(pckCert.PckCerts) == 0 || len(pckCert.PckCerts) != len(pckCert.Tcbms) {
+		return errors.New("cached pck certs and tcbms do not match")
+	}
+	certIndex, err := selectPckCert(platform, pckCert.PckCerts, tcbInfo)
+	if err != nil {
+		return errors.Wrap(err, "failed to select pck cert from cached pck certs")
+	}
+	if certIndex == pckCert.CertIndex && !isPckCertSelectionStale(pckCert) {
+		return nil
+	}
+	pckCert.CertIndex = certIndex
+	if isPckCertSelectionStale(pckCert) {
+		return errors.New("selected pck cert is out of range of cached pck certs")
+	}
+	setPckCertNotAfter(pckCert)
+
+	// saved without the zero value check of Update, so that a selection of the first cert is stored
+	return db.ExecuteInTransaction(func(tx repository.SCSDatabase) error {
+		if err := tx.PckCertRepository().Delete(pckCert); err != nil {
+			return err
+		}
+		_, err := tx.PckCertRepository().Create(pckCert)
+		return err
+	})
+}
+
+// readPcsResponseBody reads the body of a PCS response and rejects an empty body. ContentLength
+// is not relied upon, as it is -1 or 0 for responses with chunked transfer encoding
+func readPcsResponseBody(resp *http.Response, api string) ([]byte, error) {
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) == 0 {
+		return nil, errors.Errorf("no content found in %s Http Response", api)
+	}
+	return body, nil
+}
+
+func fetchPckCertInfo(ctx context.Context, platformInfo *types.Platform, conf *config.Configuration, client *domain.HttpClient) (*types.PckCert, *types.FmspcTcbInfo, string, string, error) {
 	log.Trace("resource/platform_ops: fetchPckCertInfo() Entering")
 	defer log.Trace("resource/platform_ops: fetchPckCertInfo() Leaving")
 
+	ctx, span := startSpan(ctx, "fetchPckCertInfo")
+	span.setAttribute("scs.qeid", platformInfo.QeID)
 	// using platform sgx values, fetch the pck certs from intel pcs server
 	var resp *http.Response
 	var err error
+	defer func() { span.end(err) }()
 	if platformInfo.Encppid == "" && platformInfo.Manifest == "" {
 		log.Error("invalid request")
 		return nil, nil, "", "", errors.New("invalid request, enc_ppid and platform_manifest are null")
 	}
 
+	// the manifest is preferred as PCS returns pck certs of all the packages of a platform for it
 	if platformInfo.Manifest != "" {
-		resp, err = getPckCertsWithManifestFromProvServer(platformInfo.Manifest,
+		if platformInfo.Encppid != "" {
+			log.Debugf("Both enc_ppid and manifest provided for platform with qeid %s, using manifest", platformInfo.QeID)
+		}
+		resp, err = getPckCertsWithManifestFromProvServer(ctx, platformInfo.Manifest,
 			platformInfo.PceID, conf, client)
 	} else {
-		resp, err = getPckCertFromProvServer(platformInfo.Encppid,
+		resp, err = getPckCertFromProvServer(ctx, platformInfo.Encppid,
 			platformInfo.PceID, conf, client)
 	}
 	if resp != nil {
@@ -247,14 +475,10 @@ func fetchPckCertInfo(platformInfo *types.Platform, conf *config.Configuration,
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		dump, _ := httputil.DumpResponse(resp, true)
-		log.WithField("Status Code", resp.StatusCode).Error(string(dump))
-		return nil, nil, "", "", errors.New("get pckcerts api call failed with pcs")
+		pcsErr := newPcsError("pckcerts", resp)
+		log.WithField("Status Code", resp.StatusCode).Error(pcsErr.Error())
+		return nil, nil, "", "", pcsErr
 	}
-	if resp.ContentLength == 0 {
-		return nil, nil, "", "", errors.New("no content found in getPCkCerts Http Response")
-	}
-
 	// read the PCKCertChain from HTTP response header
 	pckCertChain := resp.Header.Get("Sgx-Pck-Certificate-Issuer-Chain")
 
@@ -265,7 +489,7 @@ func fetchPckCertInfo(platformInfo *types.Platform, conf *config.Configuration,
 	ca := resp.Header.Get("Sgx-Pck-Certificate-Ca-Type")
 
 	// read the set  of PCKCerts blob sent as part of HTTP response body
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := readPcsResponseBody(resp, "getPCkCerts")
 	if err != nil {
 		log.WithError(err).Error("could not read getPckCerts http Response body")
 		return nil, nil, "", "", err
@@ -278,6 +502,7 @@ func fetchPckCertInfo(platformInfo *types.Platform, conf *config.Configuration,
 		log.WithError(err).Error("Could not decode the pckCerts json response")
 		return nil, nil, "", "", err
 	}
+	auditPcsResponse("pckcerts", platformInfo.QeID, pckCertChain, body)
 
 	pckCertList := make([]string, len(pckCerts))
 	tcbmList := make([]string, len(pckCerts))
@@ -295,6 +520,11 @@ func fetchPckCertInfo(platformInfo *types.Platform, conf *config.Configuration,
 			certCount++
 		}
 	}
+	if certCount == 0 {
+		log.Errorf("PCS returned no pck cert for any of the %d TCB levels of platform with qeid %s", len(pckCerts), platformInfo.QeID)
+		err = ErrPckCertsNotAvailable
+		return nil, nil, "", "", err
+	}
 
 	// Now we have the bunch of PCK certificates which can be safely passed
 	// to PCK Cert Selection Lib
@@ -310,27 +540,46 @@ func fetchPckCertInfo(platformInfo *types.Platform, conf *config.Configuration,
 	pckCertInfo.Fmspc = fmspc
 	pckCertInfo.QeID = platformInfo.QeID
 	pckCertInfo.PceID = platformInfo.PceID
+	pckCertInfo.CPUSvn = platformInfo.CPUSvn
+	pckCertInfo.PceSvn = platformInfo.PceSvn
 
-	fmspcTcbInfo, err := fetchFmspcTcbInfo(fmspc, conf, client)
+	fmspcTcbInfo, err := fetchFmspcTcbInfo(ctx, fmspc, conf, client)
 	if err != nil {
 		return nil, nil, "", "", err
 	}
 
 	// From bunch of PCK certificates, choose best suited PCK certificate for the
 	// current raw TCB level
-	pckCertInfo.CertIndex, err = getBestPckCert(platformInfo, pckCertInfo.PckCerts, fmspcTcbInfo.TcbInfo)
+	_, selectionSpan := startSpan(ctx, "getBestPckCert")
+	pckCertInfo.CertIndex, err = selectPckCert(platformInfo, pckCertInfo.PckCerts, fmspcTcbInfo.TcbInfo)
+	selectionSpan.end(err)
 	if err != nil {
 		log.WithError(err).Error("failed to get best suited pckcert for the current tcb level")
 		return nil, nil, "", "", err
 	}
+
+	// verify the selected PCK certificate before it is cached, so that a corrupted or spoofed
+	// response of Intel PCS server is not served to the attestation clients
+	err = checkSignature(conf, "pck certificate issuer chain", func() error {
+		return verifyPckCertChain(pckCertInfo.PckCerts[pckCertInfo.CertIndex], pckCertChain)
+	})
+	if err != nil {
+		return nil, nil, "", "", err
+	}
+
+	setPckCertNotAfter(&pckCertInfo)
+	if isPckCertExpiring(&pckCertInfo, conf) {
+		log.Warnf("PCK cert selected for platform with qeid %s fetched from PCS expires at %s", platformInfo.QeID,
+			pckCertInfo.NotAfter.Format(time.RFC3339))
+	}
 	return &pckCertInfo, fmspcTcbInfo, pckCertChain, ca, nil
 }
 
 // Fetches the latest PCK Certificate Revocation List for the sgx intel processor
 // SVS will make use of this to verify if PCK certificate in a quote is valid
 // by comparing against this CRL
-func fetchPckCrlInfo(ca string, conf *config.Configuration, client *domain.HttpClient) (*types.PckCrl, error) {
-	resp, err := getPckCrlFromProvServer(ca, constants.EncodingValue, conf, client)
+func fetchPckCrlInfo(ctx context.Context, ca string, conf *config.Configuration, client *domain.HttpClient) (*types.PckCrl, error) {
+	resp, err := getPckCrlFromProvServer(ctx, ca, constants.EncodingValue, conf, client)
 	if resp != nil {
 		defer func() {
 			derr := resp.Body.Close()
@@ -346,36 +595,128 @@ func fetchPckCrlInfo(ca string, conf *config.Configuration, client *domain.HttpC
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		log.WithField("Status Code", resp.StatusCode).Error(httputil.DumpResponse(resp, true))
-		return nil, errors.New("get revocation list api call failed with pcs")
+		pcsErr := newPcsError("pckcrl", resp)
+		log.WithField("Status Code", resp.StatusCode).Error(pcsErr.Error())
+		return nil, pcsErr
 	}
 
 	var pckCRLInfo types.PckCrl
 	pckCRLInfo.Ca = ca
 	pckCRLInfo.PckCrlCertChain = resp.Header.Get("Sgx-Pck-Crl-Issuer-Chain")
 
-	if resp.ContentLength == 0 {
-		return nil, errors.New("no content found in getPCkCrl Http Response")
-	}
-
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := readPcsResponseBody(resp, "getPCkCrl")
 	if err != nil {
 		log.WithError(err).Error("could not read getPckCrl http response")
 		return nil, err
 	}
 
 	//To validate if the response read from PCS is actually a DER encoded CRL
-	if _, err = x509.ParseDERCRL(body); err != nil {
+	crl, err := x509.ParseDERCRL(body)
+	if err != nil {
 		log.WithError(err).Error("error decoding DER CRL")
 		return nil, err
 	}
+	err = checkSignature(conf, "pck crl signature", func() error {
+		return verifyPckCrlSignature(crl, pckCRLInfo.PckCrlCertChain)
+	})
+	if err != nil {
+		return nil, err
+	}
+	auditPcsResponse("pckcrl", ca, pckCRLInfo.PckCrlCertChain, body)
 	pckCRLInfo.PckCrl = base64.StdEncoding.EncodeToString(body)
+	pckCRLInfo.ThisUpdate = crl.TBSCertList.ThisUpdate.UTC()
+	pckCRLInfo.NextUpdate = crl.TBSCertList.NextUpdate.UTC()
+	if isPckCrlExpired(&pckCRLInfo) {
+		log.Warnf("PCK CRL for ca %s fetched from PCS is past its nextUpdate %s", ca, pckCRLInfo.NextUpdate)
+	}
 	return &pckCRLInfo, nil
 }
 
+// isPckCrlExpired checks if a PCK CRL is past its nextUpdate time. CRLs cached before
+// the validity was stored do not have nextUpdate and are not reported as expired
+func isPckCrlExpired(pckCrl *types.PckCrl) bool {
+	if pckCrl.NextUpdate.IsZero() {
+		return false
+	}
+	return clock.Now().UTC().After(pckCrl.NextUpdate)
+}
+
+// Fetches the latest CRL issued by SGX Root CA, which revokes the intermediate PCK CAs and TCB
+// signing certificates. PCS v3 returns the DER CRL hex encoded while later versions return it as is
+func fetchRootCaCrlInfo(ctx context.Context, conf *config.Configuration, client *domain.HttpClient) (*types.RootCaCrl, error) {
+	resp, err := getRootCaCrlFromProvServer(ctx, conf, client)
+	if resp != nil {
+		defer func() {
+			derr := resp.Body.Close()
+			if derr != nil {
+				log.WithError(derr).Error("Error closing rootcacrl response body")
+			}
+		}()
+	}
+
+	if err != nil {
+		log.WithError(err).Error("Intel PCS Server getRootCaCrl api failed")
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		pcsErr := newPcsError("rootcacrl", resp)
+		log.WithField("Status Code", resp.StatusCode).Error(pcsErr.Error())
+		return nil, pcsErr
+	}
+
+	body, err := readPcsResponseBody(resp, "getRootCaCrl")
+	if err != nil {
+		log.WithError(err).Error("could not read getRootCaCrl http response")
+		return nil, err
+	}
+
+	crl, err := x509.ParseDERCRL(body)
+	if err != nil {
+		der, herr := hex.DecodeString(strings.TrimSpace(string(body)))
+		if herr != nil {
+			log.WithError(err).Error("error decoding DER root ca CRL")
+			return nil, err
+		}
+		body = der
+		crl, err = x509.ParseDERCRL(body)
+		if err != nil {
+			log.WithError(err).Error("error decoding hex encoded DER root ca CRL")
+			return nil, err
+		}
+	}
+
+	// the root ca CRL has no issuer chain, so it is verified against the SGX root CA trusted as anchor
+	err = checkSignature(conf, "root ca crl signature", func() error {
+		return errors.Wrap(sgxRootCA.CheckCRLSignature(crl), "root ca CRL could not be verified against sgx root ca")
+	})
+	if err != nil {
+		return nil, err
+	}
+	auditPcsResponse("rootcacrl", "", "", body)
+
+	rootCaCrlInfo := types.RootCaCrl{
+		RootCaCrl:  base64.StdEncoding.EncodeToString(body),
+		ThisUpdate: crl.TBSCertList.ThisUpdate.UTC(),
+		NextUpdate: crl.TBSCertList.NextUpdate.UTC(),
+	}
+	if isRootCaCrlExpired(&rootCaCrlInfo) {
+		log.Warnf("Root CA CRL fetched from PCS is past its nextUpdate %s", rootCaCrlInfo.NextUpdate)
+	}
+	return &rootCaCrlInfo, nil
+}
+
+// isRootCaCrlExpired checks if the root ca CRL is past its nextUpdate time
+func isRootCaCrlExpired(rootCaCrl *types.RootCaCrl) bool {
+	if rootCaCrl.NextUpdate.IsZero() {
+		return false
+	}
+	return clock.Now().UTC().After(rootCaCrl.NextUpdate)
+}
+
 // for a platform FMSPC value, fetches corresponding TCBInfo structure from Intel PCS server
-func fetchFmspcTcbInfo(fmspc string, conf *config.Configuration, client *domain.HttpClient) (*types.FmspcTcbInfo, error) {
-	resp, err := getFmspcTcbInfoFromProvServer(fmspc, conf, client)
+func fetchFmspcTcbInfo(ctx context.Context, fmspc string, conf *config.Configuration, client *domain.HttpClient) (*types.FmspcTcbInfo, error) {
+	resp, err := getFmspcTcbInfoFromProvServer(ctx, fmspc, conf.GetTcbUpdate(), conf, client)
 	if resp != nil {
 		defer func() {
 			derr := resp.Body.Close()
@@ -391,19 +732,17 @@ func fetchFmspcTcbInfo(fmspc string, conf *config.Configuration, client *domain.
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		log.WithField("Status Code", resp.StatusCode).Error(httputil.DumpResponse(resp, true))
-		return nil, errors.New("get tcb info api call failed with pcs")
+		pcsErr := newPcsError("tcb info", resp)
+		log.WithField("Status Code", resp.StatusCode).Error(pcsErr.Error())
+		return nil, pcsErr
 	}
 
 	var fmspcTcbInfo types.FmspcTcbInfo
 	fmspcTcbInfo.Fmspc = fmspc
 	fmspcTcbInfo.TcbInfoIssuerChain = resp.Header.Get("Sgx-Tcb-Info-Issuer-Chain")
+	fmspcTcbInfo.TcbUpdate = conf.GetTcbUpdate()
 
-	if resp.ContentLength == 0 {
-		return nil, errors.New("no content found in getTCBInfo Http Response")
-	}
-
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := readPcsResponseBody(resp, "getTCBInfo")
 	if err != nil {
 		log.WithError(err).Error("could not read getTCBInfo http response")
 		return nil, err
@@ -416,13 +755,21 @@ func fetchFmspcTcbInfo(fmspc string, conf *config.Configuration, client *domain.
 		return nil, err
 	}
 
+	err = checkSignature(conf, "tcb info signature", func() error {
+		return verifyTcbInfoSignature(body, fmspcTcbInfo.TcbInfoIssuerChain)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	auditPcsResponse("tcb info", fmspc, fmspcTcbInfo.TcbInfoIssuerChain, body)
 	fmspcTcbInfo.TcbInfo = string(body)
 	return &fmspcTcbInfo, nil
 }
 
 // Fetches Quoting Enclave ID details for a platform from intel PCS server
-func fetchQeIdentityInfo(conf *config.Configuration, client *domain.HttpClient) (*types.QEIdentity, error) {
-	resp, err := getQeInfoFromProvServer(conf, client)
+func fetchQeIdentityInfo(ctx context.Context, conf *config.Configuration, client *domain.HttpClient) (*types.QEIdentity, error) {
+	resp, err := getQeInfoFromProvServer(ctx, conf.GetTcbUpdate(), conf, client)
 	if resp != nil {
 		defer func() {
 			derr := resp.Body.Close()
@@ -438,18 +785,16 @@ func fetchQeIdentityInfo(conf *config.Configuration, client *domain.HttpClient)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		log.WithField("Status Code", resp.StatusCode).Error(httputil.DumpResponse(resp, true))
-		return nil, errors.New("get qe identity api call failed with pcs")
+		pcsErr := newPcsError("qe identity", resp)
+		log.WithField("Status Code", resp.StatusCode).Error(pcsErr.Error())
+		return nil, pcsErr
 	}
 
 	var qeInfo types.QEIdentity
 	qeInfo.QeIssuerChain = resp.Header.Get("Sgx-Enclave-Identity-Issuer-Chain")
+	qeInfo.TcbUpdate = conf.GetTcbUpdate()
 
-	if resp.ContentLength == 0 {
-		return nil, errors.New("no content found in getQeIdentity Http Response")
-	}
-
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := readPcsResponseBody(resp, "getQeIdentity")
 	if err != nil {
 		log.WithError(err).Error("could not read getQeIdentity http response")
 		return nil, err
@@ -462,13 +807,35 @@ func fetchQeIdentityInfo(conf *config.Configuration, client *domain.HttpClient)
 		return nil, err
 	}
 
+	err = checkSignature(conf, "qe identity signature", func() error {
+		return verifyQeIdentitySignature(body, qeInfo.QeIssuerChain)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	auditPcsResponse("qe identity", "", qeInfo.QeIssuerChain, body)
 	qeInfo.QeInfo = string(body)
 	return &qeInfo, nil
 }
 
-func cachePckCertInfo(db repository.SCSDatabase, pckCert *types.PckCert, cacheType constants.CacheType) (*types.PckCert, error) {
+// VerifyProvServerConnection checks that Intel PCS server is reachable by fetching the QE identity
+func VerifyProvServerConnection(conf *config.Configuration, client *domain.HttpClient) error {
+	_, err := fetchQeIdentityInfo(context.Background(), conf, client)
+	return err
+}
+
+// VerifyFmspcTcbInfo checks that the TCB info for an fmspc can be fetched from Intel PCS server
+func VerifyFmspcTcbInfo(fmspc string, conf *config.Configuration, client *domain.HttpClient) error {
+	_, err := fetchFmspcTcbInfo(context.Background(), fmspc, conf, client)
+	return err
+}
+
+func cachePckCertInfo(ctx context.Context, db repository.SCSDatabase, pckCert *types.PckCert, cacheType constants.CacheType) (*types.PckCert, error) {
+	_, span := startSpan(ctx, "cachePckCertInfo")
 	var err error
-	pckCert.UpdatedTime = time.Now().UTC()
+	defer func() { span.end(err) }()
+	pckCert.UpdatedTime = clock.Now().UTC()
 	if cacheType == constants.CacheRefresh {
 		err = db.PckCertRepository().Update(pckCert)
 		if err != nil {
@@ -476,7 +843,7 @@ func cachePckCertInfo(db repository.SCSDatabase, pckCert *types.PckCert, cacheTy
 			return nil, err
 		}
 	} else {
-		pckCert.CreatedTime = time.Now().UTC()
+		pckCert.CreatedTime = clock.Now().UTC()
 		pckCert, err = db.PckCertRepository().Create(pckCert)
 		if err != nil {
 			log.WithError(err).Error("PckCerts record could not be created in db")
@@ -486,9 +853,27 @@ func cachePckCertInfo(db repository.SCSDatabase, pckCert *types.PckCert, cacheTy
 	return pckCert, nil
 }
 
-func cacheQeIdentityInfo(db repository.SCSDatabase, qeIdentity *types.QEIdentity, cacheType constants.CacheType) (*types.QEIdentity, error) {
+// pckCertCacheType returns the cache operation to be used for storing the pck cert selected for
+// a raw TCB level of a platform. The platform can already have pck certs cached for other TCB levels,
+// so the record is refreshed only if one exists for this TCB level.
+func pckCertCacheType(db repository.SCSDatabase, pckCert *types.PckCert) constants.CacheType {
+	existingPckCert, _ := db.PckCertRepository().RetrieveByTcbLevel(&types.PckCert{
+		QeID:   pckCert.QeID,
+		PceID:  pckCert.PceID,
+		CPUSvn: pckCert.CPUSvn,
+		PceSvn: pckCert.PceSvn,
+	})
+	if existingPckCert != nil {
+		return constants.CacheRefresh
+	}
+	return constants.CacheInsert
+}
+
+func cacheQeIdentityInfo(ctx context.Context, db repository.SCSDatabase, qeIdentity *types.QEIdentity, cacheType constants.CacheType) (*types.QEIdentity, error) {
+	_, span := startSpan(ctx, "cacheQeIdentityInfo")
 	var err error
-	qeIdentity.UpdatedTime = time.Now().UTC()
+	defer func() { span.end(err) }()
+	qeIdentity.UpdatedTime = clock.Now().UTC()
 	if cacheType == constants.CacheRefresh {
 		err = db.QEIdentityRepository().Update(qeIdentity)
 		if err != nil {
@@ -497,8 +882,12 @@ func cacheQeIdentityInfo(db repository.SCSDatabase, qeIdentity *types.QEIdentity
 		}
 	} else {
 		qeIdentity.ID = "QE"
-		qeIdentity.CreatedTime = time.Now().UTC()
-		qeIdentity, err = db.QEIdentityRepository().Create(qeIdentity)
+		qeIdentity.CreatedTime = clock.Now().UTC()
+		_, err = db.QEIdentityRepository().Create(qeIdentity)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			// cached by a concurrent push since it was found missing
+			err = db.QEIdentityRepository().Update(qeIdentity)
+		}
 		if err != nil {
 			log.WithError(err).Error("QE Identity record could not created in db")
 			return nil, err
@@ -507,14 +896,59 @@ func cacheQeIdentityInfo(db repository.SCSDatabase, qeIdentity *types.QEIdentity
 	return qeIdentity, nil
 }
 
-func cachePckCertChainInfo(db repository.SCSDatabase, pckCertChain, ca string, cacheType constants.CacheType) (*types.PckCertChain, error) {
+// PrepareDatabase migrates the database and checks that it is reachable before the service starts
+// serving requests, so that no request is served against a partially migrated schema. Duplicate
+// qe identities are then removed, failure of which is not fatal
+func PrepareDatabase(db repository.SCSDatabase) error {
+	err := db.Migrate()
+	if err != nil {
+		return errors.Wrap(err, "failed to migrate database")
+	}
+	err = db.Ping()
+	if err != nil {
+		return errors.Wrap(err, "database health check failed")
+	}
+
+	err = DedupQEIdentities(db)
+	if err != nil {
+		log.WithError(err).Warn("Failed to remove duplicate qe identity records")
+	}
+	return nil
+}
+
+// DedupQEIdentities deletes all but the most recently updated qe identity when the cache has
+// duplicate qe identity rows, so that the qe identity served and refreshed is always the same
+func DedupQEIdentities(db repository.SCSDatabase) error {
+	return db.ExecuteInTransaction(func(tx repository.SCSDatabase) error {
+		qeIdentities, err := tx.QEIdentityRepository().RetrieveAll()
+		if err != nil {
+			return err
+		}
+		if len(qeIdentities) <= 1 {
+			return nil
+		}
+		log.Warnf("Found %d qe identity records, keeping the one with id %s updated at %s", len(qeIdentities),
+			qeIdentities[0].ID, qeIdentities[0].UpdatedTime)
+		for i := 1; i < len(qeIdentities); i++ {
+			duplicate := &qeIdentities[i]
+			if err := tx.QEIdentityRepository().Delete(duplicate); err != nil {
+				return errors.Wrapf(err, "could not delete duplicate qe identity with id %s", duplicate.ID)
+			}
+		}
+		return nil
+	})
+}
+
+func cachePckCertChainInfo(ctx context.Context, db repository.SCSDatabase, pckCertChain, ca string, cacheType constants.CacheType) (*types.PckCertChain, error) {
 	certChain := &types.PckCertChain{
 		Ca:           ca,
 		PckCertChain: pckCertChain,
 	}
 
+	_, span := startSpan(ctx, "cachePckCertChainInfo")
 	var err error
-	certChain.UpdatedTime = time.Now().UTC()
+	defer func() { span.end(err) }()
+	certChain.UpdatedTime = clock.Now().UTC()
 	if cacheType == constants.CacheRefresh {
 		err = db.PckCertChainRepository().Update(certChain)
 		if err != nil {
@@ -522,8 +956,12 @@ func cachePckCertChainInfo(db repository.SCSDatabase, pckCertChain, ca string, c
 			return nil, err
 		}
 	} else {
-		certChain.CreatedTime = time.Now().UTC()
-		certChain, err = db.PckCertChainRepository().Create(certChain)
+		certChain.CreatedTime = clock.Now().UTC()
+		_, err = db.PckCertChainRepository().Create(certChain)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			// cached by a concurrent push since it was found missing
+			err = db.PckCertChainRepository().Update(certChain)
+		}
 		if err != nil {
 			log.WithError(err).Error("PckCertChain record could not be created in db")
 			return nil, err
@@ -532,9 +970,11 @@ func cachePckCertChainInfo(db repository.SCSDatabase, pckCertChain, ca string, c
 	return certChain, nil
 }
 
-func cacheFmspcTcbInfo(db repository.SCSDatabase, fmspcTcb *types.FmspcTcbInfo, cacheType constants.CacheType) (*types.FmspcTcbInfo, error) {
+func cacheFmspcTcbInfo(ctx context.Context, db repository.SCSDatabase, fmspcTcb *types.FmspcTcbInfo, cacheType constants.CacheType) (*types.FmspcTcbInfo, error) {
+	_, span := startSpan(ctx, "cacheFmspcTcbInfo")
 	var err error
-	fmspcTcb.UpdatedTime = time.Now().UTC()
+	defer func() { span.end(err) }()
+	fmspcTcb.UpdatedTime = clock.Now().UTC()
 	if cacheType == constants.CacheRefresh {
 		err = db.FmspcTcbInfoRepository().Update(fmspcTcb)
 		if err != nil {
@@ -542,8 +982,12 @@ func cacheFmspcTcbInfo(db repository.SCSDatabase, fmspcTcb *types.FmspcTcbInfo,
 			return nil, err
 		}
 	} else {
-		fmspcTcb.CreatedTime = time.Now().UTC()
-		fmspcTcb, err = db.FmspcTcbInfoRepository().Create(fmspcTcb)
+		fmspcTcb.CreatedTime = clock.Now().UTC()
+		_, err = db.FmspcTcbInfoRepository().Create(fmspcTcb)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			// cached by a concurrent push since it was found missing
+			err = db.FmspcTcbInfoRepository().Update(fmspcTcb)
+		}
 		if err != nil {
 			log.WithError(err).Error("FmspcTcb record could not be created in db")
 			return nil, err
@@ -552,9 +996,74 @@ func cacheFmspcTcbInfo(db repository.SCSDatabase, fmspcTcb *types.FmspcTcbInfo,
 	return fmspcTcb, nil
 }
 
-func cachePlatformInfo(db repository.SCSDatabase, platform *types.Platform, cacheType constants.CacheType) error {
+// normalizeTcbLevels parses the TCB levels of TcbInfo into tcb_levels records, numbered in the
+// order raw TCBs of platforms are compared against them
+func normalizeTcbLevels(fmspcTcb *types.FmspcTcbInfo) (types.TcbLevels, error) {
+	var tcbInfo TcbInfoJSON
+	if err := json.Unmarshal([]byte(fmspcTcb.TcbInfo), &tcbInfo); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal tcbinfo")
+	}
+	sortTcbLevels(tcbInfo.TcbInfo.TcbLevels)
+
+	now := clock.Now().UTC()
+	levels := make(types.TcbLevels, len(tcbInfo.TcbInfo.TcbLevels))
+	for i, tcbLevel := range tcbInfo.TcbInfo.TcbLevels {
+		tcbDate, err := time.Parse(time.RFC3339, tcbLevel.TcbDate)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not parse tcbDate of tcb level %d", i+1)
+		}
+		tcb := tcbLevel.Tcb
+		levels[i] = types.TcbLevel{
+			Fmspc:           fmspcTcb.Fmspc,
+			Level:           i + 1,
+			SgxTcbComp01Svn: tcb.SgxTcbComp01Svn,
+			SgxTcbComp02Svn: tcb.SgxTcbComp02Svn,
+			SgxTcbComp03Svn: tcb.SgxTcbComp03Svn,
+			SgxTcbComp04Svn: tcb.SgxTcbComp04Svn,
+			SgxTcbComp05Svn: tcb.SgxTcbComp05Svn,
+			SgxTcbComp06Svn: tcb.SgxTcbComp06Svn,
+			SgxTcbComp07Svn: tcb.SgxTcbComp07Svn,
+			SgxTcbComp08Svn: tcb.SgxTcbComp08Svn,
+			SgxTcbComp09Svn: tcb.SgxTcbComp09Svn,
+			SgxTcbComp10Svn: tcb.SgxTcbComp10Svn,
+			SgxTcbComp11Svn: tcb.SgxTcbComp11Svn,
+			SgxTcbComp12Svn: tcb.SgxTcbComp12Svn,
+			SgxTcbComp13Svn: tcb.SgxTcbComp13Svn,
+			SgxTcbComp14Svn: tcb.SgxTcbComp14Svn,
+			SgxTcbComp15Svn: tcb.SgxTcbComp15Svn,
+			SgxTcbComp16Svn: tcb.SgxTcbComp16Svn,
+			PceSvn:          tcb.PceSvn,
+			TcbStatus:       tcbLevel.TcbStatus,
+			TcbDate:         tcbDate.UTC(),
+			CreatedTime:     now,
+		}
+	}
+	return levels, nil
+}
+
+// cacheTcbLevels stores the TCB levels of a cached TcbInfo in place of the levels of its previous
+// TcbInfo when normalization of TcbInfo is enabled. The raw TcbInfo is the one served, so a failure
+// to store its levels is logged without failing the caching of TcbInfo
+func cacheTcbLevels(db repository.SCSDatabase, fmspcTcb *types.FmspcTcbInfo, conf *config.Configuration) {
+	if conf == nil || !conf.NormalizeTcbInfo {
+		return
+	}
+	levels, err := normalizeTcbLevels(fmspcTcb)
+	if err == nil {
+		err = db.ExecuteInTransaction(func(tx repository.SCSDatabase) error {
+			return tx.TcbLevelRepository().Replace(fmspcTcb.Fmspc, levels)
+		})
+	}
+	if err != nil {
+		log.WithError(err).Warnf("TCB levels of TcbInfo of fmspc %s could not be stored", fmspcTcb.Fmspc)
+	}
+}
+
+func cachePlatformInfo(ctx context.Context, db repository.SCSDatabase, platform *types.Platform, cacheType constants.CacheType) error {
+	_, span := startSpan(ctx, "cachePlatformInfo")
 	var err error
-	platform.UpdatedTime = time.Now().UTC()
+	defer func() { span.end(err) }()
+	platform.UpdatedTime = clock.Now().UTC()
 	if cacheType == constants.CacheRefresh {
 		err = db.PlatformRepository().Update(platform)
 		if err != nil {
@@ -562,7 +1071,7 @@ func cachePlatformInfo(db repository.SCSDatabase, platform *types.Platform, cach
 			return err
 		}
 	} else {
-		platform.CreatedTime = time.Now().UTC()
+		platform.CreatedTime = clock.Now().UTC()
 		platform, err = db.PlatformRepository().Create(platform)
 		if err != nil {
 			log.WithError(err).Error("Platform values record could not be created in db")
@@ -572,7 +1081,7 @@ func cachePlatformInfo(db repository.SCSDatabase, platform *types.Platform, cach
 	return nil
 }
 
-func cachePlatformTcbInfo(db repository.SCSDatabase, platformInfo *types.Platform, tcbm string, cacheType constants.CacheType) error {
+func cachePlatformTcbInfo(ctx context.Context, db repository.SCSDatabase, platformInfo *types.Platform, tcbm string, cacheType constants.CacheType) error {
 	platformTcb := &types.PlatformTcb{
 		Tcbm:   tcbm,
 		CPUSvn: platformInfo.CPUSvn,
@@ -580,8 +1089,10 @@ func cachePlatformTcbInfo(db repository.SCSDatabase, platformInfo *types.Platfor
 		PceID:  platformInfo.PceID,
 		QeID:   platformInfo.QeID}
 
+	_, span := startSpan(ctx, "cachePlatformTcbInfo")
 	var err error
-	platformTcb.UpdatedTime = time.Now().UTC()
+	defer func() { span.end(err) }()
+	platformTcb.UpdatedTime = clock.Now().UTC()
 	if cacheType == constants.CacheRefresh {
 		err = db.PlatformTcbRepository().Update(platformTcb)
 		if err != nil {
@@ -589,7 +1100,7 @@ func cachePlatformTcbInfo(db repository.SCSDatabase, platformInfo *types.Platfor
 			return err
 		}
 	} else {
-		platformTcb.CreatedTime = time.Now().UTC()
+		platformTcb.CreatedTime = clock.Now().UTC()
 		platformTcb, err = db.PlatformTcbRepository().Create(platformTcb)
 		if err != nil {
 			log.WithError(err).Error("PlatformTcb values record could not be created in db")
@@ -599,9 +1110,42 @@ func cachePlatformTcbInfo(db repository.SCSDatabase, platformInfo *types.Platfor
 	return nil
 }
 
-func cachePckCrlInfo(db repository.SCSDatabase, pckCrl *types.PckCrl, cacheType constants.CacheType) (*types.PckCrl, error) {
+// upsertPlatformInfo creates a pushed platform along with its raw TCB level, or updates them when
+// the platform is already cached. It returns whether the platform was created
+func upsertPlatformInfo(ctx context.Context, db repository.SCSDatabase, platform *types.Platform, tcbm string) (bool, error) {
+	_, span := startSpan(ctx, "upsertPlatformInfo")
+	var err error
+	defer func() { span.end(err) }()
+
+	platform.CreatedTime = clock.Now().UTC()
+	platform.UpdatedTime = platform.CreatedTime
+	created, err := db.PlatformRepository().Upsert(platform)
+	if err != nil {
+		log.WithError(err).Error("Platform values record could not be cached in db")
+		return false, err
+	}
+
+	platformTcb := &types.PlatformTcb{
+		Tcbm:        tcbm,
+		CPUSvn:      platform.CPUSvn,
+		PceSvn:      platform.PceSvn,
+		PceID:       platform.PceID,
+		QeID:        platform.QeID,
+		CreatedTime: platform.CreatedTime,
+		UpdatedTime: platform.UpdatedTime}
+	_, err = db.PlatformTcbRepository().Upsert(platformTcb)
+	if err != nil {
+		log.WithError(err).Error("PlatformTcb values record could not be cached in db")
+		return false, err
+	}
+	return created, nil
+}
+
+func cachePckCrlInfo(ctx context.Context, db repository.SCSDatabase, pckCrl *types.PckCrl, cacheType constants.CacheType) (*types.PckCrl, error) {
+	_, span := startSpan(ctx, "cachePckCrlInfo")
 	var err error
-	pckCrl.UpdatedTime = time.Now().UTC()
+	defer func() { span.end(err) }()
+	pckCrl.UpdatedTime = clock.Now().UTC()
 	if cacheType == constants.CacheRefresh {
 		err = db.PckCrlRepository().Update(pckCrl)
 		if err != nil {
@@ -609,8 +1153,12 @@ func cachePckCrlInfo(db repository.SCSDatabase, pckCrl *types.PckCrl, cacheType
 			return nil, err
 		}
 	} else {
-		pckCrl.CreatedTime = time.Now().UTC()
-		pckCrl, err = db.PckCrlRepository().Create(pckCrl)
+		pckCrl.CreatedTime = clock.Now().UTC()
+		_, err = db.PckCrlRepository().Create(pckCrl)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			// cached by a concurrent push since it was found missing
+			err = db.PckCrlRepository().Update(pckCrl)
+		}
 		if err != nil {
 			log.WithError(err).Error("PckCrl record could not be created in db")
 			return nil, err
@@ -618,7 +1166,30 @@ func cachePckCrlInfo(db repository.SCSDatabase, pckCrl *types.PckCrl, cacheType
 	}
 	return pckCrl, nil
 }
-func checkPlatformDataCacheStatus(db repository.SCSDatabase, platformInfo *PlatformInfo, tokenSubject string) (bool, error) {
+func cacheRootCaCrlInfo(ctx context.Context, db repository.SCSDatabase, rootCaCrl *types.RootCaCrl, cacheType constants.CacheType) (*types.RootCaCrl, error) {
+	_, span := startSpan(ctx, "cacheRootCaCrlInfo")
+	var err error
+	defer func() { span.end(err) }()
+	rootCaCrl.ID = constants.RootCaCrlID
+	rootCaCrl.UpdatedTime = clock.Now().UTC()
+	if cacheType == constants.CacheRefresh {
+		err = db.RootCaCrlRepository().Update(rootCaCrl)
+		if err != nil {
+			log.WithError(err).Error("RootCaCrl record could not be updated in db")
+			return nil, err
+		}
+	} else {
+		rootCaCrl.CreatedTime = clock.Now().UTC()
+		rootCaCrl, err = db.RootCaCrlRepository().Create(rootCaCrl)
+		if err != nil {
+			log.WithError(err).Error("RootCaCrl record could not be created in db")
+			return nil, err
+		}
+	}
+	return rootCaCrl, nil
+}
+
+func checkPlatformDataCacheStatus(db repository.SCSDatabase, platformInfo *PlatformInfo, tokenSubject string, updateDuplicate bool) (bool, error) {
 	log.Trace("resource/platform_ops:checkPlatformDataCacheStatus() Entering")
 	defer log.Trace("resource/platform_ops:checkPlatformDataCacheStatus() Leaving")
 
@@ -627,20 +1198,32 @@ func checkPlatformDataCacheStatus(db repository.SCSDatabase, platformInfo *Platf
 		PceID: platformInfo.PceID,
 	}
 	existingPlatformData, err := db.PlatformRepository().Retrieve(platform)
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
 		log.WithError(err).Error("resource/platform_ops:checkPlatformDataCacheStatus() Error while retrieving platform data from DB")
 		return false, &resourceError{Message: err.Error(),
 			StatusCode: http.StatusInternalServerError}
 	}
 	if existingPlatformData != nil {
+		// raw TCB level of the platform has changed, pck cert needs to be selected again
+		if existingPlatformData.CPUSvn != platformInfo.CPUSvn || existingPlatformData.PceSvn != platformInfo.PceSvn {
+			return false, nil
+		}
+		// a platform re-enrolled after a TCB reset pushes a new enc_ppid for the same qeid, which is
+		// cached again along with its pck certs only if duplicate pushes are configured to update
+		if updateDuplicate && platformInfo.EncPpid != existingPlatformData.Encppid {
+			log.Infof("resource/platform_ops:checkPlatformDataCacheStatus() Updating platform with qeid %s as enc_ppid has changed", platformInfo.QeID)
+			return false, nil
+		}
 		if platformInfo.Manifest == "" {
 			platformInfo.Manifest = existingPlatformData.Manifest
 			cert := &types.PckCert{
-				QeID:  platformInfo.QeID,
-				PceID: platformInfo.PceID,
+				QeID:   platformInfo.QeID,
+				PceID:  platformInfo.PceID,
+				CPUSvn: platformInfo.CPUSvn,
+				PceSvn: platformInfo.PceSvn,
 			}
-			existingPckCert, err := db.PckCertRepository().Retrieve(cert)
-			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			existingPckCert, err := db.PckCertRepository().RetrieveByTcbLevel(cert)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
 				log.WithError(err).Error("resource/platform_ops:checkPlatformDataCacheStatus() Error while retrieving pck cert from DB")
 				return false, &resourceError{Message: err.Error(),
 					StatusCode: http.StatusInternalServerError}
@@ -655,6 +1238,30 @@ func checkPlatformDataCacheStatus(db repository.SCSDatabase, platformInfo *Platf
 	return false, nil
 }
 
+// getUnknownPlatformInfoFields returns the fields of a platform info request body
+// which are not part of PlatformInfo
+func getUnknownPlatformInfoFields(body []byte) []string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil
+	}
+
+	knownFields := make(map[string]bool)
+	platformInfoType := reflect.TypeOf(PlatformInfo{})
+	for i := 0; i < platformInfoType.NumField(); i++ {
+		knownFields[strings.Split(platformInfoType.Field(i).Tag.Get("json"), ",")[0]] = true
+	}
+
+	var unknownFields []string
+	for field := range fields {
+		if !knownFields[field] {
+			unknownFields = append(unknownFields, field)
+		}
+	}
+	sort.Strings(unknownFields)
+	return unknownFields
+}
+
 func pushPlatformInfo(db repository.SCSDatabase, config *config.Configuration, client *domain.HttpClient) errorHandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		err := authorizeEndpoint(r, constants.HostDataUpdaterGroupName, true)
@@ -669,37 +1276,86 @@ func pushPlatformInfo(db repository.SCSDatabase, config *config.Configuration, c
 				StatusCode: http.StatusBadRequest}
 		}
 
-		dec := json.NewDecoder(r.Body)
-		dec.DisallowUnknownFields()
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			slog.WithError(err).Errorf("resource/platform_ops: pushPlatformInfo() %s :  Failed to read request body", commLogMsg.InvalidInputBadEncoding)
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusBadRequest}
+		}
+
+		// structure of the payload is validated before it is decoded, so that every malformed field is
+		// reported rather than the first one the decoder or a validation fails on
+		if err := platformInfoSchema.validate(body); err != nil {
+			slog.Errorf("resource/platform_ops: pushPlatformInfo() Platform info does not match schema %s: %s", platformInfoSchemaVersion, err.Error())
+			return &resourceError{Message: "invalid platform info: " + err.Error(), StatusCode: http.StatusBadRequest}
+		}
+
+		// newer SGX Agents can push fields which are not known to this version of SCS,
+		// these are logged and ignored only if configured to do so
+		allowUnknownFields := config != nil && config.AllowUnknownPlatformInfoFields
+		dec := json.NewDecoder(bytes.NewReader(body))
+		if !allowUnknownFields {
+			dec.DisallowUnknownFields()
+		}
 		err = dec.Decode(&platformInfo)
 		if err != nil {
 			slog.WithError(err).Errorf("resource/platform_ops: pushPlatformInfo() %s :  Failed to decode request body", commLogMsg.InvalidInputBadEncoding)
 			return &resourceError{Message: err.Error(), StatusCode: http.StatusBadRequest}
 		}
-		if !validateInputString(constants.EncPPIDKey, platformInfo.EncPpid) ||
-			!validateInputString(constants.CPUSvnKey, platformInfo.CPUSvn) ||
-			!validateInputString(constants.PceSvnKey, platformInfo.PceSvn) ||
-			!validateInputString(constants.PceIDKey, platformInfo.PceID) ||
-			!validateInputString(constants.QeIDKey, platformInfo.QeID) ||
-			!validateInputString(constants.HwUUIDKey, platformInfo.HwUUID) {
-			slog.Error("resource/platform_ops: pushPlatformInfo() Input validation failed")
-			return &resourceError{Message: "invalid query param data",
+		if allowUnknownFields {
+			if unknownFields := getUnknownPlatformInfoFields(body); len(unknownFields) > 0 {
+				log.Warnf("resource/platform_ops: pushPlatformInfo() Ignoring unknown fields in platform info: %s", strings.Join(unknownFields, ", "))
+			}
+		}
+		// pck certs are fetched using the manifest when both enc_ppid and manifest are pushed,
+		// unless such pushes are configured to be rejected
+		if platformInfo.Manifest != "" && config != nil && config.EncPpidWithManifestPolicy == constants.EncPpidWithManifestReject {
+			slog.Error("resource/platform_ops: pushPlatformInfo() Both enc_ppid and manifest provided")
+			return &resourceError{Message: "only one of enc_ppid and manifest should be provided",
 				StatusCode: http.StatusBadRequest}
 		}
 
-		tokenSubject, err := context.GetTokenSubject(r)
+		tokenSubject, err := commContext.GetTokenSubject(r)
 		if err != nil || tokenSubject != platformInfo.HwUUID {
 			slog.Errorf("resource/platform_ops: pushPlatformInfo() %s : Failed to match host identity from token", commLogMsg.AuthenticationFailed)
 			return &resourceError{Message: "Invalid Token",
 				StatusCode: http.StatusUnauthorized}
 		}
 
-		isCached, err := checkPlatformDataCacheStatus(db, &platformInfo, tokenSubject)
+		// fmspc of the platform is matched against the scopes once it is known from the cache or PCS
+		scopes, err := getPlatformScopes(r, constants.HostDataUpdaterGroupName)
+		if err != nil {
+			return err
+		}
+		if err := authorizePlatformScope(r, scopes, "", platformInfo.PceID); err != nil {
+			return err
+		}
+
+		updateDuplicate := config != nil && config.DuplicatePlatformPushPolicy == constants.DuplicatePlatformPushUpdate
+		isCached, err := checkPlatformDataCacheStatus(db, &platformInfo, tokenSubject, updateDuplicate)
 		if err != nil {
 			return err
 		}
 
 		if isCached {
+			if scopes != nil {
+				existingPlatformData, err := db.PlatformRepository().Retrieve(&types.Platform{QeID: platformInfo.QeID, PceID: platformInfo.PceID})
+				if err != nil {
+					return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+				}
+				if err := authorizePlatformScope(r, scopes, existingPlatformData.Fmspc, platformInfo.PceID); err != nil {
+					return err
+				}
+			}
+
+			// platforms not pushed for long are purged, so record that the platform is still active
+			if config != nil && config.PlatformMaxAgeDays > 0 {
+				err = db.PlatformRepository().Update(&types.Platform{QeID: platformInfo.QeID, PceID: platformInfo.PceID,
+					UpdatedTime: clock.Now().UTC()})
+				if err != nil {
+					log.WithError(err).Warn("resource/platform_ops: pushPlatformInfo() Could not update last pushed time of platform")
+				}
+			}
+
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusOK)
 			res := Response{Status: "Success", Message: "platform info already cached"}
@@ -724,9 +1380,28 @@ func pushPlatformInfo(db repository.SCSDatabase, config *config.Configuration, c
 			Manifest: platformInfo.Manifest,
 		}
 
-		pckCertInfo, fmspcTcbInfo, pckCertChain, ca, err := fetchPckCertInfo(platform, config, client)
+		// PCS calls are made with the context of the request, so that they are abandoned once the
+		// client has given up on the push instead of running on after it retries
+		pckCertInfo, fmspcTcbInfo, pckCertChain, ca, err := fetchPckCertInfo(r.Context(), platform, config, client)
+		if errors.Is(err, ErrPckCertsNotAvailable) {
+			// pck certs of a platform are not available until it is registered with PCS, so the push
+			// may succeed later
+			if config != nil && config.PckCertsNotAvailablePolicy == constants.PckCertsNotAvailableRetry {
+				w.Header().Set("Retry-After", strconv.Itoa(constants.PckCertsNotAvailableRetryAfter))
+				return &resourceError{Message: err.Error(), StatusCode: http.StatusServiceUnavailable}
+			}
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusNotFound}
+		}
+		if errors.Is(err, ErrRawTcbBelowPckCerts) {
+			// no pck cert can be selected until the platform is at a TCB level PCS has a pck cert for
+			return &resourceError{Message: err.Error() + ", update the TCB of the platform and push it again",
+				StatusCode: http.StatusUnprocessableEntity}
+		}
 		if err != nil {
-			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+			return &resourceError{Message: err.Error(), StatusCode: pcsErrorStatusCode(err, http.StatusInternalServerError)}
+		}
+		if err := authorizePlatformScope(r, scopes, fmspcTcbInfo.Fmspc, platform.PceID); err != nil {
+			return err
 		}
 
 		ppid, err := getPPID(pckCertInfo.PckCerts[0])
@@ -737,53 +1412,71 @@ func pushPlatformInfo(db repository.SCSDatabase, config *config.Configuration, c
 		platform.Fmspc = fmspcTcbInfo.Fmspc
 		platform.Ca = ca
 		platform.Ppid = ppid
-		err = cachePlatformInfo(db, platform, constants.CacheInsert)
+		// platform is already cached if only its raw TCB level has changed or if it has been pushed
+		// concurrently, so it is created or updated without checking for it first
+		created, err := upsertPlatformInfo(r.Context(), db, platform, pckCertInfo.Tcbms[pckCertInfo.CertIndex])
 		if err != nil {
 			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
 		}
-
-		err = cachePlatformTcbInfo(db, platform, pckCertInfo.Tcbms[pckCertInfo.CertIndex], constants.CacheInsert)
-		if err != nil {
-			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		if !created {
+			log.Debugf("resource/platform_ops: pushPlatformInfo() Updated cached platform with qeid %s", platform.QeID)
 		}
 
+		// with the pre-caching model only the platform and its pck certs are cached on push, its
+		// TcbInfo, PCK CRL and QE identity are cached by the next refresh
+		lazyCaching := config.GetCacheModel() == constants.LazyCachingModel
+
 		tcbInfo := &types.FmspcTcbInfo{Fmspc: platform.Fmspc}
 		existingFmspc, err := db.FmspcTcbInfoRepository().Retrieve(tcbInfo)
-		if existingFmspc == nil {
-			_, err = getLazyCacheFmspcTcbInfo(db, platform.Fmspc, constants.CacheInsert, config, client)
+		if lazyCaching && existingFmspc == nil {
+			_, err = cacheMissingFmspcTcbInfo(r.Context(), db, platform.Fmspc, config, client)
 			if err != nil {
-				return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+				return &resourceError{Message: err.Error(), StatusCode: pcsErrorStatusCode(err, http.StatusInternalServerError)}
 			}
 		}
 
 		certChain := &types.PckCertChain{Ca: ca}
 		existingPckCertChain, err := db.PckCertChainRepository().Retrieve(certChain)
 		if existingPckCertChain == nil {
-			_, err = cachePckCertChainInfo(db, pckCertChain, ca, constants.CacheInsert)
+			_, err = cachePckCertChainInfo(r.Context(), db, pckCertChain, ca, constants.CacheInsert)
 			if err != nil {
 				return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
 			}
 		}
 
-		_, err = cachePckCertInfo(db, pckCertInfo, constants.CacheInsert)
+		pckCertInfo.CreatedTime = clock.Now().UTC()
+		pckCertInfo.UpdatedTime = pckCertInfo.CreatedTime
+		_, span := startSpan(r.Context(), "PckCertRepository.Upsert")
+		_, err = db.PckCertRepository().Upsert(pckCertInfo)
+		span.end(err)
 		if err != nil {
+			log.WithError(err).Error("PckCerts record could not be cached in db")
 			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
 		}
 
 		pckCrl := &types.PckCrl{Ca: ca}
 		existingPckCrl, err := db.PckCrlRepository().Retrieve(pckCrl)
-		if existingPckCrl == nil {
-			_, err = getLazyCachePckCrl(db, ca, constants.CacheInsert, config, client)
+		if lazyCaching && existingPckCrl == nil {
+			_, err = getLazyCachePckCrl(r.Context(), db, ca, constants.CacheInsert, config, client)
 			if err != nil {
-				return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+				return &resourceError{Message: err.Error(), StatusCode: pcsErrorStatusCode(err, http.StatusInternalServerError)}
 			}
 		}
 
 		qeIdentity, err := db.QEIdentityRepository().Retrieve()
-		if qeIdentity == nil {
-			_, err = getLazyCacheQEIdentityInfo(db, constants.CacheInsert, config, client)
+		if lazyCaching && qeIdentity == nil {
+			_, err = getLazyCacheQEIdentityInfo(r.Context(), db, constants.CacheInsert, config, client)
 			if err != nil {
-				return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+				return &resourceError{Message: err.Error(), StatusCode: pcsErrorStatusCode(err, http.StatusInternalServerError)}
+			}
+		}
+
+		// the platform is pushed with all its collaterals cached, so tcb status is not expected to
+		// fail here. It is evaluated again on read if it does
+		if lazyCaching && config.CacheTcbStatus {
+			_, err = cachePlatformTcbStatus(db, platform, config)
+			if err != nil {
+				log.WithError(err).Warnf("Could not evaluate tcb status of pushed platform with qeid %s", platform.QeID)
 			}
 		}
 
@@ -791,7 +1484,11 @@ func pushPlatformInfo(db repository.SCSDatabase, config *config.Configuration, c
 		w.WriteHeader(http.StatusCreated)
 		w.Header().Add("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
 
-		res := Response{Status: "Created", Message: "platform data pushed to scs"}
+		message := "platform data pushed to scs"
+		if !lazyCaching {
+			message = "platform data pushed to scs, its collaterals are cached by the next refresh"
+		}
+		res := Response{Status: "Created", Message: message}
 		js, err := json.Marshal(res)
 		if err != nil {
 			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
@@ -805,14 +1502,178 @@ func pushPlatformInfo(db repository.SCSDatabase, config *config.Configuration, c
 	}
 }
 
-func refreshPckCerts(db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) error {
-
-	existingPlatformData, _ := db.PlatformRepository().RetrieveAll()
-	if len(existingPlatformData) == 0 {
-		return errors.New("No platform value records are found in db, cannot perform refresh.")
-	}
-
-	// Envelope to pass data to go routines.
+// forEachPlatformPage retrieves the cached platforms a page at a time, so that a large fleet
+// of platforms is not loaded from the platforms table at once
+func forEachPlatformPage(db repository.SCSDatabase, pageSize int, fn func(types.Platforms)) error {
+	page := types.Pagination{Limit: pageSize}
+	for {
+		platforms, err := db.PlatformRepository().RetrievePaginated(page)
+		if err != nil {
+			return errors.Wrap(err, "could not retrieve a page of platforms")
+		}
+		if len(platforms) > 0 {
+			fn(platforms)
+		}
+		if len(platforms) < pageSize {
+			return nil
+		}
+		page.Offset += len(platforms)
+	}
+}
+
+func refreshPckCerts(ctx context.Context, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) error {
+	_, _, err := refreshSelectedPckCerts(ctx, db, conf, client, nil)
+	return err
+}
+
+// refreshOutOfDatePckCerts refreshes pck certs only of the platforms whose tcb status is not accepted
+// as UpToDate, e.g. to pick up the pck certs issued after a TCB recovery. TcbInfo is refreshed first
+// so that tcb status is evaluated against the latest tcb levels. Number of platforms refreshed and
+// skipped is returned
+func refreshOutOfDatePckCerts(ctx context.Context, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) (int, int, error) {
+	err := refreshAllTcbInfo(ctx, db, conf, client)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "could not refresh TcbInfo before evaluating tcb status of platforms")
+	}
+
+	return refreshSelectedPckCerts(ctx, db, conf, client, func(platform *types.Platform) bool {
+		status, err := platformTcbStatus(db, platform, conf)
+		if err != nil {
+			// tcb status cannot be evaluated from cache, refresh to get the platform collaterals cached
+			log.WithError(err).Debugf("Could not evaluate tcb status of platform with qeid %s", platform.QeID)
+			return true
+		}
+		return !isTcbStatusAccepted(status, conf)
+	})
+}
+
+// refreshExpiringPckCerts refreshes pck certs only of the platforms whose selected pck cert has expired or
+// expires within the configured warning days, so that they are replaced before attestation fails on them.
+// Number of platforms refreshed and skipped is returned
+func refreshExpiringPckCerts(ctx context.Context, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) (int, int, error) {
+	expiring, err := db.PckCertRepository().RetrieveExpiringBefore(pckCertExpiryWarningTime(conf))
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "could not retrieve expiring pck certs")
+	}
+	// raw tcb levels of the platforms whose pck cert is expiring
+	type rawTcbLevel struct {
+		qeID, pceID, cpuSvn, pceSvn string
+	}
+	tcbLevels := make(map[rawTcbLevel]bool)
+	for _, pckCert := range expiring {
+		tcbLevels[rawTcbLevel{pckCert.QeID, pckCert.PceID, pckCert.CPUSvn, pckCert.PceSvn}] = true
+	}
+
+	return refreshSelectedPckCerts(ctx, db, conf, client, func(platform *types.Platform) bool {
+		return tcbLevels[rawTcbLevel{platform.QeID, platform.PceID, platform.CPUSvn, platform.PceSvn}]
+	})
+}
+
+// refreshBatchSize is the number of platforms whose pck certs are refreshed in a batch
+func refreshBatchSize(conf *config.Configuration) int {
+	if conf == nil || conf.RefreshBatchSize <= 0 {
+		return constants.RefreshPlatformsPageSize
+	}
+	return conf.RefreshBatchSize
+}
+
+// pckCertRefreshResumeOffset returns the offset of the first platform not refreshed by an interrupted
+// refresh of pck certs of all platforms, 0 when the last refresh completed
+func pckCertRefreshResumeOffset(db repository.SCSDatabase) int {
+	lastRefresh, err := db.LastRefreshRepository().Retrieve()
+	if err != nil || lastRefresh == nil {
+		return 0
+	}
+	return lastRefresh.PckCertResumeOffset
+}
+
+func savePckCertRefreshResumeOffset(db repository.SCSDatabase, offset int) error {
+	lastRefresh, err := db.LastRefreshRepository().Retrieve()
+	if err != nil {
+		return err
+	}
+	if lastRefresh == nil {
+		lastRefresh = &types.LastRefresh{}
+	}
+	lastRefresh.PckCertResumeOffset = offset
+	return db.LastRefreshRepository().Update(lastRefresh)
+}
+
+// refreshSelectedPckCerts refreshes pck certs of the cached platforms for which selectPlatform returns
+// true, all platforms are refreshed when selectPlatform is nil. Platforms are refreshed a batch at a
+// time, so that only a batch of platforms is held in memory. When all platforms are refreshed, progress
+// is saved after each batch and a refresh interrupted or failed part way resumes from the first batch
+// not refreshed. Platforms are paged by offset, so platforms added or purged in between can shift a
+// resumed refresh by a few platforms, which are picked up by the next refresh. Number of platforms
+// refreshed and skipped is returned
+func refreshSelectedPckCerts(ctx context.Context, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient,
+	selectPlatform func(*types.Platform) bool) (int, int, error) {
+
+	existingPlatformData, _ := db.PlatformRepository().RetrievePaginated(types.Pagination{Limit: 1})
+	if len(existingPlatformData) == 0 {
+		return 0, 0, errors.New("No platform value records are found in db, cannot perform refresh.")
+	}
+
+	saveProgress := selectPlatform == nil
+	page := types.Pagination{Limit: refreshBatchSize(conf)}
+	if saveProgress {
+		page.Offset = pckCertRefreshResumeOffset(db)
+		if page.Offset > 0 {
+			log.Infof("Resuming refresh of pck certs from platform %d", page.Offset)
+		}
+	}
+
+	refreshed, skipped := 0, 0
+	var err error
+	for {
+		platforms, pageErr := db.PlatformRepository().RetrievePaginated(page)
+		if pageErr != nil {
+			err = errors.Wrap(pageErr, "could not retrieve a page of platforms")
+			break
+		}
+
+		var batch []*types.Platform
+		for n := 0; n < len(platforms); n++ {
+			if selectPlatform != nil && !selectPlatform(&platforms[n]) {
+				skipped++
+				continue
+			}
+			batch = append(batch, &platforms[n])
+		}
+		if len(batch) > 0 {
+			refreshed += len(batch)
+			if batchErr := refreshPckCertBatch(ctx, db, conf, client, batch); batchErr != nil && err == nil {
+				err = batchErr
+			}
+		}
+
+		done := len(platforms) < page.Limit
+		page.Offset += len(platforms)
+		// progress is not saved past a batch which failed, so that it is retried when resumed
+		if saveProgress && err == nil {
+			resumeOffset := page.Offset
+			if done {
+				resumeOffset = 0
+			}
+			if saveErr := savePckCertRefreshResumeOffset(db, resumeOffset); saveErr != nil {
+				log.WithError(saveErr).Warn("Could not save progress of pck cert refresh")
+			}
+		}
+		if done {
+			break
+		}
+	}
+	log.Infof("refreshPckCerts Complete. %d platforms refreshed, %d skipped", refreshed, skipped)
+
+	return refreshed, skipped, err
+}
+
+// refreshPckCertBatch fetches pck certs of a batch of platforms from PCS and caches them, with a pool of
+// routines for PCS requests and a pool for DB updates. It returns once the whole batch is processed
+func refreshPckCertBatch(ctx context.Context, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient,
+	platforms []*types.Platform) error {
+
+	// Envelope to pass data to go routines.
 	type refreshedDataResponse struct {
 		pckCertInfo  *types.PckCert
 		pckCertChain string
@@ -850,25 +1711,25 @@ func refreshPckCerts(db repository.SCSDatabase, conf *config.Configuration, clie
 					break
 				}
 
-				err := cachePlatformTcbInfo(db, existingPlatformData, pckCertInfo.Tcbms[pckCertInfo.CertIndex], constants.CacheRefresh)
+				err := cachePlatformTcbInfo(ctx, db, existingPlatformData, pckCertInfo.Tcbms[pckCertInfo.CertIndex], constants.CacheRefresh)
 				if err != nil {
 					errC <- errors.Wrap(err, "Error while caching Platform Tcb Info")
 					break
 				}
 
-				err = cachePlatformTcbInfo(db, existingPlatformData, pckCertInfo.Tcbms[pckCertInfo.CertIndex], constants.CacheRefresh)
+				err = cachePlatformTcbInfo(ctx, db, existingPlatformData, pckCertInfo.Tcbms[pckCertInfo.CertIndex], constants.CacheRefresh)
 				if err != nil {
 					errC <- errors.Wrap(err, "Error while caching Platform Tcb Info")
 					break
 				}
 
-				_, err = cachePckCertChainInfo(db, pckCertChain, ca, constants.CacheRefresh)
+				_, err = cachePckCertChainInfo(ctx, db, pckCertChain, ca, constants.CacheRefresh)
 				if err != nil {
 					errC <- errors.Wrap(err, "Error while caching Pck CertChain Info")
 					break
 				}
 
-				_, err = cachePckCertInfo(db, pckCertInfo, constants.CacheRefresh)
+				_, err = cachePckCertInfo(ctx, db, pckCertInfo, constants.CacheRefresh)
 				if err != nil {
 					errC <- errors.Wrap(err, "Error while caching Pck Cert Info")
 					break
@@ -884,7 +1745,7 @@ func refreshPckCerts(db repository.SCSDatabase, conf *config.Configuration, clie
 			defer fetchPckCertWG.Done()
 
 			for platformInfo := range dbRows {
-				pckCertInfo, _, pckCertChain, ca, err := fetchPckCertInfo(platformInfo, conf, client)
+				pckCertInfo, _, pckCertChain, ca, err := fetchPckCertInfo(ctx, platformInfo, conf, client)
 
 				if err != nil {
 					errC <- errors.Wrap(err, "Error while fetching pck cert info.")
@@ -919,8 +1780,8 @@ func refreshPckCerts(db repository.SCSDatabase, conf *config.Configuration, clie
 	}(errC, errorStatus)
 
 	// Stage 1 - Send rows from DB to PCCS Request Pool.
-	for n := 0; n < len(existingPlatformData); n++ {
-		dbRows <- &existingPlatformData[n]
+	for _, platform := range platforms {
+		dbRows <- platform
 	}
 	close(dbRows)
 
@@ -933,29 +1794,46 @@ func refreshPckCerts(db repository.SCSDatabase, conf *config.Configuration, clie
 	close(errC)
 
 	// Stage 4 - Check on errors
-	err := <-errorStatus
-	log.Info("refreshPckCerts Complete.")
-
-	return err
+	return <-errorStatus
 }
 
-func refreshAllPckCrl(db repository.SCSDatabase, config *config.Configuration, client *domain.HttpClient) error {
-	existingPckCrlData, err := db.PckCrlRepository().RetrieveAll()
-	if len(existingPckCrlData) == 0 {
+// refreshAllPckCrl re-fetches the cached PCK CRLs. With RefreshAllPckCrls the PCK CRLs of both processor
+// and platform CAs are fetched, and cached when not cached yet, so that a CA no platform was pushed
+// for so far is available before its first platform is pushed
+func refreshAllPckCrl(ctx context.Context, db repository.SCSDatabase, config *config.Configuration, client *domain.HttpClient) error {
+	existingPckCrlData, _ := db.PckCrlRepository().RetrieveAll()
+	cacheTypes := make(map[string]constants.CacheType)
+	var cas []string
+	for n := 0; n < len(existingPckCrlData); n++ {
+		cas = append(cas, existingPckCrlData[n].Ca)
+		cacheTypes[existingPckCrlData[n].Ca] = constants.CacheRefresh
+	}
+	if config != nil && config.RefreshAllPckCrls {
+		for _, ca := range []string{constants.PckCrlCaProcessor, constants.PckCrlCaPlatform} {
+			if _, ok := cacheTypes[ca]; !ok {
+				cas = append(cas, ca)
+				cacheTypes[ca] = constants.CacheInsert
+			}
+		}
+	}
+	if len(cas) == 0 {
 		return errors.New("no pck crl record found in db, cannot perform refresh operation")
 	}
 
-	for n := 0; n < len(existingPckCrlData); n++ {
-		_, err = getLazyCachePckCrl(db, existingPckCrlData[n].Ca, constants.CacheRefresh, config, client)
+	for _, ca := range cas {
+		pckCrl, err := getLazyCachePckCrl(ctx, db, ca, cacheTypes[ca], config, client)
 		if err != nil {
 			return fmt.Errorf("refresh of pckcrl failed: %s", err.Error())
 		}
+		if isPckCrlExpired(pckCrl) {
+			log.Warnf("PCK CRL for ca %s is still past its nextUpdate %s after refresh", pckCrl.Ca, pckCrl.NextUpdate)
+		}
 	}
 	log.Info("All PckCrls for the platform re-fetched from PCS as part of refresh")
 	return nil
 }
 
-func refreshAllTcbInfo(db repository.SCSDatabase, config *config.Configuration, client *domain.HttpClient) error {
+func refreshAllTcbInfo(ctx context.Context, db repository.SCSDatabase, config *config.Configuration, client *domain.HttpClient) error {
 	existingTcbInfoData, err := db.FmspcTcbInfoRepository().RetrieveAll()
 	if len(existingTcbInfoData) == 0 {
 		return errors.New("no tcbinfo record found in db, cannot perform refresh operation")
@@ -963,7 +1841,7 @@ func refreshAllTcbInfo(db repository.SCSDatabase, config *config.Configuration,
 
 	log.Debug("Existing Fmspc count:", len(existingTcbInfoData))
 	for n := 0; n < len(existingTcbInfoData); n++ {
-		_, err = getLazyCacheFmspcTcbInfo(db, existingTcbInfoData[n].Fmspc, constants.CacheRefresh, config, client)
+		_, err = getLazyCacheFmspcTcbInfo(ctx, db, existingTcbInfoData[n].Fmspc, constants.CacheRefresh, config, client)
 		if err != nil {
 			return errors.New(fmt.Sprintf("Error in Refresh Tcb info: %s", err.Error()))
 		}
@@ -972,13 +1850,13 @@ func refreshAllTcbInfo(db repository.SCSDatabase, config *config.Configuration,
 	return nil
 }
 
-func refreshAllQE(db repository.SCSDatabase, config *config.Configuration, client *domain.HttpClient) error {
+func refreshAllQE(ctx context.Context, db repository.SCSDatabase, config *config.Configuration, client *domain.HttpClient) error {
 	existingQEData, err := db.QEIdentityRepository().Retrieve()
 	if existingQEData == nil {
 		return errors.New("no qe identity record found in db, cannot perform refresh operation")
 	}
 
-	_, err = getLazyCacheQEIdentityInfo(db, constants.CacheRefresh, config, client)
+	_, err = getLazyCacheQEIdentityInfo(ctx, db, constants.CacheRefresh, config, client)
 	if err != nil {
 		return errors.New(fmt.Sprintf("Error in Refresh QEIdentity info: %s", err.Error()))
 	}
@@ -986,20 +1864,81 @@ func refreshAllQE(db repository.SCSDatabase, config *config.Configuration, clien
 	return nil
 }
 
-func refreshNonPCKCollaterals(db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) error {
-	err := refreshAllPckCrl(db, conf, client)
+// refreshRootCaCrl re-fetches the root ca CRL, it is cached by the refresh when not cached yet
+// as any cached PCK CRL or TCB info is only as trustworthy as its intermediate CA
+func refreshRootCaCrl(ctx context.Context, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) error {
+	var cacheType constants.CacheType = constants.CacheInsert
+	existingRootCaCrl, _ := db.RootCaCrlRepository().Retrieve()
+	if existingRootCaCrl != nil {
+		cacheType = constants.CacheRefresh
+	}
+
+	rootCaCrl, err := getLazyCacheRootCaCrl(ctx, db, cacheType, conf, client)
+	if err != nil {
+		return fmt.Errorf("refresh of root ca crl failed: %s", err.Error())
+	}
+	if isRootCaCrlExpired(rootCaCrl) {
+		log.Warnf("Root CA CRL is still past its nextUpdate %s after refresh", rootCaCrl.NextUpdate)
+	}
+	log.Info("Root CA CRL re-fetched from PCS as part of refresh")
+	return nil
+}
+
+// refreshWithGrace runs refresh of a collateral and, when it fails, attempts it again the configured
+// number of grace attempts after a delay, so that a momentary failure of PCS does not fail the whole
+// refresh. The error of the last attempt is returned, also when ctx is done while waiting to attempt again
+func refreshWithGrace(ctx context.Context, conf *config.Configuration, collateral string, refresh func() error) error {
+	err := refresh()
+	if conf == nil {
+		return err
+	}
+	delay := time.Duration(conf.RefreshGraceDelaySeconds) * time.Second
+	for attempt := 1; err != nil && attempt <= conf.RefreshGraceAttempts; attempt++ {
+		log.WithError(err).Warnf("Refresh of %s failed, attempting again in %s (%d/%d)", collateral, delay, attempt, conf.RefreshGraceAttempts)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		err = refresh()
+	}
+	return err
+}
+
+func refreshNonPCKCollaterals(ctx context.Context, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) error {
+	err := refreshWithGrace(ctx, conf, constants.CollateralPckCrl, func() error {
+		return refreshAllPckCrl(ctx, db, conf, client)
+	})
+	recordRefreshResult(constants.CollateralPckCrl, err, conf)
 	if err != nil {
 		log.WithError(err).Error("could not complete refresh of PCK Crl")
 		return err
 	}
 
-	err = refreshAllTcbInfo(db, conf, client)
+	err = refreshWithGrace(ctx, conf, constants.CollateralRootCaCrl, func() error {
+		return refreshRootCaCrl(ctx, db, conf, client)
+	})
+	recordRefreshResult(constants.CollateralRootCaCrl, err, conf)
+	if err != nil {
+		log.WithError(err).Error("could not complete refresh of Root CA Crl")
+		return err
+	}
+
+	err = refreshWithGrace(ctx, conf, constants.CollateralTcbInfo, func() error {
+		return refreshAllTcbInfo(ctx, db, conf, client)
+	})
+	recordRefreshResult(constants.CollateralTcbInfo, err, conf)
 	if err != nil {
 		log.WithError(err).Error("could not complete refresh of TcbInfo")
 		return err
 	}
 
-	err = refreshAllQE(db, conf, client)
+	err = refreshWithGrace(ctx, conf, constants.CollateralQeIdentity, func() error {
+		return refreshAllQE(ctx, db, conf, client)
+	})
+	recordRefreshResult(constants.CollateralQeIdentity, err, conf)
 	if err != nil {
 		log.WithError(err).Error("could not complete refresh of QE Identity")
 		return err
@@ -1007,6 +1946,105 @@ func refreshNonPCKCollaterals(db repository.SCSDatabase, conf *config.Configurat
 	return nil
 }
 
+// PrefetchCollaterals warms the cache with QE identity and TCB info of fmspcs of already cached
+// platforms, so that the first requests after a cold start need not wait on Intel PCS server
+func PrefetchCollaterals(db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) error {
+	log.Trace("resource/platform_ops: PrefetchCollaterals() Entering")
+	defer log.Trace("resource/platform_ops: PrefetchCollaterals() Leaving")
+
+	var cacheType constants.CacheType = constants.CacheInsert
+	existingQEData, _ := db.QEIdentityRepository().Retrieve()
+	if existingQEData != nil {
+		cacheType = constants.CacheRefresh
+	}
+	_, err := getLazyCacheQEIdentityInfo(context.Background(), db, cacheType, conf, client)
+	if err != nil {
+		return errors.Wrap(err, "could not prefetch QE identity")
+	}
+	log.Info("QEIdentity prefetched from PCS at startup")
+
+	var failedFmspcs []string
+	fetched := make(map[string]bool)
+	err = forEachPlatformPage(db, constants.RefreshPlatformsPageSize, func(platforms types.Platforms) {
+		for n := 0; n < len(platforms); n++ {
+			fmspc := platforms[n].Fmspc
+			if fmspc == "" || fetched[fmspc] {
+				continue
+			}
+			fetched[fmspc] = true
+
+			existingFmspc, _ := db.FmspcTcbInfoRepository().Retrieve(&types.FmspcTcbInfo{Fmspc: fmspc})
+			if existingFmspc != nil {
+				continue
+			}
+			_, err := getLazyCacheFmspcTcbInfo(context.Background(), db, fmspc, constants.CacheInsert, conf, client)
+			if err != nil {
+				log.WithError(err).Warnf("could not prefetch TcbInfo for fmspc %s", fmspc)
+				failedFmspcs = append(failedFmspcs, fmspc)
+			}
+		}
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not retrieve cached platforms")
+	}
+	if len(failedFmspcs) > 0 {
+		return errors.New("could not prefetch TcbInfo for fmspcs: " + strings.Join(failedFmspcs, ","))
+	}
+	log.Debug("TcbInfo of cached platforms prefetched from PCS at startup, fmspc count:", len(fetched))
+	return nil
+}
+
+// cachePendingCollaterals caches the QE identity, and the TcbInfo and PCK CRL of cached platforms,
+// which are not cached yet. With the pre-caching model these are not fetched when a platform is
+// pushed but by the refresh following the push
+func cachePendingCollaterals(ctx context.Context, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) error {
+	existingQEData, _ := db.QEIdentityRepository().Retrieve()
+	if existingQEData == nil {
+		_, err := getLazyCacheQEIdentityInfo(ctx, db, constants.CacheInsert, conf, client)
+		if err != nil {
+			return errors.Wrap(err, "could not cache QE identity")
+		}
+	}
+
+	var failed []string
+	fmspcs := make(map[string]bool)
+	cas := make(map[string]bool)
+	err := forEachPlatformPage(db, constants.RefreshPlatformsPageSize, func(platforms types.Platforms) {
+		for n := 0; n < len(platforms); n++ {
+			if fmspc := platforms[n].Fmspc; fmspc != "" && !fmspcs[fmspc] {
+				fmspcs[fmspc] = true
+				existingFmspc, _ := db.FmspcTcbInfoRepository().Retrieve(&types.FmspcTcbInfo{Fmspc: fmspc})
+				if existingFmspc == nil {
+					_, err := getLazyCacheFmspcTcbInfo(ctx, db, fmspc, constants.CacheInsert, conf, client)
+					if err != nil {
+						log.WithError(err).Warnf("could not cache TcbInfo for fmspc %s", fmspc)
+						failed = append(failed, "TcbInfo of fmspc "+fmspc)
+					}
+				}
+			}
+
+			if ca := platforms[n].Ca; ca != "" && !cas[ca] {
+				cas[ca] = true
+				existingPckCrl, _ := db.PckCrlRepository().Retrieve(&types.PckCrl{Ca: ca})
+				if existingPckCrl == nil {
+					_, err := getLazyCachePckCrl(ctx, db, ca, constants.CacheInsert, conf, client)
+					if err != nil {
+						log.WithError(err).Warnf("could not cache PCK CRL for ca %s", ca)
+						failed = append(failed, "PCK CRL of ca "+ca)
+					}
+				}
+			}
+		}
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not retrieve cached platforms")
+	}
+	if len(failed) > 0 {
+		return errors.New("could not cache " + strings.Join(failed, ", "))
+	}
+	return nil
+}
+
 func RefreshPlatformInfo(db repository.SCSDatabase, trigger <-chan constants.RefreshTrigger, conf *config.Configuration, client *domain.HttpClient) {
 	for {
 		triggerType := <-trigger
@@ -1017,27 +2055,149 @@ func RefreshPlatformInfo(db repository.SCSDatabase, trigger <-chan constants.Ref
 			continue
 		}
 
+		// reads can be held off until a full refresh has updated all the collaterals
+		setFullRefreshInProgress(triggerType == constants.TriggerStart)
+
+		// PCS requests and cache writes of a refresh are traced as children of a span of the refresh
+		ctx, span := startSpan(context.Background(), "RefreshPlatformInfo")
+		span.setAttribute("scs.refresh.trigger", int(triggerType))
+
+		// platforms which are no longer pushed are purged before refreshing the pck certs of the rest
+		if triggerType == constants.TriggerStart && conf.PlatformMaxAgeDays > 0 {
+			_, err := purgeStalePlatforms(db, conf.PlatformMaxAgeDays)
+			if err != nil {
+				status = constants.RefreshStatusFailed
+				log.WithError(err).Error("Error while purging stale platforms")
+			}
+		}
+
 		// Start refresh
-		err := refreshPckCerts(db, conf, client)
-		if err != nil {
-			status = constants.RefreshStatusFailed
-			log.WithError(err).Error("Error while refreshing PCK Certs")
+		if triggerType == constants.TriggerStart || triggerType == constants.TriggerStartCerts {
+			// a failed batch is not saved as refreshed, so the pck certs are attempted again from it
+			err := refreshWithGrace(ctx, conf, constants.CollateralPckCert, func() error {
+				return refreshPckCerts(ctx, db, conf, client)
+			})
+			recordRefreshResult(constants.CollateralPckCert, err, conf)
+			if err != nil {
+				status = constants.RefreshStatusFailed
+				log.WithError(err).Error("Error while refreshing PCK Certs")
+			}
 		}
 
-		err = refreshNonPCKCollaterals(db, conf, client)
-		if err != nil {
-			status = constants.RefreshStatusFailed
-			log.WithError(err).Error("Error while refreshing Non PCK Collaterals")
+		if triggerType == constants.TriggerStart || triggerType == constants.TriggerStartTcbs {
+			// collaterals of the platforms pushed since the last refresh are cached first, as
+			// refresh of a collateral fails when none of it is cached
+			if conf.GetCacheModel() == constants.PreCachingModel {
+				err := cachePendingCollaterals(ctx, db, conf, client)
+				if err != nil {
+					status = constants.RefreshStatusFailed
+					log.WithError(err).Error("Error while caching collaterals of pushed platforms")
+				}
+			}
+
+			err := refreshNonPCKCollaterals(ctx, db, conf, client)
+			if err != nil {
+				status = constants.RefreshStatusFailed
+				log.WithError(err).Error("Error while refreshing Non PCK Collaterals")
+			}
+		}
+
+		var refreshed, skipped *int
+		if triggerType == constants.TriggerStartOutOfDateCerts {
+			refreshedCount, skippedCount, err := refreshOutOfDatePckCerts(ctx, db, conf, client)
+			recordRefreshResult(constants.CollateralPckCert, err, conf)
+			if err != nil {
+				status = constants.RefreshStatusFailed
+				log.WithError(err).Error("Error while refreshing PCK Certs of out of date platforms")
+			}
+			refreshed, skipped = &refreshedCount, &skippedCount
+		}
+
+		if triggerType == constants.TriggerStartExpiringCerts {
+			refreshedCount, skippedCount, err := refreshExpiringPckCerts(ctx, db, conf, client)
+			recordRefreshResult(constants.CollateralPckCert, err, conf)
+			if err != nil {
+				status = constants.RefreshStatusFailed
+				log.WithError(err).Error("Error while refreshing expiring PCK Certs")
+			}
+			refreshed, skipped = &refreshedCount, &skippedCount
+		}
+
+		// QE identity alone can be refreshed without re-fetching all PCK CRLs and TcbInfos
+		if triggerType == constants.TriggerStartQe {
+			err := refreshAllQE(ctx, db, conf, client)
+			recordRefreshResult(constants.CollateralQeIdentity, err, conf)
+			if err != nil {
+				status = constants.RefreshStatusFailed
+				log.WithError(err).Error("Error while refreshing QE Identity")
+			}
+		}
+
+		// stored tcb status is recomputed even after a failed refresh, as some of the pck certs
+		// and TcbInfos could have been refreshed
+		if conf.CacheTcbStatus && triggerType != constants.TriggerStartQe {
+			err := cacheAllPlatformTcbStatus(db, conf)
+			if err != nil {
+				status = constants.RefreshStatusFailed
+				log.WithError(err).Error("Error while computing tcb status of platforms")
+			}
 		}
 
-		// Update status in DB
-		refreshInfo := types.LastRefresh{CompletedAt: time.Now(), Status: status}
-		err = db.LastRefreshRepository().Update(&refreshInfo)
+		// Update status in DB, keeping the progress of an incomplete refresh of pck certs
+		refreshInfo := types.LastRefresh{CompletedAt: clock.Now(), Status: status,
+			RefreshedPlatforms: refreshed, SkippedPlatforms: skipped, PckCertResumeOffset: pckCertRefreshResumeOffset(db)}
+		err := db.LastRefreshRepository().Update(&refreshInfo)
 		if err != nil {
 			log.WithError(err).Error("Error while updating lastRefresh Info in DB.")
 		}
+		setFullRefreshInProgress(false)
+		if status == constants.RefreshStatusFailed {
+			err = errors.New("refresh failed")
+		}
+		span.end(err)
+
+	}
+}
+
+// deletePlatform deletes a platform along with its pck certs and platform tcb
+func deletePlatform(db repository.SCSDatabase, platform *types.Platform) error {
+	if err := db.PckCertRepository().DeleteByPlatform(platform); err != nil {
+		return err
+	}
+	if err := db.PlatformTcbRepository().Delete(&types.PlatformTcb{QeID: platform.QeID, PceID: platform.PceID}); err != nil {
+		return err
+	}
+	return db.PlatformRepository().Delete(platform)
+}
+
+// purgeStalePlatforms deletes the platforms which have not been pushed for more than maxAgeDays,
+// along with their pck certs and platform tcb, as the hosts are assumed to be decommissioned.
+// Each platform is deleted in its own transaction and number of platforms purged is returned
+func purgeStalePlatforms(db repository.SCSDatabase, maxAgeDays int) (int, error) {
+	if maxAgeDays < constants.MinPlatformMaxAgeDays {
+		return 0, errors.Errorf("platform max age should be at least %d days", constants.MinPlatformMaxAgeDays)
+	}
+
+	cutoff := clock.Now().UTC().AddDate(0, 0, -maxAgeDays)
+	stalePlatforms, err := db.PlatformRepository().RetrieveNotUpdatedSince(cutoff)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not retrieve stale platforms")
+	}
 
+	purged := 0
+	for i := range stalePlatforms {
+		platform := &stalePlatforms[i]
+		err = db.ExecuteInTransaction(func(tx repository.SCSDatabase) error {
+			return deletePlatform(tx, platform)
+		})
+		if err != nil {
+			return purged, errors.Wrapf(err, "could not purge platform with qeid %s", platform.QeID)
+		}
+		purged++
+		log.Infof("Purged platform with qeid %s and pceid %s, last pushed at %s", platform.QeID, platform.PceID,
+			platform.UpdatedTime.Format(time.RFC3339))
 	}
+	return purged, nil
 }
 
 func fetchLastRefreshInfo(db repository.SCSDatabase) (*types.LastRefresh, error) {
@@ -1055,7 +2215,7 @@ func isCoolOffTimeout(lastRefresh *types.LastRefresh) *int {
 		return nil
 	}
 
-	sinceLastRefresh := time.Since(lastRefresh.CompletedAt)
+	sinceLastRefresh := clock.Now().Sub(lastRefresh.CompletedAt)
 	var lastRefreshinSeconds int
 	lastRefreshinSeconds = int(sinceLastRefresh.Seconds())
 
@@ -1068,21 +2228,58 @@ func isCoolOffTimeout(lastRefresh *types.LastRefresh) *int {
 	return nil
 }
 
-func InitAutoRefreshTimer(db repository.SCSDatabase, refreshTrigger chan<- constants.RefreshTrigger, refreshHours int) error {
+// autoRefreshHours changes the interval of the refresh timer, see ResetAutoRefreshTimer
+var autoRefreshHours = make(chan int, 1)
+
+// ResetAutoRefreshTimer restarts the refresh timer with refreshHours between refreshes, e.g. when
+// the interval is reloaded from config.yml
+func ResetAutoRefreshTimer(refreshHours int) {
+	if refreshHours <= 0 {
+		log.Warnf("Refresh interval of %d hours is not valid, keeping the current interval", refreshHours)
+		return
+	}
+	select {
+	case <-autoRefreshHours:
+	default:
+	}
+	autoRefreshHours <- refreshHours
+}
+
+// timerRefreshTrigger is the refresh triggered by the refresh timer, a full refresh unless the pck certs or
+// the collaterals are skipped by timerRefresh. false is returned when both are skipped
+func timerRefreshTrigger(timerRefresh config.TimerRefresh) (constants.RefreshTrigger, bool) {
+	switch {
+	case timerRefresh.SkipPckCerts && timerRefresh.SkipCollaterals:
+		return 0, false
+	case timerRefresh.SkipPckCerts:
+		return constants.TriggerStartTcbs, true
+	case timerRefresh.SkipCollaterals:
+		return constants.TriggerStartCerts, true
+	default:
+		return constants.TriggerStart, true
+	}
+}
+
+func InitAutoRefreshTimer(db repository.SCSDatabase, refreshTrigger chan<- constants.RefreshTrigger, refreshHours int,
+	timerRefresh config.TimerRefresh) error {
 
 	stop := make(chan os.Signal)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 
 	// Start the timer.
+	ticker := clock.NewTicker(time.Hour * time.Duration(refreshHours))
 	go func() {
-		ticker := time.NewTicker(time.Hour * time.Duration(refreshHours))
-		defer ticker.Stop()
+		defer func() { ticker.Stop() }()
 		for {
 			select {
 			case <-stop:
 				fmt.Fprintln(os.Stderr, "Got Signal for exit and exiting.... Refresh Timer")
 				return
-			case t := <-ticker.C:
+			case hours := <-autoRefreshHours:
+				ticker.Stop()
+				ticker = clock.NewTicker(time.Hour * time.Duration(hours))
+				log.Infof("Refresh timer restarted with %d hours between refreshes", hours)
+			case t := <-ticker.C():
 				log.Debug("Timer started", t)
 				// TODO : Timer expired. Check if we are in coolOff period.
 				lastRefresh, err := fetchLastRefreshInfo(db)
@@ -1091,12 +2288,15 @@ func InitAutoRefreshTimer(db repository.SCSDatabase, refreshTrigger chan<- const
 					break
 				}
 
+				trigger, ok := timerRefreshTrigger(timerRefresh)
 				coolOffTimeout := isCoolOffTimeout(lastRefresh)
-				if coolOffTimeout != nil {
+				if !ok {
+					log.Debug("Timer triggered - Refresh of both PCK certs and collaterals is left to the refresh api.")
+				} else if coolOffTimeout != nil {
 					log.Debug("Timer triggered during cooloff timeout.")
 				} else {
 					select {
-					case refreshTrigger <- constants.TriggerStart:
+					case refreshTrigger <- trigger:
 						log.Debug("Timer triggered a platforminfo refresh.")
 					default:
 						log.Debug("Timer triggered - Refresh is already in progress.")
@@ -1156,6 +2356,21 @@ func refreshPlatformInfoStart(db repository.SCSDatabase, refreshTrigger chan<- c
 			return err
 		}
 
+		if err := validateQueryParams(r.URL.Query(), refreshStartParams); err != nil {
+			slog.Errorf("resource/platform_ops: refreshPlatformInfoStart() %s", err.Error())
+			return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
+		}
+
+		var trigger constants.RefreshTrigger = constants.TriggerStart
+		refreshType := r.URL.Query().Get("type")
+		if refreshType != "" {
+			var ok bool
+			if trigger, ok = refreshTypeTriggers[refreshType]; !ok {
+				slog.Errorf("resource/platform_ops: refreshPlatformInfoStart() Invalid refresh type provided")
+				return &resourceError{Message: "invalid refresh type", StatusCode: http.StatusBadRequest}
+			}
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 
 		res := RefreshResponse{}
@@ -1170,7 +2385,7 @@ func refreshPlatformInfoStart(db repository.SCSDatabase, refreshTrigger chan<- c
 			res.Status = constants.RefreshStatusTooMany
 		} else {
 			select {
-			case refreshTrigger <- constants.TriggerStart:
+			case refreshTrigger <- trigger:
 				res.Status = constants.RefreshStatusStarted
 			default:
 				res.Status = constants.RefreshStatusInProgress
@@ -1248,11 +2463,25 @@ func getTcbCompList(tcbLevelList *TcbLevels) []byte {
 	return tcbCompLevel
 }
 
+// sortTcbLevels orders TCB levels by descending TCB, comparing components and then pcesvn.
+// TCB levels are only partially ordered, but a level which is equal or greater than another in
+// every component and pcesvn is always sorted before it, so the first level matching a raw tcb
+// is the highest one, even when TcbInfo does not list the levels newest first
+func sortTcbLevels(tcbLevels []TcbLevelsType) {
+	sort.SliceStable(tcbLevels, func(i, j int) bool {
+		cmp := bytes.Compare(getTcbCompList(&tcbLevels[i].Tcb), getTcbCompList(&tcbLevels[j].Tcb))
+		if cmp != 0 {
+			return cmp > 0
+		}
+		return tcbLevels[i].Tcb.PceSvn > tcbLevels[j].Tcb.PceSvn
+	})
+}
+
 /*
  * To Determine, if a Platform's TCB status is uptodate or not, following mechanism is employed
  * 1. Retrieve FMSPC value from SGX PCK Certificate assigned to a given platform.
  * 2. Retrieve TCB Info matching the FMSPC value
- * 3. Iterate over the sorted collection of TCB Levels retrieved from TCB Info starting from the first item on the list
+ * 3. Sort the TCB Levels retrieved from TCB Info by descending TCB and iterate over them starting from the first item on the list
  * 4. Compare all the SGX TCB Comp SVNs retrieved from the SGX PCK Certificate with the corresponding values in the TCB Level
  *    If all SGX TCB Comp SVNs in the certificate are greater or equal to the corresponding values in TCB Level, go to next step
  *    otherwise move to the next item on TCB Levels list.
@@ -1260,101 +2489,265 @@ func getTcbCompList(tcbLevelList *TcbLevels) []byte {
  *    If it is greater or equal to the value in TCB Level, read status assigned to this TCB level
  *    Otherwise, move to the next item on TCB Levels list
  * 6. If no TCB level matches SGX PCK Certificate, then TCB Level is not supported
+ * The outcome of the comparison against every TCB level is returned, so that the selection can be diagnosed
  */
-func getTcbStatus(db repository.SCSDatabase) errorHandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) error {
-		err := authorizeEndpoint(r, constants.HostDataReaderGroupName, true)
+func selectPlatformTcbLevel(db repository.SCSDatabase, platform *types.Platform, conf *config.Configuration) (*PckCertSelection, error) {
+	// select the pck cert cached for the current raw tcb level of the platform
+	pckInfo := &types.PckCert{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn}
+	existingPckCertData, err := db.PckCertRepository().RetrieveByTcbLevel(pckInfo)
+	if err != nil {
+		return nil, retrieveRecordError(err, "pck cert")
+	}
+
+	tcbInf := &types.FmspcTcbInfo{Fmspc: platform.Fmspc}
+	existingFmspc, err := db.FmspcTcbInfoRepository().Retrieve(tcbInf)
+	if err != nil {
+		return nil, retrieveRecordError(err, "tcb info")
+	}
+
+	// only tcbm of the selected pck cert is needed to evaluate the tcb level
+	if int(existingPckCertData.CertIndex) >= len(existingPckCertData.Tcbms) {
+		err = reselectPckCert(db, platform, existingPckCertData, existingFmspc.TcbInfo)
 		if err != nil {
-			return err
+			slog.WithError(err).Warnf("resource/platform_ops: selectPlatformTcbLevel() Stale pck cert selection of platform with qeid %s could not be re-resolved", platform.QeID)
+			return nil, &resourceError{Message: "pck cert selection of the platform is stale, refresh of its pck certs is required",
+				StatusCode: http.StatusConflict}
 		}
+	}
 
-		if len(r.URL.Query()) < 2 {
-			return &resourceError{Message: "query data not provided",
-				StatusCode: http.StatusBadRequest}
+	// TCB levels stored since the TcbInfo was cached are compared without parsing TcbInfo again. TcbInfo
+	// cached before normalization was enabled, or whose levels could not be stored, is parsed instead
+	if conf != nil && conf.NormalizeTcbInfo {
+		levels, err := db.TcbLevelRepository().RetrieveByFmspc(platform.Fmspc)
+		if err != nil {
+			log.WithError(err).Warnf("Could not retrieve TCB levels of fmspc %s, TcbInfo is parsed instead", platform.Fmspc)
+		} else if len(levels) > 0 && !levels[0].CreatedTime.Before(existingFmspc.UpdatedTime) {
+			return compareNormalizedTcbLevels(platform, existingPckCertData, levels)
 		}
+	}
+	return compareTcbLevels(platform, existingPckCertData, existingFmspc.TcbInfo)
+}
 
-		if err := validateQueryParams(r.URL.Query(), tcbStatusRetrieveParams); err != nil {
-			slog.Errorf("resource/platform_ops: getTcbStatus() %s", err.Error())
-			return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
-		}
+// compareTcbLevels compares the tcbm of the selected pck cert of a platform against each TCB level of the
+// TcbInfo response body, and returns the selection with the status of the first TCB level it matches
+func compareTcbLevels(platform *types.Platform, pckCert *types.PckCert, tcbInfoBody string) (*PckCertSelection, error) {
+	var tcbInfo TcbInfoJSON
 
-		qeID := r.URL.Query().Get("qeid")
-		pceID := r.URL.Query().Get("pceid")
-		if !validateInputString(constants.QeIDKey, qeID) || !validateInputString(constants.PceIDKey, pceID) {
-			slog.Errorf("resource/platform_ops: getTcbStatus() Input validation failed for query parameter")
-			return &resourceError{Message: "invalid query param",
-				StatusCode: http.StatusBadRequest}
-		}
+	// unmarshal the json encoded TcbInfo response for a platform
+	err := json.Unmarshal([]byte(tcbInfoBody), &tcbInfo)
+	if err != nil {
+		return nil, &resourceError{Message: "cannot unmarshal tcbinfo: " + err.Error(),
+			StatusCode: http.StatusInternalServerError}
+	}
 
-		pckInfo := &types.PckCert{QeID: qeID, PceID: pceID}
-		existingPckCertData, err := db.PckCertRepository().Retrieve(pckInfo)
-		if existingPckCertData == nil {
-			return &resourceError{Message: "no pck cert record found: " + err.Error(),
-				StatusCode: http.StatusNotFound}
-		}
+	sortTcbLevels(tcbInfo.TcbInfo.TcbLevels)
+	return matchTcbLevels(platform, pckCert, tcbInfo.TcbInfo.TcbLevels)
+}
 
-		certIndex := existingPckCertData.CertIndex
-		existingPlatformData := &types.Platform{QeID: qeID, PceID: pceID}
-		existingPlatformData, err = db.PlatformRepository().Retrieve(existingPlatformData)
-		if existingPlatformData == nil {
-			return &resourceError{Message: "no platform record found: " + err.Error(),
-				StatusCode: http.StatusNotFound}
-		}
+// compareNormalizedTcbLevels compares the tcbm of the selected pck cert of a platform against each TCB level
+// stored for its fmspc, which are stored in the order they are compared
+func compareNormalizedTcbLevels(platform *types.Platform, pckCert *types.PckCert, levels types.TcbLevels) (*PckCertSelection, error) {
+	tcbLevels := make([]TcbLevelsType, len(levels))
+	for i, level := range levels {
+		tcbLevels[i] = TcbLevelsType{
+			Tcb: TcbLevels{
+				SgxTcbComp01Svn: level.SgxTcbComp01Svn,
+				SgxTcbComp02Svn: level.SgxTcbComp02Svn,
+				SgxTcbComp03Svn: level.SgxTcbComp03Svn,
+				SgxTcbComp04Svn: level.SgxTcbComp04Svn,
+				SgxTcbComp05Svn: level.SgxTcbComp05Svn,
+				SgxTcbComp06Svn: level.SgxTcbComp06Svn,
+				SgxTcbComp07Svn: level.SgxTcbComp07Svn,
+				SgxTcbComp08Svn: level.SgxTcbComp08Svn,
+				SgxTcbComp09Svn: level.SgxTcbComp09Svn,
+				SgxTcbComp10Svn: level.SgxTcbComp10Svn,
+				SgxTcbComp11Svn: level.SgxTcbComp11Svn,
+				SgxTcbComp12Svn: level.SgxTcbComp12Svn,
+				SgxTcbComp13Svn: level.SgxTcbComp13Svn,
+				SgxTcbComp14Svn: level.SgxTcbComp14Svn,
+				SgxTcbComp15Svn: level.SgxTcbComp15Svn,
+				SgxTcbComp16Svn: level.SgxTcbComp16Svn,
+				PceSvn:          level.PceSvn,
+			},
+			TcbDate:   level.TcbDate.UTC().Format(time.RFC3339),
+			TcbStatus: level.TcbStatus,
+		}
+	}
+	return matchTcbLevels(platform, pckCert, tcbLevels)
+}
 
-		tcbInf := &types.FmspcTcbInfo{Fmspc: existingPlatformData.Fmspc}
-		existingFmspc, err := db.FmspcTcbInfoRepository().Retrieve(tcbInf)
-		if existingFmspc == nil {
-			return &resourceError{Message: "no tcb info record found: " + err.Error(),
-				StatusCode: http.StatusNotFound}
-		}
+// matchTcbLevels compares the tcbm of the selected pck cert of a platform against TCB levels sorted by
+// descending TCB, and returns the selection with the status of the first TCB level it matches
+func matchTcbLevels(platform *types.Platform, pckCert *types.PckCert, tcbLevels []TcbLevelsType) (*PckCertSelection, error) {
+	certIndex := pckCert.CertIndex
 
-		// for the selected pck cert, select corresponding raw tcb level (tcbm)
-		tcbm, err := hex.DecodeString(existingPckCertData.Tcbms[certIndex])
-		if err != nil {
-			return &resourceError{Message: "cannot decode tcbm: " + err.Error(),
-				StatusCode: http.StatusInternalServerError}
-		}
+	// for the selected pck cert, select corresponding raw tcb level (tcbm)
+	tcbm, err := hex.DecodeString(pckCert.Tcbms[certIndex])
+	if err != nil {
+		return nil, &resourceError{Message: "cannot decode tcbm: " + err.Error(),
+			StatusCode: http.StatusInternalServerError}
+	}
 
-		// tcbm (current raw tcb level) is 18 byte array with first 16 bytes for cpusvn
-		//  and next 2 bytes for pcesvn
-		pckComponents := tcbm[:16]
-		pckPceSvn := binary.LittleEndian.Uint16(tcbm[16:])
+	// tcbm (current raw tcb level) is 18 byte array with first 16 bytes for cpusvn
+	//  and next 2 bytes for pcesvn
+	pckComponents := tcbm[:16]
+	pckPceSvn := binary.LittleEndian.Uint16(tcbm[16:])
+
+	selection := &PckCertSelection{
+		QeID:      platform.QeID,
+		PceID:     platform.PceID,
+		Fmspc:     platform.Fmspc,
+		CertIndex: certIndex,
+		CertCount: len(pckCert.PckCerts),
+		Tcbm:      pckCert.Tcbms[certIndex],
+		TcbStatus: constants.TcbLevelNotFound,
+		TcbLevels: make([]TcbLevelComparison, len(tcbLevels)),
+	}
+	matched := false
+	// iterate through all TCB Levels present in TCBInfo
+	for i, tcbLevel := range tcbLevels {
+		tcbComponents := getTcbCompList(&tcbLevel.Tcb)
+		tcbError := compareTcbComponents(pckComponents, pckPceSvn, tcbComponents, tcbLevel.Tcb.PceSvn)
+		selection.TcbLevels[i] = TcbLevelComparison{
+			Tcb:       tcbLevel.Tcb,
+			TcbDate:   tcbLevel.TcbDate,
+			TcbStatus: tcbLevel.TcbStatus,
+			Result:    tcbComparisonResults[tcbError],
+		}
+		if tcbError == EqualOrGreater && !matched {
+			matched = true
+			selection.TcbLevels[i].Matched = true
+			selection.TcbStatus = tcbLevel.TcbStatus
+		}
+	}
+	return selection, nil
+}
 
-		var tcbInfo TcbInfoJSON
+// platformTcbStatus returns the status of the TCB level which the raw tcb of a platform matches
+func platformTcbStatus(db repository.SCSDatabase, platform *types.Platform, conf *config.Configuration) (string, error) {
+	selection, err := selectPlatformTcbLevel(db, platform, conf)
+	if err != nil {
+		return "", err
+	}
 
-		// unmarshal the json encoded TcbInfo response for a platform
-		err = json.Unmarshal([]byte(existingFmspc.TcbInfo), &tcbInfo)
-		if err != nil {
-			return &resourceError{Message: "cannot unmarshal tcbinfo: " + err.Error(),
-				StatusCode: http.StatusInternalServerError}
-		}
+	// raw tcb of the platform is lower than all tcb levels in TcbInfo, so it is
+	// reported distinctly from a platform at a known tcb level which is not UpToDate
+	if selection.TcbStatus == constants.TcbLevelNotFound {
+		slog.Warnf("resource/platform_ops: platformTcbStatus() No TCB level in TcbInfo of fmspc %s matches raw tcb of platform", platform.Fmspc)
+	}
+	return selection.TcbStatus, nil
+}
 
-		var status string
-		var response Response
-		response.Status = "false"
-		response.Message = "TCB Status is not UpToDate"
+// cachedPlatformTcbStatus returns the tcb status stored on the platform when tcb status caching is
+// enabled and neither the platform nor its TcbInfo has been updated since the status was computed,
+// otherwise the status is evaluated from TcbInfo and stored for the following requests
+func cachedPlatformTcbStatus(db repository.SCSDatabase, platform *types.Platform, conf *config.Configuration) (string, error) {
+	if conf == nil || !conf.CacheTcbStatus {
+		return platformTcbStatus(db, platform, conf)
+	}
 
-		var tcbComponents []byte
-		// iterate through all TCB Levels present in TCBInfo
-		for i := 0; i < len(tcbInfo.TcbInfo.TcbLevels); i++ {
-			tcbPceSvn := tcbInfo.TcbInfo.TcbLevels[i].Tcb.PceSvn
-			tcbComponents = getTcbCompList(&tcbInfo.TcbInfo.TcbLevels[i].Tcb)
-			tcbError := compareTcbComponents(pckComponents, pckPceSvn, tcbComponents, tcbPceSvn)
-			if tcbError == EqualOrGreater {
-				status = tcbInfo.TcbInfo.TcbLevels[i].TcbStatus
-				break
-			}
+	if platform.TcbStatus != "" && !platform.TcbStatusTime.Before(platform.UpdatedTime) {
+		existingFmspc, err := db.FmspcTcbInfoRepository().Retrieve(&types.FmspcTcbInfo{Fmspc: platform.Fmspc})
+		if existingFmspc != nil && !platform.TcbStatusTime.Before(existingFmspc.UpdatedTime) {
+			return platform.TcbStatus, nil
 		}
-
-		if status == "UpToDate" || status == "ConfigurationNeeded" {
-			response.Status = "true"
-			response.Message = "TCB Status is UpToDate"
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return "", &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
 		}
+	}
+	return cachePlatformTcbStatus(db, platform, conf)
+}
 
-		w.Header().Set("Content-Type", "application/json")
+// cachePlatformTcbStatus evaluates the tcb status of the platform and stores it on the platform
+// record. Failure to store the status is only logged as the status is evaluated again on next read
+func cachePlatformTcbStatus(db repository.SCSDatabase, platform *types.Platform, conf *config.Configuration) (string, error) {
+	status, err := platformTcbStatus(db, platform, conf)
+	if err != nil {
+		return "", err
+	}
+
+	platform.TcbStatus = status
+	platform.TcbStatusTime = clock.Now().UTC()
+	err = db.PlatformRepository().Update(&types.Platform{QeID: platform.QeID, PceID: platform.PceID,
+		TcbStatus: platform.TcbStatus, TcbStatusTime: platform.TcbStatusTime})
+	if err != nil {
+		log.WithError(err).Warnf("Could not store tcb status of platform with qeid %s", platform.QeID)
+	}
+	return status, nil
+}
+
+// cacheAllPlatformTcbStatus recomputes the stored tcb status of all platforms after a refresh, as
+// the refreshed pck certs or TcbInfo can change the tcb level the platform matches
+func cacheAllPlatformTcbStatus(db repository.SCSDatabase, conf *config.Configuration) error {
+	failed := 0
+	err := forEachPlatformPage(db, constants.RefreshPlatformsPageSize, func(platforms types.Platforms) {
+		for n := range platforms {
+			_, err := cachePlatformTcbStatus(db, &platforms[n], conf)
+			if err != nil {
+				log.WithError(err).Debugf("Could not evaluate tcb status of platform with qeid %s", platforms[n].QeID)
+				failed++
+			}
+		}
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not retrieve cached platforms")
+	}
+	if failed > 0 {
+		log.Warnf("Tcb status could not be evaluated for %d platforms", failed)
+	}
+	return nil
+}
+
+func getTcbStatus(db repository.SCSDatabase, conf *config.Configuration) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.HostDataReaderGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		if len(r.URL.Query()) < 2 {
+			return &resourceError{Message: "query data not provided",
+				StatusCode: http.StatusBadRequest}
+		}
+
+		if err := validateQueryParams(r.URL.Query(), tcbStatusRetrieveParams); err != nil {
+			slog.Errorf("resource/platform_ops: getTcbStatus() %s", err.Error())
+			return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
+		}
+
+		qeID := r.URL.Query().Get("qeid")
+		pceID := r.URL.Query().Get("pceid")
+		if !validateInputString(constants.QeIDKey, qeID) || !validateInputString(constants.PceIDKey, pceID) {
+			slog.Errorf("resource/platform_ops: getTcbStatus() Input validation failed for query parameter")
+			return &resourceError{Message: "invalid query param",
+				StatusCode: http.StatusBadRequest}
+		}
+
+		scopes, err := getPlatformScopes(r, constants.HostDataReaderGroupName)
+		if err != nil {
+			return err
+		}
+
+		existingPlatformData := &types.Platform{QeID: qeID, PceID: pceID}
+		existingPlatformData, err = db.PlatformRepository().Retrieve(existingPlatformData)
+		if err != nil {
+			return retrieveRecordError(err, "platform")
+		}
+		if err := authorizePlatformScope(r, scopes, existingPlatformData.Fmspc, existingPlatformData.PceID); err != nil {
+			return err
+		}
+		recordPlatformAccess(db, existingPlatformData)
+
+		response, err := retrieveTcbStatus(db, existingPlatformData, conf)
+		if err != nil {
+			return err
+		}
+
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 
-		res := Response{Status: response.Status, Message: response.Message}
+		res := Response{Status: response.Status, Message: response.Message, TcbStatus: response.TcbStatus,
+			TcbInfo: response.TcbInfo}
 		js, err := json.Marshal(res)
 		if err != nil {
 			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
@@ -1368,6 +2761,1259 @@ func getTcbStatus(db repository.SCSDatabase) errorHandlerFunc {
 	}
 }
 
+// getPckCertSelection returns the pck cert selected for the current raw tcb level of a platform, and
+// the outcome of comparing its tcbm against each TCB level of TcbInfo, so that operators can find out
+// why a TCB status was reported for a platform. pceid is optional, as qeid identifies a platform
+func getPckCertSelection(db repository.SCSDatabase, conf *config.Configuration) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.CacheManagerGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		if err := validateQueryParams(r.URL.Query(), pckCertSelectionRetrieveParams); err != nil {
+			slog.Errorf("resource/platform_ops: getPckCertSelection() %s", err.Error())
+			return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
+		}
+
+		qeID := strings.ToLower(r.URL.Query().Get("qeid"))
+		pceID := strings.ToLower(r.URL.Query().Get("pceid"))
+		if !validateInputString(constants.QeIDKey, qeID) || (pceID != "" && !validateInputString(constants.PceIDKey, pceID)) {
+			slog.Errorf("resource/platform_ops: getPckCertSelection() Input validation failed for query parameter")
+			return &resourceError{Message: "invalid query param",
+				StatusCode: http.StatusBadRequest}
+		}
+
+		existingPlatformData, err := db.PlatformRepository().Retrieve(&types.Platform{QeID: qeID, PceID: pceID})
+		if err != nil {
+			return retrieveRecordError(err, "platform")
+		}
+
+		selection, err := selectPlatformTcbLevel(db, existingPlatformData, conf)
+		if err != nil {
+			return err
+		}
+
+		js, err := json.Marshal(selection)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(js)
+		if err != nil {
+			log.WithError(err).Error("Could not write pck cert selection to response")
+		}
+		slog.Infof("%s: PCK cert selection retrieved by: %s", commLogMsg.AuthorizedAccess, r.RemoteAddr)
+		return nil
+	}
+}
+
+// compareRawTcb compares the current raw tcb of a platform against the raw tcb cached for it
+func compareRawTcb(cpuSvn, pceSvn string, platformTcb *types.PlatformTcb) (*TcbComparison, error) {
+	comparison := &TcbComparison{
+		QeID:         platformTcb.QeID,
+		PceID:        platformTcb.PceID,
+		CPUSvn:       cpuSvn,
+		PceSvn:       pceSvn,
+		CachedCPUSvn: strings.ToLower(platformTcb.CPUSvn),
+		CachedPceSvn: strings.ToLower(platformTcb.PceSvn),
+	}
+
+	components, err := hex.DecodeString(cpuSvn)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode cpusvn")
+	}
+	svn, err := strconv.ParseUint(pceSvn, 16, 16)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse pcesvn")
+	}
+	cachedComponents, err := hex.DecodeString(comparison.CachedCPUSvn)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode cached cpusvn")
+	}
+	cachedSvn, err := strconv.ParseUint(comparison.CachedPceSvn, 16, 16)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse cached pcesvn")
+	}
+
+	comparison.Result = tcbComparisonResults[compareTcbComponents(components, uint16(svn), cachedComponents, uint16(cachedSvn))]
+	comparison.ReselectionNeeded = comparison.CPUSvn != comparison.CachedCPUSvn || comparison.PceSvn != comparison.CachedPceSvn
+	return comparison, nil
+}
+
+// getTcbComparison compares the current raw tcb of a platform, as reported by its agent, against the
+// raw tcb cached for it, so that the agent can find out whether the platform is to be pushed again
+// after a microcode or BIOS update. The cached platform is not updated
+func getTcbComparison(db repository.SCSDatabase) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.HostDataReaderGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		if len(r.URL.Query()) < 4 {
+			return &resourceError{Message: "query data not provided",
+				StatusCode: http.StatusBadRequest}
+		}
+
+		if err := validateQueryParams(r.URL.Query(), tcbComparisonRetrieveParams); err != nil {
+			slog.Errorf("resource/platform_ops: getTcbComparison() %s", err.Error())
+			return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
+		}
+
+		qeID := strings.ToLower(r.URL.Query().Get("qeid"))
+		pceID := strings.ToLower(r.URL.Query().Get("pceid"))
+		cpuSvn := strings.ToLower(r.URL.Query().Get("cpusvn"))
+		pceSvn := strings.ToLower(r.URL.Query().Get("pcesvn"))
+		if !validateInputString(constants.QeIDKey, qeID) || !validateInputString(constants.PceIDKey, pceID) ||
+			!validateInputString(constants.CPUSvnKey, cpuSvn) || !validateInputString(constants.PceSvnKey, pceSvn) {
+			slog.Errorf("resource/platform_ops: getTcbComparison() Input validation failed for query parameter")
+			return &resourceError{Message: "invalid query param",
+				StatusCode: http.StatusBadRequest}
+		}
+
+		scopes, err := getPlatformScopes(r, constants.HostDataReaderGroupName)
+		if err != nil {
+			return err
+		}
+
+		existingPlatformData, err := db.PlatformRepository().Retrieve(&types.Platform{QeID: qeID, PceID: pceID})
+		if err != nil {
+			return retrieveRecordError(err, "platform")
+		}
+		if err := authorizePlatformScope(r, scopes, existingPlatformData.Fmspc, existingPlatformData.PceID); err != nil {
+			return err
+		}
+
+		platformTcb, err := db.PlatformTcbRepository().Retrieve(&types.PlatformTcb{QeID: qeID, PceID: pceID})
+		if err != nil {
+			return retrieveRecordError(err, "platform tcb")
+		}
+
+		comparison, err := compareRawTcb(cpuSvn, pceSvn, platformTcb)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		js, err := json.Marshal(comparison)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(js)
+		if err != nil {
+			log.WithError(err).Error("Could not write tcb comparison to response")
+		}
+		slog.Infof("%s: TCB comparison retrieved by: %s", commLogMsg.AuthorizedAccess, r.RemoteAddr)
+		return nil
+	}
+}
+
+// summarizeFmspcTcbStatus evaluates the TCB status of every cached platform of an fmspc, so that the
+// impact of a TCB recovery on a platform generation can be assessed
+func summarizeFmspcTcbStatus(db repository.SCSDatabase, fmspc string, conf *config.Configuration) (*TcbStatusSummary, error) {
+	if _, err := db.FmspcTcbInfoRepository().Retrieve(&types.FmspcTcbInfo{Fmspc: fmspc}); err != nil {
+		return nil, retrieveRecordError(err, "tcb info")
+	}
+
+	platforms, err := db.PlatformRepository().RetrieveByFmspc(fmspc)
+	if err != nil {
+		return nil, &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+	}
+
+	summary := &TcbStatusSummary{Fmspc: fmspc, Platforms: len(platforms), TcbStatuses: make(map[string]int)}
+	for n := range platforms {
+		status, err := cachedPlatformTcbStatus(db, &platforms[n], conf)
+		if err != nil {
+			log.WithError(err).Debugf("Could not evaluate tcb status of platform with qeid %s", platforms[n].QeID)
+			summary.Unevaluated++
+			continue
+		}
+		summary.TcbStatuses[status]++
+	}
+	return summary, nil
+}
+
+// getTcbStatusSummary returns how many of the cached platforms of an fmspc are at each TCB status
+func getTcbStatusSummary(db repository.SCSDatabase, conf *config.Configuration) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.CacheManagerGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		if err := validateQueryParams(r.URL.Query(), tcbStatusSummaryRetrieveParams); err != nil {
+			slog.Errorf("resource/platform_ops: getTcbStatusSummary() %s", err.Error())
+			return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
+		}
+
+		fmspc := strings.ToLower(r.URL.Query().Get("fmspc"))
+		if !validateInputString(constants.FmspcKey, fmspc) {
+			slog.Errorf("resource/platform_ops: getTcbStatusSummary() Input validation failed for query parameter")
+			return &resourceError{Message: "invalid query param",
+				StatusCode: http.StatusBadRequest}
+		}
+
+		summary, err := summarizeFmspcTcbStatus(db, fmspc, conf)
+		if err != nil {
+			return err
+		}
+
+		js, err := json.Marshal(summary)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(js)
+		if err != nil {
+			log.WithError(err).Error("Could not write tcb status summary to response")
+		}
+		slog.Infof("%s: TCB status summary retrieved by: %s", commLogMsg.AuthorizedAccess, r.RemoteAddr)
+		return nil
+	}
+}
+
+// isTcbStatusAccepted checks whether the TCB status matched for a platform is one which
+// the configured policy treats as UpToDate. When no policy is configured,
+// UpToDate and ConfigurationNeeded are accepted
+func isTcbStatusAccepted(status string, conf *config.Configuration) bool {
+	if status == "" {
+		return false
+	}
+
+	acceptedStatuses := strings.Split(constants.DefaultAcceptedTcbStatuses, ",")
+	if conf != nil && len(conf.AcceptedTcbStatuses) > 0 {
+		acceptedStatuses = conf.AcceptedTcbStatuses
+	}
+
+	for _, acceptedStatus := range acceptedStatuses {
+		if status == acceptedStatus {
+			return true
+		}
+	}
+	return false
+}
+
+// getPckCerts returns all the PCK certificates cached for the current raw TCB level of a platform
+// along with their tcbm values, in the same format as Intel PCS getPckCerts api. This allows
+// consumers to run their own PCK certificate selection
+func getPckCerts(db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.HostDataReaderGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		if len(r.URL.Query()) < 2 {
+			return &resourceError{Message: "query data not provided",
+				StatusCode: http.StatusBadRequest}
+		}
+
+		if err := validateQueryParams(r.URL.Query(), pckCertsRetrieveParams); err != nil {
+			slog.Errorf("resource/platform_ops: getPckCerts() %s", err.Error())
+			return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
+		}
+
+		qeID := strings.ToLower(r.URL.Query().Get("qeid"))
+		pceID := strings.ToLower(r.URL.Query().Get("pceid"))
+		if !validateInputString(constants.QeIDKey, qeID) || !validateInputString(constants.PceIDKey, pceID) {
+			slog.Errorf("resource/platform_ops: getPckCerts() Input validation failed for query parameter")
+			return &resourceError{Message: "invalid query param",
+				StatusCode: http.StatusBadRequest}
+		}
+
+		scopes, err := getPlatformScopes(r, constants.HostDataReaderGroupName)
+		if err != nil {
+			return err
+		}
+
+		existingPlatformData, err := db.PlatformRepository().Retrieve(&types.Platform{QeID: qeID, PceID: pceID})
+		if err != nil {
+			return retrieveRecordError(err, "platform")
+		}
+		if err := authorizePlatformScope(r, scopes, existingPlatformData.Fmspc, existingPlatformData.PceID); err != nil {
+			return err
+		}
+
+		pckInfo := &types.PckCert{QeID: qeID, PceID: pceID, CPUSvn: existingPlatformData.CPUSvn, PceSvn: existingPlatformData.PceSvn}
+		existingPckCertData, err := db.PckCertRepository().RetrieveByTcbLevel(pckInfo)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return retrieveRecordError(err, "pck certs")
+		}
+
+		var existingPckCertChain *types.PckCertChain
+		if existingPckCertData == nil {
+			// platform is cached, but pck certs are not yet cached for its current raw tcb level, with the
+			// pre-caching model they are cached by the next refresh
+			if conf.GetCacheModel() != constants.LazyCachingModel {
+				return &resourceError{Message: "pck certs not yet cached for the platform", StatusCode: http.StatusNotFound}
+			}
+			existingPckCertData, existingPckCertChain, _, err = getLazyCachePckCert(r.Context(), db, existingPlatformData, constants.CacheRefresh, conf, client)
+			if err != nil {
+				log.WithError(err).Error("resource/platform_ops: getPckCerts() Pck Certs Retrieval failed")
+				return &resourceError{Message: err.Error(), StatusCode: pcsErrorStatusCode(err, http.StatusNotFound)}
+			}
+		} else {
+			existingPckCertChain, err = db.PckCertChainRepository().Retrieve(&types.PckCertChain{Ca: existingPlatformData.Ca})
+			if err != nil {
+				return retrieveRecordError(err, "pck cert chain")
+			}
+		}
+
+		if len(existingPckCertData.PckCerts) != len(existingPckCertData.Tcbms) {
+			slog.Errorf("resource/platform_ops: getPckCerts() cached pck certs and tcbms of platform with qeid %s do not match", qeID)
+			return &resourceError{Message: "cached pck certs and tcbms do not match", StatusCode: http.StatusInternalServerError}
+		}
+		pckCerts := make([]PckCertsInfo, len(existingPckCertData.PckCerts))
+		for i := range existingPckCertData.PckCerts {
+			tcbLevel, err := getTcbLevelFromTcbm(existingPckCertData.Tcbms[i])
+			if err != nil {
+				return &resourceError{Message: "cannot decode tcbm: " + err.Error(),
+					StatusCode: http.StatusInternalServerError}
+			}
+			pckCerts[i] = PckCertsInfo{Tcb: *tcbLevel, Tcbm: existingPckCertData.Tcbms[i], Cert: existingPckCertData.PckCerts[i]}
+		}
+
+		js, err := json.Marshal(pckCerts)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header()["Sgx-Pck-Certificate-Issuer-Chain"] = []string{pckCertChainHeader(existingPckCertChain.PckCertChain)}
+		w.Header()["Sgx-Fmspc"] = []string{existingPckCertData.Fmspc}
+		w.Header()["Sgx-Pck-Certificate-Ca-Type"] = []string{existingPlatformData.Ca}
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(js)
+		if err != nil {
+			log.WithError(err).Error("Could not write pck certs data to response")
+		}
+		slog.Infof("%s: PCK certificates retrieved by: %s", commLogMsg.AuthorizedAccess, r.RemoteAddr)
+		return nil
+	}
+}
+
+// fetchRegistrationStatus queries PCS for the pck certs of a platform using its encrypted ppid.
+// PCS responds with 404 for a platform which is not yet registered with Intel, any other
+// failure is returned as a PcsError
+func fetchRegistrationStatus(ctx context.Context, encPpid, pceID string, conf *config.Configuration, client *domain.HttpClient) (string, error) {
+	resp, err := getPckCertFromProvServer(ctx, encPpid, pceID, conf, client)
+	if resp != nil {
+		defer func() {
+			derr := resp.Body.Close()
+			if derr != nil {
+				log.WithError(derr).Error("Error closing pckcerts response body")
+			}
+		}()
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "fetchRegistrationStatus: pckcerts call to PCS failed")
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return constants.RegistrationStatusRegistered, nil
+	case http.StatusNotFound:
+		return constants.RegistrationStatusUnregistered, nil
+	}
+	return "", newPcsError("pckcerts", resp)
+}
+
+// getRegistrationStatus reports whether a platform is already registered with Intel, so that
+// manifest enrollment of an already registered multi-package platform can be avoided.
+// A cached platform is looked up with qeid and pceid, an uncached one with encrypted_ppid and pceid
+func getRegistrationStatus(db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.HostDataReaderGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		if len(r.URL.Query()) != 2 {
+			return &resourceError{Message: "either qeid or encrypted_ppid must be provided along with pceid",
+				StatusCode: http.StatusBadRequest}
+		}
+
+		if err := validateQueryParams(r.URL.Query(), registrationStatusRetrieveParams); err != nil {
+			slog.Errorf("resource/platform_ops: getRegistrationStatus() %s", err.Error())
+			return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
+		}
+
+		pceID := strings.ToLower(r.URL.Query().Get("pceid"))
+		if !validateInputString(constants.PceIDKey, pceID) {
+			slog.Errorf("resource/platform_ops: getRegistrationStatus() Input validation failed for query parameter")
+			return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
+		}
+
+		// fmspc of a platform which is not cached is not known, so only its pceid is matched against the scopes
+		scopes, err := getPlatformScopes(r, constants.HostDataReaderGroupName)
+		if err != nil {
+			return err
+		}
+		if err := authorizePlatformScope(r, scopes, "", pceID); err != nil {
+			return err
+		}
+
+		regStatus := RegistrationStatus{PceID: pceID}
+		encPpid := strings.ToLower(r.URL.Query().Get("encrypted_ppid"))
+		if encPpid != "" {
+			if !validateInputString(constants.EncPPIDKey, encPpid) {
+				slog.Errorf("resource/platform_ops: getRegistrationStatus() Input validation failed for query parameter")
+				return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
+			}
+		} else {
+			qeID := strings.ToLower(r.URL.Query().Get("qeid"))
+			if !validateInputString(constants.QeIDKey, qeID) {
+				slog.Errorf("resource/platform_ops: getRegistrationStatus() Input validation failed for query parameter")
+				return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
+			}
+			regStatus.QeID = qeID
+
+			existingPlatformData, err := db.PlatformRepository().Retrieve(&types.Platform{QeID: qeID, PceID: pceID})
+			if err != nil {
+				return retrieveRecordError(err, "platform")
+			}
+			if err := authorizePlatformScope(r, scopes, existingPlatformData.Fmspc, pceID); err != nil {
+				return err
+			}
+
+			// pck certs are cached only after PCS has returned them, so the platform is registered
+			existingPckCertData, _ := db.PckCertRepository().Retrieve(&types.PckCert{QeID: qeID, PceID: pceID})
+			if existingPckCertData != nil {
+				regStatus.Status = constants.RegistrationStatusRegistered
+				regStatus.Source = constants.RegistrationSourceCache
+			} else if existingPlatformData.Encppid == "" {
+				// only a manifest is known for the platform, PCS cannot be queried without encrypted ppid
+				regStatus.Status = constants.RegistrationStatusUnknown
+				regStatus.Source = constants.RegistrationSourceCache
+			}
+			encPpid = existingPlatformData.Encppid
+		}
+
+		if regStatus.Status == "" {
+			regStatus.Status, err = fetchRegistrationStatus(r.Context(), encPpid, pceID, conf, client)
+			if err != nil {
+				log.WithError(err).Error("resource/platform_ops: getRegistrationStatus() Registration status retrieval failed")
+				return &resourceError{Message: err.Error(), StatusCode: pcsErrorStatusCode(err, http.StatusInternalServerError)}
+			}
+			regStatus.Source = constants.RegistrationSourcePcs
+		}
+
+		js, err := json.Marshal(regStatus)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(js)
+		if err != nil {
+			log.WithError(err).Error("Could not write registration status to response")
+		}
+		slog.Infof("%s: Platform registration status retrieved by: %s", commLogMsg.AuthorizedAccess, r.RemoteAddr)
+		return nil
+	}
+}
+
+// pageFromQuery reads limit and offset query params of a paginated api, limit defaults to
+// DefaultPlatformsPageLimit and cannot exceed MaxPlatformsPageLimit
+func pageFromQuery(query url.Values) (types.Pagination, error) {
+	page := types.Pagination{Limit: constants.DefaultPlatformsPageLimit}
+	var err error
+	if limit := query.Get("limit"); limit != "" {
+		page.Limit, err = strconv.Atoi(limit)
+		if err != nil || page.Limit <= 0 || page.Limit > constants.MaxPlatformsPageLimit {
+			return page, errors.Errorf("limit should be between 1 and %d", constants.MaxPlatformsPageLimit)
+		}
+	}
+	if offset := query.Get("offset"); offset != "" {
+		page.Offset, err = strconv.Atoi(offset)
+		if err != nil || page.Offset < 0 {
+			return page, errors.New("offset should not be negative")
+		}
+	}
+	return page, nil
+}
+
+// getPlatforms lists a page of the cached platforms with a given pceid, to correlate issues
+// with PCE firmware rollouts
+func getPlatforms(db repository.SCSDatabase, conf *config.Configuration) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.HostDataReaderGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		if len(r.URL.Query()) == 0 {
+			return &resourceError{Message: "query data not provided",
+				StatusCode: http.StatusBadRequest}
+		}
+
+		if err := validateQueryParams(r.URL.Query(), platformsRetrieveParams); err != nil {
+			slog.Errorf("resource/platform_ops: getPlatforms() %s", err.Error())
+			return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
+		}
+
+		pceID := strings.ToLower(r.URL.Query().Get("pceid"))
+		if !validateInputString(constants.PceIDKey, pceID) {
+			slog.Errorf("resource/platform_ops: getPlatforms() Input validation failed for query parameter")
+			return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
+		}
+
+		scopes, err := getPlatformScopes(r, constants.HostDataReaderGroupName)
+		if err != nil {
+			return err
+		}
+		if err := authorizePlatformScope(r, scopes, "", pceID); err != nil {
+			return err
+		}
+
+		page, err := pageFromQuery(r.URL.Query())
+		if err != nil {
+			slog.Errorf("resource/platform_ops: getPlatforms() %s", err.Error())
+			return &resourceError{Message: "invalid query param: " + err.Error(), StatusCode: http.StatusBadRequest}
+		}
+
+		platforms, err := db.PlatformRepository().RetrieveByPceID(pceID, page)
+		if err != nil {
+			return &resourceError{Message: "cannot retrieve platforms: " + err.Error(),
+				StatusCode: http.StatusInternalServerError}
+		}
+
+		// platforms with fmspcs out of scope are left out of the page
+		summaries := []PlatformSummary{}
+		for _, platform := range platforms {
+			if !scopes.allows(platform.Fmspc, platform.PceID) {
+				continue
+			}
+			summary := PlatformSummary{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn,
+				PceSvn: platform.PceSvn, Fmspc: platform.Fmspc, Ca: platform.Ca, UpdatedTime: platform.UpdatedTime}
+			if !platform.LastAccessedTime.IsZero() {
+				lastAccessedTime := platform.LastAccessedTime
+				summary.LastAccessedTime = &lastAccessedTime
+			}
+			setPckCertExpiry(db, &summary, conf)
+			summaries = append(summaries, summary)
+		}
+
+		js, err := json.Marshal(summaries)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(js)
+		if err != nil {
+			log.WithError(err).Error("Could not write platforms to response")
+		}
+		slog.Infof("%s: Platforms retrieved by: %s", commLogMsg.AuthorizedAccess, r.RemoteAddr)
+		return nil
+	}
+}
+
+// retrievePlatform validates the qeid of a request for a platform, given as path variable or query
+// param, along with the optional pceid query param, and retrieves the platform if it is within the
+// platform scopes of the role
+func retrievePlatform(r *http.Request, db repository.SCSDatabase, role string) (*types.Platform, error) {
+	if err := validateQueryParams(r.URL.Query(), platformRetrieveParams); err != nil {
+		slog.Errorf("resource/platform_ops: retrievePlatform() %s", err.Error())
+		return nil, &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
+	}
+
+	qeID := strings.ToLower(mux.Vars(r)["qeid"])
+	pceID := strings.ToLower(r.URL.Query().Get("pceid"))
+	if !validateInputString(constants.QeIDKey, qeID) || (pceID != "" && !validateInputString(constants.PceIDKey, pceID)) {
+		slog.Errorf("resource/platform_ops: retrievePlatform() Input validation failed for qeid or pceid")
+		return nil, &resourceError{Message: "invalid qeid or pceid", StatusCode: http.StatusBadRequest}
+	}
+
+	scopes, err := getPlatformScopes(r, role)
+	if err != nil {
+		return nil, err
+	}
+	platform, err := db.PlatformRepository().Retrieve(&types.Platform{QeID: qeID, PceID: pceID})
+	if err != nil {
+		return nil, retrieveRecordError(err, "platform")
+	}
+	if err := authorizePlatformScope(r, scopes, platform.Fmspc, platform.PceID); err != nil {
+		return nil, err
+	}
+	return platform, nil
+}
+
+// getPlatform returns the summary of a cached platform, as GET /platforms/{qeid} or GET /platforms?qeid=
+func getPlatform(db repository.SCSDatabase, conf *config.Configuration) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.HostDataReaderGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		platform, err := retrievePlatform(r, db, constants.HostDataReaderGroupName)
+		if err != nil {
+			return err
+		}
+
+		summary := PlatformSummary{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn,
+			PceSvn: platform.PceSvn, Fmspc: platform.Fmspc, Ca: platform.Ca, UpdatedTime: platform.UpdatedTime}
+		if !platform.LastAccessedTime.IsZero() {
+			summary.LastAccessedTime = &platform.LastAccessedTime
+		}
+		setPckCertExpiry(db, &summary, conf)
+		js, err := json.Marshal(summary)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(js)
+		if err != nil {
+			log.WithError(err).Error("Could not write platform to response")
+		}
+		slog.Infof("%s: Platform retrieved by: %s", commLogMsg.AuthorizedAccess, r.RemoteAddr)
+		return nil
+	}
+}
+
+// deletePlatformInfo deletes a cached platform along with its pck certs and platform tcb, as
+// DELETE /platforms/{qeid} or DELETE /platforms?qeid=. Collaterals shared with other platforms are kept
+func deletePlatformInfo(db repository.SCSDatabase) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.CacheManagerGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		platform, err := retrievePlatform(r, db, constants.CacheManagerGroupName)
+		if err != nil {
+			return err
+		}
+
+		err = db.ExecuteInTransaction(func(tx repository.SCSDatabase) error {
+			return deletePlatform(tx, platform)
+		})
+		if err != nil {
+			log.WithError(err).Errorf("Could not delete platform with qeid %s", platform.QeID)
+			return &resourceError{Message: "cannot delete platform: " + err.Error(),
+				StatusCode: http.StatusInternalServerError}
+		}
+
+		res := Response{Status: "Success", Message: "platform with qeid " + platform.QeID + " is deleted"}
+		js, err := json.Marshal(res)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(js)
+		if err != nil {
+			log.WithError(err).Error("Could not write platform deletion response")
+		}
+		slog.Infof("%s: Platform with qeid %s deleted by: %s", commLogMsg.AuthorizedAccess, platform.QeID, r.RemoteAddr)
+		return nil
+	}
+}
+
+// getPckCrls lists a page of the cached PCK CRLs ordered by ca type, so that freshness of the
+// CRLs can be audited. The base64 encoded CRL is included only when verbose is set
+func getPckCrls(db repository.SCSDatabase) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.HostDataReaderGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		if err := validateQueryParams(r.URL.Query(), pckCrlsRetrieveParams); err != nil {
+			slog.Errorf("resource/platform_ops: getPckCrls() %s", err.Error())
+			return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
+		}
+
+		verbose := false
+		if v := r.URL.Query().Get("verbose"); v != "" {
+			verbose, err = strconv.ParseBool(v)
+			if err != nil {
+				slog.Errorf("resource/platform_ops: getPckCrls() Input validation failed for query parameter")
+				return &resourceError{Message: "invalid query param: verbose should be true or false", StatusCode: http.StatusBadRequest}
+			}
+		}
+
+		page, err := pageFromQuery(r.URL.Query())
+		if err != nil {
+			slog.Errorf("resource/platform_ops: getPckCrls() %s", err.Error())
+			return &resourceError{Message: "invalid query param: " + err.Error(), StatusCode: http.StatusBadRequest}
+		}
+
+		pckCrls, err := db.PckCrlRepository().RetrievePaginated(page)
+		if err != nil {
+			return &resourceError{Message: "cannot retrieve pck crls: " + err.Error(),
+				StatusCode: http.StatusInternalServerError}
+		}
+
+		summaries := make([]PckCrlSummary, len(pckCrls))
+		for i := range pckCrls {
+			pckCrl := &pckCrls[i]
+			summaries[i] = PckCrlSummary{Ca: pckCrl.Ca, IssuerChainPresent: pckCrl.PckCrlCertChain != "",
+				Expired: isPckCrlExpired(pckCrl), UpdatedTime: pckCrl.UpdatedTime}
+			if !pckCrl.ThisUpdate.IsZero() {
+				summaries[i].ThisUpdate = &pckCrl.ThisUpdate
+			}
+			if !pckCrl.NextUpdate.IsZero() {
+				summaries[i].NextUpdate = &pckCrl.NextUpdate
+			}
+			if verbose {
+				summaries[i].PckCrl = pckCrl.PckCrl
+			}
+		}
+
+		js, err := json.Marshal(summaries)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(js)
+		if err != nil {
+			log.WithError(err).Error("Could not write pck crls to response")
+		}
+		slog.Infof("%s: PCK CRLs retrieved by: %s", commLogMsg.AuthorizedAccess, r.RemoteAddr)
+		return nil
+	}
+}
+
+// pckCertSerialNumber parses a PEM encoded pck certificate, as cached in pck_certs table,
+// and returns its issuer and serial number
+func pckCertSerialNumber(pckCert string) (string, string, error) {
+	block, _ := pem.Decode([]byte(pckCert))
+	if block == nil {
+		return "", "", errors.New("failed to decode pck certificate")
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to parse pck certificate")
+	}
+	return certificate.Issuer.String(), certificate.SerialNumber.String(), nil
+}
+
+// pckCertNotAfter parses a PEM encoded pck certificate, as cached in pck_certs table, and returns its expiry
+func pckCertNotAfter(pckCert string) (time.Time, error) {
+	block, _ := pem.Decode([]byte(pckCert))
+	if block == nil {
+		return time.Time{}, errors.New("failed to decode pck certificate")
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed to parse pck certificate")
+	}
+	return certificate.NotAfter.UTC(), nil
+}
+
+// setPckCertNotAfter stores the expiry of the selected pck cert along with the selection. A pck cert whose
+// expiry cannot be parsed is cached without it, and is not reported as expiring
+func setPckCertNotAfter(pckCert *types.PckCert) {
+	notAfter, err := pckCertNotAfter(pckCert.PckCerts[pckCert.CertIndex])
+	if err != nil {
+		log.WithError(err).Warnf("Could not read expiry of pck cert selected for platform with qeid %s", pckCert.QeID)
+	}
+	pckCert.NotAfter = notAfter
+}
+
+// pckCertExpiryWarningTime is the time before which the selected pck certs are reported as expiring
+func pckCertExpiryWarningTime(conf *config.Configuration) time.Time {
+	days := constants.DefaultPckCertExpiryWarnDays
+	if conf != nil && conf.PckCertExpiryWarningDays > 0 {
+		days = conf.PckCertExpiryWarningDays
+	}
+	return clock.Now().UTC().AddDate(0, 0, days)
+}
+
+// isPckCertExpiring checks if the selected pck cert has expired or expires within the configured warning
+// days. Pck certs cached before their expiry was stored are not reported as expiring
+func isPckCertExpiring(pckCert *types.PckCert, conf *config.Configuration) bool {
+	if pckCert.NotAfter.IsZero() {
+		return false
+	}
+	return pckCert.NotAfter.Before(pckCertExpiryWarningTime(conf))
+}
+
+// setPckCertExpiry sets the expiry of the pck cert selected for the platform of a summary, it is left
+// out when the pck cert is not cached or its expiry is not known
+func setPckCertExpiry(db repository.SCSDatabase, summary *PlatformSummary, conf *config.Configuration) {
+	pckCert, err := db.PckCertRepository().RetrieveByTcbLevel(&types.PckCert{QeID: summary.QeID, PceID: summary.PceID,
+		CPUSvn: summary.CPUSvn, PceSvn: summary.PceSvn})
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			log.WithError(err).Warnf("Could not retrieve pck cert of platform with qeid %s", summary.QeID)
+		}
+		return
+	}
+	if pckCert.NotAfter.IsZero() {
+		return
+	}
+	days := int(math.Floor(pckCert.NotAfter.Sub(clock.Now()).Hours() / 24))
+	summary.PckCertDaysToExpiry = &days
+	summary.PckCertExpiring = isPckCertExpiring(pckCert, conf)
+}
+
+// parsePemCertificates parses all the certificates of a PEM encoded certificate chain
+func parsePemCertificates(pemCerts string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(pemCerts)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse certificate")
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+	return certs, nil
+}
+
+// maxPckCertChainEscapes limits the url escapings undone on a cached PCK cert issuer chain
+const maxPckCertChainEscapes = 3
+
+// normalizePckCertChain returns a PCK cert issuer chain as clean PEM, ordered from the certificate
+// issuing the pck cert up to the root. The chain is cached as returned by Intel PCS server, which
+// url escapes it, but may also have been cached as plain PEM by a PCCS or escaped more than once
+func normalizePckCertChain(pckCertChain string) (string, error) {
+	chain := strings.TrimSpace(pckCertChain)
+	for i := 0; !strings.Contains(chain, "-----BEGIN CERTIFICATE-----"); i++ {
+		if i == maxPckCertChainEscapes {
+			return "", errors.New("pck certificate issuer chain is not PEM encoded")
+		}
+		var err error
+		// a space is escaped as + by url.QueryEscape, + of base64 is then escaped as %2B
+		if strings.Contains(chain, "BEGIN+CERTIFICATE") {
+			chain, err = url.QueryUnescape(chain)
+		} else {
+			chain, err = url.PathUnescape(chain)
+		}
+		if err != nil {
+			return "", errors.Wrap(err, "failed to unescape pck certificate issuer chain")
+		}
+	}
+	certs, err := parsePemCertificates(chain)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid pck certificate issuer chain")
+	}
+
+	// the chain starts at the certificate which has not issued any other certificate of the chain
+	var current *x509.Certificate
+	for _, cert := range certs {
+		issuesOther := false
+		for _, other := range certs {
+			if other != cert && isIssuedBy(other, cert) {
+				issuesOther = true
+				break
+			}
+		}
+		if !issuesOther {
+			current = cert
+			break
+		}
+	}
+	ordered := make([]*x509.Certificate, 0, len(certs))
+	for current != nil && len(ordered) < len(certs) {
+		ordered = append(ordered, current)
+		var issuer *x509.Certificate
+		for _, cert := range certs {
+			if cert != current && isIssuedBy(current, cert) {
+				issuer = cert
+				break
+			}
+		}
+		current = issuer
+	}
+	if len(ordered) != len(certs) {
+		return "", errors.New("pck certificate issuer chain is not a single chain")
+	}
+
+	var normalized strings.Builder
+	for _, cert := range ordered {
+		normalized.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	}
+	return normalized.String(), nil
+}
+
+// isIssuedBy checks whether cert is signed by issuer
+func isIssuedBy(cert, issuer *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, issuer.RawSubject) && cert.CheckSignatureFrom(issuer) == nil
+}
+
+// pckCertChainHeader returns the PCK cert issuer chain to be served in the
+// Sgx-Pck-Certificate-Issuer-Chain header. The normalized chain is percent encoded the way Intel
+// PCS server does, which the clients expect and which keeps the line breaks of PEM out of the
+// header. A chain which cannot be normalized is served as cached
+func pckCertChainHeader(pckCertChain string) string {
+	chain, err := normalizePckCertChain(pckCertChain)
+	if err != nil {
+		log.WithError(err).Warn("Could not normalize cached pck certificate issuer chain")
+		return pckCertChain
+	}
+	return strings.ReplaceAll(url.QueryEscape(chain), "+", "%20")
+}
+
+// LoadSgxRootCA reads the PEM encoded SGX root CA certificate which is trusted as the anchor
+// of PCK certificate issuer chains
+func LoadSgxRootCA(rootCAFile string) (*x509.Certificate, error) {
+	pemCert, err := ioutil.ReadFile(rootCAFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read sgx root ca file")
+	}
+	certs, err := parsePemCertificates(string(pemCert))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse sgx root ca file")
+	}
+	if len(certs) != 1 || !certs[0].IsCA {
+		return nil, errors.New("sgx root ca file should contain a single CA certificate")
+	}
+	return certs[0], nil
+}
+
+// sgxRootCA is the trust anchor at which the issuer chains of collaterals returned by Intel PCS
+// server and the root ca CRL should end, it is the Intel SGX Root CA unless SetSgxRootCA sets another
+var sgxRootCA = parseIntelSgxRootCA()
+
+func parseIntelSgxRootCA() *x509.Certificate {
+	certs, err := parsePemCertificates(constants.IntelSgxRootCA)
+	if err != nil {
+		panic(errors.Wrap(err, "failed to parse Intel SGX Root CA"))
+	}
+	return certs[0]
+}
+
+// SetSgxRootCA loads the SGX root CA configured as trust anchor once at startup, the Intel SGX Root
+// CA is trusted when rootCAFile is empty. It has to be called before any collateral is verified
+func SetSgxRootCA(rootCAFile string) error {
+	if rootCAFile == "" {
+		sgxRootCA = parseIntelSgxRootCA()
+		return nil
+	}
+	rootCA, err := LoadSgxRootCA(rootCAFile)
+	if err != nil {
+		return err
+	}
+	sgxRootCA = rootCA
+	return nil
+}
+
+// verifyPckCertChain verifies that the PEM encoded pck certificate chains up to the url escaped
+// issuer chain returned by Intel PCS server, and that the issuer chain ends at the SGX root CA
+// trusted as anchor, so that a corrupted or spoofed chain is caught
+func verifyPckCertChain(pckCert, pckCertChain string) error {
+	leaf, err := parsePemCertificates(pckCert)
+	if err != nil {
+		return errors.Wrap(err, "invalid pck certificate")
+	}
+	chain, err := url.QueryUnescape(pckCertChain)
+	if err != nil {
+		return errors.Wrap(err, "failed to unescape pck certificate issuer chain")
+	}
+	issuers, err := parsePemCertificates(chain)
+	if err != nil {
+		return errors.Wrap(err, "invalid pck certificate issuer chain")
+	}
+
+	err = verifyIssuerChain(leaf[0], issuers)
+	if err != nil {
+		return errors.Wrap(err, "pck certificate could not be verified against the issuer chain")
+	}
+	return nil
+}
+
+// verifyIssuerChain verifies that a certificate chains up to the issuers, and that the issuers end
+// at the SGX root CA trusted as anchor. The self signed root of the issuers is not trusted, as a
+// spoofed chain would carry its own
+func verifyIssuerChain(cert *x509.Certificate, issuers []*x509.Certificate) error {
+	roots := x509.NewCertPool()
+	roots.AddCert(sgxRootCA)
+	intermediates := x509.NewCertPool()
+	for _, issuer := range issuers {
+		if bytes.Equal(issuer.RawIssuer, issuer.RawSubject) && issuer.CheckSignatureFrom(issuer) == nil {
+			continue
+		}
+		intermediates.AddCert(issuer)
+	}
+
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   clock.Now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err
+}
+
+// signedPcsBody returns the signed object of a TcbInfo or QE identity response of Intel PCS server,
+// which is the value of field exactly as it appears in the response, along with its decoded
+// signature. The object is kept as raw bytes, since a re-marshaled object would not necessarily
+// have the field ordering and formatting of the bytes Intel has signed
+func signedPcsBody(body []byte, field string) (json.RawMessage, []byte, error) {
+	var signedBody map[string]json.RawMessage
+	err := json.Unmarshal(body, &signedBody)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to decode signed body")
+	}
+	signedObject, ok := signedBody[field]
+	if !ok || len(signedObject) == 0 {
+		return nil, nil, errors.Errorf("signed body does not have %s", field)
+	}
+	var encodedSignature string
+	err = json.Unmarshal(signedBody["signature"], &encodedSignature)
+	if err != nil {
+		return nil, nil, errors.New("signed body does not have a signature")
+	}
+	// the signature is the concatenation of r and s, each of the size of the curve order
+	signature, err := hex.DecodeString(encodedSignature)
+	if err != nil || len(signature) == 0 || len(signature)%2 != 0 {
+		return nil, nil, errors.New("invalid signature encoding")
+	}
+	return signedObject, signature, nil
+}
+
+// verifyPcsSignature verifies the ECDSA signature of a signed response of Intel PCS server. The
+// signature is over the json of field exactly as it appears in the response, by the signing
+// certificate which is the first certificate of the url escaped issuer chain
+func verifyPcsSignature(body []byte, field, issuerChain, collateral string) error {
+	chain, err := url.QueryUnescape(issuerChain)
+	if err != nil {
+		return errors.Wrapf(err, "failed to unescape %s issuer chain", collateral)
+	}
+	issuers, err := parsePemCertificates(chain)
+	if err != nil {
+		return errors.Wrapf(err, "invalid %s issuer chain", collateral)
+	}
+	signingCert := issuers[0]
+	err = verifyIssuerChain(signingCert, issuers[1:])
+	if err != nil {
+		return errors.Wrapf(err, "%s signing certificate could not be verified against the issuer chain", collateral)
+	}
+	publicKey, ok := signingCert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.Errorf("%s signing certificate does not have an ECDSA public key", collateral)
+	}
+
+	signedObject, signature, err := signedPcsBody(body, field)
+	if err != nil {
+		return errors.Wrapf(err, "invalid signed %s", collateral)
+	}
+	r := new(big.Int).SetBytes(signature[:len(signature)/2])
+	s := new(big.Int).SetBytes(signature[len(signature)/2:])
+	digest := sha256.Sum256(signedObject)
+	if !ecdsa.Verify(publicKey, digest[:], r, s) {
+		return errors.Errorf("%s signature verification failed", collateral)
+	}
+	return nil
+}
+
+// verifyPckCrlSignature verifies that the PCK CRL is signed by the first certificate of its url
+// escaped issuer chain, and that the certificate chains up to the rest of the issuer chain
+func verifyPckCrlSignature(crl *pkix.CertificateList, issuerChain string) error {
+	chain, err := url.QueryUnescape(issuerChain)
+	if err != nil {
+		return errors.Wrap(err, "failed to unescape pck crl issuer chain")
+	}
+	issuers, err := parsePemCertificates(chain)
+	if err != nil {
+		return errors.Wrap(err, "invalid pck crl issuer chain")
+	}
+	err = verifyIssuerChain(issuers[0], issuers[1:])
+	if err != nil {
+		return errors.Wrap(err, "pck crl issuer could not be verified against the issuer chain")
+	}
+	err = issuers[0].CheckCRLSignature(crl)
+	if err != nil {
+		return errors.Wrap(err, "pck crl signature verification failed")
+	}
+	return nil
+}
+
+// checkSignature runs the verification of a collateral returned by Intel PCS server as per the
+// signature verification policy. A failed verification is returned with enforce, and is only
+// logged with warn, the default, so that operators see what enforce would reject before adopting it
+func checkSignature(conf *config.Configuration, collateral string, verify func() error) error {
+	policy := constants.SignatureVerificationWarn
+	if conf != nil && conf.SignatureVerificationPolicy != "" {
+		policy = conf.SignatureVerificationPolicy
+	}
+	if policy == constants.SignatureVerificationOff {
+		return nil
+	}
+	err := verify()
+	if err == nil {
+		return nil
+	}
+	if policy == constants.SignatureVerificationEnforce {
+		log.WithError(err).Errorf("failed to verify %s", collateral)
+		return err
+	}
+	log.WithError(err).Warnf("failed to verify %s, caching it as signature verification policy is %s", collateral, policy)
+	return nil
+}
+
+// verifyQeIdentitySignature verifies the signature of the enclaveIdentity of a QE identity response
+func verifyQeIdentitySignature(qeIdentity []byte, issuerChain string) error {
+	return verifyPcsSignature(qeIdentity, "enclaveIdentity", issuerChain, "qe identity")
+}
+
+// verifyTcbInfoSignature verifies the signature of the tcbInfo of a TcbInfo response
+func verifyTcbInfoSignature(tcbInfo []byte, issuerChain string) error {
+	return verifyPcsSignature(tcbInfo, "tcbInfo", issuerChain, "tcb info")
+}
+
+// revokedPlatforms cross references the serial numbers revoked by the cached PCK CRL of a ca
+// against the cached pck certs, and returns the platforms having at least one revoked pck cert.
+// Only pck certs issued by the CRL issuer are considered, as serial numbers are unique per issuer.
+// Platforms out of the scopes are left out
+func revokedPlatforms(db repository.SCSDatabase, ca string, scopes platformScopes) ([]RevokedPlatform, error) {
+	existingPckCrl, err := db.PckCrlRepository().Retrieve(&types.PckCrl{Ca: ca})
+	if err != nil {
+		return nil, retrieveRecordError(err, "pck crl")
+	}
+
+	crlDer, err := base64.StdEncoding.DecodeString(existingPckCrl.PckCrl)
+	if err != nil {
+		return nil, &resourceError{Message: "cannot decode pck crl: " + err.Error(),
+			StatusCode: http.StatusInternalServerError}
+	}
+	crl, err := x509.ParseDERCRL(crlDer)
+	if err != nil {
+		return nil, &resourceError{Message: "cannot parse pck crl: " + err.Error(),
+			StatusCode: http.StatusInternalServerError}
+	}
+
+	var crlIssuer pkix.Name
+	crlIssuer.FillFromRDNSequence(&crl.TBSCertList.Issuer)
+	revokedSerials := make(map[string]bool)
+	for _, revoked := range crl.TBSCertList.RevokedCertificates {
+		revokedSerials[revoked.SerialNumber.String()] = true
+	}
+
+	platforms := []RevokedPlatform{}
+	if len(revokedSerials) == 0 {
+		return platforms, nil
+	}
+
+	pckCerts, err := db.PckCertRepository().RetrieveAll()
+	if err != nil {
+		return nil, &resourceError{Message: "cannot retrieve pck certs: " + err.Error(),
+			StatusCode: http.StatusInternalServerError}
+	}
+
+	found := make(map[RevokedPlatform]bool)
+	for _, pckCert := range pckCerts {
+		platform := RevokedPlatform{QeID: pckCert.QeID, PceID: pckCert.PceID}
+		if found[platform] || !scopes.allows(pckCert.Fmspc, pckCert.PceID) {
+			continue
+		}
+		for _, cert := range pckCert.PckCerts {
+			issuer, serial, err := pckCertSerialNumber(cert)
+			if err != nil {
+				log.WithError(err).Warnf("Skipping pck cert of platform with qeid %s", pckCert.QeID)
+				continue
+			}
+			if issuer == crlIssuer.String() && revokedSerials[serial] {
+				found[platform] = true
+				platforms = append(platforms, platform)
+				break
+			}
+		}
+	}
+	return platforms, nil
+}
+
+// getRevokedPlatforms lists the cached platforms whose pck certs are revoked by the cached PCK CRL
+// of a ca, so that the impact of a CRL update can be assessed
+func getRevokedPlatforms(db repository.SCSDatabase) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.HostDataReaderGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		if len(r.URL.Query()) == 0 {
+			return &resourceError{Message: "query data not provided",
+				StatusCode: http.StatusBadRequest}
+		}
+
+		if err := validateQueryParams(r.URL.Query(), revokedPlatformsRetrieveParams); err != nil {
+			slog.Errorf("resource/platform_ops: getRevokedPlatforms() %s", err.Error())
+			return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
+		}
+
+		ca := strings.ToLower(r.URL.Query().Get("ca"))
+		if !validateInputString(constants.CaKey, ca) {
+			slog.Errorf("resource/platform_ops: getRevokedPlatforms() Input validation failed for query parameter")
+			return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
+		}
+
+		scopes, err := getPlatformScopes(r, constants.HostDataReaderGroupName)
+		if err != nil {
+			return err
+		}
+
+		platforms, err := revokedPlatforms(db, ca, scopes)
+		if err != nil {
+			return err
+		}
+
+		js, err := json.Marshal(platforms)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(js)
+		if err != nil {
+			log.WithError(err).Error("Could not write revoked platforms to response")
+		}
+		slog.Infof("%s: Revoked platforms retrieved by: %s", commLogMsg.AuthorizedAccess, r.RemoteAddr)
+		return nil
+	}
+}
+
+// tcbm (raw tcb level) is 18 byte array with first 16 bytes for cpusvn and
+// next 2 bytes for pcesvn, each cpusvn byte is the svn of a tcb component
+func getTcbLevelFromTcbm(tcbm string) (*TcbLevels, error) {
+	tcbmBytes, err := hex.DecodeString(tcbm)
+	if err != nil {
+		return nil, err
+	}
+	if len(tcbmBytes) != 18 {
+		return nil, errors.New("invalid tcbm length")
+	}
+
+	c := tcbmBytes[:16]
+	return &TcbLevels{
+		SgxTcbComp01Svn: c[0], SgxTcbComp02Svn: c[1], SgxTcbComp03Svn: c[2], SgxTcbComp04Svn: c[3],
+		SgxTcbComp05Svn: c[4], SgxTcbComp06Svn: c[5], SgxTcbComp07Svn: c[6], SgxTcbComp08Svn: c[7],
+		SgxTcbComp09Svn: c[8], SgxTcbComp10Svn: c[9], SgxTcbComp11Svn: c[10], SgxTcbComp12Svn: c[11],
+		SgxTcbComp13Svn: c[12], SgxTcbComp14Svn: c[13], SgxTcbComp15Svn: c[14], SgxTcbComp16Svn: c[15],
+		PceSvn: binary.LittleEndian.Uint16(tcbmBytes[16:]),
+	}, nil
+}
+
 // Function to get PPID from provided PCK Certificate.
 // PCK Certficate has customised extensions. These extensions contain sgx platform information.
 // Following function decodes the PCK Certificate. It parses these extensions
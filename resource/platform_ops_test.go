@@ -6,17 +6,37 @@ package resource
 
 import (
 	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
-	"intel/isecl/lib/common/v5/context"
+	"encoding/pem"
+	"fmt"
+	commContext "intel/isecl/lib/common/v5/context"
 	"intel/isecl/lib/common/v5/types/aas"
 	"intel/isecl/scs/v5/config"
 	"intel/isecl/scs/v5/constants"
 	"intel/isecl/scs/v5/domain"
 	"intel/isecl/scs/v5/domain/mocks"
 	"intel/isecl/scs/v5/repository"
+	"intel/isecl/scs/v5/repository/memory"
 	"intel/isecl/scs/v5/types"
+	"io/ioutil"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -24,6 +44,7 @@ import (
 	consts "github.com/intel-secl/intel-secl/v5/pkg/lib/common/constants"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -60,7 +81,7 @@ var _ = Describe("PlatformInfo Validation", func() {
 
 				// valid permissions added insufficient roles.
 				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataUpdaterGroupName}}
-				req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
 				router.ServeHTTP(w, req)
@@ -75,7 +96,7 @@ var _ = Describe("PlatformInfo Validation", func() {
 				Expect(err).NotTo(HaveOccurred())
 
 				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: "HostDataUpdaterGroupName", Context: "type=SCS"}}
-				req = context.SetUserRoles(req, roleInfo)
+				req = commContext.SetUserRoles(req, roleInfo)
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
 				router.ServeHTTP(w, req)
@@ -91,9 +112,9 @@ var _ = Describe("PlatformInfo Validation", func() {
 
 				// valid permissions and userroles added.
 				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataUpdaterGroupName}}
-				req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
 				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataUpdaterGroupName, Context: "type=SCS"}}
-				req = context.SetUserRoles(req, roleInfo)
+				req = commContext.SetUserRoles(req, roleInfo)
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
 				router.ServeHTTP(w, req)
@@ -133,10 +154,10 @@ var _ = Describe("PlatformInfo Validation", func() {
 
 				// valid permissions and userroles added.
 				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataUpdaterGroupName}}
-				req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
 				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataUpdaterGroupName, Context: "type=SCS"}}
-				req = context.SetUserRoles(req, roleInfo)
-				req = context.SetTokenSubject(req, platformInfo.HwUUID)
+				req = commContext.SetUserRoles(req, roleInfo)
+				req = commContext.SetTokenSubject(req, platformInfo.HwUUID)
 
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
@@ -178,10 +199,10 @@ var _ = Describe("PlatformInfo Validation", func() {
 
 				// valid permissions and userroles added.
 				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataUpdaterGroupName}}
-				req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
 				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataUpdaterGroupName, Context: "type=SCS"}}
-				req = context.SetUserRoles(req, roleInfo)
-				req = context.SetTokenSubject(req, platformInfo.HwUUID)
+				req = commContext.SetUserRoles(req, roleInfo)
+				req = commContext.SetTokenSubject(req, platformInfo.HwUUID)
 
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
@@ -200,9 +221,9 @@ var _ = Describe("PlatformInfo Validation", func() {
 
 				// valid permissions and userroles added.
 				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataUpdaterGroupName}}
-				req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
 				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataUpdaterGroupName, Context: "type=SCS"}}
-				req = context.SetUserRoles(req, roleInfo)
+				req = commContext.SetUserRoles(req, roleInfo)
 
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
@@ -230,10 +251,10 @@ var _ = Describe("PlatformInfo Validation", func() {
 
 				// valid permissions and userroles added.
 				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataUpdaterGroupName}}
-				req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
 				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataUpdaterGroupName, Context: "type=SCS"}}
-				req = context.SetUserRoles(req, roleInfo)
-				req = context.SetTokenSubject(req, platformInfo.HwUUID)
+				req = commContext.SetUserRoles(req, roleInfo)
+				req = commContext.SetTokenSubject(req, platformInfo.HwUUID)
 
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
@@ -261,10 +282,10 @@ var _ = Describe("PlatformInfo Validation", func() {
 
 				// valid permissions and userroles added.
 				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataUpdaterGroupName}}
-				req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
 				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataUpdaterGroupName, Context: "type=SCS"}}
-				req = context.SetUserRoles(req, roleInfo)
-				req = context.SetTokenSubject(req, "ee37c360-7eae-4250-b777-6ee12adce8e2")
+				req = commContext.SetUserRoles(req, roleInfo)
+				req = commContext.SetTokenSubject(req, "ee37c360-7eae-4250-b777-6ee12adce8e2")
 
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
@@ -276,6 +297,619 @@ var _ = Describe("PlatformInfo Validation", func() {
 	})
 })
 
+var _ = Describe("PlatformInfo Unknown Fields Validation", func() {
+	var router *mux.Router
+	var w *httptest.ResponseRecorder
+	var client domain.HttpClient
+
+	db := getMockDatabase()
+	client = mocks.NewClientMock(200)
+
+	platform := &types.Platform{
+		QeID:     "4518145496973c5e69577195511e9080",
+		PceID:    "0000",
+		CPUSvn:   "1bf8deed6f929ce40bd658e61ea722eb",
+		PceSvn:   "0a00",
+		Fmspc:    "20606a000000",
+		Ca:       "processor",
+		Manifest: "178e874b49e44aa599bb3057170925b4",
+	}
+	db.PlatformRepository().Create(platform)
+
+	pushPlatformInfoWithUnknownField := func(conf *config.Configuration) int {
+		PlatformInfoOps(router, db, conf, &client)
+
+		platformInfo := PlatformInfo{
+			EncPpid:  "00f51b4272163732be2101ee62dfdb175205a5179c5b5faff4b2ae103cb1150ef7d4e6041775543930600e41dd2e6aee7f40790f5a0380f6b29b1f1f7e6aad75bfa666153bb325c6db5b67f694d14bff98996c4994ce153278bfeb1b455dd4acbeacc97df6a3cd439a838218c1e07dae91a62195b803b9d3808d5b8470d46b0af3f275b6f6573871eb4eeb43ed9c5a5647729f25648fa74f1ce43621618b266abde6f44e92ce65bbbbe2c50e3e7a8b84d1ed38f53a1d99d3f15fc8c39b0ee568580c37a4eb19dbe87cd447c78f05544684701c01e64e0273dc69c27e46f732f7a7ee8cc4dfaf3b921bf6bbc3ee83f8de5f4e86039595cddaf7cadfce599f0eb92509ff2a90d189bda51fdd298fa1cffd4e8d79095f104c073a2b71cf61c727f4e5718cb7ea2f8fc6d7694bf3b40764234dfbe0d35f40f557545e1729ca639be4f1bcdc9028cb590b3ad3fd176bfea3cef13e57db057b3bae7ae8553a454515aecb21e4c58c670b19d8ee12668ab8af16d56b285153589eb85d15cd9e56fe459b",
+			PceID:    platform.PceID,
+			CPUSvn:   platform.CPUSvn,
+			PceSvn:   platform.PceSvn,
+			QeID:     platform.QeID,
+			Manifest: platform.Manifest,
+			HwUUID:   "5a8de8c4-84a1-4cc0-9b95-2d8b9a0ff1b4",
+		}
+		var body map[string]interface{}
+		platformInfoJson, _ := json.Marshal(platformInfo)
+		Expect(json.Unmarshal(platformInfoJson, &body)).To(Succeed())
+		// field sent by a newer version of SGX Agent
+		body["new_field"] = "new value"
+		reqBody, _ := json.Marshal(body)
+
+		req, err := http.NewRequest(http.MethodPost, "/platforms", bytes.NewReader(reqBody))
+		Expect(err).NotTo(HaveOccurred())
+
+		permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataUpdaterGroupName}}
+		req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+		roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataUpdaterGroupName, Context: "type=SCS"}}
+		req = commContext.SetUserRoles(req, roleInfo)
+		req = commContext.SetTokenSubject(req, platformInfo.HwUUID)
+
+		req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	BeforeEach(func() {
+		router = mux.NewRouter()
+	})
+
+	Describe("pushPlatformInfo unknown fields validation", func() {
+		Context("platform info with an unknown field is pushed", func() {
+
+			It("Should return StatusBadRequest - Unknown fields are not allowed", func() {
+				Expect(pushPlatformInfoWithUnknownField(&config.Configuration{})).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusOK - Unknown fields are allowed", func() {
+				conf := &config.Configuration{AllowUnknownPlatformInfoFields: true}
+				Expect(pushPlatformInfoWithUnknownField(conf)).To(Equal(http.StatusOK))
+			})
+		})
+	})
+})
+
+var _ = Describe("PlatformInfo EncPpid With Manifest Validation", func() {
+	var router *mux.Router
+	var w *httptest.ResponseRecorder
+	var client domain.HttpClient
+
+	db := getMockDatabase()
+	client = mocks.NewClientMock(200)
+
+	platform := &types.Platform{
+		QeID:     "5518145496973c5e69577195511e9080",
+		PceID:    "0000",
+		CPUSvn:   "1bf8deed6f929ce40bd658e61ea722eb",
+		PceSvn:   "0a00",
+		Fmspc:    "20606a000000",
+		Ca:       "processor",
+		Manifest: "178e874b49e44aa599bb3057170925b4",
+	}
+	db.PlatformRepository().Create(platform)
+
+	pushPlatformInfoWithManifest := func(conf *config.Configuration, manifest string) int {
+		PlatformInfoOps(router, db, conf, &client)
+
+		platformInfo := PlatformInfo{
+			EncPpid:  "00f51b4272163732be2101ee62dfdb175205a5179c5b5faff4b2ae103cb1150ef7d4e6041775543930600e41dd2e6aee7f40790f5a0380f6b29b1f1f7e6aad75bfa666153bb325c6db5b67f694d14bff98996c4994ce153278bfeb1b455dd4acbeacc97df6a3cd439a838218c1e07dae91a62195b803b9d3808d5b8470d46b0af3f275b6f6573871eb4eeb43ed9c5a5647729f25648fa74f1ce43621618b266abde6f44e92ce65bbbbe2c50e3e7a8b84d1ed38f53a1d99d3f15fc8c39b0ee568580c37a4eb19dbe87cd447c78f05544684701c01e64e0273dc69c27e46f732f7a7ee8cc4dfaf3b921bf6bbc3ee83f8de5f4e86039595cddaf7cadfce599f0eb92509ff2a90d189bda51fdd298fa1cffd4e8d79095f104c073a2b71cf61c727f4e5718cb7ea2f8fc6d7694bf3b40764234dfbe0d35f40f557545e1729ca639be4f1bcdc9028cb590b3ad3fd176bfea3cef13e57db057b3bae7ae8553a454515aecb21e4c58c670b19d8ee12668ab8af16d56b285153589eb85d15cd9e56fe459b",
+			PceID:    platform.PceID,
+			CPUSvn:   platform.CPUSvn,
+			PceSvn:   platform.PceSvn,
+			QeID:     platform.QeID,
+			Manifest: manifest,
+			HwUUID:   "6a8de8c4-84a1-4cc0-9b95-2d8b9a0ff1b4",
+		}
+		reqBody, _ := json.Marshal(platformInfo)
+		req, err := http.NewRequest(http.MethodPost, "/platforms", bytes.NewReader(reqBody))
+		Expect(err).NotTo(HaveOccurred())
+
+		permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataUpdaterGroupName}}
+		req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+		roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataUpdaterGroupName, Context: "type=SCS"}}
+		req = commContext.SetUserRoles(req, roleInfo)
+		req = commContext.SetTokenSubject(req, platformInfo.HwUUID)
+
+		req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	BeforeEach(func() {
+		router = mux.NewRouter()
+	})
+
+	Describe("pushPlatformInfo enc_ppid with manifest validation", func() {
+		Context("platform info with both enc_ppid and manifest is pushed", func() {
+
+			It("Should return StatusOK - Manifest is preferred", func() {
+				conf := &config.Configuration{EncPpidWithManifestPolicy: constants.EncPpidWithManifestPrefer}
+				Expect(pushPlatformInfoWithManifest(conf, platform.Manifest)).To(Equal(http.StatusOK))
+			})
+
+			It("Should return StatusBadRequest - Both are rejected", func() {
+				conf := &config.Configuration{EncPpidWithManifestPolicy: constants.EncPpidWithManifestReject}
+				Expect(pushPlatformInfoWithManifest(conf, platform.Manifest)).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusBadRequest - Invalid manifest given", func() {
+				conf := &config.Configuration{EncPpidWithManifestPolicy: constants.EncPpidWithManifestPrefer}
+				Expect(pushPlatformInfoWithManifest(conf, "manifest")).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+})
+
+var _ = Describe("PckCerts Validation", func() {
+	var router *mux.Router
+	var w *httptest.ResponseRecorder
+
+	db := getMockDatabase()
+	conf := config.Load(testConfigFilePath)
+
+	platform := &types.Platform{
+		QeID:   "6518145496973c5e69577195511e9080",
+		PceID:  "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb",
+		PceSvn: "0a00",
+		Fmspc:  "20606a000000",
+		Ca:     "platform",
+	}
+	db.PlatformRepository().Create(platform)
+
+	pckCert := &types.PckCert{
+		QeID:      platform.QeID,
+		PceID:     platform.PceID,
+		CPUSvn:    platform.CPUSvn,
+		PceSvn:    platform.PceSvn,
+		CertIndex: 1,
+		Tcbms:     []string{"030300000000000000000000000000000A00", "010100000000000000000000000000000900"},
+		Fmspc:     platform.Fmspc,
+		PckCerts:  []string{"pckcert1", "pckcert2"},
+	}
+	db.PckCertRepository().Create(pckCert)
+
+	certChain := &types.PckCertChain{
+		Ca:           platform.Ca,
+		PckCertChain: "pckcertchain",
+	}
+	db.PckCertChainRepository().Create(certChain)
+
+	// platform whose cached pck certs and tcbms do not match
+	db.PlatformRepository().Create(&types.Platform{QeID: "8518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn, Fmspc: platform.Fmspc, Ca: platform.Ca})
+	db.PckCertRepository().Create(&types.PckCert{QeID: "8518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn, Tcbms: []string{"030300000000000000000000000000000A00"},
+		PckCerts: []string{"pckcert1", "pckcert2"}})
+	// platform without pck certs cached for its raw tcb level
+	db.PlatformRepository().Create(&types.Platform{QeID: "9518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn, Fmspc: platform.Fmspc, Ca: platform.Ca})
+
+	getPckCertsRequest := func(urlPath string) *http.Request {
+		req, err := http.NewRequest(http.MethodGet, urlPath, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		// valid permissions and userroles added.
+		permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataReaderGroupName}}
+		req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+		roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: "type=SCS"}}
+		req = commContext.SetUserRoles(req, roleInfo)
+		req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+		return req
+	}
+
+	BeforeEach(func() {
+		router = mux.NewRouter()
+	})
+
+	Describe("getPckCerts validation", func() {
+		Context("getPckCerts", func() {
+
+			It("Should return StatusInternalServerError - Insufficient roles", func() {
+
+				PlatformInfoOps(router, db, conf, nil)
+
+				req, err := http.NewRequest(http.MethodGet, "/pckcerts?qeid=6518145496973c5e69577195511e9080&pceid=0000", nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				// valid permissions added insufficient roles.
+				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataUpdaterGroupName}}
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				Expect(w.Code).To(Equal(http.StatusInternalServerError))
+			})
+
+			It("Should return StatusBadRequest - No URL query given", func() {
+
+				PlatformInfoOps(router, db, conf, nil)
+
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, getPckCertsRequest("/pckcerts"))
+				Expect(w.Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusBadRequest - Invalid URL query given", func() {
+
+				PlatformInfoOps(router, db, conf, nil)
+
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, getPckCertsRequest("/pckcerts?qeid=6518145496973c5e69577195511e9080&cpusvn=0000"))
+				Expect(w.Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusNotFound - Platform not cached", func() {
+
+				PlatformInfoOps(router, db, conf, nil)
+
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, getPckCertsRequest("/pckcerts?qeid=7518145496973c5e69577195511e9080&pceid=0000"))
+				Expect(w.Code).To(Equal(http.StatusNotFound))
+			})
+
+			It("Should return StatusInternalServerError - Cached pck certs and tcbms do not match", func() {
+
+				PlatformInfoOps(router, db, conf, nil)
+
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, getPckCertsRequest("/pckcerts?qeid=8518145496973c5e69577195511e9080&pceid=0000"))
+				Expect(w.Code).To(Equal(http.StatusInternalServerError))
+			})
+
+			It("Should return StatusNotFound - Pck certs not yet cached with the pre-caching model", func() {
+
+				PlatformInfoOps(router, db, &config.Configuration{CachingModel: constants.PreCachingModel}, nil)
+
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, getPckCertsRequest("/pckcerts?qeid=9518145496973c5e69577195511e9080&pceid=0000"))
+				Expect(w.Code).To(Equal(http.StatusNotFound))
+			})
+
+			It("Should return StatusOK - All cached pck certs returned", func() {
+
+				PlatformInfoOps(router, db, conf, nil)
+
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, getPckCertsRequest("/pckcerts?qeid=6518145496973c5e69577195511e9080&pceid=0000"))
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(w.Header().Get("Sgx-Pck-Certificate-Issuer-Chain")).To(Equal("pckcertchain"))
+				Expect(w.Header().Get("Sgx-Fmspc")).To(Equal("20606a000000"))
+				Expect(w.Header().Get("Sgx-Pck-Certificate-Ca-Type")).To(Equal("platform"))
+
+				var pckCerts []PckCertsInfo
+				Expect(json.Unmarshal(w.Body.Bytes(), &pckCerts)).To(Succeed())
+				Expect(pckCerts).To(HaveLen(2))
+				Expect(pckCerts[0].Cert).To(Equal("pckcert1"))
+				Expect(pckCerts[0].Tcbm).To(Equal("030300000000000000000000000000000A00"))
+				Expect(pckCerts[0].Tcb.SgxTcbComp01Svn).To(Equal(uint8(3)))
+				Expect(pckCerts[0].Tcb.PceSvn).To(Equal(uint16(10)))
+				Expect(pckCerts[1].Cert).To(Equal("pckcert2"))
+				Expect(pckCerts[1].Tcb.SgxTcbComp02Svn).To(Equal(uint8(1)))
+				Expect(pckCerts[1].Tcb.PceSvn).To(Equal(uint16(9)))
+			})
+		})
+	})
+})
+
+var _ = Describe("Registration Status Validation", func() {
+	var router *mux.Router
+	var w *httptest.ResponseRecorder
+
+	db := getMockDatabase()
+	conf := config.Load(testConfigFilePath)
+	encPpid := strings.Repeat("ab", 384)
+
+	// registered platform with pck certs cached
+	db.PlatformRepository().Create(&types.Platform{QeID: "6518145496973c5e69577195511e9080", PceID: "0000", Encppid: encPpid})
+	db.PckCertRepository().Create(&types.PckCert{QeID: "6518145496973c5e69577195511e9080", PceID: "0000", PckCerts: []string{"pckcert1"}})
+	// platform without pck certs cached
+	db.PlatformRepository().Create(&types.Platform{QeID: "7518145496973c5e69577195511e9080", PceID: "0000", Encppid: encPpid})
+	// multi-package platform for which only manifest is known
+	db.PlatformRepository().Create(&types.Platform{QeID: "8518145496973c5e69577195511e9080", PceID: "0000", Manifest: "manifest"})
+
+	getRegistrationStatusRequest := func(urlPath string) *http.Request {
+		req, err := http.NewRequest(http.MethodGet, urlPath, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataReaderGroupName}}
+		req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+		roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: "type=SCS"}}
+		req = commContext.SetUserRoles(req, roleInfo)
+		return req
+	}
+
+	getRegistrationStatusResponse := func(client domain.HttpClient, urlPath string) RegistrationStatus {
+		PlatformInfoOps(router, db, conf, &client)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, getRegistrationStatusRequest(urlPath))
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var regStatus RegistrationStatus
+		Expect(json.Unmarshal(w.Body.Bytes(), &regStatus)).To(Succeed())
+		return regStatus
+	}
+
+	BeforeEach(func() {
+		router = mux.NewRouter()
+	})
+
+	Describe("getRegistrationStatus validation", func() {
+		Context("getRegistrationStatus", func() {
+
+			It("Should return StatusBadRequest - Both qeid and encrypted_ppid given", func() {
+				PlatformInfoOps(router, db, conf, nil)
+
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, getRegistrationStatusRequest("/registrationstatus?qeid=6518145496973c5e69577195511e9080&pceid=0000&encrypted_ppid="+encPpid))
+				Expect(w.Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusNotFound - Platform not cached", func() {
+				PlatformInfoOps(router, db, conf, nil)
+
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, getRegistrationStatusRequest("/registrationstatus?qeid=9518145496973c5e69577195511e9080&pceid=0000"))
+				Expect(w.Code).To(Equal(http.StatusNotFound))
+			})
+
+			It("Should return Registered - Pck certs are cached", func() {
+				regStatus := getRegistrationStatusResponse(nil, "/registrationstatus?qeid=6518145496973c5e69577195511e9080&pceid=0000")
+				Expect(regStatus.Status).To(Equal(constants.RegistrationStatusRegistered))
+				Expect(regStatus.Source).To(Equal(constants.RegistrationSourceCache))
+			})
+
+			It("Should return NotRegistered - PCS does not know the platform", func() {
+				regStatus := getRegistrationStatusResponse(&statusClientMock{statusCode: http.StatusNotFound},
+					"/registrationstatus?qeid=7518145496973c5e69577195511e9080&pceid=0000")
+				Expect(regStatus.Status).To(Equal(constants.RegistrationStatusUnregistered))
+				Expect(regStatus.Source).To(Equal(constants.RegistrationSourcePcs))
+			})
+
+			It("Should return Unknown - Only manifest is known for the platform", func() {
+				regStatus := getRegistrationStatusResponse(nil, "/registrationstatus?qeid=8518145496973c5e69577195511e9080&pceid=0000")
+				Expect(regStatus.Status).To(Equal(constants.RegistrationStatusUnknown))
+			})
+
+			It("Should return Registered - PCS returns pck certs for encrypted ppid", func() {
+				regStatus := getRegistrationStatusResponse(&statusClientMock{statusCode: http.StatusOK},
+					"/registrationstatus?encrypted_ppid="+encPpid+"&pceid=0000")
+				Expect(regStatus.Status).To(Equal(constants.RegistrationStatusRegistered))
+				Expect(regStatus.Source).To(Equal(constants.RegistrationSourcePcs))
+			})
+
+			It("Should return StatusServiceUnavailable - PCS is rate limiting", func() {
+				var client domain.HttpClient = &statusClientMock{statusCode: http.StatusTooManyRequests}
+				PlatformInfoOps(router, db, conf, &client)
+
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, getRegistrationStatusRequest("/registrationstatus?encrypted_ppid="+encPpid+"&pceid=0000"))
+				Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+			})
+		})
+	})
+})
+
+var _ = Describe("Revoked Platforms Validation", func() {
+	var router *mux.Router
+	var w *httptest.ResponseRecorder
+
+	db := memory.NewDatabase()
+	crl, pckCerts, err := createTestRevokedPckCerts([]int64{10}, []int64{10, 11})
+	Expect(err).NotTo(HaveOccurred())
+	db.PckCrlRepository().Create(&types.PckCrl{Ca: "processor", PckCrlCertChain: "chain",
+		PckCrl: base64.StdEncoding.EncodeToString(crl)})
+	// revoked platform, whose pck cert of the other tcb level is still valid
+	db.PckCertRepository().Create(&types.PckCert{QeID: "6518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "cpusvn1", PckCerts: []string{pckCerts[1], pckCerts[0]}})
+	db.PckCertRepository().Create(&types.PckCert{QeID: "6518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "cpusvn2", PckCerts: []string{pckCerts[0]}})
+	// platform with a valid pck cert and one which cannot be parsed
+	db.PckCertRepository().Create(&types.PckCert{QeID: "7518145496973c5e69577195511e9080", PceID: "0000",
+		PckCerts: []string{pckCerts[1], "invalidcert"}})
+
+	getRevokedPlatformsRequest := func(urlPath string) *http.Request {
+		req, err := http.NewRequest(http.MethodGet, urlPath, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataReaderGroupName}}
+		req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+		roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: "type=SCS"}}
+		req = commContext.SetUserRoles(req, roleInfo)
+		return req
+	}
+
+	BeforeEach(func() {
+		router = mux.NewRouter()
+		PlatformInfoOps(router, db, nil, nil)
+	})
+
+	Describe("getRevokedPlatforms validation", func() {
+		Context("getRevokedPlatforms", func() {
+
+			It("Should return StatusBadRequest - Invalid ca", func() {
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, getRevokedPlatformsRequest("/revokedplatforms?ca=unknown"))
+				Expect(w.Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusBadRequest - Unknown query param", func() {
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, getRevokedPlatformsRequest("/revokedplatforms?ca=processor&qeid=6518145496973c5e69577195511e9080"))
+				Expect(w.Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusNotFound - PCK CRL not cached", func() {
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, getRevokedPlatformsRequest("/revokedplatforms?ca=platform"))
+				Expect(w.Code).To(Equal(http.StatusNotFound))
+			})
+
+			It("Should return StatusOK - Platforms with a revoked pck cert", func() {
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, getRevokedPlatformsRequest("/revokedplatforms?ca=processor"))
+				Expect(w.Code).To(Equal(http.StatusOK))
+
+				var platforms []RevokedPlatform
+				Expect(json.Unmarshal(w.Body.Bytes(), &platforms)).To(Succeed())
+				Expect(platforms).To(Equal([]RevokedPlatform{{QeID: "6518145496973c5e69577195511e9080", PceID: "0000"}}))
+			})
+		})
+	})
+})
+
+var _ = Describe("Platforms Retrieval Validation", func() {
+	var router *mux.Router
+	var w *httptest.ResponseRecorder
+
+	db := memory.NewDatabase()
+	for _, qeID := range []string{"6518145496973c5e69577195511e9080", "7518145496973c5e69577195511e9080", "8518145496973c5e69577195511e9080"} {
+		db.PlatformRepository().Create(&types.Platform{QeID: qeID, PceID: "0000", Fmspc: "20606a000000",
+			Encppid: "encppid", Manifest: "manifest", Ppid: "ppid"})
+	}
+	db.PlatformRepository().Create(&types.Platform{QeID: "9518145496973c5e69577195511e9080", PceID: "0001", Ppid: "ppid"})
+
+	getPlatformsResponse := func(urlPath string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, urlPath, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataReaderGroupName}}
+		req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+		roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: "type=SCS"}}
+		req = commContext.SetUserRoles(req, roleInfo)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	BeforeEach(func() {
+		router = mux.NewRouter()
+		PlatformInfoOps(router, db, nil, nil)
+	})
+
+	Describe("getPlatforms validation", func() {
+		Context("getPlatforms", func() {
+
+			It("Should return StatusBadRequest - pceid not provided", func() {
+				Expect(getPlatformsResponse("/platforms").Code).To(Equal(http.StatusBadRequest))
+				Expect(getPlatformsResponse("/platforms?limit=10").Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusBadRequest - Invalid pceid", func() {
+				Expect(getPlatformsResponse("/platforms?pceid=00000").Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusBadRequest - Invalid pagination", func() {
+				Expect(getPlatformsResponse("/platforms?pceid=0000&limit=0").Code).To(Equal(http.StatusBadRequest))
+				Expect(getPlatformsResponse("/platforms?pceid=0000&limit=1001").Code).To(Equal(http.StatusBadRequest))
+				Expect(getPlatformsResponse("/platforms?pceid=0000&offset=-1").Code).To(Equal(http.StatusBadRequest))
+				Expect(getPlatformsResponse("/platforms?pceid=0000&offset=abc").Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusOK - Platforms with the pceid", func() {
+				w := getPlatformsResponse("/platforms?pceid=0000")
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(w.Body.String()).NotTo(ContainSubstring("encppid"))
+				Expect(w.Body.String()).NotTo(ContainSubstring("manifest"))
+
+				var platforms []PlatformSummary
+				Expect(json.Unmarshal(w.Body.Bytes(), &platforms)).To(Succeed())
+				Expect(platforms).To(HaveLen(3))
+				Expect(platforms[0].Fmspc).To(Equal("20606a000000"))
+			})
+
+			It("Should return StatusOK - Page of platforms with the pceid", func() {
+				w := getPlatformsResponse("/platforms?pceid=0000&limit=2&offset=1")
+				Expect(w.Code).To(Equal(http.StatusOK))
+
+				var platforms []PlatformSummary
+				Expect(json.Unmarshal(w.Body.Bytes(), &platforms)).To(Succeed())
+				Expect(platforms).To(HaveLen(2))
+				Expect(platforms[0].QeID).To(Equal("7518145496973c5e69577195511e9080"))
+				Expect(platforms[1].QeID).To(Equal("8518145496973c5e69577195511e9080"))
+			})
+
+			It("Should return StatusOK - No platform with the pceid", func() {
+				w := getPlatformsResponse("/platforms?pceid=0002")
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(w.Body.String()).To(Equal("[]"))
+			})
+		})
+	})
+})
+
+var _ = Describe("PCK CRLs Retrieval Validation", func() {
+	var router *mux.Router
+	var w *httptest.ResponseRecorder
+
+	db := memory.NewDatabase()
+	db.PckCrlRepository().Create(&types.PckCrl{Ca: "processor", PckCrl: "processorcrl", PckCrlCertChain: "processorcrlchain",
+		ThisUpdate: time.Now().Add(-time.Hour).UTC(), NextUpdate: time.Now().Add(time.Hour).UTC(), UpdatedTime: time.Now().UTC()})
+	db.PckCrlRepository().Create(&types.PckCrl{Ca: "platform", PckCrl: "platformcrl", PckCrlCertChain: "platformcrlchain",
+		ThisUpdate: time.Now().Add(-2 * time.Hour).UTC(), NextUpdate: time.Now().Add(-time.Hour).UTC(), UpdatedTime: time.Now().UTC()})
+
+	getPckCrlsResponse := func(urlPath string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, urlPath, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataReaderGroupName}}
+		req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+		roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: "type=SCS"}}
+		req = commContext.SetUserRoles(req, roleInfo)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	BeforeEach(func() {
+		router = mux.NewRouter()
+		PlatformInfoOps(router, db, nil, nil)
+	})
+
+	Describe("getPckCrls validation", func() {
+		Context("getPckCrls", func() {
+
+			It("Should return StatusBadRequest - Invalid query params", func() {
+				Expect(getPckCrlsResponse("/pckcrls?ca=processor").Code).To(Equal(http.StatusBadRequest))
+				Expect(getPckCrlsResponse("/pckcrls?verbose=abc").Code).To(Equal(http.StatusBadRequest))
+				Expect(getPckCrlsResponse("/pckcrls?limit=0").Code).To(Equal(http.StatusBadRequest))
+				Expect(getPckCrlsResponse("/pckcrls?offset=-1").Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusOK - PCK CRLs without the CRL", func() {
+				w := getPckCrlsResponse("/pckcrls")
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(w.Body.String()).NotTo(ContainSubstring("processorcrl\""))
+
+				var pckCrls []PckCrlSummary
+				Expect(json.Unmarshal(w.Body.Bytes(), &pckCrls)).To(Succeed())
+				Expect(pckCrls).To(HaveLen(2))
+				Expect(pckCrls[0].Ca).To(Equal("platform"))
+				Expect(pckCrls[0].Expired).To(BeTrue())
+				Expect(pckCrls[1].Ca).To(Equal("processor"))
+				Expect(pckCrls[1].Expired).To(BeFalse())
+				Expect(pckCrls[1].IssuerChainPresent).To(BeTrue())
+				Expect(pckCrls[1].NextUpdate).NotTo(BeNil())
+				Expect(pckCrls[1].PckCrl).To(BeEmpty())
+			})
+
+			It("Should return StatusOK - Page of PCK CRLs with the CRL", func() {
+				w := getPckCrlsResponse("/pckcrls?verbose=true&offset=1")
+				Expect(w.Code).To(Equal(http.StatusOK))
+
+				var pckCrls []PckCrlSummary
+				Expect(json.Unmarshal(w.Body.Bytes(), &pckCrls)).To(Succeed())
+				Expect(pckCrls).To(HaveLen(1))
+				Expect(pckCrls[0].Ca).To(Equal("processor"))
+				Expect(pckCrls[0].PckCrl).To(Equal("processorcrl"))
+			})
+		})
+	})
+})
+
 var _ = Describe("TcbInfo Validation", func() {
 	var router *mux.Router
 	var w *httptest.ResponseRecorder
@@ -303,7 +937,7 @@ var _ = Describe("TcbInfo Validation", func() {
 
 				// valid permissions added insufficient roles.
 				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataUpdaterGroupName}}
-				req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
 				router.ServeHTTP(w, req)
@@ -319,9 +953,9 @@ var _ = Describe("TcbInfo Validation", func() {
 
 				// valid permissions and userroles added.
 				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataReaderGroupName}}
-				req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
 				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: "type=SCS"}}
-				req = context.SetUserRoles(req, roleInfo)
+				req = commContext.SetUserRoles(req, roleInfo)
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
 				router.ServeHTTP(w, req)
@@ -337,9 +971,9 @@ var _ = Describe("TcbInfo Validation", func() {
 
 				// valid permissions and userroles added.
 				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataReaderGroupName}}
-				req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
 				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: "type=SCS"}}
-				req = context.SetUserRoles(req, roleInfo)
+				req = commContext.SetUserRoles(req, roleInfo)
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
 				router.ServeHTTP(w, req)
@@ -355,9 +989,9 @@ var _ = Describe("TcbInfo Validation", func() {
 
 				// valid permissions and userroles added.
 				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataReaderGroupName}}
-				req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
 				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: "type=SCS"}}
-				req = context.SetUserRoles(req, roleInfo)
+				req = commContext.SetUserRoles(req, roleInfo)
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
 				router.ServeHTTP(w, req)
@@ -373,9 +1007,9 @@ var _ = Describe("TcbInfo Validation", func() {
 
 				// valid permissions and userroles added.
 				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataReaderGroupName}}
-				req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
 				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: "type=SCS"}}
-				req = context.SetUserRoles(req, roleInfo)
+				req = commContext.SetUserRoles(req, roleInfo)
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
 				router.ServeHTTP(w, req)
@@ -387,6 +1021,8 @@ var _ = Describe("TcbInfo Validation", func() {
 				newPckCert := &types.PckCert{
 					QeID:      "0518145496973c5e69577195511e9080",
 					PceID:     "0000",
+					CPUSvn:    "1bf8deed6f929ce40bd658e61ea722eb",
+					PceSvn:    "0a00",
 					CertIndex: 0,
 					Tcbms:     []string{"030300000000000000000000000000000A00"},
 					Fmspc:     "20606a000000",
@@ -423,9 +1059,9 @@ var _ = Describe("TcbInfo Validation", func() {
 
 				// valid permissions and userroles added.
 				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataReaderGroupName}}
-				req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
 				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: "type=SCS"}}
-				req = context.SetUserRoles(req, roleInfo)
+				req = commContext.SetUserRoles(req, roleInfo)
 
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
@@ -436,6 +1072,174 @@ var _ = Describe("TcbInfo Validation", func() {
 	})
 })
 
+var _ = Describe("TcbStatus Policy Validation", func() {
+	var router *mux.Router
+	var w *httptest.ResponseRecorder
+	var db repository.SCSDatabase
+
+	qeID := "2518145496973c5e69577195511e9080"
+	pceID := "0000"
+	fmspc := "30606a000000"
+
+	db = getMockDatabase()
+	db.PckCertRepository().Create(&types.PckCert{
+		QeID:      qeID,
+		PceID:     pceID,
+		CertIndex: 0,
+		Tcbms:     []string{"010100000000000000000000000000000900"},
+		Fmspc:     fmspc,
+	})
+	db.PlatformRepository().Create(&types.Platform{QeID: qeID, PceID: pceID, Fmspc: fmspc})
+	db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{
+		Fmspc:   fmspc,
+		TcbInfo: string(bytes.Replace(testTcbInfoJson, []byte("OutOfDate"), []byte("ConfigurationNeeded"), 1)),
+	})
+
+	getStatus := func(conf *config.Configuration) Response {
+		PlatformInfoOps(router, db, conf, nil)
+
+		req, err := http.NewRequest(http.MethodGet, "/tcbstatus?pceid="+pceID+"&qeid="+qeID, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataReaderGroupName}}
+		req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+		roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: "type=SCS"}}
+		req = commContext.SetUserRoles(req, roleInfo)
+		req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var res Response
+		Expect(json.Unmarshal(w.Body.Bytes(), &res)).To(Succeed())
+		return res
+	}
+
+	BeforeEach(func() {
+		router = mux.NewRouter()
+	})
+
+	Describe("Tcbstatus policy validation", func() {
+		Context("platform tcb level is ConfigurationNeeded", func() {
+
+			It("Should return true - Default lenient policy", func() {
+				res := getStatus(&config.Configuration{})
+				Expect(res.Status).To(Equal("true"))
+				Expect(res.TcbStatus).To(Equal("ConfigurationNeeded"))
+			})
+
+			It("Should return false - Strict policy accepting only UpToDate", func() {
+				res := getStatus(&config.Configuration{AcceptedTcbStatuses: []string{"UpToDate"}})
+				Expect(res.Status).To(Equal("false"))
+				Expect(res.TcbStatus).To(Equal("ConfigurationNeeded"))
+			})
+		})
+	})
+})
+
+var _ = Describe("TcbStatus TCB Level Validation", func() {
+	var router *mux.Router
+	var w *httptest.ResponseRecorder
+	var db repository.SCSDatabase
+
+	qeID := "3518145496973c5e69577195511e9080"
+	pceID := "0000"
+	fmspc := "40606a000000"
+	oldCPUSvn := "01010000000000000000000000000000"
+	newCPUSvn := "03030000000000000000000000000000"
+	lowCPUSvn := "00000000000000000000000000000000"
+
+	db = getMockDatabase()
+	// pck certs selected for two raw tcb levels of the same platform
+	db.PckCertRepository().Create(&types.PckCert{
+		QeID:      qeID,
+		PceID:     pceID,
+		CPUSvn:    oldCPUSvn,
+		PceSvn:    "0900",
+		CertIndex: 0,
+		Tcbms:     []string{"010100000000000000000000000000000900"},
+		Fmspc:     fmspc,
+	})
+	db.PckCertRepository().Create(&types.PckCert{
+		QeID:      qeID,
+		PceID:     pceID,
+		CPUSvn:    newCPUSvn,
+		PceSvn:    "0a00",
+		CertIndex: 0,
+		Tcbms:     []string{"030300000000000000000000000000000A00"},
+		Fmspc:     fmspc,
+	})
+	db.PlatformRepository().Create(&types.Platform{QeID: qeID, PceID: pceID, CPUSvn: newCPUSvn, PceSvn: "0a00", Fmspc: fmspc})
+	db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: fmspc, TcbInfo: string(testTcbInfoJson)})
+
+	getStatus := func() Response {
+		PlatformInfoOps(router, db, &config.Configuration{}, nil)
+
+		req, err := http.NewRequest(http.MethodGet, "/tcbstatus?pceid="+pceID+"&qeid="+qeID, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataReaderGroupName}}
+		req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+		roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: "type=SCS"}}
+		req = commContext.SetUserRoles(req, roleInfo)
+		req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		var res Response
+		Expect(json.Unmarshal(w.Body.Bytes(), &res)).To(Succeed())
+		return res
+	}
+
+	BeforeEach(func() {
+		router = mux.NewRouter()
+	})
+
+	Describe("Tcbstatus with multiple cached tcb levels", func() {
+		Context("platform has pck certs cached for two raw tcb levels", func() {
+
+			It("Should return UpToDate - Platform is at the newer tcb level", func() {
+				res := getStatus()
+				Expect(res.Status).To(Equal("true"))
+				Expect(res.TcbStatus).To(Equal("UpToDate"))
+			})
+
+			It("Should return OutOfDate - Platform is at the older tcb level", func() {
+				platform, err := db.PlatformRepository().Retrieve(&types.Platform{QeID: qeID, PceID: pceID})
+				Expect(err).NotTo(HaveOccurred())
+				platform.CPUSvn = oldCPUSvn
+				platform.PceSvn = "0900"
+
+				res := getStatus()
+				Expect(res.Status).To(Equal("false"))
+				Expect(res.TcbStatus).To(Equal("OutOfDate"))
+			})
+
+			It("Should return TCBLevelNotFound - Platform tcb level is below all tcb levels in TcbInfo", func() {
+				db.PckCertRepository().Create(&types.PckCert{
+					QeID:      qeID,
+					PceID:     pceID,
+					CPUSvn:    lowCPUSvn,
+					PceSvn:    "0000",
+					CertIndex: 0,
+					Tcbms:     []string{"000000000000000000000000000000000000"},
+					Fmspc:     fmspc,
+				})
+				platform, err := db.PlatformRepository().Retrieve(&types.Platform{QeID: qeID, PceID: pceID})
+				Expect(err).NotTo(HaveOccurred())
+				platform.CPUSvn = lowCPUSvn
+				platform.PceSvn = "0000"
+
+				res := getStatus()
+				Expect(res.Status).To(Equal("false"))
+				Expect(res.TcbStatus).To(Equal(constants.TcbLevelNotFound))
+				Expect(res.Message).To(Equal("TCB level of the platform is not present in TcbInfo"))
+			})
+		})
+	})
+})
+
 // RefreshPlatformInfoOps Resource validation
 var _ = Describe("RefreshPlatformInfoOps Validation", func() {
 	var router *mux.Router
@@ -459,7 +1263,7 @@ var _ = Describe("RefreshPlatformInfoOps Validation", func() {
 
 				// Invalid permission
 				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataUpdaterGroupName}}
-				req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
 				router.ServeHTTP(w, req)
@@ -475,7 +1279,7 @@ var _ = Describe("RefreshPlatformInfoOps Validation", func() {
 
 				// valid permissions added and insufficient roles.
 				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.CacheManagerGroupName}}
-				req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
 				router.ServeHTTP(w, req)
@@ -491,9 +1295,9 @@ var _ = Describe("RefreshPlatformInfoOps Validation", func() {
 
 				// valid permissions and roles added.
 				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.CacheManagerGroupName}}
-				req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
 				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.CacheManagerGroupName, Context: "type=SCS"}}
-				req = context.SetUserRoles(req, roleInfo)
+				req = commContext.SetUserRoles(req, roleInfo)
 
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
@@ -525,7 +1329,7 @@ var _ = Describe("RefreshPlatformInfoOps Validation", func() {
 
 				// invalid permissions given.
 				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataUpdaterGroupName}}
-				req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
 				router.ServeHTTP(w, req)
@@ -541,7 +1345,7 @@ var _ = Describe("RefreshPlatformInfoOps Validation", func() {
 
 				// valid permissions and insufficient roles given.
 				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.CacheManagerGroupName}}
-				req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
 				router.ServeHTTP(w, req)
@@ -557,9 +1361,9 @@ var _ = Describe("RefreshPlatformInfoOps Validation", func() {
 
 				// valid permissions and roles added.
 				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.CacheManagerGroupName}}
-				req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
 				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.CacheManagerGroupName, Context: "type=SCS"}}
-				req = context.SetUserRoles(req, roleInfo)
+				req = commContext.SetUserRoles(req, roleInfo)
 
 				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
 				w = httptest.NewRecorder()
@@ -567,6 +1371,93 @@ var _ = Describe("RefreshPlatformInfoOps Validation", func() {
 				Expect(w.Code).To(Equal(http.StatusOK))
 			})
 
+			It("Should start QE identity refresh - QE refresh type given", func() {
+
+				trigger := make(chan constants.RefreshTrigger, 1)
+				RefreshPlatformInfoOps(router, db, trigger)
+
+				req, err := http.NewRequest(http.MethodPost, "/refreshes?type="+constants.TypeRefreshQe, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				// valid permissions and roles added.
+				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.CacheManagerGroupName}}
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.CacheManagerGroupName, Context: "type=SCS"}}
+				req = commContext.SetUserRoles(req, roleInfo)
+
+				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(<-trigger).To(Equal(constants.RefreshTrigger(constants.TriggerStartQe)))
+			})
+
+			for refreshType, expectedTrigger := range map[string]constants.RefreshTrigger{
+				constants.TypeRefreshCert:      constants.TriggerStartCerts,
+				constants.TypeRefreshTcb:       constants.TriggerStartTcbs,
+				constants.TypeRefreshOutOfDate: constants.TriggerStartOutOfDateCerts,
+			} {
+				refreshType, expectedTrigger := refreshType, expectedTrigger
+				It("Should start typed refresh - "+refreshType+" refresh type given", func() {
+
+					trigger := make(chan constants.RefreshTrigger, 1)
+					RefreshPlatformInfoOps(router, db, trigger)
+
+					req, err := http.NewRequest(http.MethodPost, "/refreshes?type="+refreshType, nil)
+					Expect(err).NotTo(HaveOccurred())
+
+					// valid permissions and roles added.
+					permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.CacheManagerGroupName}}
+					req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+					roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.CacheManagerGroupName, Context: "type=SCS"}}
+					req = commContext.SetUserRoles(req, roleInfo)
+
+					req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+					w = httptest.NewRecorder()
+					router.ServeHTTP(w, req)
+					Expect(w.Code).To(Equal(http.StatusOK))
+					Expect(<-trigger).To(Equal(expectedTrigger))
+				})
+			}
+
+			It("Should return StatusBadRequest - Unknown query param given", func() {
+
+				RefreshPlatformInfoOps(router, db, nil)
+
+				req, err := http.NewRequest(http.MethodPost, "/refreshes?kind=certs", nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				// valid permissions and roles added.
+				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.CacheManagerGroupName}}
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.CacheManagerGroupName, Context: "type=SCS"}}
+				req = commContext.SetUserRoles(req, roleInfo)
+
+				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				Expect(w.Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusBadRequest - Invalid refresh type given", func() {
+
+				RefreshPlatformInfoOps(router, db, nil)
+
+				req, err := http.NewRequest(http.MethodPost, "/refreshes?type=invalid", nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				// valid permissions and roles added.
+				permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.CacheManagerGroupName}}
+				req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.CacheManagerGroupName, Context: "type=SCS"}}
+				req = commContext.SetUserRoles(req, roleInfo)
+
+				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				Expect(w.Code).To(Equal(http.StatusBadRequest))
+			})
+
 		})
 	})
 })
@@ -583,24 +1474,24 @@ func TestCachePckCertInfo(t *testing.T) {
 		PckCerts:  []string{"-----BEGIN%20CERTIFICATE-----%0AMIIE9DCCBJqgAwIBAgIUb6rZwuxZc5cIkp6%2Foqqz7HdGyFwwCgYIKoZIzj0EAwIw%0AcDEiMCAGA1UEAwwZSW50ZWwgU0dYIFBDSyBQbGF0Zm9ybSBDQTEaMBgGA1UECgwR%0ASW50ZWwgQ29ycG9yYXRpb24xFDASBgNVBAcMC1NhbnRhIENsYXJhMQswCQYDVQQI%0ADAJDQTELMAkGA1UEBhMCVVMwHhcNMjIwNjIxMTEyNDU2WhcNMjkwNjIxMTEyNDU2%0AWjBwMSIwIAYDVQQDDBlJbnRlbCBTR1ggUENLIENlcnRpZmljYXRlMRowGAYDVQQK%0ADBFJbnRlbCBDb3Jwb3JhdGlvbjEUMBIGA1UEBwwLU2FudGEgQ2xhcmExCzAJBgNV%0ABAgMAkNBMQswCQYDVQQGEwJVUzBZMBMGByqGSM49AgEGCCqGSM49AwEHA0IABOB3%0AWFm1ziJAlu79StgxfAuz8AWCkoiraneuAGgrFExeiukczJvjWdtDTM2O7w8GiZAt%0A1h84AyDRUb%2BHoNaflACjggMQMIIDDDAfBgNVHSMEGDAWgBRZI9OnSqhjVC45cK3g%0ADwcrVyQqtzBvBgNVHR8EaDBmMGSgYqBghl5odHRwczovL3NieC5hcGkudHJ1c3Rl%0AZHNlcnZpY2VzLmludGVsLmNvbS9zZ3gvY2VydGlmaWNhdGlvbi92My9wY2tjcmw%2F%0AY2E9cGxhdGZvcm0mZW5jb2Rpbmc9ZGVyMB0GA1UdDgQWBBQ6mE6WHjgoVSRiUaG%2F%0A0QmQDpX7LjAOBgNVHQ8BAf8EBAMCBsAwDAYDVR0TAQH%2FBAIwADCCAjkGCSqGSIb4%0ATQENAQSCAiowggImMB4GCiqGSIb4TQENAQEEEGzzoSC5Btq3aBE%2BWYxHhwUwggFj%0ABgoqhkiG%2BE0BDQECMIIBUzAQBgsqhkiG%2BE0BDQECAQIBATAQBgsqhkiG%2BE0BDQEC%0AAgIBATAQBgsqhkiG%2BE0BDQECAwIBADAQBgsqhkiG%2BE0BDQECBAIBADAQBgsqhkiG%0A%2BE0BDQECBQIBADAQBgsqhkiG%2BE0BDQECBgIBADAQBgsqhkiG%2BE0BDQECBwIBADAQ%0ABgsqhkiG%2BE0BDQECCAIBADAQBgsqhkiG%2BE0BDQECCQIBADAQBgsqhkiG%2BE0BDQEC%0ACgIBADAQBgsqhkiG%2BE0BDQECCwIBADAQBgsqhkiG%2BE0BDQECDAIBADAQBgsqhkiG%0A%2BE0BDQECDQIBADAQBgsqhkiG%2BE0BDQECDgIBADAQBgsqhkiG%2BE0BDQECDwIBADAQ%0ABgsqhkiG%2BE0BDQECEAIBADAQBgsqhkiG%2BE0BDQECEQIBCTAfBgsqhkiG%2BE0BDQEC%0AEgQQAQEAAAAAAAAAAAAAAAAAADAQBgoqhkiG%2BE0BDQEDBAIAADAUBgoqhkiG%2BE0B%0ADQEEBAYQYGoAAAAwDwYKKoZIhvhNAQ0BBQoBATAeBgoqhkiG%2BE0BDQEGBBDjJ4f6%0AieS5MJrtZWT28t9KMEQGCiqGSIb4TQENAQcwNjAQBgsqhkiG%2BE0BDQEHAQEB%2FzAQ%0ABgsqhkiG%2BE0BDQEHAgEBADAQBgsqhkiG%2BE0BDQEHAwEB%2FzAKBggqhkjOPQQDAgNI%0AADBFAiBJwRZ5Dkvmz41SMH%2FFojZqiPxfzpQo78iqcvTdo0DwTQIhAPzZkuFcwZUV%0Al0yBja8lgLWp%2F8eMKpx5hOAw1dDV2iST%0A-----END%20CERTIFICATE-----%0A"},
 	}
 
-	_, err := cachePckCertInfo(db, newPckCert, constants.CacheRefresh)
+	_, err := cachePckCertInfo(context.Background(), db, newPckCert, constants.CacheRefresh)
 	assert.Nil(t, err)
 
 	// Cache Type Insert
-	_, err = cachePckCertInfo(db, newPckCert, constants.CacheInsert)
+	_, err = cachePckCertInfo(context.Background(), db, newPckCert, constants.CacheInsert)
 	assert.Nil(t, err)
 
 	// negative scenario
 	newPckCert.QeID = ""
 	newPckCert.PceID = ""
-	_, err = cachePckCertInfo(db, newPckCert, constants.CacheRefresh)
+	_, err = cachePckCertInfo(context.Background(), db, newPckCert, constants.CacheRefresh)
 	assert.NotNil(t, err)
 
 	newPckCert.QeID = "0518145496973c5e69577195511e9080"
 	newPckCert.PceID = "0000"
 	db.PckCertRepository().Create(newPckCert)
 	// create existing cert
-	_, err = cachePckCertInfo(db, newPckCert, constants.CacheInsert)
+	_, err = cachePckCertInfo(context.Background(), db, newPckCert, constants.CacheInsert)
 	assert.NotNil(t, err)
 
 }
@@ -614,22 +1505,22 @@ func TestCachePckCertChainInfo(t *testing.T) {
 		PckCertChain: "-----BEGIN%20CERTIFICATE-----%0AMIIE9DCCBJqgAwIBAgIUb6rZwuxZc5cIkp6%2Foqqz7HdGyFwwCgYIKoZIzj0EAwIw%0AcDEiMCAGA1UEAwwZSW50ZWwgU0dYIFBDSyBQbGF0Zm9ybSBDQTEaMBgGA1UECgwR%0ASW50ZWwgQ29ycG9yYXRpb24xFDASBgNVBAcMC1NhbnRhIENsYXJhMQswCQYDVQQI%0ADAJDQTELMAkGA1UEBhMCVVMwHhcNMjIwNjIxMTEyNDU2WhcNMjkwNjIxMTEyNDU2%0AWjBwMSIwIAYDVQQDDBlJbnRlbCBTR1ggUENLIENlcnRpZmljYXRlMRowGAYDVQQK%0ADBFJbnRlbCBDb3Jwb3JhdGlvbjEUMBIGA1UEBwwLU2FudGEgQ2xhcmExCzAJBgNV%0ABAgMAkNBMQswCQYDVQQGEwJVUzBZMBMGByqGSM49AgEGCCqGSM49AwEHA0IABOB3%0AWFm1ziJAlu79StgxfAuz8AWCkoiraneuAGgrFExeiukczJvjWdtDTM2O7w8GiZAt%0A1h84AyDRUb%2BHoNaflACjggMQMIIDDDAfBgNVHSMEGDAWgBRZI9OnSqhjVC45cK3g%0ADwcrVyQqtzBvBgNVHR8EaDBmMGSgYqBghl5odHRwczovL3NieC5hcGkudHJ1c3Rl%0AZHNlcnZpY2VzLmludGVsLmNvbS9zZ3gvY2VydGlmaWNhdGlvbi92My9wY2tjcmw%2F%0AY2E9cGxhdGZvcm0mZW5jb2Rpbmc9ZGVyMB0GA1UdDgQWBBQ6mE6WHjgoVSRiUaG%2F%0A0QmQDpX7LjAOBgNVHQ8BAf8EBAMCBsAwDAYDVR0TAQH%2FBAIwADCCAjkGCSqGSIb4%0ATQENAQSCAiowggImMB4GCiqGSIb4TQENAQEEEGzzoSC5Btq3aBE%2BWYxHhwUwggFj%0ABgoqhkiG%2BE0BDQECMIIBUzAQBgsqhkiG%2BE0BDQECAQIBATAQBgsqhkiG%2BE0BDQEC%0AAgIBATAQBgsqhkiG%2BE0BDQECAwIBADAQBgsqhkiG%2BE0BDQECBAIBADAQBgsqhkiG%0A%2BE0BDQECBQIBADAQBgsqhkiG%2BE0BDQECBgIBADAQBgsqhkiG%2BE0BDQECBwIBADAQ%0ABgsqhkiG%2BE0BDQECCAIBADAQBgsqhkiG%2BE0BDQECCQIBADAQBgsqhkiG%2BE0BDQEC%0ACgIBADAQBgsqhkiG%2BE0BDQECCwIBADAQBgsqhkiG%2BE0BDQECDAIBADAQBgsqhkiG%0A%2BE0BDQECDQIBADAQBgsqhkiG%2BE0BDQECDgIBADAQBgsqhkiG%2BE0BDQECDwIBADAQ%0ABgsqhkiG%2BE0BDQECEAIBADAQBgsqhkiG%2BE0BDQECEQIBCTAfBgsqhkiG%2BE0BDQEC%0AEgQQAQEAAAAAAAAAAAAAAAAAADAQBgoqhkiG%2BE0BDQEDBAIAADAUBgoqhkiG%2BE0B%0ADQEEBAYQYGoAAAAwDwYKKoZIhvhNAQ0BBQoBATAeBgoqhkiG%2BE0BDQEGBBDjJ4f6%0AieS5MJrtZWT28t9KMEQGCiqGSIb4TQENAQcwNjAQBgsqhkiG%2BE0BDQEHAQEB%2FzAQ%0ABgsqhkiG%2BE0BDQEHAgEBADAQBgsqhkiG%2BE0BDQEHAwEB%2FzAKBggqhkjOPQQDAgNI%0AADBFAiBJwRZ5Dkvmz41SMH%2FFojZqiPxfzpQo78iqcvTdo0DwTQIhAPzZkuFcwZUV%0Al0yBja8lgLWp%2F8eMKpx5hOAw1dDV2iST%0A-----END%20CERTIFICATE-----%0A",
 	}
 
-	_, err := cachePckCertChainInfo(db, certChain.PckCertChain, certChain.Ca, constants.CacheRefresh)
+	_, err := cachePckCertChainInfo(context.Background(), db, certChain.PckCertChain, certChain.Ca, constants.CacheRefresh)
 	assert.Nil(t, err)
 
 	// cache type insert
-	_, err = cachePckCertChainInfo(db, certChain.PckCertChain, certChain.Ca, constants.CacheInsert)
+	_, err = cachePckCertChainInfo(context.Background(), db, certChain.PckCertChain, certChain.Ca, constants.CacheInsert)
 	assert.Nil(t, err)
 
 	// negative tests
-	_, err = cachePckCertChainInfo(db, "", "", constants.CacheRefresh)
+	_, err = cachePckCertChainInfo(context.Background(), db, "", "", constants.CacheRefresh)
 	assert.NotNil(t, err)
 
 	db.PckCertChainRepository().Create(certChain)
-	// create exisiting one
+	// create exisiting one, as cached by a concurrent push
 	// cache type insert
-	_, err = cachePckCertChainInfo(db, certChain.PckCertChain, certChain.Ca, constants.CacheInsert)
-	assert.NotNil(t, err)
+	_, err = cachePckCertChainInfo(context.Background(), db, certChain.PckCertChain, certChain.Ca, constants.CacheInsert)
+	assert.Nil(t, err)
 }
 
 func TestCachePlatformInfo(t *testing.T) {
@@ -645,24 +1536,64 @@ func TestCachePlatformInfo(t *testing.T) {
 		Ca:       "processor",
 		Manifest: "178e874b49e44aa599bb3057170925b41d6e010000000000000000000000000084947ac684404189902a7e76cd658926bc010100000000000000000000000000e5db57cfd1af3e45ddfbd7f52e74a44871d542e3893c9f6f88ef999a7969eacc38e156d8233e6479997f6daa3553d902bb6a7fd6e6db21c43c13993c91c2eb95b6654b70d3e1602d5c236f9c5e8209a6a9923f49628eb7ba934913ccee4ae3df1c9ce11d0dac1c2e6ebbeb7b036e1288ad98d0aac44e5dbe3e01ea40eb7301c513c388d7e87b6630fcee23dccc28e5466a3669137e79021e386db75569606a481ac81bcd03fdc30a142bce8cca274dc044f2b40dc9abaef952bb4f0d01058d590d6950cd56bd036c7385272789e38de9b7302fafd5514248de83c18aedc9fb3c4a60754b41d8038be2c85d9e109742450b76989ff262cb0a7a979546018c7c76109ec6dd7965eead462bbd1edf6124e4a7da00de497408e44869496138cc1383f2caef7b6bf456c1f494c2b539e1741d904515414816a5096e96350b5decc84cdb9c29fe6ffa7a1982b55fe6fb258e18f03c1724b296aab446a9f4e10de1d49485f4360dfade7bc6abf70c0f1ed59c15ab30face19e3cfee43e1bee2f2800095576af52b46344ea4e7e08c2bc1d568dd01000100bcf7744b0929b513d15794280f82233b5684b73af7f86c073d1ecec86f6cd34c0000010001000000fd8f5c411b614b97a74796f08926757b39050100000000000000000000000000200000000300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
 	}
-	err := cachePlatformInfo(db, platform, constants.CacheInsert)
+	err := cachePlatformInfo(context.Background(), db, platform, constants.CacheInsert)
 	assert.Nil(t, err)
 
-	err = cachePlatformInfo(db, platform, constants.CacheRefresh)
+	err = cachePlatformInfo(context.Background(), db, platform, constants.CacheRefresh)
 	assert.Nil(t, err)
 
 	// negative tests
 
 	db.PlatformRepository().Create(platform)
-	err = cachePlatformInfo(db, platform, constants.CacheInsert)
+	err = cachePlatformInfo(context.Background(), db, platform, constants.CacheInsert)
 	assert.NotNil(t, err)
 
 	platform.QeID = ""
 	platform.PceID = ""
-	err = cachePlatformInfo(db, platform, constants.CacheRefresh)
+	err = cachePlatformInfo(context.Background(), db, platform, constants.CacheRefresh)
 	assert.NotNil(t, err)
 }
 
+func TestUpsertPlatformInfoConcurrently(t *testing.T) {
+	db := memory.NewDatabase()
+
+	// concurrent pushes of a platform all cache it, and only one of them creates it
+	var wg sync.WaitGroup
+	var created int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+				CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000", Ca: "processor"}
+			ok, err := upsertPlatformInfo(context.Background(), db, platform, "020200000000000000000000000000000a00")
+			assert.NoError(t, err)
+			if ok {
+				atomic.AddInt32(&created, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), created)
+
+	platforms, err := db.PlatformRepository().RetrieveAll()
+	assert.NoError(t, err)
+	assert.Len(t, platforms, 1)
+	platformTcb, err := db.PlatformTcbRepository().Retrieve(&types.PlatformTcb{QeID: "0518145496973c5e69577195511e9080", PceID: "0000"})
+	assert.NoError(t, err)
+	assert.Equal(t, "020200000000000000000000000000000a00", platformTcb.Tcbm)
+
+	// the raw TCB level of a cached platform is updated
+	ok, err := upsertPlatformInfo(context.Background(), db, &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0900"}, "010100000000000000000000000000000900")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	platformTcb, err = db.PlatformTcbRepository().Retrieve(&types.PlatformTcb{QeID: "0518145496973c5e69577195511e9080", PceID: "0000"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0900", platformTcb.PceSvn)
+	assert.Equal(t, "010100000000000000000000000000000900", platformTcb.Tcbm)
+}
+
 func TestCachePlatformTcbInfo(t *testing.T) {
 	db := getMockDatabase()
 	platform := &types.Platform{
@@ -683,19 +1614,19 @@ func TestCachePlatformTcbInfo(t *testing.T) {
 		PceSvn: "0a00",
 	}
 
-	err := cachePlatformTcbInfo(db, platform, "030300000000000000000000000000000A00", constants.CacheInsert)
+	err := cachePlatformTcbInfo(context.Background(), db, platform, "030300000000000000000000000000000A00", constants.CacheInsert)
 	assert.Nil(t, err)
 
-	err = cachePlatformTcbInfo(db, platform, "030300000000000000000000000000000A00", constants.CacheRefresh)
+	err = cachePlatformTcbInfo(context.Background(), db, platform, "030300000000000000000000000000000A00", constants.CacheRefresh)
 	assert.Nil(t, err)
 	// negative tests
 	db.PlatformTcbRepository().Create(thisPlatformTcb)
-	err = cachePlatformTcbInfo(db, platform, "030300000000000000000000000000000A00", constants.CacheInsert)
+	err = cachePlatformTcbInfo(context.Background(), db, platform, "030300000000000000000000000000000A00", constants.CacheInsert)
 	assert.NotNil(t, err)
 
 	platform.QeID = ""
 	platform.PceID = ""
-	err = cachePlatformTcbInfo(db, platform, "030300000000000000000000000000000A00", constants.CacheRefresh)
+	err = cachePlatformTcbInfo(context.Background(), db, platform, "030300000000000000000000000000000A00", constants.CacheRefresh)
 	assert.NotNil(t, err)
 }
 
@@ -725,16 +1656,20 @@ func TestCheckPlatformDataCacheStatus(t *testing.T) {
 	}
 	db.PlatformRepository().Create(platform)
 
-	_, err := checkPlatformDataCacheStatus(db, &platformInfo, platformInfo.HwUUID)
+	_, err := checkPlatformDataCacheStatus(db, &platformInfo, platformInfo.HwUUID, false)
 	assert.Nil(t, err)
 
+	// the platform is not cached when no pck cert is cached for its raw tcb level
 	platformInfo.Manifest = ""
-	_, err = checkPlatformDataCacheStatus(db, &platformInfo, platformInfo.HwUUID)
-	assert.NotNil(t, err)
+	cached, err := checkPlatformDataCacheStatus(db, &platformInfo, platformInfo.HwUUID, false)
+	assert.Nil(t, err)
+	assert.False(t, cached)
 
 	newPckCert := &types.PckCert{
 		QeID:      "0518145496973c5e69577195511e9080",
 		PceID:     "0000",
+		CPUSvn:    "1bf8deed6f929ce40bd658e61ea722eb",
+		PceSvn:    "0a00",
 		CertIndex: 0,
 		Tcbms:     []string{"030300000000000000000000000000000A00"},
 		Fmspc:     "20606a000000",
@@ -742,10 +1677,46 @@ func TestCheckPlatformDataCacheStatus(t *testing.T) {
 	}
 	db.PckCertRepository().Create(newPckCert)
 	platformInfo.Manifest = ""
-	_, err = checkPlatformDataCacheStatus(db, &platformInfo, platformInfo.HwUUID)
+	_, err = checkPlatformDataCacheStatus(db, &platformInfo, platformInfo.HwUUID, false)
 	assert.Nil(t, err)
 }
 
+func TestCheckPlatformDataCacheStatusDuplicatePush(t *testing.T) {
+	db := memory.NewDatabase()
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Encppid: "01", Manifest: "cafe"}
+	db.PlatformRepository().Create(platform)
+	db.PckCertRepository().Create(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID,
+		CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn})
+
+	platformInfo := PlatformInfo{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn,
+		PceSvn: platform.PceSvn, EncPpid: "01"}
+
+	// same enc_ppid is a no-op with either policy
+	for _, updateDuplicate := range []bool{false, true} {
+		pushed := platformInfo
+		isCached, err := checkPlatformDataCacheStatus(db, &pushed, "", updateDuplicate)
+		assert.NoError(t, err)
+		assert.True(t, isCached)
+	}
+
+	// changed enc_ppid is ignored by default
+	pushed := platformInfo
+	pushed.EncPpid = "02"
+	isCached, err := checkPlatformDataCacheStatus(db, &pushed, "", false)
+	assert.NoError(t, err)
+	assert.True(t, isCached)
+	assert.Equal(t, "cafe", pushed.Manifest)
+
+	// changed enc_ppid is cached again, without falling back to the cached manifest
+	pushed = platformInfo
+	pushed.EncPpid = "02"
+	isCached, err = checkPlatformDataCacheStatus(db, &pushed, "", true)
+	assert.NoError(t, err)
+	assert.False(t, isCached)
+	assert.Empty(t, pushed.Manifest)
+}
+
 func TestRefreshPckCerts(t *testing.T) {
 
 	db := getMockDatabase()
@@ -763,11 +1734,255 @@ func TestRefreshPckCerts(t *testing.T) {
 
 	conf := config.Load(testConfigFilePath)
 	client := mocks.NewClientMock(200)
-	err := refreshPckCerts(db, conf, &client)
+	err := refreshPckCerts(context.Background(), db, conf, &client)
 	assert.NotNil(t, err)
 
 }
 
+// ppidRecordingClientMock records the encrypted ppids of pck cert requests and fails all of them
+type ppidRecordingClientMock struct {
+	mu    sync.Mutex
+	ppids []string
+}
+
+func (c *ppidRecordingClientMock) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.ppids = append(c.ppids, req.URL.Query().Get("encrypted_ppid"))
+	c.mu.Unlock()
+	return &http.Response{StatusCode: http.StatusNotFound, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+}
+
+func TestRefreshPckCertsInBatches(t *testing.T) {
+	db := memory.NewDatabase()
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		_, err := db.PlatformRepository().Create(&types.Platform{QeID: id + "518145496973c5e69577195511e9080", PceID: "0000", Encppid: id})
+		assert.NoError(t, err)
+	}
+
+	conf := config.Load(testConfigFilePath)
+	conf.RefreshBatchSize = 2
+	client := &ppidRecordingClientMock{}
+	var httpClient domain.HttpClient = client
+
+	// all platforms across the pages are refreshed even when a batch fails
+	refreshed, skipped, err := refreshSelectedPckCerts(context.Background(), db, conf, &httpClient, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 5, refreshed)
+	assert.Equal(t, 0, skipped)
+	assert.ElementsMatch(t, []string{"1", "2", "3", "4", "5"}, client.ppids)
+	// progress is not saved past the first batch which failed
+	assert.Equal(t, 0, pckCertRefreshResumeOffset(db))
+
+	// a refresh interrupted after the first batch resumes from the second batch
+	assert.NoError(t, savePckCertRefreshResumeOffset(db, 2))
+	client.ppids = nil
+	refreshed, _, err = refreshSelectedPckCerts(context.Background(), db, conf, &httpClient, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 3, refreshed)
+	assert.ElementsMatch(t, []string{"3", "4", "5"}, client.ppids)
+	assert.Equal(t, 2, pckCertRefreshResumeOffset(db))
+
+	// progress of a refresh of selected platforms is neither used nor saved
+	client.ppids = nil
+	refreshed, skipped, _ = refreshSelectedPckCerts(context.Background(), db, conf, &httpClient, func(platform *types.Platform) bool {
+		return platform.Encppid != "1"
+	})
+	assert.Equal(t, 4, refreshed)
+	assert.Equal(t, 1, skipped)
+	assert.Equal(t, 2, pckCertRefreshResumeOffset(db))
+}
+
+func TestRefreshOutOfDatePckCerts(t *testing.T) {
+	db := getMockDatabase()
+	fmspc := "20606a000000"
+	db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: fmspc, TcbInfo: string(testTcbInfoJson)})
+
+	// platform at an UpToDate tcb level is skipped
+	upToDate := &types.Platform{QeID: "1518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "03030000000000000000000000000000", PceSvn: "0a00", Fmspc: fmspc}
+	db.PlatformRepository().Create(upToDate)
+	db.PckCertRepository().Create(&types.PckCert{QeID: upToDate.QeID, PceID: upToDate.PceID, CPUSvn: upToDate.CPUSvn,
+		PceSvn: upToDate.PceSvn, Tcbms: []string{"030300000000000000000000000000000A00"}, Fmspc: fmspc})
+	// platform whose tcb level is not present in TcbInfo is refreshed
+	lowTcb := &types.Platform{QeID: "2518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "00000000000000000000000000000000", PceSvn: "0000", Fmspc: fmspc}
+	db.PlatformRepository().Create(lowTcb)
+	db.PckCertRepository().Create(&types.PckCert{QeID: lowTcb.QeID, PceID: lowTcb.PceID, CPUSvn: lowTcb.CPUSvn,
+		PceSvn: lowTcb.PceSvn, Tcbms: []string{"000000000000000000000000000000000000"}, Fmspc: fmspc})
+	// platform without pck certs cached is refreshed
+	db.PlatformRepository().Create(&types.Platform{QeID: "3518145496973c5e69577195511e9080", PceID: "0000", Fmspc: fmspc})
+
+	conf := config.Load(testConfigFilePath)
+	client := mocks.NewClientMock(200)
+	refreshed, skipped, _ := refreshOutOfDatePckCerts(context.Background(), db, conf, &client)
+	assert.Equal(t, 2, refreshed)
+	assert.Equal(t, 1, skipped)
+
+	// platforms are not evaluated when TcbInfo cannot be refreshed
+	var failingClient domain.HttpClient = &statusClientMock{statusCode: http.StatusNotFound}
+	refreshed, skipped, err := refreshOutOfDatePckCerts(context.Background(), db, conf, &failingClient)
+	assert.Error(t, err)
+	assert.Equal(t, 0, refreshed+skipped)
+}
+
+// createTestPckCertExpiringAt creates a self signed PEM encoded pck cert which expires at notAfter
+func createTestPckCertExpiringAt(notAfter time.Time) (string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Test SGX PCK Certificate"},
+		NotBefore:    notAfter.AddDate(-1, 0, 0),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})), nil
+}
+
+func TestPckCertExpiry(t *testing.T) {
+	notAfter := time.Now().UTC().Add(10*24*time.Hour + time.Hour).Truncate(time.Second)
+	soonExpiring, err := createTestPckCertExpiringAt(notAfter)
+	assert.NoError(t, err)
+	laterExpiring, err := createTestPckCertExpiringAt(notAfter.AddDate(5, 0, 0))
+	assert.NoError(t, err)
+
+	// expiry of the selected pck cert is stored
+	pckCert := &types.PckCert{QeID: "0518145496973c5e69577195511e9080", PceID: "0000", CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb",
+		PceSvn: "0a00", PckCerts: []string{laterExpiring, soonExpiring}, Tcbms: []string{"tcbm0", "tcbm1"}, CertIndex: 1}
+	setPckCertNotAfter(pckCert)
+	assert.True(t, notAfter.Equal(pckCert.NotAfter))
+	assert.True(t, isPckCertExpiring(pckCert, nil))
+	assert.True(t, isPckCertExpiring(pckCert, &config.Configuration{PckCertExpiryWarningDays: 11}))
+	assert.False(t, isPckCertExpiring(pckCert, &config.Configuration{PckCertExpiryWarningDays: 10}))
+
+	// a pck cert whose expiry cannot be read is not reported as expiring
+	invalid := &types.PckCert{PckCerts: []string{"cert0"}}
+	setPckCertNotAfter(invalid)
+	assert.True(t, invalid.NotAfter.IsZero())
+	assert.False(t, isPckCertExpiring(invalid, nil))
+
+	db := memory.NewDatabase()
+	platform := &types.Platform{QeID: pckCert.QeID, PceID: pckCert.PceID, CPUSvn: pckCert.CPUSvn, PceSvn: pckCert.PceSvn,
+		Fmspc: "20606a000000", Ca: "processor"}
+	_, err = db.PlatformRepository().Create(platform)
+	assert.NoError(t, err)
+	_, err = db.PckCertRepository().Create(pckCert)
+	assert.NoError(t, err)
+	// platform whose pck cert was cached before its expiry was stored
+	_, err = db.PlatformRepository().Create(&types.Platform{QeID: "1518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: pckCert.CPUSvn, PceSvn: pckCert.PceSvn})
+	assert.NoError(t, err)
+	_, err = db.PckCertRepository().Create(&types.PckCert{QeID: "1518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: pckCert.CPUSvn, PceSvn: pckCert.PceSvn, PckCerts: []string{laterExpiring}, Tcbms: []string{"tcbm0"}})
+	assert.NoError(t, err)
+
+	router := mux.NewRouter()
+	PlatformInfoOps(router, db, nil, nil)
+	req := httptest.NewRequest(http.MethodGet, "/platforms?pceid=0000", nil)
+	permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataReaderGroupName}}
+	req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+	roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: "type=SCS"}}
+	req = commContext.SetUserRoles(req, roleInfo)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var summaries []PlatformSummary
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &summaries))
+	if assert.Len(t, summaries, 2) {
+		if assert.NotNil(t, summaries[0].PckCertDaysToExpiry) {
+			assert.Equal(t, 10, *summaries[0].PckCertDaysToExpiry)
+		}
+		assert.True(t, summaries[0].PckCertExpiring)
+		assert.Nil(t, summaries[1].PckCertDaysToExpiry)
+		assert.False(t, summaries[1].PckCertExpiring)
+	}
+
+	// only the platform with the expiring pck cert is refreshed
+	mockDB := getMockDatabase()
+	mockDB.PlatformRepository().Create(platform)
+	mockDB.PckCertRepository().Create(pckCert)
+	mockDB.PlatformRepository().Create(&types.Platform{QeID: "1518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: pckCert.CPUSvn, PceSvn: pckCert.PceSvn})
+	conf := config.Load(testConfigFilePath)
+	client := mocks.NewClientMock(200)
+	refreshed, skipped, _ := refreshExpiringPckCerts(context.Background(), mockDB, conf, &client)
+	assert.Equal(t, 1, refreshed)
+	assert.Equal(t, 1, skipped)
+}
+
+func TestPurgeStalePlatforms(t *testing.T) {
+	db := getMockDatabase()
+
+	stale, _ := db.PlatformRepository().Create(&types.Platform{QeID: "1518145496973c5e69577195511e9080", PceID: "0000"})
+	stale.UpdatedTime = time.Now().UTC().AddDate(0, 0, -31)
+	db.PckCertRepository().Create(&types.PckCert{QeID: stale.QeID, PceID: stale.PceID, CPUSvn: "01", PceSvn: "0900"})
+	db.PckCertRepository().Create(&types.PckCert{QeID: stale.QeID, PceID: stale.PceID, CPUSvn: "03", PceSvn: "0a00"})
+
+	active, _ := db.PlatformRepository().Create(&types.Platform{QeID: "2518145496973c5e69577195511e9080", PceID: "0000"})
+	active.UpdatedTime = time.Now().UTC().AddDate(0, 0, -29)
+	db.PckCertRepository().Create(&types.PckCert{QeID: active.QeID, PceID: active.PceID, CPUSvn: "03", PceSvn: "0a00"})
+
+	_, err := purgeStalePlatforms(db, constants.MinPlatformMaxAgeDays-1)
+	assert.Error(t, err)
+
+	purged, err := purgeStalePlatforms(db, 30)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	platforms, _ := db.PlatformRepository().RetrieveAll()
+	if assert.Len(t, platforms, 1) {
+		assert.Equal(t, active.QeID, platforms[0].QeID)
+	}
+	_, err = db.PckCertRepository().Retrieve(&types.PckCert{QeID: stale.QeID, PceID: stale.PceID})
+	assert.Error(t, err)
+	_, err = db.PckCertRepository().Retrieve(&types.PckCert{QeID: active.QeID, PceID: active.PceID})
+	assert.NoError(t, err)
+}
+
+func TestPlatformWithMultiplePceIDs(t *testing.T) {
+	db := memory.NewDatabase()
+	_, err := db.PckCertChainRepository().Create(&types.PckCertChain{Ca: "processor", PckCertChain: "certchain"})
+	assert.NoError(t, err)
+
+	// a multi-package platform presents the same qeid with a pck cert for each of its pce ids
+	var platforms []*types.Platform
+	for _, pceID := range []string{"0000", "0001"} {
+		platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: pceID,
+			CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000", Ca: "processor"}
+		_, err = db.PlatformRepository().Create(platform)
+		assert.NoError(t, err)
+		assert.NoError(t, cachePlatformTcbInfo(context.Background(), db, platform, "020200000000000000000000000000000a00", constants.CacheInsert))
+		_, err = db.PckCertRepository().Create(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn,
+			PceSvn: platform.PceSvn, PckCerts: []string{"cert" + pceID}, Tcbms: []string{"020200000000000000000000000000000a00"}})
+		assert.NoError(t, err)
+		platforms = append(platforms, platform)
+	}
+
+	for _, platform := range platforms {
+		pckCert, _, err := retrievePckCert(context.Background(), db, &types.Platform{QeID: platform.QeID, PceID: platform.PceID,
+			CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn}, nil, nil)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "cert"+platform.PceID, pckCert.PckCerts[0])
+		}
+		_, err = db.PlatformTcbRepository().Retrieve(&types.PlatformTcb{QeID: platform.QeID, PceID: platform.PceID})
+		assert.NoError(t, err)
+	}
+
+	// deleting the platform for one pce id leaves the records of the other
+	assert.NoError(t, deletePlatform(db, platforms[0]))
+	_, err = db.PlatformTcbRepository().Retrieve(&types.PlatformTcb{QeID: platforms[0].QeID, PceID: platforms[0].PceID})
+	assert.Error(t, err)
+	_, err = db.PlatformTcbRepository().Retrieve(&types.PlatformTcb{QeID: platforms[1].QeID, PceID: platforms[1].PceID})
+	assert.NoError(t, err)
+	_, err = db.PckCertRepository().Retrieve(&types.PckCert{QeID: platforms[1].QeID, PceID: platforms[1].PceID})
+	assert.NoError(t, err)
+}
+
 func TestRefreshAllPckCrl(t *testing.T) {
 
 	db := getMockDatabase()
@@ -777,13 +1992,54 @@ func TestRefreshAllPckCrl(t *testing.T) {
 	}
 	db.PckCrlRepository().Create(pckCrl)
 
-	conf := config.Load(testConfigFilePath)
-	client := mocks.NewClientMock(200)
-	err := refreshAllPckCrl(db, conf, &client)
-	assert.Nil(t, err)
-	// Empty configuration given
-	err = refreshAllPckCrl(db, nil, &client)
-	assert.NotNil(t, err)
+	conf := config.Load(testConfigFilePath)
+	client := mocks.NewClientMock(200)
+	err := refreshAllPckCrl(context.Background(), db, conf, &client)
+	assert.Nil(t, err)
+	// Empty configuration given
+	err = refreshAllPckCrl(context.Background(), db, nil, &client)
+	assert.NotNil(t, err)
+}
+
+// pckCrlCaClient records the ca of each PCK CRL requested from PCS
+type pckCrlCaClient struct {
+	cas    []string
+	client domain.HttpClient
+}
+
+func (c *pckCrlCaClient) Do(req *http.Request) (*http.Response, error) {
+	if strings.Contains(req.URL.Path, "pckcrl") {
+		c.cas = append(c.cas, req.URL.Query().Get("ca"))
+	}
+	return c.client.Do(req)
+}
+
+func TestRefreshAllPckCrlAllCas(t *testing.T) {
+	db := memory.NewDatabase()
+	conf := config.Load(testConfigFilePath)
+	recorder := &pckCrlCaClient{client: mocks.NewClientMock(http.StatusOK)}
+	var client domain.HttpClient = recorder
+
+	// nothing to refresh when no PCK CRL is cached
+	err := refreshAllPckCrl(context.Background(), db, conf, &client)
+	assert.Error(t, err)
+	assert.Empty(t, recorder.cas)
+
+	// PCK CRLs of both CAs are cached though no platform was pushed
+	conf.RefreshAllPckCrls = true
+	err = refreshAllPckCrl(context.Background(), db, conf, &client)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{constants.PckCrlCaProcessor, constants.PckCrlCaPlatform}, recorder.cas)
+	for _, ca := range []string{constants.PckCrlCaProcessor, constants.PckCrlCaPlatform} {
+		_, err = db.PckCrlRepository().Retrieve(&types.PckCrl{Ca: ca})
+		assert.NoError(t, err, ca)
+	}
+
+	// and refreshed once cached
+	recorder.cas = nil
+	err = refreshAllPckCrl(context.Background(), db, conf, &client)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{constants.PckCrlCaProcessor, constants.PckCrlCaPlatform}, recorder.cas)
 }
 
 func TestRefreshAllTcbInfo(t *testing.T) {
@@ -801,13 +2057,39 @@ func TestRefreshAllTcbInfo(t *testing.T) {
 
 	conf := config.Load(testConfigFilePath)
 	client := mocks.NewClientMock(200)
-	err := refreshAllTcbInfo(db, conf, &client)
+	err := refreshAllTcbInfo(context.Background(), db, conf, &client)
 	assert.Nil(t, err)
 	// Empty configuration given
-	err = refreshAllTcbInfo(db, nil, &client)
+	err = refreshAllTcbInfo(context.Background(), db, nil, &client)
 	assert.NotNil(t, err)
 }
 
+func TestFetchFmspcTcbInfoChunkedResponse(t *testing.T) {
+	body := testTcbInfoJson
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Sgx-Tcb-Info-Issuer-Chain", "issuerchain")
+		// flushing before the whole body is written makes the response chunked
+		half := len(body) / 2
+		w.Write(body[:half])
+		w.(http.Flusher).Flush()
+		w.Write(body[half:])
+	}))
+	defer server.Close()
+
+	conf := config.Load(testConfigFilePath)
+	conf.ProvServerInfo.ProvServerURL = server.URL
+	var client domain.HttpClient = server.Client()
+
+	tcbInfo, err := fetchFmspcTcbInfo(context.Background(), "20606a000000", conf, &client)
+	assert.NoError(t, err)
+	assert.Equal(t, string(testTcbInfoJson), tcbInfo.TcbInfo)
+
+	// a response without body is still rejected
+	body = nil
+	_, err = fetchFmspcTcbInfo(context.Background(), "20606a000000", conf, &client)
+	assert.Error(t, err)
+}
+
 func TestRefreshAllQE(t *testing.T) {
 
 	db := getMockDatabase()
@@ -821,10 +2103,10 @@ func TestRefreshAllQE(t *testing.T) {
 
 	conf := config.Load(testConfigFilePath)
 	client := mocks.NewClientMock(200)
-	err := refreshAllQE(db, conf, &client)
+	err := refreshAllQE(context.Background(), db, conf, &client)
 	assert.Nil(t, err)
 	// Empty configuration given
-	err = refreshAllQE(db, nil, &client)
+	err = refreshAllQE(context.Background(), db, nil, &client)
 	assert.NotNil(t, err)
 }
 
@@ -835,7 +2117,7 @@ func TestRefreshNonPCKCollaterals(t *testing.T) {
 	client := mocks.NewClientMock(200)
 
 	// No PCK CRL data provided
-	err := refreshNonPCKCollaterals(db, conf, &client)
+	err := refreshNonPCKCollaterals(context.Background(), db, conf, &client)
 	assert.NotNil(t, err)
 
 	platform := &types.Platform{
@@ -857,7 +2139,7 @@ func TestRefreshNonPCKCollaterals(t *testing.T) {
 	db.PckCrlRepository().Create(pckCrl)
 
 	// No TCB INFO data provided
-	err = refreshNonPCKCollaterals(db, conf, &client)
+	err = refreshNonPCKCollaterals(context.Background(), db, conf, &client)
 	assert.NotNil(t, err)
 
 	var tcbInfoJson TcbInfoJSON
@@ -871,7 +2153,7 @@ func TestRefreshNonPCKCollaterals(t *testing.T) {
 	db.FmspcTcbInfoRepository().Create(tcbInfo)
 
 	// No qeInfo data provided
-	err = refreshNonPCKCollaterals(db, conf, &client)
+	err = refreshNonPCKCollaterals(context.Background(), db, conf, &client)
 	assert.NotNil(t, err)
 
 	qeIdentity := &types.QEIdentity{
@@ -882,8 +2164,13 @@ func TestRefreshNonPCKCollaterals(t *testing.T) {
 
 	db.QEIdentityRepository().Create(qeIdentity)
 
-	err = refreshNonPCKCollaterals(db, conf, &client)
+	err = refreshNonPCKCollaterals(context.Background(), db, conf, &client)
 	assert.Nil(t, err)
+
+	// root ca crl is cached by the refresh when not cached yet
+	rootCaCrl, err := db.RootCaCrlRepository().Retrieve()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, rootCaCrl.RootCaCrl)
 }
 
 func TestIsCoolOffTimeout(t *testing.T) {
@@ -907,22 +2194,676 @@ func TestCachePckCrlInfo(t *testing.T) {
 		Ca:              "processor",
 		PckCrlCertChain: "-----BEGIN%20CERTIFICATE-----%0AMIIE9DCCBJqgAwIBAgIUb6rZwuxZc5cIkp6%2Foqqz7HdGyFwwCgYIKoZIzj0EAwIw%0AcDEiMCAGA1UEAwwZSW50ZWwgU0dYIFBDSyBQbGF0Zm9ybSBDQTEaMBgGA1UECgwR%0ASW50ZWwgQ29ycG9yYXRpb24xFDASBgNVBAcMC1NhbnRhIENsYXJhMQswCQYDVQQI%0ADAJDQTELMAkGA1UEBhMCVVMwHhcNMjIwNjIxMTEyNDU2WhcNMjkwNjIxMTEyNDU2%0AWjBwMSIwIAYDVQQDDBlJbnRlbCBTR1ggUENLIENlcnRpZmljYXRlMRowGAYDVQQK%0ADBFJbnRlbCBDb3Jwb3JhdGlvbjEUMBIGA1UEBwwLU2FudGEgQ2xhcmExCzAJBgNV%0ABAgMAkNBMQswCQYDVQQGEwJVUzBZMBMGByqGSM49AgEGCCqGSM49AwEHA0IABOB3%0AWFm1ziJAlu79StgxfAuz8AWCkoiraneuAGgrFExeiukczJvjWdtDTM2O7w8GiZAt%0A1h84AyDRUb%2BHoNaflACjggMQMIIDDDAfBgNVHSMEGDAWgBRZI9OnSqhjVC45cK3g%0ADwcrVyQqtzBvBgNVHR8EaDBmMGSgYqBghl5odHRwczovL3NieC5hcGkudHJ1c3Rl%0AZHNlcnZpY2VzLmludGVsLmNvbS9zZ3gvY2VydGlmaWNhdGlvbi92My9wY2tjcmw%2F%0AY2E9cGxhdGZvcm0mZW5jb2Rpbmc9ZGVyMB0GA1UdDgQWBBQ6mE6WHjgoVSRiUaG%2F%0A0QmQDpX7LjAOBgNVHQ8BAf8EBAMCBsAwDAYDVR0TAQH%2FBAIwADCCAjkGCSqGSIb4%0ATQENAQSCAiowggImMB4GCiqGSIb4TQENAQEEEGzzoSC5Btq3aBE%2BWYxHhwUwggFj%0ABgoqhkiG%2BE0BDQECMIIBUzAQBgsqhkiG%2BE0BDQECAQIBATAQBgsqhkiG%2BE0BDQEC%0AAgIBATAQBgsqhkiG%2BE0BDQECAwIBADAQBgsqhkiG%2BE0BDQECBAIBADAQBgsqhkiG%0A%2BE0BDQECBQIBADAQBgsqhkiG%2BE0BDQECBgIBADAQBgsqhkiG%2BE0BDQECBwIBADAQ%0ABgsqhkiG%2BE0BDQECCAIBADAQBgsqhkiG%2BE0BDQECCQIBADAQBgsqhkiG%2BE0BDQEC%0ACgIBADAQBgsqhkiG%2BE0BDQECCwIBADAQBgsqhkiG%2BE0BDQECDAIBADAQBgsqhkiG%0A%2BE0BDQECDQIBADAQBgsqhkiG%2BE0BDQECDgIBADAQBgsqhkiG%2BE0BDQECDwIBADAQ%0ABgsqhkiG%2BE0BDQECEAIBADAQBgsqhkiG%2BE0BDQECEQIBCTAfBgsqhkiG%2BE0BDQEC%0AEgQQAQEAAAAAAAAAAAAAAAAAADAQBgoqhkiG%2BE0BDQEDBAIAADAUBgoqhkiG%2BE0B%0ADQEEBAYQYGoAAAAwDwYKKoZIhvhNAQ0BBQoBATAeBgoqhkiG%2BE0BDQEGBBDjJ4f6%0AieS5MJrtZWT28t9KMEQGCiqGSIb4TQENAQcwNjAQBgsqhkiG%2BE0BDQEHAQEB%2FzAQ%0ABgsqhkiG%2BE0BDQEHAgEBADAQBgsqhkiG%2BE0BDQEHAwEB%2FzAKBggqhkjOPQQDAgNI%0AADBFAiBJwRZ5Dkvmz41SMH%2FFojZqiPxfzpQo78iqcvTdo0DwTQIhAPzZkuFcwZUV%0Al0yBja8lgLWp%2F8eMKpx5hOAw1dDV2iST%0A-----END%20CERTIFICATE-----%0A",
 	}
-	_, err := cachePckCrlInfo(db, pckCrl, constants.CacheRefresh)
+	_, err := cachePckCrlInfo(context.Background(), db, pckCrl, constants.CacheRefresh)
 	assert.Nil(t, err)
 
-	_, err = cachePckCrlInfo(db, pckCrl, constants.CacheInsert)
+	_, err = cachePckCrlInfo(context.Background(), db, pckCrl, constants.CacheInsert)
 	assert.Nil(t, err)
 
 	pckCrl.Ca = ""
 	pckCrl.PckCrlCertChain = ""
-	_, err = cachePckCrlInfo(db, pckCrl, constants.CacheRefresh)
+	_, err = cachePckCrlInfo(context.Background(), db, pckCrl, constants.CacheRefresh)
 	assert.NotNil(t, err)
 
 	db.PckCrlRepository().Create(pckCrl)
-	_, err = cachePckCrlInfo(db, pckCrl, constants.CacheInsert)
+	_, err = cachePckCrlInfo(context.Background(), db, pckCrl, constants.CacheInsert)
 	assert.NotNil(t, err)
 }
 
+// createTestPckCrl creates a DER encoded CRL with the given validity period
+func createTestPckCrl(thisUpdate, nextUpdate time.Time) ([]byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	issuer := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Test SGX PCK Processor CA"},
+		KeyUsage:     x509.KeyUsageCRLSign,
+		SubjectKeyId: []byte{1, 2, 3, 4},
+	}
+	return x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: thisUpdate,
+		NextUpdate: nextUpdate,
+	}, issuer, key)
+}
+
+// createTestRevokedPckCerts creates a DER encoded CRL revoking the given serial numbers and
+// PEM encoded pck certs with the given serial numbers, all issued by the same CA
+func createTestRevokedPckCerts(revokedSerials, certSerials []int64) ([]byte, []string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test SGX PCK Processor CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		SubjectKeyId:          []byte{1, 2, 3, 4},
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	ca, err := x509.ParseCertificate(caDer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var revoked []pkix.RevokedCertificate
+	for _, serial := range revokedSerials {
+		revoked = append(revoked, pkix.RevokedCertificate{SerialNumber: big.NewInt(serial), RevocationTime: time.Now()})
+	}
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:              big.NewInt(1),
+		ThisUpdate:          time.Now().Add(-time.Hour),
+		NextUpdate:          time.Now().Add(time.Hour),
+		RevokedCertificates: revoked,
+	}, ca, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pckCerts []string
+	for _, serial := range certSerials {
+		certDer, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "Test SGX PCK Certificate"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+		}, ca, &key.PublicKey, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		pemCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDer})
+		pckCerts = append(pckCerts, string(pemCert))
+	}
+	return crl, pckCerts, nil
+}
+
+// createTestPckCertChain creates a root CA, an intermediate CA issued by the root CA and a pck cert
+// issued by the intermediate CA. It returns the PEM encoded pck cert, the url escaped PEM encoded
+// issuer chain as returned by Intel PCS server and the PEM encoded root CA
+func createTestPckCertChain() (string, string, string, error) {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", "", err
+	}
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test SGX Root CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	rootDer, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		return "", "", "", err
+	}
+	root, err := x509.ParseCertificate(rootDer)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", "", err
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Test SGX PCK Processor CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}, root, &caKey.PublicKey, rootKey)
+	if err != nil {
+		return "", "", "", err
+	}
+	ca, err := x509.ParseCertificate(caDer)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	pckKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", "", err
+	}
+	pckDer, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "Test SGX PCK Certificate"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+	}, ca, &pckKey.PublicKey, caKey)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	pemCert := func(der []byte) string {
+		return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	}
+	return pemCert(pckDer), url.QueryEscape(pemCert(caDer) + pemCert(rootDer)), pemCert(rootDer), nil
+}
+
+// useSgxRootCA trusts the self signed root of a url escaped test issuer chain as SGX root CA until
+// the test ends, in place of the Intel SGX Root CA
+func useSgxRootCA(t *testing.T, issuerChain string) {
+	chain, err := url.QueryUnescape(issuerChain)
+	assert.NoError(t, err)
+	certs, err := parsePemCertificates(chain)
+	assert.NoError(t, err)
+	sgxRootCA = certs[len(certs)-1]
+	t.Cleanup(func() {
+		sgxRootCA = parseIntelSgxRootCA()
+	})
+}
+
+var (
+	testSigningRootOnce sync.Once
+	testSigningRootKey  *ecdsa.PrivateKey
+	testSigningRootDer  []byte
+	testSigningRootErr  error
+)
+
+// createTestSigningRoot creates the root CA of the test signing certificates once, so that the signed
+// bodies of a test end at the same root CA which the test trusts as SGX root CA
+func createTestSigningRoot() (*ecdsa.PrivateKey, []byte, error) {
+	testSigningRootOnce.Do(func() {
+		testSigningRootKey, testSigningRootErr = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if testSigningRootErr != nil {
+			return
+		}
+		rootTemplate := &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "Test SGX Root CA"},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(24 * time.Hour),
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}
+		testSigningRootDer, testSigningRootErr = x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate,
+			&testSigningRootKey.PublicKey, testSigningRootKey)
+	})
+	return testSigningRootKey, testSigningRootDer, testSigningRootErr
+}
+
+// createTestSignedPcsBody signs object the way Intel PCS server does, and returns the signed body
+// having object as the value of field along with the url escaped issuer chain of the signature
+func createTestSignedPcsBody(field, object string) ([]byte, string, error) {
+	rootKey, rootDer, err := createTestSigningRoot()
+	if err != nil {
+		return nil, "", err
+	}
+	root, err := x509.ParseCertificate(rootDer)
+	if err != nil {
+		return nil, "", err
+	}
+
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+	signingDer, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Test SGX TCB Signing"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+	}, root, &signingKey.PublicKey, rootKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	digest := sha256.Sum256([]byte(object))
+	r, s, err := ecdsa.Sign(rand.Reader, signingKey, digest[:])
+	if err != nil {
+		return nil, "", err
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	signedBody := fmt.Sprintf(`{"%s":%s,"signature":"%s"}`, field, object, hex.EncodeToString(signature))
+
+	pemCert := func(der []byte) string {
+		return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	}
+	return []byte(signedBody), url.QueryEscape(pemCert(signingDer) + pemCert(rootDer)), nil
+}
+
+// createTestQeIdentity returns a QE identity signed by a test signing certificate, along with
+// its url escaped issuer chain
+func createTestQeIdentity() ([]byte, string, error) {
+	enclaveIdentity := `{"id":"QE","version":2,"issueDate":"2022-06-21T10:00:00Z","nextUpdate":"2022-07-21T10:00:00Z",` +
+		`"tcbEvaluationDataNumber":12,"miscselect":"00000000","miscselectMask":"FFFFFFFF","attributes":"11000000000000000000000000000000",` +
+		`"attributesMask":"FBFFFFFFFFFFFFFF0000000000000000","mrsigner":"8C4F5775D796503E96137F77C68A829A0056AC8DED70140B081B094490C57BFF",` +
+		`"isvprodid":1,"tcbLevels":[{"tcb":{"isvsvn":6},"tcbDate":"2021-11-10T00:00:00Z","tcbStatus":"UpToDate"}]}`
+	return createTestSignedPcsBody("enclaveIdentity", enclaveIdentity)
+}
+
+func TestVerifyQeIdentitySignature(t *testing.T) {
+	qeIdentity, issuerChain, err := createTestQeIdentity()
+	assert.NoError(t, err)
+	otherQeIdentity, otherIssuerChain, err := createTestQeIdentity()
+	assert.NoError(t, err)
+
+	// the issuer chain should end at the trusted SGX root CA rather than at its own root
+	assert.Error(t, verifyQeIdentitySignature(qeIdentity, issuerChain))
+	useSgxRootCA(t, issuerChain)
+	assert.NoError(t, verifyQeIdentitySignature(qeIdentity, issuerChain))
+	assert.NoError(t, verifyQeIdentitySignature(otherQeIdentity, otherIssuerChain))
+
+	// signed by a different signing certificate than the one of the issuer chain
+	assert.Error(t, verifyQeIdentitySignature(qeIdentity, otherIssuerChain))
+	assert.Error(t, verifyQeIdentitySignature(qeIdentity, "invalid"))
+
+	// tampered enclave identity
+	tampered := bytes.Replace(qeIdentity, []byte(`"isvsvn":6`), []byte(`"isvsvn":7`), 1)
+	assert.Error(t, verifyQeIdentitySignature(tampered, issuerChain))
+
+	// tampered signature
+	var signed types.QeIdentityJSON
+	assert.NoError(t, json.Unmarshal(qeIdentity, &signed))
+	tampered = bytes.Replace(qeIdentity, []byte(signed.Signature), []byte(strings.Repeat("0", len(signed.Signature))), 1)
+	assert.Error(t, verifyQeIdentitySignature(tampered, issuerChain))
+	tampered = bytes.Replace(qeIdentity, []byte(signed.Signature), []byte("xyz"), 1)
+	assert.Error(t, verifyQeIdentitySignature(tampered, issuerChain))
+}
+
+func TestSignedPcsBody(t *testing.T) {
+	// fields are not in the order of TcbInfoType, and the response is not compact
+	tcbInfo := `{
+  "version": 2, "fmspc": "00606A000000", "pceId": "0000", "issueDate": "2022-06-21T10:00:00Z",
+  "nextUpdate": "2022-07-21T10:00:00Z", "tcbType": 0, "tcbEvaluationDataNumber": 12,
+  "tcbLevels": [{"tcb": {"sgxtcbcomp01svn": 2, "pcesvn": 11}, "tcbDate": "2021-11-10T00:00:00Z", "tcbStatus": "UpToDate"}]
+}`
+	signedTcbInfo, issuerChain, err := createTestSignedPcsBody("tcbInfo", tcbInfo)
+	assert.NoError(t, err)
+
+	signedObject, signature, err := signedPcsBody(signedTcbInfo, "tcbInfo")
+	assert.NoError(t, err)
+	assert.Equal(t, tcbInfo, string(signedObject))
+	assert.Len(t, signature, 64)
+
+	useSgxRootCA(t, issuerChain)
+	assert.NoError(t, verifyTcbInfoSignature(signedTcbInfo, issuerChain))
+
+	// the signature does not verify over the re-marshaled tcbInfo
+	var tcbInfoJSON TcbInfoJSON
+	assert.NoError(t, json.Unmarshal(signedTcbInfo, &tcbInfoJSON))
+	remarshaled, err := json.Marshal(tcbInfoJSON.TcbInfo)
+	assert.NoError(t, err)
+	assert.NotEqual(t, tcbInfo, string(remarshaled))
+	body, err := json.Marshal(map[string]interface{}{"tcbInfo": json.RawMessage(remarshaled), "signature": tcbInfoJSON.Signature})
+	assert.NoError(t, err)
+	assert.Error(t, verifyTcbInfoSignature(body, issuerChain))
+
+	_, _, err = signedPcsBody(signedTcbInfo, "enclaveIdentity")
+	assert.Error(t, err)
+	_, _, err = signedPcsBody([]byte(`{"tcbInfo":{}}`), "tcbInfo")
+	assert.Error(t, err)
+	_, _, err = signedPcsBody([]byte(`{"tcbInfo":{},"signature":"xyz"}`), "tcbInfo")
+	assert.Error(t, err)
+	_, _, err = signedPcsBody([]byte(`invalid`), "tcbInfo")
+	assert.Error(t, err)
+}
+
+func TestNormalizePckCertChain(t *testing.T) {
+	_, pckCertChain, rootCA, err := createTestPckCertChain()
+	assert.NoError(t, err)
+	chain, err := url.QueryUnescape(pckCertChain)
+	assert.NoError(t, err)
+	intermediateCA := strings.TrimSuffix(chain, rootCA)
+
+	// Sgx-Pck-Certificate-Issuer-Chain header of Intel PCS server, with the root listed first
+	intelHeader := strings.ReplaceAll(url.QueryEscape(rootCA+intermediateCA), "+", "%20")
+	assert.True(t, strings.HasPrefix(intelHeader, "-----BEGIN%20CERTIFICATE-----%0A"))
+
+	for _, cached := range []string{
+		intelHeader,
+		pckCertChain,
+		url.QueryEscape(pckCertChain),
+		rootCA + "\n" + intermediateCA,
+		"\n" + intermediateCA + rootCA + "\n",
+	} {
+		normalized, err := normalizePckCertChain(cached)
+		assert.NoError(t, err)
+		assert.Equal(t, intermediateCA+rootCA, normalized)
+
+		header, err := url.PathUnescape(pckCertChainHeader(cached))
+		assert.NoError(t, err)
+		assert.Equal(t, intermediateCA+rootCA, header)
+	}
+
+	_, otherPckCertChain, otherRootCA, err := createTestPckCertChain()
+	assert.NoError(t, err)
+	_, err = normalizePckCertChain(intermediateCA + otherRootCA)
+	assert.Error(t, err)
+	_, err = normalizePckCertChain(otherPckCertChain + pckCertChain)
+	assert.Error(t, err)
+	_, err = normalizePckCertChain("invalid")
+	assert.Error(t, err)
+	assert.Equal(t, "invalid", pckCertChainHeader("invalid"))
+}
+
+func TestVerifyPckCertChain(t *testing.T) {
+	pckCert, pckCertChain, rootCA, err := createTestPckCertChain()
+	assert.NoError(t, err)
+	otherPckCert, otherPckCertChain, otherRootCA, err := createTestPckCertChain()
+	assert.NoError(t, err)
+
+	writeRootCA := func(rootCA string) string {
+		file, err := ioutil.TempFile("", "sgx-root-ca.pem")
+		assert.NoError(t, err)
+		defer file.Close()
+		_, err = file.WriteString(rootCA)
+		assert.NoError(t, err)
+		return file.Name()
+	}
+	rootCAFile := writeRootCA(rootCA)
+	defer os.Remove(rootCAFile)
+	otherRootCAFile := writeRootCA(otherRootCA)
+	defer os.Remove(otherRootCAFile)
+
+	// the Intel SGX Root CA is trusted when no root ca is configured, not the root of the issuer chain
+	assert.NoError(t, SetSgxRootCA(""))
+	t.Cleanup(func() {
+		assert.NoError(t, SetSgxRootCA(""))
+	})
+	assert.Error(t, verifyPckCertChain(pckCert, pckCertChain))
+	assert.Error(t, verifyPckCertChain(otherPckCert, otherPckCertChain))
+
+	// issuer chain should end at the configured root ca
+	assert.NoError(t, SetSgxRootCA(rootCAFile))
+	assert.NoError(t, verifyPckCertChain(pckCert, pckCertChain))
+	assert.Error(t, verifyPckCertChain(otherPckCert, otherPckCertChain))
+
+	// pck cert issued by a different CA than the issuer chain
+	assert.Error(t, verifyPckCertChain(pckCert, otherPckCertChain))
+	assert.Error(t, verifyPckCertChain("invalid", pckCertChain))
+	assert.Error(t, verifyPckCertChain(pckCert, "invalid"))
+
+	assert.NoError(t, SetSgxRootCA(otherRootCAFile))
+	assert.Error(t, verifyPckCertChain(pckCert, pckCertChain))
+
+	// an invalid root ca file keeps the root ca trusted before
+	assert.Error(t, SetSgxRootCA("/nonexistent/sgx-root-ca.pem"))
+	assert.NoError(t, verifyPckCertChain(otherPckCert, otherPckCertChain))
+
+	// expired pck cert
+	assert.NoError(t, SetSgxRootCA(rootCAFile))
+	useFakeClock(t, time.Now().Add(48*time.Hour))
+	assert.Error(t, verifyPckCertChain(pckCert, pckCertChain))
+}
+
+func TestFetchPckCrlInfoValidity(t *testing.T) {
+	conf := config.Load(testConfigFilePath)
+	client := mocks.NewClientMock(http.StatusOK)
+
+	// sample crl returned by the mock client expired on 18 Apr 2022
+	pckCrl, err := fetchPckCrlInfo(context.Background(), "processor", conf, &client)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2022, time.March, 19, 14, 11, 51, 0, time.UTC), pckCrl.ThisUpdate)
+	assert.Equal(t, time.Date(2022, time.April, 18, 14, 11, 51, 0, time.UTC), pckCrl.NextUpdate)
+	assert.True(t, isPckCrlExpired(pckCrl))
+}
+
+func TestFetchRootCaCrlInfo(t *testing.T) {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test SGX Root CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		SubjectKeyId:          []byte{1, 2, 3, 4},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	rootDer, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+	assert.NoError(t, err)
+	root, err := x509.ParseCertificate(rootDer)
+	assert.NoError(t, err)
+	now := time.Now().UTC().Truncate(time.Second)
+	rootCrl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: now.Add(-time.Hour),
+		NextUpdate: now.Add(365 * 24 * time.Hour),
+	}, root, rootKey)
+	assert.NoError(t, err)
+
+	body := rootCrl
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	rootCAFile, err := ioutil.TempFile("", "sgx-root-ca.pem")
+	assert.NoError(t, err)
+	defer os.Remove(rootCAFile.Name())
+	assert.NoError(t, pem.Encode(rootCAFile, &pem.Block{Type: "CERTIFICATE", Bytes: rootDer}))
+	rootCAFile.Close()
+
+	conf := config.Load(testConfigFilePath)
+	conf.ProvServerInfo.ProvServerURL = server.URL
+	assert.NoError(t, SetSgxRootCA(rootCAFile.Name()))
+	t.Cleanup(func() {
+		assert.NoError(t, SetSgxRootCA(""))
+	})
+	var client domain.HttpClient = server.Client()
+
+	rootCaCrl, err := fetchRootCaCrlInfo(context.Background(), conf, &client)
+	assert.NoError(t, err)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(rootCrl), rootCaCrl.RootCaCrl)
+	assert.Equal(t, now.Add(-time.Hour), rootCaCrl.ThisUpdate)
+	assert.Equal(t, now.Add(365*24*time.Hour), rootCaCrl.NextUpdate)
+	assert.False(t, isRootCaCrlExpired(rootCaCrl))
+
+	// hex encoded CRL as returned by PCS v3
+	body = []byte(hex.EncodeToString(rootCrl))
+	rootCaCrl, err = fetchRootCaCrlInfo(context.Background(), conf, &client)
+	assert.NoError(t, err)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(rootCrl), rootCaCrl.RootCaCrl)
+
+	// CRL not issued by the configured root ca
+	body, err = createTestPckCrl(now.Add(-time.Hour), now.Add(time.Hour))
+	assert.NoError(t, err)
+	conf.SignatureVerificationPolicy = constants.SignatureVerificationEnforce
+	_, err = fetchRootCaCrlInfo(context.Background(), conf, &client)
+	assert.Error(t, err)
+	conf.SignatureVerificationPolicy = constants.SignatureVerificationWarn
+	_, err = fetchRootCaCrlInfo(context.Background(), conf, &client)
+	assert.NoError(t, err)
+
+	body = []byte("not a crl")
+	_, err = fetchRootCaCrlInfo(context.Background(), conf, &client)
+	assert.Error(t, err)
+}
+
+// createTestSignedPckCrl creates a DER encoded PCK CRL issued by a test PCK CA, along with the url
+// escaped issuer chain of the CRL as returned by Intel PCS server
+func createTestSignedPckCrl() ([]byte, string, error) {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test SGX Root CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	rootDer, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		return nil, "", err
+	}
+	root, err := x509.ParseCertificate(rootDer)
+	if err != nil {
+		return nil, "", err
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Test SGX PCK Processor CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		SubjectKeyId:          []byte{1, 2, 3, 4},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, root, &caKey.PublicKey, rootKey)
+	if err != nil {
+		return nil, "", err
+	}
+	ca, err := x509.ParseCertificate(caDer)
+	if err != nil {
+		return nil, "", err
+	}
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now().Add(-time.Hour),
+		NextUpdate: time.Now().Add(24 * time.Hour),
+	}, ca, caKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pemCert := func(der []byte) string {
+		return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	}
+	return crl, url.QueryEscape(pemCert(caDer) + pemCert(rootDer)), nil
+}
+
+func TestVerifyPckCrlSignature(t *testing.T) {
+	pckCrl, issuerChain, err := createTestSignedPckCrl()
+	assert.NoError(t, err)
+	_, otherIssuerChain, err := createTestSignedPckCrl()
+	assert.NoError(t, err)
+	crl, err := x509.ParseDERCRL(pckCrl)
+	assert.NoError(t, err)
+
+	assert.Error(t, verifyPckCrlSignature(crl, issuerChain))
+	useSgxRootCA(t, issuerChain)
+	assert.NoError(t, verifyPckCrlSignature(crl, issuerChain))
+
+	// issued by a different CA than the one of the issuer chain
+	assert.Error(t, verifyPckCrlSignature(crl, otherIssuerChain))
+	assert.Error(t, verifyPckCrlSignature(crl, "invalid"))
+}
+
+func TestSignatureVerificationPolicy(t *testing.T) {
+	tcbInfo, tcbInfoIssuerChain, err := createTestSignedPcsBody("tcbInfo", `{"version":2,"fmspc":"20606a000000","pceId":"0000",`+
+		`"issueDate":"2022-06-21T10:00:00Z","nextUpdate":"2022-07-21T10:00:00Z","tcbType":0,"tcbEvaluationDataNumber":12,`+
+		`"tcbLevels":[{"tcb":{"sgxtcbcomp01svn":2,"pcesvn":11},"tcbDate":"2021-11-10T00:00:00Z","tcbStatus":"UpToDate"}]}`)
+	assert.NoError(t, err)
+	qeIdentity, qeIssuerChain, err := createTestQeIdentity()
+	assert.NoError(t, err)
+	pckCrl, _, err := createTestSignedPckCrl()
+	assert.NoError(t, err)
+	_, otherPckCrlIssuerChain, err := createTestSignedPckCrl()
+	assert.NoError(t, err)
+
+	// tampered TcbInfo and QE identity, and a PCK CRL not issued by the CA of its issuer chain
+	tamperedTcbInfo := bytes.Replace(tcbInfo, []byte(`"tcbStatus":"UpToDate"`), []byte(`"tcbStatus":"OutOfDate"`), 1)
+	tamperedQeIdentity := bytes.Replace(qeIdentity, []byte(`"isvsvn":6`), []byte(`"isvsvn":7`), 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/tcb"):
+			w.Header().Set("Sgx-Tcb-Info-Issuer-Chain", tcbInfoIssuerChain)
+			w.Write(tamperedTcbInfo)
+		case strings.HasSuffix(r.URL.Path, "/qe/identity"):
+			w.Header().Set("Sgx-Enclave-Identity-Issuer-Chain", qeIssuerChain)
+			w.Write(tamperedQeIdentity)
+		case strings.HasSuffix(r.URL.Path, "/pckcrl"):
+			w.Header().Set("Sgx-Pck-Crl-Issuer-Chain", otherPckCrlIssuerChain)
+			w.Write(pckCrl)
+		}
+	}))
+	defer server.Close()
+
+	conf := config.Load(testConfigFilePath)
+	conf.ProvServerInfo.ProvServerURL = server.URL
+	var client domain.HttpClient = server.Client()
+
+	for _, policy := range []string{"", constants.SignatureVerificationEnforce, constants.SignatureVerificationWarn, constants.SignatureVerificationOff} {
+		conf.SignatureVerificationPolicy = policy
+		fmspcTcbInfo, tcbInfoErr := fetchFmspcTcbInfo(context.Background(), "20606a000000", conf, &client)
+		qeInfo, qeErr := fetchQeIdentityInfo(context.Background(), conf, &client)
+		pckCrlInfo, pckCrlErr := fetchPckCrlInfo(context.Background(), "processor", conf, &client)
+
+		if policy == constants.SignatureVerificationEnforce {
+			assert.Error(t, tcbInfoErr)
+			assert.Error(t, qeErr)
+			assert.Error(t, pckCrlErr)
+			continue
+		}
+		// collaterals failing verification are cached with warn, the default, and with off
+		assert.NoError(t, tcbInfoErr, policy)
+		assert.Equal(t, string(tamperedTcbInfo), fmspcTcbInfo.TcbInfo)
+		assert.NoError(t, qeErr, policy)
+		assert.Equal(t, string(tamperedQeIdentity), qeInfo.QeInfo)
+		assert.NoError(t, pckCrlErr, policy)
+		assert.Equal(t, base64.StdEncoding.EncodeToString(pckCrl), pckCrlInfo.PckCrl)
+	}
+
+	// PCK certificate not issued by the CA of the issuer chain
+	pckCert, _, _, err := createTestPckCertChain()
+	assert.NoError(t, err)
+	_, otherPckCertChain, _, err := createTestPckCertChain()
+	assert.NoError(t, err)
+	verify := func() error {
+		return verifyPckCertChain(pckCert, otherPckCertChain)
+	}
+	conf.SignatureVerificationPolicy = constants.SignatureVerificationEnforce
+	assert.Error(t, checkSignature(conf, "pck certificate issuer chain", verify))
+	conf.SignatureVerificationPolicy = constants.SignatureVerificationWarn
+	assert.NoError(t, checkSignature(conf, "pck certificate issuer chain", verify))
+	conf.SignatureVerificationPolicy = constants.SignatureVerificationOff
+	assert.NoError(t, checkSignature(conf, "pck certificate issuer chain", func() error {
+		t.Error("verification should be skipped when the policy is off")
+		return nil
+	}))
+}
+
+func TestIsPckCrlExpired(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	validCrl, err := createTestPckCrl(now.Add(-time.Hour), now.Add(30*24*time.Hour))
+	assert.NoError(t, err)
+	crl, err := x509.ParseDERCRL(validCrl)
+	assert.NoError(t, err)
+	pckCrl := &types.PckCrl{Ca: "processor", ThisUpdate: crl.TBSCertList.ThisUpdate, NextUpdate: crl.TBSCertList.NextUpdate}
+	assert.False(t, isPckCrlExpired(pckCrl))
+
+	expiredCrl, err := createTestPckCrl(now.Add(-31*24*time.Hour), now.Add(-24*time.Hour))
+	assert.NoError(t, err)
+	crl, err = x509.ParseDERCRL(expiredCrl)
+	assert.NoError(t, err)
+	pckCrl = &types.PckCrl{Ca: "processor", ThisUpdate: crl.TBSCertList.ThisUpdate, NextUpdate: crl.TBSCertList.NextUpdate}
+	assert.True(t, isPckCrlExpired(pckCrl))
+
+	// crl cached without validity
+	assert.False(t, isPckCrlExpired(&types.PckCrl{Ca: "processor"}))
+}
+
 func TestCacheFmspcTcbInfo(t *testing.T) {
 	db := getMockDatabase()
 	var tcbInfoJson TcbInfoJSON
@@ -934,22 +2875,54 @@ func TestCacheFmspcTcbInfo(t *testing.T) {
 		TcbInfo: string(testTcbInfoJson),
 	}
 
-	_, err := cacheFmspcTcbInfo(db, tcbInfo, constants.CacheRefresh)
+	_, err := cacheFmspcTcbInfo(context.Background(), db, tcbInfo, constants.CacheRefresh)
 	assert.Nil(t, err)
 
-	_, err = cacheFmspcTcbInfo(db, tcbInfo, constants.CacheInsert)
+	_, err = cacheFmspcTcbInfo(context.Background(), db, tcbInfo, constants.CacheInsert)
 	assert.Nil(t, err)
 
+	// already cached by a concurrent push
 	db.FmspcTcbInfoRepository().Create(tcbInfo)
-	_, err = cacheFmspcTcbInfo(db, tcbInfo, constants.CacheInsert)
-	assert.NotNil(t, err)
+	_, err = cacheFmspcTcbInfo(context.Background(), db, tcbInfo, constants.CacheInsert)
+	assert.Nil(t, err)
 
 	tcbInfo.Fmspc = ""
-	_, err = cacheFmspcTcbInfo(db, tcbInfo, constants.CacheRefresh)
+	_, err = cacheFmspcTcbInfo(context.Background(), db, tcbInfo, constants.CacheRefresh)
 	assert.NotNil(t, err)
 
 }
 
+func TestCacheTcbLevels(t *testing.T) {
+	db := memory.NewDatabase()
+	tcbInfo := &types.FmspcTcbInfo{Fmspc: "20606a000000", TcbInfo: string(testTcbInfoJson)}
+
+	// levels are stored only when normalization of TcbInfo is enabled
+	cacheTcbLevels(db, tcbInfo, &config.Configuration{})
+	levels, err := db.TcbLevelRepository().RetrieveByFmspc(tcbInfo.Fmspc)
+	assert.NoError(t, err)
+	assert.Empty(t, levels)
+
+	conf := &config.Configuration{NormalizeTcbInfo: true}
+	cacheTcbLevels(db, tcbInfo, conf)
+	levels, err = db.TcbLevelRepository().RetrieveByFmspc(tcbInfo.Fmspc)
+	assert.NoError(t, err)
+	if assert.Len(t, levels, 3) {
+		assert.Equal(t, 1, levels[0].Level)
+		assert.Equal(t, "UpToDate", levels[0].TcbStatus)
+		assert.Equal(t, uint16(10), levels[0].PceSvn)
+		assert.Equal(t, time.Date(2020, 5, 28, 0, 0, 0, 0, time.UTC), levels[0].TcbDate)
+		assert.Equal(t, "OutOfDate", levels[1].TcbStatus)
+		assert.Equal(t, uint16(9), levels[1].PceSvn)
+	}
+
+	// levels of a TcbInfo which cannot be parsed are not stored, the previous levels are kept
+	cacheTcbLevels(db, &types.FmspcTcbInfo{Fmspc: tcbInfo.Fmspc, TcbInfo: strings.Replace(string(testTcbInfoJson),
+		"2020-05-28T00:00:00Z", "28-05-2020", 1)}, conf)
+	levels, err = db.TcbLevelRepository().RetrieveByFmspc(tcbInfo.Fmspc)
+	assert.NoError(t, err)
+	assert.Len(t, levels, 3)
+}
+
 func TestCacheQeIdentityInfo(t *testing.T) {
 	db := getMockDatabase()
 	qeIdentity := &types.QEIdentity{
@@ -958,26 +2931,86 @@ func TestCacheQeIdentityInfo(t *testing.T) {
 		QeIssuerChain: "-----BEGIN%20CERTIFICATE-----%0AMIIE9DCCBJqgAwIBAgIUb6rZwuxZc5cIkp6%2Foqqz7HdGyFwwCgYIKoZIzj0EAwIw%0AcDEiMCAGA1UEAwwZSW50ZWwgU0dYIFBDSyBQbGF0Zm9ybSBDQTEaMBgGA1UECgwR%0ASW50ZWwgQ29ycG9yYXRpb24xFDASBgNVBAcMC1NhbnRhIENsYXJhMQswCQYDVQQI%0ADAJDQTELMAkGA1UEBhMCVVMwHhcNMjIwNjIxMTEyNDU2WhcNMjkwNjIxMTEyNDU2%0AWjBwMSIwIAYDVQQDDBlJbnRlbCBTR1ggUENLIENlcnRpZmljYXRlMRowGAYDVQQK%0ADBFJbnRlbCBDb3Jwb3JhdGlvbjEUMBIGA1UEBwwLU2FudGEgQ2xhcmExCzAJBgNV%0ABAgMAkNBMQswCQYDVQQGEwJVUzBZMBMGByqGSM49AgEGCCqGSM49AwEHA0IABOB3%0AWFm1ziJAlu79StgxfAuz8AWCkoiraneuAGgrFExeiukczJvjWdtDTM2O7w8GiZAt%0A1h84AyDRUb%2BHoNaflACjggMQMIIDDDAfBgNVHSMEGDAWgBRZI9OnSqhjVC45cK3g%0ADwcrVyQqtzBvBgNVHR8EaDBmMGSgYqBghl5odHRwczovL3NieC5hcGkudHJ1c3Rl%0AZHNlcnZpY2VzLmludGVsLmNvbS9zZ3gvY2VydGlmaWNhdGlvbi92My9wY2tjcmw%2F%0AY2E9cGxhdGZvcm0mZW5jb2Rpbmc9ZGVyMB0GA1UdDgQWBBQ6mE6WHjgoVSRiUaG%2F%0A0QmQDpX7LjAOBgNVHQ8BAf8EBAMCBsAwDAYDVR0TAQH%2FBAIwADCCAjkGCSqGSIb4%0ATQENAQSCAiowggImMB4GCiqGSIb4TQENAQEEEGzzoSC5Btq3aBE%2BWYxHhwUwggFj%0ABgoqhkiG%2BE0BDQECMIIBUzAQBgsqhkiG%2BE0BDQECAQIBATAQBgsqhkiG%2BE0BDQEC%0AAgIBATAQBgsqhkiG%2BE0BDQECAwIBADAQBgsqhkiG%2BE0BDQECBAIBADAQBgsqhkiG%0A%2BE0BDQECBQIBADAQBgsqhkiG%2BE0BDQECBgIBADAQBgsqhkiG%2BE0BDQECBwIBADAQ%0ABgsqhkiG%2BE0BDQECCAIBADAQBgsqhkiG%2BE0BDQECCQIBADAQBgsqhkiG%2BE0BDQEC%0ACgIBADAQBgsqhkiG%2BE0BDQECCwIBADAQBgsqhkiG%2BE0BDQECDAIBADAQBgsqhkiG%0A%2BE0BDQECDQIBADAQBgsqhkiG%2BE0BDQECDgIBADAQBgsqhkiG%2BE0BDQECDwIBADAQ%0ABgsqhkiG%2BE0BDQECEAIBADAQBgsqhkiG%2BE0BDQECEQIBCTAfBgsqhkiG%2BE0BDQEC%0AEgQQAQEAAAAAAAAAAAAAAAAAADAQBgoqhkiG%2BE0BDQEDBAIAADAUBgoqhkiG%2BE0B%0ADQEEBAYQYGoAAAAwDwYKKoZIhvhNAQ0BBQoBATAeBgoqhkiG%2BE0BDQEGBBDjJ4f6%0AieS5MJrtZWT28t9KMEQGCiqGSIb4TQENAQcwNjAQBgsqhkiG%2BE0BDQEHAQEB%2FzAQ%0ABgsqhkiG%2BE0BDQEHAgEBADAQBgsqhkiG%2BE0BDQEHAwEB%2FzAKBggqhkjOPQQDAgNI%0AADBFAiBJwRZ5Dkvmz41SMH%2FFojZqiPxfzpQo78iqcvTdo0DwTQIhAPzZkuFcwZUV%0Al0yBja8lgLWp%2F8eMKpx5hOAw1dDV2iST%0A-----END%20CERTIFICATE-----%0A",
 	}
 
-	_, err := cacheQeIdentityInfo(db, qeIdentity, constants.CacheRefresh)
+	_, err := cacheQeIdentityInfo(context.Background(), db, qeIdentity, constants.CacheRefresh)
 	assert.Nil(t, err)
 
-	_, err = cacheQeIdentityInfo(db, qeIdentity, constants.CacheInsert)
+	_, err = cacheQeIdentityInfo(context.Background(), db, qeIdentity, constants.CacheInsert)
 	assert.Nil(t, err)
 
-	// negative tests
+	// already cached by a concurrent push
 	db.QEIdentityRepository().Create(qeIdentity)
-	_, err = cacheQeIdentityInfo(db, qeIdentity, constants.CacheInsert)
-	assert.NotNil(t, err)
+	_, err = cacheQeIdentityInfo(context.Background(), db, qeIdentity, constants.CacheInsert)
+	assert.Nil(t, err)
 
+	// negative tests
 	qeIdentity.QeInfo = ""
-	_, err = cacheQeIdentityInfo(db, qeIdentity, constants.CacheRefresh)
+	_, err = cacheQeIdentityInfo(context.Background(), db, qeIdentity, constants.CacheRefresh)
 	assert.NotNil(t, err)
 }
 
+func TestDedupQEIdentities(t *testing.T) {
+	db := memory.NewDatabase()
+	assert.NoError(t, DedupQEIdentities(db))
+
+	now := time.Now()
+	_, err := db.QEIdentityRepository().Create(&types.QEIdentity{ID: "QE", QeInfo: "stale", UpdatedTime: now.Add(-time.Hour)})
+	assert.NoError(t, err)
+	_, err = db.QEIdentityRepository().Create(&types.QEIdentity{ID: "QE1", QeInfo: "latest", UpdatedTime: now})
+	assert.NoError(t, err)
+	_, err = db.QEIdentityRepository().Create(&types.QEIdentity{ID: "QE2", QeInfo: "older", UpdatedTime: now.Add(-2 * time.Hour)})
+	assert.NoError(t, err)
+
+	// the most recently updated record is served with duplicates present, and is the only one kept
+	qe, err := db.QEIdentityRepository().Retrieve()
+	assert.NoError(t, err)
+	assert.Equal(t, "latest", qe.QeInfo)
+	assert.NoError(t, DedupQEIdentities(db))
+	qes, err := db.QEIdentityRepository().RetrieveAll()
+	assert.NoError(t, err)
+	assert.Len(t, qes, 1)
+	assert.Equal(t, "latest", qes[0].QeInfo)
+}
+
+// unpreparedDatabase fails to migrate or to be reached
+type unpreparedDatabase struct {
+	*memory.Database
+	migrateErr error
+	pingErr    error
+	migrated   bool
+}
+
+func (d *unpreparedDatabase) Migrate() error {
+	d.migrated = d.migrateErr == nil
+	return d.migrateErr
+}
+
+func (d *unpreparedDatabase) Ping() error {
+	return d.pingErr
+}
+
+func TestPrepareDatabase(t *testing.T) {
+	db := &unpreparedDatabase{Database: memory.NewDatabase()}
+	assert.NoError(t, PrepareDatabase(db))
+	assert.True(t, db.migrated)
+
+	// startup is aborted when the schema could not be migrated
+	db = &unpreparedDatabase{Database: memory.NewDatabase(), migrateErr: errors.New("failed to migrate platforms table")}
+	err := PrepareDatabase(db)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "failed to migrate platforms table")
+	}
+
+	db = &unpreparedDatabase{Database: memory.NewDatabase(), pingErr: errors.New("connection refused")}
+	err = PrepareDatabase(db)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "database health check failed")
+	}
+}
+
 func TestInitAutoRefreshTimer(t *testing.T) {
 	db := getMockDatabase()
 	refreshTrigger := make(chan constants.RefreshTrigger)
-	err := InitAutoRefreshTimer(db, refreshTrigger, 1)
+	err := InitAutoRefreshTimer(db, refreshTrigger, 1, config.TimerRefresh{})
 	assert.Nil(t, err)
 }
 
@@ -985,37 +3018,37 @@ func TestWithNegativeCases(t *testing.T) {
 	conf := config.Load(testConfigFilePath)
 	client := mocks.NewClientMock(400)
 
-	_, err := fetchPckCrlInfo("processor", conf, &client)
+	_, err := fetchPckCrlInfo(context.Background(), "processor", conf, &client)
 	assert.NotNil(t, err)
 
-	_, err = fetchFmspcTcbInfo("0387928700021483000", conf, &client)
+	_, err = fetchFmspcTcbInfo(context.Background(), "0387928700021483000", conf, &client)
 	assert.NotNil(t, err)
 
-	_, err = fetchQeIdentityInfo(conf, &client)
+	_, err = fetchQeIdentityInfo(context.Background(), conf, &client)
 	assert.NotNil(t, err)
 
 	client = mocks.NewClientMock(201)
-	_, err = fetchPckCrlInfo("processor", conf, &client)
+	_, err = fetchPckCrlInfo(context.Background(), "processor", conf, &client)
 	assert.NotNil(t, err)
-	_, err = fetchFmspcTcbInfo("0387928700021483000", conf, &client)
+	_, err = fetchFmspcTcbInfo(context.Background(), "0387928700021483000", conf, &client)
 	assert.NotNil(t, err)
-	_, err = fetchQeIdentityInfo(conf, &client)
+	_, err = fetchQeIdentityInfo(context.Background(), conf, &client)
 	assert.NotNil(t, err)
 
 	client = mocks.NewClientMock(204)
-	_, err = fetchPckCrlInfo("processor", conf, &client)
+	_, err = fetchPckCrlInfo(context.Background(), "processor", conf, &client)
 	assert.NotNil(t, err)
-	_, err = fetchFmspcTcbInfo("0387928700021483000", conf, &client)
+	_, err = fetchFmspcTcbInfo(context.Background(), "0387928700021483000", conf, &client)
 	assert.NotNil(t, err)
-	_, err = fetchQeIdentityInfo(conf, &client)
+	_, err = fetchQeIdentityInfo(context.Background(), conf, &client)
 	assert.NotNil(t, err)
 
 	client = mocks.NewClientMock(205)
-	_, err = fetchPckCrlInfo("processor", conf, &client)
+	_, err = fetchPckCrlInfo(context.Background(), "processor", conf, &client)
 	assert.NotNil(t, err)
-	_, err = fetchFmspcTcbInfo("0387928700021483000", conf, &client)
+	_, err = fetchFmspcTcbInfo(context.Background(), "0387928700021483000", conf, &client)
 	assert.NotNil(t, err)
-	_, err = fetchQeIdentityInfo(conf, &client)
+	_, err = fetchQeIdentityInfo(context.Background(), conf, &client)
 	assert.NotNil(t, err)
 
 }
@@ -1061,6 +3094,46 @@ func TestGetPPID(t *testing.T) {
 	}
 }
 
+func TestGetUnknownPlatformInfoFields(t *testing.T) {
+	body := []byte(`{"qe_id":"4518145496973c5e69577195511e9080","pce_id":"0000","new_field":"value","another_field":1}`)
+	assert.Equal(t, []string{"another_field", "new_field"}, getUnknownPlatformInfoFields(body))
+
+	body = []byte(`{"qe_id":"4518145496973c5e69577195511e9080","pce_id":"0000"}`)
+	assert.Empty(t, getUnknownPlatformInfoFields(body))
+
+	assert.Empty(t, getUnknownPlatformInfoFields([]byte(`{"qe_id":`)))
+}
+
+func TestGetTcbLevelFromTcbm(t *testing.T) {
+	tcbLevel, err := getTcbLevelFromTcbm("0203000000000000000000000000000b0a00")
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(2), tcbLevel.SgxTcbComp01Svn)
+	assert.Equal(t, uint8(3), tcbLevel.SgxTcbComp02Svn)
+	assert.Equal(t, uint8(11), tcbLevel.SgxTcbComp16Svn)
+	assert.Equal(t, uint16(10), tcbLevel.PceSvn)
+
+	_, err = getTcbLevelFromTcbm("02030000")
+	assert.Error(t, err)
+
+	_, err = getTcbLevelFromTcbm("invalid")
+	assert.Error(t, err)
+}
+
+func TestIsTcbStatusAccepted(t *testing.T) {
+	lenient := &config.Configuration{}
+	strict := &config.Configuration{AcceptedTcbStatuses: []string{"UpToDate"}}
+
+	assert.True(t, isTcbStatusAccepted("UpToDate", nil))
+	assert.True(t, isTcbStatusAccepted("UpToDate", lenient))
+	assert.True(t, isTcbStatusAccepted("ConfigurationNeeded", lenient))
+	assert.False(t, isTcbStatusAccepted("OutOfDate", lenient))
+	assert.False(t, isTcbStatusAccepted("", lenient))
+
+	assert.True(t, isTcbStatusAccepted("UpToDate", strict))
+	assert.False(t, isTcbStatusAccepted("ConfigurationNeeded", strict))
+	assert.False(t, isTcbStatusAccepted("SWHardeningNeeded", strict))
+}
+
 var pckCert = `-----BEGIN CERTIFICATE-----
 MIIE5jCCBI2gAwIBAgIUNpxbNVz2bbSgFUKTNewIizm6qHEwCgYIKoZIzj0EAwIwcDEiMCAGA1UE
 AwwZSW50ZWwgU0dYIFBDSyBQbGF0Zm9ybSBDQTEaMBgGA1UECgwRSW50ZWwgQ29ycG9yYXRpb24x
@@ -1086,3 +3159,716 @@ CyqGSIb4TQENAQcCAQH/MBAGCyqGSIb4TQENAQcDAQH/MAoGCCqGSM49BAMCA0cAMEQCIE6gwTxP
 C2kacUlGEjRWcz84BTnqZExC87NZqrlCF/HyAiA0qmvZocv+UQ1VnqtGQFrku/HdZdW171dBr4v2
 UYU+3g==
 -----END CERTIFICATE-----`
+
+func TestPrefetchCollaterals(t *testing.T) {
+	db := getMockDatabase()
+	db.PlatformRepository().Create(&types.Platform{QeID: "qeid1", PceID: "0000", Fmspc: "20606a000000"})
+	db.PlatformRepository().Create(&types.Platform{QeID: "qeid2", PceID: "0000", Fmspc: "20606a000000"})
+
+	conf := config.Load(testConfigFilePath)
+	client := mocks.NewClientMock(200)
+	err := PrefetchCollaterals(db, conf, &client)
+	assert.Nil(t, err)
+
+	qeIdentity, _ := db.QEIdentityRepository().Retrieve()
+	assert.NotNil(t, qeIdentity)
+	fmspcTcb, _ := db.FmspcTcbInfoRepository().Retrieve(&types.FmspcTcbInfo{Fmspc: "20606a000000"})
+	assert.NotNil(t, fmspcTcb)
+
+	// QE identity already cached is refreshed
+	err = PrefetchCollaterals(db, conf, &client)
+	assert.Nil(t, err)
+
+	// PCS unreachable
+	client = mocks.NewClientMock(http.StatusBadRequest)
+	err = PrefetchCollaterals(getMockDatabase(), conf, &client)
+	assert.NotNil(t, err)
+}
+
+func TestCachePendingCollaterals(t *testing.T) {
+	db := getMockDatabase()
+	db.PlatformRepository().Create(&types.Platform{QeID: "qeid1", PceID: "0000", Fmspc: "20606a000000", Ca: "processor"})
+	db.PlatformRepository().Create(&types.Platform{QeID: "qeid2", PceID: "0000", Fmspc: "20606a000000", Ca: "processor"})
+
+	conf := config.Load(testConfigFilePath)
+	conf.CachingModel = constants.PreCachingModel
+	client := mocks.NewClientMock(200)
+
+	// none of the collaterals of pre-cached platforms can be refreshed before they are cached
+	err := refreshNonPCKCollaterals(context.Background(), db, conf, &client)
+	assert.NotNil(t, err)
+
+	err = cachePendingCollaterals(context.Background(), db, conf, &client)
+	assert.Nil(t, err)
+	qeIdentity, _ := db.QEIdentityRepository().Retrieve()
+	assert.NotNil(t, qeIdentity)
+	fmspcTcb, _ := db.FmspcTcbInfoRepository().Retrieve(&types.FmspcTcbInfo{Fmspc: "20606a000000"})
+	assert.NotNil(t, fmspcTcb)
+	pckCrl, _ := db.PckCrlRepository().Retrieve(&types.PckCrl{Ca: "processor"})
+	assert.NotNil(t, pckCrl)
+
+	err = refreshNonPCKCollaterals(context.Background(), db, conf, &client)
+	assert.Nil(t, err)
+
+	// PCS unreachable
+	client = mocks.NewClientMock(http.StatusBadRequest)
+	db = getMockDatabase()
+	db.QEIdentityRepository().Create(&types.QEIdentity{ID: "QE"})
+	db.PlatformRepository().Create(&types.Platform{QeID: "qeid1", PceID: "0000", Fmspc: "20606a000000", Ca: "processor"})
+	err = cachePendingCollaterals(context.Background(), db, conf, &client)
+	assert.NotNil(t, err)
+}
+
+func TestForEachPlatformPage(t *testing.T) {
+	db := getMockDatabase()
+	for i := 0; i < 5; i++ {
+		db.PlatformRepository().Create(&types.Platform{QeID: fmt.Sprintf("qeid%d", i), PceID: "0000"})
+	}
+
+	var pageSizes []int
+	var qeIDs []string
+	err := forEachPlatformPage(db, 2, func(platforms types.Platforms) {
+		pageSizes = append(pageSizes, len(platforms))
+		for _, platform := range platforms {
+			qeIDs = append(qeIDs, platform.QeID)
+		}
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 2, 1}, pageSizes)
+	assert.Equal(t, []string{"qeid0", "qeid1", "qeid2", "qeid3", "qeid4"}, qeIDs)
+
+	// page size dividing the number of platforms
+	pageSizes = nil
+	err = forEachPlatformPage(db, 5, func(platforms types.Platforms) {
+		pageSizes = append(pageSizes, len(platforms))
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{5}, pageSizes)
+
+	err = forEachPlatformPage(db, 0, func(platforms types.Platforms) {})
+	assert.NotNil(t, err)
+}
+
+func TestPlatformTcbStatusWithMemoryDatabase(t *testing.T) {
+	db := memory.NewDatabase()
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000"}
+
+	_, err := platformTcbStatus(db, platform, nil)
+	assert.Equal(t, http.StatusNotFound, err.(*resourceError).StatusCode)
+
+	_, err = db.PckCertRepository().Create(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID,
+		CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn, Tcbms: []string{"ffffffffffffffffffffffffffffffffffff"}})
+	assert.NoError(t, err)
+	_, err = platformTcbStatus(db, platform, nil)
+	assert.Equal(t, http.StatusNotFound, err.(*resourceError).StatusCode)
+
+	_, err = db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: platform.Fmspc,
+		TcbInfo: string(testTcbInfoJson)})
+	assert.NoError(t, err)
+	status, err := platformTcbStatus(db, platform, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "UpToDate", status)
+}
+
+func TestPlatformTcbStatusWithShuffledTcbLevels(t *testing.T) {
+	var tcbInfo TcbInfoJSON
+	assert.NoError(t, json.Unmarshal(testTcbInfoJson, &tcbInfo))
+	levels := tcbInfo.TcbInfo.TcbLevels
+	assert.Len(t, levels, 3)
+
+	// lowest level first, which a raw tcb at the highest level also matches
+	tcbInfo.TcbInfo.TcbLevels = []TcbLevelsType{levels[2], levels[0], levels[1]}
+	shuffled, err := json.Marshal(tcbInfo)
+	assert.NoError(t, err)
+
+	sortTcbLevels(tcbInfo.TcbInfo.TcbLevels)
+	assert.Equal(t, levels, tcbInfo.TcbInfo.TcbLevels)
+
+	db := memory.NewDatabase()
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000"}
+	_, err = db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: platform.Fmspc, TcbInfo: string(shuffled)})
+	assert.NoError(t, err)
+	pckCert := &types.PckCert{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn,
+		PceSvn: platform.PceSvn, Tcbms: []string{"020200000000000000000000000000000a00"}}
+	_, err = db.PckCertRepository().Create(pckCert)
+	assert.NoError(t, err)
+
+	status, err := platformTcbStatus(db, platform, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "UpToDate", status)
+
+	pckCert.Tcbms = []string{"010100000000000000000000000000000900"}
+	assert.NoError(t, db.PckCertRepository().Update(pckCert))
+	status, err = platformTcbStatus(db, platform, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "OutOfDate", status)
+}
+
+func TestPlatformTcbStatusFromNormalizedTcbLevels(t *testing.T) {
+	fc := useFakeClock(t, time.Date(2022, 6, 21, 0, 0, 0, 0, time.UTC))
+	db := memory.NewDatabase()
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000"}
+	tcbInfo := &types.FmspcTcbInfo{Fmspc: platform.Fmspc, TcbInfo: string(testTcbInfoJson), UpdatedTime: clock.Now().UTC()}
+	_, err := db.FmspcTcbInfoRepository().Create(tcbInfo)
+	assert.NoError(t, err)
+	_, err = db.PckCertRepository().Create(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn,
+		PceSvn: platform.PceSvn, Tcbms: []string{"010100000000000000000000000000000900"}})
+	assert.NoError(t, err)
+
+	// levels of TcbInfo cached before normalization was enabled are not stored, TcbInfo is parsed
+	conf := &config.Configuration{NormalizeTcbInfo: true}
+	status, err := platformTcbStatus(db, platform, conf)
+	assert.NoError(t, err)
+	assert.Equal(t, "OutOfDate", status)
+
+	// the stored levels are compared in place of TcbInfo, which is told apart here by a status
+	// only the stored levels have
+	levels, err := normalizeTcbLevels(tcbInfo)
+	assert.NoError(t, err)
+	levels[1].TcbStatus = "ConfigurationNeeded"
+	assert.NoError(t, db.TcbLevelRepository().Replace(platform.Fmspc, levels))
+	status, err = platformTcbStatus(db, platform, conf)
+	assert.NoError(t, err)
+	assert.Equal(t, "ConfigurationNeeded", status)
+	selection, err := selectPlatformTcbLevel(db, platform, conf)
+	assert.NoError(t, err)
+	if assert.Len(t, selection.TcbLevels, 3) {
+		assert.Equal(t, "2020-05-28T00:00:00Z", selection.TcbLevels[0].TcbDate)
+		assert.True(t, selection.TcbLevels[1].Matched)
+	}
+
+	// levels are not used when normalization is disabled
+	status, err = platformTcbStatus(db, platform, &config.Configuration{})
+	assert.NoError(t, err)
+	assert.Equal(t, "OutOfDate", status)
+
+	// nor when they are older than TcbInfo, as when the levels of a refreshed TcbInfo could not be stored
+	fc.Advance(time.Hour)
+	tcbInfo.UpdatedTime = clock.Now().UTC()
+	assert.NoError(t, db.FmspcTcbInfoRepository().Update(tcbInfo))
+	status, err = platformTcbStatus(db, platform, conf)
+	assert.NoError(t, err)
+	assert.Equal(t, "OutOfDate", status)
+}
+
+func TestCachedPlatformTcbStatus(t *testing.T) {
+	fc := useFakeClock(t, time.Date(2022, 6, 21, 0, 0, 0, 0, time.UTC))
+	db := memory.NewDatabase()
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000", UpdatedTime: clock.Now().UTC()}
+	_, err := db.PlatformRepository().Create(platform)
+	assert.NoError(t, err)
+	tcbInfo := &types.FmspcTcbInfo{Fmspc: platform.Fmspc, TcbInfo: string(testTcbInfoJson), UpdatedTime: clock.Now().UTC()}
+	_, err = db.FmspcTcbInfoRepository().Create(tcbInfo)
+	assert.NoError(t, err)
+	pckCert := &types.PckCert{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn,
+		PceSvn: platform.PceSvn, Tcbms: []string{"020200000000000000000000000000000a00"}}
+	_, err = db.PckCertRepository().Create(pckCert)
+	assert.NoError(t, err)
+	cachedPlatform := func() *types.Platform {
+		p, err := db.PlatformRepository().Retrieve(&types.Platform{QeID: platform.QeID, PceID: platform.PceID})
+		assert.NoError(t, err)
+		return p
+	}
+
+	// status is not stored when caching is disabled
+	conf := &config.Configuration{}
+	status, err := cachedPlatformTcbStatus(db, cachedPlatform(), conf)
+	assert.NoError(t, err)
+	assert.Equal(t, "UpToDate", status)
+	assert.Empty(t, cachedPlatform().TcbStatus)
+
+	conf.CacheTcbStatus = true
+	status, err = cachedPlatformTcbStatus(db, cachedPlatform(), conf)
+	assert.NoError(t, err)
+	assert.Equal(t, "UpToDate", status)
+	assert.Equal(t, "UpToDate", cachedPlatform().TcbStatus)
+
+	// stored status is served until the platform or its TcbInfo is updated
+	pckCert.Tcbms = []string{"010100000000000000000000000000000900"}
+	assert.NoError(t, db.PckCertRepository().Update(pckCert))
+	status, err = cachedPlatformTcbStatus(db, cachedPlatform(), conf)
+	assert.NoError(t, err)
+	assert.Equal(t, "UpToDate", status)
+
+	fc.Advance(time.Hour)
+	tcbInfo.UpdatedTime = clock.Now().UTC()
+	assert.NoError(t, db.FmspcTcbInfoRepository().Update(tcbInfo))
+	status, err = cachedPlatformTcbStatus(db, cachedPlatform(), conf)
+	assert.NoError(t, err)
+	assert.Equal(t, "OutOfDate", status)
+	assert.Equal(t, "OutOfDate", cachedPlatform().TcbStatus)
+
+	// refresh recomputes the stored status of all platforms
+	pckCert.Tcbms = []string{"020200000000000000000000000000000a00"}
+	assert.NoError(t, db.PckCertRepository().Update(pckCert))
+	fc.Advance(time.Hour)
+	assert.NoError(t, cacheAllPlatformTcbStatus(db, nil))
+	assert.Equal(t, "UpToDate", cachedPlatform().TcbStatus)
+	assert.Equal(t, clock.Now().UTC(), cachedPlatform().TcbStatusTime)
+}
+
+func TestGetPckCertSelection(t *testing.T) {
+	db := memory.NewDatabase()
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000"}
+	_, err := db.PlatformRepository().Create(platform)
+	assert.NoError(t, err)
+	_, err = db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: platform.Fmspc, TcbInfo: string(testTcbInfoJson)})
+	assert.NoError(t, err)
+	_, err = db.PckCertRepository().Create(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn,
+		PceSvn: platform.PceSvn, CertIndex: 1, PckCerts: []string{"cert0", "cert1"},
+		Tcbms: []string{"020200000000000000000000000000000a00", "010100000000000000000000000000000900"}})
+	assert.NoError(t, err)
+
+	router := mux.NewRouter()
+	PlatformInfoOps(router, db, nil, nil)
+	selectionResponse := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/pckcertselection?"+query, nil)
+		permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.CacheManagerGroupName}}
+		req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+		roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.CacheManagerGroupName, Context: "type=SCS"}}
+		req = commContext.SetUserRoles(req, roleInfo)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, selectionResponse("").Code)
+	assert.Equal(t, http.StatusBadRequest, selectionResponse("qeid=0518145496973c5e69577195511e9080&pceid=00").Code)
+	assert.Equal(t, http.StatusNotFound, selectionResponse("qeid=1518145496973c5e69577195511e9080").Code)
+
+	w := selectionResponse("qeid=0518145496973C5E69577195511E9080")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var selection PckCertSelection
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &selection))
+	assert.Equal(t, uint8(1), selection.CertIndex)
+	assert.Equal(t, 2, selection.CertCount)
+	assert.Equal(t, "010100000000000000000000000000000900", selection.Tcbm)
+	assert.Equal(t, "OutOfDate", selection.TcbStatus)
+
+	// levels higher than the raw tcb are not matched, the status is of the first level matched
+	assert.Len(t, selection.TcbLevels, 3)
+	assert.Equal(t, "Lower", selection.TcbLevels[0].Result)
+	assert.False(t, selection.TcbLevels[0].Matched)
+	matched := 0
+	for _, level := range selection.TcbLevels {
+		if level.Matched {
+			matched++
+			assert.Equal(t, "EqualOrGreater", level.Result)
+			assert.Equal(t, selection.TcbStatus, level.TcbStatus)
+		}
+	}
+	assert.Equal(t, 1, matched)
+
+	status, err := platformTcbStatus(db, platform, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, selection.TcbStatus, status)
+}
+
+func TestGetTcbComparison(t *testing.T) {
+	db := memory.NewDatabase()
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000"}
+	_, err := db.PlatformRepository().Create(platform)
+	assert.NoError(t, err)
+	_, err = db.PlatformTcbRepository().Create(&types.PlatformTcb{QeID: platform.QeID, PceID: platform.PceID,
+		CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn, Tcbm: "1bf8deed6f929ce40bd658e61ea722eb0a00"})
+	assert.NoError(t, err)
+
+	router := mux.NewRouter()
+	PlatformInfoOps(router, db, nil, nil)
+	comparisonResponse := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/tcbcomparison?"+query, nil)
+		permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataReaderGroupName}}
+		req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+		roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: "type=SCS"}}
+		req = commContext.SetUserRoles(req, roleInfo)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	comparison := func(cpuSvn, pceSvn string) TcbComparison {
+		w := comparisonResponse("qeid=0518145496973c5e69577195511e9080&pceid=0000&cpusvn=" + cpuSvn + "&pcesvn=" + pceSvn)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var c TcbComparison
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &c))
+		return c
+	}
+
+	assert.Equal(t, http.StatusBadRequest, comparisonResponse("qeid=0518145496973c5e69577195511e9080&pceid=0000").Code)
+	assert.Equal(t, http.StatusBadRequest, comparisonResponse("qeid=0518145496973c5e69577195511e9080&pceid=0000&cpusvn=1bf8&pcesvn=0a00").Code)
+	assert.Equal(t, http.StatusNotFound, comparisonResponse("qeid=1518145496973c5e69577195511e9080&pceid=0000&cpusvn=1bf8deed6f929ce40bd658e61ea722eb&pcesvn=0a00").Code)
+
+	c := comparison("1BF8DEED6F929CE40BD658E61EA722EB", "0a00")
+	assert.Equal(t, "EqualOrGreater", c.Result)
+	assert.False(t, c.ReselectionNeeded)
+
+	c = comparison("1bf8deed6f929ce40bd658e61ea722eb", "0b00")
+	assert.Equal(t, "EqualOrGreater", c.Result)
+	assert.True(t, c.ReselectionNeeded)
+	assert.Equal(t, "0a00", c.CachedPceSvn)
+
+	c = comparison("1af8deed6f929ce40bd658e61ea722eb", "0a00")
+	assert.Equal(t, "Lower", c.Result)
+	assert.True(t, c.ReselectionNeeded)
+
+	c = comparison("1af8deed6f929ce40bd658e61ea722eb", "0b00")
+	assert.Equal(t, "Undefined", c.Result)
+	assert.True(t, c.ReselectionNeeded)
+
+	// comparison does not update the cached raw tcb
+	platformTcb, err := db.PlatformTcbRepository().Retrieve(&types.PlatformTcb{QeID: platform.QeID, PceID: platform.PceID})
+	assert.NoError(t, err)
+	assert.Equal(t, "0a00", platformTcb.PceSvn)
+	assert.Equal(t, platform.CPUSvn, platformTcb.CPUSvn)
+}
+
+func TestPlatformByQeID(t *testing.T) {
+	db := memory.NewDatabase()
+	platform := &types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000", Ca: "processor"}
+	_, err := db.PlatformRepository().Create(platform)
+	assert.NoError(t, err)
+	_, err = db.PckCertRepository().Create(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID, PckCerts: []string{"cert0"}})
+	assert.NoError(t, err)
+
+	router := mux.NewRouter()
+	PlatformInfoOps(router, db, nil, nil)
+	platformResponse := func(method, target, role, roleContext string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{role}}
+		req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+		roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: role, Context: roleContext}}
+		req = commContext.SetUserRoles(req, roleInfo)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// the path and query forms are validated and authorized alike
+	for _, target := range []string{"/platforms/0518145496973C5E69577195511E9080", "/platforms?qeid=0518145496973C5E69577195511E9080"} {
+		w := platformResponse(http.MethodGet, target, constants.HostDataReaderGroupName, "type=SCS")
+		assert.Equal(t, http.StatusOK, w.Code, target)
+		var summary PlatformSummary
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+		assert.Equal(t, platform.QeID, summary.QeID)
+		assert.Equal(t, platform.Fmspc, summary.Fmspc)
+		assert.Equal(t, platform.Ca, summary.Ca)
+
+		assert.Equal(t, http.StatusForbidden, platformResponse(http.MethodGet, target, constants.HostDataReaderGroupName,
+			"type=SCS;fmspc=00906ed50000").Code, target)
+		assert.Equal(t, http.StatusForbidden, platformResponse(http.MethodDelete, target, constants.CacheManagerGroupName,
+			"type=SCS;fmspc=00906ed50000").Code, target)
+	}
+	assert.Equal(t, http.StatusOK, platformResponse(http.MethodGet, "/platforms/0518145496973c5e69577195511e9080?pceid=0000",
+		constants.HostDataReaderGroupName, "type=SCS").Code)
+	assert.Equal(t, http.StatusOK, platformResponse(http.MethodGet, "/platforms?qeid=0518145496973c5e69577195511e9080&pceid=0000",
+		constants.HostDataReaderGroupName, "type=SCS").Code)
+
+	for _, target := range []string{"/platforms/05181454", "/platforms?qeid=05181454", "/platforms/0518145496973c5e69577195511e9080?pceid=00",
+		"/platforms/0518145496973c5e69577195511e9080?fmspc=20606a000000"} {
+		assert.Equal(t, http.StatusBadRequest, platformResponse(http.MethodGet, target, constants.HostDataReaderGroupName, "type=SCS").Code, target)
+		assert.Equal(t, http.StatusBadRequest, platformResponse(http.MethodDelete, target, constants.CacheManagerGroupName, "type=SCS").Code, target)
+	}
+	assert.Equal(t, http.StatusNotFound, platformResponse(http.MethodGet, "/platforms/1518145496973c5e69577195511e9080",
+		constants.HostDataReaderGroupName, "type=SCS").Code)
+	assert.Equal(t, http.StatusNotFound, platformResponse(http.MethodGet, "/platforms/0518145496973c5e69577195511e9080?pceid=0001",
+		constants.HostDataReaderGroupName, "type=SCS").Code)
+
+	// deleting needs the cache manager role
+	assert.Equal(t, http.StatusForbidden, platformResponse(http.MethodDelete, "/platforms/0518145496973c5e69577195511e9080",
+		constants.HostDataReaderGroupName, "type=SCS").Code)
+	w := platformResponse(http.MethodDelete, "/platforms/0518145496973c5e69577195511e9080", constants.CacheManagerGroupName, "type=SCS")
+	assert.Equal(t, http.StatusOK, w.Code)
+	_, err = db.PlatformRepository().Retrieve(&types.Platform{QeID: platform.QeID})
+	assert.Error(t, err)
+	_, err = db.PckCertRepository().Retrieve(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, platformResponse(http.MethodDelete, "/platforms?qeid=0518145496973c5e69577195511e9080",
+		constants.CacheManagerGroupName, "type=SCS").Code)
+
+	// listing the platforms of a pceid is still served by GET /platforms
+	assert.Equal(t, http.StatusOK, platformResponse(http.MethodGet, "/platforms?pceid=0000", constants.HostDataReaderGroupName, "type=SCS").Code)
+}
+
+func TestGetTcbStatusSummary(t *testing.T) {
+	db := memory.NewDatabase()
+	tcbms := []string{"020200000000000000000000000000000a00", "010100000000000000000000000000000900", "020200000000000000000000000000000a00"}
+	for i, tcbm := range tcbms {
+		platform := &types.Platform{QeID: fmt.Sprintf("%031d%d", 0, i), PceID: "0000",
+			CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000"}
+		_, err := db.PlatformRepository().Create(platform)
+		assert.NoError(t, err)
+		_, err = db.PckCertRepository().Create(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID,
+			CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn, PckCerts: []string{"cert0"}, Tcbms: []string{tcbm}})
+		assert.NoError(t, err)
+	}
+	// tcb status of a platform whose pck certs are not cached cannot be evaluated
+	_, err := db.PlatformRepository().Create(&types.Platform{QeID: "00000000000000000000000000000009", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000"})
+	assert.NoError(t, err)
+	_, err = db.PlatformRepository().Create(&types.Platform{QeID: "10000000000000000000000000000000", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "00906ed50000"})
+	assert.NoError(t, err)
+
+	router := mux.NewRouter()
+	PlatformInfoOps(router, db, nil, nil)
+	summaryResponse := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/tcbstatussummary?"+query, nil)
+		permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.CacheManagerGroupName}}
+		req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+		roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.CacheManagerGroupName, Context: "type=SCS"}}
+		req = commContext.SetUserRoles(req, roleInfo)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, summaryResponse("").Code)
+	assert.Equal(t, http.StatusBadRequest, summaryResponse("fmspc=20606a").Code)
+	assert.Equal(t, http.StatusBadRequest, summaryResponse("fmspc=20606a000000&qeid=00000000000000000000000000000000").Code)
+	assert.Equal(t, http.StatusNotFound, summaryResponse("fmspc=20606a000000").Code)
+
+	_, err = db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: "20606a000000", TcbInfo: string(testTcbInfoJson)})
+	assert.NoError(t, err)
+	w := summaryResponse("fmspc=20606A000000")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var summary TcbStatusSummary
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.Equal(t, TcbStatusSummary{Fmspc: "20606a000000", Platforms: 4,
+		TcbStatuses: map[string]int{"UpToDate": 2, "OutOfDate": 1}, Unevaluated: 1}, summary)
+}
+
+func TestPckCertsNotAvailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Sgx-Fmspc", "20606a000000")
+		w.Header().Set("Sgx-Pck-Certificate-Ca-Type", "processor")
+		w.Write([]byte(`[{"tcb":{"pcesvn":11},"tcbm":"0202020202020202020202020202020200b0","cert":"Not available"},` +
+			`{"tcb":{"pcesvn":10},"tcbm":"0101010101010101010101010101010100a0","cert":"Not available"}]`))
+	}))
+	defer server.Close()
+
+	conf := config.Load(testConfigFilePath)
+	conf.ProvServerInfo.ProvServerURL = server.URL
+	var client domain.HttpClient = server.Client()
+
+	platformInfo := PlatformInfo{
+		EncPpid: strings.Repeat("0f", 384),
+		CPUSvn:  "1bf8deed6f929ce40bd658e61ea722eb",
+		PceSvn:  "0a00",
+		PceID:   "0000",
+		QeID:    "6518145496973c5e69577195511e9080",
+		HwUUID:  "5a8de8c4-84a1-4cc0-9b95-2d8b9a0ff1b4",
+	}
+	_, _, _, _, err := fetchPckCertInfo(context.Background(), &types.Platform{Encppid: platformInfo.EncPpid, CPUSvn: platformInfo.CPUSvn,
+		PceSvn: platformInfo.PceSvn, PceID: platformInfo.PceID, QeID: platformInfo.QeID}, conf, &client)
+	assert.True(t, errors.Is(err, ErrPckCertsNotAvailable))
+
+	push := func(policy string) *httptest.ResponseRecorder {
+		conf.PckCertsNotAvailablePolicy = policy
+		router := mux.NewRouter()
+		db := memory.NewDatabase()
+		PlatformInfoOps(router, db, conf, &client)
+
+		reqBody, _ := json.Marshal(platformInfo)
+		req := httptest.NewRequest(http.MethodPost, "/platforms", bytes.NewReader(reqBody))
+		req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+		permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataUpdaterGroupName}}
+		req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+		roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataUpdaterGroupName, Context: "type=SCS"}}
+		req = commContext.SetUserRoles(req, roleInfo)
+		req = commContext.SetTokenSubject(req, platformInfo.HwUUID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		platforms, _ := db.PlatformRepository().RetrieveAll()
+		assert.Empty(t, platforms)
+		return w
+	}
+
+	w := push(constants.PckCertsNotAvailableReject)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), ErrPckCertsNotAvailable.Error())
+
+	w = push(constants.PckCertsNotAvailableRetry)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, strconv.Itoa(constants.PckCertsNotAvailableRetryAfter), w.Header().Get("Retry-After"))
+}
+
+func TestPushRawTcbBelowPckCerts(t *testing.T) {
+	selectPckCert = func(platform *types.Platform, pckCerts []string, tcbInfo string) (uint8, error) {
+		return 0, ErrRawTcbBelowPckCerts
+	}
+	defer func() {
+		selectPckCert = getBestPckCert
+	}()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/tcb") {
+			w.Write([]byte(`{"tcbInfo":{"version":2,"fmspc":"20606a000000","pceId":"0000","tcbLevels":[{"tcb":{"sgxtcbcomp01svn":2,` +
+				`"pcesvn":11},"tcbDate":"2021-11-10T00:00:00Z","tcbStatus":"UpToDate"}]},"signature":"00"}`))
+			return
+		}
+		w.Header().Set("Sgx-Fmspc", "20606a000000")
+		w.Header().Set("Sgx-Pck-Certificate-Ca-Type", "processor")
+		w.Write([]byte(`[{"tcb":{"pcesvn":11},"tcbm":"0202020202020202020202020202020200b0","cert":"cert0"},` +
+			`{"tcb":{"pcesvn":10},"tcbm":"0101010101010101010101010101010100a0","cert":"cert1"}]`))
+	}))
+	defer server.Close()
+
+	conf := config.Load(testConfigFilePath)
+	conf.ProvServerInfo.ProvServerURL = server.URL
+	conf.SignatureVerificationPolicy = constants.SignatureVerificationOff
+	var client domain.HttpClient = server.Client()
+
+	platformInfo := PlatformInfo{
+		EncPpid: strings.Repeat("0f", 384),
+		CPUSvn:  "0000deed6f929ce40bd658e61ea722eb",
+		PceSvn:  "0100",
+		PceID:   "0000",
+		QeID:    "6518145496973c5e69577195511e9080",
+		HwUUID:  "5a8de8c4-84a1-4cc0-9b95-2d8b9a0ff1b4",
+	}
+	_, _, _, _, err := fetchPckCertInfo(context.Background(), &types.Platform{Encppid: platformInfo.EncPpid, CPUSvn: platformInfo.CPUSvn,
+		PceSvn: platformInfo.PceSvn, PceID: platformInfo.PceID, QeID: platformInfo.QeID}, conf, &client)
+	assert.True(t, errors.Is(err, ErrRawTcbBelowPckCerts))
+
+	router := mux.NewRouter()
+	db := memory.NewDatabase()
+	PlatformInfoOps(router, db, conf, &client)
+
+	reqBody, _ := json.Marshal(platformInfo)
+	req := httptest.NewRequest(http.MethodPost, "/platforms", bytes.NewReader(reqBody))
+	req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+	permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataUpdaterGroupName}}
+	req = commContext.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+	roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataUpdaterGroupName, Context: "type=SCS"}}
+	req = commContext.SetUserRoles(req, roleInfo)
+	req = commContext.SetTokenSubject(req, platformInfo.HwUUID)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// reported distinctly from internal errors, with what is to be done about it
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), ErrRawTcbBelowPckCerts.Error())
+	assert.Contains(t, w.Body.String(), "update the TCB of the platform")
+	platforms, err := db.PlatformRepository().RetrieveAll()
+	assert.NoError(t, err)
+	assert.Empty(t, platforms)
+}
+
+func TestPushChangedRawTcb(t *testing.T) {
+	db := memory.NewDatabase()
+	platform := &types.Platform{QeID: "6518145496973c5e69577195511e9080", PceID: "0000",
+		CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Encppid: "01", Fmspc: "20606a000000"}
+	_, err := upsertPlatformInfo(context.Background(), db, platform, "1bf8deed6f929ce40bd658e61ea722eb0a00")
+	assert.NoError(t, err)
+	db.PckCertRepository().Create(&types.PckCert{QeID: platform.QeID, PceID: platform.PceID,
+		CPUSvn: platform.CPUSvn, PceSvn: platform.PceSvn})
+
+	platformInfo := PlatformInfo{QeID: platform.QeID, PceID: platform.PceID, CPUSvn: platform.CPUSvn,
+		PceSvn: platform.PceSvn, EncPpid: "01"}
+	isCached, err := checkPlatformDataCacheStatus(db, &platformInfo, "", false)
+	assert.NoError(t, err)
+	assert.True(t, isCached)
+
+	// a push after a microcode update is not a duplicate with either policy, so that the pck cert
+	// is selected again for the new raw tcb
+	platformInfo.CPUSvn = "1cf8deed6f929ce40bd658e61ea722eb"
+	platformInfo.PceSvn = "0b00"
+	for _, updateDuplicate := range []bool{false, true} {
+		pushed := platformInfo
+		isCached, err := checkPlatformDataCacheStatus(db, &pushed, "", updateDuplicate)
+		assert.NoError(t, err)
+		assert.False(t, isCached)
+	}
+
+	// the cached platform and its raw tcb are updated with the newly selected tcbm
+	updated := *platform
+	updated.CPUSvn = platformInfo.CPUSvn
+	updated.PceSvn = platformInfo.PceSvn
+	created, err := upsertPlatformInfo(context.Background(), db, &updated, "1cf8deed6f929ce40bd658e61ea722eb0b00")
+	assert.NoError(t, err)
+	assert.False(t, created)
+
+	platformTcb, err := db.PlatformTcbRepository().Retrieve(&types.PlatformTcb{QeID: platform.QeID, PceID: platform.PceID})
+	assert.NoError(t, err)
+	assert.Equal(t, platformInfo.CPUSvn, platformTcb.CPUSvn)
+	assert.Equal(t, platformInfo.PceSvn, platformTcb.PceSvn)
+	assert.Equal(t, "1cf8deed6f929ce40bd658e61ea722eb0b00", platformTcb.Tcbm)
+	cached, err := db.PlatformRepository().Retrieve(&types.Platform{QeID: platform.QeID, PceID: platform.PceID})
+	assert.NoError(t, err)
+	assert.Equal(t, platformInfo.PceSvn, cached.PceSvn)
+}
+
+// flakyClient fails the first failures requests with 503, as PCS does when it is momentarily unavailable
+type flakyClient struct {
+	failures int
+	client   domain.HttpClient
+}
+
+func (c *flakyClient) Do(req *http.Request) (*http.Response, error) {
+	if c.failures > 0 {
+		c.failures--
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+	}
+	return c.client.Do(req)
+}
+
+func TestRefreshWithGrace(t *testing.T) {
+	db := getMockDatabase()
+	conf := config.Load(testConfigFilePath)
+	client := mocks.NewClientMock(http.StatusOK)
+	_, err := getLazyCacheQEIdentityInfo(context.Background(), db, constants.CacheInsert, conf, &client)
+	assert.NoError(t, err)
+
+	// PCS requests are sent once, so that each 503 fails a refresh attempt
+	conf.RetryCount = 1
+	// first attempt fails and the grace attempt succeeds
+	conf.RefreshGraceAttempts = 1
+	conf.RefreshGraceDelaySeconds = 0
+	var flaky domain.HttpClient = &flakyClient{failures: 1, client: client}
+	attempts := 0
+	err = refreshWithGrace(context.Background(), conf, constants.CollateralQeIdentity, func() error {
+		attempts++
+		return refreshAllQE(context.Background(), db, conf, &flaky)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+
+	// failed once the grace attempts are used up
+	flaky = &flakyClient{failures: 2, client: client}
+	attempts = 0
+	err = refreshWithGrace(context.Background(), conf, constants.CollateralQeIdentity, func() error {
+		attempts++
+		return refreshAllQE(context.Background(), db, conf, &flaky)
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 2, attempts)
+
+	// not attempted again without grace attempts, nor once the refresh is cancelled
+	conf.RefreshGraceAttempts = 0
+	attempts = 0
+	err = refreshWithGrace(context.Background(), conf, constants.CollateralQeIdentity, func() error {
+		attempts++
+		return errors.New("pcs unreachable")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+
+	conf.RefreshGraceAttempts = 3
+	conf.RefreshGraceDelaySeconds = 3600
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	attempts = 0
+	err = refreshWithGrace(ctx, conf, constants.CollateralQeIdentity, func() error {
+		attempts++
+		return errors.New("pcs unreachable")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
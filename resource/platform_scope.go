@@ -0,0 +1,113 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"intel/isecl/lib/common/v5/context"
+	commLogMsg "intel/isecl/lib/common/v5/log/message"
+	"intel/isecl/scs/v5/constants"
+	"net/http"
+	"strings"
+)
+
+// platformScope restricts a role to the platforms with one of the listed fmspcs and pceids,
+// platforms are not restricted on a value for which the list is empty
+type platformScope struct {
+	fmspcs map[string]bool
+	pceIDs map[string]bool
+}
+
+// parsePlatformScope reads the fmspc and pceid scope of a role from its context, which is a
+// semicolon separated list of key=value pairs such as "type=SCS;fmspc=20606a000000,00906ed50000;pceid=0000".
+// false is returned when the context does not scope the role
+func parsePlatformScope(roleContext string) (platformScope, bool) {
+	scope := platformScope{fmspcs: map[string]bool{}, pceIDs: map[string]bool{}}
+	scoped := false
+	for _, pair := range strings.Split(roleContext, ";") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		var values map[string]bool
+		switch strings.ToLower(strings.TrimSpace(kv[0])) {
+		case constants.ScopeFmspcKey:
+			values = scope.fmspcs
+		case constants.ScopePceIDKey:
+			values = scope.pceIDs
+		default:
+			continue
+		}
+		scoped = true
+		for _, value := range strings.Split(kv[1], ",") {
+			if value = strings.ToLower(strings.TrimSpace(value)); value != "" {
+				values[value] = true
+			}
+		}
+	}
+	return scope, scoped
+}
+
+// allows checks if a platform is within the scope, fmspc is not matched when it is empty as
+// it is not known until the platform is cached
+func (s platformScope) allows(fmspc, pceID string) bool {
+	if len(s.pceIDs) > 0 && !s.pceIDs[strings.ToLower(pceID)] {
+		return false
+	}
+	if fmspc != "" && len(s.fmspcs) > 0 && !s.fmspcs[strings.ToLower(fmspc)] {
+		return false
+	}
+	return true
+}
+
+// platformScopes are the scopes of all the roles of a request with the given name
+type platformScopes []platformScope
+
+// allows checks if a platform is within the scope of any of the roles, platforms are not
+// restricted when there are no scopes
+func (scopes platformScopes) allows(fmspc, pceID string) bool {
+	if scopes == nil {
+		return true
+	}
+	for _, scope := range scopes {
+		if scope.allows(fmspc, pceID) {
+			return true
+		}
+	}
+	return false
+}
+
+// getPlatformScopes returns the platform scopes of the roles of a request with the given name.
+// nil is returned when any of the roles is not scoped, so that only the coarse group
+// authorization of authorizeEndpoint applies
+func getPlatformScopes(r *http.Request, roleName string) (platformScopes, error) {
+	privileges, err := context.GetUserRoles(r)
+	if err != nil {
+		slog.WithError(err).Error("resource/platform_scope: getPlatformScopes() Failed to read roles and permissions")
+		return nil, &resourceError{Message: "Could not get user roles from http context", StatusCode: http.StatusInternalServerError}
+	}
+
+	scopes := platformScopes{}
+	for _, role := range privileges {
+		if role.Service != constants.ServiceName || role.Name != roleName {
+			continue
+		}
+		scope, scoped := parsePlatformScope(role.Context)
+		if !scoped {
+			return nil, nil
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+// authorizePlatformScope returns a privilegeError when a platform is not within the scopes
+func authorizePlatformScope(r *http.Request, scopes platformScopes, fmspc, pceID string) error {
+	if scopes.allows(fmspc, pceID) {
+		return nil
+	}
+	slog.Infof("resource/platform_scope: authorizePlatformScope() %s: platform with fmspc %s and pceid %s is out of scope, requested by: %s",
+		commLogMsg.UnauthorizedAccess, fmspc, pceID, r.RemoteAddr)
+	return &privilegeError{Message: "platform is out of scope", StatusCode: http.StatusForbidden}
+}
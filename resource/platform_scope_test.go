@@ -0,0 +1,169 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"encoding/json"
+	"intel/isecl/lib/common/v5/context"
+	"intel/isecl/lib/common/v5/types/aas"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/repository/memory"
+	"intel/isecl/scs/v5/types"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePlatformScope(t *testing.T) {
+	_, scoped := parsePlatformScope("")
+	assert.False(t, scoped)
+	_, scoped = parsePlatformScope("type=SCS")
+	assert.False(t, scoped)
+
+	scope, scoped := parsePlatformScope("type=SCS; fmspc=20606A000000, 00906ed50000")
+	assert.True(t, scoped)
+	assert.True(t, scope.allows("20606a000000", "0000"))
+	assert.True(t, scope.allows("00906ED50000", "0001"))
+	assert.False(t, scope.allows("30606a000000", "0000"))
+	// fmspc is not matched until it is known
+	assert.True(t, scope.allows("", "0000"))
+
+	scope, scoped = parsePlatformScope("pceid=0000;fmspc=20606a000000")
+	assert.True(t, scoped)
+	assert.True(t, scope.allows("20606a000000", "0000"))
+	assert.False(t, scope.allows("20606a000000", "0001"))
+	assert.False(t, scope.allows("", "0001"))
+}
+
+func TestPlatformScopesAllows(t *testing.T) {
+	var unscoped platformScopes
+	assert.True(t, unscoped.allows("20606a000000", "0000"))
+
+	processorScope, _ := parsePlatformScope("fmspc=20606a000000")
+	platformScope, _ := parsePlatformScope("pceid=0001")
+	scopes := platformScopes{processorScope, platformScope}
+	assert.True(t, scopes.allows("20606a000000", "0000"))
+	assert.True(t, scopes.allows("00906ed50000", "0001"))
+	assert.False(t, scopes.allows("00906ed50000", "0000"))
+
+	assert.False(t, platformScopes{}.allows("20606a000000", "0000"))
+}
+
+func TestGetPlatformScopes(t *testing.T) {
+	request := func(roles ...aas.RoleInfo) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/platforms", nil)
+		return context.SetUserRoles(req, roles)
+	}
+	reader := func(roleContext string) aas.RoleInfo {
+		return aas.RoleInfo{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: roleContext}
+	}
+
+	scopes, err := getPlatformScopes(request(reader("type=SCS")), constants.HostDataReaderGroupName)
+	assert.NoError(t, err)
+	assert.Nil(t, scopes)
+
+	scopes, err = getPlatformScopes(request(reader("fmspc=20606a000000"),
+		aas.RoleInfo{Service: constants.ServiceName, Name: constants.HostDataUpdaterGroupName}), constants.HostDataReaderGroupName)
+	assert.NoError(t, err)
+	assert.Len(t, scopes, 1)
+
+	// an unscoped role grants access to all the platforms
+	scopes, err = getPlatformScopes(request(reader("fmspc=20606a000000"), reader("")), constants.HostDataReaderGroupName)
+	assert.NoError(t, err)
+	assert.Nil(t, scopes)
+
+	_, err = getPlatformScopes(httptest.NewRequest(http.MethodGet, "/platforms", nil), constants.HostDataReaderGroupName)
+	assert.Error(t, err)
+}
+
+var _ = Describe("Platform Scope Validation", func() {
+	var router *mux.Router
+	var w *httptest.ResponseRecorder
+
+	db := memory.NewDatabase()
+	db.PlatformRepository().Create(&types.Platform{QeID: "6518145496973c5e69577195511e9080", PceID: "0000", Fmspc: "20606a000000"})
+	db.PlatformRepository().Create(&types.Platform{QeID: "7518145496973c5e69577195511e9080", PceID: "0000", Fmspc: "00906ed50000"})
+
+	scopedResponse := func(urlPath, roleContext string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, urlPath, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataReaderGroupName}}
+		req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+		roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: roleContext}}
+		req = context.SetUserRoles(req, roleInfo)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	BeforeEach(func() {
+		router = mux.NewRouter()
+		PlatformInfoOps(router, db, nil, nil)
+	})
+
+	Describe("Platform scope validation", func() {
+		Context("getPlatforms", func() {
+
+			It("Should return StatusForbidden - pceid out of scope", func() {
+				Expect(scopedResponse("/platforms?pceid=0000", "type=SCS;pceid=0001").Code).To(Equal(http.StatusForbidden))
+			})
+
+			It("Should return StatusOK - Only platforms within scope", func() {
+				w := scopedResponse("/platforms?pceid=0000", "type=SCS;fmspc=20606a000000")
+				Expect(w.Code).To(Equal(http.StatusOK))
+
+				var platforms []PlatformSummary
+				Expect(json.Unmarshal(w.Body.Bytes(), &platforms)).To(Succeed())
+				Expect(platforms).To(HaveLen(1))
+				Expect(platforms[0].Fmspc).To(Equal("20606a000000"))
+			})
+
+			It("Should return StatusOK - All platforms without scope", func() {
+				w := scopedResponse("/platforms?pceid=0000", "type=SCS")
+				Expect(w.Code).To(Equal(http.StatusOK))
+
+				var platforms []PlatformSummary
+				Expect(json.Unmarshal(w.Body.Bytes(), &platforms)).To(Succeed())
+				Expect(platforms).To(HaveLen(2))
+			})
+		})
+
+		Context("getTcbStatus", func() {
+
+			It("Should return StatusForbidden - fmspc out of scope", func() {
+				w := scopedResponse("/tcbstatus?qeid=7518145496973c5e69577195511e9080&pceid=0000", "type=SCS;fmspc=20606a000000")
+				Expect(w.Code).To(Equal(http.StatusForbidden))
+			})
+		})
+
+		Context("getPckCerts", func() {
+
+			It("Should return StatusForbidden - fmspc out of scope", func() {
+				w := scopedResponse("/pckcerts?qeid=7518145496973c5e69577195511e9080&pceid=0000", "type=SCS;fmspc=20606a000000")
+				Expect(w.Code).To(Equal(http.StatusForbidden))
+			})
+		})
+
+		Context("getRegistrationStatus", func() {
+
+			It("Should return StatusForbidden - fmspc out of scope", func() {
+				w := scopedResponse("/registrationstatus?qeid=7518145496973c5e69577195511e9080&pceid=0000", "type=SCS;fmspc=20606a000000")
+				Expect(w.Code).To(Equal(http.StatusForbidden))
+			})
+
+			It("Should return StatusForbidden - pceid out of scope", func() {
+				w := scopedResponse("/registrationstatus?qeid=7518145496973c5e69577195511e9080&pceid=0000", "type=SCS;pceid=0001")
+				Expect(w.Code).To(Equal(http.StatusForbidden))
+			})
+		})
+	})
+})
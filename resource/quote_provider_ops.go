@@ -6,6 +6,7 @@ package resource
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -18,6 +19,7 @@ import (
 	"intel/isecl/scs/v5/types"
 	"intel/isecl/scs/v5/version"
 
+	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 )
 
@@ -25,12 +27,16 @@ type PCKCertInfo struct {
 	Ppid string `json:"ppid"`
 }
 
+// Large read responses are gzip compressed when requested by client via Accept-Encoding,
+// pckcrl and rootcacrl are left as is since DER encoded CRL does not compress well
 func QuoteProviderOps(r *mux.Router, db repository.SCSDatabase, config *config.Configuration, client *domain.HttpClient) {
-	r.Handle("/pckcert", getPckCertificate(db, config, client)).Methods("GET")
+	r.Handle("/pckcert", handlers.CompressHandler(getPckCertificate(db, config, client))).Methods("GET")
 	r.Handle("/pckcert", updatePckCertificate(db, config, client)).Methods("PUT")
 	r.Handle("/pckcrl", getPckCrl(db, config, client)).Methods("GET")
-	r.Handle("/tcb", getTcbInfo(db, config, client)).Methods("GET")
-	r.Handle("/qe/identity", getQeIdentityInfo(db, config, client)).Methods("GET")
+	r.Handle("/rootcacrl", getRootCaCrl(db, config, client)).Methods("GET")
+	r.Handle("/tcb", handlers.CompressHandler(getTcbInfo(db, config, client))).Methods("GET")
+	r.Handle("/qe/identity", handlers.CompressHandler(getQeIdentityInfo(db, config, client))).Methods("GET")
+	r.Handle("/collateral", handlers.CompressHandler(getCollateral(db, config, client))).Methods("GET")
 	r.Handle("/version", getVersion()).Methods("GET")
 }
 
@@ -41,6 +47,8 @@ var pckCrlRetrieveParams = map[string]bool{"ca": true, "encoding": true}
 
 var tcbInfoRetrieveParams = map[string]bool{"fmspc": true}
 
+var collateralRetrieveParams = map[string]bool{"qeid": true, "pceid": true, "fmspc": true}
+
 func getVersion() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		verStr := version.GetVersion()
@@ -79,13 +87,13 @@ func updatePckCertificate(db repository.SCSDatabase, conf *config.Configuration,
 		pInfo := &types.Platform{Ppid: ppid}
 		existingPinfo, err := db.PlatformRepository().Retrieve(pInfo)
 		if err != nil {
-			return &resourceError{Message: err.Error(), StatusCode: http.StatusNotFound}
+			return retrieveRecordError(err, "platform")
 		}
 		// getLazyCachePckCert API will get PCK Certs and will cache it as well.
-		_, _, _, err = getLazyCachePckCert(db, existingPinfo, constants.CacheRefresh, conf, client)
+		_, _, _, err = getLazyCachePckCert(r.Context(), db, existingPinfo, constants.CacheRefresh, conf, client)
 		if err != nil {
 			log.WithError(err).Error("Pck Cert Retrieval failed")
-			return &resourceError{Message: err.Error(), StatusCode: http.StatusNotFound}
+			return &resourceError{Message: err.Error(), StatusCode: pcsErrorStatusCode(err, http.StatusNotFound)}
 		}
 		slog.Infof("%s: PCK certificate updated by: %s", commLogMsg.AuthorizedAccess, r.RemoteAddr)
 		return nil
@@ -121,47 +129,15 @@ func getPckCertificate(db repository.SCSDatabase, conf *config.Configuration, cl
 			return &resourceError{Message: "invalid query param",
 				StatusCode: http.StatusBadRequest}
 		}
-		var existingPckCert *types.PckCert
-		var existingPckCertChain *types.PckCertChain
-
-		pInfo := &types.Platform{QeID: qeid, PceID: pceid}
-
-		existingPinfo, err := db.PlatformRepository().Retrieve(pInfo)
+		pInfo := &types.Platform{QeID: qeid, PceID: pceid, Encppid: encryptedppid, CPUSvn: cpusvn, PceSvn: pcesvn}
+		existingPckCert, existingPckCertChain, err := retrievePckCert(r.Context(), db, pInfo, conf, client)
 		if err != nil {
-			return &resourceError{Message: err.Error(), StatusCode: http.StatusNotFound}
-		}
-
-		if existingPinfo != nil {
-			pckCert := &types.PckCert{QeID: qeid, PceID: pceid}
-			existingPckCert, err = db.PckCertRepository().Retrieve(pckCert)
-			if err != nil {
-				return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
-			}
-			certChain := &types.PckCertChain{Ca: existingPinfo.Ca}
-			existingPckCertChain, err = db.PckCertChainRepository().Retrieve(certChain)
-			if err != nil {
-				return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
-			}
-		}
-		if existingPckCert == nil {
-			pInfo.Encppid = encryptedppid
-			if existingPinfo != nil {
-				pInfo.Manifest = existingPinfo.Manifest
-			}
-
-			// getLazyCachePckCert API will get PCK Certs and will cache it as well.
-			p, c, _, err := getLazyCachePckCert(db, pInfo, constants.CacheInsert, conf, client)
-			if err != nil {
-				log.WithError(err).Error("Pck Cert Retrieval failed")
-				return &resourceError{Message: err.Error(), StatusCode: http.StatusNotFound}
-			}
-			existingPckCert = p
-			existingPckCertChain = c
+			return err
 		}
 
 		certIndex := existingPckCert.CertIndex
 		w.Header().Set("Content-Type", "application/x-pem-file")
-		w.Header()["sgx-pck-certificate-issuer-chain"] = []string{existingPckCertChain.PckCertChain}
+		w.Header()["sgx-pck-certificate-issuer-chain"] = []string{pckCertChainHeader(existingPckCertChain.PckCertChain)}
 		w.Header()["sgx-tcbm"] = []string{existingPckCert.Tcbms[certIndex]}
 
 		w.WriteHeader(http.StatusOK)
@@ -196,16 +172,17 @@ func getPckCrl(db repository.SCSDatabase, conf *config.Configuration, client *do
 				StatusCode: http.StatusBadRequest}
 		}
 
-		pckCrl := &types.PckCrl{Ca: ca}
+		existingPckCrl, err := retrievePckCrl(r.Context(), db, ca, conf, client)
+		if err != nil {
+			return err
+		}
 
-		existingPckCrl, err := db.PckCrlRepository().Retrieve(pckCrl)
-		if existingPckCrl == nil {
-			existingPckCrl, err = getLazyCachePckCrl(db, ca, constants.CacheInsert, conf, client)
-			if existingPckCrl == nil || err != nil {
-				return &resourceError{Message: "Error retrieving required PCK CRL", StatusCode: http.StatusNotFound}
-			}
+		crlExpired := isPckCrlExpired(existingPckCrl)
+		if crlExpired {
+			log.Warnf("Serving PCK CRL for ca %s past its nextUpdate %s", ca, existingPckCrl.NextUpdate)
 		}
 
+		w.Header()["SGX-PCK-CRL-Expired"] = []string{strconv.FormatBool(crlExpired)}
 		w.Header()["SGX-PCK-CRL-Issuer-Chain"] = []string{existingPckCrl.PckCrlCertChain}
 		w.WriteHeader(http.StatusOK)
 		_, err = w.Write([]byte(existingPckCrl.PckCrl))
@@ -217,19 +194,48 @@ func getPckCrl(db repository.SCSDatabase, conf *config.Configuration, client *do
 	}
 }
 
+// api to get the CRL issued by SGX Root CA, which revokes the PCK CA certificates
+func getRootCaCrl(db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if len(r.URL.Query()) != 0 {
+			slog.Error("resource/quote_provider_ops: getRootCaCrl() Query params not supported")
+			return &resourceError{Message: "query parameters are not supported", StatusCode: http.StatusBadRequest}
+		}
+
+		existingRootCaCrl, err := retrieveRootCaCrl(r.Context(), db, conf, client)
+		if err != nil {
+			return err
+		}
+
+		crlExpired := isRootCaCrlExpired(existingRootCaCrl)
+		if crlExpired {
+			log.Warnf("Serving Root CA CRL past its nextUpdate %s", existingRootCaCrl.NextUpdate)
+		}
+
+		w.Header()["SGX-Root-CA-CRL-Expired"] = []string{strconv.FormatBool(crlExpired)}
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write([]byte(existingRootCaCrl.RootCaCrl))
+		if err != nil {
+			log.WithError(err).Error("Could not write root ca crl data to response")
+		}
+		slog.Infof("%s: Root CA CRL retrieved by: %s", commLogMsg.AuthorizedAccess, r.RemoteAddr)
+		return nil
+	}
+}
+
 // api to get quoting enclave identity information for a sgx platform
 func getQeIdentityInfo(db repository.SCSDatabase, config *config.Configuration, client *domain.HttpClient) errorHandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) error {
-		existingQeInfo, err := db.QEIdentityRepository().Retrieve()
-		if existingQeInfo == nil {
-			existingQeInfo, err = getLazyCacheQEIdentityInfo(db, constants.CacheInsert, config, client)
-			if err != nil || existingQeInfo == nil {
-				return &resourceError{Message: "Error retrieving QEIdentity info", StatusCode: http.StatusNotFound}
-			}
+		existingQeInfo, err := retrieveQeIdentity(r.Context(), db, config, client)
+		if err != nil {
+			return err
 		}
 
+		// issuer chain is returned in the header of Intel PCS as well, so that SCS can be consumed in
+		// place of PCS, and in the header the existing quote provider clients read
 		w.Header().Set("Content-Type", "application/json")
 		w.Header()["Sgx-Qe-Identity-Issuer-Chain"] = []string{existingQeInfo.QeIssuerChain}
+		w.Header()["Sgx-Enclave-Identity-Issuer-Chain"] = []string{existingQeInfo.QeIssuerChain}
 		w.WriteHeader(http.StatusOK)
 		_, err = w.Write([]byte(existingQeInfo.QeInfo))
 		if err != nil {
@@ -260,13 +266,9 @@ func getTcbInfo(db repository.SCSDatabase, config *config.Configuration, client
 			return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
 		}
 
-		tcbInfo := &types.FmspcTcbInfo{Fmspc: fmspc}
-		existingFmspc, err := db.FmspcTcbInfoRepository().Retrieve(tcbInfo)
-		if existingFmspc == nil {
-			existingFmspc, err = getLazyCacheFmspcTcbInfo(db, fmspc, constants.CacheInsert, config, client)
-			if err != nil || existingFmspc == nil {
-				return &resourceError{Message: "Error retrieving TCB info", StatusCode: http.StatusNotFound}
-			}
+		existingFmspc, err := retrieveTcbInfo(r.Context(), db, fmspc, config, client)
+		if err != nil {
+			return err
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -283,3 +285,59 @@ func getTcbInfo(db repository.SCSDatabase, config *config.Configuration, client
 		return nil
 	}
 }
+
+// api to get every collateral needed to verify a quote of a platform in a single response, so that a
+// verifier makes one call instead of one for each collateral. The platform is identified by qeid and
+// pceid, only TcbInfo and QE identity are returned when just fmspc is provided
+func getCollateral(db repository.SCSDatabase, config *config.Configuration, client *domain.HttpClient) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if len(r.URL.Query()) == 0 {
+			return &resourceError{Message: "query data not provided",
+				StatusCode: http.StatusBadRequest}
+		}
+
+		if err := validateQueryParams(r.URL.Query(), collateralRetrieveParams); err != nil {
+			slog.Errorf("resource/quote_provider_ops: getCollateral() %s", err.Error())
+			return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
+		}
+
+		qeid := strings.ToLower(r.URL.Query().Get("qeid"))
+		pceid := strings.ToLower(r.URL.Query().Get("pceid"))
+		fmspc := strings.ToLower(r.URL.Query().Get("fmspc"))
+
+		var platform *types.Platform
+		if qeid != "" || pceid != "" {
+			if !validateInputString(constants.QeIDKey, qeid) || !validateInputString(constants.PceIDKey, pceid) ||
+				(fmspc != "" && !validateInputString(constants.FmspcKey, fmspc)) {
+				slog.Errorf("resource/quote_provider_ops: getCollateral() Input validation failed for query parameter")
+				return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
+			}
+			var err error
+			platform, err = db.PlatformRepository().Retrieve(&types.Platform{QeID: qeid, PceID: pceid})
+			if err != nil {
+				return retrieveRecordError(err, "platform")
+			}
+			if fmspc != "" && fmspc != platform.Fmspc {
+				return &resourceError{Message: "fmspc does not match the cached platform", StatusCode: http.StatusBadRequest}
+			}
+		} else if !validateInputString(constants.FmspcKey, fmspc) {
+			slog.Errorf("resource/quote_provider_ops: getCollateral() Input validation failed for query parameter")
+			return &resourceError{Message: "invalid query param", StatusCode: http.StatusBadRequest}
+		}
+
+		bundle := retrieveCollateralBundle(r.Context(), db, platform, fmspc, config, client)
+		js, err := json.Marshal(bundle)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(js)
+		if err != nil {
+			log.WithError(err).Error("Could not write collateral bundle to response")
+		}
+		slog.Infof("%s: Collateral bundle retrieved by: %s", commLogMsg.AuthorizedAccess, r.RemoteAddr)
+		return nil
+	}
+}
@@ -5,17 +5,22 @@
 package resource
 
 import (
+	"compress/gzip"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
 	"intel/isecl/scs/v5/domain"
 	"intel/isecl/scs/v5/domain/mocks"
+	"intel/isecl/scs/v5/repository/memory"
 	"intel/isecl/scs/v5/repository/postgres/mock"
 	"intel/isecl/scs/v5/types"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	consts "github.com/intel-secl/intel-secl/v5/pkg/lib/common/constants"
@@ -154,6 +159,8 @@ var _ = Describe("Get PckCertificate Validation", func() {
 				newPckCert := &types.PckCert{
 					QeID:      "0518145496973c5e69577195511e9080",
 					PceID:     "0000",
+					CPUSvn:    "1bf8deed6f929ce40bd658e61ea722eb",
+					PceSvn:    "0a00",
 					CertIndex: 0,
 					Tcbms:     []string{"030300000000000000000000000000000A00"},
 					Fmspc:     "20606a000000",
@@ -343,6 +350,134 @@ var _ = Describe("Get PCKCRL Validation", func() {
 				router.ServeHTTP(w, req)
 				Expect(w.Code).To(Equal(http.StatusOK))
 			})
+
+			It("Should return StatusOK with CRL not expired - Valid CRL cached", func() {
+				now := time.Now().UTC()
+				validCrl, err := createTestPckCrl(now.Add(-time.Hour), now.Add(30*24*time.Hour))
+				Expect(err).NotTo(HaveOccurred())
+				pckCrl := &types.PckCrl{
+					Ca:              "processor",
+					PckCrlCertChain: "validcrlchain",
+					PckCrl:          base64.StdEncoding.EncodeToString(validCrl),
+					ThisUpdate:      now.Add(-time.Hour),
+					NextUpdate:      now.Add(30 * 24 * time.Hour),
+				}
+				crlDb := getMockDatabase()
+				crlDb.PckCrlRepository().Create(pckCrl)
+
+				QuoteProviderOps(router, crlDb, conf, &client)
+				req, err := http.NewRequest(http.MethodGet, "/pckcrl?ca=processor", nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(w.Header()["SGX-PCK-CRL-Expired"]).To(Equal([]string{"false"}))
+				Expect(w.Body.String()).To(Equal(pckCrl.PckCrl))
+			})
+
+			It("Should return StatusOK with CRL expired - Expired CRL cached and PCS returns expired CRL", func() {
+				now := time.Now().UTC()
+				expiredCrl, err := createTestPckCrl(now.Add(-31*24*time.Hour), now.Add(-24*time.Hour))
+				Expect(err).NotTo(HaveOccurred())
+				pckCrl := &types.PckCrl{
+					Ca:              "platform",
+					PckCrlCertChain: "expiredcrlchain",
+					PckCrl:          base64.StdEncoding.EncodeToString(expiredCrl),
+					ThisUpdate:      now.Add(-31 * 24 * time.Hour),
+					NextUpdate:      now.Add(-24 * time.Hour),
+				}
+				crlDb := getMockDatabase()
+				crlDb.PckCrlRepository().Create(pckCrl)
+
+				QuoteProviderOps(router, crlDb, conf, &client)
+				req, err := http.NewRequest(http.MethodGet, "/pckcrl?ca=platform", nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				Expect(w.Code).To(Equal(http.StatusOK))
+				// the mock PCS returns a CRL which expired in 2022
+				Expect(w.Header()["SGX-PCK-CRL-Expired"]).To(Equal([]string{"true"}))
+				Expect(w.Body.String()).NotTo(Equal(pckCrl.PckCrl))
+			})
+		})
+	})
+})
+
+// /rootcacrl resource validation
+var _ = Describe("Get Root CA CRL Validation", func() {
+	var router *mux.Router
+	var w *httptest.ResponseRecorder
+	var conf *config.Configuration
+	var client domain.HttpClient
+
+	conf = config.Load(testConfigFilePath)
+	client = mocks.NewClientMock(200)
+
+	rootCaCrlResponse := func(db *mock.MockDatabase, urlPath string) *httptest.ResponseRecorder {
+		QuoteProviderOps(router, db, conf, &client)
+		req, err := http.NewRequest(http.MethodGet, urlPath, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	BeforeEach(func() {
+		router = mux.NewRouter()
+	})
+
+	Describe("getRootCaCrl Resource validation", func() {
+		Context("getRootCaCrl request validation", func() {
+			It("Should return StatusBadRequest - Query params not supported", func() {
+				Expect(rootCaCrlResponse(getMockDatabase(), "/rootcacrl?ca=root").Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusOK - Root CA CRL fetched from PCS and cached", func() {
+				crlDb := getMockDatabase()
+				w := rootCaCrlResponse(crlDb, "/rootcacrl")
+				Expect(w.Code).To(Equal(http.StatusOK))
+				// the mock PCS returns a CRL which expired in 2022
+				Expect(w.Header()["SGX-Root-CA-CRL-Expired"]).To(Equal([]string{"true"}))
+
+				rootCaCrl, err := crlDb.RootCaCrlRepository().Retrieve()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(w.Body.String()).To(Equal(rootCaCrl.RootCaCrl))
+			})
+
+			It("Should return StatusOK with CRL not expired - Valid CRL cached", func() {
+				now := time.Now().UTC()
+				validCrl, err := createTestPckCrl(now.Add(-time.Hour), now.Add(30*24*time.Hour))
+				Expect(err).NotTo(HaveOccurred())
+				rootCaCrl := &types.RootCaCrl{
+					ID:         constants.RootCaCrlID,
+					RootCaCrl:  base64.StdEncoding.EncodeToString(validCrl),
+					ThisUpdate: now.Add(-time.Hour),
+					NextUpdate: now.Add(30 * 24 * time.Hour),
+				}
+				crlDb := getMockDatabase()
+				crlDb.RootCaCrlRepository().Create(rootCaCrl)
+
+				w := rootCaCrlResponse(crlDb, "/rootcacrl")
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(w.Header()["SGX-Root-CA-CRL-Expired"]).To(Equal([]string{"false"}))
+				Expect(w.Body.String()).To(Equal(rootCaCrl.RootCaCrl))
+			})
+
+			It("Should return StatusNotFound - PCS not reachable and no CRL cached", func() {
+				unavailableClient := mocks.NewClientMock(http.StatusBadRequest)
+				QuoteProviderOps(router, getMockDatabase(), conf, &unavailableClient)
+				req, err := http.NewRequest(http.MethodGet, "/rootcacrl", nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				Expect(w.Code).To(Equal(http.StatusNotFound))
+			})
 		})
 	})
 })
@@ -409,6 +544,27 @@ var _ = Describe("Get tcb info resource Validation", func() {
 				w = httptest.NewRecorder()
 				router.ServeHTTP(w, req)
 				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(w.Header().Get("Content-Encoding")).To(BeEmpty())
+			})
+
+			It("Should return gzip compressed response - Valid Request with Accept-Encoding gzip", func() {
+				QuoteProviderOps(router, db, conf, &client)
+				urlPath := fmt.Sprintf("/tcb?fmspc=20606a000000")
+				req, err := http.NewRequest(http.MethodGet, urlPath, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+				req.Header.Set("Accept-Encoding", "gzip")
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(w.Header().Get("Content-Encoding")).To(Equal("gzip"))
+
+				gr, err := gzip.NewReader(w.Body)
+				Expect(err).NotTo(HaveOccurred())
+				body, err := io.ReadAll(gr)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(json.Valid(body)).To(BeTrue())
 			})
 		})
 	})
@@ -464,6 +620,83 @@ var _ = Describe("Get QeIndentiry resource Validation", func() {
 				w = httptest.NewRecorder()
 				router.ServeHTTP(w, req)
 				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(w.Body.String()).To(Equal(qeIdentity.QeInfo))
+				Expect(w.Header().Get("Sgx-Enclave-Identity-Issuer-Chain")).To(Equal(qeIdentity.QeIssuerChain))
+				Expect(w.Header().Get("Sgx-Qe-Identity-Issuer-Chain")).To(Equal(qeIdentity.QeIssuerChain))
+			})
+		})
+	})
+})
+
+// /collateral resource validation
+var _ = Describe("Get collateral bundle resource Validation", func() {
+	var router *mux.Router
+	var db *memory.Database
+
+	getCollateralResponse := func(query string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, "/collateral"+query, nil)
+		Expect(err).NotTo(HaveOccurred())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	BeforeEach(func() {
+		router = mux.NewRouter()
+		db = memory.NewDatabase()
+		QuoteProviderOps(router, db, nil, nil)
+
+		db.PlatformRepository().Create(&types.Platform{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+			CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", Fmspc: "20606a000000", Ca: "processor"})
+		db.PckCertChainRepository().Create(&types.PckCertChain{Ca: "processor", PckCertChain: "certchain"})
+		db.PckCertRepository().Create(&types.PckCert{QeID: "0518145496973c5e69577195511e9080", PceID: "0000",
+			CPUSvn: "1bf8deed6f929ce40bd658e61ea722eb", PceSvn: "0a00", PckCerts: []string{"cert0"},
+			Tcbms: []string{"020200000000000000000000000000000a00"}})
+		db.FmspcTcbInfoRepository().Create(&types.FmspcTcbInfo{Fmspc: "20606a000000", TcbInfo: string(testTcbInfoJson)})
+	})
+
+	Describe("Get collateral bundle Resource validation", func() {
+		Context("Get collateral bundle request validation", func() {
+			It("Should return StatusBadRequest - No query parameters", func() {
+				Expect(getCollateralResponse("").Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusBadRequest - Invalid query parameters", func() {
+				Expect(getCollateralResponse("?qeid=0518145496973c5e69577195511e9080").Code).To(Equal(http.StatusBadRequest))
+				Expect(getCollateralResponse("?fmspc=20606a").Code).To(Equal(http.StatusBadRequest))
+				Expect(getCollateralResponse("?ca=processor").Code).To(Equal(http.StatusBadRequest))
+				Expect(getCollateralResponse("?qeid=0518145496973c5e69577195511e9080&pceid=0000&fmspc=00906ed50000").Code).
+					To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should return StatusNotFound - Platform not cached", func() {
+				Expect(getCollateralResponse("?qeid=1518145496973c5e69577195511e9080&pceid=0000").Code).To(Equal(http.StatusNotFound))
+			})
+
+			It("Should return StatusOK - Collaterals of platform with per collateral status", func() {
+				w := getCollateralResponse("?qeid=0518145496973c5e69577195511e9080&pceid=0000")
+				Expect(w.Code).To(Equal(http.StatusOK))
+
+				var bundle CollateralBundle
+				Expect(json.Unmarshal(w.Body.Bytes(), &bundle)).To(Succeed())
+				Expect(bundle.Fmspc).To(Equal("20606a000000"))
+				Expect(bundle.PckCert.Status).To(Equal(constants.CollateralStatusPresent))
+				Expect(bundle.PckCert.Data).To(Equal("cert0"))
+				Expect(bundle.PckCrl.Status).To(Equal(constants.CollateralStatusMissing))
+				// TcbInfo past its nextUpdate is returned along with its status
+				Expect(bundle.TcbInfo.Status).To(Equal(constants.CollateralStatusExpired))
+				Expect(bundle.TcbInfo.Data).To(Equal(string(testTcbInfoJson)))
+				Expect(bundle.QeIdentity.Status).To(Equal(constants.CollateralStatusMissing))
+			})
+
+			It("Should return StatusOK - Collaterals of fmspc", func() {
+				w := getCollateralResponse("?fmspc=20606a000000")
+				Expect(w.Code).To(Equal(http.StatusOK))
+
+				var bundle CollateralBundle
+				Expect(json.Unmarshal(w.Body.Bytes(), &bundle)).To(Succeed())
+				Expect(bundle.PckCert).To(BeNil())
+				Expect(bundle.TcbInfo.Data).To(Equal(string(testTcbInfoJson)))
 			})
 		})
 	})
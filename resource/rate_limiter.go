@@ -0,0 +1,135 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// tokenBucket holds up to burst tokens which are refilled at rate tokens per second,
+// a request is allowed only if a token can be taken from the bucket
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: now}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+}
+
+// wait returns how long a request has to wait for a token, after refilling the bucket
+func (b *tokenBucket) wait(now time.Time) time.Duration {
+	b.refill(now)
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// RateLimiter limits the rate of requests from all clients and from each client IP with token
+// buckets. A limit is disabled when its rate is not positive
+type RateLimiter struct {
+	mu      sync.Mutex
+	limit   config.WriteRateLimit
+	global  *tokenBucket
+	clients map[string]*tokenBucket
+}
+
+func NewRateLimiter(limit config.WriteRateLimit) *RateLimiter {
+	l := &RateLimiter{limit: limit, clients: make(map[string]*tokenBucket)}
+	if limit.Rate > 0 {
+		l.global = newTokenBucket(limit.Rate, limit.Burst, clock.Now())
+	}
+	return l
+}
+
+// allow takes a token for a request from the client, if both the global and the client bucket
+// have one. Otherwise the duration after which the request can be retried is returned
+func (l *RateLimiter) allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := clock.Now()
+	var buckets []*tokenBucket
+	if l.global != nil {
+		buckets = append(buckets, l.global)
+	}
+	if l.limit.ClientRate > 0 {
+		bucket, ok := l.clients[client]
+		if !ok {
+			l.evictIdleClients(now)
+			bucket = newTokenBucket(l.limit.ClientRate, l.limit.ClientBurst, now)
+			l.clients[client] = bucket
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	var retryAfter time.Duration
+	for _, bucket := range buckets {
+		if wait := bucket.wait(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	if retryAfter > 0 {
+		return false, retryAfter
+	}
+	for _, bucket := range buckets {
+		bucket.tokens--
+	}
+	return true, 0
+}
+
+// evictIdleClients drops the buckets of clients which have been refilled completely once too
+// many clients are tracked, as such clients are not limited anyway
+func (l *RateLimiter) evictIdleClients(now time.Time) {
+	if len(l.clients) < constants.MaxRateLimitedClients {
+		return
+	}
+	for client, bucket := range l.clients {
+		if bucket.refill(now); bucket.tokens >= bucket.burst {
+			delete(l.clients, client)
+		}
+	}
+}
+
+// Middleware rejects the mutating requests exceeding the limits with 429 and a Retry-After header
+// in seconds, read only requests are not limited
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		client, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			client = r.RemoteAddr
+		}
+		allowed, retryAfter := l.allow(client)
+		if !allowed {
+			slog.Warnf("resource/rate_limiter: Middleware() Rate limit exceeded for %s %s by: %s", r.Method, r.URL.Path, r.RemoteAddr)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"intel/isecl/scs/v5/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func rateLimitedResponse(handler http.Handler, method, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/platforms", nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimiterClientLimit(t *testing.T) {
+	fc := useFakeClock(t, time.Date(2022, 6, 21, 0, 0, 0, 0, time.UTC))
+	limiter := NewRateLimiter(config.WriteRateLimit{ClientRate: 0.5, ClientBurst: 2})
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	assert.Equal(t, http.StatusCreated, rateLimitedResponse(handler, http.MethodPost, "10.0.0.1:4000").Code)
+	assert.Equal(t, http.StatusCreated, rateLimitedResponse(handler, http.MethodPost, "10.0.0.1:4001").Code)
+	w := rateLimitedResponse(handler, http.MethodPost, "10.0.0.1:4002")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+	// other clients and read only requests are not limited
+	assert.Equal(t, http.StatusCreated, rateLimitedResponse(handler, http.MethodPost, "10.0.0.2:4000").Code)
+	assert.Equal(t, http.StatusCreated, rateLimitedResponse(handler, http.MethodGet, "10.0.0.1:4003").Code)
+
+	fc.Advance(2 * time.Second)
+	assert.Equal(t, http.StatusCreated, rateLimitedResponse(handler, http.MethodPost, "10.0.0.1:4004").Code)
+	assert.Equal(t, http.StatusTooManyRequests, rateLimitedResponse(handler, http.MethodPost, "10.0.0.1:4005").Code)
+}
+
+func TestRateLimiterGlobalLimit(t *testing.T) {
+	fc := useFakeClock(t, time.Date(2022, 6, 21, 0, 0, 0, 0, time.UTC))
+	limiter := NewRateLimiter(config.WriteRateLimit{Rate: 10, Burst: 3, ClientRate: 1, ClientBurst: 2})
+
+	allowed, _ := limiter.allow("10.0.0.1")
+	assert.True(t, allowed)
+	allowed, _ = limiter.allow("10.0.0.2")
+	assert.True(t, allowed)
+	allowed, _ = limiter.allow("10.0.0.3")
+	assert.True(t, allowed)
+	allowed, retryAfter := limiter.allow("10.0.0.4")
+	assert.False(t, allowed)
+	assert.Equal(t, 100*time.Millisecond, retryAfter)
+	// a request rejected by the global limit does not take a token of the client
+	assert.Equal(t, float64(2), limiter.clients["10.0.0.4"].tokens)
+
+	// client limit applies along with the global limit
+	fc.Advance(100 * time.Millisecond)
+	allowed, _ = limiter.allow("10.0.0.1")
+	assert.True(t, allowed)
+	fc.Advance(100 * time.Millisecond)
+	allowed, _ = limiter.allow("10.0.0.1")
+	assert.False(t, allowed)
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	limiter := NewRateLimiter(config.WriteRateLimit{})
+	for i := 0; i < 100; i++ {
+		allowed, _ := limiter.allow("10.0.0.1")
+		assert.True(t, allowed)
+	}
+	assert.Empty(t, limiter.clients)
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RefreshFailureAlert is posted to the alert webhook when a collateral has failed to refresh
+// the configured number of consecutive times
+type RefreshFailureAlert struct {
+	Collateral          string    `json:"collateral"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Error               string    `json:"error"`
+	Time                time.Time `json:"time"`
+}
+
+// refreshFailures counts the consecutive failed refreshes of each collateral, so that a collateral
+// which persistently fails to refresh is alerted once instead of logging the same error every refresh
+type refreshFailures struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newRefreshFailures() *refreshFailures {
+	return &refreshFailures{counts: make(map[string]int)}
+}
+
+// failures is replaced only by tests
+var failures = newRefreshFailures()
+
+func (f *refreshFailures) consecutiveFailures(collateral string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.counts[collateral]
+}
+
+// record counts a failed refresh of the collateral, or resets its count when err is nil. It
+// returns the count and whether the count has just reached the alert threshold
+func (f *refreshFailures) record(collateral string, err error, threshold int) (int, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err == nil {
+		count := f.counts[collateral]
+		delete(f.counts, collateral)
+		if threshold > 0 && count >= threshold {
+			log.Infof("Refresh of %s succeeded after %d consecutive failures", collateral, count)
+		}
+		return 0, false
+	}
+	f.counts[collateral]++
+	return f.counts[collateral], threshold > 0 && f.counts[collateral] == threshold
+}
+
+// recordRefreshResult records the result of a refresh of the collateral, raising an alert once the
+// collateral has failed to refresh the configured number of consecutive times
+func recordRefreshResult(collateral string, err error, conf *config.Configuration) {
+	var alertConf config.RefreshFailureAlert
+	if conf != nil {
+		alertConf = conf.RefreshFailureAlert
+	}
+	count, alert := failures.record(collateral, err, alertConf.Threshold)
+	if !alert {
+		return
+	}
+
+	log.WithError(err).Errorf("ALERT: refresh of %s has failed %d consecutive times, cached %s is not being refreshed from PCS",
+		collateral, count, collateral)
+	if alertConf.WebhookURL != "" {
+		alertErr := postRefreshFailureAlert(alertConf.WebhookURL,
+			RefreshFailureAlert{Collateral: collateral, ConsecutiveFailures: count, Error: err.Error(), Time: clock.Now().UTC()})
+		if alertErr != nil {
+			log.WithError(alertErr).Errorf("Could not post refresh failure alert of %s", collateral)
+		}
+	}
+}
+
+func postRefreshFailureAlert(webhookURL string, alert RefreshFailureAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal alert")
+	}
+	client := &http.Client{Timeout: constants.RefreshAlertWebhookTimeout}
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "could not post alert to webhook")
+	}
+	defer func() {
+		derr := resp.Body.Close()
+		if derr != nil {
+			log.WithError(derr).Error("Error closing response body")
+		}
+	}()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return errors.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"encoding/json"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// useRefreshFailures resets the refresh failure counts of resource package until the test completes
+func useRefreshFailures(t *testing.T) {
+	failures = newRefreshFailures()
+	t.Cleanup(func() {
+		failures = newRefreshFailures()
+	})
+}
+
+func TestRecordRefreshResult(t *testing.T) {
+	useRefreshFailures(t)
+
+	var alerts []RefreshFailureAlert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert RefreshFailureAlert
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&alert))
+		alerts = append(alerts, alert)
+	}))
+	defer server.Close()
+
+	conf := &config.Configuration{RefreshFailureAlert: config.RefreshFailureAlert{Threshold: 2, WebhookURL: server.URL}}
+	refreshErr := errors.New("pcs unreachable")
+
+	recordRefreshResult(constants.CollateralTcbInfo, refreshErr, conf)
+	assert.Empty(t, alerts)
+	recordRefreshResult(constants.CollateralTcbInfo, refreshErr, conf)
+	assert.Equal(t, []RefreshFailureAlert{{Collateral: constants.CollateralTcbInfo, ConsecutiveFailures: 2,
+		Error: "pcs unreachable", Time: alerts[0].Time}}, alerts)
+
+	// alerted only once when the threshold is crossed
+	recordRefreshResult(constants.CollateralTcbInfo, refreshErr, conf)
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, 3, failures.consecutiveFailures(constants.CollateralTcbInfo))
+
+	// failures of other collaterals are counted separately
+	recordRefreshResult(constants.CollateralPckCrl, refreshErr, conf)
+	assert.Len(t, alerts, 1)
+
+	// count is reset on success and the collateral is alerted again on crossing the threshold
+	recordRefreshResult(constants.CollateralTcbInfo, nil, conf)
+	assert.Equal(t, 0, failures.consecutiveFailures(constants.CollateralTcbInfo))
+	recordRefreshResult(constants.CollateralTcbInfo, refreshErr, conf)
+	recordRefreshResult(constants.CollateralTcbInfo, refreshErr, conf)
+	assert.Len(t, alerts, 2)
+}
+
+func TestRecordRefreshResultDisabled(t *testing.T) {
+	useRefreshFailures(t)
+
+	conf := &config.Configuration{}
+	for i := 0; i < 5; i++ {
+		count, alert := failures.record(constants.CollateralQeIdentity, errors.New("pcs unreachable"), conf.RefreshFailureAlert.Threshold)
+		assert.Equal(t, i+1, count)
+		assert.False(t, alert)
+	}
+}
+
+func TestPostRefreshFailureAlert(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := postRefreshFailureAlert(server.URL, RefreshFailureAlert{Collateral: constants.CollateralPckCert})
+	assert.Error(t, err)
+}
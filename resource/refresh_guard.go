@@ -0,0 +1,60 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"intel/isecl/scs/v5/constants"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gorilla/mux"
+)
+
+// fullRefreshInProgress is 1 while a full refresh updates the cached collaterals, it is an int32 as
+// atomic.Bool needs Go 1.19 and SCS is built with Go 1.18
+var fullRefreshInProgress int32
+
+func setFullRefreshInProgress(inProgress bool) {
+	var value int32
+	if inProgress {
+		value = 1
+	}
+	atomic.StoreInt32(&fullRefreshInProgress, value)
+}
+
+func isFullRefreshInProgress() bool {
+	return atomic.LoadInt32(&fullRefreshInProgress) == 1
+}
+
+// NewRefreshGuard fails the read apis with 503 and a Retry-After header while a full refresh is in
+// progress when policy is unavailable, so that consumers requiring consistent data do not read a cache
+// partly refreshed. Status of the refresh is still served, and mutating apis are not affected
+func NewRefreshGuard(policy string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if policy != constants.ReadsDuringRefreshUnavailable {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isFullRefreshInProgress() || (r.Method != http.MethodGet && r.Method != http.MethodHead) || isRefreshStatusRoute(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			log.Debugf("resource/refresh_guard: Read of %s rejected during a full refresh", r.URL.Path)
+			w.Header().Set("Retry-After", strconv.Itoa(constants.RefreshInProgressRetryAfter))
+			http.Error(w, "refresh in progress", http.StatusServiceUnavailable)
+		})
+	}
+}
+
+func isRefreshStatusRoute(r *http.Request) bool {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return false
+	}
+	template, err := route.GetPathTemplate()
+	return err == nil && strings.TrimPrefix(template, constants.APIPathPrefix) == "/refreshes"
+}
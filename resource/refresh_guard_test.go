@@ -0,0 +1,62 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"intel/isecl/scs/v5/constants"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRefreshGuardRouter(policy string) *mux.Router {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	router := mux.NewRouter()
+	sr := router.PathPrefix(constants.APIPathPrefix).Subrouter()
+	sr.Use(NewRefreshGuard(policy))
+	sr.Handle("/tcbstatus", ok).Methods("GET")
+	sr.Handle("/refreshes", ok).Methods("GET", "POST")
+	sr.Handle("/platforms", ok).Methods("POST")
+	return router
+}
+
+func refreshGuardResponse(router *mux.Router, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(method, constants.APIPathPrefix+path, nil))
+	return w
+}
+
+func TestRefreshGuard(t *testing.T) {
+	defer setFullRefreshInProgress(false)
+	router := newRefreshGuardRouter(constants.ReadsDuringRefreshUnavailable)
+
+	assert.Equal(t, http.StatusOK, refreshGuardResponse(router, http.MethodGet, "/tcbstatus").Code)
+
+	// reads are rejected while a full refresh is in progress
+	setFullRefreshInProgress(true)
+	w := refreshGuardResponse(router, http.MethodGet, "/tcbstatus")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	// except the status of the refresh, and mutating apis are not affected
+	assert.Equal(t, http.StatusOK, refreshGuardResponse(router, http.MethodGet, "/refreshes").Code)
+	assert.Equal(t, http.StatusOK, refreshGuardResponse(router, http.MethodPost, "/platforms").Code)
+
+	setFullRefreshInProgress(false)
+	assert.Equal(t, http.StatusOK, refreshGuardResponse(router, http.MethodGet, "/tcbstatus").Code)
+}
+
+func TestRefreshGuardServe(t *testing.T) {
+	defer setFullRefreshInProgress(false)
+	router := newRefreshGuardRouter(constants.ReadsDuringRefreshServe)
+
+	setFullRefreshInProgress(true)
+	assert.Equal(t, http.StatusOK, refreshGuardResponse(router, http.MethodGet, "/tcbstatus").Code)
+}
@@ -12,9 +12,11 @@ import (
 	commLogMsg "intel/isecl/lib/common/v5/log/message"
 	ct "intel/isecl/lib/common/v5/types/aas"
 	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/repository"
 	"net/http"
 
-	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 var log = clog.GetDefaultLogger()
@@ -27,7 +29,7 @@ func (ehf errorHandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	defer log.Trace("resource/resource:ServeHTTP() Leaving")
 	if err := ehf(w, r); err != nil {
 		log.WithError(err).Error("HTTP Error")
-		if gorm.IsRecordNotFoundError(err) {
+		if errors.Is(err, repository.ErrNotFound) {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
 		}
@@ -46,6 +48,16 @@ func (ehf errorHandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// retrieveRecordError returns the error of a failed retrieval of a record, which is 404 when the
+// record is not found and 500 when the database could not be queried
+func retrieveRecordError(err error, record string) *resourceError {
+	if errors.Is(err, repository.ErrNotFound) {
+		return &resourceError{Message: "no " + record + " record found: " + err.Error(), StatusCode: http.StatusNotFound}
+	}
+	return &resourceError{Message: "could not retrieve " + record + " record: " + err.Error(),
+		StatusCode: http.StatusInternalServerError}
+}
+
 type privilegeError struct {
 	StatusCode int
 	Message    string
@@ -64,21 +76,52 @@ func (e resourceError) Error() string {
 	return fmt.Sprintf("%d: %s", e.StatusCode, e.Message)
 }
 
+// pcsErrorStatusCode maps a failed Intel PCS server api call to the status code returned
+// by SCS, defaultCode is returned when err is neither a PcsError nor a PcsReadError
+func pcsErrorStatusCode(err error, defaultCode int) int {
+	var readErr *PcsReadError
+	if errors.As(err, &readErr) {
+		return http.StatusBadGateway
+	}
+	var pcsErr *PcsError
+	if !errors.As(err, &pcsErr) {
+		return defaultCode
+	}
+	switch pcsErr.StatusCode {
+	case http.StatusBadRequest, http.StatusNotFound:
+		return pcsErr.StatusCode
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadGateway
+}
+
 func authorizeEndpoint(r *http.Request, roleName string, retNilCtxForEmptyCtx bool) error {
 	log.Trace("resource/resource:authorizeEndpoint() Entering")
 	defer log.Trace("resource/resource:authorizeEndpoint() Leaving")
 
 	privileges, err := context.GetUserRoles(r)
 	if err != nil {
-		slog.WithError(err).Error("resource/resource: authorizeEndpoint() Failed to read roles and permissions")
+		authorizationAuditEntry(r, roleName).WithError(err).Error("resource/resource: authorizeEndpoint() Failed to read roles and permissions")
 		return &resourceError{Message: "Could not get user roles from http context", StatusCode: http.StatusInternalServerError}
 	}
 
 	_, foundRole := auth.ValidatePermissionAndGetRoleContext(privileges, []ct.RoleInfo{{Service: constants.ServiceName, Name: roleName}}, retNilCtxForEmptyCtx)
 	if !foundRole {
-		slog.Infof("resource/resource: authorizeEndpoint() %s: endpoint access unauthorized, request role: %v", commLogMsg.UnauthorizedAccess, roleName)
+		authorizationAuditEntry(r, roleName).WithField("decision", "denied").
+			Warnf("resource/resource: authorizeEndpoint() %s: endpoint access unauthorized, request role: %v", commLogMsg.UnauthorizedAccess, roleName)
 		return &privilegeError{Message: "", StatusCode: http.StatusForbidden}
 	}
-	slog.Infof("resource/resource: authorizeEndpoint() %s - %s", commLogMsg.AuthorizedAccess, r.RequestURI)
+	authorizationAuditEntry(r, roleName).WithField("decision", "granted").
+		Infof("resource/resource: authorizeEndpoint() %s - %s", commLogMsg.AuthorizedAccess, r.URL.Path)
 	return nil
 }
+
+// authorizationAuditEntry is the security log entry of an authorization decision, it identifies the
+// caller and the endpoint so that denials by probing or misconfigured clients can be traced. The query
+// is left out of the endpoint as it may carry an encrypted ppid
+func authorizationAuditEntry(r *http.Request, roleName string) *logrus.Entry {
+	subject, _ := context.GetTokenSubject(r)
+	return slog.WithField("subject", subject).WithField("endpoint", r.Method+" "+r.URL.Path).
+		WithField("required-role", roleName).WithField("remote-addr", r.RemoteAddr)
+}
@@ -136,14 +136,17 @@ func TestResource(t *testing.T) {
 
 func getMockDatabase() *mock.MockDatabase {
 	db := &mock.MockDatabase{
-		MockPlatformRepository:     mock.NewMockPlatformRepository(),
-		MockPlatformTcbRepository:  mock.NewMockPlatformTcbRepository(),
-		MockFmspcTcbInfoRepository: mock.NewMockFmspcTcbInfoRepository(),
-		MockPckCertChainRepository: mock.NewMockPckCertChainRepository(),
-		MockPckCertRepository:      mock.NewMockPckCertRepository(),
-		MockPckCrlRepository:       mock.NewMockPckCrlRepository(),
-		MockLastRefreshRepository:  mock.NewMockLastRefreshRepository(),
-		MockQEIdentityRepository:   mock.NewMockQEIdentityRepository(),
+		MockPlatformRepository:       mock.NewMockPlatformRepository(),
+		MockPlatformTcbRepository:    mock.NewMockPlatformTcbRepository(),
+		MockFmspcTcbInfoRepository:   mock.NewMockFmspcTcbInfoRepository(),
+		MockPckCertChainRepository:   mock.NewMockPckCertChainRepository(),
+		MockPckCertRepository:        mock.NewMockPckCertRepository(),
+		MockPckCrlRepository:         mock.NewMockPckCrlRepository(),
+		MockLastRefreshRepository:    mock.NewMockLastRefreshRepository(),
+		MockQEIdentityRepository:     mock.NewMockQEIdentityRepository(),
+		MockRootCaCrlRepository:      mock.NewMockRootCaCrlRepository(),
+		MockPcsAuditRecordRepository: mock.NewMockPcsAuditRecordRepository(),
+		MockTcbLevelRepository:       mock.NewMockTcbLevelRepository(),
 	}
 
 	return db
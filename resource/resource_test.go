@@ -5,11 +5,16 @@
 package resource
 
 import (
+	"intel/isecl/lib/common/v5/context"
+	"intel/isecl/lib/common/v5/types/aas"
+	"intel/isecl/scs/v5/constants"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -66,3 +71,35 @@ func TestErrorHandlerFuncServeHTTP(t *testing.T) {
 	ts = testServerHTTP(404)
 	ts.ServeHTTP(w, r)
 }
+
+func TestAuthorizeEndpointAudit(t *testing.T) {
+	hook := logtest.NewLocal(slog.Logger)
+	defer hook.Reset()
+
+	req := httptest.NewRequest(http.MethodPost, "/cache/purge?fmspc=20606a000000", nil)
+	req.RemoteAddr = "10.1.2.3:4567"
+	req = context.SetTokenSubject(req, "5a8de8c4-84a1-4cc0-9b95-2d8b9a0ff1b4")
+	permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{constants.HostDataReaderGroupName}}
+	req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+	roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.HostDataReaderGroupName, Context: "type=SCS"}}
+	req = context.SetUserRoles(req, roleInfo)
+
+	err := authorizeEndpoint(req, constants.CacheManagerGroupName, true)
+	assert.IsType(t, &privilegeError{}, err)
+	entry := hook.LastEntry()
+	if assert.NotNil(t, entry) {
+		assert.Equal(t, logrus.WarnLevel, entry.Level)
+		assert.Equal(t, "denied", entry.Data["decision"])
+		assert.Equal(t, "5a8de8c4-84a1-4cc0-9b95-2d8b9a0ff1b4", entry.Data["subject"])
+		assert.Equal(t, "POST /cache/purge", entry.Data["endpoint"])
+		assert.Equal(t, constants.CacheManagerGroupName, entry.Data["required-role"])
+		assert.Equal(t, "10.1.2.3:4567", entry.Data["remote-addr"])
+	}
+
+	assert.NoError(t, authorizeEndpoint(req, constants.HostDataReaderGroupName, true))
+	entry = hook.LastEntry()
+	if assert.NotNil(t, entry) {
+		assert.Equal(t, "granted", entry.Data["decision"])
+		assert.NotContains(t, entry.Message, "fmspc")
+	}
+}
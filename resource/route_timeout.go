@@ -0,0 +1,47 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"intel/isecl/scs/v5/constants"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// NewRouteTimeout bounds the time to serve the routes with a timeout, keyed by path template after the
+// api prefix. A request exceeding the timeout of its route has its context canceled and is answered
+// with 503, requests of other routes are bound only by the server timeouts. The timeout response is
+// written within the server write timeout, which setup checks the route timeouts do not exceed
+func NewRouteTimeout(timeouts map[string]time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := routeTimeout(r, timeouts)
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			http.TimeoutHandler(next, timeout, "request timed out").ServeHTTP(w, r)
+		})
+	}
+}
+
+func routeTimeout(r *http.Request, timeouts map[string]time.Duration) time.Duration {
+	if len(timeouts) == 0 {
+		return 0
+	}
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return 0
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return 0
+	}
+	return timeouts[strings.TrimPrefix(template, constants.APIPathPrefix)]
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"intel/isecl/scs/v5/constants"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// sleepHandler responds after the delay, or gives up when the request is canceled
+func sleepHandler(delay time.Duration, canceled chan<- bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+			_, _ = w.Write([]byte("done"))
+		case <-r.Context().Done():
+			canceled <- true
+		}
+	}
+}
+
+func newRouteTimeoutRouter(timeouts map[string]time.Duration, canceled chan<- bool) *mux.Router {
+	router := mux.NewRouter()
+	sr := router.PathPrefix(constants.APIPathPrefix).Subrouter()
+	sr.Use(NewRouteTimeout(timeouts))
+	sr.Handle("/refreshes", sleepHandler(100*time.Millisecond, canceled)).Methods("POST")
+	sr.Handle("/tcbstatus", sleepHandler(100*time.Millisecond, canceled)).Methods("GET")
+	sr.Handle("/platforms/{qeid}", sleepHandler(100*time.Millisecond, canceled)).Methods("GET")
+	return router
+}
+
+func TestRouteTimeout(t *testing.T) {
+	canceled := make(chan bool, 1)
+	router := newRouteTimeoutRouter(map[string]time.Duration{"/refreshes": time.Second, "/tcbstatus": 20 * time.Millisecond,
+		"/platforms/{qeid}": 20 * time.Millisecond}, canceled)
+
+	// route exceeding its timeout is canceled and answered with 503
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, constants.APIPathPrefix+"/tcbstatus?qeid=0518145496973c5e69577195511e9080", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "request timed out")
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("context of the timed out request was not canceled")
+	}
+
+	// timeouts are looked up by the route template
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, constants.APIPathPrefix+"/platforms/0518145496973c5e69577195511e9080", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	<-canceled
+
+	// route within its timeout
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, constants.APIPathPrefix+"/refreshes", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "done", w.Body.String())
+
+	// routes are not bound when no timeouts are configured
+	router = newRouteTimeoutRouter(nil, canceled)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, constants.APIPathPrefix+"/tcbstatus", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -6,11 +6,17 @@ package resource
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
 	"intel/isecl/scs/v5/domain"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
@@ -28,19 +34,39 @@ func getRespFromProvServer(req *http.Request, client domain.HttpClient, conf *co
 		return nil, errors.New("getRespFromProvServer(): Empty client provided")
 	}
 
-	var retries int = conf.RetryCount
-	var timeBwCalls int = conf.WaitTime
+	retries, timeBwCalls := conf.PcsRetries()
 
 	for retries >= 0 {
-		resp, err := client.Do(req)
+		resp, err := doPcsRequest(req, client)
+		if err == nil && resp.StatusCode == http.StatusOK {
+			// body is read here, so that a body cut short after an OK status, e.g. as the connection
+			// dropped midway, is retried like a failed request instead of failing its caller
+			err = bufferPcsResponseBody(req, resp)
+		} else if err == nil && resp.StatusCode >= http.StatusInternalServerError {
+			// server errors of PCS are retried, the error of the last attempt is returned to the caller
+			err = newPcsError(req.URL.Path, resp)
+		}
+		if conf.PcsDebugLogEnabled {
+			logPcsExchange(req, resp, err)
+		}
 
 		if err == nil {
 			return resp, err
 		}
 
-		if resp != nil && resp.StatusCode < http.StatusInternalServerError {
+		if resp != nil && !isTransientPcsError(err) {
 			return resp, err
 		}
+		if resp != nil && resp.Body != nil {
+			derr := resp.Body.Close()
+			if derr != nil {
+				log.WithError(derr).Error("Error closing failed PCS response body")
+			}
+		}
+		// the request is not retried once its caller has given up on it
+		if req.Context().Err() != nil {
+			return resp, errors.Wrap(req.Context().Err(), "getRespFromProvServer: PCS request abandoned")
+		}
 		retries -= 1
 		if retries <= 0 {
 			log.Error("getRespFromProvServer:ERROR ", err)
@@ -49,12 +75,184 @@ func getRespFromProvServer(req *http.Request, client domain.HttpClient, conf *co
 
 		select {
 		case <-time.After(time.Duration(timeBwCalls) * time.Second):
+		case <-req.Context().Done():
+			return resp, errors.Wrap(req.Context().Err(), "getRespFromProvServer: PCS request abandoned")
+		}
+		if req.GetBody != nil {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return nil, errors.Wrap(err, "getRespFromProvServer: Failed to reset request body")
+			}
+		}
+	}
+	return resp, err
+}
+
+// bufferPcsResponseBody reads the body of a PCS response in full and replaces it with the bytes read,
+// a body which cannot be read in full is returned as a PcsReadError
+func bufferPcsResponseBody(req *http.Request, resp *http.Response) error {
+	if resp.Body == nil {
+		return nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	derr := resp.Body.Close()
+	if derr != nil {
+		log.WithError(derr).Error("Error closing PCS response body")
+	}
+	if err != nil {
+		return &PcsReadError{Endpoint: req.URL.Path, Err: err}
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return nil
+}
+
+// isTransientPcsError tells whether a PCS request which failed may succeed when sent again. Failures
+// to read a response body and server errors of PCS are transient, other statuses of PCS are not
+func isTransientPcsError(err error) bool {
+	var readErr *PcsReadError
+	if errors.As(err, &readErr) {
+		return true
+	}
+	var pcsErr *PcsError
+	if errors.As(err, &pcsErr) {
+		return pcsErr.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// pcsRequestSlots caps the number of PCS requests in flight from refresh, lazy caching and every
+// other path together, so that bursts do not get rate limited by PCS. Requests are not limited when nil
+var pcsRequestSlots chan struct{}
+
+// LimitPcsRequests sets the number of concurrent requests to Intel PCS server, the default is used
+// when limit is not positive. It has to be called before any PCS request is sent
+func LimitPcsRequests(limit int) {
+	if limit <= 0 {
+		limit = constants.DefaultPcsRequestConcurrency
+	}
+	pcsRequestSlots = make(chan struct{}, limit)
+}
+
+// doPcsRequest sends a PCS request once a request slot is free. The slot is held until response
+// headers are received, the request fails if its context is done while waiting for a slot
+func doPcsRequest(req *http.Request, client domain.HttpClient) (*http.Response, error) {
+	slots := pcsRequestSlots
+	if slots != nil {
+		select {
+		case slots <- struct{}{}:
+		case <-req.Context().Done():
+			return nil, errors.Wrap(req.Context().Err(), "doPcsRequest(): Waiting for a PCS request slot was cancelled")
+		}
+		defer func() {
+			<-slots
+		}()
+	}
+
+	// the trace of the request is continued by PCCS, and by any proxy to Intel PCS server which is traced
+	_, span := startSpanOfKind(req.Context(), "PCS "+req.Method+" "+req.URL.Path, spanKindClient)
+	if span != nil {
+		req.Header.Set("traceparent", span.context.traceparent())
+	}
+	span.setAttribute("http.method", req.Method)
+	span.setAttribute("http.url", redactPcsURL(req.URL))
+	resp, err := client.Do(req)
+	spanErr := err
+	if err == nil && resp != nil {
+		span.setAttribute("http.status_code", resp.StatusCode)
+		if resp.StatusCode >= http.StatusBadRequest {
+			spanErr = errors.Errorf("PCS returned status %d", resp.StatusCode)
+		}
+	}
+	span.end(spanErr)
+	return resp, err
+}
+
+const redactedValue = "REDACTED"
+
+// query params and headers of PCS requests which must never be logged
+var pcsSecretQueryParams = []string{"encrypted_ppid"}
+var pcsSecretHeaders = []string{"Ocp-Apim-Subscription-Key"}
+
+// redactPcsURL returns the url of a PCS request with secret query params redacted
+func redactPcsURL(u *url.URL) string {
+	redacted := *u
+	q := redacted.Query()
+	for _, param := range pcsSecretQueryParams {
+		if _, ok := q[param]; ok {
+			q.Set(param, redactedValue)
+		}
+	}
+	redacted.RawQuery = q.Encode()
+	return redacted.String()
+}
+
+// redactPcsHeaders returns a copy of headers with secret headers redacted
+func redactPcsHeaders(headers http.Header) http.Header {
+	redacted := headers.Clone()
+	for _, header := range pcsSecretHeaders {
+		if redacted.Get(header) != "" {
+			redacted.Set(header, redactedValue)
+		}
+	}
+	return redacted
+}
+
+// logPcsExchange logs the url and headers of a PCS request and its response, request body is not
+// logged as it may carry a platform manifest
+func logPcsExchange(req *http.Request, resp *http.Response, err error) {
+	entry := log.WithField("method", req.Method).WithField("url", redactPcsURL(req.URL)).
+		WithField("request-headers", redactPcsHeaders(req.Header))
+	if err != nil {
+		entry.WithError(err).Info("PCS request failed")
+		return
+	}
+	entry.WithField("status", resp.StatusCode).WithField("response-headers", redactPcsHeaders(resp.Header)).
+		Info("PCS request completed")
+}
+
+// index of the subscription key to be used for the next PCS request
+var subscriptionKeyIndex uint32
+
+// getRespFromProvServerWithSubscriptionKey sends a PCS request which needs an api subscription key.
+// Configured keys are used in round robin order, and the request fails over to the next key when
+// PCS rate limits the key in use
+func getRespFromProvServerWithSubscriptionKey(req *http.Request, client domain.HttpClient, conf *config.Configuration) (*http.Response, error) {
+	if conf == nil {
+		return nil, errors.New("getRespFromProvServerWithSubscriptionKey(): Configuration not provided")
+	}
+
+	keys := conf.SubscriptionKeys()
+	start := int(atomic.AddUint32(&subscriptionKeyIndex, 1) - 1)
+
+	var resp *http.Response
+	var err error
+	for i := 0; i < len(keys); i++ {
+		keyIndex := (start + i) % len(keys)
+		req.Header.Set("Ocp-Apim-Subscription-Key", keys[keyIndex])
+		if req.GetBody != nil {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return nil, errors.Wrap(err, "getRespFromProvServerWithSubscriptionKey(): Failed to reset request body")
+			}
+		}
+
+		resp, err = getRespFromProvServer(req, client, conf)
+		if err != nil || resp == nil || resp.StatusCode != http.StatusTooManyRequests || i == len(keys)-1 {
+			return resp, err
+		}
+
+		log.Warnf("getRespFromProvServerWithSubscriptionKey: PCS rate limited subscription key %d, failing over to next key", keyIndex)
+		if resp.Body != nil {
+			derr := resp.Body.Close()
+			if derr != nil {
+				log.WithError(derr).Error("Error closing rate limited response body")
+			}
 		}
 	}
 	return resp, err
 }
 
-func getPckCertFromProvServer(encryptedPPID, pceID string, conf *config.Configuration, client *domain.HttpClient) (*http.Response, error) {
+func getPckCertFromProvServer(ctx context.Context, encryptedPPID, pceID string, conf *config.Configuration, client *domain.HttpClient) (*http.Response, error) {
 	log.Trace("resource/sgx_prov_client_ops: getPckCertFromProvServer() Entering")
 	defer log.Trace("resource/sgx_prov_client_ops: getPckCertFromProvServer() Leaving")
 
@@ -66,21 +264,20 @@ func getPckCertFromProvServer(encryptedPPID, pceID string, conf *config.Configur
 		return nil, errors.New("getPckCertFromProvServer(): Empty client provided")
 	}
 
-	url := fmt.Sprintf("%s/pckcerts", conf.ProvServerInfo.ProvServerURL)
+	url := conf.ProvServerAPIURL("pckcerts")
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "getPckCertFromProvServer: Getpckcerts http request Failed")
 	}
 
-	req.Header.Add("Ocp-Apim-Subscription-Key", conf.ProvServerInfo.APISubscriptionkey)
 	q := req.URL.Query()
 	q.Add("encrypted_ppid", encryptedPPID)
 	q.Add("pceid", pceID)
 
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := getRespFromProvServer(req, *client, conf)
+	resp, err := getRespFromProvServerWithSubscriptionKey(req, *client, conf)
 
 	if err != nil {
 		return nil, errors.Wrap(err, "getPckCertFromProvServer: Getpckcerts call to PCS Server Failed")
@@ -88,7 +285,7 @@ func getPckCertFromProvServer(encryptedPPID, pceID string, conf *config.Configur
 	return resp, nil
 }
 
-func getPckCertsWithManifestFromProvServer(manifest, pceID string, conf *config.Configuration, client *domain.HttpClient) (*http.Response, error) {
+func getPckCertsWithManifestFromProvServer(ctx context.Context, manifest, pceID string, conf *config.Configuration, client *domain.HttpClient) (*http.Response, error) {
 	log.Trace("resource/sgx_prov_client_ops: getPckCertsWithManifestFromProvServer() Entering")
 	defer log.Trace("resource/sgx_prov_client_ops: getPckCertsWithManifestFromProvServer() Leaving")
 
@@ -100,7 +297,7 @@ func getPckCertsWithManifestFromProvServer(manifest, pceID string, conf *config.
 		return nil, errors.New("getPckCertsWithManifestFromProvServer(): Empty client provided")
 	}
 
-	url := fmt.Sprintf("%s/pckcerts", conf.ProvServerInfo.ProvServerURL)
+	url := conf.ProvServerAPIURL("pckcerts")
 
 	requestStr := map[string]string{
 		"platformManifest": manifest,
@@ -111,15 +308,14 @@ func getPckCertsWithManifestFromProvServer(manifest, pceID string, conf *config.
 		return nil, errors.Wrap(err, "getPckCertsWithManifestFromProvServer: Marshal error:"+err.Error())
 	}
 
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(reqBytes))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return nil, errors.Wrap(err, "getPckCertsWithManifestFromProvServer: Getpckcerts http request Failed")
 	}
 
-	req.Header.Add("Ocp-Apim-Subscription-Key", conf.ProvServerInfo.APISubscriptionkey)
 	req.Header.Add("Content-Type", "application/json")
 
-	resp, err := getRespFromProvServer(req, *client, conf)
+	resp, err := getRespFromProvServerWithSubscriptionKey(req, *client, conf)
 	if err != nil {
 		log.Error("error came: ", err)
 		return nil, errors.Wrap(err, "getPckCertsWithManifestFromProvServer: Getpckcerts call to PCS Server Failed")
@@ -127,7 +323,7 @@ func getPckCertsWithManifestFromProvServer(manifest, pceID string, conf *config.
 	return resp, nil
 }
 
-func getPckCrlFromProvServer(ca, encoding string, conf *config.Configuration, client *domain.HttpClient) (*http.Response, error) {
+func getPckCrlFromProvServer(ctx context.Context, ca, encoding string, conf *config.Configuration, client *domain.HttpClient) (*http.Response, error) {
 	log.Trace("resource/sgx_prov_client_ops: getPckCrlFromProvServer() Entering")
 	defer log.Trace("resource/sgx_prov_client_ops: getPckCrlFromProvServer() Leaving")
 
@@ -139,8 +335,8 @@ func getPckCrlFromProvServer(ca, encoding string, conf *config.Configuration, cl
 		return nil, errors.New("getPckCrlFromProvServer(): Empty client provided")
 	}
 
-	url := fmt.Sprintf("%s/pckcrl", conf.ProvServerInfo.ProvServerURL)
-	req, err := http.NewRequest("GET", url, nil)
+	url := conf.ProvServerAPIURL("pckcrl")
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "getPckCrlFromProvServer(): GetpckCrl http request Failed")
 	}
@@ -159,7 +355,33 @@ func getPckCrlFromProvServer(ca, encoding string, conf *config.Configuration, cl
 	return resp, nil
 }
 
-func getFmspcTcbInfoFromProvServer(fmspc string, conf *config.Configuration, client *domain.HttpClient) (*http.Response, error) {
+func getRootCaCrlFromProvServer(ctx context.Context, conf *config.Configuration, client *domain.HttpClient) (*http.Response, error) {
+	log.Trace("resource/sgx_prov_client_ops: getRootCaCrlFromProvServer() Entering")
+	defer log.Trace("resource/sgx_prov_client_ops: getRootCaCrlFromProvServer() Leaving")
+
+	if conf == nil {
+		return nil, errors.New("getRootCaCrlFromProvServer(): Configuration not provided")
+	}
+
+	if client == nil {
+		return nil, errors.New("getRootCaCrlFromProvServer(): Empty client provided")
+	}
+
+	url := conf.ProvServerAPIURL("rootcacrl")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "getRootCaCrlFromProvServer(): GetRootCaCrl http request Failed")
+	}
+
+	resp, err := getRespFromProvServer(req, *client, conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "getRootCaCrlFromProvServer(): GetRootCaCrl call to PCS Server Failed")
+	}
+	return resp, nil
+}
+
+// getFmspcTcbInfoFromProvServer fetches the TcbInfo of fmspc of the update type, standard or early
+func getFmspcTcbInfoFromProvServer(ctx context.Context, fmspc, update string, conf *config.Configuration, client *domain.HttpClient) (*http.Response, error) {
 	log.Trace("resource/sgx_prov_client_ops: getFmspcTcbInfoFromProvServer() Entering")
 	defer log.Trace("resource/sgx_prov_client_ops: getFmspcTcbInfoFromProvServer() Leaving")
 
@@ -171,14 +393,15 @@ func getFmspcTcbInfoFromProvServer(fmspc string, conf *config.Configuration, cli
 		return nil, errors.New("getFmspcTcbInfoFromProvServer(): Empty client provided")
 	}
 
-	url := fmt.Sprintf("%s/tcb", conf.ProvServerInfo.ProvServerURL)
-	req, err := http.NewRequest("GET", url, nil)
+	url := conf.ProvServerAPIURL("tcb")
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "getFmspcTcbInfoFromProvServer(): GetTcb http request Failed")
 	}
 
 	q := req.URL.Query()
 	q.Add("fmspc", fmspc)
+	q.Add("update", update)
 
 	req.URL.RawQuery = q.Encode()
 
@@ -190,7 +413,8 @@ func getFmspcTcbInfoFromProvServer(fmspc string, conf *config.Configuration, cli
 	return resp, nil
 }
 
-func getQeInfoFromProvServer(conf *config.Configuration, client *domain.HttpClient) (*http.Response, error) {
+// getQeInfoFromProvServer fetches the QE identity of the update type, standard or early
+func getQeInfoFromProvServer(ctx context.Context, update string, conf *config.Configuration, client *domain.HttpClient) (*http.Response, error) {
 	log.Trace("resource/sgx_prov_client_ops: getQeInfoFromProvServer() Entering")
 	defer log.Trace("resource/sgx_prov_client_ops: getQeInfoFromProvServer() Leaving")
 
@@ -202,12 +426,16 @@ func getQeInfoFromProvServer(conf *config.Configuration, client *domain.HttpClie
 		return nil, errors.New("getQeInfoFromProvServer(): Empty client provided")
 	}
 
-	url := fmt.Sprintf("%s/qe/identity", conf.ProvServerInfo.ProvServerURL)
-	req, err := http.NewRequest("GET", url, nil)
+	url := conf.ProvServerAPIURL("qe/identity")
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "getQeInfoFromProvServer(): getQeIdentity http request Failed")
 	}
 
+	q := req.URL.Query()
+	q.Add("update", update)
+	req.URL.RawQuery = q.Encode()
+
 	resp, err := getRespFromProvServer(req, *client, conf)
 
 	if err != nil {
@@ -215,3 +443,44 @@ func getQeInfoFromProvServer(conf *config.Configuration, client *domain.HttpClie
 	}
 	return resp, nil
 }
+
+// maxPcsErrorBodyLen limits the part of a failed PCS response body kept in PcsError
+const maxPcsErrorBodyLen = 256
+
+// PcsError is returned when Intel PCS server responds to an api call with a status other than OK
+type PcsError struct {
+	Endpoint   string
+	StatusCode int
+	Body       string
+}
+
+func (e *PcsError) Error() string {
+	return fmt.Sprintf("get %s api call failed with pcs, status code: %d, body: %s", e.Endpoint, e.StatusCode, e.Body)
+}
+
+// PcsReadError is returned when the body of a response of Intel PCS server with an OK status could
+// not be read in full. Unlike a PcsError it is a transient failure, the request is sent again
+type PcsReadError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *PcsReadError) Error() string {
+	return fmt.Sprintf("reading %s response body from pcs failed: %s", e.Endpoint, e.Err.Error())
+}
+
+func (e *PcsReadError) Unwrap() error {
+	return e.Err
+}
+
+func newPcsError(endpoint string, resp *http.Response) *PcsError {
+	pcsErr := &PcsError{Endpoint: endpoint, StatusCode: resp.StatusCode}
+	if resp.Body != nil {
+		body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxPcsErrorBodyLen))
+		if err != nil {
+			log.WithError(err).Warnf("could not read %s error response body", endpoint)
+		}
+		pcsErr.Body = string(body)
+	}
+	return pcsErr
+}
@@ -5,11 +5,25 @@
 package resource
 
 import (
+	"context"
 	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/domain"
 	"intel/isecl/scs/v5/domain/mocks"
+	"intel/isecl/scs/v5/types"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -42,17 +56,17 @@ func TestGetPckCertFromProvServer(t *testing.T) {
 	conf := config.Load(testConfigFilePath)
 	client := mocks.NewClientMock(http.StatusOK)
 
-	_, err := getPckCertFromProvServer("", "", nil, nil)
+	_, err := getPckCertFromProvServer(context.Background(), "", "", nil, nil)
 	assert.NotNil(t, err)
 
-	_, err = getPckCertFromProvServer("", "", conf, nil)
+	_, err = getPckCertFromProvServer(context.Background(), "", "", conf, nil)
 	assert.NotNil(t, err)
 
-	_, err = getPckCertFromProvServer("", "", conf, &client)
+	_, err = getPckCertFromProvServer(context.Background(), "", "", conf, &client)
 	assert.Nil(t, err)
 
 	client = mocks.NewClientMock(401)
-	_, err = getPckCertFromProvServer("", "", conf, &client)
+	_, err = getPckCertFromProvServer(context.Background(), "", "", conf, &client)
 	assert.NotNil(t, err)
 }
 
@@ -62,33 +76,441 @@ func TestNegativeCases(t *testing.T) {
 
 	// getPckCertsWithManifestFromProvServer
 
-	_, err := getPckCertsWithManifestFromProvServer("", "", nil, nil)
+	_, err := getPckCertsWithManifestFromProvServer(context.Background(), "", "", nil, nil)
 	assert.NotNil(t, err)
 
-	_, err = getPckCertsWithManifestFromProvServer("", "", conf, nil)
+	_, err = getPckCertsWithManifestFromProvServer(context.Background(), "", "", conf, nil)
 	assert.NotNil(t, err)
 
-	_, err = getPckCertsWithManifestFromProvServer("", "", conf, &client)
+	_, err = getPckCertsWithManifestFromProvServer(context.Background(), "", "", conf, &client)
 	assert.Nil(t, err)
 
 	client = mocks.NewClientMock(401)
-	_, err = getPckCertsWithManifestFromProvServer("", "", conf, &client)
+	_, err = getPckCertsWithManifestFromProvServer(context.Background(), "", "", conf, &client)
 	assert.NotNil(t, err)
 
 	// getPckCrlFromProvServer
-	_, err = getPckCrlFromProvServer("", "", conf, nil)
+	_, err = getPckCrlFromProvServer(context.Background(), "", "", conf, nil)
 	assert.NotNil(t, err)
-	_, err = getPckCrlFromProvServer("", "", conf, &client)
+	_, err = getPckCrlFromProvServer(context.Background(), "", "", conf, &client)
 	assert.NotNil(t, err)
 
 	// getFmspcTcbInfoFromProvServer
-	_, err = getFmspcTcbInfoFromProvServer("", conf, &client)
+	_, err = getFmspcTcbInfoFromProvServer(context.Background(), "", constants.StandardTcbUpdate, conf, &client)
 	assert.NotNil(t, err)
 
 	// getQeInfoFromProvServer
-	_, err = getQeInfoFromProvServer(conf, nil)
+	_, err = getQeInfoFromProvServer(context.Background(), constants.StandardTcbUpdate, conf, nil)
 	assert.NotNil(t, err)
 
-	_, err = getQeInfoFromProvServer(conf, &client)
+	_, err = getQeInfoFromProvServer(context.Background(), constants.StandardTcbUpdate, conf, &client)
 	assert.NotNil(t, err)
 }
+
+// updateClientMock records the update type requested from PCS
+type updateClientMock struct {
+	updates []string
+}
+
+func (c *updateClientMock) Do(req *http.Request) (*http.Response, error) {
+	c.updates = append(c.updates, req.URL.Query().Get("update"))
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader("{}"))}, nil
+}
+
+func TestFetchTcbUpdate(t *testing.T) {
+	conf := config.Load(testConfigFilePath)
+	recorder := &updateClientMock{}
+	var client domain.HttpClient = recorder
+
+	tcbInfo, err := fetchFmspcTcbInfo(context.Background(), "20606a000000", conf, &client)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.StandardTcbUpdate, tcbInfo.TcbUpdate)
+
+	conf.ProvServerInfo.TcbUpdate = constants.EarlyTcbUpdate
+	tcbInfo, err = fetchFmspcTcbInfo(context.Background(), "20606a000000", conf, &client)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.EarlyTcbUpdate, tcbInfo.TcbUpdate)
+	qeIdentity, err := fetchQeIdentityInfo(context.Background(), conf, &client)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.EarlyTcbUpdate, qeIdentity.TcbUpdate)
+
+	assert.Equal(t, []string{constants.StandardTcbUpdate, constants.EarlyTcbUpdate, constants.EarlyTcbUpdate}, recorder.updates)
+}
+
+// subscriptionKeyClientMock records the subscription keys used and rate limits the given key
+type subscriptionKeyClientMock struct {
+	rateLimitedKey string
+	usedKeys       []string
+}
+
+func (c *subscriptionKeyClientMock) Do(req *http.Request) (*http.Response, error) {
+	key := req.Header.Get("Ocp-Apim-Subscription-Key")
+	c.usedKeys = append(c.usedKeys, key)
+	if key == c.rateLimitedKey {
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Body: http.NoBody}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+}
+
+func TestGetRespFromProvServerWithSubscriptionKey(t *testing.T) {
+	conf := config.Load(testConfigFilePath)
+	conf.ProvServerInfo.APISubscriptionkeys = []string{"key1", "key2", "key3"}
+	subscriptionKeyIndex = 0
+
+	// keys are used in round robin order
+	client := &subscriptionKeyClientMock{}
+	for i := 0; i < 4; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "/pckcerts", nil)
+		resp, err := getRespFromProvServerWithSubscriptionKey(req, client, conf)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.Equal(t, []string{"key1", "key2", "key3", "key1"}, client.usedKeys)
+
+	// rate limited key fails over to the next key, request body is sent again
+	client = &subscriptionKeyClientMock{rateLimitedKey: "key2"}
+	req, _ := http.NewRequest(http.MethodPost, "/pckcerts", strings.NewReader(`{"pceid":"0000"}`))
+	resp, err := getRespFromProvServerWithSubscriptionKey(req, client, conf)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"key2", "key3"}, client.usedKeys)
+	body, _ := ioutil.ReadAll(req.Body)
+	assert.Equal(t, `{"pceid":"0000"}`, string(body))
+
+	// all keys rate limited
+	conf.ProvServerInfo.APISubscriptionkeys = []string{"key2"}
+	client = &subscriptionKeyClientMock{rateLimitedKey: "key2"}
+	req, _ = http.NewRequest(http.MethodGet, "/pckcerts", nil)
+	resp, err = getRespFromProvServerWithSubscriptionKey(req, client, conf)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	// single key configuration
+	conf.ProvServerInfo.APISubscriptionkeys = nil
+	conf.ProvServerInfo.APISubscriptionkey = "singlekey"
+	client = &subscriptionKeyClientMock{}
+	req, _ = http.NewRequest(http.MethodGet, "/pckcerts", nil)
+	_, err = getRespFromProvServerWithSubscriptionKey(req, client, conf)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"singlekey"}, client.usedKeys)
+
+	_, err = getRespFromProvServerWithSubscriptionKey(req, client, nil)
+	assert.Error(t, err)
+}
+
+func TestPcsDebugLog(t *testing.T) {
+	conf := config.Load(testConfigFilePath)
+	conf.ProvServerInfo.APISubscriptionkeys = []string{"secretkey"}
+	client := &subscriptionKeyClientMock{}
+	hook := logtest.NewLocal(log.Logger)
+	defer hook.Reset()
+
+	encPpid := strings.Repeat("ab", 384)
+	req, _ := http.NewRequest(http.MethodGet, "/pckcerts?encrypted_ppid="+encPpid+"&pceid=0000", nil)
+
+	// nothing is logged by default
+	_, err := getRespFromProvServerWithSubscriptionKey(req, client, conf)
+	assert.NoError(t, err)
+	for _, entry := range hook.AllEntries() {
+		assert.NotEqual(t, "PCS request completed", entry.Message)
+	}
+
+	conf.PcsDebugLogEnabled = true
+	hook.Reset()
+	_, err = getRespFromProvServerWithSubscriptionKey(req, client, conf)
+	assert.NoError(t, err)
+
+	entry := hook.LastEntry()
+	assert.Equal(t, "PCS request completed", entry.Message)
+	assert.Equal(t, http.StatusOK, entry.Data["status"])
+	assert.Equal(t, "/pckcerts?encrypted_ppid=REDACTED&pceid=0000", entry.Data["url"])
+	assert.Equal(t, "REDACTED", entry.Data["request-headers"].(http.Header).Get("Ocp-Apim-Subscription-Key"))
+	dump, _ := entry.String()
+	assert.NotContains(t, dump, "secretkey")
+	assert.NotContains(t, dump, encPpid)
+
+	// request itself is not modified
+	assert.Equal(t, "secretkey", req.Header.Get("Ocp-Apim-Subscription-Key"))
+	assert.Equal(t, encPpid, req.URL.Query().Get("encrypted_ppid"))
+}
+
+// statusClientMock responds to all PCS requests with the given status and body
+type statusClientMock struct {
+	statusCode int
+	body       string
+}
+
+func (c *statusClientMock) Do(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: c.statusCode, Body: ioutil.NopCloser(strings.NewReader(c.body))}, nil
+}
+
+func TestFetchReturnsPcsError(t *testing.T) {
+	conf := config.Load(testConfigFilePath)
+	var client domain.HttpClient = &statusClientMock{statusCode: http.StatusNotFound, body: strings.Repeat("x", 2*maxPcsErrorBodyLen)}
+
+	_, _, _, _, err := fetchPckCertInfo(context.Background(), &types.Platform{Encppid: "encppid", PceID: "0000"}, conf, &client)
+	assertPcsError(t, err, "pckcerts", http.StatusNotFound)
+	_, err = fetchPckCrlInfo(context.Background(), "processor", conf, &client)
+	assertPcsError(t, err, "pckcrl", http.StatusNotFound)
+	_, err = fetchFmspcTcbInfo(context.Background(), "20606a000000", conf, &client)
+	assertPcsError(t, err, "tcb info", http.StatusNotFound)
+	_, err = fetchQeIdentityInfo(context.Background(), conf, &client)
+	assertPcsError(t, err, "qe identity", http.StatusNotFound)
+
+	// PcsError is preserved through lazy caching
+	_, err = getLazyCacheFmspcTcbInfo(context.Background(), getMockDatabase(), "20606a000000", constants.CacheInsert, conf, &client)
+	assertPcsError(t, err, "tcb info", http.StatusNotFound)
+}
+
+func assertPcsError(t *testing.T, err error, endpoint string, statusCode int) {
+	var pcsErr *PcsError
+	if assert.True(t, errors.As(err, &pcsErr)) {
+		assert.Equal(t, endpoint, pcsErr.Endpoint)
+		assert.Equal(t, statusCode, pcsErr.StatusCode)
+		assert.Len(t, pcsErr.Body, maxPcsErrorBodyLen)
+	}
+}
+
+func TestPcsErrorStatusCode(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, pcsErrorStatusCode(errors.New("db error"), http.StatusNotFound))
+	assert.Equal(t, http.StatusNotFound, pcsErrorStatusCode(&PcsError{StatusCode: http.StatusNotFound}, http.StatusInternalServerError))
+	assert.Equal(t, http.StatusBadRequest, pcsErrorStatusCode(&PcsError{StatusCode: http.StatusBadRequest}, http.StatusInternalServerError))
+	assert.Equal(t, http.StatusServiceUnavailable, pcsErrorStatusCode(errors.Wrap(&PcsError{StatusCode: http.StatusTooManyRequests}, "fetch"), http.StatusNotFound))
+	assert.Equal(t, http.StatusBadGateway, pcsErrorStatusCode(&PcsError{StatusCode: http.StatusUnauthorized}, http.StatusNotFound))
+	assert.Equal(t, http.StatusBadGateway, pcsErrorStatusCode(errors.Wrap(&PcsReadError{Endpoint: "/pckcerts",
+		Err: errors.New("unexpected EOF")}, "fetch"), http.StatusNotFound))
+}
+
+// cutShortPcsServer responds to the first cutShort requests with a body which ends as the connection
+// drops midway, and to the following requests with the full body
+func cutShortPcsServer(cutShort int32, status int, body string) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= cutShort {
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body[:len(body)/2]))
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	return server, &requests
+}
+
+func TestGetRespFromProvServerBodyCutShort(t *testing.T) {
+	conf := config.Load(testConfigFilePath)
+	conf.RetryCount = 3
+	conf.WaitTime = 0
+	body := strings.Repeat(`{"tcbm":"020200000000000000000000000000000a00"}`, 100)
+
+	// a body cut short after an OK status is retried
+	server, requests := cutShortPcsServer(1, http.StatusOK, body)
+	defer server.Close()
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/pckcerts", nil)
+	resp, err := getRespFromProvServer(req, server.Client(), conf)
+	if assert.NoError(t, err) {
+		received, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, body, string(received))
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(requests))
+
+	// the request fails as transient once every attempt is cut short
+	server, requests = cutShortPcsServer(3, http.StatusOK, body)
+	defer server.Close()
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/pckcerts", nil)
+	_, err = getRespFromProvServer(req, server.Client(), conf)
+	var readErr *PcsReadError
+	if assert.True(t, errors.As(err, &readErr)) {
+		assert.Equal(t, "/pckcerts", readErr.Endpoint)
+	}
+	assert.True(t, isTransientPcsError(err))
+	assert.Equal(t, http.StatusBadGateway, pcsErrorStatusCode(err, http.StatusInternalServerError))
+	assert.Equal(t, int32(3), atomic.LoadInt32(requests))
+
+	// a status failure is returned to the caller without retrying, whatever happens to its body
+	server, requests = cutShortPcsServer(1, http.StatusBadRequest, body)
+	defer server.Close()
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/pckcerts", nil)
+	resp, err = getRespFromProvServer(req, server.Client(), conf)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+	assert.False(t, isTransientPcsError(&PcsError{StatusCode: http.StatusBadRequest}))
+	assert.True(t, isTransientPcsError(&PcsError{StatusCode: http.StatusBadGateway}))
+}
+
+// failingPcsServer responds to the first failures requests with status and to the following
+// requests with an OK status and body
+func failingPcsServer(failures int32, status int, body string) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= failures {
+			http.Error(w, "pcs unavailable", status)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	return server, &requests
+}
+
+func TestGetRespFromProvServerServerError(t *testing.T) {
+	conf := config.Load(testConfigFilePath)
+	conf.RetryCount = 3
+	conf.WaitTime = 0
+	body := `{"tcbm":"020200000000000000000000000000000a00"}`
+
+	// server errors of PCS are retried
+	server, requests := failingPcsServer(1, http.StatusServiceUnavailable, body)
+	defer server.Close()
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/pckcerts", nil)
+	resp, err := getRespFromProvServer(req, server.Client(), conf)
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		received, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, body, string(received))
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(requests))
+
+	// the server error of the last attempt is returned once every attempt fails
+	server, requests = failingPcsServer(3, http.StatusInternalServerError, body)
+	defer server.Close()
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/pckcerts", nil)
+	_, err = getRespFromProvServer(req, server.Client(), conf)
+	var pcsErr *PcsError
+	if assert.True(t, errors.As(err, &pcsErr)) {
+		assert.Equal(t, "/pckcerts", pcsErr.Endpoint)
+		assert.Equal(t, http.StatusInternalServerError, pcsErr.StatusCode)
+		assert.Contains(t, pcsErr.Body, "pcs unavailable")
+	}
+	assert.True(t, isTransientPcsError(err))
+	assert.Equal(t, http.StatusBadGateway, pcsErrorStatusCode(err, http.StatusNotFound))
+	assert.Equal(t, int32(3), atomic.LoadInt32(requests))
+
+	// other failure statuses are returned to the caller without retrying
+	server, requests = failingPcsServer(1, http.StatusNotFound, body)
+	defer server.Close()
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/pckcerts", nil)
+	resp, err = getRespFromProvServer(req, server.Client(), conf)
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+}
+
+func TestGetTcbInfoPcsErrorStatus(t *testing.T) {
+	conf := config.Load(testConfigFilePath)
+	var client domain.HttpClient = &statusClientMock{statusCode: http.StatusTooManyRequests}
+	router := mux.NewRouter()
+	QuoteProviderOps(router, getMockDatabase(), conf, &client)
+
+	req, _ := http.NewRequest(http.MethodGet, "/tcb?fmspc=20606a000000", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+// concurrencyClientMock records the highest number of PCS requests in flight at once
+type concurrencyClientMock struct {
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	requests    int
+}
+
+func (c *concurrencyClientMock) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.inFlight++
+	c.requests++
+	if c.inFlight > c.maxInFlight {
+		c.maxInFlight = c.inFlight
+	}
+	c.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+}
+
+// useLimitPcsRequests limits the PCS requests of resource package until the test completes
+func useLimitPcsRequests(t *testing.T, limit int) {
+	LimitPcsRequests(limit)
+	t.Cleanup(func() {
+		pcsRequestSlots = nil
+	})
+}
+
+func TestLimitPcsRequests(t *testing.T) {
+	useLimitPcsRequests(t, 3)
+	conf := config.Load(testConfigFilePath)
+	recorder := &concurrencyClientMock{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+			_, err := getRespFromProvServer(req, recorder, conf)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 20, recorder.requests)
+	assert.LessOrEqual(t, recorder.maxInFlight, 3)
+
+	LimitPcsRequests(0)
+	assert.Equal(t, constants.DefaultPcsRequestConcurrency, cap(pcsRequestSlots))
+}
+
+func TestLimitPcsRequestsCancelled(t *testing.T) {
+	useLimitPcsRequests(t, 1)
+	conf := config.Load(testConfigFilePath)
+	recorder := &concurrencyClientMock{}
+
+	// the only slot is held, so the request waits until its context is done
+	pcsRequestSlots <- struct{}{}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/test", nil)
+	_, err := getRespFromProvServer(req, recorder, conf)
+	assert.Error(t, err)
+	assert.Equal(t, 0, recorder.requests)
+}
+
+// blockingClientMock fails every request once its context is done, the way a PCS call which is
+// slower than the client of the request fails
+type blockingClientMock struct {
+	requests int32
+}
+
+func (c *blockingClientMock) Do(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.requests, 1)
+	<-req.Context().Done()
+	return nil, req.Context().Err()
+}
+
+func TestPcsRequestCancelledByClientDeadline(t *testing.T) {
+	conf := config.Load(testConfigFilePath)
+	conf.RetryCount = 3
+	conf.WaitTime = 5
+	var client domain.HttpClient = &blockingClientMock{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, _, _, _, err := fetchPckCertInfo(ctx, &types.Platform{Encppid: "encppid", PceID: "0000"}, conf, &client)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	// the PCS call is abandoned with the client, without waiting to retry it
+	assert.True(t, time.Since(start) < time.Duration(conf.WaitTime)*time.Second)
+	assert.Equal(t, int32(1), client.(*blockingClientMock).requests)
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"encoding/json"
+	"fmt"
+	commLogMsg "intel/isecl/lib/common/v5/log/message"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/domain"
+	"intel/isecl/scs/v5/repository"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+)
+
+// SubscriptionKeyUpdateRequest replaces the Intel PCS api subscription keys, which are written to
+// the configuration file as well when Persist is set
+type SubscriptionKeyUpdateRequest struct {
+	SubscriptionKeys []string `json:"subscription_keys"`
+	Persist          bool     `json:"persist"`
+}
+
+func SubscriptionKeyOps(r *mux.Router, db repository.SCSDatabase, conf *config.Configuration, client *domain.HttpClient) {
+	r.Handle("/subscription-keys", handlers.ContentTypeHandler(updateSubscriptionKeys(conf), "application/json")).Methods("PUT")
+}
+
+// updateSubscriptionKeys rotates the Intel PCS api subscription keys without restarting SCS. The
+// keys are never logged
+func updateSubscriptionKeys(conf *config.Configuration) errorHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		err := authorizeEndpoint(r, constants.CacheManagerGroupName, true)
+		if err != nil {
+			return err
+		}
+
+		if conf == nil {
+			return &resourceError{Message: "configuration not provided", StatusCode: http.StatusInternalServerError}
+		}
+
+		if r.ContentLength == 0 {
+			slog.Error("resource/subscription_key_ops: updateSubscriptionKeys() The request body was not provided")
+			return &resourceError{Message: "subscription keys not provided",
+				StatusCode: http.StatusBadRequest}
+		}
+
+		var updateReq SubscriptionKeyUpdateRequest
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&updateReq)
+		if err != nil {
+			// decode errors are not returned as is, they can quote the keys
+			slog.Errorf("resource/subscription_key_ops: updateSubscriptionKeys() %s :  Failed to decode request body", commLogMsg.InvalidInputBadEncoding)
+			return &resourceError{Message: "invalid subscription key update request", StatusCode: http.StatusBadRequest}
+		}
+
+		if len(updateReq.SubscriptionKeys) == 0 || len(updateReq.SubscriptionKeys) > constants.MaxSubscriptionKeys {
+			return &resourceError{Message: fmt.Sprintf("between 1 and %d subscription keys should be provided", constants.MaxSubscriptionKeys),
+				StatusCode: http.StatusBadRequest}
+		}
+		// keys loaded from a secret source are updated at the source, they are not written to the
+		// configuration file
+		if updateReq.Persist && conf.ProvServerInfo.APISubscriptionkeySource != "" {
+			return &resourceError{Message: "subscription keys are loaded from a secret source and cannot be persisted",
+				StatusCode: http.StatusConflict}
+		}
+		keys := make([]string, 0, len(updateReq.SubscriptionKeys))
+		for _, key := range updateReq.SubscriptionKeys {
+			key = strings.TrimSpace(key)
+			if key == "" || strings.Contains(key, ",") {
+				slog.Error("resource/subscription_key_ops: updateSubscriptionKeys() Input validation failed")
+				return &resourceError{Message: "invalid subscription key provided", StatusCode: http.StatusBadRequest}
+			}
+			keys = append(keys, key)
+		}
+
+		conf.SetSubscriptionKeys(keys)
+		log.Infof("Intel PCS api subscription keys updated, %d keys configured", len(keys))
+
+		if updateReq.Persist {
+			if err := conf.Save(); err != nil {
+				log.WithError(err).Error("Could not persist subscription keys to configuration file")
+				return &resourceError{Message: "subscription keys updated but could not be persisted",
+					StatusCode: http.StatusInternalServerError}
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		slog.Infof("%s: Subscription keys updated by: %s", commLogMsg.AuthorizedAccess, r.RemoteAddr)
+		return nil
+	}
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"intel/isecl/lib/common/v5/context"
+	"intel/isecl/lib/common/v5/types/aas"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func updateSubscriptionKeysResponse(conf *config.Configuration, role, body string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	SubscriptionKeyOps(router, nil, conf, nil)
+
+	req := httptest.NewRequest(http.MethodPut, "/subscription-keys", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	permissions := aas.PermissionInfo{Service: constants.ServiceName, Rules: []string{role}}
+	req = context.SetUserPermissions(req, []aas.PermissionInfo{permissions})
+	roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: role, Context: "type=SCS"}}
+	req = context.SetUserRoles(req, roleInfo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestUpdateSubscriptionKeys(t *testing.T) {
+	conf := config.Load(testConfigFilePath)
+	conf.ProvServerInfo.APISubscriptionkeys = []string{"oldkey"}
+	subscriptionKeyIndex = 0
+
+	assert.Equal(t, http.StatusForbidden, updateSubscriptionKeysResponse(conf, constants.HostDataReaderGroupName,
+		`{"subscription_keys": ["newkey1"]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, updateSubscriptionKeysResponse(conf, constants.CacheManagerGroupName, "").Code)
+	assert.Equal(t, http.StatusBadRequest, updateSubscriptionKeysResponse(conf, constants.CacheManagerGroupName,
+		`{"subscription_keys": []}`).Code)
+	assert.Equal(t, http.StatusBadRequest, updateSubscriptionKeysResponse(conf, constants.CacheManagerGroupName,
+		`{"subscription_keys": ["newkey1", " "]}`).Code)
+	assert.Equal(t, []string{"oldkey"}, conf.SubscriptionKeys())
+
+	// the key is not echoed back when the request cannot be decoded
+	w := updateSubscriptionKeysResponse(conf, constants.CacheManagerGroupName, `{"subscription_keys": "secretkey"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.NotContains(t, w.Body.String(), "secretkey")
+
+	w = updateSubscriptionKeysResponse(conf, constants.CacheManagerGroupName, `{"subscription_keys": ["newkey1", "newkey2"]}`)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// subsequent PCS requests use the new keys
+	client := &subscriptionKeyClientMock{}
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "/pckcerts", nil)
+		_, err := getRespFromProvServerWithSubscriptionKey(req, client, conf)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []string{"newkey1", "newkey2"}, client.usedKeys)
+}
+
+func TestUpdateSubscriptionKeysPersisted(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yml")
+	conf := config.Load(configFile)
+	conf.SetSubscriptionKeys([]string{"oldkey1", "oldkey2", "oldkey3"})
+	assert.NoError(t, conf.Save())
+
+	w := updateSubscriptionKeysResponse(conf, constants.CacheManagerGroupName, `{"subscription_keys": ["newkey"], "persist": true}`)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, []string{"newkey"}, config.Load(configFile).SubscriptionKeys())
+
+	// keys are only updated in memory unless persisted
+	w = updateSubscriptionKeysResponse(conf, constants.CacheManagerGroupName, `{"subscription_keys": ["otherkey"]}`)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, []string{"otherkey"}, conf.SubscriptionKeys())
+	assert.Equal(t, []string{"newkey"}, config.Load(configFile).SubscriptionKeys())
+}
+
+func TestUpdateSubscriptionKeysFromSource(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yml")
+	conf := config.Load(configFile)
+	conf.ProvServerInfo.APISubscriptionkeySource = constants.SecretEnvReference + "SCS_TEST_PCS_API_KEY"
+	conf.SetSubscriptionKeys([]string{"sourcekey"})
+
+	// keys loaded from a source are rotated in memory, but are not written to the configuration file
+	w := updateSubscriptionKeysResponse(conf, constants.CacheManagerGroupName, `{"subscription_keys": ["newkey"], "persist": true}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, []string{"sourcekey"}, conf.SubscriptionKeys())
+
+	w = updateSubscriptionKeysResponse(conf, constants.CacheManagerGroupName, `{"subscription_keys": ["newkey"]}`)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, []string{"newkey"}, conf.SubscriptionKeys())
+}
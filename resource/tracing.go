@@ -0,0 +1,362 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"intel/isecl/scs/v5/constants"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// span kinds and status codes of the OpenTelemetry protocol
+const (
+	spanKindInternal = 1
+	spanKindServer   = 2
+	spanKindClient   = 3
+
+	spanStatusError = 2
+)
+
+// spanContext identifies a span within a trace, it is carried in the context of a request so that
+// the spans started with the context are its children
+type spanContext struct {
+	traceID [16]byte
+	spanID  [8]byte
+}
+
+type spanContextKey struct{}
+
+// traceparent formats the span context as a W3C traceparent header, with the sampled flag set
+func (sc spanContext) traceparent() string {
+	return "00-" + hex.EncodeToString(sc.traceID[:]) + "-" + hex.EncodeToString(sc.spanID[:]) + "-01"
+}
+
+// parseTraceparent reads the span context of a W3C traceparent header, false is returned when the
+// header is missing or invalid so that a new trace is started
+func parseTraceparent(header string) (spanContext, bool) {
+	var sc spanContext
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.traceID[:], []byte(parts[1])); err != nil {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.spanID[:], []byte(parts[2])); err != nil {
+		return sc, false
+	}
+	if sc.traceID == ([16]byte{}) || sc.spanID == ([8]byte{}) {
+		return sc, false
+	}
+	return sc, true
+}
+
+// traceSpan is a timed operation of a trace. Spans are started only when tracing is enabled, the
+// methods of a nil span do nothing so that callers need not check whether tracing is enabled
+type traceSpan struct {
+	context    spanContext
+	parentID   [8]byte
+	name       string
+	kind       int
+	startTime  time.Time
+	attributes map[string]interface{}
+	errMessage string
+}
+
+// startSpan starts a span as child of the span of ctx, or as the root of a new trace when ctx has
+// no span. The returned context carries the new span
+func startSpan(ctx context.Context, name string) (context.Context, *traceSpan) {
+	return startSpanOfKind(ctx, name, spanKindInternal)
+}
+
+func startSpanOfKind(ctx context.Context, name string, kind int) (context.Context, *traceSpan) {
+	if exporter == nil {
+		return ctx, nil
+	}
+	span := &traceSpan{name: name, kind: kind, startTime: clock.Now(), attributes: map[string]interface{}{}}
+	if parent, ok := ctx.Value(spanContextKey{}).(spanContext); ok {
+		span.context.traceID = parent.traceID
+		span.parentID = parent.spanID
+	} else if _, err := rand.Read(span.context.traceID[:]); err != nil {
+		return ctx, nil
+	}
+	if _, err := rand.Read(span.context.spanID[:]); err != nil {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, spanContextKey{}, span.context), span
+}
+
+// setAttribute records a string or integer attribute of the span
+func (s *traceSpan) setAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.attributes[key] = value
+}
+
+// end ends the span, marking it as failed when err is not nil, and queues it for export
+func (s *traceSpan) end(err error) {
+	if s == nil {
+		return
+	}
+	if err != nil {
+		s.errMessage = err.Error()
+	}
+	if e := exporter; e != nil {
+		e.export(s.otlpSpan(clock.Now()))
+	}
+}
+
+// otlpSpan is a span in the OTLP/HTTP JSON encoding
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string            `json:"key"`
+	Value map[string]string `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+func (s *traceSpan) otlpSpan(endTime time.Time) otlpSpan {
+	span := otlpSpan{
+		TraceID:           hex.EncodeToString(s.context.traceID[:]),
+		SpanID:            hex.EncodeToString(s.context.spanID[:]),
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: strconv.FormatInt(s.startTime.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(endTime.UnixNano(), 10),
+	}
+	if s.parentID != ([8]byte{}) {
+		span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+	}
+	for key, value := range s.attributes {
+		if v, ok := value.(int); ok {
+			span.Attributes = append(span.Attributes, otlpAttribute{Key: key, Value: map[string]string{"intValue": strconv.Itoa(v)}})
+			continue
+		}
+		span.Attributes = append(span.Attributes, otlpAttribute{Key: key, Value: map[string]string{"stringValue": fmt.Sprint(value)}})
+	}
+	if s.errMessage != "" {
+		span.Status = &otlpStatus{Code: spanStatusError, Message: s.errMessage}
+	}
+	return span
+}
+
+// spanExporter posts the ended spans in batches to an OTLP/HTTP traces endpoint in background,
+// so that requests are never blocked on exporting their spans
+type spanExporter struct {
+	url    string
+	client *http.Client
+	spans  chan otlpSpan
+	// stop asks the exporter to post the queued spans and to exit, which closes stopped
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+var exporter *spanExporter
+
+// StartTracing starts exporting spans of pushes, refreshes and the other apis to the OTLP/HTTP
+// endpoint, such as http://otel-collector:4318, of an OpenTelemetry collector. Spans are not
+// started until tracing is started
+func StartTracing(endpoint string) {
+	e := &spanExporter{
+		url:     strings.TrimRight(endpoint, "/") + "/v1/traces",
+		client:  &http.Client{Timeout: constants.TraceExportTimeout},
+		spans:   make(chan otlpSpan, constants.TraceQueueSize),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go e.run()
+	exporter = e
+	log.Infof("Traces are exported to %s", e.url)
+}
+
+// StopTracing posts the spans queued for export, so that the spans of the last requests are not
+// lost when SCS is stopped. Spans ended afterwards are not exported
+func StopTracing(ctx context.Context) error {
+	e := exporter
+	if e == nil {
+		return nil
+	}
+	close(e.stop)
+	select {
+	case <-e.stopped:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "queued spans could not be exported")
+	}
+}
+
+func (e *spanExporter) run() {
+	ticker := time.NewTicker(constants.TraceExportInterval)
+	defer ticker.Stop()
+	defer close(e.stopped)
+
+	var batch []otlpSpan
+	for {
+		select {
+		case span := <-e.spans:
+			batch = append(batch, span)
+			if len(batch) < constants.TraceExportBatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		case <-e.stop:
+			e.flush(batch)
+			return
+		}
+		if err := e.post(batch); err != nil {
+			log.WithError(err).Warnf("could not export %d spans", len(batch))
+		}
+		batch = nil
+	}
+}
+
+// flush posts the batch being collected along with the spans still queued
+func (e *spanExporter) flush(batch []otlpSpan) {
+	for {
+		select {
+		case span := <-e.spans:
+			batch = append(batch, span)
+			if len(batch) < constants.TraceExportBatchSize {
+				continue
+			}
+		default:
+			if len(batch) == 0 {
+				return
+			}
+		}
+		if err := e.post(batch); err != nil {
+			log.WithError(err).Warnf("could not export %d spans", len(batch))
+		}
+		batch = nil
+	}
+}
+
+// export queues a span for export, the span is dropped when the queue is full
+func (e *spanExporter) export(span otlpSpan) {
+	select {
+	case e.spans <- span:
+	default:
+		log.Debugf("trace export queue is full, span %s is dropped", span.Name)
+	}
+}
+
+func (e *spanExporter) post(spans []otlpSpan) error {
+	request := map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": []otlpAttribute{{Key: "service.name", Value: map[string]string{"stringValue": constants.ServiceName}}},
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]string{"name": constants.ServiceName},
+				"spans": spans,
+			}},
+		}},
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal spans")
+	}
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "could not post spans to trace endpoint")
+	}
+	defer func() {
+		derr := resp.Body.Close()
+		if derr != nil {
+			log.WithError(derr).Error("Error closing response body")
+		}
+	}()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return errors.Errorf("trace endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// statusRecorder records the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush sends the buffered response to the client, which the gRPC api requires of the writer
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer, for wrappers looking for the interfaces which it implements
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// TracingMiddleware starts a server span for each request, continuing the trace of the W3C
+// traceparent header of the request when it has one. The span is named after the route template
+// rather than the path, so that qeids in paths do not make every span name unique
+func TracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if exporter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := r.Context()
+		if parent, ok := parseTraceparent(r.Header.Get("traceparent")); ok {
+			ctx = context.WithValue(ctx, spanContextKey{}, parent)
+		}
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		ctx, span := startSpanOfKind(ctx, r.Method+" "+route, spanKindServer)
+		span.setAttribute("http.method", r.Method)
+		span.setAttribute("http.route", route)
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.setAttribute("http.status_code", recorder.status)
+		var err error
+		if recorder.status >= http.StatusInternalServerError {
+			err = errors.New(http.StatusText(recorder.status))
+		}
+		span.end(err)
+	})
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// enableTestTracing installs an exporter whose spans are read from the returned channel
+// instead of being posted
+func enableTestTracing(t *testing.T) chan otlpSpan {
+	spans := make(chan otlpSpan, 16)
+	exporter = &spanExporter{spans: spans}
+	t.Cleanup(func() {
+		exporter = nil
+	})
+	return spans
+}
+
+type traceparentClient struct {
+	traceparent string
+}
+
+func (c *traceparentClient) Do(req *http.Request) (*http.Response, error) {
+	c.traceparent = req.Header.Get("traceparent")
+	return &http.Response{StatusCode: http.StatusNotFound, Body: http.NoBody}, nil
+}
+
+func TestParseTraceparent(t *testing.T) {
+	sc, ok := parseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.True(t, ok)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sc.traceparent())
+
+	for _, header := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+		"00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+	} {
+		_, ok = parseTraceparent(header)
+		assert.False(t, ok, header)
+	}
+}
+
+func TestTracingDisabled(t *testing.T) {
+	ctx, span := startSpan(context.Background(), "disabled")
+	assert.Nil(t, span)
+	assert.Nil(t, ctx.Value(spanContextKey{}))
+	span.setAttribute("key", "value")
+	span.end(nil)
+
+	client := &traceparentClient{}
+	req, _ := http.NewRequest(http.MethodGet, "https://pcs.example.com/sgx/certification/v3/qe/identity", nil)
+	_, err := doPcsRequest(req, client)
+	assert.NoError(t, err)
+	assert.Empty(t, client.traceparent)
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	spans := enableTestTracing(t)
+	client := &traceparentClient{}
+
+	router := mux.NewRouter()
+	router.Use(TracingMiddleware)
+	router.HandleFunc("/platforms/{qeid}", func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, "https://pcs.example.com/sgx/certification/v3/pckcerts?encrypted_ppid=secret", nil)
+		_, _ = doPcsRequest(req, client)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/platforms/0518145496973c5e69577195511e9080", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	// the PCS request span ends first, and is a child of the server span
+	pcsSpan := <-spans
+	serverSpan := <-spans
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", serverSpan.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", serverSpan.ParentSpanID)
+	assert.Equal(t, "GET /platforms/{qeid}", serverSpan.Name)
+	assert.Equal(t, spanKindServer, serverSpan.Kind)
+	assert.NotNil(t, serverSpan.Status)
+
+	assert.Equal(t, serverSpan.TraceID, pcsSpan.TraceID)
+	assert.Equal(t, serverSpan.SpanID, pcsSpan.ParentSpanID)
+	assert.Equal(t, spanKindClient, pcsSpan.Kind)
+	assert.NotNil(t, pcsSpan.Status)
+	assert.Equal(t, "00-"+pcsSpan.TraceID+"-"+pcsSpan.SpanID+"-01", client.traceparent)
+	for _, attribute := range pcsSpan.Attributes {
+		if attribute.Key == "http.url" {
+			assert.NotContains(t, attribute.Value["stringValue"], "secret")
+		}
+	}
+}
+
+func TestSpanExporterPost(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &received))
+	}))
+	defer server.Close()
+
+	spans := enableTestTracing(t)
+	_, span := startSpan(context.Background(), "cachePckCrlInfo")
+	span.setAttribute("scs.ca", "processor")
+	span.end(nil)
+
+	e := &spanExporter{url: server.URL + "/v1/traces", client: server.Client()}
+	assert.NoError(t, e.post([]otlpSpan{<-spans}))
+	assert.Contains(t, received, "resourceSpans")
+
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	e.url = missing.URL + "/v1/traces"
+	assert.Error(t, e.post([]otlpSpan{}))
+}
+
+func TestStopTracing(t *testing.T) {
+	var exported []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var received struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []otlpSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &received))
+		for _, span := range received.ResourceSpans[0].ScopeSpans[0].Spans {
+			exported = append(exported, span.Name)
+		}
+	}))
+	defer server.Close()
+
+	assert.NoError(t, StopTracing(context.Background()))
+	StartTracing(server.URL)
+	t.Cleanup(func() {
+		exporter = nil
+	})
+
+	// spans queued before the export interval elapses are posted when tracing is stopped
+	for _, name := range []string{"pushPlatformInfo", "refreshAllPckCerts"} {
+		_, span := startSpan(context.Background(), name)
+		span.end(nil)
+	}
+	assert.NoError(t, StopTracing(context.Background()))
+	assert.Equal(t, []string{"pushPlatformInfo", "refreshAllPckCerts"}, exported)
+}
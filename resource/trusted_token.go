@@ -0,0 +1,114 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	commLogMsg "intel/isecl/lib/common/v5/log/message"
+	"intel/isecl/scs/v5/config"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// tokenClaims are the registered claims of a JWT checked against the trusted issuers and audiences
+type tokenClaims struct {
+	Issuer   string          `json:"iss"`
+	Audience json.RawMessage `json:"aud"`
+}
+
+// audiences returns the aud claim, which is either a single string or an array of strings
+func (c tokenClaims) audiences() ([]string, error) {
+	if len(c.Audience) == 0 {
+		return nil, nil
+	}
+	var audience string
+	if err := json.Unmarshal(c.Audience, &audience); err == nil {
+		return []string{audience}, nil
+	}
+	var audiences []string
+	if err := json.Unmarshal(c.Audience, &audiences); err != nil {
+		return nil, errors.Wrap(err, "invalid aud claim")
+	}
+	return audiences, nil
+}
+
+// parseTokenClaims decodes the claims of the bearer token of a request. The signature is not
+// verified here, the token has already been validated by the token auth middleware
+func parseTokenClaims(r *http.Request) (*tokenClaims, error) {
+	splitAuthHeader := strings.Split(r.Header.Get("Authorization"), "Bearer ")
+	if len(splitAuthHeader) <= 1 {
+		return nil, errors.New("no bearer token provided")
+	}
+	parts := strings.Split(strings.TrimSpace(splitAuthHeader[1]), ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed token payload")
+	}
+	var claims tokenClaims
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return nil, errors.Wrap(err, "malformed token claims")
+	}
+	return &claims, nil
+}
+
+func containsAny(trusted, values []string) bool {
+	for _, value := range values {
+		for _, t := range trusted {
+			if value == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkTrustedToken returns the reason a token is rejected for, or an empty string when its
+// issuer and audience are trusted
+func checkTrustedToken(trusted config.TrustedTokens, claims *tokenClaims) string {
+	if len(trusted.Issuers) > 0 && !containsAny(trusted.Issuers, []string{claims.Issuer}) {
+		return "token issuer is not trusted"
+	}
+	if len(trusted.Audiences) > 0 {
+		audiences, err := claims.audiences()
+		if err != nil || !containsAny(trusted.Audiences, audiences) {
+			return "token audience is not accepted"
+		}
+	}
+	return ""
+}
+
+// NewTrustedTokenAuth rejects the requests with 401 when the bearer token is not minted by one of
+// the trusted issuers for one of the accepted audiences. It has to run after the token auth
+// middleware, which verifies the signature of the token
+func NewTrustedTokenAuth(trusted config.TrustedTokens) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(trusted.Issuers) == 0 && len(trusted.Audiences) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var reason string
+			claims, err := parseTokenClaims(r)
+			if err != nil {
+				reason = "invalid token: " + err.Error()
+			} else {
+				reason = checkTrustedToken(trusted, claims)
+			}
+			if reason != "" {
+				slog.Warningf("resource/trusted_token: NewTrustedTokenAuth() %s: %s, requested from %s", commLogMsg.AuthenticationFailed, reason, r.RemoteAddr)
+				http.Error(w, reason, http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"encoding/base64"
+	"intel/isecl/scs/v5/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bearerToken(claims string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return "Bearer " + encode([]byte(`{"alg":"RS384","typ":"JWT"}`)) + "." + encode([]byte(claims)) + "." + encode([]byte("signature"))
+}
+
+func trustedTokenResponse(trusted config.TrustedTokens, authHeader string) *httptest.ResponseRecorder {
+	handler := NewTrustedTokenAuth(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/platforms", nil)
+	req.Header.Set("Authorization", authHeader)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestTrustedTokenAuthAccepted(t *testing.T) {
+	trusted := config.TrustedTokens{Issuers: []string{"AAS JWT Issuer"}, Audiences: []string{"SCS"}}
+
+	assert.Equal(t, http.StatusOK, trustedTokenResponse(trusted, bearerToken(`{"iss":"AAS JWT Issuer","aud":"SCS"}`)).Code)
+	assert.Equal(t, http.StatusOK, trustedTokenResponse(trusted, bearerToken(`{"iss":"AAS JWT Issuer","aud":["SHVS","SCS"]}`)).Code)
+	// any token is accepted when no issuers and audiences are configured
+	assert.Equal(t, http.StatusOK, trustedTokenResponse(config.TrustedTokens{}, bearerToken(`{"iss":"Other Issuer"}`)).Code)
+	// only the configured claims are checked
+	assert.Equal(t, http.StatusOK, trustedTokenResponse(config.TrustedTokens{Issuers: []string{"AAS JWT Issuer"}},
+		bearerToken(`{"iss":"AAS JWT Issuer","aud":"SHVS"}`)).Code)
+}
+
+func TestTrustedTokenAuthRejected(t *testing.T) {
+	trusted := config.TrustedTokens{Issuers: []string{"AAS JWT Issuer"}, Audiences: []string{"SCS"}}
+
+	w := trustedTokenResponse(trusted, bearerToken(`{"iss":"AAS JWT Issuer","aud":"SHVS"}`))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "token audience is not accepted")
+
+	w = trustedTokenResponse(trusted, bearerToken(`{"iss":"AAS JWT Issuer","aud":["SHVS","SQVS"]}`))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = trustedTokenResponse(trusted, bearerToken(`{"iss":"AAS JWT Issuer"}`))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "token audience is not accepted")
+
+	w = trustedTokenResponse(trusted, bearerToken(`{"iss":"Other Issuer","aud":"SCS"}`))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "token issuer is not trusted")
+
+	w = trustedTokenResponse(trusted, "Bearer abc")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid token")
+}
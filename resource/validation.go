@@ -13,15 +13,16 @@ import (
 )
 
 var regExMap = map[string]*regexp.Regexp{
-	constants.EncPPIDKey: regexp.MustCompile(`^[0-9a-fA-F]{768}$`),
-	constants.CPUSvnKey:  regexp.MustCompile(`^[0-9a-fA-F]{32}$`),
-	constants.PceSvnKey:  regexp.MustCompile(`^[0-9a-fA-F]{4}$`),
-	constants.PceIDKey:   regexp.MustCompile(`^[0-9a-fA-F]{4}$`),
-	constants.CaKey:      regexp.MustCompile(`^(processor|platform)$`),
-	constants.FmspcKey:   regexp.MustCompile(`^[0-9a-fA-F]{12}$`),
-	constants.QeIDKey:    regexp.MustCompile(`^[0-9a-fA-F]{32}$`),
-	constants.HwUUIDKey:  regexp.MustCompile(`([a-fA-F0-9]{8}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{12}){1}`),
-	constants.PPID:       regexp.MustCompile(`^[0-9a-f]{32}$`)}
+	constants.EncPPIDKey:  regexp.MustCompile(`^[0-9a-fA-F]{768}$`),
+	constants.CPUSvnKey:   regexp.MustCompile(`^[0-9a-fA-F]{32}$`),
+	constants.PceSvnKey:   regexp.MustCompile(`^[0-9a-fA-F]{4}$`),
+	constants.PceIDKey:    regexp.MustCompile(`^[0-9a-fA-F]{4}$`),
+	constants.CaKey:       regexp.MustCompile(`^(processor|platform)$`),
+	constants.FmspcKey:    regexp.MustCompile(`^[0-9a-fA-F]{12}$`),
+	constants.QeIDKey:     regexp.MustCompile(`^[0-9a-fA-F]{32}$`),
+	constants.ManifestKey: regexp.MustCompile(`^[0-9a-fA-F]+$`),
+	constants.HwUUIDKey:   regexp.MustCompile(`([a-fA-F0-9]{8}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{12}){1}`),
+	constants.PPID:        regexp.MustCompile(`^[0-9a-f]{32}$`)}
 
 func validateInputString(key, inString string) bool {
 	regEx := regExMap[key]
@@ -10,8 +10,10 @@ import (
 )
 
 type LastRefresh struct {
-	CompletedAt time.Time `json:"completed-at"`
-	Status      string    `json:"status"`
+	CompletedAt        time.Time `json:"completed-at"`
+	Status             string    `json:"status"`
+	RefreshedPlatforms int       `json:"refreshed-platforms,omitempty"`
+	SkippedPlatforms   int       `json:"skipped-platforms,omitempty"`
 }
 
 type RefreshResponse struct {
@@ -44,6 +46,111 @@ type StatusResponse struct {
 	Body resource.Response
 }
 
+// PckCertsResponse response payload
+// swagger:response PckCertsResponse
+type PckCertsResponse struct {
+	// in:body
+	Body []resource.PckCertsInfo
+}
+
+// RegistrationStatusResponse response payload
+// swagger:response RegistrationStatusResponse
+type RegistrationStatusResponse struct {
+	// in:body
+	Body resource.RegistrationStatus
+}
+
+// PlatformsResponse response payload
+// swagger:response PlatformsResponse
+type PlatformsResponse struct {
+	// in:body
+	Body []resource.PlatformSummary
+}
+
+// RevokedPlatformsResponse response payload
+// swagger:response RevokedPlatformsResponse
+type RevokedPlatformsResponse struct {
+	// in:body
+	Body []resource.RevokedPlatform
+}
+
+// PckCrlsResponse response payload
+// swagger:response PckCrlsResponse
+type PckCrlsResponse struct {
+	// in:body
+	Body []resource.PckCrlSummary
+}
+
+// PckCertSelectionResponse response payload
+// swagger:response PckCertSelectionResponse
+type PckCertSelectionResponse struct {
+	// in:body
+	Body resource.PckCertSelection
+}
+
+// EvaluatedPckCertSelectionResponse response payload
+// swagger:response EvaluatedPckCertSelectionResponse
+type EvaluatedPckCertSelectionResponse struct {
+	// in:body
+	Body resource.EvaluatedPckCertSelection
+}
+
+// TcbComparisonResponse response payload
+// swagger:response TcbComparisonResponse
+type TcbComparisonResponse struct {
+	// in:body
+	Body resource.TcbComparison
+}
+
+// TcbStatusSummaryResponse response payload
+// swagger:response TcbStatusSummaryResponse
+type TcbStatusSummaryResponse struct {
+	// in:body
+	Body resource.TcbStatusSummary
+}
+
+// CachePurgeResponse response payload
+// swagger:response CachePurgeResponse
+type CachePurgeResponse struct {
+	// in:body
+	Body []resource.CachePurgeResult
+}
+
+// PckCertReselectionResponse response payload
+// swagger:response PckCertReselectionResponse
+type PckCertReselectionResponse struct {
+	// in:body
+	Body resource.PckCertReselectionResult
+}
+
+// CacheArchiveResponse response payload
+// swagger:response CacheArchiveResponse
+type CacheArchiveResponse struct {
+	// in:body
+	Body resource.CacheArchive
+}
+
+// CacheImportResponse response payload
+// swagger:response CacheImportResponse
+type CacheImportResponse struct {
+	// in:body
+	Body resource.CacheImportResult
+}
+
+// CacheStatsResponse response payload
+// swagger:response CacheStatsResponse
+type CacheStatsResponse struct {
+	// in:body
+	Body []resource.CollateralCacheStats
+}
+
+// CollateralCheckResponse response payload
+// swagger:response CollateralCheckResponse
+type CollateralCheckResponse struct {
+	// in:body
+	Body resource.CollateralCheckResult
+}
+
 // RefreshStatusResponse response payload
 // swagger:response RefreshStatusResponse
 type RefreshStatusResponse struct {
@@ -56,6 +163,11 @@ type RefreshStatusResponse struct {
 //
 // description: |
 //   SGX Agent uses this API to push the platform values (such as enc_ppi, pceid, cpisvn, pcesvn, qeid and manifest) to SCS.
+//   The PCK certificates of the platform are cached on push. TCB info, PCK CRL and QE identity are also fetched on
+//   push with the lazy caching model, while with the precache caching model they are cached by the next refresh.
+//   A platform pushed again with a changed cpusvn or pcesvn, e.g. after a microcode or BIOS update, is not treated
+//   as a duplicate push. Its PCK certificate is selected again for the new raw TCB level, which is cached in place
+//   of the previous one.
 //   A valid bearer token should be provided to authorize this REST call.
 //
 // security:
@@ -75,6 +187,23 @@ type RefreshStatusResponse struct {
 //     description: Successfully pushed the platform values to SCS.
 //     schema:
 //       "$ref": "#/definitions/Response"
+//   '400':
+//     description: |
+//       Platform values do not match the platform info schema (resource/schemas/platform_info.v1.json), every
+//       invalid field is listed in the error, e.g. "invalid platform info: pce_svn: should be 4 characters long,
+//       qe_id: is required".
+//   '404':
+//     description: No PCK certificate is available from Intel PCS server for any TCB level of the platform.
+//   '422':
+//     description: |
+//       Raw TCB of the platform is lower than the TCB of all its PCK certificates, so none can be selected. The TCB of
+//       the platform is to be updated, e.g. by a microcode or BIOS update, before it is pushed again.
+//   '429':
+//     description: Rate limit of mutating APIs exceeded, request can be retried after the seconds in Retry-After header.
+//   '503':
+//     description: |
+//       No PCK certificate is available from Intel PCS server for any TCB level of the platform, with the retry policy
+//       configured the push can be retried after the seconds in Retry-After header.
 //
 // x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/platforms
 // x-sample-call-input: |
@@ -227,11 +356,32 @@ type RefreshStatusResponse struct {
 //  - bearerAuth: []
 // produces:
 //  - application/json
+// parameters:
+// - name: type
+//   description: |
+//     Type of the refresh to be performed. All the platform collaterals are refreshed if not provided.
+//       "certs" - Refresh only the PCK Certificates.
+//       "tcbs" - Refresh the PCK CRL, TCB info and QE Identity information.
+//       "qe" - Refresh only the QE Identity information.
+//       "outofdate" - Refresh the TCB info, then the PCK Certificates of only those platforms whose TCB status
+//                     is not UpToDate. Number of platforms refreshed and skipped is reported in
+//                     last-refresh.refreshed-platforms and last-refresh.skipped-platforms once the refresh completes.
+//       "expiring" - Refresh the PCK Certificates of only those platforms whose selected PCK Certificate has expired
+//                    or expires within SCS_PCK_CERT_EXPIRY_WARNING_DAYS. Number of platforms refreshed and skipped
+//                    is reported the same way as for "outofdate".
+//   in: query
+//   type: string
+//   required: false
+//   enum: [certs, tcbs, qe, outofdate, expiring]
 // responses:
 //   '200':
 //     description: Successfully refreshed the platform collaterals.
 //     schema:
 //       "$ref": "#/definitions/RefreshResponse"
+//   '400':
+//     description: Unknown query parameter or refresh type provided.
+//   '429':
+//     description: Rate limit of mutating APIs exceeded, request can be retried after the seconds in Retry-After header.
 //
 // x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/refreshes
 // x-sample-call-output: |
@@ -248,6 +398,16 @@ type RefreshStatusResponse struct {
 // ---
 // description: |
 //   This API is used by SGX Agent to determine the TCB up-to-date status of a platform.
+//   Status is reported as true when the TCB status of the platform is one of the statuses
+//   configured in SCS_ACCEPTED_TCB_STATUSES (UpToDate and ConfigurationNeeded by default).
+//   When a TCB baseline is configured in SCS_MIN_TCB_COMP_SVNS and SCS_MIN_PCE_SVN, status is also
+//   reported as false when a comp svn or the pcesvn of the TCB of the platform is lower than the
+//   baseline, with Message telling which one.
+//   The TCB status matched for the platform is returned in TcbStatus. When the raw TCB of the
+//   platform is lower than all TCB levels in TcbInfo, TcbStatus is reported as TCBLevelNotFound.
+//   TcbInfo holds the issueDate, nextUpdate and tcbEvaluationDataNumber of the TcbInfo the status was
+//   evaluated against, and the time SCS last cached it in UpdatedTime, so that the consumer can apply
+//   its own freshness policy. It is left out when the cached TcbInfo cannot be read.
 //   A valid bearer token should be provided to authorize this REST call.
 //
 // security:
@@ -273,6 +433,1004 @@ type RefreshStatusResponse struct {
 // x-sample-call-output: |
 //    {
 //        "Status": "true",
-//        "Message": "TCB Status is UpToDate"
+//        "Message": "TCB Status is UpToDate",
+//        "TcbStatus": "UpToDate",
+//        "TcbInfo": {
+//            "IssueDate": "2022-06-21T10:00:00Z",
+//            "NextUpdate": "2022-07-21T10:00:00Z",
+//            "TcbEvaluationDataNumber": 12,
+//            "UpdatedTime": "2022-06-21T10:05:12.345678Z"
+//        }
+//    }
+// ---
+
+// swagger:operation POST /collateral/check PlatformInfo checkCollateral
+// ---
+// description: |
+//   This API reports whether SCS has cached all the collaterals needed to verify a quote produced by a
+//   platform, so that a client can find out in one call if the quote can be sent for verification.
+//   The status of each collateral is reported as present, missing or expired, and ready is true only
+//   when all of them are present. Collaterals are only looked up in the cache, nothing is fetched from
+//   Intel PCS. fmspc is needed only to check the TCB info of a platform which is not cached.
+//   A valid bearer token should be provided to authorize this REST call.
+//
+// security:
+//  - bearerAuth: []
+// consumes:
+//  - application/json
+// produces:
+//  - application/json
+// parameters:
+// - name: request body
+//   in: body
+//   required: true
+//   schema:
+//     "$ref": "#/definitions/CollateralCheckRequest"
+// responses:
+//   '200':
+//     description: Successfully checked the collaterals of the platform.
+//     schema:
+//       "$ref": "#/definitions/CollateralCheckResult"
+//   '400':
+//     description: Invalid request body or platform identifiers provided.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/collateral/check
+// x-sample-call-input: |
+//    {
+//        "qeid": "0f16dfa4033e66e642af8fe358c18751",
+//        "pceid": "0000"
+//    }
+// x-sample-call-output: |
+//    {
+//        "ready": false,
+//        "collaterals": [
+//            {"collateral": "pckcert", "status": "present"},
+//            {"collateral": "pckcertchain", "status": "present"},
+//            {"collateral": "pckcrl", "status": "expired"},
+//            {"collateral": "tcbinfo", "status": "present"},
+//            {"collateral": "qeidentity", "status": "present"},
+//            {"collateral": "rootcacrl", "status": "missing"}
+//        ]
+//    }
+// ---
+
+// swagger:operation POST /collateral/cache PlatformInfo cacheCollateral
+// ---
+// description: |
+//   This API caches the TCB info of an fmspc, the PCK CRL of a ca and the QE identity, for verifiers which
+//   have quotes of a platform but not its encrypted PPID or platform manifest needed to push it. The platform
+//   is not registered and its PCK certificates are not cached. Collaterals which are already cached are not
+//   fetched again from Intel PCS, and are kept up to date by refresh.
+//   A valid bearer token should be provided to authorize this REST call.
+//
+// security:
+//  - bearerAuth: []
+// consumes:
+//  - application/json
+// produces:
+//  - application/json
+// parameters:
+// - name: request body
+//   in: body
+//   required: true
+//   schema:
+//     "$ref": "#/definitions/CollateralCacheRequest"
+// responses:
+//   '200':
+//     description: Successfully cached the collaterals.
+//     schema:
+//       "$ref": "#/definitions/Response"
+//   '400':
+//     description: Invalid request body, fmspc or ca provided.
+//   '404':
+//     description: Collaterals could not be fetched from Intel PCS.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/collateral/cache
+// x-sample-call-input: |
+//    {
+//        "fmspc": "20606a000000",
+//        "ca": "processor"
+//    }
+// x-sample-call-output: |
+//    {
+//        "Status": "Success",
+//        "Message": "collaterals of fmspc 20606a000000 and ca processor are cached"
+//    }
+// ---
+
+// swagger:operation GET /pckcerts PlatformInfo getPckCerts
+// ---
+// description: |
+//   This API returns all the PCK Certificates cached for the current raw TCB level of a platform along with
+//   their TCB levels, in the same format as Intel PCS getPckCerts API. Consumers can make use of this API to
+//   perform their own PCK Certificate selection. If the PCK Certificates are not yet cached for the current
+//   raw TCB level of the platform, they are fetched from Intel PCS and cached.
+//   The PCK Certificate issuer chain, FMSPC and CA type are returned in Sgx-Pck-Certificate-Issuer-Chain,
+//   Sgx-Fmspc and Sgx-Pck-Certificate-Ca-Type headers respectively.
+//   A valid bearer token should be provided to authorize this REST call.
+//
+// security:
+//  - bearerAuth: []
+// produces:
+//  - application/json
+// parameters:
+// - name: qeid
+//   description: Quoting Enclave ID specific to a platform.
+//   in: query
+//   type: string
+//   required: true
+// - name: pceid
+//   description: Provisioning Certificate Enclave ID specific to a platform.
+//   in: query
+//   type: string
+//   required: true
+// responses:
+//   '200':
+//     description: Successfully retrieved the PCK Certificates for the platform.
+//     schema:
+//       type: array
+//       items:
+//         "$ref": "#/definitions/PckCertsInfo"
+//   '404':
+//     description: Platform is not cached in SCS.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/pckcerts?qeid=0f16dfa4033e66e642af8fe358c18751&pceid=0000
+// x-sample-call-output: |
+//    [
+//        {
+//            "tcb": {
+//                "sgxtcbcomp01svn": 3,
+//                "sgxtcbcomp02svn": 3,
+//                "sgxtcbcomp03svn": 0,
+//                "sgxtcbcomp04svn": 0,
+//                "sgxtcbcomp05svn": 0,
+//                "sgxtcbcomp06svn": 0,
+//                "sgxtcbcomp07svn": 0,
+//                "sgxtcbcomp08svn": 0,
+//                "sgxtcbcomp09svn": 0,
+//                "sgxtcbcomp10svn": 0,
+//                "sgxtcbcomp11svn": 0,
+//                "sgxtcbcomp12svn": 0,
+//                "sgxtcbcomp13svn": 0,
+//                "sgxtcbcomp14svn": 0,
+//                "sgxtcbcomp15svn": 0,
+//                "sgxtcbcomp16svn": 0,
+//                "pcesvn": 10
+//            },
+//            "tcbm": "030300000000000000000000000000000A00",
+//            "cert": "-----BEGIN CERTIFICATE-----\nMIIE8zCCBJmgAwIBAgIVAL...\n-----END CERTIFICATE-----\n"
+//        }
+//    ]
+// ---
+
+// swagger:operation GET /registrationstatus PlatformInfo getRegistrationStatus
+// ---
+// description: |
+//   This API reports whether a platform is already registered with Intel, so that manifest enrollment of an
+//   already registered multi-package platform can be avoided. A cached platform is identified by qeid and pceid,
+//   an uncached platform by encrypted_ppid and pceid. The status is Registered when PCK Certificates are cached
+//   for the platform or returned by Intel PCS, NotRegistered when Intel PCS does not know the platform and
+//   Unknown when only the platform manifest is cached and Intel PCS cannot be queried with it.
+//   The source field tells whether the status is derived from the cache or from Intel PCS.
+//   A valid bearer token should be provided to authorize this REST call.
+//
+// security:
+//  - bearerAuth: []
+// produces:
+//  - application/json
+// parameters:
+// - name: qeid
+//   description: Quoting Enclave ID specific to a platform. Must be given when encrypted_ppid is not given.
+//   in: query
+//   type: string
+// - name: encrypted_ppid
+//   description: Encrypted PPID of a platform. Must be given when qeid is not given.
+//   in: query
+//   type: string
+// - name: pceid
+//   description: Provisioning Certificate Enclave ID specific to a platform.
+//   in: query
+//   type: string
+//   required: true
+// responses:
+//   '200':
+//     description: Successfully retrieved the registration status of the platform.
+//     schema:
+//       "$ref": "#/definitions/RegistrationStatus"
+//   '400':
+//     description: Invalid query parameters provided.
+//   '404':
+//     description: Platform is not cached in SCS.
+//   '502':
+//     description: Intel PCS returned an unexpected error.
+//   '503':
+//     description: Intel PCS is unavailable or rate limiting requests.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/registrationstatus?qeid=0f16dfa4033e66e642af8fe358c18751&pceid=0000
+// x-sample-call-output: |
+//    {
+//        "qeid": "0f16dfa4033e66e642af8fe358c18751",
+//        "pceid": "0000",
+//        "status": "Registered",
+//        "source": "pcs"
+//    }
+// ---
+
+// swagger:operation GET /revokedplatforms PlatformInfo getRevokedPlatforms
+// ---
+// description: |
+//   This API lists the cached platforms affected by the revocations of the cached PCK CRL of a CA.
+//   The serial numbers revoked by the CRL are cross referenced against the cached PCK Certificates issued
+//   by the CRL issuer, and a platform is listed when any of its cached PCK Certificates is revoked.
+//   A valid bearer token should be provided to authorize this REST call.
+//
+// security:
+//  - bearerAuth: []
+// produces:
+//  - application/json
+// parameters:
+// - name: ca
+//   description: Type of the PCK CRL to cross reference. Valid values are processor and platform.
+//   in: query
+//   type: string
+//   required: true
+// responses:
+//   '200':
+//     description: Successfully retrieved the platforms with revoked PCK Certificates.
+//     schema:
+//       type: array
+//       items:
+//         "$ref": "#/definitions/RevokedPlatform"
+//   '400':
+//     description: Invalid query parameters provided.
+//   '404':
+//     description: PCK CRL of the CA is not cached in SCS.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/revokedplatforms?ca=processor
+// x-sample-call-output: |
+//    [
+//        {
+//            "qeid": "0f16dfa4033e66e642af8fe358c18751",
+//            "pceid": "0000"
+//        }
+//    ]
+// ---
+
+// swagger:operation GET /platforms PlatformInfo getPlatforms
+// ---
+// description: |
+//   This API lists a page of the cached platforms with a given pceid, which helps in correlating issues
+//   with PCE firmware rollouts. Encrypted PPID and manifest of the platforms are not returned.
+//   Platforms are ordered by qeid. last-accessed-time is the time the platform was last served by the
+//   tcbstatus or a pck cert api, tracked to a resolution of 10 minutes, and is not returned for
+//   platforms not served since. pck-cert-days-to-expiry is the number of days until the PCK certificate
+//   selected for the platform expires, negative once it has expired, and is not returned when the expiry
+//   is not known. pck-cert-expiring is set from SCS_PCK_CERT_EXPIRY_WARNING_DAYS before the expiry.
+//   A valid bearer token should be provided to authorize this REST call.
+//
+// security:
+//  - bearerAuth: []
+// produces:
+//  - application/json
+// parameters:
+// - name: pceid
+//   description: Provisioning Certificate Enclave ID of the platforms.
+//   in: query
+//   type: string
+//   required: true
+// - name: limit
+//   description: Maximum number of platforms returned, between 1 and 1000. Defaults to 100.
+//   in: query
+//   type: integer
+// - name: offset
+//   description: Number of platforms skipped before the returned page. Defaults to 0.
+//   in: query
+//   type: integer
+// responses:
+//   '200':
+//     description: Successfully retrieved the platforms with the pceid.
+//     schema:
+//       type: array
+//       items:
+//         "$ref": "#/definitions/PlatformSummary"
+//   '400':
+//     description: Invalid query parameters provided.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/platforms?pceid=0000&limit=10
+// x-sample-call-output: |
+//    [
+//        {
+//            "qeid": "0f16dfa4033e66e642af8fe358c18751",
+//            "pceid": "0000",
+//            "cpusvn": "1bf8deed6f929ce40bd658e61ea722eb",
+//            "pcesvn": "0a00",
+//            "fmspc": "20606a000000",
+//            "ca": "processor",
+//            "updated-time": "2022-06-21T11:24:56.123456Z",
+//            "last-accessed-time": "2022-06-22T08:10:12.123456Z",
+//            "pck-cert-days-to-expiry": 2553,
+//            "pck-cert-expiring": false
+//        }
+//    ]
+// ---
+
+// swagger:operation GET /platforms/{qeid} PlatformInfo getPlatform
+// ---
+// description: |
+//   This API returns the summary of a cached platform, along with the expiry of its selected PCK certificate
+//   as listed by GET /platforms. The qeid can also be given as query parameter,
+//   as GET /platforms?qeid=0f16dfa4033e66e642af8fe358c18751, which is served the same way.
+//   pceid is optional, as qeid identifies a platform.
+//   A valid bearer token should be provided to authorize this REST call.
+//
+// security:
+//  - bearerAuth: []
+// produces:
+//  - application/json
+// parameters:
+// - name: qeid
+//   description: Quoting Enclave ID of the platform.
+//   in: path
+//   type: string
+//   required: true
+// - name: pceid
+//   description: Provisioning Certificate Enclave ID of the platform.
+//   in: query
+//   type: string
+// responses:
+//   '200':
+//     description: Successfully retrieved the platform.
+//     schema:
+//       "$ref": "#/definitions/PlatformSummary"
+//   '400':
+//     description: Invalid qeid or pceid provided.
+//   '403':
+//     description: Platform is out of the scope of the role.
+//   '404':
+//     description: Platform is not cached.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/platforms/0f16dfa4033e66e642af8fe358c18751
+// x-sample-call-output: |
+//    {
+//        "qeid": "0f16dfa4033e66e642af8fe358c18751",
+//        "pceid": "0000",
+//        "cpusvn": "1bf8deed6f929ce40bd658e61ea722eb",
+//        "pcesvn": "0a00",
+//        "fmspc": "20606a000000",
+//        "ca": "processor",
+//        "updated-time": "2022-06-21T11:24:56.123456Z",
+//        "last-accessed-time": "2022-06-22T08:10:12.123456Z",
+//        "pck-cert-days-to-expiry": 2553,
+//        "pck-cert-expiring": false
+//    }
+// ---
+
+// swagger:operation DELETE /platforms/{qeid} PlatformInfo deletePlatform
+// ---
+// description: |
+//   This API deletes a cached platform along with its PCK certificates. TcbInfo, PCK CRL and QE identity
+//   are kept, as they are shared with other platforms. The qeid can also be given as query parameter,
+//   as DELETE /platforms?qeid=0f16dfa4033e66e642af8fe358c18751, which is served the same way.
+//   A valid bearer token should be provided to authorize this REST call.
+//
+// security:
+//  - bearerAuth: []
+// produces:
+//  - application/json
+// parameters:
+// - name: qeid
+//   description: Quoting Enclave ID of the platform.
+//   in: path
+//   type: string
+//   required: true
+// - name: pceid
+//   description: Provisioning Certificate Enclave ID of the platform.
+//   in: query
+//   type: string
+// responses:
+//   '200':
+//     description: Successfully deleted the platform.
+//     schema:
+//       "$ref": "#/definitions/Response"
+//   '400':
+//     description: Invalid qeid or pceid provided.
+//   '403':
+//     description: Platform is out of the scope of the role.
+//   '404':
+//     description: Platform is not cached.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/platforms/0f16dfa4033e66e642af8fe358c18751
+// x-sample-call-output: |
+//    {
+//        "Status": "Success",
+//        "Message": "platform with qeid 0f16dfa4033e66e642af8fe358c18751 is deleted"
+//    }
+// ---
+
+// swagger:operation GET /pckcrls PlatformInfo getPckCrls
+// ---
+// description: |
+//   This API lists a page of the cached PCK CRLs ordered by CA type, along with their validity, so that
+//   freshness of the CRLs can be audited. The base64 encoded CRL is returned only when verbose is true.
+//   thisUpdate and nextUpdate are not returned for CRLs cached before their validity was stored.
+//   A valid bearer token should be provided to authorize this REST call.
+//
+// security:
+//  - bearerAuth: []
+// produces:
+//  - application/json
+// parameters:
+// - name: verbose
+//   description: Returns the base64 encoded CRL as well when true. Defaults to false.
+//   in: query
+//   type: boolean
+// - name: limit
+//   description: Maximum number of CRLs returned, between 1 and 1000. Defaults to 100.
+//   in: query
+//   type: integer
+// - name: offset
+//   description: Number of CRLs skipped before the returned page. Defaults to 0.
+//   in: query
+//   type: integer
+// responses:
+//   '200':
+//     description: Successfully retrieved the cached PCK CRLs.
+//     schema:
+//       type: array
+//       items:
+//         "$ref": "#/definitions/PckCrlSummary"
+//   '400':
+//     description: Invalid query parameters provided.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/pckcrls
+// x-sample-call-output: |
+//    [
+//        {
+//            "ca": "platform",
+//            "issuer-chain-present": true,
+//            "this-update": "2022-06-21T11:24:56Z",
+//            "next-update": "2022-07-21T11:24:56Z",
+//            "expired": false,
+//            "updated-time": "2022-06-21T11:30:02.123456Z"
+//        },
+//        {
+//            "ca": "processor",
+//            "issuer-chain-present": true,
+//            "this-update": "2022-06-21T11:24:56Z",
+//            "next-update": "2022-07-21T11:24:56Z",
+//            "expired": false,
+//            "updated-time": "2022-06-21T11:30:02.123456Z"
+//        }
+//    ]
+// ---
+
+// swagger:operation GET /pckcertselection PlatformInfo getPckCertSelection
+// ---
+// description: |
+//   This API returns the PCK certificate selected for the current raw TCB level of a platform, along with
+//   the outcome of comparing its tcbm against each TCB level of the TcbInfo of the platform fmspc. TCB levels
+//   are listed sorted by descending TCB, the order in which they are compared, and the TCB status is the
+//   status of the first level which the tcbm is equal or greater than. This helps diagnose why a TCB status
+//   was reported for a platform.
+//   A valid bearer token should be provided to authorize this REST call.
+//
+// security:
+//  - bearerAuth: []
+// produces:
+//  - application/json
+// parameters:
+// - name: qeid
+//   description: Hex encoded QE ID of the platform.
+//   in: query
+//   type: string
+//   required: true
+// - name: pceid
+//   description: Hex encoded PCE ID of the platform.
+//   in: query
+//   type: string
+// responses:
+//   '200':
+//     description: Successfully retrieved the PCK certificate selection of the platform.
+//     schema:
+//       "$ref": "#/definitions/PckCertSelection"
+//   '400':
+//     description: Invalid query parameters provided.
+//   '404':
+//     description: Platform, PCK certificates or TcbInfo of the platform are not cached.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/pckcertselection?qeid=0f16dfa4033e66e642af8fe358c18751&pceid=0000
+// x-sample-call-output: |
+//    {
+//        "qe_id": "0f16dfa4033e66e642af8fe358c18751",
+//        "pce_id": "0000",
+//        "fmspc": "20606a000000",
+//        "cert_index": 1,
+//        "cert_count": 2,
+//        "tcbm": "010100000000000000000000000000000900",
+//        "tcb_status": "OutOfDate",
+//        "tcb_levels": [
+//            {
+//                "tcb": {"sgxtcbcomp01svn": 2, "sgxtcbcomp02svn": 2, ..., "pcesvn": 10},
+//                "tcb_date": "2021-11-10T00:00:00Z",
+//                "tcb_status": "UpToDate",
+//                "result": "Lower",
+//                "matched": false
+//            },
+//            {
+//                "tcb": {"sgxtcbcomp01svn": 1, "sgxtcbcomp02svn": 1, ..., "pcesvn": 9},
+//                "tcb_date": "2020-11-11T00:00:00Z",
+//                "tcb_status": "OutOfDate",
+//                "result": "EqualOrGreater",
+//                "matched": true
+//            }
+//        ]
+//    }
+// ---
+
+// swagger:operation GET /tcbcomparison PlatformInfo getTcbComparison
+// ---
+// description: |
+//   This API compares the current raw TCB level (cpusvn and pcesvn) of a platform against the raw TCB level
+//   cached for it, and reports whether they differ. A platform whose raw TCB level changed, e.g. after a
+//   microcode or BIOS update, should be pushed again so that its PCK certificate is selected for the current
+//   raw TCB level. The result is the outcome of comparing the current raw TCB level against the cached one.
+//   The cached platform is not updated by this API.
+//   A valid bearer token should be provided to authorize this REST call.
+//
+// security:
+//  - bearerAuth: []
+// produces:
+//  - application/json
+// parameters:
+// - name: qeid
+//   description: Hex encoded QE ID of the platform.
+//   in: query
+//   type: string
+//   required: true
+// - name: pceid
+//   description: Hex encoded PCE ID of the platform.
+//   in: query
+//   type: string
+//   required: true
+// - name: cpusvn
+//   description: Hex encoded current CPU SVN of the platform.
+//   in: query
+//   type: string
+//   required: true
+// - name: pcesvn
+//   description: Hex encoded current PCE SVN of the platform.
+//   in: query
+//   type: string
+//   required: true
+// responses:
+//   '200':
+//     description: Successfully compared the raw TCB level of the platform.
+//     schema:
+//       "$ref": "#/definitions/TcbComparison"
+//   '400':
+//     description: Invalid query parameters provided.
+//   '404':
+//     description: Platform is not cached.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/tcbcomparison?qeid=0f16dfa4033e66e642af8fe358c18751&pceid=0000&cpusvn=1bf8deed6f929ce40bd658e61ea722eb&pcesvn=0b00
+// x-sample-call-output: |
+//    {
+//        "qe_id": "0f16dfa4033e66e642af8fe358c18751",
+//        "pce_id": "0000",
+//        "cpu_svn": "1bf8deed6f929ce40bd658e61ea722eb",
+//        "pce_svn": "0b00",
+//        "cached_cpu_svn": "1bf8deed6f929ce40bd658e61ea722eb",
+//        "cached_pce_svn": "0a00",
+//        "result": "EqualOrGreater",
+//        "reselection_needed": true
+//    }
+// ---
+
+// swagger:operation GET /tcbstatussummary PlatformInfo getTcbStatusSummary
+// ---
+// description: |
+//   This API returns how many of the cached platforms of an fmspc are at each TCB status, which shows the
+//   impact of a TCB recovery on a platform generation. Platforms whose TCB status cannot be evaluated, e.g.
+//   as their PCK certificates are not cached, are counted as unevaluated.
+//   A valid bearer token should be provided to authorize this REST call.
+//
+// security:
+//  - bearerAuth: []
+// produces:
+//  - application/json
+// parameters:
+// - name: fmspc
+//   description: Hex encoded fmspc of the platforms.
+//   in: query
+//   type: string
+//   required: true
+// responses:
+//   '200':
+//     description: Successfully retrieved the TCB status summary of the fmspc.
+//     schema:
+//       "$ref": "#/definitions/TcbStatusSummary"
+//   '400':
+//     description: Invalid query parameters provided.
+//   '404':
+//     description: TcbInfo of the fmspc is not cached.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/tcbstatussummary?fmspc=20606a000000
+// x-sample-call-output: |
+//    {
+//        "fmspc": "20606a000000",
+//        "platforms": 12,
+//        "tcb_statuses": {
+//            "UpToDate": 9,
+//            "OutOfDate": 2
+//        },
+//        "unevaluated": 1
+//    }
+// ---
+
+// swagger:operation POST /cache/purge CachePurge purgeCache
+// ---
+//
+// description: |
+//   This API purges the cached TCB info of each of the given fmspcs, and optionally the cached platforms
+//   with those fmspcs along with their PCK certificates. Each fmspc is purged in its own transaction and
+//   a result is returned for every fmspc.
+//   A valid bearer token should be provided to authorize this REST call.
+//
+// security:
+//  - bearerAuth: []
+// consumes:
+//  - application/json
+// produces:
+//  - application/json
+// parameters:
+// - name: request body
+//   in: body
+//   required: true
+//   schema:
+//     "$ref": "#/definitions/CachePurgeRequest"
+// responses:
+//   '200':
+//     description: Purge was attempted for every fmspc, see the per fmspc status.
+//     schema:
+//       type: array
+//       items:
+//         "$ref": "#/definitions/CachePurgeResult"
+//   '400':
+//     description: Invalid request body or fmspc provided.
+//   '429':
+//     description: Rate limit of mutating APIs exceeded, request can be retried after the seconds in Retry-After header.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/cache/purge
+// x-sample-call-input: |
+//    {
+//        "fmspcs": ["20606a000000", "00906ed50000"],
+//        "purge_platforms": true
+//    }
+// x-sample-call-output: |
+//    [
+//        {
+//            "fmspc": "20606a000000",
+//            "status": "purged",
+//            "purged_platforms": 2
+//        },
+//        {
+//            "fmspc": "00906ed50000",
+//            "status": "notfound",
+//            "purged_platforms": 0
+//        }
+//    ]
+// ---
+
+// swagger:operation POST /cache/pckcerts/reselect PckCertSelection reselectPckCerts
+// ---
+//
+// description: |
+//   This API selects again the PCK certificate of every cached platform for its current raw TCB level, from
+//   the cached PCK certificates of the platform and the cached TCB info of its fmspc. It is used after TCB info
+//   was updated, e.g. on a TCB recovery, to keep the selection correct without refreshing PCK certificates
+//   from Intel PCS. Platforms whose selection fails keep their earlier selection and are listed by qeid.
+//   A valid bearer token should be provided to authorize this REST call.
+//
+// security:
+//  - bearerAuth: []
+// produces:
+//  - application/json
+// responses:
+//   '200':
+//     description: Selection was attempted for every cached platform.
+//     schema:
+//       "$ref": "#/definitions/PckCertReselectionResult"
+//   '429':
+//     description: Rate limit of mutating APIs exceeded, request can be retried after the seconds in Retry-After header.
+//   '500':
+//     description: Cached platforms could not be retrieved.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/cache/pckcerts/reselect
+// x-sample-call-output: |
+//    {
+//        "platforms": 3,
+//        "reselected": 1,
+//        "unchanged": 1,
+//        "failed": ["7518145496973c5e69577195511e9080"]
+//    }
+// ---
+
+// swagger:operation POST /pckcertselection PckCertSelection evaluatePckCertSelection
+// ---
+//
+// description: |
+//   This API selects the PCK certificate of a cached platform from its cached PCK certificates and evaluates
+//   its TCB status against a TCB info supplied by the client, e.g. one obtained from Intel PCS through another
+//   channel in an air-gapped environment. SCS does not contact Intel PCS. tcb_info is the TCB info response
+//   of Intel PCS as is, and tcb_info_issuer_chain its url escaped Sgx-Tcb-Info-Issuer-Chain header. The
+//   signature of the TCB info is always verified, regardless of the signature verification policy.
+//   Nothing is cached unless persist is set, in which case the TCB info is cached for the fmspc of the
+//   platform along with the PCK certificate selected against it. A TCB info older than the cached one,
+//   by its tcbEvaluationDataNumber, is not cached. pce_id is optional, as qe_id identifies a platform.
+//   A valid bearer token should be provided to authorize this REST call.
+//
+// security:
+//  - bearerAuth: []
+// consumes:
+//  - application/json
+// produces:
+//  - application/json
+// parameters:
+// - name: request body
+//   in: body
+//   required: true
+//   schema:
+//     "$ref": "#/definitions/PckCertSelectionRequest"
+// responses:
+//   '200':
+//     description: PCK certificate selection of the platform evaluated against the supplied TCB info.
+//     schema:
+//       "$ref": "#/definitions/EvaluatedPckCertSelection"
+//   '400':
+//     description: Invalid request body, TCB info whose signature could not be verified, or TCB info of another fmspc.
+//   '404':
+//     description: Platform or its PCK certificates are not cached.
+//   '409':
+//     description: Cached PCK certificates of the platform do not match their tcbms, or persist is set with a TCB info older than the cached one.
+//   '422':
+//     description: Raw TCB of the platform is lower than the TCB of all its cached PCK certificates.
+//   '429':
+//     description: Rate limit of mutating APIs exceeded, request can be retried after the seconds in Retry-After header.
+//   '500':
+//     description: TCB info or PCK certificate selection could not be cached.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/pckcertselection
+// x-sample-call-input: |
+//    {
+//        "qe_id": "0f16dfa4033e66e642af8fe358c18751",
+//        "tcb_info": {"tcbInfo": {"version": 2, "fmspc": "20606A000000", ...}, "signature": "..."},
+//        "tcb_info_issuer_chain": "-----BEGIN%20CERTIFICATE-----...",
+//        "persist": false
+//    }
+// x-sample-call-output: |
+//    {
+//        "qe_id": "0f16dfa4033e66e642af8fe358c18751",
+//        "pce_id": "0000",
+//        "fmspc": "20606a000000",
+//        "cert_index": 1,
+//        "cert_count": 2,
+//        "tcbm": "010100000000000000000000000000000900",
+//        "tcb_status": "OutOfDate",
+//        "tcb_levels": [
+//            {
+//                "tcb": {"sgxtcbcomp01svn": 2, "sgxtcbcomp02svn": 2, "pcesvn": 10},
+//                "tcb_date": "2021-11-10T00:00:00Z",
+//                "tcb_status": "UpToDate",
+//                "result": "Lower",
+//                "matched": false
+//            },
+//            {
+//                "tcb": {"sgxtcbcomp01svn": 1, "sgxtcbcomp02svn": 1, "pcesvn": 9},
+//                "tcb_date": "2020-11-11T00:00:00Z",
+//                "tcb_status": "OutOfDate",
+//                "result": "EqualOrGreater",
+//                "matched": true
+//            }
+//        ],
+//        "persisted": false
+//    }
+// ---
+
+// swagger:operation PUT /subscription-keys SubscriptionKeys updateSubscriptionKeys
+// ---
+//
+// description: |
+//   This API replaces the Intel PCS API subscription keys used by SCS, so that the keys can be rotated
+//   without restarting SCS. PCS requests sent after the update use the new keys. The keys are written to
+//   the configuration file as well when persist is set, otherwise they are used until SCS is restarted.
+//   Keys loaded from INTEL_PROVISIONING_SERVER_API_KEY_SOURCE cannot be persisted, they are to be updated at
+//   the source.
+//   A valid bearer token should be provided to authorize this REST call.
+//
+// security:
+//  - bearerAuth: []
+// consumes:
+//  - application/json
+// parameters:
+// - name: request body
+//   in: body
+//   required: true
+//   schema:
+//     "$ref": "#/definitions/SubscriptionKeyUpdateRequest"
+// responses:
+//   '204':
+//     description: Subscription keys updated.
+//   '400':
+//     description: Invalid request body or subscription key provided.
+//   '409':
+//     description: Persist is set while the subscription keys are loaded from a secret source.
+//   '429':
+//     description: Rate limit of mutating APIs exceeded, request can be retried after the seconds in Retry-After header.
+//   '500':
+//     description: Subscription keys were updated but could not be persisted to the configuration file.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/subscription-keys
+// x-sample-call-input: |
+//    {
+//        "subscription_keys": ["<new subscription key>"],
+//        "persist": true
+//    }
+// ---
+
+// swagger:operation GET /cache/export CacheArchive exportCache
+// ---
+//
+// description: |
+//   This API exports all the cached platforms, PCK certificates, PCK CRLs, TCB info and QE identity as a
+//   versioned archive. The archive can be imported into another SCS instance which has no access to PCS.
+//   The checksum is the hex encoded sha256 digest of the records, the archive should not be modified.
+//   A valid bearer token should be provided to authorize this REST call.
+//
+// security:
+//  - bearerAuth: []
+// produces:
+//  - application/json
+// responses:
+//   '200':
+//     description: Successfully exported the cache archive.
+//     schema:
+//       "$ref": "#/definitions/CacheArchive"
+//   '400':
+//     description: Query parameters provided.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/cache/export
+// x-sample-call-output: |
+//    {
+//        "version": 1,
+//        "created_time": "2022-06-21T10:00:00Z",
+//        "checksum": "5b4c0e9f0b2c7d6a6e3f2b7f1c8d9e0a1b2c3d4e5f60718293a4b5c6d7e8f901",
+//        "records": {
+//            "platforms": [...],
+//            "platform_tcbs": [...],
+//            "pck_certs": [...],
+//            "pck_cert_chains": [...],
+//            "pck_crls": [...],
+//            "fmspc_tcb_infos": [...],
+//            "qe_identities": [...],
+//            "root_ca_crls": [...]
+//        }
 //    }
 // ---
+
+// swagger:operation POST /cache/import CacheArchive importCache
+// ---
+//
+// description: |
+//   This API imports an archive created by the export API. The archive version and checksum are validated
+//   before any record is imported. Cached records with the same keys are replaced by the archived records,
+//   and all the records are imported in a single transaction.
+//   A valid bearer token should be provided to authorize this REST call.
+//
+// security:
+//  - bearerAuth: []
+// consumes:
+//  - application/json
+// produces:
+//  - application/json
+// parameters:
+// - name: request body
+//   in: body
+//   required: true
+//   schema:
+//     "$ref": "#/definitions/CacheArchive"
+// responses:
+//   '200':
+//     description: Successfully imported the records of the archive.
+//     schema:
+//       "$ref": "#/definitions/CacheImportResult"
+//   '400':
+//     description: Invalid archive, unsupported archive version or checksum mismatch.
+//   '429':
+//     description: Rate limit of mutating APIs exceeded, request can be retried after the seconds in Retry-After header.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/cache/import
+// x-sample-call-output: |
+//    {
+//        "platforms": 2,
+//        "platform_tcbs": 2,
+//        "pck_certs": 2,
+//        "pck_cert_chains": 1,
+//        "pck_crls": 2,
+//        "fmspc_tcb_infos": 1,
+//        "qe_identities": 1,
+//        "root_ca_crls": 1
+//    }
+// ---
+
+// swagger:operation GET /cache/stats CacheStats getCacheStats
+// ---
+//
+// description: |
+//   This API reports the cache hits and misses of each collateral since the service started. A read of
+//   PCK certificate, PCK CRL, TCB info or QE identity served from the cache is a hit, a read which fetches
+//   the collateral from PCS is a miss. Fetches counts all the lazy fetches from PCS, including the ones
+//   done while refreshing the cache. latest_updated_time is the time the most recently updated record of
+//   the collateral was fetched or refreshed from PCS, it is left out when the collateral is not cached.
+//   consecutive_refresh_failures is the number of refreshes of the collateral failed since it was last refreshed.
+//   expiring is reported for PCK certificates only, as the number of platforms whose selected PCK certificate
+//   has expired or expires within SCS_PCK_CERT_EXPIRY_WARNING_DAYS.
+//   A valid bearer token should be provided to authorize this REST call.
+//
+// security:
+//  - bearerAuth: []
+// produces:
+//  - application/json
+// responses:
+//   '200':
+//     description: Successfully retrieved the cache stats.
+//     schema:
+//       type: array
+//       items:
+//         "$ref": "#/definitions/CollateralCacheStats"
+//   '400':
+//     description: Query parameters provided.
+//   '500':
+//     description: Failed to retrieve the latest updated collaterals.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/cache/stats
+// x-sample-call-output: |
+//    [
+//        {
+//            "collateral": "pckcert",
+//            "hits": 950,
+//            "misses": 50,
+//            "miss_rate": 0.05,
+//            "fetches": 120,
+//            "latest_updated_time": "2022-06-21T02:00:13.04Z",
+//            "consecutive_refresh_failures": 0,
+//            "expiring": 3
+//        },
+//        {
+//            "collateral": "pckcrl",
+//            "hits": 1000,
+//            "misses": 0,
+//            "miss_rate": 0,
+//            "fetches": 4,
+//            "latest_updated_time": "2022-06-21T02:00:10.71Z",
+//            "consecutive_refresh_failures": 0
+//        },
+//        {
+//            "collateral": "tcbinfo",
+//            "hits": 999,
+//            "misses": 1,
+//            "miss_rate": 0.001,
+//            "fetches": 3,
+//            "latest_updated_time": "2022-06-21T02:00:11.25Z",
+//            "consecutive_refresh_failures": 0
+//        },
+//        {
+//            "collateral": "qeidentity",
+//            "hits": 1000,
+//            "misses": 0,
+//            "miss_rate": 0,
+//            "fetches": 2,
+//            "latest_updated_time": "2022-06-21T02:00:11.83Z",
+//            "consecutive_refresh_failures": 0
+//        }
+//    ]
+// ---
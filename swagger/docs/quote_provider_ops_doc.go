@@ -13,6 +13,13 @@ type TcbInfoJSONResp struct {
 	Body resource.TcbInfoJSON
 }
 
+// CollateralBundleResp response payload
+// swagger:response CollateralBundleResp
+type CollateralBundleResp struct {
+	// in:body
+	Body resource.CollateralBundle
+}
+
 // swagger:operation GET /pckcert Certificates getPckCertificate
 // ---
 // description: |
@@ -84,6 +91,8 @@ type TcbInfoJSONResp struct {
 //   Retrieves the base64 encoded latest PCK Certificate Revocation List (CRL) for any SGX enabled platforms.
 //   A CRL is a list of revoked SGX PCK Certificates that are issued by Intel SGX Processor CA.
 //   The query parameter 'ca' should be provided as mandatory for this REST call.
+//   A cached CRL past its nextUpdate time is re-fetched from Intel PCS. If a valid CRL cannot be fetched,
+//   the cached CRL is returned and SGX-PCK-CRL-Expired response header is set to true.
 //
 // produces:
 //  - application/x-x509-ca-cert
@@ -103,6 +112,27 @@ type TcbInfoJSONResp struct {
 //    MIIBKTCB0QIBATAKBggqhkjOPQQDAjBxMSMwIQYDVQQDDBpJbnRlbCBTR1ggUENLIFByb2Nlc3NvciBDQTEaMBgGA1UECgwRSW50ZWwgQ29ycG9yYXRpb24xFDASBgNVBAcMC1NhbnRhIENsYXJhMQswCQYDVQQIDAJDQTELMAkGA1UEBhMCVVMXDTIwMTExMzA0Mzc1NVoXDTIwMTIxMzA0Mzc1NVqgLzAtMAoGA1UdFAQDAgEBMB8GA1UdIwQYMBaAFMHp3Hk19b3/LlphIQ0b13g7pUxaMAoGCCqGSM49BAMCA0cAMEQCIGWhZDw8yQ56EfFYNaIX96ZaEEurMTcyGlxsNYBjBUXAAiBNRVW27AQvV04YimDhB+evEHvgbyOjj1+7bemmpQZoOg==
 // ---
 
+// swagger:operation GET /rootcacrl Certificates getRootCaCrl
+// ---
+// description: |
+//   Retrieves the base64 encoded latest Certificate Revocation List (CRL) issued by Intel SGX Root CA.
+//   The CRL lists the revoked intermediate CA certificates, such as the PCK Processor and Platform CAs.
+//   A cached CRL past its nextUpdate time is re-fetched from Intel PCS. If a valid CRL cannot be fetched,
+//   the cached CRL is returned and SGX-Root-CA-CRL-Expired response header is set to true.
+//
+// produces:
+//  - application/x-x509-ca-cert
+// responses:
+//   '200':
+//     description: Successfully retrieved the Root CA CRL.
+//     schema:
+//       type: string
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/rootcacrl
+// x-sample-call-output: |
+//    MIIBzDCCAXMCAQEwCgYIKoZIzj0EAwIwcDEiMCAGA1UEAwwZSW50ZWwgU0dYIFBDSyBQbGF0Zm9ybSBDQTEaMBgGA1UECgwRSW50ZWwgQ29ycG9yYXRpb24xFDASBgNVBAcMC1NhbnRhIENsYXJhMQswCQYDVQQIDAJDQTELMAkGA1UEBhMCVVMXDTIyMDMxOTE0MTE1MVoXDTIyMDQxODE0MTE1MVowgaAwMwIUY58TmlBA/c/xkeik+xvwhu1gOXEXDTIyMDMxOTE0MTE1MVowDDAKBgNVHRUEAwoBATA0AhUAlZ1TP5JJ3B5RNUTNyDC/Gbfx8wEXDTIyMDMxOTE0MTE1MVowDDAKBgNVHRUEAwoBATAzAhQP2kOgC2jqebfC3q6sC0mL37KvkBcNMjIwMzE5MTQxMTUxWjAMMAoGA1UdFQQDCgEBoC8wLTAKBgNVHRQEAwIBATAfBgNVHSMEGDAWgBSVb13NvRvh6UBJydT0M84BVwveVDAKBggqhkjOPQQDAgNHADBEAiBi9RwbmK38uHy4CKr3pivHx55McabuTuEwMl2MFbFPiQIgGQi+I37kQACAl9bql4qx1N36YQUq12/PD41pUoYTF80=
+// ---
+
 // swagger:operation GET /tcb Certificates getTcbInfo
 // ---
 // description: |
@@ -213,6 +243,9 @@ type TcbInfoJSONResp struct {
 // ---
 // description: |
 //   Retrieves the Quote Identity information for Quoting Enclave issued by Intel for a platform.
+//   The issuer chain of the QE identity is returned in both Sgx-Enclave-Identity-Issuer-Chain, as
+//   by Intel PCS, and Sgx-Qe-Identity-Issuer-Chain headers. QE identity which is not cached is fetched
+//   from Intel PCS and cached.
 //
 // produces:
 //  - application/json
@@ -257,3 +290,66 @@ type TcbInfoJSONResp struct {
 //        "signature": "2c50f0f4297781594e4d86c864ef1bd6797ab77566c9ddc417330ca7f37456f2f998a44e8230c57c2c8f51258ce5044cf0ac0af58e5c953e466f51981dc1390c"
 //    }
 // ---
+
+// swagger:operation GET /collateral Certificates getCollateral
+// ---
+// description: |
+//   Retrieves every collateral needed to verify a quote of a platform in a single response: the PCK
+//   certificate selected for the platform with its issuer chain and TCBm, the PCK CRL, the TCB info and
+//   the QE identity, each with its issuer chain as PEM. Collaterals not cached are fetched from Intel PCS.
+//   The status of each collateral is reported as present, expired or missing, a missing collateral carries
+//   the error it could not be retrieved with instead of failing the whole request. The platform is
+//   identified by qeid and pceid, only the TCB info and QE identity are returned when just fmspc is provided.
+//
+// produces:
+//  - application/json
+// parameters:
+// - name: qeid
+//   description: Quoting Enclave ID specific to a platform.
+//   in: query
+//   type: string
+// - name: pceid
+//   description: Provisioning Certificate Enclave ID specific to a platform.
+//   in: query
+//   type: string
+// - name: fmspc
+//   description: FMSPC value of the platform, needed only when qeid and pceid are not provided.
+//   in: query
+//   type: string
+// responses:
+//   '200':
+//     description: Successfully retrieved the collaterals, see the status of each collateral.
+//     schema:
+//       "$ref": "#/definitions/CollateralBundle"
+//   '400':
+//     description: Invalid query parameters provided.
+//   '404':
+//     description: Platform with the provided qeid and pceid is not cached.
+//
+// x-sample-call-endpoint: https://scs.server.com:9000/scs/sgx/certification/v1/collateral?qeid=0f16dfa4033e66e642af8fe358c18751&pceid=0000
+// x-sample-call-output: |
+//    {
+//        "fmspc": "20606a000000",
+//        "ca": "processor",
+//        "pck_cert": {
+//            "status": "present",
+//            "data": "-----BEGIN CERTIFICATE-----\nMIIE8zCCBJigAwIBAgIUfr2dYzWeT...\n-----END CERTIFICATE-----\n",
+//            "issuer_chain": "-----BEGIN CERTIFICATE-----\nMIICmjCCAkCgAwIBAgIUWSPTp0qo...\n-----END CERTIFICATE-----\n",
+//            "tcbm": "020200000000000000000000000000000a00"
+//        },
+//        "pck_crl": {
+//            "status": "expired",
+//            "data": "3082...",
+//            "issuer_chain": "-----BEGIN CERTIFICATE-----\nMIICmjCCAkCgAwIBAgIUWSPTp0qo...\n-----END CERTIFICATE-----\n"
+//        },
+//        "tcb_info": {
+//            "status": "present",
+//            "data": "{\"tcbInfo\":{...},\"signature\":\"...\"}",
+//            "issuer_chain": "-----BEGIN CERTIFICATE-----\nMIICizCCAjKgAwIBAgIUfjiC1ftV...\n-----END CERTIFICATE-----\n"
+//        },
+//        "qe_identity": {
+//            "status": "missing",
+//            "error": "Error retrieving QEIdentity info"
+//        }
+//    }
+// ---
@@ -17,6 +17,7 @@ import (
 	"intel/isecl/scs/v5/repository/postgres"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -40,6 +41,9 @@ func (db Database) Run(c setup.Context) error {
 	envDBSSLMode, _ := c.GetenvString("SCS_DB_SSLMODE", "Database SSLMode")
 	envDBSSLCert, _ := c.GetenvString("SCS_DB_SSLCERT", "Database SSL Certificate")
 	envDBSSLCertSrc, _ := c.GetenvString("SCS_DB_SSLCERTSRC", "Database SSL Cert file source file")
+	envDBSchema, _ := c.GetenvString("SCS_DB_SCHEMA", "Database Schema")
+	envDBBackend, _ := c.GetenvString("SCS_DB_BACKEND", "Database Backend")
+	envDBSqlitePath, _ := c.GetenvString("SCS_DB_SQLITE_PATH", "SQLite Database Path")
 
 	fs := flag.NewFlagSet("database", flag.ContinueOnError)
 	fs.StringVar(&db.Config.Postgres.Hostname, "db-host", envHost, "Database Hostname")
@@ -50,11 +54,26 @@ func (db Database) Run(c setup.Context) error {
 	fs.StringVar(&db.Config.Postgres.SSLMode, "db-sslmode", envDBSSLMode, "SSL mode of connection to database")
 	fs.StringVar(&db.Config.Postgres.SSLCert, "db-sslcert", envDBSSLCert, "SSL certificate of database")
 	fs.StringVar(&envDBSSLCertSrc, "db-sslcertsrc", envDBSSLCertSrc, "DB SSL certificate to be copied from")
+	fs.StringVar(&db.Config.Postgres.Schema, "db-schema", envDBSchema, "Database Schema")
+	fs.StringVar(&db.Config.DBBackend, "db-backend", envDBBackend, "Database Backend")
+	fs.StringVar(&db.Config.SqlitePath, "db-sqlite-path", envDBSqlitePath, "SQLite Database Path")
 	err := fs.Parse(db.Flags)
 	if err != nil {
 		return errors.Wrap(err, "setup database: failed to parse cmd flags")
 	}
 
+	db.Config.DBBackend = strings.TrimSpace(db.Config.DBBackend)
+	switch db.Config.DBBackend {
+	case "":
+		db.Config.DBBackend = constants.DBBackendPostgres
+	case constants.DBBackendPostgres:
+	case constants.DBBackendSqlite:
+		return db.setupSqlite()
+	default:
+		return errors.New("setup database: database backend should be either " +
+			constants.DBBackendPostgres + " or " + constants.DBBackendSqlite)
+	}
+
 	var validErr error
 
 	validErr = validation.ValidateHostname(db.Config.Postgres.Hostname)
@@ -73,6 +92,14 @@ func (db Database) Run(c setup.Context) error {
 		return validErr
 	}
 
+	if db.Config.Postgres.Schema != "" {
+		validErr = validation.ValidateIdentifier(db.Config.Postgres.Schema)
+		if validErr != nil {
+			slog.Errorf("%s: Failed to connect to db, Input validation failed for database schema", commLogMsg.BadConnection)
+			return validErr
+		}
+	}
+
 	db.Config.Postgres.SSLMode, db.Config.Postgres.SSLCert, validErr = configureDBSSLParams(
 		db.Config.Postgres.SSLMode, envDBSSLCertSrc,
 		db.Config.Postgres.SSLCert)
@@ -82,7 +109,7 @@ func (db Database) Run(c setup.Context) error {
 	}
 
 	pg := db.Config.Postgres
-	p, err := postgres.Open(pg.Hostname, pg.Port, pg.DBName, pg.Username, pg.Password, pg.SSLMode, pg.SSLCert)
+	p, err := postgres.Open(pg.Hostname, pg.Port, pg.DBName, pg.Username, pg.Password, pg.SSLMode, pg.SSLCert, pg.Schema)
 	if err != nil {
 		return errors.Wrap(err, "setup database: failed to open database")
 	}
@@ -97,16 +124,42 @@ func (db Database) Run(c setup.Context) error {
 	return nil
 }
 
+// setupSqlite creates and migrates the SQLite database, Postgres settings are not needed with it
+func (db Database) setupSqlite() error {
+	db.Config.SqlitePath = strings.TrimSpace(db.Config.SqlitePath)
+	if db.Config.SqlitePath == "" {
+		db.Config.SqlitePath = constants.DefaultSqliteDBFile
+	}
+	if !filepath.IsAbs(db.Config.SqlitePath) {
+		return errors.New("setup database: path of SQLite database should be absolute")
+	}
+
+	p, err := postgres.OpenSqlite(db.Config.SqlitePath)
+	if err != nil {
+		return errors.Wrap(err, "setup database: failed to open database")
+	}
+	defer p.Close()
+	err = p.Migrate()
+	if err != nil {
+		return errors.Wrap(err, "setup database: failed to migrate database")
+	}
+	err = db.Config.Save()
+	if err != nil {
+		return errors.Wrap(err, "setup database: failed to save config")
+	}
+	return nil
+}
+
 func configureDBSSLParams(sslMode, sslCertSrc, sslCert string) (mode, cert string, err error) {
-	sslMode = strings.TrimSpace(strings.ToLower(sslMode))
 	sslCert = strings.TrimSpace(sslCert)
 	sslCertSrc = strings.TrimSpace(sslCertSrc)
 
-	if sslMode != "allow" && sslMode != "prefer" && sslMode != "require" && sslMode != "verify-ca" {
-		sslMode = "verify-full"
+	sslMode, err = postgres.ParseSSLMode(sslMode)
+	if err != nil {
+		return "", "", err
 	}
 
-	if sslMode == "verify-ca" || sslMode == "verify-full" {
+	if postgres.SSLModeVerifiesServer(sslMode) {
 		// cover different scenarios
 		if sslCertSrc == "" && sslCert != "" {
 			if _, err := os.Stat(sslCert); os.IsNotExist(err) {
@@ -136,6 +189,12 @@ func configureDBSSLParams(sslMode, sslCertSrc, sslCert string) (mode, cert strin
 }
 
 func (db Database) Validate(c setup.Context) error {
+	if db.Config.DBBackend == constants.DBBackendSqlite {
+		if db.Config.SqlitePath == "" {
+			return errors.New("database setup: SQLite database path is not set")
+		}
+		return nil
+	}
 	if db.Config.Postgres.Hostname == "" {
 		return errors.New("database setup: Hostname is not set")
 	}
@@ -7,6 +7,7 @@ package tasks
 import (
 	"intel/isecl/lib/common/v5/setup"
 	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
 	"math/rand"
 	"os"
 	"testing"
@@ -139,6 +140,14 @@ func TestConfigureDBSSLParams(t *testing.T) {
 
 	_, _, err = configureDBSSLParams("verify-ca", "testsslCert", "")
 	assert.NotNil(t, err)
+
+	// invalid mode is rejected instead of being replaced with verify-full
+	_, _, err = configureDBSSLParams("verify", "", testsslCert)
+	assert.NotNil(t, err)
+
+	mode, _, err := configureDBSSLParams(" Require ", "", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "require", mode)
 }
 
 const letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
@@ -151,3 +160,49 @@ func RandStringBytes() string {
 	}
 	return string(b)
 }
+
+func TestDatabaseSetupSchema(t *testing.T) {
+	c := config.Configuration{}
+	s := Database{
+		Flags:         []string{"-db-host=hostname", "-db-port=5432", "-db-user=" + RandStringBytes(), "-db-pass=" + RandStringBytes(), "-db-name=scs_db", "-db-schema=scs"},
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, "scs", c.Postgres.Schema)
+
+	s.Flags = []string{"-db-host=hostname", "-db-port=5432", "-db-user=" + RandStringBytes(), "-db-pass=" + RandStringBytes(), "-db-name=scs_db", "-db-schema=scs;drop"}
+	err = s.Run(ctx)
+	assert.EqualError(t, err, "Invalid identifier")
+}
+
+func TestDatabaseSetupBackend(t *testing.T) {
+	c := config.Configuration{}
+	s := Database{
+		Flags:         []string{"-db-backend=mysql"},
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.Error(t, err)
+
+	// Postgres settings are not needed with the sqlite backend, the database is opened only
+	// when SCS is built with the sqlite tag
+	s.Flags = []string{"-db-backend=sqlite", "-db-sqlite-path=scs.db"}
+	err = s.Run(ctx)
+	assert.EqualError(t, err, "setup database: path of SQLite database should be absolute")
+
+	sqlitePath := t.TempDir() + "/scs.db"
+	s.Flags = []string{"-db-backend=sqlite", "-db-sqlite-path=" + sqlitePath}
+	_ = s.Run(ctx)
+	assert.Equal(t, constants.DBBackendSqlite, c.DBBackend)
+	assert.Equal(t, sqlitePath, c.SqlitePath)
+
+	c.SqlitePath = ""
+	assert.Error(t, s.Validate(ctx))
+	c.SqlitePath = constants.DefaultSqliteDBFile
+	assert.NoError(t, s.Validate(ctx))
+}
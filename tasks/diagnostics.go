@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2020 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package tasks
+
+import (
+	"flag"
+	"fmt"
+	"intel/isecl/lib/common/v5/setup"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/domain"
+	"intel/isecl/scs/v5/repository/postgres"
+	"intel/isecl/scs/v5/resource"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Diagnostics validates the saved configuration of SCS end to end, by checking that the
+// database and Intel PCS server can be reached and that TCB info can be fetched from PCS
+type Diagnostics struct {
+	Flags         []string
+	Config        *config.Configuration
+	ConsoleWriter io.Writer
+	HttpClient    domain.HttpClient
+}
+
+func (d Diagnostics) Run(c setup.Context) error {
+	fmt.Fprintln(d.ConsoleWriter, "Running diagnostics...")
+
+	fs := flag.NewFlagSet("diagnostics", flag.ContinueOnError)
+	fmspc := fs.String("fmspc", constants.DefaultDiagnosticsFmspc, "FMSPC value used to fetch TCB info from Intel PCS server")
+	err := fs.Parse(d.Flags)
+	if err != nil {
+		return errors.Wrap(err, "tasks/Diagnostics:Run() Could not parse input flags")
+	}
+
+	if err = d.Config.ValidateProvServerInfo(); err != nil {
+		return errors.Wrap(err, "tasks/Diagnostics:Run() Invalid Intel PCS server url")
+	}
+
+	client := d.HttpClient
+	if client == nil {
+		if err = d.Config.ValidateProvServerTLS(); err != nil {
+			return errors.Wrap(err, "tasks/Diagnostics:Run() Invalid Intel PCS server TLS configuration")
+		}
+		client = domain.NewPCCSClient(d.Config.ProvServerInsecureSkipVerify, d.Config.ProvServerTransport)
+	}
+
+	failed := false
+	check := func(name string, err error) {
+		if err != nil {
+			failed = true
+			fmt.Fprintf(d.ConsoleWriter, "%-40s FAIL: %s\n", name, err.Error())
+			return
+		}
+		fmt.Fprintf(d.ConsoleWriter, "%-40s PASS\n", name)
+	}
+
+	check("Database connection", d.verifyDatabase())
+	check("Intel PCS server connection", resource.VerifyProvServerConnection(d.Config, &client))
+	check("TCB info fetch for fmspc "+*fmspc, resource.VerifyFmspcTcbInfo(*fmspc, d.Config, &client))
+
+	if failed {
+		return errors.New("tasks/Diagnostics:Run() One or more diagnostics checks failed")
+	}
+	fmt.Fprintln(d.ConsoleWriter, "All diagnostics checks passed")
+	return nil
+}
+
+func (d Diagnostics) verifyDatabase() error {
+	p, err := postgres.OpenConfigured(d.Config)
+	if err != nil {
+		return errors.Wrap(err, "failed to open database")
+	}
+	defer p.Close()
+	return p.Ping()
+}
+
+func (d Diagnostics) Validate(c setup.Context) error {
+	return nil
+}
@@ -0,0 +1,44 @@
+/*
+ * Copyright (C) 2020 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package tasks
+
+import (
+	"bytes"
+	"intel/isecl/lib/common/v5/setup"
+	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/domain/mocks"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnostics(t *testing.T) {
+	c := config.Configuration{}
+	c.Postgres.Hostname = "localhost"
+	c.Postgres.Port = 1
+	c.Postgres.SSLMode = "disable"
+	c.ProvServerInfo.ProvServerURL = "https://localhost/sgx/certification/v3"
+
+	var out bytes.Buffer
+	s := Diagnostics{
+		Flags:         []string{"-fmspc=20606a000000"},
+		Config:        &c,
+		ConsoleWriter: &out,
+		HttpClient:    mocks.NewClientMock(http.StatusOK),
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.Error(t, err)
+	assert.Regexp(t, "Database connection +FAIL", out.String())
+	assert.Regexp(t, "TCB info fetch for fmspc 20606a000000 +PASS", out.String())
+
+	out.Reset()
+	s.HttpClient = mocks.NewClientMock(http.StatusBadRequest)
+	err = s.Run(ctx)
+	assert.Error(t, err)
+	assert.Regexp(t, "Intel PCS server connection +FAIL", out.String())
+	assert.Regexp(t, "TCB info fetch for fmspc 20606a000000 +FAIL", out.String())
+}
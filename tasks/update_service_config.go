@@ -10,14 +10,43 @@ import (
 	"intel/isecl/lib/common/v5/setup"
 	"intel/isecl/scs/v5/config"
 	"intel/isecl/scs/v5/constants"
+	"intel/isecl/scs/v5/resource"
 	"io"
+	"math"
+	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
+var validTcbStatuses = map[string]bool{
+	"UpToDate":                          true,
+	"SWHardeningNeeded":                 true,
+	"ConfigurationNeeded":               true,
+	"ConfigurationAndSWHardeningNeeded": true,
+	"OutOfDate":                         true,
+	"OutOfDateConfigurationNeeded":      true,
+	"Revoked":                           true,
+}
+
+// methods of SCS apis which can be allowed from browsers
+var corsMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodHead:   true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodDelete: true,
+}
+
+var cachingModels = map[string]int{
+	"lazy":     constants.LazyCachingModel,
+	"precache": constants.PreCachingModel,
+}
+
 type Update_Service_Config struct {
 	Flags         []string
 	Config        *config.Configuration
@@ -96,16 +125,55 @@ func (u Update_Service_Config) Run(c setup.Context) error {
 	intelProvURL, err := c.GetenvString("INTEL_PROVISIONING_SERVER", "Intel ECDSA Provisioning Server URL")
 	if err != nil {
 		intelProvURL = constants.DefaultIntelProvServerURL
-	} else if _, err = url.ParseRequestURI(intelProvURL); err != nil {
-		return errors.Wrap(err, "SaveConfiguration() INTEL_PROVISIONING_SERVER provided is invalid")
 	}
-	u.Config.ProvServerInfo.ProvServerURL = intelProvURL
+	// a url ending with the api version, as configured before the certification path and the api
+	// version could be set separately, is split into them
+	provServerInfo := &u.Config.ProvServerInfo
+	provServerInfo.ProvServerURL, provServerInfo.CertificationPath, provServerInfo.APIVersion = config.SplitProvServerURL(intelProvURL)
+	if provURL, err := url.Parse(intelProvURL); err == nil && strings.Trim(provURL.Path, "/") == "" {
+		provServerInfo.CertificationPath = constants.DefaultProvServerCertPath
+		provServerInfo.APIVersion = constants.DefaultProvServerAPIVersion
+	}
+	certificationPath, err := c.GetenvString("INTEL_PROVISIONING_SERVER_CERTIFICATION_PATH", "Intel ECDSA Provisioning Server certification path")
+	if err == nil && certificationPath != "" {
+		provServerInfo.CertificationPath = certificationPath
+	}
+	apiVersion, err := c.GetenvString("INTEL_PROVISIONING_SERVER_API_VERSION", "Intel ECDSA Provisioning Server API version")
+	if err == nil && apiVersion != "" {
+		provServerInfo.APIVersion = apiVersion
+	}
+	provServerInfo.TcbUpdate = constants.StandardTcbUpdate
+	tcbUpdate, err := c.GetenvString("INTEL_PROVISIONING_SERVER_TCB_UPDATE", "Intel ECDSA Provisioning Server update type of TcbInfo and QE identity")
+	if err == nil && tcbUpdate != "" {
+		provServerInfo.TcbUpdate = tcbUpdate
+	}
 
-	intelProvAPIKey, err := c.GetenvString("INTEL_PROVISIONING_SERVER_API_KEY", "Intel ECDSA Provisioning Server API Subscription key")
-	if err != nil {
-		return errors.Wrap(err, "Intel API Subscription key not provided")
+	// keys are loaded from a secret file or environment variable referenced by the source when SCS
+	// starts, instead of being stored in plaintext in the configuration file
+	keySource, err := c.GetenvString("INTEL_PROVISIONING_SERVER_API_KEY_SOURCE", "Intel ECDSA Provisioning Server API Subscription key source")
+	u.Config.ProvServerInfo.APISubscriptionkeySource = ""
+	if err == nil && strings.TrimSpace(keySource) != "" {
+		u.Config.ProvServerInfo.APISubscriptionkeySource = strings.TrimSpace(keySource)
+		if err = u.Config.LoadSubscriptionKeys(); err != nil {
+			return errors.Wrap(err, "SaveConfiguration() INTEL_PROVISIONING_SERVER_API_KEY_SOURCE provided is invalid")
+		}
+	} else {
+		intelProvAPIKey, err := c.GetenvString("INTEL_PROVISIONING_SERVER_API_KEY", "Intel ECDSA Provisioning Server API Subscription key")
+		if err != nil {
+			return errors.Wrap(err, "Intel API Subscription key not provided")
+		}
+		// multiple comma separated keys can be provided to spread the load on PCS across keys
+		u.Config.ProvServerInfo.APISubscriptionkeys = config.SplitSubscriptionKeys(intelProvAPIKey)
+		if len(u.Config.ProvServerInfo.APISubscriptionkeys) == 0 {
+			return errors.New("Intel API Subscription key not provided")
+		}
+		u.Config.ProvServerInfo.APISubscriptionkey = u.Config.ProvServerInfo.APISubscriptionkeys[0]
+		slog.Warn("tasks/update_service_config:Run() INTEL_PROVISIONING_SERVER_API_KEY is stored in plaintext in the configuration file " +
+			"and is deprecated, use INTEL_PROVISIONING_SERVER_API_KEY_SOURCE instead")
+	}
+	if err = u.Config.ValidateProvServerInfo(); err != nil {
+		return errors.Wrap(err, "SaveConfiguration() INTEL_PROVISIONING_SERVER provided is invalid")
 	}
-	u.Config.ProvServerInfo.APISubscriptionkey = intelProvAPIKey
 
 	logLevel, err := c.GetenvString("SCS_LOGLEVEL", "SCS Log Level")
 	if err != nil {
@@ -179,6 +247,345 @@ func (u Update_Service_Config) Run(c setup.Context) error {
 		u.Config.RetryCount = constants.DefaultRetrycount
 	}
 
+	acceptedTcbStatuses, err := c.GetenvString("SCS_ACCEPTED_TCB_STATUSES", "TCB Statuses treated as UpToDate")
+	if err != nil || strings.TrimSpace(acceptedTcbStatuses) == "" {
+		acceptedTcbStatuses = constants.DefaultAcceptedTcbStatuses
+	}
+	u.Config.AcceptedTcbStatuses = nil
+	for _, status := range strings.Split(acceptedTcbStatuses, ",") {
+		status = strings.TrimSpace(status)
+		if !validTcbStatuses[status] {
+			return errors.New("SaveConfiguration() SCS_ACCEPTED_TCB_STATUSES contains invalid TCB status: " + status)
+		}
+		u.Config.AcceptedTcbStatuses = append(u.Config.AcceptedTcbStatuses, status)
+	}
+
+	u.Config.MinTcbCompSvns, u.Config.MinPceSvn, err = u.tcbBaseline(c)
+	if err != nil {
+		return err
+	}
+
+	u.Config.AllowUnknownPlatformInfoFields = false
+	allowUnknownFields, err := c.GetenvString("SCS_ALLOW_UNKNOWN_PLATFORM_INFO_FIELDS", "Allow unknown fields in pushed platform info")
+	if err == nil && allowUnknownFields != "" {
+		u.Config.AllowUnknownPlatformInfoFields, err = strconv.ParseBool(allowUnknownFields)
+		if err != nil {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid value provided for SCS_ALLOW_UNKNOWN_PLATFORM_INFO_FIELDS setting it to the default value\n")
+			u.Config.AllowUnknownPlatformInfoFields = false
+		}
+	}
+
+	duplicatePushPolicy, err := c.GetenvString("SCS_DUPLICATE_PLATFORM_PUSH_POLICY", "Policy for platforms pushed again with a different enc_ppid")
+	if err != nil || strings.TrimSpace(duplicatePushPolicy) == "" {
+		duplicatePushPolicy = constants.DuplicatePlatformPushIgnore
+	}
+	duplicatePushPolicy = strings.TrimSpace(duplicatePushPolicy)
+	if duplicatePushPolicy != constants.DuplicatePlatformPushIgnore && duplicatePushPolicy != constants.DuplicatePlatformPushUpdate {
+		return errors.New("SaveConfiguration() SCS_DUPLICATE_PLATFORM_PUSH_POLICY should be either " +
+			constants.DuplicatePlatformPushIgnore + " or " + constants.DuplicatePlatformPushUpdate)
+	}
+	u.Config.DuplicatePlatformPushPolicy = duplicatePushPolicy
+
+	manifestPolicy, err := c.GetenvString("SCS_ENC_PPID_WITH_MANIFEST_POLICY", "Policy for platforms pushed with both enc_ppid and manifest")
+	if err != nil || strings.TrimSpace(manifestPolicy) == "" {
+		manifestPolicy = constants.EncPpidWithManifestPrefer
+	}
+	manifestPolicy = strings.TrimSpace(manifestPolicy)
+	if manifestPolicy != constants.EncPpidWithManifestPrefer && manifestPolicy != constants.EncPpidWithManifestReject {
+		return errors.New("SaveConfiguration() SCS_ENC_PPID_WITH_MANIFEST_POLICY should be either " +
+			constants.EncPpidWithManifestPrefer + " or " + constants.EncPpidWithManifestReject)
+	}
+	u.Config.EncPpidWithManifestPolicy = manifestPolicy
+
+	notAvailablePolicy, err := c.GetenvString("SCS_PCK_CERTS_NOT_AVAILABLE_POLICY", "Policy for platforms pushed while no pck cert is available from PCS")
+	if err != nil || strings.TrimSpace(notAvailablePolicy) == "" {
+		notAvailablePolicy = constants.PckCertsNotAvailableReject
+	}
+	notAvailablePolicy = strings.TrimSpace(notAvailablePolicy)
+	if notAvailablePolicy != constants.PckCertsNotAvailableReject && notAvailablePolicy != constants.PckCertsNotAvailableRetry {
+		return errors.New("SaveConfiguration() SCS_PCK_CERTS_NOT_AVAILABLE_POLICY should be either " +
+			constants.PckCertsNotAvailableReject + " or " + constants.PckCertsNotAvailableRetry)
+	}
+	u.Config.PckCertsNotAvailablePolicy = notAvailablePolicy
+
+	readsPolicy, err := c.GetenvString("SCS_READS_DURING_REFRESH_POLICY", "Policy for read apis while a full refresh is in progress")
+	if err != nil || strings.TrimSpace(readsPolicy) == "" {
+		readsPolicy = constants.ReadsDuringRefreshServe
+	}
+	readsPolicy = strings.TrimSpace(readsPolicy)
+	if readsPolicy != constants.ReadsDuringRefreshServe && readsPolicy != constants.ReadsDuringRefreshUnavailable {
+		return errors.New("SaveConfiguration() SCS_READS_DURING_REFRESH_POLICY should be either " +
+			constants.ReadsDuringRefreshServe + " or " + constants.ReadsDuringRefreshUnavailable)
+	}
+	u.Config.ReadsDuringRefreshPolicy = readsPolicy
+
+	cachingModel, err := c.GetenvString("SCS_CACHING_MODEL", "Caching model of the collaterals of pushed platforms")
+	if err != nil || strings.TrimSpace(cachingModel) == "" {
+		cachingModel = "lazy"
+	}
+	model, ok := cachingModels[strings.TrimSpace(cachingModel)]
+	if !ok {
+		return errors.New("SaveConfiguration() SCS_CACHING_MODEL should be either lazy or precache")
+	}
+	u.Config.CachingModel = model
+
+	u.Config.PrefetchCollateralsOnStart = false
+	prefetchCollaterals, err := c.GetenvString("SCS_PREFETCH_COLLATERALS_ON_START", "Prefetch QE identity and TCB info at startup")
+	if err == nil && prefetchCollaterals != "" {
+		u.Config.PrefetchCollateralsOnStart, err = strconv.ParseBool(prefetchCollaterals)
+		if err != nil {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid value provided for SCS_PREFETCH_COLLATERALS_ON_START setting it to the default value\n")
+			u.Config.PrefetchCollateralsOnStart = false
+		}
+	}
+
+	u.Config.RefreshAllPckCrls = false
+	refreshAllPckCrls, err := c.GetenvString("SCS_REFRESH_ALL_PCK_CRLS", "Refresh PCK CRLs of both processor and platform CAs")
+	if err == nil && refreshAllPckCrls != "" {
+		u.Config.RefreshAllPckCrls, err = strconv.ParseBool(refreshAllPckCrls)
+		if err != nil {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid value provided for SCS_REFRESH_ALL_PCK_CRLS setting it to the default value\n")
+			u.Config.RefreshAllPckCrls = false
+		}
+	}
+
+	u.Config.PcsAuditEnabled = false
+	pcsAudit, err := c.GetenvString("SCS_PCS_AUDIT_ENABLED", "Retain raw Intel PCS server responses for audit")
+	if err == nil && pcsAudit != "" {
+		u.Config.PcsAuditEnabled, err = strconv.ParseBool(pcsAudit)
+		if err != nil {
+			return errors.Wrap(err, "SaveConfiguration() SCS_PCS_AUDIT_ENABLED provided is invalid")
+		}
+	}
+
+	pcsAuditMaxRecords, err := c.GetenvInt("SCS_PCS_AUDIT_MAX_RECORDS", "Max number of Intel PCS server responses retained for audit")
+	if err == nil && pcsAuditMaxRecords > 0 {
+		u.Config.PcsAuditMaxRecords = pcsAuditMaxRecords
+	} else if u.Config.PcsAuditMaxRecords <= 0 {
+		u.Config.PcsAuditMaxRecords = constants.DefaultPcsAuditMaxRecords
+	}
+
+	u.Config.PlatformMaxAgeDays = 0
+	platformMaxAgeDays, err := c.GetenvString("SCS_PLATFORM_MAX_AGE_DAYS", "Days after which platforms not pushed are purged")
+	if err == nil && platformMaxAgeDays != "" {
+		u.Config.PlatformMaxAgeDays, err = strconv.Atoi(platformMaxAgeDays)
+		if err != nil {
+			return errors.Wrap(err, "SaveConfiguration() SCS_PLATFORM_MAX_AGE_DAYS provided is invalid")
+		}
+		if u.Config.PlatformMaxAgeDays != 0 && u.Config.PlatformMaxAgeDays < constants.MinPlatformMaxAgeDays {
+			return errors.Errorf("SaveConfiguration() SCS_PLATFORM_MAX_AGE_DAYS should be 0 to disable purging or at least %d",
+				constants.MinPlatformMaxAgeDays)
+		}
+	}
+
+	u.Config.PckCertExpiryWarningDays = constants.DefaultPckCertExpiryWarnDays
+	expiryWarningDays, err := c.GetenvString("SCS_PCK_CERT_EXPIRY_WARNING_DAYS", "Days before expiry from which PCK certs are reported as expiring")
+	if err == nil && expiryWarningDays != "" {
+		u.Config.PckCertExpiryWarningDays, err = strconv.Atoi(expiryWarningDays)
+		if err != nil || u.Config.PckCertExpiryWarningDays <= 0 {
+			return errors.New("SaveConfiguration() SCS_PCK_CERT_EXPIRY_WARNING_DAYS should be a positive integer")
+		}
+	}
+
+	u.Config.RefreshBatchSize = constants.RefreshPlatformsPageSize
+	refreshBatchSize, err := c.GetenvString("SCS_REFRESH_BATCH_SIZE", "Number of platforms whose PCK certs are refreshed in a batch")
+	if err == nil && refreshBatchSize != "" {
+		u.Config.RefreshBatchSize, err = strconv.Atoi(refreshBatchSize)
+		if err != nil || u.Config.RefreshBatchSize <= 0 || u.Config.RefreshBatchSize > constants.MaxRefreshBatchSize {
+			return errors.Errorf("SaveConfiguration() SCS_REFRESH_BATCH_SIZE should be between 1 and %d",
+				constants.MaxRefreshBatchSize)
+		}
+	}
+
+	u.Config.RefreshGraceAttempts = constants.DefaultRefreshGraceAttempts
+	graceAttempts, err := c.GetenvString("SCS_REFRESH_GRACE_ATTEMPTS", "Attempts of refresh of a failed collateral within the same refresh")
+	if err == nil && graceAttempts != "" {
+		u.Config.RefreshGraceAttempts, err = strconv.Atoi(graceAttempts)
+		if err != nil || u.Config.RefreshGraceAttempts < 0 {
+			return errors.New("SaveConfiguration() SCS_REFRESH_GRACE_ATTEMPTS should be a non negative integer")
+		}
+	}
+	u.Config.RefreshGraceDelaySeconds = constants.DefaultRefreshGraceDelaySecs
+	graceDelay, err := c.GetenvString("SCS_REFRESH_GRACE_DELAY_SECONDS", "Delay in seconds before refresh of a failed collateral is attempted again")
+	if err == nil && graceDelay != "" {
+		u.Config.RefreshGraceDelaySeconds, err = strconv.Atoi(graceDelay)
+		if err != nil || u.Config.RefreshGraceDelaySeconds < 0 {
+			return errors.New("SaveConfiguration() SCS_REFRESH_GRACE_DELAY_SECONDS should be a non negative integer")
+		}
+	}
+
+	alert := &u.Config.RefreshFailureAlert
+	alert.Threshold = constants.DefaultRefreshFailureThreshold
+	alertThreshold, err := c.GetenvString("SCS_REFRESH_FAILURE_ALERT_THRESHOLD", "Consecutive refresh failures of a collateral after which it is alerted")
+	if err == nil && alertThreshold != "" {
+		alert.Threshold, err = strconv.Atoi(alertThreshold)
+		if err != nil || alert.Threshold < 0 {
+			return errors.New("SaveConfiguration() SCS_REFRESH_FAILURE_ALERT_THRESHOLD should be a non negative integer")
+		}
+	}
+	alert.WebhookURL = ""
+	alertWebhook, err := c.GetenvString("SCS_REFRESH_FAILURE_ALERT_WEBHOOK", "URL to which refresh failure alerts are posted")
+	if err == nil && alertWebhook != "" {
+		webhookURL, err := url.Parse(alertWebhook)
+		if err != nil || (webhookURL.Scheme != "http" && webhookURL.Scheme != "https") || webhookURL.Host == "" {
+			return errors.New("SaveConfiguration() SCS_REFRESH_FAILURE_ALERT_WEBHOOK should be an absolute http or https url")
+		}
+		alert.WebhookURL = alertWebhook
+	}
+
+	u.Config.TimerRefresh.SkipPckCerts, err = u.timerRefreshSkipped(c, "SCS_TIMER_REFRESH_PCK_CERTS", "Refresh PCK certs on the refresh timer")
+	if err != nil {
+		return err
+	}
+	u.Config.TimerRefresh.SkipCollaterals, err = u.timerRefreshSkipped(c, "SCS_TIMER_REFRESH_COLLATERALS", "Refresh PCK CRLs, TcbInfo and QE identity on the refresh timer")
+	if err != nil {
+		return err
+	}
+
+	u.Config.CacheTcbStatus = false
+	cacheTcbStatus, err := c.GetenvString("SCS_CACHE_TCB_STATUS", "Precompute and cache tcb status of platforms")
+	if err == nil && cacheTcbStatus != "" {
+		u.Config.CacheTcbStatus, err = strconv.ParseBool(cacheTcbStatus)
+		if err != nil {
+			return errors.Wrap(err, "SaveConfiguration() SCS_CACHE_TCB_STATUS provided is invalid")
+		}
+	}
+
+	u.Config.NormalizeTcbInfo = false
+	normalizeTcbInfo, err := c.GetenvString("SCS_NORMALIZE_TCB_INFO", "Store TCB levels of TcbInfo in normalized table")
+	if err == nil && normalizeTcbInfo != "" {
+		u.Config.NormalizeTcbInfo, err = strconv.ParseBool(normalizeTcbInfo)
+		if err != nil {
+			return errors.Wrap(err, "SaveConfiguration() SCS_NORMALIZE_TCB_INFO provided is invalid")
+		}
+	}
+
+	u.Config.PcsDebugLogEnabled = false
+	pcsDebugLog, err := c.GetenvString("SCS_PCS_DEBUG_LOG_ENABLED", "Log Intel PCS server requests and responses for troubleshooting")
+	if err == nil && pcsDebugLog != "" {
+		u.Config.PcsDebugLogEnabled, err = strconv.ParseBool(pcsDebugLog)
+		if err != nil {
+			return errors.Wrap(err, "SaveConfiguration() SCS_PCS_DEBUG_LOG_ENABLED provided is invalid")
+		}
+	}
+
+	u.Config.TracingOtlpEndpoint = ""
+	tracingEndpoint, err := c.GetenvString("SCS_TRACING_OTLP_ENDPOINT", "OTLP/HTTP endpoint to which traces are exported")
+	if err == nil && tracingEndpoint != "" {
+		endpointURL, err := url.Parse(tracingEndpoint)
+		if err != nil || (endpointURL.Scheme != "http" && endpointURL.Scheme != "https") || endpointURL.Host == "" {
+			return errors.New("SaveConfiguration() SCS_TRACING_OTLP_ENDPOINT should be an absolute http or https url")
+		}
+		u.Config.TracingOtlpEndpoint = tracingEndpoint
+	}
+
+	u.Config.SgxRootCAFile = ""
+	sgxRootCAFile, err := c.GetenvString("SCS_SGX_ROOT_CA_FILE", "SGX Root CA certificate trusted for PCK certificate issuer chains")
+	if err == nil && sgxRootCAFile != "" {
+		if _, err = resource.LoadSgxRootCA(sgxRootCAFile); err != nil {
+			return errors.Wrap(err, "SaveConfiguration() SCS_SGX_ROOT_CA_FILE provided is invalid")
+		}
+		u.Config.SgxRootCAFile = sgxRootCAFile
+	}
+
+	signaturePolicy, err := c.GetenvString("SCS_SIGNATURE_VERIFICATION_POLICY", "Policy for collaterals failing signature verification")
+	if err != nil || strings.TrimSpace(signaturePolicy) == "" {
+		signaturePolicy = constants.SignatureVerificationWarn
+	}
+	signaturePolicy = strings.TrimSpace(signaturePolicy)
+	if signaturePolicy != constants.SignatureVerificationEnforce && signaturePolicy != constants.SignatureVerificationWarn &&
+		signaturePolicy != constants.SignatureVerificationOff {
+		return errors.New("SaveConfiguration() SCS_SIGNATURE_VERIFICATION_POLICY should be one of " + constants.SignatureVerificationEnforce +
+			", " + constants.SignatureVerificationWarn + " or " + constants.SignatureVerificationOff)
+	}
+	u.Config.SignatureVerificationPolicy = signaturePolicy
+	if signaturePolicy == constants.SignatureVerificationOff {
+		fmt.Fprintln(u.ConsoleWriter, "WARNING: signature verification of collaterals returned by Intel PCS server is disabled")
+	}
+
+	u.Config.HTTP2Enabled = nil
+	http2Enabled, err := c.GetenvString("SCS_HTTP2_ENABLED", "Negotiate HTTP/2 with clients")
+	if err == nil && http2Enabled != "" {
+		enabled, err := strconv.ParseBool(http2Enabled)
+		if err != nil {
+			return errors.Wrap(err, "SaveConfiguration() SCS_HTTP2_ENABLED provided is invalid")
+		}
+		u.Config.HTTP2Enabled = &enabled
+	}
+
+	u.Config.DevMode = false
+	devMode, err := c.GetenvString("SCS_DEV_MODE", "SGX Caching Service Development Mode")
+	if err == nil && devMode != "" {
+		u.Config.DevMode, err = strconv.ParseBool(devMode)
+		if err != nil {
+			return errors.Wrap(err, "SaveConfiguration() SCS_DEV_MODE provided is invalid")
+		}
+	}
+
+	u.Config.ProvServerInsecureSkipVerify = false
+	insecureSkipVerify, err := c.GetenvString("INTEL_PROVISIONING_SERVER_INSECURE_SKIP_VERIFY", "Skip TLS verification of Intel ECDSA Provisioning Server")
+	if err == nil && insecureSkipVerify != "" {
+		u.Config.ProvServerInsecureSkipVerify, err = strconv.ParseBool(insecureSkipVerify)
+		if err != nil {
+			return errors.Wrap(err, "SaveConfiguration() INTEL_PROVISIONING_SERVER_INSECURE_SKIP_VERIFY provided is invalid")
+		}
+	}
+	if err = u.Config.ValidateProvServerTLS(); err != nil {
+		return errors.Wrap(err, "SaveConfiguration() INTEL_PROVISIONING_SERVER_INSECURE_SKIP_VERIFY can be enabled only with SCS_DEV_MODE")
+	}
+	if u.Config.ProvServerInsecureSkipVerify {
+		fmt.Fprintln(u.ConsoleWriter, "WARNING: TLS certificate verification of Intel ECDSA Provisioning Server is disabled. This is INSECURE and must not be used in production")
+	}
+
+	transport := &u.Config.ProvServerTransport
+	transport.RequestTimeout = u.pcsTransportTimeout(c, "SCS_PCS_REQUEST_TIMEOUT", "Intel PCS client Request Timeout",
+		constants.DefaultPcsClientTimeout)
+	transport.DialTimeout = u.pcsTransportTimeout(c, "SCS_PCS_DIAL_TIMEOUT", "Intel PCS client Dial Timeout",
+		constants.DefaultPcsDialTimeout)
+	transport.TLSHandshakeTimeout = u.pcsTransportTimeout(c, "SCS_PCS_TLS_HANDSHAKE_TIMEOUT", "Intel PCS client TLS Handshake Timeout",
+		constants.DefaultPcsTLSHandshakeTimeout)
+	transport.ResponseHeaderTimeout = u.pcsTransportTimeout(c, "SCS_PCS_RESPONSE_HEADER_TIMEOUT", "Intel PCS client Response Header Timeout",
+		constants.DefaultPcsRespHeaderTimeout)
+	transport.IdleConnTimeout = u.pcsTransportTimeout(c, "SCS_PCS_IDLE_CONN_TIMEOUT", "Intel PCS client Idle Connection Timeout",
+		constants.DefaultPcsIdleConnTimeout)
+	maxIdleConnsPerHost, err := c.GetenvInt("SCS_PCS_MAX_IDLE_CONNS_PER_HOST", "Intel PCS client Max Idle Connections")
+	if err == nil && maxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	} else {
+		transport.MaxIdleConnsPerHost = constants.DefaultPcsMaxIdleConnsPerHost
+	}
+	maxConcurrentRequests, err := c.GetenvInt("SCS_PCS_MAX_CONCURRENT_REQUESTS", "Intel PCS client Max Concurrent Requests")
+	if err == nil && maxConcurrentRequests > 0 {
+		transport.MaxConcurrentRequests = maxConcurrentRequests
+	} else {
+		transport.MaxConcurrentRequests = constants.DefaultPcsRequestConcurrency
+	}
+
+	rateLimit := &u.Config.WriteRateLimit
+	rateLimit.Rate, rateLimit.Burst, err = u.writeRateLimit(c, "SCS_WRITE_RATE_LIMIT", "SCS_WRITE_RATE_BURST",
+		constants.DefaultWriteRateLimit, constants.DefaultWriteRateBurst)
+	if err != nil {
+		return err
+	}
+	rateLimit.ClientRate, rateLimit.ClientBurst, err = u.writeRateLimit(c, "SCS_WRITE_CLIENT_RATE_LIMIT", "SCS_WRITE_CLIENT_RATE_BURST",
+		constants.DefaultWriteClientRateLimit, constants.DefaultWriteClientRateBurst)
+	if err != nil {
+		return err
+	}
+
+	u.Config.TrustedTokens.Issuers = u.envList(c, "SCS_TRUSTED_JWT_ISSUERS", "Comma separated JWT issuers trusted by SCS")
+	u.Config.TrustedTokens.Audiences = u.envList(c, "SCS_TRUSTED_JWT_AUDIENCES", "Comma separated JWT audiences accepted by SCS")
+
+	u.Config.RouteTimeouts, err = u.routeTimeouts(c)
+	if err != nil {
+		return err
+	}
+
+	u.Config.CORS, err = u.cors(c)
+	if err != nil {
+		return err
+	}
+
 	aasAPIURL, err := c.GetenvString("AAS_API_URL", "AAS Base URL")
 	if err == nil && aasAPIURL != "" {
 		if _, err = url.ParseRequestURI(aasAPIURL); err != nil {
@@ -198,6 +605,181 @@ func (u Update_Service_Config) Run(c setup.Context) error {
 	return nil
 }
 
+// writeRateLimit reads the rate and burst of a rate limit of the mutating apis from env, default
+// values are used when they are not set. A rate of 0 disables the limit
+func (u Update_Service_Config) writeRateLimit(c setup.Context, rateEnv, burstEnv string, defaultRate float64, defaultBurst int) (float64, int, error) {
+	rate := defaultRate
+	value, err := c.GetenvString(rateEnv, "Requests per second allowed on mutating apis")
+	if err == nil && value != "" {
+		rate, err = strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+			return 0, 0, errors.Errorf("SaveConfiguration() %s should be a non negative number", rateEnv)
+		}
+	}
+	if rate == 0 {
+		return 0, 0, nil
+	}
+
+	burst := defaultBurst
+	value, err = c.GetenvString(burstEnv, "Burst of requests allowed on mutating apis")
+	if err == nil && value != "" {
+		burst, err = strconv.Atoi(value)
+		if err != nil || burst <= 0 {
+			return 0, 0, errors.Errorf("SaveConfiguration() %s should be a positive integer", burstEnv)
+		}
+	}
+	return rate, burst, nil
+}
+
+// tcbBaseline reads the minimum comp svns, in order from sgxtcbcomp01svn, and the minimum pcesvn
+// of the TCB baseline enforced by tcbstatus API
+func (u Update_Service_Config) tcbBaseline(c setup.Context) ([]int, int, error) {
+	entries := u.envList(c, "SCS_MIN_TCB_COMP_SVNS", "Comma separated minimum SGX TCB comp svns of an UpToDate platform")
+	if len(entries) > constants.MaxTcbLevels {
+		return nil, 0, errors.Errorf("SaveConfiguration() SCS_MIN_TCB_COMP_SVNS should have at most %d svns", constants.MaxTcbLevels)
+	}
+	var minCompSvns []int
+	for _, entry := range entries {
+		svn, err := strconv.ParseUint(entry, 10, 8)
+		if err != nil {
+			return nil, 0, errors.Errorf("SaveConfiguration() SCS_MIN_TCB_COMP_SVNS svn %s should be between 0 and 255", entry)
+		}
+		minCompSvns = append(minCompSvns, int(svn))
+	}
+
+	minPceSvn := 0
+	value, err := c.GetenvString("SCS_MIN_PCE_SVN", "Minimum pcesvn of an UpToDate platform")
+	if err == nil && strings.TrimSpace(value) != "" {
+		svn, err := strconv.ParseUint(strings.TrimSpace(value), 10, 16)
+		if err != nil {
+			return nil, 0, errors.New("SaveConfiguration() SCS_MIN_PCE_SVN should be between 0 and 65535")
+		}
+		minPceSvn = int(svn)
+	}
+	return minCompSvns, minPceSvn, nil
+}
+
+// routeTimeouts reads the comma separated route=duration pairs of SCS_ROUTE_TIMEOUTS, routes are path
+// templates after the api prefix such as /refreshes. A timeout cannot exceed the server write timeout,
+// as the response of a route could not be written past it
+func (u Update_Service_Config) routeTimeouts(c setup.Context) (map[string]time.Duration, error) {
+	entries := u.envList(c, "SCS_ROUTE_TIMEOUTS", "Comma separated route=duration timeouts of SCS apis")
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	timeouts := make(map[string]time.Duration, len(entries))
+	for _, entry := range entries {
+		route, value, found := strings.Cut(entry, "=")
+		route = strings.TrimSpace(route)
+		if !found || !strings.HasPrefix(route, "/") {
+			return nil, errors.Errorf("SaveConfiguration() SCS_ROUTE_TIMEOUTS entry %s should be a route=duration pair", entry)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || timeout <= 0 {
+			return nil, errors.Errorf("SaveConfiguration() SCS_ROUTE_TIMEOUTS timeout of %s should be a positive duration", route)
+		}
+		if u.Config.WriteTimeout > 0 && timeout > u.Config.WriteTimeout {
+			return nil, errors.Errorf("SaveConfiguration() SCS_ROUTE_TIMEOUTS timeout of %s should not exceed SCS_SERVER_WRITE_TIMEOUT %s",
+				route, u.Config.WriteTimeout)
+		}
+		timeouts[route] = timeout
+	}
+	return timeouts, nil
+}
+
+// cors reads the origins allowed to call SCS apis from browsers, along with the methods and headers
+// they can use. Methods default to GET so that only the read apis are enabled
+func (u Update_Service_Config) cors(c setup.Context) (config.CORS, error) {
+	cors := config.CORS{AllowedOrigins: u.envList(c, "SCS_CORS_ALLOWED_ORIGINS", "Comma separated origins allowed to call SCS apis from browsers")}
+	if len(cors.AllowedOrigins) == 0 {
+		return cors, nil
+	}
+	anyOrigin := false
+	for _, origin := range cors.AllowedOrigins {
+		if origin == "*" {
+			anyOrigin = true
+			continue
+		}
+		originURL, err := url.Parse(origin)
+		if err != nil || (originURL.Scheme != "http" && originURL.Scheme != "https") || originURL.Host == "" ||
+			strings.TrimSuffix(originURL.Path, "/") != "" {
+			return config.CORS{}, errors.Errorf("SaveConfiguration() SCS_CORS_ALLOWED_ORIGINS origin %s should be * or an http or https origin", origin)
+		}
+	}
+
+	cors.AllowedMethods = []string{http.MethodGet}
+	if methods := u.envList(c, "SCS_CORS_ALLOWED_METHODS", "Comma separated methods of SCS apis allowed from browsers"); len(methods) > 0 {
+		cors.AllowedMethods = nil
+		for _, method := range methods {
+			method = strings.ToUpper(method)
+			if !corsMethods[method] {
+				return config.CORS{}, errors.Errorf("SaveConfiguration() SCS_CORS_ALLOWED_METHODS method %s is not supported", method)
+			}
+			cors.AllowedMethods = append(cors.AllowedMethods, method)
+		}
+	}
+	cors.AllowedHeaders = u.envList(c, "SCS_CORS_ALLOWED_HEADERS", "Comma separated request headers allowed from browsers")
+
+	value, err := c.GetenvString("SCS_CORS_ALLOW_CREDENTIALS", "Allow browsers to send credentials to SCS apis")
+	if err == nil && value != "" {
+		cors.AllowCredentials, err = strconv.ParseBool(value)
+		if err != nil {
+			return config.CORS{}, errors.Wrap(err, "SaveConfiguration() SCS_CORS_ALLOW_CREDENTIALS provided is invalid")
+		}
+	}
+	// browsers reject credentialed responses allowing any origin
+	if cors.AllowCredentials && anyOrigin {
+		return config.CORS{}, errors.New("SaveConfiguration() SCS_CORS_ALLOW_CREDENTIALS cannot be enabled with * in SCS_CORS_ALLOWED_ORIGINS")
+	}
+	return cors, nil
+}
+
+// timerRefreshSkipped reads whether the refresh timer refreshes a collateral from envName, which defaults
+// to true, and returns whether the collateral is skipped instead
+func (u Update_Service_Config) timerRefreshSkipped(c setup.Context, envName, description string) (bool, error) {
+	value, err := c.GetenvString(envName, description)
+	if err != nil || value == "" {
+		return false, nil
+	}
+	refresh, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.Wrapf(err, "SaveConfiguration() %s provided is invalid", envName)
+	}
+	return !refresh, nil
+}
+
+// envList reads a comma separated list from env, empty entries are dropped and nil is returned
+// when it is not set
+func (u Update_Service_Config) envList(c setup.Context, envName, description string) []string {
+	value, err := c.GetenvString(envName, description)
+	if err != nil {
+		return nil
+	}
+	var list []string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// pcsTransportTimeout reads a connection level timeout of Intel PCS client from env, the
+// default value is used when it is not set or is not a valid positive duration
+func (u Update_Service_Config) pcsTransportTimeout(c setup.Context, envName, description string, defaultValue time.Duration) time.Duration {
+	value, err := c.GetenvString(envName, description)
+	if err != nil {
+		return defaultValue
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		fmt.Fprintf(u.ConsoleWriter, "Invalid duration provided for %s setting it to the default value\n", envName)
+		return defaultValue
+	}
+	return timeout
+}
+
 func (s Update_Service_Config) Validate(c setup.Context) error {
 	return nil
 }
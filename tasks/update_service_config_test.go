@@ -5,11 +5,22 @@
 package tasks
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"intel/isecl/lib/common/v5/setup"
 	"intel/isecl/scs/v5/config"
+	"intel/isecl/scs/v5/constants"
+	"io/ioutil"
+	"math/big"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -175,3 +186,1193 @@ func TestServerSetupWithInvalidValues(t *testing.T) {
 	err = s.Validate(ctx)
 	assert.NoError(t, err)
 }
+
+func TestServerSetupAcceptedTcbStatuses(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_ACCEPTED_TCB_STATUSES")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"UpToDate", "ConfigurationNeeded"}, c.AcceptedTcbStatuses)
+
+	os.Setenv("SCS_ACCEPTED_TCB_STATUSES", "UpToDate")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"UpToDate"}, c.AcceptedTcbStatuses)
+
+	os.Setenv("SCS_ACCEPTED_TCB_STATUSES", "UpToDate,Unknown")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupAllowUnknownPlatformInfoFields(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_ALLOW_UNKNOWN_PLATFORM_INFO_FIELDS")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.False(t, c.AllowUnknownPlatformInfoFields)
+
+	os.Setenv("SCS_ALLOW_UNKNOWN_PLATFORM_INFO_FIELDS", "true")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.True(t, c.AllowUnknownPlatformInfoFields)
+
+	os.Setenv("SCS_ALLOW_UNKNOWN_PLATFORM_INFO_FIELDS", "abc")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.False(t, c.AllowUnknownPlatformInfoFields)
+}
+
+func TestServerSetupDuplicatePlatformPushPolicy(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_DUPLICATE_PLATFORM_PUSH_POLICY")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.DuplicatePlatformPushIgnore, c.DuplicatePlatformPushPolicy)
+
+	os.Setenv("SCS_DUPLICATE_PLATFORM_PUSH_POLICY", "update")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.DuplicatePlatformPushUpdate, c.DuplicatePlatformPushPolicy)
+
+	os.Setenv("SCS_DUPLICATE_PLATFORM_PUSH_POLICY", "replace")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupEncPpidWithManifestPolicy(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_ENC_PPID_WITH_MANIFEST_POLICY")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.EncPpidWithManifestPrefer, c.EncPpidWithManifestPolicy)
+
+	os.Setenv("SCS_ENC_PPID_WITH_MANIFEST_POLICY", "reject")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.EncPpidWithManifestReject, c.EncPpidWithManifestPolicy)
+
+	os.Setenv("SCS_ENC_PPID_WITH_MANIFEST_POLICY", "encppid")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupPckCertsNotAvailablePolicy(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_PCK_CERTS_NOT_AVAILABLE_POLICY")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.PckCertsNotAvailableReject, c.PckCertsNotAvailablePolicy)
+
+	os.Setenv("SCS_PCK_CERTS_NOT_AVAILABLE_POLICY", "retry")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.PckCertsNotAvailableRetry, c.PckCertsNotAvailablePolicy)
+
+	os.Setenv("SCS_PCK_CERTS_NOT_AVAILABLE_POLICY", "queue")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupSignatureVerificationPolicy(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_SIGNATURE_VERIFICATION_POLICY")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.SignatureVerificationWarn, c.SignatureVerificationPolicy)
+
+	for _, policy := range []string{constants.SignatureVerificationEnforce, constants.SignatureVerificationOff, constants.SignatureVerificationWarn} {
+		os.Setenv("SCS_SIGNATURE_VERIFICATION_POLICY", policy)
+		err = s.Run(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, policy, c.SignatureVerificationPolicy)
+	}
+
+	os.Setenv("SCS_SIGNATURE_VERIFICATION_POLICY", "strict")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupProvServerInsecureSkipVerify(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_DEV_MODE")
+		os.Unsetenv("INTEL_PROVISIONING_SERVER_INSECURE_SKIP_VERIFY")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.False(t, c.ProvServerInsecureSkipVerify)
+
+	os.Setenv("INTEL_PROVISIONING_SERVER_INSECURE_SKIP_VERIFY", "true")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+
+	os.Setenv("SCS_DEV_MODE", "true")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.True(t, c.ProvServerInsecureSkipVerify)
+	assert.True(t, c.DevMode)
+
+	os.Setenv("INTEL_PROVISIONING_SERVER_INSECURE_SKIP_VERIFY", "abc")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupMultipleSubscriptionKeys(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234, def5678")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc1234", c.ProvServerInfo.APISubscriptionkey)
+	assert.Equal(t, []string{"abc1234", "def5678"}, c.ProvServerInfo.APISubscriptionkeys)
+
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", " , ")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupSubscriptionKeySource(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Unsetenv("INTEL_PROVISIONING_SERVER_API_KEY")
+	secretFile := filepath.Join(t.TempDir(), "pcs-api-key")
+	assert.NoError(t, ioutil.WriteFile(secretFile, []byte("filekey1,filekey2\n"), 0600))
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("INTEL_PROVISIONING_SERVER_API_KEY_SOURCE")
+		os.Unsetenv("SCS_TEST_PCS_API_KEY")
+		os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY_SOURCE", "file:"+secretFile)
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"filekey1", "filekey2"}, c.SubscriptionKeys())
+	saved := config.Load("testconfig.yml")
+	assert.Equal(t, "file:"+secretFile, saved.ProvServerInfo.APISubscriptionkeySource)
+	assert.Empty(t, saved.ProvServerInfo.APISubscriptionkeys)
+
+	os.Setenv("SCS_TEST_PCS_API_KEY", "envkey")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY_SOURCE", "env:SCS_TEST_PCS_API_KEY")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"envkey"}, c.SubscriptionKeys())
+
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY_SOURCE", "env:SCS_TEST_PCS_API_KEY_UNSET")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+
+	// plaintext key is still accepted without a source
+	os.Unsetenv("INTEL_PROVISIONING_SERVER_API_KEY_SOURCE")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, c.ProvServerInfo.APISubscriptionkeySource)
+	assert.Equal(t, []string{"abc1234"}, config.Load("testconfig.yml").SubscriptionKeys())
+}
+
+func TestServerSetupPrefetchCollateralsOnStart(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_PREFETCH_COLLATERALS_ON_START")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.False(t, c.PrefetchCollateralsOnStart)
+
+	os.Setenv("SCS_PREFETCH_COLLATERALS_ON_START", "true")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.True(t, c.PrefetchCollateralsOnStart)
+
+	os.Setenv("SCS_PREFETCH_COLLATERALS_ON_START", "abc")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.False(t, c.PrefetchCollateralsOnStart)
+}
+
+func TestServerSetupReadsDuringRefreshPolicy(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_READS_DURING_REFRESH_POLICY")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.ReadsDuringRefreshServe, c.ReadsDuringRefreshPolicy)
+
+	os.Setenv("SCS_READS_DURING_REFRESH_POLICY", "unavailable")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.ReadsDuringRefreshUnavailable, c.ReadsDuringRefreshPolicy)
+
+	os.Setenv("SCS_READS_DURING_REFRESH_POLICY", "snapshot")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupRefreshAllPckCrls(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_REFRESH_ALL_PCK_CRLS")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.False(t, c.RefreshAllPckCrls)
+
+	os.Setenv("SCS_REFRESH_ALL_PCK_CRLS", "true")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.True(t, c.RefreshAllPckCrls)
+}
+
+func TestServerSetupPcsAudit(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_PCS_AUDIT_ENABLED")
+		os.Unsetenv("SCS_PCS_AUDIT_MAX_RECORDS")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.False(t, c.PcsAuditEnabled)
+	assert.Equal(t, constants.DefaultPcsAuditMaxRecords, c.PcsAuditMaxRecords)
+
+	os.Setenv("SCS_PCS_AUDIT_ENABLED", "true")
+	os.Setenv("SCS_PCS_AUDIT_MAX_RECORDS", "500")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.True(t, c.PcsAuditEnabled)
+	assert.Equal(t, 500, c.PcsAuditMaxRecords)
+
+	os.Setenv("SCS_PCS_AUDIT_ENABLED", "abc")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupPcsDebugLog(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_PCS_DEBUG_LOG_ENABLED")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.False(t, c.PcsDebugLogEnabled)
+
+	os.Setenv("SCS_PCS_DEBUG_LOG_ENABLED", "true")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.True(t, c.PcsDebugLogEnabled)
+
+	os.Setenv("SCS_PCS_DEBUG_LOG_ENABLED", "abc")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupRefreshGrace(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_REFRESH_GRACE_ATTEMPTS")
+		os.Unsetenv("SCS_REFRESH_GRACE_DELAY_SECONDS")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.DefaultRefreshGraceAttempts, c.RefreshGraceAttempts)
+	assert.Equal(t, constants.DefaultRefreshGraceDelaySecs, c.RefreshGraceDelaySeconds)
+
+	os.Setenv("SCS_REFRESH_GRACE_ATTEMPTS", "0")
+	os.Setenv("SCS_REFRESH_GRACE_DELAY_SECONDS", "5")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, c.RefreshGraceAttempts)
+	assert.Equal(t, 5, c.RefreshGraceDelaySeconds)
+
+	os.Setenv("SCS_REFRESH_GRACE_ATTEMPTS", "-1")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+
+	os.Setenv("SCS_REFRESH_GRACE_ATTEMPTS", "2")
+	os.Setenv("SCS_REFRESH_GRACE_DELAY_SECONDS", "abc")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupTracingOtlpEndpoint(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_TRACING_OTLP_ENDPOINT")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, c.TracingOtlpEndpoint)
+
+	os.Setenv("SCS_TRACING_OTLP_ENDPOINT", "http://otel-collector:4318")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://otel-collector:4318", c.TracingOtlpEndpoint)
+
+	os.Setenv("SCS_TRACING_OTLP_ENDPOINT", "otel-collector:4318")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupCacheTcbStatus(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_CACHE_TCB_STATUS")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.False(t, c.CacheTcbStatus)
+
+	os.Setenv("SCS_CACHE_TCB_STATUS", "true")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.True(t, c.CacheTcbStatus)
+
+	os.Setenv("SCS_CACHE_TCB_STATUS", "abc")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupNormalizeTcbInfo(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_NORMALIZE_TCB_INFO")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.False(t, c.NormalizeTcbInfo)
+
+	os.Setenv("SCS_NORMALIZE_TCB_INFO", "true")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.True(t, c.NormalizeTcbInfo)
+
+	os.Setenv("SCS_NORMALIZE_TCB_INFO", "abc")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupPcsTransport(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_PCS_REQUEST_TIMEOUT")
+		os.Unsetenv("SCS_PCS_DIAL_TIMEOUT")
+		os.Unsetenv("SCS_PCS_TLS_HANDSHAKE_TIMEOUT")
+		os.Unsetenv("SCS_PCS_MAX_IDLE_CONNS_PER_HOST")
+		os.Unsetenv("SCS_PCS_MAX_CONCURRENT_REQUESTS")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.DefaultPcsClientTimeout, c.ProvServerTransport.RequestTimeout)
+	assert.Equal(t, constants.DefaultPcsDialTimeout, c.ProvServerTransport.DialTimeout)
+	assert.Equal(t, constants.DefaultPcsIdleConnTimeout, c.ProvServerTransport.IdleConnTimeout)
+	assert.Equal(t, constants.DefaultPcsMaxIdleConnsPerHost, c.ProvServerTransport.MaxIdleConnsPerHost)
+	assert.Equal(t, constants.DefaultPcsRequestConcurrency, c.ProvServerTransport.MaxConcurrentRequests)
+
+	os.Setenv("SCS_PCS_REQUEST_TIMEOUT", "10s")
+	os.Setenv("SCS_PCS_DIAL_TIMEOUT", "1s")
+	os.Setenv("SCS_PCS_TLS_HANDSHAKE_TIMEOUT", "abc")
+	os.Setenv("SCS_PCS_MAX_IDLE_CONNS_PER_HOST", "20")
+	os.Setenv("SCS_PCS_MAX_CONCURRENT_REQUESTS", "4")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, c.ProvServerTransport.RequestTimeout)
+	assert.Equal(t, time.Second, c.ProvServerTransport.DialTimeout)
+	assert.Equal(t, constants.DefaultPcsTLSHandshakeTimeout, c.ProvServerTransport.TLSHandshakeTimeout)
+	assert.Equal(t, 20, c.ProvServerTransport.MaxIdleConnsPerHost)
+	assert.Equal(t, 4, c.ProvServerTransport.MaxConcurrentRequests)
+}
+
+func TestServerSetupPlatformMaxAge(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_PLATFORM_MAX_AGE_DAYS")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, c.PlatformMaxAgeDays)
+
+	os.Setenv("SCS_PLATFORM_MAX_AGE_DAYS", "90")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 90, c.PlatformMaxAgeDays)
+
+	os.Setenv("SCS_PLATFORM_MAX_AGE_DAYS", "1")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+
+	os.Setenv("SCS_PLATFORM_MAX_AGE_DAYS", "abc")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupPckCertExpiryWarningDays(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_PCK_CERT_EXPIRY_WARNING_DAYS")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.DefaultPckCertExpiryWarnDays, c.PckCertExpiryWarningDays)
+
+	os.Setenv("SCS_PCK_CERT_EXPIRY_WARNING_DAYS", "60")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 60, c.PckCertExpiryWarningDays)
+
+	os.Setenv("SCS_PCK_CERT_EXPIRY_WARNING_DAYS", "0")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupRefreshBatchSize(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_REFRESH_BATCH_SIZE")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.RefreshPlatformsPageSize, c.RefreshBatchSize)
+
+	os.Setenv("SCS_REFRESH_BATCH_SIZE", "100")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, c.RefreshBatchSize)
+
+	for _, size := range []string{"0", "abc", "10001"} {
+		os.Setenv("SCS_REFRESH_BATCH_SIZE", size)
+		err = s.Run(ctx)
+		assert.Error(t, err)
+	}
+}
+
+func TestServerSetupSgxRootCAFile(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test SGX Root CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	rootCAFile, err := ioutil.TempFile("", "sgx-root-ca.pem")
+	assert.NoError(t, err)
+	assert.NoError(t, pem.Encode(rootCAFile, &pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	rootCAFile.Close()
+	defer func() {
+		os.Unsetenv("SCS_SGX_ROOT_CA_FILE")
+		os.Remove(rootCAFile.Name())
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, c.SgxRootCAFile)
+
+	os.Setenv("SCS_SGX_ROOT_CA_FILE", rootCAFile.Name())
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, rootCAFile.Name(), c.SgxRootCAFile)
+
+	os.Setenv("SCS_SGX_ROOT_CA_FILE", "testconfig.yml")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+
+	os.Setenv("SCS_SGX_ROOT_CA_FILE", "/nonexistent/sgx-root-ca.pem")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupWriteRateLimit(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_WRITE_RATE_LIMIT")
+		os.Unsetenv("SCS_WRITE_RATE_BURST")
+		os.Unsetenv("SCS_WRITE_CLIENT_RATE_LIMIT")
+		os.Unsetenv("SCS_WRITE_CLIENT_RATE_BURST")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, config.WriteRateLimit{Rate: constants.DefaultWriteRateLimit, Burst: constants.DefaultWriteRateBurst,
+		ClientRate: constants.DefaultWriteClientRateLimit, ClientBurst: constants.DefaultWriteClientRateBurst}, c.WriteRateLimit)
+
+	os.Setenv("SCS_WRITE_RATE_LIMIT", "0")
+	os.Setenv("SCS_WRITE_CLIENT_RATE_LIMIT", "0.5")
+	os.Setenv("SCS_WRITE_CLIENT_RATE_BURST", "3")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, config.WriteRateLimit{ClientRate: 0.5, ClientBurst: 3}, c.WriteRateLimit)
+
+	os.Setenv("SCS_WRITE_RATE_LIMIT", "-1")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+
+	os.Setenv("SCS_WRITE_RATE_LIMIT", "10")
+	os.Setenv("SCS_WRITE_RATE_BURST", "0")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupHTTP2(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_HTTP2_ENABLED")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, c.HTTP2Enabled)
+
+	os.Setenv("SCS_HTTP2_ENABLED", "false")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	if assert.NotNil(t, c.HTTP2Enabled) {
+		assert.False(t, *c.HTTP2Enabled)
+	}
+
+	os.Setenv("SCS_HTTP2_ENABLED", "abc")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupRouteTimeouts(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_ROUTE_TIMEOUTS")
+		os.Unsetenv("SCS_SERVER_WRITE_TIMEOUT")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, c.RouteTimeouts)
+
+	os.Setenv("SCS_ROUTE_TIMEOUTS", "/refreshes=60s, /tcbstatus = 5s,/platforms/{qeid}=2s")
+	err = s.Run(ctx)
+	assert.Error(t, err, "timeout exceeding the default write timeout")
+
+	os.Setenv("SCS_SERVER_WRITE_TIMEOUT", "60s")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{"/refreshes": time.Minute, "/tcbstatus": 5 * time.Second,
+		"/platforms/{qeid}": 2 * time.Second}, c.RouteTimeouts)
+
+	for _, invalid := range []string{"/refreshes", "refreshes=60s", "/refreshes=abc", "/refreshes=0s"} {
+		os.Setenv("SCS_ROUTE_TIMEOUTS", invalid)
+		err = s.Run(ctx)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestServerSetupTrustedTokens(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_TRUSTED_JWT_ISSUERS")
+		os.Unsetenv("SCS_TRUSTED_JWT_AUDIENCES")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, c.TrustedTokens.Issuers)
+	assert.Empty(t, c.TrustedTokens.Audiences)
+
+	os.Setenv("SCS_TRUSTED_JWT_ISSUERS", "AAS JWT Issuer")
+	os.Setenv("SCS_TRUSTED_JWT_AUDIENCES", "SCS, SHVS,")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"AAS JWT Issuer"}, c.TrustedTokens.Issuers)
+	assert.Equal(t, []string{"SCS", "SHVS"}, c.TrustedTokens.Audiences)
+}
+
+func TestServerSetupProvServerAPIVersion(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("INTEL_PROVISIONING_SERVER")
+		os.Unsetenv("INTEL_PROVISIONING_SERVER_API_VERSION")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	os.Setenv("INTEL_PROVISIONING_SERVER", "https://api.trustedservices.intel.com")
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://api.trustedservices.intel.com/sgx/certification/v3/pckcerts", c.ProvServerAPIURL("pckcerts"))
+
+	os.Setenv("INTEL_PROVISIONING_SERVER", "https://localhost:8081/sgx/certification/v3/")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://localhost:8081", c.ProvServerInfo.ProvServerURL)
+	assert.Equal(t, "https://localhost:8081/sgx/certification/v3/tcb", c.ProvServerAPIURL("tcb"))
+
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_VERSION", "v4")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://localhost:8081/sgx/certification/v4/tcb", c.ProvServerAPIURL("tcb"))
+
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_VERSION", "4")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupCachingModel(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_CACHING_MODEL")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.LazyCachingModel, c.GetCacheModel())
+
+	os.Setenv("SCS_CACHING_MODEL", "precache")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.PreCachingModel, c.GetCacheModel())
+
+	os.Setenv("SCS_CACHING_MODEL", "eager")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupRefreshFailureAlert(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_REFRESH_FAILURE_ALERT_THRESHOLD")
+		os.Unsetenv("SCS_REFRESH_FAILURE_ALERT_WEBHOOK")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, config.RefreshFailureAlert{Threshold: constants.DefaultRefreshFailureThreshold}, c.RefreshFailureAlert)
+
+	os.Setenv("SCS_REFRESH_FAILURE_ALERT_THRESHOLD", "5")
+	os.Setenv("SCS_REFRESH_FAILURE_ALERT_WEBHOOK", "https://alerts.example.com/scs")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, config.RefreshFailureAlert{Threshold: 5, WebhookURL: "https://alerts.example.com/scs"}, c.RefreshFailureAlert)
+
+	os.Setenv("SCS_REFRESH_FAILURE_ALERT_WEBHOOK", "alerts.example.com")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+
+	os.Unsetenv("SCS_REFRESH_FAILURE_ALERT_WEBHOOK")
+	os.Setenv("SCS_REFRESH_FAILURE_ALERT_THRESHOLD", "-1")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupTimerRefresh(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_TIMER_REFRESH_PCK_CERTS")
+		os.Unsetenv("SCS_TIMER_REFRESH_COLLATERALS")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, config.TimerRefresh{}, c.TimerRefresh)
+
+	os.Setenv("SCS_TIMER_REFRESH_PCK_CERTS", "false")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, config.TimerRefresh{SkipPckCerts: true}, c.TimerRefresh)
+
+	os.Setenv("SCS_TIMER_REFRESH_PCK_CERTS", "true")
+	os.Setenv("SCS_TIMER_REFRESH_COLLATERALS", "false")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, config.TimerRefresh{SkipCollaterals: true}, c.TimerRefresh)
+
+	os.Setenv("SCS_TIMER_REFRESH_PCK_CERTS", "false")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, config.TimerRefresh{SkipPckCerts: true, SkipCollaterals: true}, c.TimerRefresh)
+
+	os.Setenv("SCS_TIMER_REFRESH_COLLATERALS", "never")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupCORS(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_CORS_ALLOWED_ORIGINS")
+		os.Unsetenv("SCS_CORS_ALLOWED_METHODS")
+		os.Unsetenv("SCS_CORS_ALLOWED_HEADERS")
+		os.Unsetenv("SCS_CORS_ALLOW_CREDENTIALS")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, config.CORS{}, c.CORS)
+
+	os.Setenv("SCS_CORS_ALLOWED_ORIGINS", "https://dashboard.example.com, http://localhost:8080/")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, config.CORS{AllowedOrigins: []string{"https://dashboard.example.com", "http://localhost:8080/"},
+		AllowedMethods: []string{"GET"}}, c.CORS)
+
+	os.Setenv("SCS_CORS_ALLOWED_METHODS", "get,post")
+	os.Setenv("SCS_CORS_ALLOWED_HEADERS", "Content-Type")
+	os.Setenv("SCS_CORS_ALLOW_CREDENTIALS", "true")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, config.CORS{AllowedOrigins: []string{"https://dashboard.example.com", "http://localhost:8080/"},
+		AllowedMethods: []string{"GET", "POST"}, AllowedHeaders: []string{"Content-Type"}, AllowCredentials: true}, c.CORS)
+
+	os.Setenv("SCS_CORS_ALLOWED_METHODS", "GET,PATCH")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+
+	os.Setenv("SCS_CORS_ALLOWED_METHODS", "GET")
+	os.Setenv("SCS_CORS_ALLOW_CREDENTIALS", "sometimes")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+
+	os.Setenv("SCS_CORS_ALLOW_CREDENTIALS", "true")
+	os.Setenv("SCS_CORS_ALLOWED_ORIGINS", "*")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+
+	os.Unsetenv("SCS_CORS_ALLOW_CREDENTIALS")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"*"}, c.CORS.AllowedOrigins)
+
+	os.Setenv("SCS_CORS_ALLOWED_ORIGINS", "https://dashboard.example.com/scs")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+
+	os.Setenv("SCS_CORS_ALLOWED_ORIGINS", "dashboard.example.com")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupProvServerTcbUpdate(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("INTEL_PROVISIONING_SERVER_TCB_UPDATE")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.StandardTcbUpdate, c.GetTcbUpdate())
+
+	os.Setenv("INTEL_PROVISIONING_SERVER_TCB_UPDATE", "early")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.EarlyTcbUpdate, c.GetTcbUpdate())
+
+	os.Setenv("INTEL_PROVISIONING_SERVER_TCB_UPDATE", "latest")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
+func TestServerSetupTcbBaseline(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_PORT", "9000")
+	os.Setenv("INTEL_PROVISIONING_SERVER_API_KEY", "abc1234")
+	c := *config.Load("testconfig.yml")
+	defer func() {
+		os.Unsetenv("SCS_MIN_TCB_COMP_SVNS")
+		os.Unsetenv("SCS_MIN_PCE_SVN")
+		os.Remove("testconfig.yml")
+	}()
+
+	s := Update_Service_Config{
+		Flags:         nil,
+		Config:        &c,
+		ConsoleWriter: os.Stdout,
+	}
+	ctx := setup.Context{}
+	err := s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, c.MinTcbCompSvns)
+	assert.Equal(t, 0, c.MinPceSvn)
+
+	os.Setenv("SCS_MIN_TCB_COMP_SVNS", "2, 2,0,1")
+	os.Setenv("SCS_MIN_PCE_SVN", "10")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 2, 0, 1}, c.MinTcbCompSvns)
+	assert.Equal(t, 10, c.MinPceSvn)
+
+	os.Setenv("SCS_MIN_TCB_COMP_SVNS", "2,256")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+
+	os.Setenv("SCS_MIN_TCB_COMP_SVNS", "1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+
+	os.Setenv("SCS_MIN_TCB_COMP_SVNS", "2")
+	os.Setenv("SCS_MIN_PCE_SVN", "-1")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
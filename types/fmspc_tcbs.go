@@ -13,6 +13,7 @@ type FmspcTcbInfo struct {
 	Fmspc              string    `json:"-" gorm:"primary_key"`
 	TcbInfo            string    `json:"-" gorm:"type:text;not null"`
 	TcbInfoIssuerChain string    `json:"-" gorm:"type:text;not null"`
+	TcbUpdate          string    `json:"-" gorm:"type:text"`
 	CreatedTime        time.Time `json:"-"`
 	UpdatedTime        time.Time `json:"-"`
 }
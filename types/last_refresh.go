@@ -11,4 +11,9 @@ import (
 type LastRefresh struct {
 	CompletedAt time.Time `json:"completed-at"`
 	Status      string    `json:"status"`
+	// number of platforms refreshed and skipped, set only by a refresh of out of date platforms
+	RefreshedPlatforms *int `json:"refreshed-platforms,omitempty"`
+	SkippedPlatforms   *int `json:"skipped-platforms,omitempty"`
+	// offset of the first platform not refreshed by an incomplete refresh of pck certs of all platforms
+	PckCertResumeOffset int `json:"-"`
 }
@@ -0,0 +1,19 @@
+/*
+ * Copyright (C) 2020 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package types
+
+// Pagination selects a page of records for RetrievePaginated repository methods.
+// Records are ordered by primary key unless OrderBy names one of the time columns.
+type Pagination struct {
+	Limit      int
+	Offset     int
+	OrderBy    string
+	Descending bool
+}
+
+const (
+	OrderByCreatedTime = "created_time"
+	OrderByUpdatedTime = "updated_time"
+)
@@ -14,12 +14,17 @@ import (
 type PckCert struct {
 	QeID        string         `json:"-" gorm:"primary_key"`
 	PceID       string         `json:"-" gorm:"primary_key"`
+	CPUSvn      string         `json:"-" gorm:"primary_key"`
+	PceSvn      string         `json:"-" gorm:"primary_key"`
 	CertIndex   uint8          `json:"-"`
 	Tcbms       pq.StringArray `json:"-" gorm:"type:text[];not null"`
 	Fmspc       string         `json:"-"`
 	PckCerts    pq.StringArray `json:"-" gorm:"type:text[];not null"`
 	CreatedTime time.Time      `json:"-"`
 	UpdatedTime time.Time      `json:"-"`
+	// NotAfter is the expiry of the pck cert at CertIndex, it is not known for pck certs
+	// cached before the expiry was stored
+	NotAfter time.Time `json:"-"`
 }
 
 type PckCerts []PckCert
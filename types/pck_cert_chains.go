@@ -15,3 +15,5 @@ type PckCertChain struct {
 	CreatedTime  time.Time `json:"-"`
 	UpdatedTime  time.Time `json:"-"`
 }
+
+type PckCertChains []PckCertChain
@@ -13,6 +13,8 @@ type PckCrl struct {
 	Ca              string    `json:"-" gorm:"primary_key"`
 	PckCrlCertChain string    `json:"-" gorm:"index:idx_pckcrlcertchain;type:text;not null;unique"`
 	PckCrl          string    `json:"-" gorm:"type:text;idx_pckcrl;not null;unique"`
+	ThisUpdate      time.Time `json:"-"`
+	NextUpdate      time.Time `json:"-"`
 	CreatedTime     time.Time `json:"-"`
 	UpdatedTime     time.Time `json:"-"`
 }
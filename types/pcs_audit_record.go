@@ -0,0 +1,21 @@
+/*
+ * Copyright (C) 2020 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package types
+
+import (
+	"time"
+)
+
+// PcsAuditRecord struct is the database schema for pcs_audit_records table, which retains
+// raw responses of Intel PCS server for audit. Response holds the base64 encoded response body
+// and RequestKey the fmspc, qeid or ca the response was requested for.
+type PcsAuditRecord struct {
+	ID          uint      `json:"-" gorm:"primary_key"`
+	Endpoint    string    `json:"-" gorm:"not null"`
+	RequestKey  string    `json:"-"`
+	IssuerChain string    `json:"-" gorm:"type:text"`
+	Response    string    `json:"-" gorm:"type:text;not null"`
+	CreatedTime time.Time `json:"-"`
+}
@@ -21,6 +21,15 @@ type Platform struct {
 	Ppid        string    `gorm:"not null"`
 	CreatedTime time.Time `json:"-"`
 	UpdatedTime time.Time `json:"-"`
+
+	// TcbStatus is the tcb status of the platform computed at TcbStatusTime, it is set only
+	// when tcb status caching is enabled
+	TcbStatus     string    `json:"-"`
+	TcbStatusTime time.Time `json:"-"`
+
+	// LastAccessedTime is the time the platform was last served by a read api, it is updated at
+	// most once in PlatformAccessUpdateInterval so that reads do not each write the platform
+	LastAccessedTime time.Time `json:"-"`
 }
 
 type Platforms []Platform
@@ -11,7 +11,7 @@ import (
 // PlatformTcb struct is the database schema for platform_tcbs table
 type PlatformTcb struct {
 	QeID        string    `json:"-" gorm:"primary_key"`
-	PceID       string    `json:"-"`
+	PceID       string    `json:"-" gorm:"primary_key"`
 	CPUSvn      string    `json:"-"`
 	PceSvn      string    `json:"-"`
 	Tcbm        string    `json:"-"`
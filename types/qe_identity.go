@@ -13,10 +13,13 @@ type QEIdentity struct {
 	ID            string    `json:"-" gorm:"primary_key"`
 	QeInfo        string    `json:"-" gorm:"type:text;not null"`
 	QeIssuerChain string    `json:"-" gorm:"type:text;not null"`
+	TcbUpdate     string    `json:"-" gorm:"type:text"`
 	CreatedTime   time.Time `json:"-"`
 	UpdatedTime   time.Time `json:"-"`
 }
 
+type QEIdentities []QEIdentity
+
 type QeIdentityJSON struct {
 	EnclaveIdentity EnclaveIdentityType `json:"enclaveIdentity"`
 	Signature       string              `json:"signature"`
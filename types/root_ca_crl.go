@@ -0,0 +1,20 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package types
+
+import (
+	"time"
+)
+
+// RootCaCrl struct is the database schema for root_ca_crls table, which holds the single CRL
+// issued by SGX Root CA
+type RootCaCrl struct {
+	ID          string    `json:"-" gorm:"primary_key"`
+	RootCaCrl   string    `json:"-" gorm:"type:text;not null"`
+	ThisUpdate  time.Time `json:"-"`
+	NextUpdate  time.Time `json:"-"`
+	CreatedTime time.Time `json:"-"`
+	UpdatedTime time.Time `json:"-"`
+}
@@ -0,0 +1,39 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package types
+
+import (
+	"time"
+)
+
+// TcbLevel struct is the database schema for tcb_levels table, which holds the TCB levels of the
+// cached TcbInfo of an fmspc parsed into columns. Level is the 1 based position of the TCB level in
+// the order raw TCBs of platforms are compared against, highest TCB first.
+type TcbLevel struct {
+	Fmspc           string    `json:"-" gorm:"primary_key"`
+	Level           int       `json:"-" gorm:"primary_key;auto_increment:false"`
+	SgxTcbComp01Svn uint8     `json:"-"`
+	SgxTcbComp02Svn uint8     `json:"-"`
+	SgxTcbComp03Svn uint8     `json:"-"`
+	SgxTcbComp04Svn uint8     `json:"-"`
+	SgxTcbComp05Svn uint8     `json:"-"`
+	SgxTcbComp06Svn uint8     `json:"-"`
+	SgxTcbComp07Svn uint8     `json:"-"`
+	SgxTcbComp08Svn uint8     `json:"-"`
+	SgxTcbComp09Svn uint8     `json:"-"`
+	SgxTcbComp10Svn uint8     `json:"-"`
+	SgxTcbComp11Svn uint8     `json:"-"`
+	SgxTcbComp12Svn uint8     `json:"-"`
+	SgxTcbComp13Svn uint8     `json:"-"`
+	SgxTcbComp14Svn uint8     `json:"-"`
+	SgxTcbComp15Svn uint8     `json:"-"`
+	SgxTcbComp16Svn uint8     `json:"-"`
+	PceSvn          uint16    `json:"-"`
+	TcbStatus       string    `json:"-" gorm:"not null;index"`
+	TcbDate         time.Time `json:"-" gorm:"index"`
+	CreatedTime     time.Time `json:"-"`
+}
+
+type TcbLevels []TcbLevel